@@ -22,7 +22,7 @@ func GetAccountId(t testing.TestingT) string {
 
 // GetAccountIdE gets the Account ID for the currently logged in IAM User.
 func GetAccountIdE(t testing.TestingT) (string, error) {
-	stsClient, err := NewStsClientE(t, defaultRegion)
+	stsClient, err := NewStsClientE(t, getDefaultRegion())
 	if err != nil {
 		return "", err
 	}
@@ -35,8 +35,9 @@ func GetAccountIdE(t testing.TestingT) (string, error) {
 	return aws.ToString(identity.Account), nil
 }
 
-// An IAM arn is of the format arn:aws:iam::123456789012:user/test. The account id is the number after arn:aws:iam::,
-// so we split on a colon and return the 5th item.
+// An IAM arn is of the format arn:aws:iam::123456789012:user/test, or arn:aws-us-gov:iam::... and arn:aws-cn:iam::... in
+// the other partitions. The account id is the number after arn:<partition>:iam::, so we split on a colon and return
+// the 5th item.
 func extractAccountIDFromARN(arn string) (string, error) {
 	arnParts := strings.Split(arn, ":")
 
@@ -0,0 +1,364 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ApiGatewayInvokeOptions are the options of a request to an API Gateway stage.
+type ApiGatewayInvokeOptions struct {
+	Method  string            // The HTTP method. Defaults to GET.
+	Path    string            // The path of the request, relative to the stage URL, e.g., /users/42
+	Body    string            // The body of the request
+	Headers map[string]string // Additional headers of the request
+	ApiKey  string            // If set, sent in the x-api-key header, for methods that require an API key
+	SigV4   bool              // If true, the request is signed with the current credentials, for methods using IAM authorization
+}
+
+// ApiGatewayIntegration is the integration of a REST API method or an HTTP API route.
+type ApiGatewayIntegration struct {
+	Type       string // The type of the integration, e.g., AWS_PROXY, HTTP_PROXY, or MOCK
+	Uri        string // The URI of the integration, e.g., the invocation ARN of a Lambda function or the URL of an HTTP backend
+	HttpMethod string // The HTTP method used to call the backend, if any
+}
+
+// GetApiGatewayStageUrl returns the URL of the given stage of the given REST or HTTP API. The $default stage of HTTP
+// APIs is served at the root of the API.
+func GetApiGatewayStageUrl(apiID string, stage string, region string) string {
+	url := fmt.Sprintf("https://%s.execute-api.%s.%s", apiID, region, GetDnsSuffixForRegion(region))
+	if stage == "" || stage == "$default" {
+		return url
+	}
+	return url + "/" + stage
+}
+
+// InvokeApiGateway sends a request to the given API Gateway stage URL and returns the status code and body of the
+// response. See InvokeApiGatewayE.
+func InvokeApiGateway(t testing.TestingT, region string, stageURL string, options ApiGatewayInvokeOptions) (int, string) {
+	status, body, err := InvokeApiGatewayE(t, region, stageURL, options)
+	require.NoError(t, err)
+	return status, body
+}
+
+// InvokeApiGatewayE sends a request to the given API Gateway stage URL (see GetApiGatewayStageUrl), with an API key
+// or a SigV4 signature if the options say so, and returns the status code and body of the response.
+func InvokeApiGatewayE(t testing.TestingT, region string, stageURL string, options ApiGatewayInvokeOptions) (int, string, error) {
+	method := options.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	url := strings.TrimSuffix(stageURL, "/") + "/" + strings.TrimPrefix(options.Path, "/")
+
+	headers := map[string]string{}
+	for name, value := range options.Headers {
+		headers[name] = value
+	}
+	if options.ApiKey != "" {
+		headers["x-api-key"] = options.ApiKey
+	}
+	if options.SigV4 {
+		sess, err := NewAuthenticatedSession(region)
+		if err != nil {
+			return -1, "", err
+		}
+		credentials, err := sess.Credentials.Retrieve(context.Background())
+		if err != nil {
+			return -1, "", err
+		}
+		if headers, err = signApiGatewayRequest(credentials, region, method, url, options.Body, headers, time.Now()); err != nil {
+			return -1, "", err
+		}
+	}
+
+	return http_helper.HTTPDoWithOptionsE(t, http_helper.HttpDoOptions{
+		Method:  method,
+		Url:     url,
+		Body:    strings.NewReader(options.Body),
+		Headers: headers,
+		Timeout: 30,
+	})
+}
+
+// signApiGatewayRequest returns the given headers of a request to API Gateway with the SigV4 signature headers added.
+func signApiGatewayRequest(credentials aws.Credentials, region string, method string, url string, body string, headers map[string]string, signingTime time.Time) (map[string]string, error) {
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	payloadHash := sha256.Sum256([]byte(body))
+	if err := v4.NewSigner().SignHTTP(context.Background(), credentials, request, hex.EncodeToString(payloadHash[:]), "execute-api", region, signingTime); err != nil {
+		return nil, err
+	}
+
+	signed := map[string]string{}
+	for name := range request.Header {
+		signed[name] = request.Header.Get(name)
+	}
+	return signed, nil
+}
+
+// WaitForApiGatewayResponse sends requests to the given API Gateway stage URL until it responds with the expected
+// status and body. See WaitForApiGatewayResponseE.
+func WaitForApiGatewayResponse(t testing.TestingT, region string, stageURL string, options ApiGatewayInvokeOptions, expectedStatus int, expectedBody string, policy retry.Policy) string {
+	body, err := WaitForApiGatewayResponseE(t, region, stageURL, options, expectedStatus, expectedBody, policy)
+	require.NoError(t, err)
+	return body
+}
+
+// WaitForApiGatewayResponseE sends requests to the given API Gateway stage URL until it responds with the expected
+// status and a body containing the expected text, retrying according to the given policy (DefaultWaitPolicy if nil),
+// and returns the body. This accounts for the time it takes for a new deployment to become effective.
+func WaitForApiGatewayResponseE(t testing.TestingT, region string, stageURL string, options ApiGatewayInvokeOptions, expectedStatus int, expectedBody string, policy retry.Policy) (string, error) {
+	type response struct {
+		status int
+		body   string
+	}
+	result, err := waitForE(t,
+		fmt.Sprintf("Waiting for %s%s to respond with status %d", stageURL, options.Path, expectedStatus),
+		func() (response, error) {
+			status, body, err := InvokeApiGatewayE(t, region, stageURL, options)
+			return response{status: status, body: body}, err
+		},
+		func(result response) bool {
+			return result.status == expectedStatus && strings.Contains(result.body, expectedBody)
+		},
+		policy,
+	)
+	return result.body, err
+}
+
+// WaitForRestApiStageDeployment waits until the given stage of the given REST API serves the given deployment.
+func WaitForRestApiStageDeployment(t testing.TestingT, region string, restApiID string, stage string, deploymentID string, policy retry.Policy) {
+	err := WaitForRestApiStageDeploymentE(t, region, restApiID, stage, deploymentID, policy)
+	require.NoError(t, err)
+}
+
+// WaitForRestApiStageDeploymentE waits until the given stage of the given REST API serves the given deployment,
+// retrying according to the given policy (DefaultWaitPolicy if nil).
+func WaitForRestApiStageDeploymentE(t testing.TestingT, region string, restApiID string, stage string, deploymentID string, policy retry.Policy) error {
+	client, err := NewApiGatewayClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for stage %s of REST API %s to serve deployment %s", stage, restApiID, deploymentID),
+		func() (string, error) {
+			output, err := client.GetStage(context.Background(), &apigateway.GetStageInput{
+				RestApiId: aws.String(restApiID),
+				StageName: aws.String(stage),
+			})
+			if err != nil {
+				return "", err
+			}
+			return aws.ToString(output.DeploymentId), nil
+		},
+		func(current string) bool { return current == deploymentID },
+		policy,
+	)
+	return err
+}
+
+// WaitForHttpApiStageDeployment waits until the given stage of the given HTTP API serves the given deployment.
+func WaitForHttpApiStageDeployment(t testing.TestingT, region string, apiID string, stage string, deploymentID string, policy retry.Policy) {
+	err := WaitForHttpApiStageDeploymentE(t, region, apiID, stage, deploymentID, policy)
+	require.NoError(t, err)
+}
+
+// WaitForHttpApiStageDeploymentE waits until the given stage (e.g., $default) of the given HTTP API serves the given
+// deployment, retrying according to the given policy (DefaultWaitPolicy if nil). For stages with automatic
+// deployment, pass an empty deployment ID to wait until any deployment is served.
+func WaitForHttpApiStageDeploymentE(t testing.TestingT, region string, apiID string, stage string, deploymentID string, policy retry.Policy) error {
+	client, err := NewApiGatewayV2ClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for stage %s of HTTP API %s to serve deployment %s", stage, apiID, deploymentID),
+		func() (string, error) {
+			output, err := client.GetStage(context.Background(), &apigatewayv2.GetStageInput{
+				ApiId:     aws.String(apiID),
+				StageName: aws.String(stage),
+			})
+			if err != nil {
+				return "", err
+			}
+			return aws.ToString(output.DeploymentId), nil
+		},
+		func(current string) bool { return current != "" && (deploymentID == "" || current == deploymentID) },
+		policy,
+	)
+	return err
+}
+
+// GetRestApiIntegration returns the integration of the given method of the given resource of a REST API.
+func GetRestApiIntegration(t testing.TestingT, region string, restApiID string, resourcePath string, httpMethod string) ApiGatewayIntegration {
+	integration, err := GetRestApiIntegrationE(t, region, restApiID, resourcePath, httpMethod)
+	require.NoError(t, err)
+	return integration
+}
+
+// GetRestApiIntegrationE returns the integration of the given method (e.g., GET) of the resource with the given path
+// (e.g., /users/{id}) of a REST API.
+func GetRestApiIntegrationE(t testing.TestingT, region string, restApiID string, resourcePath string, httpMethod string) (ApiGatewayIntegration, error) {
+	client, err := NewApiGatewayClientE(t, region)
+	if err != nil {
+		return ApiGatewayIntegration{}, err
+	}
+
+	var resourceID string
+	paginator := apigateway.NewGetResourcesPaginator(client, &apigateway.GetResourcesInput{RestApiId: aws.String(restApiID)})
+	for paginator.HasMorePages() && resourceID == "" {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return ApiGatewayIntegration{}, err
+		}
+		for _, resource := range page.Items {
+			if aws.ToString(resource.Path) == resourcePath {
+				resourceID = aws.ToString(resource.Id)
+				break
+			}
+		}
+	}
+	if resourceID == "" {
+		return ApiGatewayIntegration{}, NewNotFoundError("REST API resource", restApiID+resourcePath, region)
+	}
+
+	output, err := client.GetIntegration(context.Background(), &apigateway.GetIntegrationInput{
+		RestApiId:  aws.String(restApiID),
+		ResourceId: aws.String(resourceID),
+		HttpMethod: aws.String(httpMethod),
+	})
+	if err != nil {
+		return ApiGatewayIntegration{}, err
+	}
+	return ApiGatewayIntegration{
+		Type:       string(output.Type),
+		Uri:        aws.ToString(output.Uri),
+		HttpMethod: aws.ToString(output.HttpMethod),
+	}, nil
+}
+
+// AssertRestApiIntegration checks that the given method of the given resource of a REST API has an integration of the
+// given type (e.g., AWS_PROXY) and URI.
+func AssertRestApiIntegration(t testing.TestingT, region string, restApiID string, resourcePath string, httpMethod string, expectedType string, expectedUri string) {
+	integration, err := GetRestApiIntegrationE(t, region, restApiID, resourcePath, httpMethod)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedType, integration.Type, "Unexpected integration type of %s %s", httpMethod, resourcePath)
+		assert.Equalf(t, expectedUri, integration.Uri, "Unexpected integration URI of %s %s", httpMethod, resourcePath)
+	}
+}
+
+// GetHttpApiRouteIntegration returns the integration of the given route of an HTTP API.
+func GetHttpApiRouteIntegration(t testing.TestingT, region string, apiID string, routeKey string) ApiGatewayIntegration {
+	integration, err := GetHttpApiRouteIntegrationE(t, region, apiID, routeKey)
+	require.NoError(t, err)
+	return integration
+}
+
+// GetHttpApiRouteIntegrationE returns the integration of the route with the given key (e.g., "GET /users/{id}" or
+// $default) of an HTTP API.
+func GetHttpApiRouteIntegrationE(t testing.TestingT, region string, apiID string, routeKey string) (ApiGatewayIntegration, error) {
+	client, err := NewApiGatewayV2ClientE(t, region)
+	if err != nil {
+		return ApiGatewayIntegration{}, err
+	}
+
+	var target string
+	input := &apigatewayv2.GetRoutesInput{ApiId: aws.String(apiID)}
+	for {
+		output, err := client.GetRoutes(context.Background(), input)
+		if err != nil {
+			return ApiGatewayIntegration{}, err
+		}
+		for _, route := range output.Items {
+			if aws.ToString(route.RouteKey) == routeKey {
+				target = aws.ToString(route.Target)
+			}
+		}
+		if target != "" || output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	if target == "" {
+		return ApiGatewayIntegration{}, NewNotFoundError("HTTP API route with an integration", apiID+" "+routeKey, region)
+	}
+
+	output, err := client.GetIntegration(context.Background(), &apigatewayv2.GetIntegrationInput{
+		ApiId:         aws.String(apiID),
+		IntegrationId: aws.String(strings.TrimPrefix(target, "integrations/")),
+	})
+	if err != nil {
+		return ApiGatewayIntegration{}, err
+	}
+	return ApiGatewayIntegration{
+		Type:       string(output.IntegrationType),
+		Uri:        aws.ToString(output.IntegrationUri),
+		HttpMethod: aws.ToString(output.IntegrationMethod),
+	}, nil
+}
+
+// AssertHttpApiRouteIntegration checks that the given route of an HTTP API has an integration of the given type (e.g.,
+// AWS_PROXY) and URI.
+func AssertHttpApiRouteIntegration(t testing.TestingT, region string, apiID string, routeKey string, expectedType string, expectedUri string) {
+	integration, err := GetHttpApiRouteIntegrationE(t, region, apiID, routeKey)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedType, integration.Type, "Unexpected integration type of route %s", routeKey)
+		assert.Equalf(t, expectedUri, integration.Uri, "Unexpected integration URI of route %s", routeKey)
+	}
+}
+
+// NewApiGatewayClient creates an API Gateway client for REST APIs.
+func NewApiGatewayClient(t testing.TestingT, region string) *apigateway.Client {
+	client, err := NewApiGatewayClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewApiGatewayClientE creates an API Gateway client for REST APIs.
+func NewApiGatewayClientE(t testing.TestingT, region string) (*apigateway.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return apigateway.NewFromConfig(*sess), nil
+}
+
+// NewApiGatewayV2Client creates an API Gateway client for HTTP and WebSocket APIs.
+func NewApiGatewayV2Client(t testing.TestingT, region string) *apigatewayv2.Client {
+	client, err := NewApiGatewayV2ClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewApiGatewayV2ClientE creates an API Gateway client for HTTP and WebSocket APIs.
+func NewApiGatewayV2ClientE(t testing.TestingT, region string) (*apigatewayv2.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return apigatewayv2.NewFromConfig(*sess), nil
+}
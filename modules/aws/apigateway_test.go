@@ -0,0 +1,34 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetApiGatewayStageUrl(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "https://abc123.execute-api.eu-west-1.amazonaws.com/prod", GetApiGatewayStageUrl("abc123", "prod", "eu-west-1"))
+	assert.Equal(t, "https://abc123.execute-api.eu-west-1.amazonaws.com", GetApiGatewayStageUrl("abc123", "$default", "eu-west-1"))
+}
+
+func TestSignApiGatewayRequest(t *testing.T) {
+	t.Parallel()
+
+	credentials := aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}
+	signingTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	headers, err := signApiGatewayRequest(credentials, "us-east-1", "POST", "https://abc123.execute-api.us-east-1.amazonaws.com/prod/users", `{"name":"terratest"}`, map[string]string{"Content-Type": "application/json"}, signingTime)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/json", headers["Content-Type"])
+	assert.Equal(t, "20240102T030405Z", headers["X-Amz-Date"])
+	assert.Equal(t, "token", headers["X-Amz-Security-Token"])
+	assert.True(t, strings.HasPrefix(headers["Authorization"], "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240102/us-east-1/execute-api/aws4_request"), headers["Authorization"])
+	assert.Contains(t, headers["Authorization"], "content-type")
+}
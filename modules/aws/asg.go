@@ -3,10 +3,13 @@ package aws
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
@@ -105,24 +108,285 @@ func WaitForCapacityE(
 	maxRetries int,
 	sleepBetweenRetries time.Duration,
 ) error {
-	msg, err := retry.DoWithRetryE(
+	capacityInfo, err := waitForE(
 		t,
 		fmt.Sprintf("Waiting for ASG %s to reach desired capacity.", asgName),
-		maxRetries,
-		sleepBetweenRetries,
-		func() (string, error) {
-			capacityInfo, err := GetCapacityInfoForAsgE(t, asgName, region)
+		func() (AsgCapacityInfo, error) { return GetCapacityInfoForAsgE(t, asgName, region) },
+		func(capacityInfo AsgCapacityInfo) bool {
+			return capacityInfo.CurrentCapacity == capacityInfo.DesiredCapacity
+		},
+		fixedWaitPolicy(maxRetries, sleepBetweenRetries),
+	)
+	if err != nil {
+		return err
+	}
+	logger.Default.Logf(t, "ASG %s is now at desired capacity %d", asgName, capacityInfo.DesiredCapacity)
+	return nil
+}
+
+// AsgScalingActivity is a scaling activity of an ASG, e.g., the launch or termination of an instance.
+type AsgScalingActivity struct {
+	Description   string
+	Cause         string
+	StatusCode    string // e.g., Successful, Failed, or InProgress
+	StatusMessage string // The reason for a failure, e.g., an unavailable instance type or a launch template error
+	StartTime     time.Time
+}
+
+// GetAsg returns the details of the given ASG.
+func GetAsg(t testing.TestingT, asgName string, awsRegion string) *types.AutoScalingGroup {
+	group, err := GetAsgE(t, asgName, awsRegion)
+	require.NoError(t, err)
+	return group
+}
+
+// GetAsgE returns the details of the given ASG.
+func GetAsgE(t testing.TestingT, asgName string, awsRegion string) (*types.AutoScalingGroup, error) {
+	asgClient, err := NewAsgClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	input := autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []string{asgName}}
+	output, err := asgClient.DescribeAutoScalingGroups(context.Background(), &input)
+	if err != nil {
+		return nil, err
+	}
+	if len(output.AutoScalingGroups) == 0 {
+		return nil, NewNotFoundError("ASG", asgName, awsRegion)
+	}
+	return &output.AutoScalingGroups[0], nil
+}
+
+// WaitForInServiceCapacity waits until as many instances of the ASG as its desired capacity are in service and
+// healthy. See WaitForInServiceCapacityE.
+func WaitForInServiceCapacity(t testing.TestingT, asgName string, awsRegion string, policy retry.Policy) {
+	err := WaitForInServiceCapacityE(t, asgName, awsRegion, policy)
+	require.NoError(t, err)
+}
+
+// WaitForInServiceCapacityE waits until as many instances of the ASG as its desired capacity are in service and
+// healthy, retrying according to the given policy (DefaultWaitPolicy if nil). Unlike WaitForCapacityE, instances that
+// are still pending, or are being terminated, don't count. If the capacity is never reached, the error includes the
+// failed scaling activities of the ASG, which usually explain why.
+func WaitForInServiceCapacityE(t testing.TestingT, asgName string, awsRegion string, policy retry.Policy) error {
+	group, err := waitForE(t,
+		fmt.Sprintf("Waiting for ASG %s to have its desired capacity in service", asgName),
+		func() (*types.AutoScalingGroup, error) { return GetAsgE(t, asgName, awsRegion) },
+		func(group *types.AutoScalingGroup) bool {
+			return countInServiceInstances(group) == int(aws.ToInt32(group.DesiredCapacity))
+		},
+		policy,
+	)
+	if err != nil {
+		activities, activitiesErr := GetAsgScalingActivitiesE(t, asgName, awsRegion, 10)
+		if activitiesErr == nil {
+			if failures := failedScalingActivityMessages(activities); len(failures) > 0 {
+				return fmt.Errorf("%w; failed scaling activities: %s", err, strings.Join(failures, "; "))
+			}
+		}
+		return err
+	}
+
+	logger.Default.Logf(t, "ASG %s now has its desired capacity %d in service", asgName, aws.ToInt32(group.DesiredCapacity))
+	return nil
+}
+
+// countInServiceInstances returns the number of healthy instances of the given ASG that are in service.
+func countInServiceInstances(group *types.AutoScalingGroup) int {
+	count := 0
+	for _, instance := range group.Instances {
+		if instance.LifecycleState == types.LifecycleStateInService && aws.ToString(instance.HealthStatus) == "Healthy" {
+			count++
+		}
+	}
+	return count
+}
+
+// StartInstanceRefresh starts an instance refresh of the ASG and returns its ID.
+func StartInstanceRefresh(t testing.TestingT, asgName string, awsRegion string, minHealthyPercentage int32) string {
+	refreshID, err := StartInstanceRefreshE(t, asgName, awsRegion, minHealthyPercentage)
+	require.NoError(t, err)
+	return refreshID
+}
+
+// StartInstanceRefreshE starts a rolling instance refresh of the ASG, which replaces its instances, e.g., to roll out
+// a new launch template version, keeping at least the given percentage of the desired capacity healthy, and returns
+// its ID.
+func StartInstanceRefreshE(t testing.TestingT, asgName string, awsRegion string, minHealthyPercentage int32) (string, error) {
+	asgClient, err := NewAsgClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Default.Logf(t, "Starting an instance refresh of ASG %s", asgName)
+	output, err := asgClient.StartInstanceRefresh(context.Background(), &autoscaling.StartInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(asgName),
+		Strategy:             types.RefreshStrategyRolling,
+		Preferences:          &types.RefreshPreferences{MinHealthyPercentage: aws.Int32(minHealthyPercentage)},
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.InstanceRefreshId), nil
+}
+
+// WaitForInstanceRefresh waits until the given instance refresh of the ASG succeeds. See WaitForInstanceRefreshE.
+func WaitForInstanceRefresh(t testing.TestingT, asgName string, awsRegion string, refreshID string, policy retry.Policy) *types.InstanceRefresh {
+	refresh, err := WaitForInstanceRefreshE(t, asgName, awsRegion, refreshID, policy)
+	require.NoError(t, err)
+	return refresh
+}
+
+// WaitForInstanceRefreshE waits until the given instance refresh of the ASG succeeds, retrying according to the given
+// policy (DefaultWaitPolicy if nil), and returns it. It stops waiting as soon as the refresh fails, is cancelled, or is
+// rolled back, and returns an error with the reason.
+func WaitForInstanceRefreshE(t testing.TestingT, asgName string, awsRegion string, refreshID string, policy retry.Policy) (*types.InstanceRefresh, error) {
+	asgClient, err := NewAsgClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	return waitForE(t,
+		fmt.Sprintf("Waiting for instance refresh %s of ASG %s to complete", refreshID, asgName),
+		func() (*types.InstanceRefresh, error) {
+			output, err := asgClient.DescribeInstanceRefreshes(context.Background(), &autoscaling.DescribeInstanceRefreshesInput{
+				AutoScalingGroupName: aws.String(asgName),
+				InstanceRefreshIds:   []string{refreshID},
+			})
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			if capacityInfo.CurrentCapacity != capacityInfo.DesiredCapacity {
-				return "", NewAsgCapacityNotMetError(asgName, capacityInfo.DesiredCapacity, capacityInfo.CurrentCapacity)
+			if len(output.InstanceRefreshes) == 0 {
+				return nil, retry.FatalError{Underlying: NewNotFoundError("ASG instance refresh", refreshID, awsRegion)}
 			}
-			return fmt.Sprintf("ASG %s is now at desired capacity %d", asgName, capacityInfo.DesiredCapacity), nil
+			refresh := output.InstanceRefreshes[0]
+			if isInstanceRefreshUnsuccessful(refresh.Status) {
+				return nil, retry.FatalError{Underlying: fmt.Errorf("instance refresh %s of ASG %s ended with status %s: %s", refreshID, asgName, refresh.Status, aws.ToString(refresh.StatusReason))}
+			}
+			return &refresh, nil
+		},
+		func(refresh *types.InstanceRefresh) bool {
+			return refresh.Status == types.InstanceRefreshStatusSuccessful
 		},
+		policy,
 	)
-	logger.Default.Logf(t, "%s", msg)
-	return err
+}
+
+// isInstanceRefreshUnsuccessful returns true if an instance refresh with the given status ended without succeeding.
+func isInstanceRefreshUnsuccessful(status types.InstanceRefreshStatus) bool {
+	switch status {
+	case types.InstanceRefreshStatusFailed, types.InstanceRefreshStatusCancelled, types.InstanceRefreshStatusRollbackSuccessful, types.InstanceRefreshStatusRollbackFailed:
+		return true
+	}
+	return false
+}
+
+// AssertAsgLaunchTemplate checks that the ASG launches instances with the given launch template and version (e.g.,
+// $Latest, $Default, or a version number), whether directly or through a mixed instances policy.
+func AssertAsgLaunchTemplate(t testing.TestingT, asgName string, awsRegion string, expectedName string, expectedVersion string) {
+	group, err := GetAsgE(t, asgName, awsRegion)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	launchTemplate := asgLaunchTemplate(group)
+	if !assert.NotNilf(t, launchTemplate, "ASG %s has no launch template", asgName) {
+		return
+	}
+	assert.Equalf(t, expectedName, aws.ToString(launchTemplate.LaunchTemplateName), "Unexpected launch template of ASG %s", asgName)
+	assert.Equalf(t, expectedVersion, aws.ToString(launchTemplate.Version), "Unexpected launch template version of ASG %s", asgName)
+}
+
+// asgLaunchTemplate returns the launch template of the given ASG, if any.
+func asgLaunchTemplate(group *types.AutoScalingGroup) *types.LaunchTemplateSpecification {
+	if group.LaunchTemplate != nil {
+		return group.LaunchTemplate
+	}
+	if group.MixedInstancesPolicy != nil && group.MixedInstancesPolicy.LaunchTemplate != nil {
+		return group.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+	}
+	return nil
+}
+
+// AssertAsgMixedInstancesPolicy checks that the ASG has a mixed instances policy with the given instance types, in
+// order of priority, and the given percentage of on-demand instances above the on-demand base capacity.
+func AssertAsgMixedInstancesPolicy(t testing.TestingT, asgName string, awsRegion string, expectedInstanceTypes []string, expectedOnDemandPercentageAboveBase int32) {
+	group, err := GetAsgE(t, asgName, awsRegion)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	policy := group.MixedInstancesPolicy
+	if !assert.NotNilf(t, policy, "ASG %s has no mixed instances policy", asgName) {
+		return
+	}
+	assert.Equalf(t, expectedInstanceTypes, mixedInstancesPolicyInstanceTypes(policy), "Unexpected instance types of ASG %s", asgName)
+	if assert.NotNilf(t, policy.InstancesDistribution, "ASG %s has no instances distribution", asgName) {
+		assert.Equalf(t, expectedOnDemandPercentageAboveBase, aws.ToInt32(policy.InstancesDistribution.OnDemandPercentageAboveBaseCapacity), "Unexpected on-demand percentage of ASG %s", asgName)
+	}
+}
+
+// mixedInstancesPolicyInstanceTypes returns the instance types of the overrides of the given mixed instances policy.
+func mixedInstancesPolicyInstanceTypes(policy *types.MixedInstancesPolicy) []string {
+	instanceTypes := []string{}
+	if policy.LaunchTemplate == nil {
+		return instanceTypes
+	}
+	for _, override := range policy.LaunchTemplate.Overrides {
+		if override.InstanceType != nil {
+			instanceTypes = append(instanceTypes, aws.ToString(override.InstanceType))
+		}
+	}
+	return instanceTypes
+}
+
+// GetAsgScalingActivities returns the most recent scaling activities of the ASG, most recent first.
+func GetAsgScalingActivities(t testing.TestingT, asgName string, awsRegion string, maxActivities int32) []AsgScalingActivity {
+	activities, err := GetAsgScalingActivitiesE(t, asgName, awsRegion, maxActivities)
+	require.NoError(t, err)
+	return activities
+}
+
+// GetAsgScalingActivitiesE returns up to maxActivities (at most 100) of the most recent scaling activities of the
+// ASG, most recent first, which help diagnose why instances fail to launch.
+func GetAsgScalingActivitiesE(t testing.TestingT, asgName string, awsRegion string, maxActivities int32) ([]AsgScalingActivity, error) {
+	asgClient, err := NewAsgClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := asgClient.DescribeScalingActivities(context.Background(), &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String(asgName),
+		MaxRecords:           aws.Int32(maxActivities),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	activities := []AsgScalingActivity{}
+	for _, activity := range output.Activities {
+		activities = append(activities, AsgScalingActivity{
+			Description:   aws.ToString(activity.Description),
+			Cause:         aws.ToString(activity.Cause),
+			StatusCode:    string(activity.StatusCode),
+			StatusMessage: aws.ToString(activity.StatusMessage),
+			StartTime:     aws.ToTime(activity.StartTime),
+		})
+	}
+	return activities, nil
+}
+
+// failedScalingActivityMessages returns the descriptions and status messages of the failed ones of the given
+// activities.
+func failedScalingActivityMessages(activities []AsgScalingActivity) []string {
+	messages := []string{}
+	for _, activity := range activities {
+		if activity.StatusCode == string(types.ScalingActivityStatusCodeFailed) {
+			messages = append(messages, fmt.Sprintf("%s: %s", activity.Description, activity.StatusMessage))
+		}
+	}
+	return messages
 }
 
 // NewAsgClient creates an Auto Scaling Group client.
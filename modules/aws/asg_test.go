@@ -188,3 +188,62 @@ func scaleAsgToZero(t *testing.T, name string, region string) {
 	// scaling activity so we add a 5-second pause here to work around it.
 	time.Sleep(5 * time.Second)
 }
+
+func TestCountInServiceInstances(t *testing.T) {
+	t.Parallel()
+
+	group := &autoscalingTypes.AutoScalingGroup{
+		Instances: []autoscalingTypes.Instance{
+			{LifecycleState: autoscalingTypes.LifecycleStateInService, HealthStatus: aws.String("Healthy")},
+			{LifecycleState: autoscalingTypes.LifecycleStateInService, HealthStatus: aws.String("Unhealthy")},
+			{LifecycleState: autoscalingTypes.LifecycleStatePending, HealthStatus: aws.String("Healthy")},
+			{LifecycleState: autoscalingTypes.LifecycleStateTerminating, HealthStatus: aws.String("Healthy")},
+		},
+	}
+	assert.Equal(t, 1, countInServiceInstances(group))
+}
+
+func TestIsInstanceRefreshUnsuccessful(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, isInstanceRefreshUnsuccessful(autoscalingTypes.InstanceRefreshStatusInProgress))
+	assert.False(t, isInstanceRefreshUnsuccessful(autoscalingTypes.InstanceRefreshStatusSuccessful))
+	assert.True(t, isInstanceRefreshUnsuccessful(autoscalingTypes.InstanceRefreshStatusFailed))
+	assert.True(t, isInstanceRefreshUnsuccessful(autoscalingTypes.InstanceRefreshStatusRollbackSuccessful))
+}
+
+func TestAsgLaunchTemplate(t *testing.T) {
+	t.Parallel()
+
+	direct := &autoscalingTypes.AutoScalingGroup{
+		LaunchTemplate: &autoscalingTypes.LaunchTemplateSpecification{LaunchTemplateName: aws.String("direct"), Version: aws.String("$Latest")},
+	}
+	assert.Equal(t, "direct", aws.ToString(asgLaunchTemplate(direct).LaunchTemplateName))
+
+	mixed := &autoscalingTypes.AutoScalingGroup{
+		MixedInstancesPolicy: &autoscalingTypes.MixedInstancesPolicy{
+			LaunchTemplate: &autoscalingTypes.LaunchTemplate{
+				LaunchTemplateSpecification: &autoscalingTypes.LaunchTemplateSpecification{LaunchTemplateName: aws.String("mixed"), Version: aws.String("3")},
+				Overrides: []autoscalingTypes.LaunchTemplateOverrides{
+					{InstanceType: aws.String("t3.micro")},
+					{InstanceRequirements: &autoscalingTypes.InstanceRequirements{}},
+					{InstanceType: aws.String("t3a.micro")},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "mixed", aws.ToString(asgLaunchTemplate(mixed).LaunchTemplateName))
+	assert.Equal(t, []string{"t3.micro", "t3a.micro"}, mixedInstancesPolicyInstanceTypes(mixed.MixedInstancesPolicy))
+
+	assert.Nil(t, asgLaunchTemplate(&autoscalingTypes.AutoScalingGroup{}))
+}
+
+func TestFailedScalingActivityMessages(t *testing.T) {
+	t.Parallel()
+
+	messages := failedScalingActivityMessages([]AsgScalingActivity{
+		{Description: "Launching a new EC2 instance", StatusCode: "Successful"},
+		{Description: "Launching a new EC2 instance. Status Reason: ...", StatusCode: "Failed", StatusMessage: "We currently do not have sufficient t3.micro capacity"},
+	})
+	assert.Equal(t, []string{"Launching a new EC2 instance. Status Reason: ...: We currently do not have sufficient t3.micro capacity"}, messages)
+}
@@ -17,52 +17,133 @@ import (
 )
 
 const (
-	AuthAssumeRoleEnvVar = "TERRATEST_IAM_ROLE" // OS environment variable name through which Assume Role ARN may be passed for authentication
+	AuthAssumeRoleEnvVar            = "TERRATEST_IAM_ROLE"              // OS environment variable name through which Assume Role ARN may be passed for authentication
+	AuthAssumeRoleExternalIDEnvVar  = "TERRATEST_IAM_ROLE_EXTERNAL_ID"  // OS environment variable name through which the external ID to assume the role with may be passed
+	AuthAssumeRoleSessionNameEnvVar = "TERRATEST_IAM_ROLE_SESSION_NAME" // OS environment variable name through which the session name to assume the role with may be passed
+	AuthAssumeRoleDurationEnvVar    = "TERRATEST_IAM_ROLE_DURATION"     // OS environment variable name through which the duration of the role sessions (e.g., 1h) may be passed
+	AuthProfileEnvVar               = "TERRATEST_AWS_PROFILE"           // OS environment variable name through which the shared config profile (e.g., an IAM Identity Center profile) may be passed
+	AuthEndpointURLEnvVar           = "TERRATEST_AWS_ENDPOINT_URL"      // OS environment variable name through which a custom endpoint for all AWS clients (e.g., LocalStack) may be passed
 )
 
+// DummyAccessKeyID and DummySecretAccessKey are the credentials used with AuthOptions.UseDummyCredentials. Emulators
+// such as LocalStack and moto accept any credentials.
+const (
+	DummyAccessKeyID     = "test"
+	DummySecretAccessKey = "test"
+)
+
+// AuthOptions are the options to authenticate to AWS with.
+type AuthOptions struct {
+	// The shared config profile to load credentials from (e.g., an AWS IAM Identity Center (SSO) profile, which must
+	// have been logged in to with `aws sso login`). SSO tokens are refreshed automatically. Defaults to the standard
+	// credential chain (e.g., AWS_PROFILE or the AWS_ACCESS_KEY_ID environment variables).
+	Profile string
+
+	// The ARN of an IAM role to assume with the credentials of the profile. The role credentials are refreshed
+	// automatically before they expire, so they can be used for tests that run longer than the role session duration.
+	RoleArn string
+
+	// The external ID to assume RoleArn with, if the trust policy of the role requires one.
+	ExternalID string
+
+	// The session name to assume RoleArn with, which shows up in CloudTrail. Defaults to a name generated by the SDK.
+	SessionName string
+
+	// The duration of the role sessions. Defaults to 15 minutes.
+	Duration time.Duration
+
+	// A custom endpoint to send the requests of all services to, e.g., http://localhost:4566 for LocalStack. S3 clients
+	// use path-style addressing with a custom endpoint.
+	EndpointURL string
+
+	// Use DummyAccessKeyID and DummySecretAccessKey instead of looking up credentials, e.g., for an emulator with
+	// EndpointURL. RoleArn and Profile are ignored in this case.
+	UseDummyCredentials bool
+}
+
 // NewAuthenticatedSession creates an AWS Config following to standard AWS authentication workflow.
-// If AuthAssumeIamRoleEnvVar environment variable is set, assumes IAM role specified in it.
+// If AuthAssumeIamRoleEnvVar environment variable is set, assumes IAM role specified in it, with the external ID,
+// session name, and duration in the AuthAssumeRole*EnvVar environment variables, if set. If AuthProfileEnvVar is set,
+// the credentials are loaded from that profile. If AuthEndpointURLEnvVar is set, all requests are sent to that
+// endpoint with dummy credentials, which makes every client in this package work against LocalStack or moto.
 func NewAuthenticatedSession(region string) (*aws.Config, error) {
-	if assumeRoleArn, ok := os.LookupEnv(AuthAssumeRoleEnvVar); ok {
-		return NewAuthenticatedSessionFromRole(region, assumeRoleArn)
-	} else {
-		return NewAuthenticatedSessionFromDefaultCredentials(region)
+	authOptions, err := authOptionsFromEnv()
+	if err != nil {
+		return nil, err
 	}
+	return NewAuthenticatedSessionWithOptions(region, authOptions)
 }
 
-// NewAuthenticatedSessionFromDefaultCredentials gets an AWS Config, checking that the user has credentials properly configured in their environment.
-func NewAuthenticatedSessionFromDefaultCredentials(region string) (*aws.Config, error) {
-	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+// NewAuthenticatedSessionWithOptions creates an AWS Config authenticated according to the given options. If a role is
+// assumed, this checks that it can be assumed right away, rather than failing on the first API call.
+func NewAuthenticatedSessionWithOptions(region string, authOptions AuthOptions) (*aws.Config, error) {
+	loadOptions := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if authOptions.UseDummyCredentials {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(DummyAccessKeyID, DummySecretAccessKey, "")))
+	} else if authOptions.Profile != "" {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(authOptions.Profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), loadOptions...)
 	if err != nil {
 		return nil, CredentialsError{UnderlyingErr: err}
 	}
+	if authOptions.EndpointURL != "" {
+		cfg.BaseEndpoint = aws.String(authOptions.EndpointURL)
+	}
+	if authOptions.RoleArn == "" || authOptions.UseDummyCredentials {
+		return &cfg, nil
+	}
+
+	roleProvider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), authOptions.RoleArn, func(options *stscreds.AssumeRoleOptions) {
+		if authOptions.ExternalID != "" {
+			options.ExternalID = aws.String(authOptions.ExternalID)
+		}
+		if authOptions.SessionName != "" {
+			options.RoleSessionName = authOptions.SessionName
+		}
+		if authOptions.Duration > 0 {
+			options.Duration = authOptions.Duration
+		}
+	})
+	cfg.Credentials = aws.NewCredentialsCache(roleProvider)
+	if _, err := cfg.Credentials.Retrieve(context.Background()); err != nil {
+		return nil, CredentialsError{UnderlyingErr: err}
+	}
 
 	return &cfg, nil
 }
 
+// authOptionsFromEnv returns the AuthOptions set in the Auth*EnvVar environment variables.
+func authOptionsFromEnv() (AuthOptions, error) {
+	authOptions := AuthOptions{
+		Profile:     os.Getenv(AuthProfileEnvVar),
+		RoleArn:     os.Getenv(AuthAssumeRoleEnvVar),
+		ExternalID:  os.Getenv(AuthAssumeRoleExternalIDEnvVar),
+		SessionName: os.Getenv(AuthAssumeRoleSessionNameEnvVar),
+		EndpointURL: os.Getenv(AuthEndpointURLEnvVar),
+	}
+	authOptions.UseDummyCredentials = authOptions.EndpointURL != ""
+	if duration := os.Getenv(AuthAssumeRoleDurationEnvVar); duration != "" {
+		parsed, err := time.ParseDuration(duration)
+		if err != nil {
+			return authOptions, fmt.Errorf("invalid duration in %s: %w", AuthAssumeRoleDurationEnvVar, err)
+		}
+		authOptions.Duration = parsed
+	}
+	return authOptions, nil
+}
+
+// NewAuthenticatedSessionFromDefaultCredentials gets an AWS Config, checking that the user has credentials properly configured in their environment.
+func NewAuthenticatedSessionFromDefaultCredentials(region string) (*aws.Config, error) {
+	return NewAuthenticatedSessionWithOptions(region, AuthOptions{})
+}
+
 // NewAuthenticatedSessionFromRole returns a new AWS Config after assuming the
 // role whose ARN is provided in roleARN. If the credentials are not properly
 // configured in the underlying environment, an error is returned.
 func NewAuthenticatedSessionFromRole(region string, roleARN string) (*aws.Config, error) {
-	cfg, err := NewAuthenticatedSessionFromDefaultCredentials(region)
-	if err != nil {
-		return nil, err
-	}
-
-	client := sts.NewFromConfig(*cfg)
-
-	roleProvider := stscreds.NewAssumeRoleProvider(client, roleARN)
-	retrieve, err := roleProvider.Retrieve(context.Background())
-	if err != nil {
-		return nil, CredentialsError{UnderlyingErr: err}
-	}
-
-	return &aws.Config{
-		Region: region,
-		Credentials: aws.NewCredentialsCache(credentials.StaticCredentialsProvider{
-			Value: retrieve,
-		}),
-	}, nil
+	return NewAuthenticatedSessionWithOptions(region, AuthOptions{RoleArn: roleARN})
 }
 
 // CreateAwsSessionWithCreds creates a new AWS Config using explicit credentials. This is useful if you want to create an IAM User dynamically and
@@ -0,0 +1,61 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Not parallel, as it sets environment variables
+func TestAuthOptionsFromEnv(t *testing.T) {
+	t.Setenv(AuthProfileEnvVar, "sso-dev")
+	t.Setenv(AuthAssumeRoleEnvVar, "arn:aws:iam::123456789012:role/terratest")
+	t.Setenv(AuthAssumeRoleExternalIDEnvVar, "external-id")
+	t.Setenv(AuthAssumeRoleSessionNameEnvVar, "ci-job-42")
+	t.Setenv(AuthAssumeRoleDurationEnvVar, "2h")
+	t.Setenv(AuthEndpointURLEnvVar, "")
+
+	authOptions, err := authOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, AuthOptions{
+		Profile:     "sso-dev",
+		RoleArn:     "arn:aws:iam::123456789012:role/terratest",
+		ExternalID:  "external-id",
+		SessionName: "ci-job-42",
+		Duration:    2 * time.Hour,
+	}, authOptions)
+
+	t.Setenv(AuthEndpointURLEnvVar, "http://localhost:4566")
+	authOptions, err = authOptionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:4566", authOptions.EndpointURL)
+	assert.True(t, authOptions.UseDummyCredentials)
+
+	t.Setenv(AuthAssumeRoleDurationEnvVar, "two hours")
+	_, err = authOptionsFromEnv()
+	assert.Error(t, err)
+}
+
+func TestNewAuthenticatedSessionWithCustomEndpoint(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := NewAuthenticatedSessionWithOptions("us-east-1", AuthOptions{
+		EndpointURL:         "http://localhost:4566",
+		UseDummyCredentials: true,
+		RoleArn:             "arn:aws:iam::123456789012:role/ignored",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, cfg.BaseEndpoint)
+	assert.Equal(t, "http://localhost:4566", *cfg.BaseEndpoint)
+
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, DummyAccessKeyID, creds.AccessKeyID)
+	assert.Equal(t, DummySecretAccessKey, creds.SecretAccessKey)
+
+	assert.True(t, s3.NewFromConfig(*cfg, s3PathStyleForCustomEndpoint(cfg)).Options().UsePathStyle)
+}
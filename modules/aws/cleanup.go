@@ -0,0 +1,328 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// CleanupResourceType is a type of AWS resource that can be registered for cleanup.
+type CleanupResourceType string
+
+const (
+	CleanupEc2Instance       CleanupResourceType = "ec2:instance"            // ID is the instance ID
+	CleanupElasticIP         CleanupResourceType = "ec2:elastic-ip"          // ID is the allocation ID
+	CleanupEc2KeyPair        CleanupResourceType = "ec2:key-pair"            // ID is the key pair name or ID (key-...)
+	CleanupEbsVolume         CleanupResourceType = "ec2:volume"              // ID is the volume ID
+	CleanupEbsSnapshot       CleanupResourceType = "ec2:snapshot"            // ID is the snapshot ID
+	CleanupSecurityGroup     CleanupResourceType = "ec2:security-group"      // ID is the security group ID
+	CleanupSecurityGroupRule CleanupResourceType = "ec2:security-group-rule" // ID is <security group ID>/<rule ID>
+	CleanupLogGroup          CleanupResourceType = "logs:log-group"          // ID is the log group name
+	CleanupS3Bucket          CleanupResourceType = "s3:bucket"               // ID is the bucket name. The bucket is emptied first.
+	CleanupSnsTopic          CleanupResourceType = "sns:topic"               // ID is the topic ARN
+	CleanupSqsQueue          CleanupResourceType = "sqs:queue"               // ID is the queue URL
+)
+
+// cleanupOrder is the order resources are deleted in: instances first, as most other resources can't be deleted while
+// an instance uses them, and security groups last, as they may be referenced by other resources. Types that are not
+// listed are deleted in between.
+var cleanupOrder = map[CleanupResourceType]int{
+	CleanupEc2Instance:   0,
+	CleanupSecurityGroup: 2,
+}
+
+const defaultCleanupOrder = 1
+
+// CleanupResource is an AWS resource registered for cleanup.
+type CleanupResource struct {
+	Type   CleanupResourceType
+	Region string
+	ID     string
+}
+
+func (resource CleanupResource) String() string {
+	return fmt.Sprintf("%s %s in %s", resource.Type, resource.ID, resource.Region)
+}
+
+// cleanupRegistry holds the resources registered for cleanup by each test, keyed by test name.
+var cleanupRegistry = struct {
+	sync.Mutex
+	resources map[string][]CleanupResource
+}{resources: map[string][]CleanupResource{}}
+
+// RegisterForCleanup registers the given resource to be deleted by CleanupRegisteredResources for the given test.
+func RegisterForCleanup(t testing.TestingT, resourceType CleanupResourceType, region string, id string) {
+	cleanupRegistry.Lock()
+	defer cleanupRegistry.Unlock()
+
+	resource := CleanupResource{Type: resourceType, Region: region, ID: id}
+	logger.Default.Logf(t, "Registering %s for cleanup", resource)
+	cleanupRegistry.resources[t.Name()] = append(cleanupRegistry.resources[t.Name()], resource)
+}
+
+// GetRegisteredResources returns the resources registered for cleanup for the given test.
+func GetRegisteredResources(t testing.TestingT) []CleanupResource {
+	cleanupRegistry.Lock()
+	defer cleanupRegistry.Unlock()
+
+	return append([]CleanupResource{}, cleanupRegistry.resources[t.Name()]...)
+}
+
+// RegisterTaggedEc2ResourcesForCleanup finds the EC2 instances, Elastic IPs, key pairs, EBS volumes and snapshots, and
+// security groups in the given region that have the given tag (e.g., a tag with the unique ID of the test), and
+// registers them for cleanup. This is useful to clean up resources created by Terraform, even if the apply failed
+// half-way.
+func RegisterTaggedEc2ResourcesForCleanup(t testing.TestingT, region string, tagKey string, tagValue string) []CleanupResource {
+	resources, err := RegisterTaggedEc2ResourcesForCleanupE(t, region, tagKey, tagValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resources
+}
+
+// RegisterTaggedEc2ResourcesForCleanupE finds the EC2 instances, Elastic IPs, key pairs, EBS volumes and snapshots,
+// and security groups in the given region that have the given tag (e.g., a tag with the unique ID of the test), and
+// registers them for cleanup.
+func RegisterTaggedEc2ResourcesForCleanupE(t testing.TestingT, region string, tagKey string, tagValue string) ([]CleanupResource, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	filters := []types.Filter{{Name: aws.String("tag:" + tagKey), Values: []string{tagValue}}}
+	found := []CleanupResource{}
+
+	instances := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{Filters: filters})
+	for instances.HasMorePages() {
+		page, err := instances.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.State != nil && instance.State.Name == types.InstanceStateNameTerminated {
+					continue
+				}
+				found = append(found, CleanupResource{Type: CleanupEc2Instance, Region: region, ID: aws.ToString(instance.InstanceId)})
+			}
+		}
+	}
+
+	addresses, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses.Addresses {
+		found = append(found, CleanupResource{Type: CleanupElasticIP, Region: region, ID: aws.ToString(address.AllocationId)})
+	}
+
+	keyPairs, err := client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+	for _, keyPair := range keyPairs.KeyPairs {
+		found = append(found, CleanupResource{Type: CleanupEc2KeyPair, Region: region, ID: aws.ToString(keyPair.KeyPairId)})
+	}
+
+	volumes := ec2.NewDescribeVolumesPaginator(client, &ec2.DescribeVolumesInput{Filters: filters})
+	for volumes.HasMorePages() {
+		page, err := volumes.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range page.Volumes {
+			found = append(found, CleanupResource{Type: CleanupEbsVolume, Region: region, ID: aws.ToString(volume.VolumeId)})
+		}
+	}
+
+	snapshots := ec2.NewDescribeSnapshotsPaginator(client, &ec2.DescribeSnapshotsInput{Filters: filters, OwnerIds: []string{"self"}})
+	for snapshots.HasMorePages() {
+		page, err := snapshots.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, snapshot := range page.Snapshots {
+			found = append(found, CleanupResource{Type: CleanupEbsSnapshot, Region: region, ID: aws.ToString(snapshot.SnapshotId)})
+		}
+	}
+
+	securityGroups := ec2.NewDescribeSecurityGroupsPaginator(client, &ec2.DescribeSecurityGroupsInput{Filters: filters})
+	for securityGroups.HasMorePages() {
+		page, err := securityGroups.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, securityGroup := range page.SecurityGroups {
+			found = append(found, CleanupResource{Type: CleanupSecurityGroup, Region: region, ID: aws.ToString(securityGroup.GroupId)})
+		}
+	}
+
+	for _, resource := range found {
+		RegisterForCleanup(t, resource.Type, resource.Region, resource.ID)
+	}
+	return found, nil
+}
+
+// CleanupRegisteredResources deletes the resources registered for cleanup for the given test, in dependency order.
+// Resources that no longer exist are skipped. Failures are reported with t.Errorf rather than by stopping the test,
+// so that this can be deferred at the start of a test and still runs, and lets the panic through, if the test panics:
+//
+//	defer aws.CleanupRegisteredResources(t)
+func CleanupRegisteredResources(t testing.TestingT) {
+	if err := CleanupRegisteredResourcesE(t); err != nil {
+		t.Errorf("Failed to clean up registered AWS resources: %v", err)
+	}
+}
+
+// CleanupRegisteredResourcesE deletes the resources registered for cleanup for the given test, in dependency order.
+// Resources that no longer exist are skipped. The resources that fail to be deleted stay registered, and their errors
+// are returned together.
+func CleanupRegisteredResourcesE(t testing.TestingT) error {
+	cleanupRegistry.Lock()
+	resources := cleanupRegistry.resources[t.Name()]
+	delete(cleanupRegistry.resources, t.Name())
+	cleanupRegistry.Unlock()
+
+	var errs []error
+	var failed []CleanupResource
+	for _, resource := range orderForCleanup(resources) {
+		if err := deleteCleanupResourceE(t, resource); err != nil && !isNotFoundError(err) {
+			errs = append(errs, fmt.Errorf("%s: %w", resource, err))
+			failed = append(failed, resource)
+		}
+	}
+
+	if len(failed) > 0 {
+		cleanupRegistry.Lock()
+		cleanupRegistry.resources[t.Name()] = append(cleanupRegistry.resources[t.Name()], failed...)
+		cleanupRegistry.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// orderForCleanup returns the given resources in the order they should be deleted in. Within each step of the cleanup
+// order, the most recently registered resources come first, as they are the most likely to depend on the others.
+func orderForCleanup(resources []CleanupResource) []CleanupResource {
+	ordered := make([]CleanupResource, len(resources))
+	for i, resource := range resources {
+		ordered[len(resources)-1-i] = resource
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return cleanupOrderOf(ordered[i].Type) < cleanupOrderOf(ordered[j].Type)
+	})
+	return ordered
+}
+
+// cleanupOrderOf returns the step of the cleanup order that resources of the given type are deleted in.
+func cleanupOrderOf(resourceType CleanupResourceType) int {
+	if order, ok := cleanupOrder[resourceType]; ok {
+		return order
+	}
+	return defaultCleanupOrder
+}
+
+// deleteCleanupResourceE deletes the given resource.
+func deleteCleanupResourceE(t testing.TestingT, resource CleanupResource) error {
+	logger.Default.Logf(t, "Cleaning up %s", resource)
+	ctx := context.Background()
+
+	switch resource.Type {
+	case CleanupEc2Instance:
+		if err := TerminateInstanceE(t, resource.Region, resource.ID); err != nil {
+			return err
+		}
+		// Wait for the instance to be gone, as its volumes, Elastic IPs, and security groups can't be deleted before
+		client, err := NewEc2ClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		return ec2.NewInstanceTerminatedWaiter(client).Wait(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{resource.ID}}, 10*time.Minute)
+	case CleanupElasticIP:
+		client, err := NewEc2ClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		_, err = client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(resource.ID)})
+		return err
+	case CleanupEc2KeyPair:
+		client, err := NewEc2ClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		input := &ec2.DeleteKeyPairInput{KeyName: aws.String(resource.ID)}
+		if strings.HasPrefix(resource.ID, "key-") {
+			input = &ec2.DeleteKeyPairInput{KeyPairId: aws.String(resource.ID)}
+		}
+		_, err = client.DeleteKeyPair(ctx, input)
+		return err
+	case CleanupEbsVolume:
+		client, err := NewEc2ClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		_, err = client.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: aws.String(resource.ID)})
+		return err
+	case CleanupEbsSnapshot:
+		return DeleteEbsSnapshotE(t, resource.Region, resource.ID)
+	case CleanupSecurityGroup:
+		client, err := NewEc2ClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		_, err = client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(resource.ID)})
+		return err
+	case CleanupSecurityGroupRule:
+		client, err := NewEc2ClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		groupID, ruleID, _ := strings.Cut(resource.ID, "/")
+		_, err = client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:              aws.String(groupID),
+			SecurityGroupRuleIds: []string{ruleID},
+		})
+		return err
+	case CleanupLogGroup:
+		client, err := NewCloudWatchLogsClientE(t, resource.Region)
+		if err != nil {
+			return err
+		}
+		_, err = client.DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String(resource.ID)})
+		return err
+	case CleanupS3Bucket:
+		if err := EmptyS3BucketE(t, resource.Region, resource.ID); err != nil {
+			return err
+		}
+		return DeleteS3BucketE(t, resource.Region, resource.ID)
+	case CleanupSnsTopic:
+		return DeleteSNSTopicE(t, resource.Region, resource.ID)
+	case CleanupSqsQueue:
+		return DeleteQueueE(t, resource.Region, resource.ID)
+	default:
+		return UnsupportedCleanupResourceType(resource.Type)
+	}
+}
+
+// isNotFoundError returns true if the given error means that the resource doesn't exist (anymore), e.g., because
+// terraform destroy already deleted it.
+func isNotFoundError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	code := apiErr.ErrorCode()
+	return strings.Contains(code, "NotFound") || strings.HasPrefix(code, "NoSuch") || strings.Contains(code, "NonExistent")
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderForCleanup(t *testing.T) {
+	t.Parallel()
+
+	resources := []CleanupResource{
+		{Type: CleanupSecurityGroup, Region: "us-east-1", ID: "sg-1"},
+		{Type: CleanupEc2KeyPair, Region: "us-east-1", ID: "key-1"},
+		{Type: CleanupEc2Instance, Region: "us-east-1", ID: "i-1"},
+		{Type: CleanupElasticIP, Region: "us-east-1", ID: "eipalloc-1"},
+		{Type: CleanupEc2Instance, Region: "us-east-1", ID: "i-2"},
+	}
+
+	ids := []string{}
+	for _, resource := range orderForCleanup(resources) {
+		ids = append(ids, resource.ID)
+	}
+	assert.Equal(t, []string{"i-2", "i-1", "eipalloc-1", "key-1", "sg-1"}, ids)
+}
+
+func TestCleanupRegisteredResourcesKeepsFailures(t *testing.T) {
+	t.Parallel()
+
+	RegisterForCleanup(t, CleanupResourceType("foo:bar"), "us-east-1", "baz")
+	assert.Equal(t, []CleanupResource{{Type: "foo:bar", Region: "us-east-1", ID: "baz"}}, GetRegisteredResources(t))
+
+	err := CleanupRegisteredResourcesE(t)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, UnsupportedCleanupResourceType("foo:bar"))
+	assert.Len(t, GetRegisteredResources(t), 1)
+}
+
+func TestIsNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		code     string
+		expected bool
+	}{
+		{"InvalidInstanceID.NotFound", true},
+		{"InvalidKeyPair.NotFound", true},
+		{"ResourceNotFoundException", true},
+		{"NoSuchBucket", true},
+		{"AWS.SimpleQueueService.NonExistentQueue", true},
+		{"DependencyViolation", false},
+	}
+
+	for _, testCase := range testCases {
+		err := fmt.Errorf("wrapped: %w", &smithy.GenericAPIError{Code: testCase.code})
+		assert.Equal(t, testCase.expected, isNotFoundError(err), testCase.code)
+	}
+	assert.False(t, isNotFoundError(errors.New("NotFound")))
+}
@@ -0,0 +1,306 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// CloudFrontOrigin is an origin of a CloudFront distribution.
+type CloudFrontOrigin struct {
+	Id                    string // The ID of the origin, which cache behaviors refer to
+	DomainName            string // The domain name of the origin, e.g., that of an S3 bucket or a load balancer
+	OriginPath            string // The path that CloudFront appends to the requests to the origin
+	OriginAccessControlId string // The ID of the origin access control of an S3 origin, if any
+}
+
+// CloudFrontCacheBehavior is a cache behavior of a CloudFront distribution.
+type CloudFrontCacheBehavior struct {
+	PathPattern          string   // The path pattern of the behavior, or * for the default behavior
+	IsDefault            bool     // If the behavior is the default one, which applies to the requests no other behavior matches
+	TargetOriginId       string   // The ID of the origin the behavior routes requests to
+	ViewerProtocolPolicy string   // allow-all, https-only, or redirect-to-https
+	CachePolicyId        string   // The ID of the cache policy of the behavior, if any
+	AllowedMethods       []string // The HTTP methods the behavior allows
+}
+
+// GetCloudFrontDistribution returns the details of the given CloudFront distribution.
+func GetCloudFrontDistribution(t testing.TestingT, distributionID string) *types.Distribution {
+	distribution, err := GetCloudFrontDistributionE(t, distributionID)
+	require.NoError(t, err)
+	return distribution
+}
+
+// GetCloudFrontDistributionE returns the details of the given CloudFront distribution.
+func GetCloudFrontDistributionE(t testing.TestingT, distributionID string) (*types.Distribution, error) {
+	client, err := NewCloudFrontClientE(t, getDefaultRegion())
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.GetDistribution(context.Background(), &cloudfront.GetDistributionInput{Id: aws.String(distributionID)})
+	if err != nil {
+		return nil, err
+	}
+	return output.Distribution, nil
+}
+
+// WaitForCloudFrontDistributionDeployed waits until the changes to the given CloudFront distribution are deployed to
+// all edge locations. See WaitForCloudFrontDistributionDeployedE.
+func WaitForCloudFrontDistributionDeployed(t testing.TestingT, distributionID string, policy retry.Policy) *types.Distribution {
+	distribution, err := WaitForCloudFrontDistributionDeployedE(t, distributionID, policy)
+	require.NoError(t, err)
+	return distribution
+}
+
+// WaitForCloudFrontDistributionDeployedE waits until the changes to the given CloudFront distribution are deployed to
+// all edge locations, retrying according to the given policy (DefaultWaitPolicy if nil). As this usually takes several
+// minutes, the policy should allow for 15 minutes or more.
+func WaitForCloudFrontDistributionDeployedE(t testing.TestingT, distributionID string, policy retry.Policy) (*types.Distribution, error) {
+	return waitForE(t,
+		fmt.Sprintf("Waiting for CloudFront distribution %s to be deployed", distributionID),
+		func() (*types.Distribution, error) { return GetCloudFrontDistributionE(t, distributionID) },
+		func(distribution *types.Distribution) bool { return aws.ToString(distribution.Status) == "Deployed" },
+		policy,
+	)
+}
+
+// CreateCloudFrontInvalidation creates an invalidation of the given paths of the given CloudFront distribution and
+// returns its ID.
+func CreateCloudFrontInvalidation(t testing.TestingT, distributionID string, paths []string) string {
+	invalidationID, err := CreateCloudFrontInvalidationE(t, distributionID, paths)
+	require.NoError(t, err)
+	return invalidationID
+}
+
+// CreateCloudFrontInvalidationE creates an invalidation of the given paths (e.g., /index.html or /*) of the given
+// CloudFront distribution, so that the edge locations fetch them from the origin again, and returns its ID.
+func CreateCloudFrontInvalidationE(t testing.TestingT, distributionID string, paths []string) (string, error) {
+	client, err := NewCloudFrontClientE(t, getDefaultRegion())
+	if err != nil {
+		return "", err
+	}
+
+	logger.Default.Logf(t, "Invalidating %v in CloudFront distribution %s", paths, distributionID)
+	output, err := client.CreateInvalidation(context.Background(), &cloudfront.CreateInvalidationInput{
+		DistributionId: aws.String(distributionID),
+		InvalidationBatch: &types.InvalidationBatch{
+			CallerReference: aws.String(fmt.Sprintf("terratest-%d", time.Now().UnixNano())),
+			Paths:           &types.Paths{Items: paths, Quantity: aws.Int32(int32(len(paths)))},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.Invalidation.Id), nil
+}
+
+// WaitForCloudFrontInvalidation waits until the given invalidation of the given CloudFront distribution is completed.
+func WaitForCloudFrontInvalidation(t testing.TestingT, distributionID string, invalidationID string, policy retry.Policy) {
+	err := WaitForCloudFrontInvalidationE(t, distributionID, invalidationID, policy)
+	require.NoError(t, err)
+}
+
+// WaitForCloudFrontInvalidationE waits until the given invalidation of the given CloudFront distribution is completed,
+// retrying according to the given policy (DefaultWaitPolicy if nil).
+func WaitForCloudFrontInvalidationE(t testing.TestingT, distributionID string, invalidationID string, policy retry.Policy) error {
+	client, err := NewCloudFrontClientE(t, getDefaultRegion())
+	if err != nil {
+		return err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for invalidation %s of CloudFront distribution %s to complete", invalidationID, distributionID),
+		func() (*types.Invalidation, error) {
+			output, err := client.GetInvalidation(context.Background(), &cloudfront.GetInvalidationInput{
+				DistributionId: aws.String(distributionID),
+				Id:             aws.String(invalidationID),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.Invalidation, nil
+		},
+		func(invalidation *types.Invalidation) bool { return aws.ToString(invalidation.Status) == "Completed" },
+		policy,
+	)
+	return err
+}
+
+// InvalidateCloudFrontPaths invalidates the given paths of the given CloudFront distribution and waits for the
+// invalidation to complete.
+func InvalidateCloudFrontPaths(t testing.TestingT, distributionID string, paths []string, policy retry.Policy) {
+	err := InvalidateCloudFrontPathsE(t, distributionID, paths, policy)
+	require.NoError(t, err)
+}
+
+// InvalidateCloudFrontPathsE invalidates the given paths of the given CloudFront distribution and waits, according to
+// the given policy (DefaultWaitPolicy if nil), for the invalidation to complete.
+func InvalidateCloudFrontPathsE(t testing.TestingT, distributionID string, paths []string, policy retry.Policy) error {
+	invalidationID, err := CreateCloudFrontInvalidationE(t, distributionID, paths)
+	if err != nil {
+		return err
+	}
+	return WaitForCloudFrontInvalidationE(t, distributionID, invalidationID, policy)
+}
+
+// GetCloudFrontOrigins returns the origins of the given CloudFront distribution.
+func GetCloudFrontOrigins(t testing.TestingT, distributionID string) []CloudFrontOrigin {
+	origins, err := GetCloudFrontOriginsE(t, distributionID)
+	require.NoError(t, err)
+	return origins
+}
+
+// GetCloudFrontOriginsE returns the origins of the given CloudFront distribution.
+func GetCloudFrontOriginsE(t testing.TestingT, distributionID string) ([]CloudFrontOrigin, error) {
+	distribution, err := GetCloudFrontDistributionE(t, distributionID)
+	if err != nil {
+		return nil, err
+	}
+	return cloudFrontOrigins(distribution.DistributionConfig), nil
+}
+
+// cloudFrontOrigins returns the origins of the given distribution configuration.
+func cloudFrontOrigins(config *types.DistributionConfig) []CloudFrontOrigin {
+	origins := []CloudFrontOrigin{}
+	if config == nil || config.Origins == nil {
+		return origins
+	}
+	for _, origin := range config.Origins.Items {
+		origins = append(origins, CloudFrontOrigin{
+			Id:                    aws.ToString(origin.Id),
+			DomainName:            aws.ToString(origin.DomainName),
+			OriginPath:            aws.ToString(origin.OriginPath),
+			OriginAccessControlId: aws.ToString(origin.OriginAccessControlId),
+		})
+	}
+	return origins
+}
+
+// GetCloudFrontCacheBehaviors returns the cache behaviors of the given CloudFront distribution.
+func GetCloudFrontCacheBehaviors(t testing.TestingT, distributionID string) []CloudFrontCacheBehavior {
+	behaviors, err := GetCloudFrontCacheBehaviorsE(t, distributionID)
+	require.NoError(t, err)
+	return behaviors
+}
+
+// GetCloudFrontCacheBehaviorsE returns the cache behaviors of the given CloudFront distribution, in order of
+// precedence, the default behavior last.
+func GetCloudFrontCacheBehaviorsE(t testing.TestingT, distributionID string) ([]CloudFrontCacheBehavior, error) {
+	distribution, err := GetCloudFrontDistributionE(t, distributionID)
+	if err != nil {
+		return nil, err
+	}
+	return cloudFrontCacheBehaviors(distribution.DistributionConfig), nil
+}
+
+// cloudFrontCacheBehaviors returns the cache behaviors of the given distribution configuration, the default behavior
+// last.
+func cloudFrontCacheBehaviors(config *types.DistributionConfig) []CloudFrontCacheBehavior {
+	behaviors := []CloudFrontCacheBehavior{}
+	if config == nil {
+		return behaviors
+	}
+	if config.CacheBehaviors != nil {
+		for _, behavior := range config.CacheBehaviors.Items {
+			behaviors = append(behaviors, CloudFrontCacheBehavior{
+				PathPattern:          aws.ToString(behavior.PathPattern),
+				TargetOriginId:       aws.ToString(behavior.TargetOriginId),
+				ViewerProtocolPolicy: string(behavior.ViewerProtocolPolicy),
+				CachePolicyId:        aws.ToString(behavior.CachePolicyId),
+				AllowedMethods:       cloudFrontAllowedMethods(behavior.AllowedMethods),
+			})
+		}
+	}
+	if behavior := config.DefaultCacheBehavior; behavior != nil {
+		behaviors = append(behaviors, CloudFrontCacheBehavior{
+			PathPattern:          "*",
+			IsDefault:            true,
+			TargetOriginId:       aws.ToString(behavior.TargetOriginId),
+			ViewerProtocolPolicy: string(behavior.ViewerProtocolPolicy),
+			CachePolicyId:        aws.ToString(behavior.CachePolicyId),
+			AllowedMethods:       cloudFrontAllowedMethods(behavior.AllowedMethods),
+		})
+	}
+	return behaviors
+}
+
+// cloudFrontAllowedMethods returns the given allowed methods as strings.
+func cloudFrontAllowedMethods(allowedMethods *types.AllowedMethods) []string {
+	methods := []string{}
+	if allowedMethods == nil {
+		return methods
+	}
+	for _, method := range allowedMethods.Items {
+		methods = append(methods, string(method))
+	}
+	return methods
+}
+
+// WaitForCloudFrontContent sends GET requests to the given path of the given CloudFront distribution until it serves
+// the expected content. See WaitForCloudFrontContentE.
+func WaitForCloudFrontContent(t testing.TestingT, distributionID string, path string, expectedStatus int, expectedBody string, policy retry.Policy) string {
+	body, err := WaitForCloudFrontContentE(t, distributionID, path, expectedStatus, expectedBody, policy)
+	require.NoError(t, err)
+	return body
+}
+
+// WaitForCloudFrontContentE sends GET requests to the given path (e.g., /index.html) of the domain name of the given
+// CloudFront distribution, over HTTPS, until it responds with the expected status and a body containing the expected
+// text, retrying according to the given policy (DefaultWaitPolicy if nil), and returns the body. This accounts for
+// the time it takes for a new or updated distribution, or an invalidation, to propagate to the edge locations.
+func WaitForCloudFrontContentE(t testing.TestingT, distributionID string, path string, expectedStatus int, expectedBody string, policy retry.Policy) (string, error) {
+	distribution, err := GetCloudFrontDistributionE(t, distributionID)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := fmt.Sprintf("https://%s%s", aws.ToString(distribution.DomainName), path)
+	type response struct {
+		status int
+		body   string
+	}
+	result, err := waitForE(t,
+		fmt.Sprintf("Waiting for %s to respond with status %d", url, expectedStatus),
+		func() (response, error) {
+			status, body, err := http_helper.HttpGetE(t, url, nil)
+			return response{status: status, body: body}, err
+		},
+		func(result response) bool {
+			return result.status == expectedStatus && strings.Contains(result.body, expectedBody)
+		},
+		policy,
+	)
+	return result.body, err
+}
+
+// NewCloudFrontClient creates a CloudFront client.
+func NewCloudFrontClient(t testing.TestingT, region string) *cloudfront.Client {
+	client, err := NewCloudFrontClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewCloudFrontClientE creates a CloudFront client. As CloudFront is a global service, the region only determines the
+// endpoint used for the API calls.
+func NewCloudFrontClientE(t testing.TestingT, region string) (*cloudfront.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloudfront.NewFromConfig(*sess), nil
+}
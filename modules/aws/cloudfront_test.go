@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFrontOriginsAndCacheBehaviors(t *testing.T) {
+	t.Parallel()
+
+	config := &types.DistributionConfig{
+		Origins: &types.Origins{Items: []types.Origin{
+			{Id: aws.String("s3"), DomainName: aws.String("bucket.s3.us-east-1.amazonaws.com"), OriginAccessControlId: aws.String("E2QWRUHAPOMQZL")},
+			{Id: aws.String("api"), DomainName: aws.String("api-lb.us-east-1.elb.amazonaws.com"), OriginPath: aws.String("/v1")},
+		}},
+		CacheBehaviors: &types.CacheBehaviors{Items: []types.CacheBehavior{
+			{
+				PathPattern:          aws.String("/api/*"),
+				TargetOriginId:       aws.String("api"),
+				ViewerProtocolPolicy: types.ViewerProtocolPolicyHttpsOnly,
+				AllowedMethods:       &types.AllowedMethods{Items: []types.Method{types.MethodGet, types.MethodHead, types.MethodPost}},
+			},
+		}},
+		DefaultCacheBehavior: &types.DefaultCacheBehavior{
+			TargetOriginId:       aws.String("s3"),
+			ViewerProtocolPolicy: types.ViewerProtocolPolicyRedirectToHttps,
+			CachePolicyId:        aws.String("658327ea-f89d-4fab-a63d-7e88639e58f6"),
+		},
+	}
+
+	assert.Equal(t, []CloudFrontOrigin{
+		{Id: "s3", DomainName: "bucket.s3.us-east-1.amazonaws.com", OriginAccessControlId: "E2QWRUHAPOMQZL"},
+		{Id: "api", DomainName: "api-lb.us-east-1.elb.amazonaws.com", OriginPath: "/v1"},
+	}, cloudFrontOrigins(config))
+
+	assert.Equal(t, []CloudFrontCacheBehavior{
+		{
+			PathPattern:          "/api/*",
+			TargetOriginId:       "api",
+			ViewerProtocolPolicy: "https-only",
+			AllowedMethods:       []string{"GET", "HEAD", "POST"},
+		},
+		{
+			PathPattern:          "*",
+			IsDefault:            true,
+			TargetOriginId:       "s3",
+			ViewerProtocolPolicy: "redirect-to-https",
+			CachePolicyId:        "658327ea-f89d-4fab-a63d-7e88639e58f6",
+			AllowedMethods:       []string{},
+		},
+	}, cloudFrontCacheBehaviors(config))
+
+	assert.Empty(t, cloudFrontOrigins(nil))
+	assert.Empty(t, cloudFrontCacheBehaviors(nil))
+}
@@ -0,0 +1,203 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// cloudTrailLookupInterval is the time between two CloudTrail lookups, as LookupEvents is limited to 2 requests per
+	// second per account and events take minutes to be available.
+	cloudTrailLookupInterval = 30 * time.Second
+
+	// cloudTrailMaxPagesPerLookup is the maximum number of pages of events (of up to 50 events each) read by a single
+	// lookup, so that a lookup attribute matching a lot of events doesn't page through up to 90 days of history on
+	// every poll. Events are returned most recent first, so the events of the test are on the first pages.
+	cloudTrailMaxPagesPerLookup = 10
+)
+
+// CloudTrailEvent is a management event recorded by CloudTrail.
+type CloudTrailEvent struct {
+	EventId     string    // The ID of the event
+	EventName   string    // The name of the API call, e.g., CreateBucket
+	EventSource string    // The service of the API call, e.g., s3.amazonaws.com
+	EventTime   time.Time // The time of the API call
+	Username    string    // The user name or role session name of the caller
+	Resources   []string  // The names of the resources of the event
+	RawEvent    string    // The full event, as JSON
+}
+
+// WaitForCloudTrailEvent waits for a management event matching the given lookup attributes to be recorded by
+// CloudTrail. See WaitForCloudTrailEventE.
+func WaitForCloudTrailEvent(t testing.TestingT, region string, lookupAttributes map[string]string, timeout time.Duration) CloudTrailEvent {
+	event, err := WaitForCloudTrailEventE(t, region, lookupAttributes, timeout)
+	require.NoError(t, err)
+	return event
+}
+
+// WaitForCloudTrailEventE polls CloudTrail for up to the given timeout until a management event matching all the given
+// lookup attributes is recorded, and returns the most recent one. The keys of the attributes are the CloudTrail lookup
+// attribute keys: EventId, EventName, EventSource, Username, ResourceName, ResourceType, ReadOnly, or AccessKeyId.
+// Events of the past 90 days match, so include an attribute unique to the test, such as the name of a resource it
+// created, or use WaitForCloudTrailEventSinceE. CloudTrail typically delivers events within 5 minutes, but can take up
+// to 15.
+func WaitForCloudTrailEventE(t testing.TestingT, region string, lookupAttributes map[string]string, timeout time.Duration) (CloudTrailEvent, error) {
+	return WaitForCloudTrailEventSinceE(t, region, lookupAttributes, time.Time{}, timeout)
+}
+
+// WaitForCloudTrailEventSince waits for a management event matching the given lookup attributes, that happened at or
+// after the given time, to be recorded by CloudTrail. See WaitForCloudTrailEventSinceE.
+func WaitForCloudTrailEventSince(t testing.TestingT, region string, lookupAttributes map[string]string, since time.Time, timeout time.Duration) CloudTrailEvent {
+	event, err := WaitForCloudTrailEventSinceE(t, region, lookupAttributes, since, timeout)
+	require.NoError(t, err)
+	return event
+}
+
+// WaitForCloudTrailEventSinceE is like WaitForCloudTrailEventE, but only matches events that happened at or after the
+// given time, e.g., the start of the test.
+func WaitForCloudTrailEventSinceE(t testing.TestingT, region string, lookupAttributes map[string]string, since time.Time, timeout time.Duration) (CloudTrailEvent, error) {
+	if len(lookupAttributes) == 0 {
+		return CloudTrailEvent{}, fmt.Errorf("at least one lookup attribute is required to look up CloudTrail events")
+	}
+	client, err := NewCloudTrailClientE(t, region)
+	if err != nil {
+		return CloudTrailEvent{}, err
+	}
+
+	// LookupEvents accepts a single lookup attribute, so the others are matched on the returned events
+	input := &cloudtrail.LookupEventsInput{LookupAttributes: []types.LookupAttribute{cloudTrailLookupAttribute(lookupAttributes)}}
+	if !since.IsZero() {
+		input.StartTime = aws.Time(since)
+	}
+
+	return waitForE(t,
+		fmt.Sprintf("Waiting for a CloudTrail event matching %v", lookupAttributes),
+		func() (CloudTrailEvent, error) {
+			return findCloudTrailEventE(client, input, lookupAttributes, since)
+		},
+		func(event CloudTrailEvent) bool { return event.EventId != "" },
+		fixedWaitPolicy(cloudTrailLookupRetries(timeout), cloudTrailLookupInterval),
+	)
+}
+
+// findCloudTrailEventE returns the most recent event returned by the given lookup that matches all the given lookup
+// attributes and happened at or after since, or an empty event if there is none. It reads at most
+// cloudTrailMaxPagesPerLookup pages, and stops as soon as it reaches events older than since.
+func findCloudTrailEventE(client cloudtrail.LookupEventsAPIClient, input *cloudtrail.LookupEventsInput, lookupAttributes map[string]string, since time.Time) (CloudTrailEvent, error) {
+	paginator := cloudtrail.NewLookupEventsPaginator(client, input)
+	for pages := 0; pages < cloudTrailMaxPagesPerLookup && paginator.HasMorePages(); pages++ {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return CloudTrailEvent{}, err
+		}
+		// Events are returned most recent first
+		for _, event := range page.Events {
+			if aws.ToTime(event.EventTime).Before(since) {
+				return CloudTrailEvent{}, nil
+			}
+			if cloudTrailEventMatches(event, lookupAttributes) {
+				return newCloudTrailEvent(event), nil
+			}
+		}
+	}
+	return CloudTrailEvent{}, nil
+}
+
+// cloudTrailLookupRetries returns the number of lookups to make, one every cloudTrailLookupInterval, to cover the given
+// timeout. This is rounded up, and there is always at least one lookup.
+func cloudTrailLookupRetries(timeout time.Duration) int {
+	retries := int((timeout + cloudTrailLookupInterval - 1) / cloudTrailLookupInterval)
+	if retries < 1 {
+		return 1
+	}
+	return retries
+}
+
+// cloudTrailLookupAttribute returns the lookup attribute to send to the API, chosen deterministically among the given
+// ones.
+func cloudTrailLookupAttribute(lookupAttributes map[string]string) types.LookupAttribute {
+	keys := make([]string, 0, len(lookupAttributes))
+	for key := range lookupAttributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return types.LookupAttribute{
+		AttributeKey:   types.LookupAttributeKey(keys[0]),
+		AttributeValue: aws.String(lookupAttributes[keys[0]]),
+	}
+}
+
+// cloudTrailEventMatches returns true if the given event matches all the given lookup attributes.
+func cloudTrailEventMatches(event types.Event, lookupAttributes map[string]string) bool {
+	for key, value := range lookupAttributes {
+		var matches bool
+		switch types.LookupAttributeKey(key) {
+		case types.LookupAttributeKeyEventId:
+			matches = aws.ToString(event.EventId) == value
+		case types.LookupAttributeKeyEventName:
+			matches = aws.ToString(event.EventName) == value
+		case types.LookupAttributeKeyEventSource:
+			matches = aws.ToString(event.EventSource) == value
+		case types.LookupAttributeKeyUsername:
+			matches = aws.ToString(event.Username) == value
+		case types.LookupAttributeKeyReadOnly:
+			matches = aws.ToString(event.ReadOnly) == value
+		case types.LookupAttributeKeyAccessKeyId:
+			matches = aws.ToString(event.AccessKeyId) == value
+		case types.LookupAttributeKeyResourceName:
+			for _, resource := range event.Resources {
+				matches = matches || aws.ToString(resource.ResourceName) == value
+			}
+		case types.LookupAttributeKeyResourceType:
+			for _, resource := range event.Resources {
+				matches = matches || aws.ToString(resource.ResourceType) == value
+			}
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+// newCloudTrailEvent converts the given event.
+func newCloudTrailEvent(event types.Event) CloudTrailEvent {
+	resources := []string{}
+	for _, resource := range event.Resources {
+		resources = append(resources, aws.ToString(resource.ResourceName))
+	}
+	return CloudTrailEvent{
+		EventId:     aws.ToString(event.EventId),
+		EventName:   aws.ToString(event.EventName),
+		EventSource: aws.ToString(event.EventSource),
+		EventTime:   aws.ToTime(event.EventTime),
+		Username:    aws.ToString(event.Username),
+		Resources:   resources,
+		RawEvent:    aws.ToString(event.CloudTrailEvent),
+	}
+}
+
+// NewCloudTrailClient creates a CloudTrail client.
+func NewCloudTrailClient(t testing.TestingT, region string) *cloudtrail.Client {
+	client, err := NewCloudTrailClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewCloudTrailClientE creates a CloudTrail client.
+func NewCloudTrailClientE(t testing.TestingT, region string) (*cloudtrail.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloudtrail.NewFromConfig(*sess), nil
+}
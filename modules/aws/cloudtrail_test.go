@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudTrailLookupAttribute(t *testing.T) {
+	t.Parallel()
+
+	attribute := cloudTrailLookupAttribute(map[string]string{"ResourceName": "my-bucket", "EventName": "PutBucketPolicy"})
+	assert.Equal(t, types.LookupAttributeKeyEventName, attribute.AttributeKey)
+	assert.Equal(t, "PutBucketPolicy", aws.ToString(attribute.AttributeValue))
+}
+
+func TestCloudTrailEventMatches(t *testing.T) {
+	t.Parallel()
+
+	event := types.Event{
+		EventId:     aws.String("0a1b2c3d-0000-0000-0000-000000000000"),
+		EventName:   aws.String("PutBucketPolicy"),
+		EventSource: aws.String("s3.amazonaws.com"),
+		Username:    aws.String("terratest"),
+		Resources:   []types.Resource{{ResourceName: aws.String("my-bucket"), ResourceType: aws.String("AWS::S3::Bucket")}},
+	}
+
+	assert.True(t, cloudTrailEventMatches(event, map[string]string{"EventName": "PutBucketPolicy", "ResourceName": "my-bucket"}))
+	assert.True(t, cloudTrailEventMatches(event, map[string]string{"ResourceType": "AWS::S3::Bucket", "Username": "terratest"}))
+	assert.False(t, cloudTrailEventMatches(event, map[string]string{"EventName": "PutBucketPolicy", "ResourceName": "other-bucket"}))
+	assert.False(t, cloudTrailEventMatches(event, map[string]string{"EventSource": "ec2.amazonaws.com"}))
+	assert.False(t, cloudTrailEventMatches(event, map[string]string{"Unknown": "value"}))
+}
+
+// fakeLookupEventsClient returns the given pages of events, in order, and records how many were requested.
+type fakeLookupEventsClient struct {
+	pages    [][]types.Event
+	requests int
+}
+
+func (client *fakeLookupEventsClient) LookupEvents(_ context.Context, input *cloudtrail.LookupEventsInput, _ ...func(*cloudtrail.Options)) (*cloudtrail.LookupEventsOutput, error) {
+	client.requests++
+	index := 0
+	if input.NextToken != nil {
+		index, _ = strconv.Atoi(aws.ToString(input.NextToken))
+	}
+	output := &cloudtrail.LookupEventsOutput{Events: client.pages[index]}
+	if index+1 < len(client.pages) {
+		output.NextToken = aws.String(strconv.Itoa(index + 1))
+	}
+	return output, nil
+}
+
+func newFakeCloudTrailEvent(id int, name string, eventTime time.Time) types.Event {
+	return types.Event{EventId: aws.String(fmt.Sprint(id)), EventName: aws.String(name), EventTime: aws.Time(eventTime)}
+}
+
+func TestFindCloudTrailEventCapsPages(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	client := &fakeLookupEventsClient{}
+	for i := 0; i < 3*cloudTrailMaxPagesPerLookup; i++ {
+		client.pages = append(client.pages, []types.Event{newFakeCloudTrailEvent(i, "GetObject", now.Add(-time.Duration(i)*time.Minute))})
+	}
+
+	event, err := findCloudTrailEventE(client, &cloudtrail.LookupEventsInput{}, map[string]string{"EventName": "PutObject"}, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, event.EventId)
+	assert.Equal(t, cloudTrailMaxPagesPerLookup, client.requests)
+}
+
+func TestFindCloudTrailEventStopsAtSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	client := &fakeLookupEventsClient{pages: [][]types.Event{
+		{newFakeCloudTrailEvent(1, "GetObject", now), newFakeCloudTrailEvent(2, "GetObject", now.Add(-time.Hour))},
+		{newFakeCloudTrailEvent(3, "PutObject", now.Add(-2*time.Hour))},
+	}}
+
+	event, err := findCloudTrailEventE(client, &cloudtrail.LookupEventsInput{}, map[string]string{"EventName": "PutObject"}, now.Add(-10*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, event.EventId)
+	assert.Equal(t, 1, client.requests)
+}
+
+func TestFindCloudTrailEventReturnsMostRecentMatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	client := &fakeLookupEventsClient{pages: [][]types.Event{
+		{newFakeCloudTrailEvent(1, "GetObject", now)},
+		{newFakeCloudTrailEvent(2, "PutObject", now.Add(-time.Minute)), newFakeCloudTrailEvent(3, "PutObject", now.Add(-2*time.Minute))},
+	}}
+
+	event, err := findCloudTrailEventE(client, &cloudtrail.LookupEventsInput{}, map[string]string{"EventName": "PutObject"}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "2", event.EventId)
+	assert.Equal(t, 2, client.requests)
+}
+
+func TestCloudTrailLookupRetries(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 1, cloudTrailLookupRetries(0))
+	assert.Equal(t, 1, cloudTrailLookupRetries(10*time.Second))
+	assert.Equal(t, 1, cloudTrailLookupRetries(cloudTrailLookupInterval))
+	assert.Equal(t, 2, cloudTrailLookupRetries(cloudTrailLookupInterval+time.Second))
+	assert.Equal(t, 30, cloudTrailLookupRetries(15*time.Minute))
+}
@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// logsInsightsPollPolicy is how GetQueryResults is polled while a Logs Insights query runs. Queries time out after 60
+// minutes on the CloudWatch side, but those of tests typically complete within seconds.
+var logsInsightsPollPolicy = retry.BackoffPolicy{MaxRetries: 100, InitialDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 1.5}
+
+// QueryLogsInsights runs the given CloudWatch Logs Insights query on the given log groups, over the given window up to
+// now, and returns the matching rows. This will fail the test if there is an error.
+func QueryLogsInsights(t testing.TestingT, region string, logGroups []string, query string, window time.Duration) []map[string]string {
+	rows, err := QueryLogsInsightsE(t, region, logGroups, query, window)
+	require.NoError(t, err)
+	return rows
+}
+
+// QueryLogsInsightsE runs the given CloudWatch Logs Insights query (e.g., "fields @timestamp, @message | filter
+// @message like /ERROR/") on the given log groups, over the given window up to now, waits for it to complete, and
+// returns the matching rows, as maps of field names to values.
+func QueryLogsInsightsE(t testing.TestingT, region string, logGroups []string, query string, window time.Duration) ([]map[string]string, error) {
+	client, err := NewCloudWatchLogsClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	started, err := client.StartQuery(context.Background(), &cloudwatchlogs.StartQueryInput{
+		LogGroupNames: logGroups,
+		QueryString:   aws.String(query),
+		StartTime:     aws.Int64(now.Add(-window).Unix()),
+		EndTime:       aws.Int64(now.Unix()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := waitForE(t,
+		fmt.Sprintf("Waiting for Logs Insights query %s to complete", aws.ToString(started.QueryId)),
+		func() (*cloudwatchlogs.GetQueryResultsOutput, error) {
+			results, err := client.GetQueryResults(context.Background(), &cloudwatchlogs.GetQueryResultsInput{QueryId: started.QueryId})
+			if err != nil {
+				return nil, err
+			}
+			switch results.Status {
+			case types.QueryStatusFailed, types.QueryStatusCancelled, types.QueryStatusTimeout:
+				return nil, retry.FatalError{Underlying: LogsInsightsQueryFailed{QueryID: aws.ToString(started.QueryId), Status: string(results.Status)}}
+			}
+			return results, nil
+		},
+		func(results *cloudwatchlogs.GetQueryResultsOutput) bool {
+			return results.Status == types.QueryStatusComplete
+		},
+		logsInsightsPollPolicy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return logsInsightsRows(results.Results), nil
+}
+
+// logsInsightsRows converts the given Logs Insights results to maps of field names to values, leaving out the @ptr
+// field, which is only useful to the GetLogRecord API.
+func logsInsightsRows(results [][]types.ResultField) []map[string]string {
+	rows := make([]map[string]string, 0, len(results))
+	for _, result := range results {
+		row := map[string]string{}
+		for _, field := range result {
+			if name := aws.ToString(field.Field); name != "@ptr" {
+				row[name] = aws.ToString(field.Value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WaitForLogsInsightsRows runs the given Logs Insights query until it returns at least one row, and returns the rows.
+// See WaitForLogsInsightsRowsE.
+func WaitForLogsInsightsRows(t testing.TestingT, region string, logGroups []string, query string, window time.Duration, policy retry.Policy) []map[string]string {
+	rows, err := WaitForLogsInsightsRowsE(t, region, logGroups, query, window, policy)
+	require.NoError(t, err)
+	return rows
+}
+
+// WaitForLogsInsightsRowsE runs the given Logs Insights query, over the given window up to now, until it returns at
+// least one row, retrying according to the given policy (DefaultWaitPolicy if nil), and returns the rows. This is
+// useful to wait for a log entry that an application writes after a deploy, as logs take a while to be ingested.
+func WaitForLogsInsightsRowsE(t testing.TestingT, region string, logGroups []string, query string, window time.Duration, policy retry.Policy) ([]map[string]string, error) {
+	return waitForE(t,
+		fmt.Sprintf("Waiting for Logs Insights query to match in %s", strings.Join(logGroups, ", ")),
+		func() ([]map[string]string, error) { return QueryLogsInsightsE(t, region, logGroups, query, window) },
+		func(rows []map[string]string) bool { return len(rows) > 0 },
+		policy,
+	)
+}
+
+// WaitForLogMessage waits until a log entry whose message contains the given text is written to one of the given log
+// groups. See WaitForLogMessageE.
+func WaitForLogMessage(t testing.TestingT, region string, logGroups []string, text string, window time.Duration, policy retry.Policy) map[string]string {
+	row, err := WaitForLogMessageE(t, region, logGroups, text, window, policy)
+	require.NoError(t, err)
+	return row
+}
+
+// WaitForLogMessageE waits until a log entry whose message contains the given text is written to one of the given log
+// groups within the given window up to now, retrying according to the given policy (DefaultWaitPolicy if nil), and
+// returns the most recent such entry, with its @timestamp, @message, and @logStream fields.
+func WaitForLogMessageE(t testing.TestingT, region string, logGroups []string, text string, window time.Duration, policy retry.Policy) (map[string]string, error) {
+	rows, err := WaitForLogsInsightsRowsE(t, region, logGroups, logMessageQuery(text), window, policy)
+	if err != nil {
+		return nil, err
+	}
+	return rows[0], nil
+}
+
+// logMessageQuery returns a Logs Insights query for the most recent log entry whose message contains the given text.
+func logMessageQuery(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+	return fmt.Sprintf(`fields @timestamp, @message, @logStream | filter @message like "%s" | sort @timestamp desc | limit 1`, escaped)
+}
@@ -0,0 +1,30 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsInsightsRows(t *testing.T) {
+	t.Parallel()
+
+	rows := logsInsightsRows([][]types.ResultField{
+		{
+			{Field: aws.String("@timestamp"), Value: aws.String("2024-01-01 00:00:00.000")},
+			{Field: aws.String("@message"), Value: aws.String("started")},
+			{Field: aws.String("@ptr"), Value: aws.String("abc")},
+		},
+	})
+	assert.Equal(t, []map[string]string{{"@timestamp": "2024-01-01 00:00:00.000", "@message": "started"}}, rows)
+}
+
+func TestLogMessageQuery(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		`fields @timestamp, @message, @logStream | filter @message like "say \"hi\" to C:\\app" | sort @timestamp desc | limit 1`,
+		logMessageQuery(`say "hi" to C:\app`))
+}
@@ -0,0 +1,242 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricQueryID is the ID of the query built from a MetricQuery.
+const metricQueryID = "m0"
+
+// MetricQuery identifies a statistic of a CloudWatch metric.
+type MetricQuery struct {
+	Namespace  string            // e.g., AWS/EC2
+	MetricName string            // e.g., CPUUtilization
+	Dimensions map[string]string // e.g., {"AutoScalingGroupName": "my-asg"}
+	Statistic  string            // e.g., Average, Maximum, Sum, or p99
+	Period     time.Duration     // The granularity of the datapoints. Defaults to 1 minute.
+}
+
+// MetricSeries contains the datapoints of a metric, sorted by timestamp, oldest first.
+type MetricSeries struct {
+	Timestamps []time.Time
+	Values     []float64
+}
+
+// toMetricDataQuery returns the GetMetricData query for the given MetricQuery.
+func (query MetricQuery) toMetricDataQuery() types.MetricDataQuery {
+	period := query.Period
+	if period == 0 {
+		period = time.Minute
+	}
+
+	dimensions := make([]types.Dimension, 0, len(query.Dimensions))
+	for name, value := range query.Dimensions {
+		dimensions = append(dimensions, types.Dimension{Name: aws.String(name), Value: aws.String(value)})
+	}
+	sort.Slice(dimensions, func(i, j int) bool { return aws.ToString(dimensions[i].Name) < aws.ToString(dimensions[j].Name) })
+
+	return types.MetricDataQuery{
+		Id: aws.String(metricQueryID),
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
+				Namespace:  aws.String(query.Namespace),
+				MetricName: aws.String(query.MetricName),
+				Dimensions: dimensions,
+			},
+			Stat:   aws.String(query.Statistic),
+			Period: aws.Int32(int32(period.Seconds())),
+		},
+	}
+}
+
+// GetMetricData runs the given metric queries, which may include metric math expressions, over the given window up to
+// now, and returns the datapoints of each query that returns data, by query ID. This will fail the test if there is
+// an error.
+func GetMetricData(t testing.TestingT, region string, queries []types.MetricDataQuery, window time.Duration) map[string]MetricSeries {
+	series, err := GetMetricDataE(t, region, queries, window)
+	require.NoError(t, err)
+	return series
+}
+
+// GetMetricDataE runs the given metric queries, which may include metric math expressions (e.g., a query with the
+// expression "m1 / m2 * 100" and two queries with IDs m1 and m2 and ReturnData set to false), over the given window up
+// to now, and returns the datapoints of each query that returns data, by query ID.
+func GetMetricDataE(t testing.TestingT, region string, queries []types.MetricDataQuery, window time.Duration) (map[string]MetricSeries, error) {
+	client, err := NewCloudWatchClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	series := map[string]MetricSeries{}
+	paginator := cloudwatch.NewGetMetricDataPaginator(client, &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         aws.Time(now.Add(-window)),
+		EndTime:           aws.Time(now),
+		ScanBy:            types.ScanByTimestampAscending,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range page.MetricDataResults {
+			id := aws.ToString(result.Id)
+			if result.StatusCode == types.StatusCodeForbidden || result.StatusCode == types.StatusCodeInternalError {
+				return nil, fmt.Errorf("metric query %s failed: %s", id, result.StatusCode)
+			}
+			current := series[id]
+			current.Timestamps = append(current.Timestamps, result.Timestamps...)
+			current.Values = append(current.Values, result.Values...)
+			series[id] = current
+		}
+	}
+	return series, nil
+}
+
+// GetMetricStatisticValues returns the datapoints of the given metric statistic over the given window up to now,
+// oldest first. This will fail the test if there is an error.
+func GetMetricStatisticValues(t testing.TestingT, region string, query MetricQuery, window time.Duration) []float64 {
+	values, err := GetMetricStatisticValuesE(t, region, query, window)
+	require.NoError(t, err)
+	return values
+}
+
+// GetMetricStatisticValuesE returns the datapoints of the given metric statistic over the given window up to now,
+// oldest first. Note that CloudWatch only returns datapoints for the periods in which the metric has data.
+func GetMetricStatisticValuesE(t testing.TestingT, region string, query MetricQuery, window time.Duration) ([]float64, error) {
+	series, err := GetMetricDataE(t, region, []types.MetricDataQuery{query.toMetricDataQuery()}, window)
+	if err != nil {
+		return nil, err
+	}
+	return series[metricQueryID].Values, nil
+}
+
+// AssertMetricBreachesThreshold checks that at least one datapoint of the given metric statistic, over the given window
+// up to now, compares to the threshold with the given operator (e.g., types.ComparisonOperatorGreaterThanThreshold),
+// e.g., to check that load generated by the test actually shows up in the metric that an autoscaling policy uses.
+func AssertMetricBreachesThreshold(t testing.TestingT, region string, query MetricQuery, window time.Duration, operator types.ComparisonOperator, threshold float64) {
+	values, err := GetMetricStatisticValuesE(t, region, query, window)
+	if !assert.NoError(t, err) {
+		return
+	}
+	breaching, err := breachingMetricValues(values, operator, threshold)
+	if assert.NoError(t, err) {
+		assert.NotEmptyf(t, breaching, "No datapoint of %s %s/%s is %s %v: %v", query.Statistic, query.Namespace, query.MetricName, operator, threshold, values)
+	}
+}
+
+// AssertMetricWithinThreshold checks that no datapoint of the given metric statistic, over the given window up to now,
+// compares to the threshold with the given operator (e.g., types.ComparisonOperatorGreaterThanThreshold).
+func AssertMetricWithinThreshold(t testing.TestingT, region string, query MetricQuery, window time.Duration, operator types.ComparisonOperator, threshold float64) {
+	values, err := GetMetricStatisticValuesE(t, region, query, window)
+	if !assert.NoError(t, err) {
+		return
+	}
+	breaching, err := breachingMetricValues(values, operator, threshold)
+	if assert.NoError(t, err) {
+		assert.Emptyf(t, breaching, "Datapoints of %s %s/%s are %s %v", query.Statistic, query.Namespace, query.MetricName, operator, threshold)
+	}
+}
+
+// breachingMetricValues returns the given values that compare to the threshold with the given operator.
+func breachingMetricValues(values []float64, operator types.ComparisonOperator, threshold float64) ([]float64, error) {
+	breaching := []float64{}
+	for _, value := range values {
+		var breaches bool
+		switch operator {
+		case types.ComparisonOperatorGreaterThanThreshold:
+			breaches = value > threshold
+		case types.ComparisonOperatorGreaterThanOrEqualToThreshold:
+			breaches = value >= threshold
+		case types.ComparisonOperatorLessThanThreshold:
+			breaches = value < threshold
+		case types.ComparisonOperatorLessThanOrEqualToThreshold:
+			breaches = value <= threshold
+		default:
+			return nil, fmt.Errorf("unsupported comparison operator %s", operator)
+		}
+		if breaches {
+			breaching = append(breaching, value)
+		}
+	}
+	return breaching, nil
+}
+
+// GetAlarmState returns the state (OK, ALARM, or INSUFFICIENT_DATA) of the given metric or composite alarm. This will
+// fail the test if there is an error.
+func GetAlarmState(t testing.TestingT, region string, alarmName string) types.StateValue {
+	state, err := GetAlarmStateE(t, region, alarmName)
+	require.NoError(t, err)
+	return state
+}
+
+// GetAlarmStateE returns the state (OK, ALARM, or INSUFFICIENT_DATA) of the given metric or composite alarm.
+func GetAlarmStateE(t testing.TestingT, region string, alarmName string) (types.StateValue, error) {
+	client, err := NewCloudWatchClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.DescribeAlarms(context.Background(), &cloudwatch.DescribeAlarmsInput{
+		AlarmNames: []string{alarmName},
+		AlarmTypes: []types.AlarmType{types.AlarmTypeMetricAlarm, types.AlarmTypeCompositeAlarm},
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, alarm := range output.MetricAlarms {
+		return alarm.StateValue, nil
+	}
+	for _, alarm := range output.CompositeAlarms {
+		return alarm.StateValue, nil
+	}
+	return "", NewNotFoundError("CloudWatch alarm", alarmName, region)
+}
+
+// WaitForAlarmState waits until the given alarm is in the expected state. This will fail the test if it never is.
+func WaitForAlarmState(t testing.TestingT, region string, alarmName string, expectedState types.StateValue, policy retry.Policy) {
+	require.NoError(t, WaitForAlarmStateE(t, region, alarmName, expectedState, policy))
+}
+
+// WaitForAlarmStateE waits until the given metric or composite alarm is in the expected state (e.g.,
+// types.StateValueAlarm after generating load, or types.StateValueOk after it stops), retrying according to the given
+// policy (DefaultWaitPolicy if nil). As alarms evaluate over several periods, the policy should allow for at least
+// EvaluationPeriods x Period.
+func WaitForAlarmStateE(t testing.TestingT, region string, alarmName string, expectedState types.StateValue, policy retry.Policy) error {
+	_, err := waitForE(t,
+		fmt.Sprintf("Waiting for CloudWatch alarm %s to be in state %s", alarmName, expectedState),
+		func() (types.StateValue, error) { return GetAlarmStateE(t, region, alarmName) },
+		func(state types.StateValue) bool { return state == expectedState },
+		policy,
+	)
+	return err
+}
+
+// NewCloudWatchClient creates a new CloudWatch client.
+func NewCloudWatchClient(t testing.TestingT, region string) *cloudwatch.Client {
+	client, err := NewCloudWatchClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewCloudWatchClientE creates a new CloudWatch client.
+func NewCloudWatchClientE(t testing.TestingT, region string) (*cloudwatch.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloudwatch.NewFromConfig(*sess), nil
+}
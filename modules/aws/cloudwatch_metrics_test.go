@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricQueryToMetricDataQuery(t *testing.T) {
+	t.Parallel()
+
+	query := MetricQuery{
+		Namespace:  "AWS/ApplicationELB",
+		MetricName: "RequestCount",
+		Dimensions: map[string]string{"TargetGroup": "targetgroup/tg/1", "LoadBalancer": "app/lb/2"},
+		Statistic:  "Sum",
+	}.toMetricDataQuery()
+
+	assert.Equal(t, metricQueryID, aws.ToString(query.Id))
+	assert.Equal(t, "AWS/ApplicationELB", aws.ToString(query.MetricStat.Metric.Namespace))
+	assert.Equal(t, "RequestCount", aws.ToString(query.MetricStat.Metric.MetricName))
+	assert.Equal(t, "Sum", aws.ToString(query.MetricStat.Stat))
+	assert.Equal(t, int32(60), aws.ToInt32(query.MetricStat.Period))
+	assert.Equal(t, []types.Dimension{
+		{Name: aws.String("LoadBalancer"), Value: aws.String("app/lb/2")},
+		{Name: aws.String("TargetGroup"), Value: aws.String("targetgroup/tg/1")},
+	}, query.MetricStat.Metric.Dimensions)
+
+	query = MetricQuery{Namespace: "AWS/EC2", MetricName: "CPUUtilization", Statistic: "Average", Period: 5 * time.Minute}.toMetricDataQuery()
+	assert.Equal(t, int32(300), aws.ToInt32(query.MetricStat.Period))
+	assert.Empty(t, query.MetricStat.Metric.Dimensions)
+}
+
+func TestBreachingMetricValues(t *testing.T) {
+	t.Parallel()
+
+	values := []float64{10, 50, 80, 95}
+	testCases := []struct {
+		operator types.ComparisonOperator
+		expected []float64
+	}{
+		{types.ComparisonOperatorGreaterThanThreshold, []float64{95}},
+		{types.ComparisonOperatorGreaterThanOrEqualToThreshold, []float64{80, 95}},
+		{types.ComparisonOperatorLessThanThreshold, []float64{10, 50}},
+		{types.ComparisonOperatorLessThanOrEqualToThreshold, []float64{10, 50, 80}},
+	}
+	for _, testCase := range testCases {
+		breaching, err := breachingMetricValues(values, testCase.operator, 80)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, breaching, string(testCase.operator))
+	}
+
+	breaching, err := breachingMetricValues(nil, types.ComparisonOperatorGreaterThanThreshold, 80)
+	require.NoError(t, err)
+	assert.Empty(t, breaching)
+
+	_, err = breachingMetricValues(values, types.ComparisonOperatorGreaterThanUpperThreshold, 80)
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/configservice"
+	"github.com/aws/aws-sdk-go-v2/service/configservice/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// StartConfigRuleEvaluation starts an evaluation of the given AWS Config rule. See StartConfigRuleEvaluationE.
+func StartConfigRuleEvaluation(t testing.TestingT, region string, ruleName string) time.Time {
+	startTime, err := StartConfigRuleEvaluationE(t, region, ruleName)
+	require.NoError(t, err)
+	return startTime
+}
+
+// StartConfigRuleEvaluationE starts an on-demand evaluation of the given AWS Config rule and returns the time it was
+// started at, to pass to WaitForConfigRuleEvaluationE.
+func StartConfigRuleEvaluationE(t testing.TestingT, region string, ruleName string) (time.Time, error) {
+	client, err := NewConfigServiceClientE(t, region)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	startTime := time.Now()
+	_, err = client.StartConfigRulesEvaluation(context.Background(), &configservice.StartConfigRulesEvaluationInput{
+		ConfigRuleNames: []string{ruleName},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	logger.Default.Logf(t, "Started evaluation of AWS Config rule %s", ruleName)
+	return startTime, nil
+}
+
+// WaitForConfigRuleEvaluation waits for an evaluation of the given AWS Config rule to complete. See
+// WaitForConfigRuleEvaluationE.
+func WaitForConfigRuleEvaluation(t testing.TestingT, region string, ruleName string, since time.Time, policy retry.Policy) {
+	require.NoError(t, WaitForConfigRuleEvaluationE(t, region, ruleName, since, policy))
+}
+
+// WaitForConfigRuleEvaluationE waits for an evaluation of the given AWS Config rule to complete successfully at or
+// after the given time (e.g., returned by StartConfigRuleEvaluationE), retrying according to the given policy
+// (DefaultWaitPolicy if nil). An evaluation that fails after that time is returned as an error right away.
+func WaitForConfigRuleEvaluationE(t testing.TestingT, region string, ruleName string, since time.Time, policy retry.Policy) error {
+	client, err := NewConfigServiceClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for an evaluation of AWS Config rule %s", ruleName),
+		func() (types.ConfigRuleEvaluationStatus, error) {
+			output, err := client.DescribeConfigRuleEvaluationStatus(context.Background(), &configservice.DescribeConfigRuleEvaluationStatusInput{
+				ConfigRuleNames: []string{ruleName},
+			})
+			if err != nil {
+				return types.ConfigRuleEvaluationStatus{}, err
+			}
+			if len(output.ConfigRulesEvaluationStatus) == 0 {
+				return types.ConfigRuleEvaluationStatus{}, NewNotFoundError("AWS Config rule", ruleName, region)
+			}
+			status := output.ConfigRulesEvaluationStatus[0]
+			if isConfigRuleEvaluationFailedSince(status, since) {
+				return status, retry.FatalError{Underlying: fmt.Errorf("evaluation of AWS Config rule %s failed: %s: %s", ruleName, aws.ToString(status.LastErrorCode), aws.ToString(status.LastErrorMessage))}
+			}
+			return status, nil
+		},
+		func(status types.ConfigRuleEvaluationStatus) bool { return isConfigRuleEvaluatedSince(status, since) },
+		policy,
+	)
+	return err
+}
+
+// isConfigRuleEvaluatedSince returns true if the given rule was successfully evaluated at or after the given time.
+func isConfigRuleEvaluatedSince(status types.ConfigRuleEvaluationStatus, since time.Time) bool {
+	return status.LastSuccessfulEvaluationTime != nil && !status.LastSuccessfulEvaluationTime.Before(since)
+}
+
+// isConfigRuleEvaluationFailedSince returns true if the last evaluation of the given rule failed at or after the given
+// time, and after the last successful one.
+func isConfigRuleEvaluationFailedSince(status types.ConfigRuleEvaluationStatus, since time.Time) bool {
+	if status.LastFailedEvaluationTime == nil || status.LastFailedEvaluationTime.Before(since) {
+		return false
+	}
+	return status.LastSuccessfulEvaluationTime == nil || status.LastFailedEvaluationTime.After(*status.LastSuccessfulEvaluationTime)
+}
+
+// GetConfigRuleComplianceByResource returns the compliance of each resource evaluated by the given AWS Config rule.
+// See GetConfigRuleComplianceByResourceE.
+func GetConfigRuleComplianceByResource(t testing.TestingT, region string, ruleName string) map[string]string {
+	compliance, err := GetConfigRuleComplianceByResourceE(t, region, ruleName)
+	require.NoError(t, err)
+	return compliance
+}
+
+// GetConfigRuleComplianceByResourceE returns the compliance (COMPLIANT, NON_COMPLIANT, or NOT_APPLICABLE) of each
+// resource evaluated by the given AWS Config rule, indexed by resource ID.
+func GetConfigRuleComplianceByResourceE(t testing.TestingT, region string, ruleName string) (map[string]string, error) {
+	client, err := NewConfigServiceClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []types.EvaluationResult{}
+	paginator := configservice.NewGetComplianceDetailsByConfigRulePaginator(client, &configservice.GetComplianceDetailsByConfigRuleInput{
+		ConfigRuleName:  aws.String(ruleName),
+		ComplianceTypes: []types.ComplianceType{types.ComplianceTypeCompliant, types.ComplianceTypeNonCompliant, types.ComplianceTypeNotApplicable},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page.EvaluationResults...)
+	}
+	return configComplianceByResource(results), nil
+}
+
+// GetConfigRuleComplianceForResource returns the compliance of the given resource according to the given AWS Config
+// rule. See GetConfigRuleComplianceForResourceE.
+func GetConfigRuleComplianceForResource(t testing.TestingT, region string, ruleName string, resourceID string) string {
+	compliance, err := GetConfigRuleComplianceForResourceE(t, region, ruleName, resourceID)
+	require.NoError(t, err)
+	return compliance
+}
+
+// GetConfigRuleComplianceForResourceE returns the compliance (COMPLIANT, NON_COMPLIANT, or NOT_APPLICABLE) of the
+// resource with the given ID (e.g., a bucket name or a security group ID) according to the given AWS Config rule. A
+// resource the rule didn't evaluate returns a NotFoundError.
+func GetConfigRuleComplianceForResourceE(t testing.TestingT, region string, ruleName string, resourceID string) (string, error) {
+	compliance, err := GetConfigRuleComplianceByResourceE(t, region, ruleName)
+	if err != nil {
+		return "", err
+	}
+	resourceCompliance, found := compliance[resourceID]
+	if !found {
+		return "", NewNotFoundError("evaluation by AWS Config rule "+ruleName+" of resource", resourceID, region)
+	}
+	return resourceCompliance, nil
+}
+
+// AssertConfigRuleCompliance checks that the given resources have the given compliance (e.g.,
+// types.ComplianceTypeCompliant) according to the given AWS Config rule.
+func AssertConfigRuleCompliance(t testing.TestingT, region string, ruleName string, resourceIDs []string, expected types.ComplianceType) {
+	compliance, err := GetConfigRuleComplianceByResourceE(t, region, ruleName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, resourceID := range resourceIDs {
+		actual, found := compliance[resourceID]
+		if assert.Truef(t, found, "Resource %s was not evaluated by AWS Config rule %s", resourceID, ruleName) {
+			assert.Equalf(t, string(expected), actual, "Unexpected compliance of resource %s according to AWS Config rule %s", resourceID, ruleName)
+		}
+	}
+}
+
+// configComplianceByResource indexes the compliance of the given evaluation results by resource ID.
+func configComplianceByResource(results []types.EvaluationResult) map[string]string {
+	compliance := map[string]string{}
+	for _, result := range results {
+		if result.EvaluationResultIdentifier == nil || result.EvaluationResultIdentifier.EvaluationResultQualifier == nil {
+			continue
+		}
+		resourceID := aws.ToString(result.EvaluationResultIdentifier.EvaluationResultQualifier.ResourceId)
+		compliance[resourceID] = string(result.ComplianceType)
+	}
+	return compliance
+}
+
+// NewConfigServiceClient creates an AWS Config client.
+func NewConfigServiceClient(t testing.TestingT, region string) *configservice.Client {
+	client, err := NewConfigServiceClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewConfigServiceClientE creates an AWS Config client.
+func NewConfigServiceClientE(t testing.TestingT, region string) (*configservice.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return configservice.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/configservice/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigRuleEvaluationStatus(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	evaluated := types.ConfigRuleEvaluationStatus{LastSuccessfulEvaluationTime: aws.Time(since.Add(time.Minute))}
+	assert.True(t, isConfigRuleEvaluatedSince(evaluated, since))
+	assert.False(t, isConfigRuleEvaluationFailedSince(evaluated, since))
+
+	stale := types.ConfigRuleEvaluationStatus{LastSuccessfulEvaluationTime: aws.Time(since.Add(-time.Hour))}
+	assert.False(t, isConfigRuleEvaluatedSince(stale, since))
+
+	failed := types.ConfigRuleEvaluationStatus{
+		LastSuccessfulEvaluationTime: aws.Time(since.Add(-time.Hour)),
+		LastFailedEvaluationTime:     aws.Time(since.Add(time.Minute)),
+	}
+	assert.True(t, isConfigRuleEvaluationFailedSince(failed, since))
+
+	recovered := types.ConfigRuleEvaluationStatus{
+		LastSuccessfulEvaluationTime: aws.Time(since.Add(2 * time.Minute)),
+		LastFailedEvaluationTime:     aws.Time(since.Add(time.Minute)),
+	}
+	assert.False(t, isConfigRuleEvaluationFailedSince(recovered, since))
+}
+
+func TestConfigComplianceByResource(t *testing.T) {
+	t.Parallel()
+
+	result := func(resourceID string, compliance types.ComplianceType) types.EvaluationResult {
+		return types.EvaluationResult{
+			ComplianceType: compliance,
+			EvaluationResultIdentifier: &types.EvaluationResultIdentifier{
+				EvaluationResultQualifier: &types.EvaluationResultQualifier{ResourceId: aws.String(resourceID)},
+			},
+		}
+	}
+
+	assert.Equal(t, map[string]string{
+		"my-bucket":    "COMPLIANT",
+		"other-bucket": "NON_COMPLIANT",
+	}, configComplianceByResource([]types.EvaluationResult{
+		result("my-bucket", types.ComplianceTypeCompliant),
+		result("other-bucket", types.ComplianceTypeNonCompliant),
+		{ComplianceType: types.ComplianceTypeCompliant},
+	}))
+}
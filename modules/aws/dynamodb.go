@@ -2,11 +2,14 @@ package aws
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -80,6 +83,183 @@ func GetDynamoDBTableE(t testing.TestingT, region string, tableName string) (*ty
 	return out.Table, err
 }
 
+// PutDynamoDBItem writes the given item, a struct or map marshaled with attributevalue.MarshalMap (so `dynamodbav`
+// struct tags are honored), to the given table. This will fail the test if there are any errors.
+func PutDynamoDBItem(t testing.TestingT, region string, tableName string, item interface{}) {
+	require.NoError(t, PutDynamoDBItemE(t, region, tableName, item))
+}
+
+// PutDynamoDBItemE writes the given item, a struct or map marshaled with attributevalue.MarshalMap (so `dynamodbav`
+// struct tags are honored), to the given table.
+func PutDynamoDBItemE(t testing.TestingT, region string, tableName string, item interface{}) error {
+	client, err := NewDynamoDBClientE(t, region)
+	if err != nil {
+		return err
+	}
+	attributes, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      attributes,
+	})
+	return err
+}
+
+// GetDynamoDBItem reads the item with the given key (a struct or map with the key attributes) from the given table with
+// a strongly consistent read, and unmarshals it into out (a pointer to a struct or map). This will fail the test if the
+// item doesn't exist or there are any errors.
+func GetDynamoDBItem(t testing.TestingT, region string, tableName string, key interface{}, out interface{}) {
+	require.NoError(t, GetDynamoDBItemE(t, region, tableName, key, out))
+}
+
+// GetDynamoDBItemE reads the item with the given key (a struct or map with the key attributes) from the given table with
+// a strongly consistent read, and unmarshals it into out (a pointer to a struct or map). Returns a NotFoundError if the
+// item doesn't exist.
+func GetDynamoDBItemE(t testing.TestingT, region string, tableName string, key interface{}, out interface{}) error {
+	client, err := NewDynamoDBClientE(t, region)
+	if err != nil {
+		return err
+	}
+	keyAttributes, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+	output, err := client.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName:      aws.String(tableName),
+		Key:            keyAttributes,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	if len(output.Item) == 0 {
+		return NewNotFoundError("DynamoDB item", fmt.Sprintf("%v in table %s", key, tableName), region)
+	}
+	return attributevalue.UnmarshalMap(output.Item, out)
+}
+
+// QueryDynamoDBItems runs the given query, going through all the pages of results, and unmarshals the items into out (a
+// pointer to a slice of structs or maps). This will fail the test if there are any errors.
+func QueryDynamoDBItems(t testing.TestingT, region string, input *dynamodb.QueryInput, out interface{}) {
+	require.NoError(t, QueryDynamoDBItemsE(t, region, input, out))
+}
+
+// QueryDynamoDBItemsE runs the given query, going through all the pages of results, and unmarshals the items into out (a
+// pointer to a slice of structs or maps).
+func QueryDynamoDBItemsE(t testing.TestingT, region string, input *dynamodb.QueryInput, out interface{}) error {
+	client, err := NewDynamoDBClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	items := []map[string]types.AttributeValue{}
+	paginator := dynamodb.NewQueryPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return err
+		}
+		items = append(items, page.Items...)
+	}
+	return attributevalue.UnmarshalListOfMaps(items, out)
+}
+
+// AssertDynamoDBTableBillingMode checks that the given table uses the expected billing mode.
+func AssertDynamoDBTableBillingMode(t testing.TestingT, region string, tableName string, expectedBillingMode types.BillingMode) {
+	table, err := GetDynamoDBTableE(t, region, tableName)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedBillingMode, dynamoDBTableBillingMode(table), "Unexpected billing mode of DynamoDB table %s", tableName)
+	}
+}
+
+// dynamoDBTableBillingMode returns the billing mode of the given table. Tables that were always provisioned have no
+// billing mode summary.
+func dynamoDBTableBillingMode(table *types.TableDescription) types.BillingMode {
+	if table.BillingModeSummary == nil || table.BillingModeSummary.BillingMode == "" {
+		return types.BillingModeProvisioned
+	}
+	return table.BillingModeSummary.BillingMode
+}
+
+// AssertDynamoDBTableTimeToLive checks that TTL is enabled on the given table, with the expected attribute.
+func AssertDynamoDBTableTimeToLive(t testing.TestingT, region string, tableName string, expectedAttributeName string) {
+	ttl, err := GetDynamoDBTableTimeToLiveE(t, region, tableName)
+	if !assert.NoError(t, err) || !assert.NotNilf(t, ttl, "DynamoDB table %s has no TTL configuration", tableName) {
+		return
+	}
+	assert.Equalf(t, types.TimeToLiveStatusEnabled, ttl.TimeToLiveStatus, "TTL is not enabled on DynamoDB table %s", tableName)
+	assert.Equalf(t, expectedAttributeName, aws.ToString(ttl.AttributeName), "Unexpected TTL attribute of DynamoDB table %s", tableName)
+}
+
+// AssertDynamoDBTableHasGlobalSecondaryIndex checks that the given table has a global secondary index with the given
+// name and key attributes. Pass an empty expectedRangeKey for an index without a sort key.
+func AssertDynamoDBTableHasGlobalSecondaryIndex(t testing.TestingT, region string, tableName string, indexName string, expectedHashKey string, expectedRangeKey string) {
+	table, err := GetDynamoDBTableE(t, region, tableName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, index := range table.GlobalSecondaryIndexes {
+		if aws.ToString(index.IndexName) == indexName {
+			assertDynamoDBKeySchema(t, index.KeySchema, expectedHashKey, expectedRangeKey, "global secondary index "+indexName)
+			return
+		}
+	}
+	assert.Failf(t, "Global secondary index not found", "DynamoDB table %s has no global secondary index %s", tableName, indexName)
+}
+
+// AssertDynamoDBTableHasLocalSecondaryIndex checks that the given table has a local secondary index with the given
+// name and key attributes.
+func AssertDynamoDBTableHasLocalSecondaryIndex(t testing.TestingT, region string, tableName string, indexName string, expectedHashKey string, expectedRangeKey string) {
+	table, err := GetDynamoDBTableE(t, region, tableName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, index := range table.LocalSecondaryIndexes {
+		if aws.ToString(index.IndexName) == indexName {
+			assertDynamoDBKeySchema(t, index.KeySchema, expectedHashKey, expectedRangeKey, "local secondary index "+indexName)
+			return
+		}
+	}
+	assert.Failf(t, "Local secondary index not found", "DynamoDB table %s has no local secondary index %s", tableName, indexName)
+}
+
+// assertDynamoDBKeySchema checks that the given key schema has the expected hash and range keys.
+func assertDynamoDBKeySchema(t testing.TestingT, keySchema []types.KeySchemaElement, expectedHashKey string, expectedRangeKey string, description string) {
+	hashKey, rangeKey := dynamoDBKeyAttributes(keySchema)
+	assert.Equalf(t, expectedHashKey, hashKey, "Unexpected hash key of %s", description)
+	assert.Equalf(t, expectedRangeKey, rangeKey, "Unexpected range key of %s", description)
+}
+
+// dynamoDBKeyAttributes returns the names of the hash and range key attributes of the given key schema.
+func dynamoDBKeyAttributes(keySchema []types.KeySchemaElement) (string, string) {
+	var hashKey, rangeKey string
+	for _, element := range keySchema {
+		switch element.KeyType {
+		case types.KeyTypeHash:
+			hashKey = aws.ToString(element.AttributeName)
+		case types.KeyTypeRange:
+			rangeKey = aws.ToString(element.AttributeName)
+		}
+	}
+	return hashKey, rangeKey
+}
+
+// AssertDynamoDBTableStream checks that streams are enabled on the given table, with the expected view type (e.g.,
+// types.StreamViewTypeNewAndOldImages).
+func AssertDynamoDBTableStream(t testing.TestingT, region string, tableName string, expectedViewType types.StreamViewType) {
+	table, err := GetDynamoDBTableE(t, region, tableName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	stream := table.StreamSpecification
+	if !assert.Truef(t, stream != nil && aws.ToBool(stream.StreamEnabled), "Streams are not enabled on DynamoDB table %s", tableName) {
+		return
+	}
+	assert.Equalf(t, expectedViewType, stream.StreamViewType, "Unexpected stream view type of DynamoDB table %s", tableName)
+}
+
 // NewDynamoDBClient creates a DynamoDB client.
 func NewDynamoDBClient(t testing.TestingT, region string) *dynamodb.Client {
 	client, err := NewDynamoDBClientE(t, region)
@@ -0,0 +1,33 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamoDBTableBillingMode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, types.BillingModeProvisioned, dynamoDBTableBillingMode(&types.TableDescription{}))
+	assert.Equal(t, types.BillingModePayPerRequest, dynamoDBTableBillingMode(&types.TableDescription{
+		BillingModeSummary: &types.BillingModeSummary{BillingMode: types.BillingModePayPerRequest},
+	}))
+}
+
+func TestDynamoDBKeyAttributes(t *testing.T) {
+	t.Parallel()
+
+	hashKey, rangeKey := dynamoDBKeyAttributes([]types.KeySchemaElement{
+		{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+		{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+	})
+	assert.Equal(t, "pk", hashKey)
+	assert.Equal(t, "sk", rangeKey)
+
+	hashKey, rangeKey = dynamoDBKeyAttributes([]types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}})
+	assert.Equal(t, "id", hashKey)
+	assert.Empty(t, rangeKey)
+}
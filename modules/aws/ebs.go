@@ -2,13 +2,37 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dlm"
+	dlmtypes "github.com/aws/aws-sdk-go-v2/service/dlm/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// dlmPolicyIDTag is the tag that Data Lifecycle Manager sets on the snapshots it creates to the ID of their policy.
+const dlmPolicyIDTag = "aws:dlm:lifecycle-policy-id"
+
+// EbsVolume is an EBS volume attached to an EC2 instance.
+type EbsVolume struct {
+	VolumeId   string // The ID of the volume
+	Device     string // The device name of the attachment, e.g., /dev/xvda
+	Type       string // The type of the volume, e.g., gp3
+	SizeGiB    int32  // The size of the volume in GiB
+	Iops       int32  // The provisioned IOPS of the volume, if any
+	Throughput int32  // The provisioned throughput of the volume in MiB/s, if any
+	Encrypted  bool   // Whether the volume is encrypted
+	KmsKeyId   string // The ARN of the KMS key the volume is encrypted with, if any
+}
+
 // DeleteEbsSnapshot deletes the given EBS snapshot
 func DeleteEbsSnapshot(t testing.TestingT, region string, snapshot string) {
 	err := DeleteEbsSnapshotE(t, region, snapshot)
@@ -30,3 +54,264 @@ func DeleteEbsSnapshotE(t testing.TestingT, region string, snapshot string) erro
 	})
 	return err
 }
+
+// GetEbsVolumesForInstance returns the EBS volumes attached to the given EC2 instance.
+func GetEbsVolumesForInstance(t testing.TestingT, region string, instanceID string) []EbsVolume {
+	volumes, err := GetEbsVolumesForInstanceE(t, region, instanceID)
+	require.NoError(t, err)
+	return volumes
+}
+
+// GetEbsVolumesForInstanceE returns the EBS volumes attached to the given EC2 instance.
+func GetEbsVolumesForInstanceE(t testing.TestingT, region string, instanceID string) ([]EbsVolume, error) {
+	ec2Client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []EbsVolume{}
+	paginator := ec2.NewDescribeVolumesPaginator(ec2Client, &ec2.DescribeVolumesInput{
+		Filters: []types.Filter{{Name: aws.String("attachment.instance-id"), Values: []string{instanceID}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range page.Volumes {
+			volumes = append(volumes, newEbsVolume(volume, instanceID))
+		}
+	}
+	return volumes, nil
+}
+
+// GetEbsVolumeForInstanceDevice returns the EBS volume attached to the given EC2 instance as the given device.
+func GetEbsVolumeForInstanceDevice(t testing.TestingT, region string, instanceID string, device string) EbsVolume {
+	volume, err := GetEbsVolumeForInstanceDeviceE(t, region, instanceID, device)
+	require.NoError(t, err)
+	return volume
+}
+
+// GetEbsVolumeForInstanceDeviceE returns the EBS volume attached to the given EC2 instance as the given device, e.g.,
+// /dev/xvda for the root volume of Amazon Linux instances.
+func GetEbsVolumeForInstanceDeviceE(t testing.TestingT, region string, instanceID string, device string) (EbsVolume, error) {
+	volumes, err := GetEbsVolumesForInstanceE(t, region, instanceID)
+	if err != nil {
+		return EbsVolume{}, err
+	}
+	for _, volume := range volumes {
+		if volume.Device == device {
+			return volume, nil
+		}
+	}
+	return EbsVolume{}, NewNotFoundError("EBS volume attached as "+device, instanceID, region)
+}
+
+// AssertEbsVolumeTypeAndSize checks that the EBS volume attached to the given EC2 instance as the given device has the
+// given type (e.g., gp3) and size in GiB.
+func AssertEbsVolumeTypeAndSize(t testing.TestingT, region string, instanceID string, device string, expectedType string, expectedSizeGiB int32) {
+	volume, err := GetEbsVolumeForInstanceDeviceE(t, region, instanceID, device)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedType, volume.Type, "Unexpected type of volume %s (%s of %s)", volume.VolumeId, device, instanceID)
+		assert.Equalf(t, expectedSizeGiB, volume.SizeGiB, "Unexpected size of volume %s (%s of %s)", volume.VolumeId, device, instanceID)
+	}
+}
+
+// AssertEbsVolumeEncrypted checks that the EBS volume attached to the given EC2 instance as the given device is
+// encrypted and, if kmsKeyID is not empty, that it is encrypted with that KMS key. The key can be given by ID, ARN, or
+// alias, such as "alias/my-key".
+func AssertEbsVolumeEncrypted(t testing.TestingT, region string, instanceID string, device string, kmsKeyID string) {
+	volume, err := GetEbsVolumeForInstanceDeviceE(t, region, instanceID, device)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Truef(t, volume.Encrypted, "Volume %s (%s of %s) is not encrypted", volume.VolumeId, device, instanceID) || kmsKeyID == "" {
+		return
+	}
+	keyArn, err := GetCmkArnE(t, region, kmsKeyID)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, keyArn, volume.KmsKeyId, "Volume %s (%s of %s) is encrypted with an unexpected KMS key", volume.VolumeId, device, instanceID)
+	}
+}
+
+// newEbsVolume converts the given volume, with the device of its attachment to the given instance.
+func newEbsVolume(volume types.Volume, instanceID string) EbsVolume {
+	result := EbsVolume{
+		VolumeId:   aws.ToString(volume.VolumeId),
+		Type:       string(volume.VolumeType),
+		SizeGiB:    aws.ToInt32(volume.Size),
+		Iops:       aws.ToInt32(volume.Iops),
+		Throughput: aws.ToInt32(volume.Throughput),
+		Encrypted:  aws.ToBool(volume.Encrypted),
+		KmsKeyId:   aws.ToString(volume.KmsKeyId),
+	}
+	for _, attachment := range volume.Attachments {
+		if aws.ToString(attachment.InstanceId) == instanceID {
+			result.Device = aws.ToString(attachment.Device)
+		}
+	}
+	return result
+}
+
+// CreateEbsSnapshot creates a snapshot of the given EBS volume and waits for it to complete. See CreateEbsSnapshotE.
+func CreateEbsSnapshot(t testing.TestingT, region string, volumeID string, description string) string {
+	snapshotID, err := CreateEbsSnapshotE(t, region, volumeID, description)
+	require.NoError(t, err)
+	return snapshotID
+}
+
+// CreateEbsSnapshotE creates a snapshot of the given EBS volume, registers it for cleanup, waits for it to complete
+// according to DefaultWaitPolicy, and returns its ID. Use WaitForEbsSnapshotE with a longer policy for large volumes.
+func CreateEbsSnapshotE(t testing.TestingT, region string, volumeID string, description string) (string, error) {
+	ec2Client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot, err := ec2Client.CreateSnapshot(context.Background(), &ec2.CreateSnapshotInput{
+		VolumeId:    aws.String(volumeID),
+		Description: aws.String(description),
+	})
+	if err != nil {
+		return "", err
+	}
+	snapshotID := aws.ToString(snapshot.SnapshotId)
+	logger.Default.Logf(t, "Created EBS snapshot %s of volume %s", snapshotID, volumeID)
+	RegisterForCleanup(t, CleanupEbsSnapshot, region, snapshotID)
+
+	return snapshotID, WaitForEbsSnapshotE(t, region, snapshotID, nil)
+}
+
+// WaitForEbsSnapshot waits for the given EBS snapshot to complete.
+func WaitForEbsSnapshot(t testing.TestingT, region string, snapshotID string, policy retry.Policy) {
+	require.NoError(t, WaitForEbsSnapshotE(t, region, snapshotID, policy))
+}
+
+// WaitForEbsSnapshotE waits for the given EBS snapshot to complete, retrying according to the given policy
+// (DefaultWaitPolicy if nil). A snapshot in the error state is returned as an error right away.
+func WaitForEbsSnapshotE(t testing.TestingT, region string, snapshotID string, policy retry.Policy) error {
+	ec2Client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for EBS snapshot %s to complete", snapshotID),
+		func() (types.SnapshotState, error) {
+			output, err := ec2Client.DescribeSnapshots(context.Background(), &ec2.DescribeSnapshotsInput{SnapshotIds: []string{snapshotID}})
+			if err != nil {
+				return "", err
+			}
+			if len(output.Snapshots) == 0 {
+				return "", NewNotFoundError("EBS snapshot", snapshotID, region)
+			}
+			snapshot := output.Snapshots[0]
+			if snapshot.State == types.SnapshotStateError {
+				return "", retry.FatalError{Underlying: fmt.Errorf("EBS snapshot %s failed: %s", snapshotID, aws.ToString(snapshot.StateMessage))}
+			}
+			return snapshot.State, nil
+		},
+		func(state types.SnapshotState) bool { return state == types.SnapshotStateCompleted },
+		policy,
+	)
+	return err
+}
+
+// GetDlmPolicySnapshots returns the snapshots created by the given DLM lifecycle policy since the given time. See
+// GetDlmPolicySnapshotsE.
+func GetDlmPolicySnapshots(t testing.TestingT, region string, policyID string, since time.Time) []string {
+	snapshots, err := GetDlmPolicySnapshotsE(t, region, policyID, since)
+	require.NoError(t, err)
+	return snapshots
+}
+
+// GetDlmPolicySnapshotsE returns the IDs of the completed snapshots that the given Data Lifecycle Manager policy (e.g.,
+// policy-0123456789abcdef0) started since the given time, found by the tag DLM sets on them.
+func GetDlmPolicySnapshotsE(t testing.TestingT, region string, policyID string, since time.Time) ([]string, error) {
+	ec2Client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []types.Snapshot{}
+	paginator := ec2.NewDescribeSnapshotsPaginator(ec2Client, &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+		Filters:  []types.Filter{{Name: aws.String("tag:" + dlmPolicyIDTag), Values: []string{policyID}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, page.Snapshots...)
+	}
+	return completedSnapshotsSince(snapshots, since), nil
+}
+
+// WaitForDlmPolicySnapshots waits for the given DLM lifecycle policy to create snapshots. See
+// WaitForDlmPolicySnapshotsE.
+func WaitForDlmPolicySnapshots(t testing.TestingT, region string, policyID string, since time.Time, minSnapshots int, policy retry.Policy) []string {
+	snapshots, err := WaitForDlmPolicySnapshotsE(t, region, policyID, since, minSnapshots, policy)
+	require.NoError(t, err)
+	return snapshots
+}
+
+// WaitForDlmPolicySnapshotsE waits for the given Data Lifecycle Manager policy to have created at least the given
+// number of completed snapshots since the given time, retrying according to the given policy (DefaultWaitPolicy if
+// nil), and returns their IDs. Use a policy that covers the schedule of the DLM policy, as DLM starts snapshots up to
+// an hour after their scheduled time.
+func WaitForDlmPolicySnapshotsE(t testing.TestingT, region string, policyID string, since time.Time, minSnapshots int, policy retry.Policy) ([]string, error) {
+	return waitForE(t,
+		fmt.Sprintf("Waiting for DLM policy %s to create %d snapshots", policyID, minSnapshots),
+		func() ([]string, error) { return GetDlmPolicySnapshotsE(t, region, policyID, since) },
+		func(snapshots []string) bool { return len(snapshots) >= minSnapshots },
+		policy,
+	)
+}
+
+// AssertDlmPolicyEnabled checks that the given Data Lifecycle Manager policy is enabled.
+func AssertDlmPolicyEnabled(t testing.TestingT, region string, policyID string) {
+	client, err := NewDlmClientE(t, region)
+	if !assert.NoError(t, err) {
+		return
+	}
+	output, err := client.GetLifecyclePolicy(context.Background(), &dlm.GetLifecyclePolicyInput{PolicyId: aws.String(policyID)})
+	if assert.NoError(t, err) {
+		assert.Equalf(t, dlmtypes.GettablePolicyStateValuesEnabled, output.Policy.State, "DLM policy %s is not enabled", policyID)
+	}
+}
+
+// completedSnapshotsSince returns the IDs of the given snapshots that completed and were started at or after the given
+// time, oldest first.
+func completedSnapshotsSince(snapshots []types.Snapshot, since time.Time) []string {
+	completed := []types.Snapshot{}
+	for _, snapshot := range snapshots {
+		if snapshot.State == types.SnapshotStateCompleted && snapshot.StartTime != nil && !snapshot.StartTime.Before(since) {
+			completed = append(completed, snapshot)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool { return completed[i].StartTime.Before(*completed[j].StartTime) })
+
+	ids := []string{}
+	for _, snapshot := range completed {
+		ids = append(ids, aws.ToString(snapshot.SnapshotId))
+	}
+	return ids
+}
+
+// NewDlmClient creates a Data Lifecycle Manager client.
+func NewDlmClient(t testing.TestingT, region string) *dlm.Client {
+	client, err := NewDlmClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewDlmClientE creates a Data Lifecycle Manager client.
+func NewDlmClientE(t testing.TestingT, region string) (*dlm.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return dlm.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEbsVolume(t *testing.T) {
+	t.Parallel()
+
+	volume := newEbsVolume(types.Volume{
+		VolumeId:   aws.String("vol-0123456789abcdef0"),
+		VolumeType: types.VolumeTypeGp3,
+		Size:       aws.Int32(20),
+		Iops:       aws.Int32(3000),
+		Throughput: aws.Int32(125),
+		Encrypted:  aws.Bool(true),
+		KmsKeyId:   aws.String("arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+		Attachments: []types.VolumeAttachment{
+			{InstanceId: aws.String("i-0123456789abcdef0"), Device: aws.String("/dev/xvda")},
+		},
+	}, "i-0123456789abcdef0")
+
+	assert.Equal(t, EbsVolume{
+		VolumeId:   "vol-0123456789abcdef0",
+		Device:     "/dev/xvda",
+		Type:       "gp3",
+		SizeGiB:    20,
+		Iops:       3000,
+		Throughput: 125,
+		Encrypted:  true,
+		KmsKeyId:   "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+	}, volume)
+}
+
+func TestCompletedSnapshotsSince(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []types.Snapshot{
+		{SnapshotId: aws.String("snap-late"), State: types.SnapshotStateCompleted, StartTime: aws.Time(since.Add(2 * time.Hour))},
+		{SnapshotId: aws.String("snap-old"), State: types.SnapshotStateCompleted, StartTime: aws.Time(since.Add(-time.Hour))},
+		{SnapshotId: aws.String("snap-pending"), State: types.SnapshotStatePending, StartTime: aws.Time(since.Add(3 * time.Hour))},
+		{SnapshotId: aws.String("snap-early"), State: types.SnapshotStateCompleted, StartTime: aws.Time(since.Add(time.Hour))},
+	}
+
+	assert.Equal(t, []string{"snap-early", "snap-late"}, completedSnapshotsSince(snapshots, since))
+}
@@ -0,0 +1,213 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// imdsTokenTTLSeconds is how long the IMDSv2 session tokens requested by the metadata helpers are valid.
+const imdsTokenTTLSeconds = 300
+
+// Ec2MetadataOptions are the instance metadata service (IMDS) settings of an EC2 instance.
+type Ec2MetadataOptions struct {
+	HttpEndpointEnabled         bool  // Whether the IMDS is reachable from the instance at all
+	HttpTokensRequired          bool  // Whether IMDSv2 session tokens are required, i.e., IMDSv1 is disabled
+	HttpPutResponseHopLimit     int32 // How many network hops the token responses may travel; containers need at least 2
+	InstanceMetadataTagsEnabled bool  // Whether the instance tags are exposed in the metadata
+}
+
+// Ec2InstanceIdentityDocument is the instance identity document of an EC2 instance, as returned by the IMDS at
+// dynamic/instance-identity/document.
+type Ec2InstanceIdentityDocument struct {
+	AccountId        string    `json:"accountId"`
+	Architecture     string    `json:"architecture"`
+	AvailabilityZone string    `json:"availabilityZone"`
+	ImageId          string    `json:"imageId"`
+	InstanceId       string    `json:"instanceId"`
+	InstanceType     string    `json:"instanceType"`
+	KernelId         string    `json:"kernelId"`
+	PendingTime      time.Time `json:"pendingTime"`
+	PrivateIp        string    `json:"privateIp"`
+	Region           string    `json:"region"`
+	Version          string    `json:"version"`
+}
+
+// GetEc2InstanceMetadataOptions returns the instance metadata service settings of the given EC2 instance.
+func GetEc2InstanceMetadataOptions(t testing.TestingT, awsRegion string, instanceID string) Ec2MetadataOptions {
+	options, err := GetEc2InstanceMetadataOptionsE(t, awsRegion, instanceID)
+	require.NoError(t, err)
+	return options
+}
+
+// GetEc2InstanceMetadataOptionsE returns the instance metadata service settings of the given EC2 instance.
+func GetEc2InstanceMetadataOptionsE(t testing.TestingT, awsRegion string, instanceID string) (Ec2MetadataOptions, error) {
+	response, err := getEc2InstanceMetadataOptionsResponseE(t, awsRegion, instanceID)
+	if err != nil {
+		return Ec2MetadataOptions{}, err
+	}
+	return newEc2MetadataOptions(response), nil
+}
+
+// SetEc2InstanceMetadataOptions updates the instance metadata service settings of the given EC2 instance. See
+// SetEc2InstanceMetadataOptionsE.
+func SetEc2InstanceMetadataOptions(t testing.TestingT, awsRegion string, instanceID string, options Ec2MetadataOptions) {
+	require.NoError(t, SetEc2InstanceMetadataOptionsE(t, awsRegion, instanceID, options))
+}
+
+// SetEc2InstanceMetadataOptionsE updates the instance metadata service settings of the given EC2 instance, e.g., to
+// require IMDSv2 or raise the hop limit so that containers on the instance can get session tokens, and waits for the
+// new settings to be applied. A zero HttpPutResponseHopLimit leaves the hop limit unchanged.
+func SetEc2InstanceMetadataOptionsE(t testing.TestingT, awsRegion string, instanceID string, options Ec2MetadataOptions) error {
+	logger.Default.Logf(t, "Updating the metadata options of EC2 instance %s in %s", instanceID, awsRegion)
+
+	client, err := NewEc2ClientE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+
+	input := &ec2.ModifyInstanceMetadataOptionsInput{
+		InstanceId:           aws.String(instanceID),
+		HttpEndpoint:         types.InstanceMetadataEndpointStateDisabled,
+		HttpTokens:           types.HttpTokensStateOptional,
+		InstanceMetadataTags: types.InstanceMetadataTagsStateDisabled,
+	}
+	if options.HttpEndpointEnabled {
+		input.HttpEndpoint = types.InstanceMetadataEndpointStateEnabled
+	}
+	if options.HttpTokensRequired {
+		input.HttpTokens = types.HttpTokensStateRequired
+	}
+	if options.InstanceMetadataTagsEnabled {
+		input.InstanceMetadataTags = types.InstanceMetadataTagsStateEnabled
+	}
+	if options.HttpPutResponseHopLimit > 0 {
+		input.HttpPutResponseHopLimit = aws.Int32(options.HttpPutResponseHopLimit)
+	}
+
+	if _, err := client.ModifyInstanceMetadataOptions(context.Background(), input); err != nil {
+		return err
+	}
+
+	_, err = waitForE(t, fmt.Sprintf("Waiting for the metadata options of EC2 instance %s to be applied", instanceID),
+		func() (*types.InstanceMetadataOptionsResponse, error) {
+			return getEc2InstanceMetadataOptionsResponseE(t, awsRegion, instanceID)
+		},
+		func(response *types.InstanceMetadataOptionsResponse) bool {
+			return response.State == types.InstanceMetadataOptionsStateApplied
+		},
+		nil,
+	)
+	return err
+}
+
+// AssertEc2InstanceRequiresImdsv2 asserts that the given EC2 instance only accepts IMDSv2 requests, i.e., requests
+// with a session token.
+func AssertEc2InstanceRequiresImdsv2(t testing.TestingT, awsRegion string, instanceID string) {
+	options := GetEc2InstanceMetadataOptions(t, awsRegion, instanceID)
+	assert.True(t, options.HttpEndpointEnabled, "The metadata endpoint of EC2 instance %s is disabled", instanceID)
+	assert.True(t, options.HttpTokensRequired, "EC2 instance %s does not require IMDSv2 session tokens", instanceID)
+}
+
+// GetEc2InstanceMetadata returns the instance metadata at the given path (e.g., meta-data/instance-id), fetched on the
+// given EC2 instance. See GetEc2InstanceMetadataE.
+func GetEc2InstanceMetadata(t testing.TestingT, awsRegion string, instanceID string, path string, timeout time.Duration) string {
+	metadata, err := GetEc2InstanceMetadataE(t, awsRegion, instanceID, path, timeout)
+	require.NoError(t, err)
+	return metadata
+}
+
+// GetEc2InstanceMetadataE returns the instance metadata at the given path relative to
+// http://169.254.169.254/latest/ (e.g., meta-data/instance-id), fetched on the given EC2 instance through SSM Run
+// Command. The metadata is requested with an IMDSv2 session token, so this works whether or not the instance requires
+// IMDSv2; the instance must be managed by SSM and have curl installed.
+func GetEc2InstanceMetadataE(t testing.TestingT, awsRegion string, instanceID string, path string, timeout time.Duration) (string, error) {
+	command, err := imdsv2MetadataCommand(path)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := RunSsmCommandE(t, awsRegion, instanceID, []string{command}, timeout)
+	if err != nil {
+		return "", err
+	}
+	if output.ExitCode != 0 {
+		return "", Ec2MetadataRequestFailed{InstanceID: instanceID, Path: path, ExitCode: output.ExitCode, Stderr: output.Stderr}
+	}
+	return strings.TrimSpace(output.Stdout), nil
+}
+
+// GetEc2InstanceIdentityDocument returns the instance identity document of the given EC2 instance. See
+// GetEc2InstanceIdentityDocumentE.
+func GetEc2InstanceIdentityDocument(t testing.TestingT, awsRegion string, instanceID string, timeout time.Duration) Ec2InstanceIdentityDocument {
+	document, err := GetEc2InstanceIdentityDocumentE(t, awsRegion, instanceID, timeout)
+	require.NoError(t, err)
+	return document
+}
+
+// GetEc2InstanceIdentityDocumentE returns the instance identity document of the given EC2 instance, fetched on the
+// instance as with GetEc2InstanceMetadataE.
+func GetEc2InstanceIdentityDocumentE(t testing.TestingT, awsRegion string, instanceID string, timeout time.Duration) (Ec2InstanceIdentityDocument, error) {
+	out, err := GetEc2InstanceMetadataE(t, awsRegion, instanceID, "dynamic/instance-identity/document", timeout)
+	if err != nil {
+		return Ec2InstanceIdentityDocument{}, err
+	}
+	return parseEc2InstanceIdentityDocument(out)
+}
+
+// parseEc2InstanceIdentityDocument parses the given instance identity document.
+func parseEc2InstanceIdentityDocument(document string) (Ec2InstanceIdentityDocument, error) {
+	var parsed Ec2InstanceIdentityDocument
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return Ec2InstanceIdentityDocument{}, fmt.Errorf("failed to parse the instance identity document: %w", err)
+	}
+	return parsed, nil
+}
+
+// imdsv2MetadataCommand returns the shell command that gets an IMDSv2 session token and fetches the metadata at the
+// given path with it.
+func imdsv2MetadataCommand(path string) (string, error) {
+	path = strings.TrimPrefix(path, "/")
+	if strings.ContainsAny(path, "'\\") {
+		return "", fmt.Errorf("invalid instance metadata path %q", path)
+	}
+
+	return fmt.Sprintf(
+		"TOKEN=$(curl -sSf -X PUT -H 'X-aws-ec2-metadata-token-ttl-seconds: %d' http://169.254.169.254/latest/api/token) && "+
+			"curl -sSf -H \"X-aws-ec2-metadata-token: $TOKEN\" 'http://169.254.169.254/latest/%s'",
+		imdsTokenTTLSeconds, path,
+	), nil
+}
+
+// getEc2InstanceMetadataOptionsResponseE returns the metadata options of the given EC2 instance, as returned by
+// DescribeInstances.
+func getEc2InstanceMetadataOptionsResponseE(t testing.TestingT, awsRegion string, instanceID string) (*types.InstanceMetadataOptionsResponse, error) {
+	instance, err := getEc2InstanceE(t, awsRegion, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if instance.MetadataOptions == nil {
+		return nil, NewNotFoundError("EC2 instance metadata options", instanceID, awsRegion)
+	}
+	return instance.MetadataOptions, nil
+}
+
+// newEc2MetadataOptions converts the metadata options returned by DescribeInstances.
+func newEc2MetadataOptions(response *types.InstanceMetadataOptionsResponse) Ec2MetadataOptions {
+	return Ec2MetadataOptions{
+		HttpEndpointEnabled:         response.HttpEndpoint == types.InstanceMetadataEndpointStateEnabled,
+		HttpTokensRequired:          response.HttpTokens == types.HttpTokensStateRequired,
+		HttpPutResponseHopLimit:     aws.ToInt32(response.HttpPutResponseHopLimit),
+		InstanceMetadataTagsEnabled: response.InstanceMetadataTags == types.InstanceMetadataTagsStateEnabled,
+	}
+}
@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEc2InstanceIdentityDocument(t *testing.T) {
+	t.Parallel()
+
+	document, err := parseEc2InstanceIdentityDocument(`{
+		"accountId": "123456789012",
+		"architecture": "x86_64",
+		"availabilityZone": "us-east-1a",
+		"imageId": "ami-0abcdef1234567890",
+		"instanceId": "i-1234567890abcdef0",
+		"instanceType": "t3.micro",
+		"pendingTime": "2024-01-02T03:04:05Z",
+		"privateIp": "10.0.1.23",
+		"region": "us-east-1",
+		"version": "2017-09-30"
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", document.AccountId)
+	assert.Equal(t, "i-1234567890abcdef0", document.InstanceId)
+	assert.Equal(t, "t3.micro", document.InstanceType)
+	assert.Equal(t, "us-east-1a", document.AvailabilityZone)
+	assert.Equal(t, "10.0.1.23", document.PrivateIp)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), document.PendingTime)
+
+	_, err = parseEc2InstanceIdentityDocument("<html>")
+	assert.Error(t, err)
+}
+
+func TestImdsv2MetadataCommand(t *testing.T) {
+	t.Parallel()
+
+	command, err := imdsv2MetadataCommand("/meta-data/instance-id")
+	require.NoError(t, err)
+	assert.Contains(t, command, "-X PUT -H 'X-aws-ec2-metadata-token-ttl-seconds: 300' http://169.254.169.254/latest/api/token")
+	assert.Contains(t, command, `-H "X-aws-ec2-metadata-token: $TOKEN" 'http://169.254.169.254/latest/meta-data/instance-id'`)
+
+	_, err = imdsv2MetadataCommand("meta-data/'; rm -rf /")
+	assert.Error(t, err)
+}
+
+func TestNewEc2MetadataOptions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Ec2MetadataOptions{
+		HttpEndpointEnabled:     true,
+		HttpTokensRequired:      true,
+		HttpPutResponseHopLimit: 2,
+	}, newEc2MetadataOptions(&types.InstanceMetadataOptionsResponse{
+		HttpEndpoint:            types.InstanceMetadataEndpointStateEnabled,
+		HttpTokens:              types.HttpTokensStateRequired,
+		HttpPutResponseHopLimit: aws.Int32(2),
+		InstanceMetadataTags:    types.InstanceMetadataTagsStateDisabled,
+	}))
+}
@@ -0,0 +1,499 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ec2CapacityErrorCodes are the EC2 error codes that mean there is no capacity for the requested instances right now,
+// which may succeed with another instance type, in another AZ, or later.
+var ec2CapacityErrorCodes = map[string]bool{
+	"InsufficientInstanceCapacity":         true,
+	"InsufficientHostCapacity":             true,
+	"InsufficientReservedInstanceCapacity": true,
+	"InsufficientCapacity":                 true,
+	"SpotMaxPriceTooLow":                   true,
+	"Unsupported":                          true,
+}
+
+// spotInterruptionStatusCodes are the spot request status codes that mean AWS interrupted (or is about to interrupt)
+// the spot instance. See https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/spot-request-status.html
+var spotInterruptionStatusCodes = map[string]bool{
+	"marked-for-stop":                             true,
+	"marked-for-termination":                      true,
+	"instance-stopped-by-price":                   true,
+	"instance-stopped-no-capacity":                true,
+	"instance-stopped-capacity-oversubscribed":    true,
+	"instance-terminated-by-price":                true,
+	"instance-terminated-no-capacity":             true,
+	"instance-terminated-capacity-oversubscribed": true,
+	"instance-terminated-launch-group-constraint": true,
+}
+
+// spotInterruptionStateReasonCodes are the instance state reason codes of spot instances stopped or terminated by AWS.
+var spotInterruptionStateReasonCodes = map[string]bool{
+	"Server.SpotInstanceShutdown":    true,
+	"Server.SpotInstanceTermination": true,
+}
+
+// SpotInstanceOptions are the settings of the spot instance launched by LaunchSpotInstanceE.
+type SpotInstanceOptions struct {
+	AmiID                  string
+	InstanceTypes          []string // The instance types to try, in order, until one has capacity
+	SubnetIDs              []string // The subnets (and thus AZs) to try, in order, for each instance type. If empty, the default VPC is used.
+	SecurityGroupIDs       []string
+	KeyPairName            string
+	IamInstanceProfileName string
+	UserData               string            // The user data, which is base64 encoded for you
+	MaxPrice               string            // The maximum hourly price; defaults to the on-demand price
+	Tags                   map[string]string // The tags of the instance and its volumes
+}
+
+// Ec2CapacityOptions configures how GetRecommendedInstanceTypeWithCapacityE picks an instance type and AZ.
+type Ec2CapacityOptions struct {
+	Spot                       bool  // Whether the instances will be spot instances, in which case AZs are ranked by spot placement score
+	TargetCapacity             int32 // How many instances are needed; defaults to 1
+	MinSpotPlacementScore      int32 // The minimum spot placement score (1 to 10) of the AZ picked for spot instances
+	PreferCapacityReservations bool  // Whether AZs with open capacity reservations for the instance type are picked first
+}
+
+// Ec2InstanceTypeRecommendation is an instance type and an AZ in which it has capacity.
+type Ec2InstanceTypeRecommendation struct {
+	InstanceType          string
+	AvailabilityZone      string
+	SpotPlacementScore    int32  // The spot placement score of the AZ, if Spot was set
+	CapacityReservationId string // The open capacity reservation in the AZ, if one was picked
+}
+
+// IsEc2CapacityError returns true if the given error means that EC2 has no capacity for the requested instances
+// right now, e.g., InsufficientInstanceCapacity, in which case another instance type or AZ may work.
+func IsEc2CapacityError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && ec2CapacityErrorCodes[apiErr.ErrorCode()]
+}
+
+// LaunchSpotInstance launches a one-time spot instance and returns its ID. See LaunchSpotInstanceE.
+func LaunchSpotInstance(t testing.TestingT, awsRegion string, options SpotInstanceOptions) string {
+	instanceID, err := LaunchSpotInstanceE(t, awsRegion, options)
+	require.NoError(t, err)
+	return instanceID
+}
+
+// LaunchSpotInstanceE launches a one-time spot instance, trying each of the instance types in each of the subnets in
+// order until one has capacity, and returns its ID. The instance is registered for cleanup (see
+// CleanupRegisteredResources). If none of the combinations has capacity, an Ec2CapacityUnavailable error is returned.
+// Use WaitForSpotInstanceRunningE to wait for the instance to start.
+func LaunchSpotInstanceE(t testing.TestingT, awsRegion string, options SpotInstanceOptions) (string, error) {
+	client, err := NewEc2ClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	subnetIDs := options.SubnetIDs
+	if len(subnetIDs) == 0 {
+		subnetIDs = []string{""}
+	}
+
+	var lastErr error
+	for _, instanceType := range options.InstanceTypes {
+		for _, subnetID := range subnetIDs {
+			logger.Default.Logf(t, "Launching a %s spot instance in %s (subnet %s)", instanceType, awsRegion, subnetID)
+
+			output, err := client.RunInstances(context.Background(), newSpotRunInstancesInput(options, instanceType, subnetID))
+			if err != nil {
+				if !IsEc2CapacityError(err) {
+					return "", err
+				}
+				logger.Default.Logf(t, "No capacity for a %s spot instance in subnet %s: %v", instanceType, subnetID, err)
+				lastErr = err
+				continue
+			}
+
+			instanceID := aws.ToString(output.Instances[0].InstanceId)
+			RegisterForCleanup(t, CleanupEc2Instance, awsRegion, instanceID)
+			return instanceID, nil
+		}
+	}
+
+	return "", Ec2CapacityUnavailable{Region: awsRegion, InstanceTypes: options.InstanceTypes, Underlying: lastErr}
+}
+
+// newSpotRunInstancesInput returns the RunInstances input that launches a one-time spot instance of the given type in
+// the given subnet (the default VPC if empty).
+func newSpotRunInstancesInput(options SpotInstanceOptions, instanceType string, subnetID string) *ec2.RunInstancesInput {
+	input := &ec2.RunInstancesInput{
+		ImageId:      aws.String(options.AmiID),
+		InstanceType: types.InstanceType(instanceType),
+		MinCount:     aws.Int32(1),
+		MaxCount:     aws.Int32(1),
+		InstanceMarketOptions: &types.InstanceMarketOptionsRequest{
+			MarketType: types.MarketTypeSpot,
+			SpotOptions: &types.SpotMarketOptions{
+				SpotInstanceType:             types.SpotInstanceTypeOneTime,
+				InstanceInterruptionBehavior: types.InstanceInterruptionBehaviorTerminate,
+			},
+		},
+	}
+	if options.MaxPrice != "" {
+		input.InstanceMarketOptions.SpotOptions.MaxPrice = aws.String(options.MaxPrice)
+	}
+	if subnetID != "" {
+		input.SubnetId = aws.String(subnetID)
+	}
+	if len(options.SecurityGroupIDs) > 0 {
+		input.SecurityGroupIds = options.SecurityGroupIDs
+	}
+	if options.KeyPairName != "" {
+		input.KeyName = aws.String(options.KeyPairName)
+	}
+	if options.IamInstanceProfileName != "" {
+		input.IamInstanceProfile = &types.IamInstanceProfileSpecification{Name: aws.String(options.IamInstanceProfileName)}
+	}
+	if options.UserData != "" {
+		input.UserData = aws.String(base64.StdEncoding.EncodeToString([]byte(options.UserData)))
+	}
+	if len(options.Tags) > 0 {
+		var tags []types.Tag
+		for key, value := range options.Tags {
+			tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		sort.SliceStable(tags, func(i, j int) bool { return aws.ToString(tags[i].Key) < aws.ToString(tags[j].Key) })
+		input.TagSpecifications = []types.TagSpecification{
+			{ResourceType: types.ResourceTypeInstance, Tags: tags},
+			{ResourceType: types.ResourceTypeVolume, Tags: tags},
+		}
+	}
+	return input
+}
+
+// WaitForSpotInstanceRunning waits until the given spot instance is running. See WaitForSpotInstanceRunningE.
+func WaitForSpotInstanceRunning(t testing.TestingT, awsRegion string, instanceID string, policy retry.Policy) {
+	require.NoError(t, WaitForSpotInstanceRunningE(t, awsRegion, instanceID, policy))
+}
+
+// WaitForSpotInstanceRunningE waits until the given spot instance is running, according to the given policy
+// (DefaultWaitPolicy if nil). If AWS interrupts the instance in the meantime, this stops waiting and returns a
+// SpotInstanceInterrupted error.
+func WaitForSpotInstanceRunningE(t testing.TestingT, awsRegion string, instanceID string, policy retry.Policy) error {
+	_, err := waitForE(t, "Waiting for spot instance "+instanceID+" to be running",
+		func() (*types.Instance, error) {
+			instance, err := getEc2InstanceE(t, awsRegion, instanceID)
+			if err != nil {
+				return nil, err
+			}
+			if isSpotInterruptionStateReason(instance.StateReason) {
+				return nil, retry.FatalError{Underlying: SpotInstanceInterrupted{InstanceID: instanceID, Region: awsRegion, Reason: aws.ToString(instance.StateReason.Message)}}
+			}
+			return instance, nil
+		},
+		func(instance *types.Instance) bool {
+			return instance.State != nil && instance.State.Name == types.InstanceStateNameRunning
+		},
+		policy,
+	)
+	return err
+}
+
+// IsSpotInstanceInterrupted returns true if AWS interrupted (or is about to interrupt) the given spot instance. See
+// IsSpotInstanceInterruptedE.
+func IsSpotInstanceInterrupted(t testing.TestingT, awsRegion string, instanceID string) bool {
+	interrupted, err := IsSpotInstanceInterruptedE(t, awsRegion, instanceID)
+	require.NoError(t, err)
+	return interrupted
+}
+
+// IsSpotInstanceInterruptedE returns true if AWS interrupted the given spot instance, or marked it for interruption,
+// according to the instance state reason and the status of its spot request. Returns false for on-demand instances.
+func IsSpotInstanceInterruptedE(t testing.TestingT, awsRegion string, instanceID string) (bool, error) {
+	instance, err := getEc2InstanceE(t, awsRegion, instanceID)
+	if err != nil {
+		return false, err
+	}
+	if isSpotInterruptionStateReason(instance.StateReason) {
+		return true, nil
+	}
+	if instance.SpotInstanceRequestId == nil {
+		return false, nil
+	}
+
+	client, err := NewEc2ClientE(t, awsRegion)
+	if err != nil {
+		return false, err
+	}
+	output, err := client.DescribeSpotInstanceRequests(context.Background(), &ec2.DescribeSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: []string{aws.ToString(instance.SpotInstanceRequestId)},
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, request := range output.SpotInstanceRequests {
+		if request.Status != nil && spotInterruptionStatusCodes[aws.ToString(request.Status.Code)] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DoWithSpotInterruptionRetry launches a spot instance and runs the given action against it, relaunching it if AWS
+// interrupts it. See DoWithSpotInterruptionRetryE.
+func DoWithSpotInterruptionRetry(t testing.TestingT, awsRegion string, maxAttempts int, launch func() (string, error), action func(instanceID string) error) {
+	require.NoError(t, DoWithSpotInterruptionRetryE(t, awsRegion, maxAttempts, launch, action))
+}
+
+// DoWithSpotInterruptionRetryE calls launch to launch a spot instance (e.g., with LaunchSpotInstanceE) and runs the
+// given action against it. If the action fails and AWS interrupted the instance, the instance is terminated and the
+// whole sequence is retried, up to maxAttempts times in total, after which a SpotInstanceInterrupted error is
+// returned. Errors of the action that are not caused by an interruption are returned as is, so that tests still fail
+// for real problems.
+func DoWithSpotInterruptionRetryE(t testing.TestingT, awsRegion string, maxAttempts int, launch func() (string, error), action func(instanceID string) error) error {
+	for attempt := 1; ; attempt++ {
+		instanceID, err := launch()
+		if err != nil {
+			return err
+		}
+
+		actionErr := action(instanceID)
+		if actionErr == nil {
+			return nil
+		}
+
+		interrupted, err := IsSpotInstanceInterruptedE(t, awsRegion, instanceID)
+		if err != nil {
+			logger.Default.Logf(t, "Failed to check whether spot instance %s was interrupted: %v", instanceID, err)
+			return actionErr
+		}
+		if !interrupted {
+			return actionErr
+		}
+		if attempt >= maxAttempts {
+			return SpotInstanceInterrupted{InstanceID: instanceID, Region: awsRegion, Attempts: attempt, Reason: actionErr.Error()}
+		}
+
+		logger.Default.Logf(t, "Spot instance %s was interrupted (attempt %d of %d), launching a new one", instanceID, attempt, maxAttempts)
+		if err := TerminateInstanceE(t, awsRegion, instanceID); err != nil && !isNotFoundError(err) {
+			logger.Default.Logf(t, "Failed to terminate interrupted spot instance %s: %v", instanceID, err)
+		}
+	}
+}
+
+// GetRecommendedInstanceTypeWithCapacity returns the first of the given instance types that has capacity in an AZ of
+// the given region, along with that AZ. See GetRecommendedInstanceTypeWithCapacityE.
+func GetRecommendedInstanceTypeWithCapacity(t testing.TestingT, region string, instanceTypeOptions []string, options Ec2CapacityOptions) Ec2InstanceTypeRecommendation {
+	recommendation, err := GetRecommendedInstanceTypeWithCapacityE(t, region, instanceTypeOptions, options)
+	require.NoError(t, err)
+	return recommendation
+}
+
+// GetRecommendedInstanceTypeWithCapacityE is like GetRecommendedInstanceTypeE, but takes capacity signals into account
+// to pick an AZ for the instances, rather than requiring the instance type to be offered in all AZs. The first of the
+// given instance types that has capacity is returned, along with the AZ to use:
+//
+//   - If options.Spot is set, the AZ with the highest spot placement score (see GetSpotPlacementScores), if at least
+//     options.MinSpotPlacementScore. Note that spot placement scores are only available to accounts with spot usage.
+//   - Otherwise, if options.PreferCapacityReservations is set, an AZ with an active, open capacity reservation with
+//     enough available instances, if any.
+//   - Otherwise, the first AZ, in alphabetical order, in which the instance type is offered.
+func GetRecommendedInstanceTypeWithCapacityE(t testing.TestingT, region string, instanceTypeOptions []string, options Ec2CapacityOptions) (Ec2InstanceTypeRecommendation, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return Ec2InstanceTypeRecommendation{}, err
+	}
+
+	offerings, err := getInstanceTypeOfferingsE(client, instanceTypeOptions)
+	if err != nil {
+		return Ec2InstanceTypeRecommendation{}, err
+	}
+
+	var reservations []types.CapacityReservation
+	if options.PreferCapacityReservations && !options.Spot {
+		reservations, err = getActiveCapacityReservationsE(client, instanceTypeOptions)
+		if err != nil {
+			return Ec2InstanceTypeRecommendation{}, err
+		}
+	}
+
+	var spotScores map[string]map[string]int32
+	if options.Spot {
+		spotScores, err = getSpotPlacementScoresE(client, region, instanceTypeOptions, options.TargetCapacity)
+		if err != nil {
+			return Ec2InstanceTypeRecommendation{}, err
+		}
+	}
+
+	return pickInstanceTypeWithCapacityE(instanceTypeOptions, offerings, reservations, spotScores, options)
+}
+
+// pickInstanceTypeWithCapacityE picks the first instance type from instanceTypeOptions that has capacity according
+// to the given offerings, capacity reservations, and spot placement scores (instance type to AZ name to score), as
+// documented on GetRecommendedInstanceTypeWithCapacityE.
+func pickInstanceTypeWithCapacityE(instanceTypeOptions []string, offerings []types.InstanceTypeOffering, reservations []types.CapacityReservation, spotScores map[string]map[string]int32, options Ec2CapacityOptions) (Ec2InstanceTypeRecommendation, error) {
+	targetCapacity := options.TargetCapacity
+	if targetCapacity <= 0 {
+		targetCapacity = 1
+	}
+
+	allAzs := map[string]bool{}
+	for _, instanceType := range instanceTypeOptions {
+		var azs []string
+		for _, offering := range offerings {
+			if string(offering.InstanceType) == instanceType {
+				azs = append(azs, aws.ToString(offering.Location))
+				allAzs[aws.ToString(offering.Location)] = true
+			}
+		}
+		sort.Strings(azs)
+		if len(azs) == 0 {
+			continue
+		}
+
+		if options.Spot {
+			best := Ec2InstanceTypeRecommendation{}
+			for _, az := range azs {
+				score := spotScores[instanceType][az]
+				if score > 0 && score >= options.MinSpotPlacementScore && score > best.SpotPlacementScore {
+					best = Ec2InstanceTypeRecommendation{InstanceType: instanceType, AvailabilityZone: az, SpotPlacementScore: score}
+				}
+			}
+			if best.InstanceType != "" {
+				return best, nil
+			}
+			continue
+		}
+
+		if options.PreferCapacityReservations {
+			for _, reservation := range reservations {
+				if aws.ToString(reservation.InstanceType) == instanceType &&
+					reservation.State == types.CapacityReservationStateActive &&
+					reservation.InstanceMatchCriteria == types.InstanceMatchCriteriaOpen &&
+					aws.ToInt32(reservation.AvailableInstanceCount) >= targetCapacity {
+					return Ec2InstanceTypeRecommendation{
+						InstanceType:          instanceType,
+						AvailabilityZone:      aws.ToString(reservation.AvailabilityZone),
+						CapacityReservationId: aws.ToString(reservation.CapacityReservationId),
+					}, nil
+				}
+			}
+		}
+
+		return Ec2InstanceTypeRecommendation{InstanceType: instanceType, AvailabilityZone: azs[0]}, nil
+	}
+
+	var azs []string
+	for az := range allAzs {
+		azs = append(azs, az)
+	}
+	sort.Strings(azs)
+	return Ec2InstanceTypeRecommendation{}, NoInstanceTypeError{InstanceTypeOptions: instanceTypeOptions, Azs: azs}
+}
+
+// getActiveCapacityReservationsE returns the active capacity reservations of the given instance types in the region
+// configured in the given EC2 client.
+func getActiveCapacityReservationsE(client *ec2.Client, instanceTypes []string) ([]types.CapacityReservation, error) {
+	paginator := ec2.NewDescribeCapacityReservationsPaginator(client, &ec2.DescribeCapacityReservationsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("instance-type"), Values: instanceTypes},
+			{Name: aws.String("state"), Values: []string{string(types.CapacityReservationStateActive)}},
+		},
+	})
+
+	var reservations []types.CapacityReservation
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, page.CapacityReservations...)
+	}
+	return reservations, nil
+}
+
+// getSpotPlacementScoresE returns the single-AZ spot placement score of each of the given instance types in each AZ
+// of the given region, keyed by instance type and then AZ name.
+func getSpotPlacementScoresE(client *ec2.Client, region string, instanceTypes []string, targetCapacity int32) (map[string]map[string]int32, error) {
+	if targetCapacity <= 0 {
+		targetCapacity = 1
+	}
+
+	azNamesByID, err := getAvailabilityZoneNamesByIdE(client)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[string]map[string]int32{}
+	for _, instanceType := range instanceTypes {
+		output, err := client.GetSpotPlacementScores(context.Background(), &ec2.GetSpotPlacementScoresInput{
+			InstanceTypes:          []string{instanceType},
+			TargetCapacity:         aws.Int32(targetCapacity),
+			TargetCapacityUnitType: types.TargetCapacityUnitTypeUnits,
+			SingleAvailabilityZone: aws.Bool(true),
+			RegionNames:            []string{region},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		scores[instanceType] = map[string]int32{}
+		for _, score := range output.SpotPlacementScores {
+			if azName, ok := azNamesByID[aws.ToString(score.AvailabilityZoneId)]; ok {
+				scores[instanceType][azName] = aws.ToInt32(score.Score)
+			}
+		}
+	}
+	return scores, nil
+}
+
+// getAvailabilityZoneNamesByIdE returns the names of the AZs in the region configured in the given EC2 client, keyed
+// by AZ ID (e.g., use1-az1), as the AZ names are mapped to different AZs in each account.
+func getAvailabilityZoneNamesByIdE(client *ec2.Client) (map[string]string, error) {
+	output, err := client.DescribeAvailabilityZones(context.Background(), &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, az := range output.AvailabilityZones {
+		names[aws.ToString(az.ZoneId)] = aws.ToString(az.ZoneName)
+	}
+	return names, nil
+}
+
+// getEc2InstanceE returns the given EC2 instance.
+func getEc2InstanceE(t testing.TestingT, awsRegion string, instanceID string) (*types.Instance, error) {
+	client, err := NewEc2ClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			if aws.ToString(instance.InstanceId) == instanceID {
+				return &instance, nil
+			}
+		}
+	}
+	return nil, NewNotFoundError("EC2 instance", instanceID, awsRegion)
+}
+
+// isSpotInterruptionStateReason returns true if the given instance state reason means that AWS stopped or terminated
+// the spot instance.
+func isSpotInterruptionStateReason(reason *types.StateReason) bool {
+	return reason != nil && spotInterruptionStateReasonCodes[aws.ToString(reason.Code)]
+}
@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEc2CapacityError(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsEc2CapacityError(fmt.Errorf("wrapped: %w", &smithy.GenericAPIError{Code: "InsufficientInstanceCapacity"})))
+	assert.True(t, IsEc2CapacityError(&smithy.GenericAPIError{Code: "SpotMaxPriceTooLow"}))
+	assert.False(t, IsEc2CapacityError(&smithy.GenericAPIError{Code: "InvalidAMIID.NotFound"}))
+	assert.False(t, IsEc2CapacityError(errors.New("InsufficientInstanceCapacity")))
+}
+
+func TestNewSpotRunInstancesInput(t *testing.T) {
+	t.Parallel()
+
+	input := newSpotRunInstancesInput(SpotInstanceOptions{
+		AmiID:    "ami-0abcdef1234567890",
+		MaxPrice: "0.05",
+		UserData: "#!/bin/bash",
+		Tags:     map[string]string{"Name": "test", "Env": "ci"},
+	}, "t3.micro", "subnet-123")
+
+	assert.Equal(t, types.InstanceType("t3.micro"), input.InstanceType)
+	assert.Equal(t, "subnet-123", aws.ToString(input.SubnetId))
+	assert.Equal(t, types.MarketTypeSpot, input.InstanceMarketOptions.MarketType)
+	assert.Equal(t, "0.05", aws.ToString(input.InstanceMarketOptions.SpotOptions.MaxPrice))
+	assert.Equal(t, "IyEvYmluL2Jhc2g=", aws.ToString(input.UserData))
+	require.Len(t, input.TagSpecifications, 2)
+	assert.Equal(t, "Env", aws.ToString(input.TagSpecifications[0].Tags[0].Key))
+
+	input = newSpotRunInstancesInput(SpotInstanceOptions{AmiID: "ami-0abcdef1234567890"}, "t3.micro", "")
+	assert.Nil(t, input.SubnetId)
+	assert.Nil(t, input.InstanceMarketOptions.SpotOptions.MaxPrice)
+	assert.Empty(t, input.TagSpecifications)
+}
+
+func TestIsSpotInterruptionStateReason(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isSpotInterruptionStateReason(&types.StateReason{Code: aws.String("Server.SpotInstanceTermination")}))
+	assert.False(t, isSpotInterruptionStateReason(&types.StateReason{Code: aws.String("Client.UserInitiatedShutdown")}))
+	assert.False(t, isSpotInterruptionStateReason(nil))
+}
+
+func TestPickInstanceTypeWithCapacity(t *testing.T) {
+	t.Parallel()
+
+	offerings := []types.InstanceTypeOffering{
+		{InstanceType: "t3.micro", Location: aws.String("us-east-1b")},
+		{InstanceType: "t3.micro", Location: aws.String("us-east-1a")},
+		{InstanceType: "t3a.micro", Location: aws.String("us-east-1a")},
+		{InstanceType: "t3a.micro", Location: aws.String("us-east-1c")},
+	}
+	instanceTypes := []string{"t2.micro", "t3.micro", "t3a.micro"}
+
+	recommendation, err := pickInstanceTypeWithCapacityE(instanceTypes, offerings, nil, nil, Ec2CapacityOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, Ec2InstanceTypeRecommendation{InstanceType: "t3.micro", AvailabilityZone: "us-east-1a"}, recommendation)
+
+	reservations := []types.CapacityReservation{
+		{CapacityReservationId: aws.String("cr-full"), InstanceType: aws.String("t3.micro"), AvailabilityZone: aws.String("us-east-1a"), State: types.CapacityReservationStateActive, InstanceMatchCriteria: types.InstanceMatchCriteriaOpen, AvailableInstanceCount: aws.Int32(0)},
+		{CapacityReservationId: aws.String("cr-open"), InstanceType: aws.String("t3.micro"), AvailabilityZone: aws.String("us-east-1b"), State: types.CapacityReservationStateActive, InstanceMatchCriteria: types.InstanceMatchCriteriaOpen, AvailableInstanceCount: aws.Int32(2)},
+	}
+	recommendation, err = pickInstanceTypeWithCapacityE(instanceTypes, offerings, reservations, nil, Ec2CapacityOptions{PreferCapacityReservations: true, TargetCapacity: 2})
+	require.NoError(t, err)
+	assert.Equal(t, Ec2InstanceTypeRecommendation{InstanceType: "t3.micro", AvailabilityZone: "us-east-1b", CapacityReservationId: "cr-open"}, recommendation)
+
+	spotScores := map[string]map[string]int32{
+		"t3.micro":  {"us-east-1a": 2, "us-east-1b": 3},
+		"t3a.micro": {"us-east-1a": 9, "us-east-1c": 7},
+	}
+	recommendation, err = pickInstanceTypeWithCapacityE(instanceTypes, offerings, nil, spotScores, Ec2CapacityOptions{Spot: true, MinSpotPlacementScore: 5})
+	require.NoError(t, err)
+	assert.Equal(t, Ec2InstanceTypeRecommendation{InstanceType: "t3a.micro", AvailabilityZone: "us-east-1a", SpotPlacementScore: 9}, recommendation)
+
+	_, err = pickInstanceTypeWithCapacityE(instanceTypes, offerings, nil, spotScores, Ec2CapacityOptions{Spot: true, MinSpotPlacementScore: 10})
+	assert.Equal(t, NoInstanceTypeError{InstanceTypeOptions: instanceTypes, Azs: []string{"us-east-1a", "us-east-1b", "us-east-1c"}}, err)
+}
@@ -0,0 +1,320 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/gruntwork-io/terratest/modules/docker"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ecrSeverities are the severities of image scan findings, from the most to the least severe.
+var ecrSeverities = []types.FindingSeverity{
+	types.FindingSeverityCritical,
+	types.FindingSeverityHigh,
+	types.FindingSeverityMedium,
+	types.FindingSeverityLow,
+	types.FindingSeverityInformational,
+	types.FindingSeverityUndefined,
+}
+
+// ECRAuthorization are the credentials to log in to the ECR registry of an account with Docker.
+type ECRAuthorization struct {
+	Registry string // The host name of the registry, e.g., 123456789012.dkr.ecr.us-east-1.amazonaws.com
+	Username string // The user name, always AWS
+	Password string // The password, valid for 12 hours
+}
+
+// ECRLifecyclePolicyPreviewResult is an image that a lifecycle policy would act on.
+type ECRLifecyclePolicyPreviewResult struct {
+	ImageDigest  string   // The digest of the image
+	ImageTags    []string // The tags of the image
+	Action       string   // The action the policy would take, i.e., EXPIRE
+	RulePriority int32    // The priority of the rule of the policy that applies to the image
+}
+
+// GetECRAuthorization gets the credentials to log in to the ECR registry of the current account in the given region.
+func GetECRAuthorization(t testing.TestingT, region string) ECRAuthorization {
+	auth, err := GetECRAuthorizationE(t, region)
+	require.NoError(t, err)
+	return auth
+}
+
+// GetECRAuthorizationE gets the credentials to log in to the ECR registry of the current account in the given region.
+func GetECRAuthorizationE(t testing.TestingT, region string) (ECRAuthorization, error) {
+	client, err := NewECRClientE(t, region)
+	if err != nil {
+		return ECRAuthorization{}, err
+	}
+
+	resp, err := client.GetAuthorizationToken(context.Background(), &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return ECRAuthorization{}, err
+	}
+	if len(resp.AuthorizationData) == 0 {
+		return ECRAuthorization{}, fmt.Errorf("no authorization data returned for ECR in %s", region)
+	}
+	data := resp.AuthorizationData[0]
+	return parseECRAuthorization(aws.ToString(data.AuthorizationToken), aws.ToString(data.ProxyEndpoint))
+}
+
+// parseECRAuthorization returns the credentials in the given base64-encoded user:password token for the registry with
+// the given endpoint, e.g., https://123456789012.dkr.ecr.us-east-1.amazonaws.com.
+func parseECRAuthorization(token string, endpoint string) (ECRAuthorization, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return ECRAuthorization{}, err
+	}
+	username, password, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return ECRAuthorization{}, fmt.Errorf("invalid ECR authorization token for %s", endpoint)
+	}
+	return ECRAuthorization{
+		Registry: strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://"),
+		Username: username,
+		Password: password,
+	}, nil
+}
+
+// DockerLoginToECR logs Docker in to the ECR registry of the current account in the given region.
+func DockerLoginToECR(t testing.TestingT, region string) {
+	require.NoError(t, DockerLoginToECRE(t, region))
+}
+
+// DockerLoginToECRE logs Docker in to the ECR registry of the current account in the given region, so that the docker
+// module can push and pull images of its repositories. The password is passed on stdin so it doesn't show in the logs.
+func DockerLoginToECRE(t testing.TestingT, region string) error {
+	auth, err := GetECRAuthorizationE(t, region)
+	if err != nil {
+		return err
+	}
+
+	logger.Default.Logf(t, "Logging in to ECR registry %s", auth.Registry)
+	cmd := exec.Command("docker", "login", "--username", auth.Username, "--password-stdin", auth.Registry)
+	cmd.Stdin = strings.NewReader(auth.Password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker login to %s failed: %w: %s", auth.Registry, err, output)
+	}
+	return nil
+}
+
+// PushImageToECR pushes the given local image to the given ECR repository. See PushImageToECRE.
+func PushImageToECR(t testing.TestingT, region string, repo *types.Repository, localImage string, tag string) string {
+	imageURI, err := PushImageToECRE(t, region, repo, localImage, tag)
+	require.NoError(t, err)
+	return imageURI
+}
+
+// PushImageToECRE logs in to the registry of the given ECR repository, tags the given local image (e.g., built with
+// docker.Build) with the given tag in the repository, pushes it, and returns its URI.
+func PushImageToECRE(t testing.TestingT, region string, repo *types.Repository, localImage string, tag string) (string, error) {
+	if err := DockerLoginToECRE(t, region); err != nil {
+		return "", err
+	}
+
+	imageURI := fmt.Sprintf("%s:%s", aws.ToString(repo.RepositoryUri), tag)
+	err := shell.RunCommandE(t, shell.Command{
+		Command: "docker",
+		Args:    []string{"tag", localImage, imageURI},
+		Logger:  logger.Default,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := docker.PushE(t, logger.Default, imageURI); err != nil {
+		return "", err
+	}
+	return imageURI, nil
+}
+
+// WaitForECRImageScanFindings waits for the scan of the given image to complete and returns the number of findings by
+// severity. See WaitForECRImageScanFindingsE.
+func WaitForECRImageScanFindings(t testing.TestingT, region string, repo *types.Repository, tag string, policy retry.Policy) map[string]int32 {
+	counts, err := WaitForECRImageScanFindingsE(t, region, repo, tag, policy)
+	require.NoError(t, err)
+	return counts
+}
+
+// WaitForECRImageScanFindingsE waits for the scan of the image with the given tag in the given repository to complete,
+// retrying according to the given policy (DefaultWaitPolicy if nil), and returns the number of findings by severity,
+// e.g., {"CRITICAL": 1, "HIGH": 4}. This works with both basic scanning on push and enhanced scanning. A failed scan is
+// returned as an error right away.
+func WaitForECRImageScanFindingsE(t testing.TestingT, region string, repo *types.Repository, tag string, policy retry.Policy) (map[string]int32, error) {
+	client, err := NewECRClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := waitForE(t,
+		fmt.Sprintf("Waiting for the scan of image %s:%s", aws.ToString(repo.RepositoryName), tag),
+		func() (*ecr.DescribeImageScanFindingsOutput, error) {
+			resp, err := client.DescribeImageScanFindings(context.Background(), &ecr.DescribeImageScanFindingsInput{
+				RepositoryName: repo.RepositoryName,
+				RegistryId:     repo.RegistryId,
+				ImageId:        &types.ImageIdentifier{ImageTag: aws.String(tag)},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if resp.ImageScanStatus != nil && isECRImageScanFailed(resp.ImageScanStatus.Status) {
+				return nil, retry.FatalError{Underlying: fmt.Errorf("scan of image %s:%s failed: %s", aws.ToString(repo.RepositoryName), tag, aws.ToString(resp.ImageScanStatus.Description))}
+			}
+			return resp, nil
+		},
+		func(resp *ecr.DescribeImageScanFindingsOutput) bool {
+			return resp.ImageScanStatus != nil && isECRImageScanComplete(resp.ImageScanStatus.Status)
+		},
+		policy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int32{}
+	if resp.ImageScanFindings != nil {
+		for severity, count := range resp.ImageScanFindings.FindingSeverityCounts {
+			counts[severity] = count
+		}
+	}
+	return counts, nil
+}
+
+// AssertECRImageHasNoFindingsAtOrAbove waits for the scan of the given image to complete and checks that it has no
+// findings of the given severity (e.g., HIGH) or more severe.
+func AssertECRImageHasNoFindingsAtOrAbove(t testing.TestingT, region string, repo *types.Repository, tag string, severity types.FindingSeverity, policy retry.Policy) {
+	counts, err := WaitForECRImageScanFindingsE(t, region, repo, tag, policy)
+	if assert.NoError(t, err) {
+		assert.Emptyf(t, ecrFindingsAtOrAbove(counts, severity), "Image %s:%s has findings of severity %s or more", aws.ToString(repo.RepositoryName), tag, severity)
+	}
+}
+
+// ecrFindingsAtOrAbove returns the given finding counts of the given severity or more severe ones.
+func ecrFindingsAtOrAbove(counts map[string]int32, severity types.FindingSeverity) map[string]int32 {
+	result := map[string]int32{}
+	for _, candidate := range ecrSeverities {
+		if count := counts[string(candidate)]; count > 0 {
+			result[string(candidate)] = count
+		}
+		if candidate == severity {
+			break
+		}
+	}
+	return result
+}
+
+// isECRImageScanComplete returns true if the given scan status means that the findings are available: COMPLETE for
+// basic scanning, and ACTIVE for continuous enhanced scanning.
+func isECRImageScanComplete(status types.ScanStatus) bool {
+	return status == types.ScanStatusComplete || status == types.ScanStatusActive
+}
+
+// isECRImageScanFailed returns true if the given scan status means that the findings will never be available.
+func isECRImageScanFailed(status types.ScanStatus) bool {
+	return status == types.ScanStatusFailed || status == types.ScanStatusUnsupportedImage || status == types.ScanStatusScanEligibilityExpired
+}
+
+// GetECRLifecyclePolicyPreview previews the given lifecycle policy on the given repository. See
+// GetECRLifecyclePolicyPreviewE.
+func GetECRLifecyclePolicyPreview(t testing.TestingT, region string, repo *types.Repository, policy string, waitPolicy retry.Policy) []ECRLifecyclePolicyPreviewResult {
+	results, err := GetECRLifecyclePolicyPreviewE(t, region, repo, policy, waitPolicy)
+	require.NoError(t, err)
+	return results
+}
+
+// GetECRLifecyclePolicyPreviewE previews the given lifecycle policy on the images of the given repository, waiting for
+// the preview to complete according to the given wait policy (DefaultWaitPolicy if nil), and returns the images the
+// policy would expire. Pass an empty policy to preview the lifecycle policy of the repository. This verifies a
+// lifecycle policy without waiting for it to run, which can take up to 24 hours.
+func GetECRLifecyclePolicyPreviewE(t testing.TestingT, region string, repo *types.Repository, policy string, waitPolicy retry.Policy) ([]ECRLifecyclePolicyPreviewResult, error) {
+	client, err := NewECRClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &ecr.StartLifecyclePolicyPreviewInput{RepositoryName: repo.RepositoryName, RegistryId: repo.RegistryId}
+	if policy != "" {
+		input.LifecyclePolicyText = aws.String(policy)
+	}
+	if _, err := client.StartLifecyclePolicyPreview(context.Background(), input); err != nil {
+		return nil, err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for the lifecycle policy preview of ECR repository %s", aws.ToString(repo.RepositoryName)),
+		func() (types.LifecyclePolicyPreviewStatus, error) {
+			resp, err := client.GetLifecyclePolicyPreview(context.Background(), &ecr.GetLifecyclePolicyPreviewInput{
+				RepositoryName: repo.RepositoryName,
+				RegistryId:     repo.RegistryId,
+			})
+			if err != nil {
+				return "", err
+			}
+			if resp.Status == types.LifecyclePolicyPreviewStatusFailed || resp.Status == types.LifecyclePolicyPreviewStatusExpired {
+				return "", retry.FatalError{Underlying: fmt.Errorf("lifecycle policy preview of ECR repository %s is %s", aws.ToString(repo.RepositoryName), resp.Status)}
+			}
+			return resp.Status, nil
+		},
+		func(status types.LifecyclePolicyPreviewStatus) bool {
+			return status == types.LifecyclePolicyPreviewStatusComplete
+		},
+		waitPolicy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []ECRLifecyclePolicyPreviewResult{}
+	paginator := ecr.NewGetLifecyclePolicyPreviewPaginator(client, &ecr.GetLifecyclePolicyPreviewInput{
+		RepositoryName: repo.RepositoryName,
+		RegistryId:     repo.RegistryId,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range page.PreviewResults {
+			converted := ECRLifecyclePolicyPreviewResult{
+				ImageDigest:  aws.ToString(result.ImageDigest),
+				ImageTags:    result.ImageTags,
+				RulePriority: aws.ToInt32(result.AppliedRulePriority),
+			}
+			if result.Action != nil {
+				converted.Action = string(result.Action.Type)
+			}
+			results = append(results, converted)
+		}
+	}
+	return results, nil
+}
+
+// AssertECRLifecyclePolicyExpiresTags checks that a preview of the given lifecycle policy on the given repository
+// expires exactly the images with the given tags. See GetECRLifecyclePolicyPreviewE.
+func AssertECRLifecyclePolicyExpiresTags(t testing.TestingT, region string, repo *types.Repository, policy string, expectedTags []string, waitPolicy retry.Policy) {
+	results, err := GetECRLifecyclePolicyPreviewE(t, region, repo, policy, waitPolicy)
+	if assert.NoError(t, err) {
+		assert.ElementsMatchf(t, expectedTags, expiredECRImageTags(results), "Unexpected images expired by the lifecycle policy of ECR repository %s", aws.ToString(repo.RepositoryName))
+	}
+}
+
+// expiredECRImageTags returns the tags of the images that the given preview results expire.
+func expiredECRImageTags(results []ECRLifecyclePolicyPreviewResult) []string {
+	tags := []string{}
+	for _, result := range results {
+		if result.Action == string(types.ImageActionTypeExpire) {
+			tags = append(tags, result.ImageTags...)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseECRAuthorization(t *testing.T) {
+	t.Parallel()
+
+	token := base64.StdEncoding.EncodeToString([]byte("AWS:secret:with:colons"))
+	auth, err := parseECRAuthorization(token, "https://123456789012.dkr.ecr.us-east-1.amazonaws.com")
+	require.NoError(t, err)
+	assert.Equal(t, ECRAuthorization{Registry: "123456789012.dkr.ecr.us-east-1.amazonaws.com", Username: "AWS", Password: "secret:with:colons"}, auth)
+
+	_, err = parseECRAuthorization(base64.StdEncoding.EncodeToString([]byte("no-separator")), "https://123456789012.dkr.ecr.us-east-1.amazonaws.com")
+	assert.Error(t, err)
+}
+
+func TestEcrFindingsAtOrAbove(t *testing.T) {
+	t.Parallel()
+
+	counts := map[string]int32{"CRITICAL": 1, "MEDIUM": 3, "LOW": 7}
+
+	assert.Equal(t, map[string]int32{"CRITICAL": 1}, ecrFindingsAtOrAbove(counts, types.FindingSeverityHigh))
+	assert.Equal(t, map[string]int32{"CRITICAL": 1, "MEDIUM": 3}, ecrFindingsAtOrAbove(counts, types.FindingSeverityMedium))
+	assert.Empty(t, ecrFindingsAtOrAbove(map[string]int32{"LOW": 2}, types.FindingSeverityHigh))
+}
+
+func TestExpiredECRImageTags(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"v1", "old"}, expiredECRImageTags([]ECRLifecyclePolicyPreviewResult{
+		{ImageDigest: "sha256:1", ImageTags: []string{"v1", "old"}, Action: "EXPIRE", RulePriority: 1},
+		{ImageDigest: "sha256:2", ImageTags: []string{"v2"}},
+	}))
+}
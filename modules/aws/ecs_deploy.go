@@ -0,0 +1,249 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// maxDescribeEcsTasks is the maximum number of tasks that can be passed to a single DescribeTasks call.
+const maxDescribeEcsTasks = 100
+
+// EcsStoppedTask describes why a task of an ECS service stopped.
+type EcsStoppedTask struct {
+	TaskArn       string
+	StopCode      string
+	StoppedReason string
+
+	// The reason each container stopped (e.g., "Essential container in task exited" or an OOM error), with its exit
+	// code if it has one, by container name.
+	ContainerReasons map[string]string
+}
+
+// IsEcsServiceStable returns true if the given ECS service has settled: it's active, it has a single deployment, whose
+// rollout (if the service uses the ECS deployment controller) is completed, and all its desired tasks are running. This
+// is the same condition as the services-stable waiter of the AWS CLI, and works for both EC2 and Fargate launch types.
+func IsEcsServiceStable(service *types.Service) bool {
+	if aws.ToString(service.Status) != "ACTIVE" || len(service.Deployments) != 1 {
+		return false
+	}
+	deployment := service.Deployments[0]
+	if deployment.RolloutState != "" && deployment.RolloutState != types.DeploymentRolloutStateCompleted {
+		return false
+	}
+	return service.RunningCount == service.DesiredCount && service.PendingCount == 0
+}
+
+// WaitForEcsServiceStable waits until the given ECS service is stable (see IsEcsServiceStable), e.g., after a rolling
+// deploy, retrying according to the given policy (DefaultWaitPolicy if nil). This will fail the test if the service
+// doesn't settle.
+func WaitForEcsServiceStable(t testing.TestingT, region string, clusterName string, serviceName string, policy retry.Policy) *types.Service {
+	service, err := WaitForEcsServiceStableE(t, region, clusterName, serviceName, policy)
+	require.NoError(t, err)
+	return service
+}
+
+// WaitForEcsServiceStableE waits until the given ECS service is stable (see IsEcsServiceStable), e.g., after a rolling
+// deploy, retrying according to the given policy (DefaultWaitPolicy if nil).
+func WaitForEcsServiceStableE(t testing.TestingT, region string, clusterName string, serviceName string, policy retry.Policy) (*types.Service, error) {
+	return waitForE(t,
+		fmt.Sprintf("Waiting for ECS service %s in cluster %s to be stable", serviceName, clusterName),
+		func() (*types.Service, error) { return GetEcsServiceE(t, region, clusterName, serviceName) },
+		IsEcsServiceStable,
+		policy,
+	)
+}
+
+// GetEcsStoppedTasks returns why the recently stopped tasks of the given ECS service stopped. ECS only keeps stopped
+// tasks for about an hour.
+func GetEcsStoppedTasks(t testing.TestingT, region string, clusterName string, serviceName string) []EcsStoppedTask {
+	tasks, err := GetEcsStoppedTasksE(t, region, clusterName, serviceName)
+	require.NoError(t, err)
+	return tasks
+}
+
+// GetEcsStoppedTasksE returns why the recently stopped tasks of the given ECS service stopped. ECS only keeps stopped
+// tasks for about an hour.
+func GetEcsStoppedTasksE(t testing.TestingT, region string, clusterName string, serviceName string) ([]EcsStoppedTask, error) {
+	client, err := NewEcsClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	taskArns, err := listEcsServiceTaskArnsE(t, region, clusterName, serviceName, types.DesiredStatusStopped)
+	if err != nil {
+		return nil, err
+	}
+
+	stoppedTasks := []EcsStoppedTask{}
+	for start := 0; start < len(taskArns); start += maxDescribeEcsTasks {
+		end := start + maxDescribeEcsTasks
+		if end > len(taskArns) {
+			end = len(taskArns)
+		}
+
+		output, err := client.DescribeTasks(context.Background(), &ecs.DescribeTasksInput{
+			Cluster: aws.String(clusterName),
+			Tasks:   taskArns[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range output.Tasks {
+			stoppedTasks = append(stoppedTasks, newEcsStoppedTask(task))
+		}
+	}
+	return stoppedTasks, nil
+}
+
+// listEcsServiceTaskArnsE returns the ARNs of the tasks of the given ECS service with the given desired status.
+func listEcsServiceTaskArnsE(t testing.TestingT, region string, clusterName string, serviceName string, status types.DesiredStatus) ([]string, error) {
+	client, err := NewEcsClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	taskArns := []string{}
+	paginator := ecs.NewListTasksPaginator(client, &ecs.ListTasksInput{
+		Cluster:       aws.String(clusterName),
+		ServiceName:   aws.String(serviceName),
+		DesiredStatus: status,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		taskArns = append(taskArns, page.TaskArns...)
+	}
+	return taskArns, nil
+}
+
+// newEcsStoppedTask returns why the given task stopped.
+func newEcsStoppedTask(task types.Task) EcsStoppedTask {
+	stoppedTask := EcsStoppedTask{
+		TaskArn:          aws.ToString(task.TaskArn),
+		StopCode:         string(task.StopCode),
+		StoppedReason:    aws.ToString(task.StoppedReason),
+		ContainerReasons: map[string]string{},
+	}
+	for _, container := range task.Containers {
+		reason := aws.ToString(container.Reason)
+		if container.ExitCode != nil {
+			reason = strings.TrimSpace(fmt.Sprintf("%s (exit code %d)", reason, *container.ExitCode))
+		}
+		stoppedTask.ContainerReasons[aws.ToString(container.Name)] = reason
+	}
+	return stoppedTask
+}
+
+// GetEcsServiceRunningTaskArns returns the ARNs of the running tasks of the given ECS service, e.g., to run commands in
+// them with RunEcsExecCommand.
+func GetEcsServiceRunningTaskArns(t testing.TestingT, region string, clusterName string, serviceName string) []string {
+	taskArns, err := GetEcsServiceRunningTaskArnsE(t, region, clusterName, serviceName)
+	require.NoError(t, err)
+	return taskArns
+}
+
+// GetEcsServiceRunningTaskArnsE returns the ARNs of the running tasks of the given ECS service, e.g., to run commands in
+// them with RunEcsExecCommandE.
+func GetEcsServiceRunningTaskArnsE(t testing.TestingT, region string, clusterName string, serviceName string) ([]string, error) {
+	return listEcsServiceTaskArnsE(t, region, clusterName, serviceName, types.DesiredStatusRunning)
+}
+
+// RunEcsExecCommand runs the given command in the given container of a running ECS task through ECS Exec, and returns
+// its output. This will fail the test if there is an error.
+func RunEcsExecCommand(t testing.TestingT, region string, clusterName string, taskArn string, containerName string, command string) string {
+	out, err := RunEcsExecCommandE(t, region, clusterName, taskArn, containerName, command)
+	require.NoError(t, err)
+	return out
+}
+
+// RunEcsExecCommandE runs the given command in the given container of a running ECS task through ECS Exec, and returns
+// its output. This works for both EC2 and Fargate launch types, but requires the service or task to have been started
+// with enableExecuteCommand, the task role to allow the ssmmessages actions, and, as for the AWS CLI, the
+// session-manager-plugin binary to be installed on the test runner.
+func RunEcsExecCommandE(t testing.TestingT, region string, clusterName string, taskArn string, containerName string, command string) (string, error) {
+	client, err := NewEcsClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	tasks, err := client.DescribeTasks(context.Background(), &ecs.DescribeTasksInput{
+		Cluster: aws.String(clusterName),
+		Tasks:   []string{taskArn},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(tasks.Tasks) != 1 {
+		return "", fmt.Errorf("expected to find 1 ECS task %s in cluster %s, but found %d", taskArn, clusterName, len(tasks.Tasks))
+	}
+	target, err := ecsExecTarget(clusterName, tasks.Tasks[0], containerName)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.ExecuteCommand(context.Background(), &ecs.ExecuteCommandInput{
+		Cluster:     aws.String(clusterName),
+		Task:        aws.String(taskArn),
+		Container:   aws.String(containerName),
+		Command:     aws.String(command),
+		Interactive: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Run the session the same way the AWS CLI does. Don't let the shell module log the command line, as it contains
+	// the session token.
+	logger.Default.Logf(t, "Running %s in container %s of ECS task %s through ECS Exec", command, containerName, taskArn)
+	session, err := json.Marshal(output.Session)
+	if err != nil {
+		return "", err
+	}
+	parameters, err := json.Marshal(map[string]string{"Target": target})
+	if err != nil {
+		return "", err
+	}
+	return shell.RunCommandAndGetStdOutE(t, shell.Command{
+		Command: "session-manager-plugin",
+		Args: []string{
+			string(session),
+			region,
+			"StartSession",
+			"",
+			string(parameters),
+			GetServiceEndpoint("ssm", region),
+		},
+		Logger: logger.Discard,
+	})
+}
+
+// ecsExecTarget returns the SSM target of the given container of the given task, in the format
+// ecs:<cluster>_<task id>_<container runtime id>.
+func ecsExecTarget(clusterName string, task types.Task, containerName string) (string, error) {
+	// The cluster and task may be given as ARNs, e.g., arn:aws:ecs:us-east-1:123456789012:cluster/name
+	clusterName = clusterName[strings.LastIndex(clusterName, "/")+1:]
+	taskArn := aws.ToString(task.TaskArn)
+	taskID := taskArn[strings.LastIndex(taskArn, "/")+1:]
+	for _, container := range task.Containers {
+		if aws.ToString(container.Name) == containerName {
+			if aws.ToString(container.RuntimeId) == "" {
+				return "", fmt.Errorf("container %s of ECS task %s is not running", containerName, taskArn)
+			}
+			return fmt.Sprintf("ecs:%s_%s_%s", clusterName, taskID, aws.ToString(container.RuntimeId)), nil
+		}
+	}
+	return "", fmt.Errorf("ECS task %s has no container named %s", taskArn, containerName)
+}
@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEcsServiceStable(t *testing.T) {
+	t.Parallel()
+
+	stable := types.Service{
+		Status:       aws.String("ACTIVE"),
+		DesiredCount: 2,
+		RunningCount: 2,
+		Deployments:  []types.Deployment{{RolloutState: types.DeploymentRolloutStateCompleted}},
+	}
+	assert.True(t, IsEcsServiceStable(&stable))
+
+	scaling := stable
+	scaling.RunningCount = 1
+	assert.False(t, IsEcsServiceStable(&scaling))
+
+	deploying := stable
+	deploying.Deployments = []types.Deployment{{RolloutState: types.DeploymentRolloutStateInProgress}, {}}
+	assert.False(t, IsEcsServiceStable(&deploying))
+
+	inProgress := stable
+	inProgress.Deployments = []types.Deployment{{RolloutState: types.DeploymentRolloutStateInProgress}}
+	assert.False(t, IsEcsServiceStable(&inProgress))
+
+	// Services using an external or CodeDeploy deployment controller have no rollout state
+	external := stable
+	external.Deployments = []types.Deployment{{}}
+	assert.True(t, IsEcsServiceStable(&external))
+}
+
+func TestNewEcsStoppedTask(t *testing.T) {
+	t.Parallel()
+
+	task := types.Task{
+		TaskArn:       aws.String("arn:aws:ecs:us-east-1:123456789012:task/my-cluster/abc"),
+		StopCode:      types.TaskStopCodeEssentialContainerExited,
+		StoppedReason: aws.String("Essential container in task exited"),
+		Containers: []types.Container{
+			{Name: aws.String("app"), ExitCode: aws.Int32(137), Reason: aws.String("OutOfMemoryError: Container killed due to memory usage")},
+			{Name: aws.String("sidecar"), ExitCode: aws.Int32(0)},
+		},
+	}
+
+	assert.Equal(t, EcsStoppedTask{
+		TaskArn:       "arn:aws:ecs:us-east-1:123456789012:task/my-cluster/abc",
+		StopCode:      "EssentialContainerExited",
+		StoppedReason: "Essential container in task exited",
+		ContainerReasons: map[string]string{
+			"app":     "OutOfMemoryError: Container killed due to memory usage (exit code 137)",
+			"sidecar": "(exit code 0)",
+		},
+	}, newEcsStoppedTask(task))
+}
+
+func TestEcsExecTarget(t *testing.T) {
+	t.Parallel()
+
+	task := types.Task{
+		TaskArn:    aws.String("arn:aws:ecs:us-east-1:123456789012:task/my-cluster/abc"),
+		Containers: []types.Container{{Name: aws.String("app"), RuntimeId: aws.String("abc-123")}},
+	}
+
+	target, err := ecsExecTarget("arn:aws:ecs:us-east-1:123456789012:cluster/my-cluster", task, "app")
+	require.NoError(t, err)
+	assert.Equal(t, "ecs:my-cluster_abc_abc-123", target)
+
+	_, err = ecsExecTarget("my-cluster", task, "other")
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// eksClusterIDHeader is the header that binds an EKS token to a cluster.
+	eksClusterIDHeader = "x-k8s-aws-id"
+
+	// eksTokenPrefix is the prefix of the EKS tokens, followed by the base64 encoded presigned URL.
+	eksTokenPrefix = "k8s-aws-v1."
+
+	// EksTokenLifetime is how long an EKS token returned by GetEksTokenE is accepted by the cluster.
+	EksTokenLifetime = 15 * time.Minute
+)
+
+// EksClusterAuth contains what a Kubernetes client needs to connect to an EKS cluster.
+type EksClusterAuth struct {
+	// The URL of the API server of the cluster.
+	Endpoint string
+
+	// The PEM encoded certificate authority of the API server.
+	CertificateAuthorityData []byte
+
+	// A bearer token for the AWS identity of the test, accepted by the cluster for EksTokenLifetime.
+	Token string
+}
+
+// GetEksCluster returns the EKS cluster with the given name.
+func GetEksCluster(t testing.TestingT, region string, clusterName string) *types.Cluster {
+	cluster, err := GetEksClusterE(t, region, clusterName)
+	require.NoError(t, err)
+	return cluster
+}
+
+// GetEksClusterE returns the EKS cluster with the given name.
+func GetEksClusterE(t testing.TestingT, region string, clusterName string) (*types.Cluster, error) {
+	client, err := NewEksClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.DescribeCluster(context.Background(), &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return nil, err
+	}
+	return output.Cluster, nil
+}
+
+// GetEksToken returns a bearer token to authenticate to the given EKS cluster as the AWS identity of the test.
+func GetEksToken(t testing.TestingT, region string, clusterName string) string {
+	token, err := GetEksTokenE(t, region, clusterName)
+	require.NoError(t, err)
+	return token
+}
+
+// GetEksTokenE returns a bearer token to authenticate to the given EKS cluster as the AWS identity of the test. This is
+// the same token as returned by `aws eks get-token`: a presigned STS GetCallerIdentity URL bound to the cluster name,
+// which the cluster accepts for EksTokenLifetime.
+func GetEksTokenE(t testing.TestingT, region string, clusterName string) (string, error) {
+	stsClient, err := NewStsClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := sts.NewPresignClient(stsClient).PresignGetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}, func(options *sts.PresignOptions) {
+		options.ClientOptions = append(options.ClientOptions, func(stsOptions *sts.Options) {
+			stsOptions.APIOptions = append(stsOptions.APIOptions,
+				smithyhttp.SetHeaderValue(eksClusterIDHeader, clusterName),
+				smithyhttp.SetHeaderValue("X-Amz-Expires", "60"),
+			)
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(request.URL)), nil
+}
+
+// GetEksClusterAuth returns the endpoint, certificate authority, and a token to connect to the given EKS cluster.
+func GetEksClusterAuth(t testing.TestingT, region string, clusterName string) EksClusterAuth {
+	auth, err := GetEksClusterAuthE(t, region, clusterName)
+	require.NoError(t, err)
+	return auth
+}
+
+// GetEksClusterAuthE returns the endpoint, certificate authority, and a token to connect to the given EKS cluster. See
+// k8s.NewKubectlOptionsForEksClusterE to use it with the helpers of the k8s module.
+func GetEksClusterAuthE(t testing.TestingT, region string, clusterName string) (EksClusterAuth, error) {
+	cluster, err := GetEksClusterE(t, region, clusterName)
+	if err != nil {
+		return EksClusterAuth{}, err
+	}
+
+	auth := EksClusterAuth{Endpoint: aws.ToString(cluster.Endpoint)}
+	if cluster.CertificateAuthority != nil && cluster.CertificateAuthority.Data != nil {
+		auth.CertificateAuthorityData, err = base64.StdEncoding.DecodeString(aws.ToString(cluster.CertificateAuthority.Data))
+		if err != nil {
+			return EksClusterAuth{}, err
+		}
+	}
+
+	auth.Token, err = GetEksTokenE(t, region, clusterName)
+	if err != nil {
+		return EksClusterAuth{}, err
+	}
+	return auth, nil
+}
+
+// NewEksClient creates a new EKS client.
+func NewEksClient(t testing.TestingT, region string) *eks.Client {
+	client, err := NewEksClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewEksClientE creates a new EKS client.
+func NewEksClientE(t testing.TestingT, region string) (*eks.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return eks.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,279 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ElastiCacheReplicationGroup is a Redis or Valkey replication group. Endpoints are in host:port form.
+type ElastiCacheReplicationGroup struct {
+	Id                       string // The ID of the replication group
+	Engine                   string // The engine of the replication group, e.g., redis or valkey
+	ClusterModeEnabled       bool   // Whether the data is partitioned across shards
+	ConfigurationEndpoint    string // The endpoint to use with cluster mode enabled
+	PrimaryEndpoint          string // The endpoint of the primary node, with cluster mode disabled
+	ReaderEndpoint           string // The endpoint load balancing across the replicas, with cluster mode disabled
+	TransitEncryptionEnabled bool   // Whether connections require TLS
+	AuthTokenEnabled         bool   // Whether connections require an AUTH token
+	AtRestEncryptionEnabled  bool   // Whether the data is encrypted at rest
+}
+
+// Endpoint returns the endpoint to send commands to: the configuration endpoint with cluster mode enabled, and the
+// primary endpoint otherwise.
+func (group ElastiCacheReplicationGroup) Endpoint() string {
+	if group.ClusterModeEnabled {
+		return group.ConfigurationEndpoint
+	}
+	return group.PrimaryEndpoint
+}
+
+// ElastiCacheConnectionOptions are the options of a connection to a Redis or Valkey node.
+type ElastiCacheConnectionOptions struct {
+	Username      string        // The user to authenticate as, with RBAC. Leave empty to authenticate with an AUTH token only.
+	AuthToken     string        // The AUTH token or the password of the user, if any
+	TLS           bool          // Whether to connect with TLS, required if transit encryption is enabled
+	TLSServerName string        // The host name to verify the certificate against, e.g., the endpoint when connecting through a tunnel
+	Timeout       time.Duration // The timeout of the connection and of each command. Defaults to 10 seconds.
+}
+
+// ElastiCacheCommandError is an error returned by a Redis or Valkey node in response to a command.
+type ElastiCacheCommandError struct {
+	Address string
+	Message string
+}
+
+func (err ElastiCacheCommandError) Error() string {
+	return fmt.Sprintf("%s returned an error: %s", err.Address, err.Message)
+}
+
+// GetElastiCacheReplicationGroup returns the replication group with the given ID.
+func GetElastiCacheReplicationGroup(t testing.TestingT, region string, replicationGroupID string) ElastiCacheReplicationGroup {
+	group, err := GetElastiCacheReplicationGroupE(t, region, replicationGroupID)
+	require.NoError(t, err)
+	return group
+}
+
+// GetElastiCacheReplicationGroupE returns the replication group with the given ID.
+func GetElastiCacheReplicationGroupE(t testing.TestingT, region string, replicationGroupID string) (ElastiCacheReplicationGroup, error) {
+	client, err := NewElastiCacheClientE(t, region)
+	if err != nil {
+		return ElastiCacheReplicationGroup{}, err
+	}
+
+	output, err := client.DescribeReplicationGroups(context.Background(), &elasticache.DescribeReplicationGroupsInput{
+		ReplicationGroupId: aws.String(replicationGroupID),
+	})
+	if err != nil {
+		return ElastiCacheReplicationGroup{}, err
+	}
+	if len(output.ReplicationGroups) == 0 {
+		return ElastiCacheReplicationGroup{}, NewNotFoundError("ElastiCache replication group", replicationGroupID, region)
+	}
+	return newElastiCacheReplicationGroup(output.ReplicationGroups[0]), nil
+}
+
+// AssertElastiCacheTransitEncryptionEnabled checks that the given replication group requires TLS.
+func AssertElastiCacheTransitEncryptionEnabled(t testing.TestingT, region string, replicationGroupID string) {
+	group, err := GetElastiCacheReplicationGroupE(t, region, replicationGroupID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, group.TransitEncryptionEnabled, "Transit encryption is not enabled on ElastiCache replication group %s", replicationGroupID)
+	}
+}
+
+// AssertElastiCacheAuthTokenEnabled checks that the given replication group requires an AUTH token.
+func AssertElastiCacheAuthTokenEnabled(t testing.TestingT, region string, replicationGroupID string) {
+	group, err := GetElastiCacheReplicationGroupE(t, region, replicationGroupID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, group.AuthTokenEnabled, "AUTH is not enabled on ElastiCache replication group %s", replicationGroupID)
+	}
+}
+
+// AssertElastiCacheSetGetRoundTrip checks that a value written to the given Redis or Valkey endpoint can be read back.
+// See AssertElastiCacheSetGetRoundTripE.
+func AssertElastiCacheSetGetRoundTrip(t testing.TestingT, address string, options ElastiCacheConnectionOptions) {
+	err := AssertElastiCacheSetGetRoundTripE(t, address, options)
+	require.NoError(t, err)
+}
+
+// AssertElastiCacheSetGetRoundTripE writes a random value to a random key of the given Redis or Valkey endpoint
+// (host:port), reads it back, and deletes it. With cluster mode enabled, MOVED redirections to the node owning the key
+// are followed. The endpoints are only reachable from within their VPC: to run this from outside, open a tunnel with
+// StartSsmPortForwardingSession or an SSH port forward through a bastion, pass localhost:<local port> as the address,
+// and set TLSServerName to the endpoint host so that its certificate can be verified. Redirections can't be followed
+// through a tunnel, so tunnel to a cluster mode disabled endpoint or to the node owning the key.
+func AssertElastiCacheSetGetRoundTripE(t testing.TestingT, address string, options ElastiCacheConnectionOptions) error {
+	key := "terratest-" + random.UniqueId()
+	value := random.UniqueId()
+
+	if _, err := elastiCacheCommandE(address, options, "SET", key, value, "EX", "300"); err != nil {
+		return err
+	}
+	actual, err := elastiCacheCommandE(address, options, "GET", key)
+	if err != nil {
+		return err
+	}
+	if _, err := elastiCacheCommandE(address, options, "DEL", key); err != nil {
+		return err
+	}
+
+	if actual != value {
+		return fmt.Errorf("read %q back from key %s of %s, but wrote %q", actual, key, address, value)
+	}
+	logger.Default.Logf(t, "Wrote and read back key %s of %s", key, address)
+	return nil
+}
+
+// elastiCacheCommandE runs the given command on a new connection to the given address, authenticating first if
+// needed, and returns its reply. A MOVED redirection is followed once.
+func elastiCacheCommandE(address string, options ElastiCacheConnectionOptions, args ...string) (string, error) {
+	reply, err := elastiCacheCommandOnceE(address, options, args...)
+	if commandErr, ok := err.(ElastiCacheCommandError); ok && strings.HasPrefix(commandErr.Message, "MOVED ") {
+		fields := strings.Fields(commandErr.Message)
+		return elastiCacheCommandOnceE(fields[len(fields)-1], options, args...)
+	}
+	return reply, err
+}
+
+// elastiCacheCommandOnceE runs the given command on a new connection to the given address, authenticating first if
+// needed, and returns its reply.
+func elastiCacheCommandOnceE(address string, options ElastiCacheConnectionOptions, args ...string) (string, error) {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if options.TLS {
+		serverName := options.TLSServerName
+		if serverName == "" {
+			serverName, _, _ = net.SplitHostPort(address)
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return "", err
+	}
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	if options.AuthToken != "" {
+		auth := []string{"AUTH", options.AuthToken}
+		if options.Username != "" {
+			auth = []string{"AUTH", options.Username, options.AuthToken}
+		}
+		if _, err := respCommandE(rw, address, auth...); err != nil {
+			return "", err
+		}
+	}
+	return respCommandE(rw, address, args...)
+}
+
+// respCommandE sends the given command in the Redis serialization protocol (RESP) and reads its reply. Simple
+// strings, integers, and bulk strings are returned as strings, with a nil bulk string returned as the empty string.
+func respCommandE(rw *bufio.ReadWriter, address string, args ...string) (string, error) {
+	fmt.Fprintf(rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := rw.Flush(); err != nil {
+		return "", err
+	}
+
+	line, err := rw.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply from %s", address)
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", ElastiCacheCommandError{Address: address, Message: line[1:]}
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", err
+		}
+		if length < 0 {
+			return "", nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(rw, data); err != nil {
+			return "", err
+		}
+		return string(data[:length]), nil
+	default:
+		return "", fmt.Errorf("unsupported reply from %s: %q", address, line)
+	}
+}
+
+// newElastiCacheReplicationGroup converts the given replication group.
+func newElastiCacheReplicationGroup(group types.ReplicationGroup) ElastiCacheReplicationGroup {
+	result := ElastiCacheReplicationGroup{
+		Id:                       aws.ToString(group.ReplicationGroupId),
+		Engine:                   aws.ToString(group.Engine),
+		ClusterModeEnabled:       aws.ToBool(group.ClusterEnabled),
+		ConfigurationEndpoint:    elastiCacheEndpointAddress(group.ConfigurationEndpoint),
+		TransitEncryptionEnabled: aws.ToBool(group.TransitEncryptionEnabled),
+		AuthTokenEnabled:         aws.ToBool(group.AuthTokenEnabled),
+		AtRestEncryptionEnabled:  aws.ToBool(group.AtRestEncryptionEnabled),
+	}
+	if !result.ClusterModeEnabled && len(group.NodeGroups) > 0 {
+		result.PrimaryEndpoint = elastiCacheEndpointAddress(group.NodeGroups[0].PrimaryEndpoint)
+		result.ReaderEndpoint = elastiCacheEndpointAddress(group.NodeGroups[0].ReaderEndpoint)
+	}
+	return result
+}
+
+// elastiCacheEndpointAddress returns the given endpoint in host:port form, or the empty string if it is nil.
+func elastiCacheEndpointAddress(endpoint *types.Endpoint) string {
+	if endpoint == nil {
+		return ""
+	}
+	return net.JoinHostPort(aws.ToString(endpoint.Address), strconv.Itoa(int(aws.ToInt32(endpoint.Port))))
+}
+
+// NewElastiCacheClient creates an ElastiCache client.
+func NewElastiCacheClient(t testing.TestingT, region string) *elasticache.Client {
+	client, err := NewElastiCacheClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewElastiCacheClientE creates an ElastiCache client.
+func NewElastiCacheClientE(t testing.TestingT, region string) (*elasticache.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return elasticache.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewElastiCacheReplicationGroup(t *testing.T) {
+	t.Parallel()
+
+	clusterModeDisabled := newElastiCacheReplicationGroup(types.ReplicationGroup{
+		ReplicationGroupId:       aws.String("cache"),
+		Engine:                   aws.String("valkey"),
+		ClusterEnabled:           aws.Bool(false),
+		TransitEncryptionEnabled: aws.Bool(true),
+		AuthTokenEnabled:         aws.Bool(true),
+		NodeGroups: []types.NodeGroup{{
+			PrimaryEndpoint: &types.Endpoint{Address: aws.String("master.cache.abc123.use1.cache.amazonaws.com"), Port: aws.Int32(6379)},
+			ReaderEndpoint:  &types.Endpoint{Address: aws.String("replica.cache.abc123.use1.cache.amazonaws.com"), Port: aws.Int32(6379)},
+		}},
+	})
+	assert.Equal(t, ElastiCacheReplicationGroup{
+		Id:                       "cache",
+		Engine:                   "valkey",
+		PrimaryEndpoint:          "master.cache.abc123.use1.cache.amazonaws.com:6379",
+		ReaderEndpoint:           "replica.cache.abc123.use1.cache.amazonaws.com:6379",
+		TransitEncryptionEnabled: true,
+		AuthTokenEnabled:         true,
+	}, clusterModeDisabled)
+	assert.Equal(t, "master.cache.abc123.use1.cache.amazonaws.com:6379", clusterModeDisabled.Endpoint())
+
+	clusterModeEnabled := newElastiCacheReplicationGroup(types.ReplicationGroup{
+		ReplicationGroupId:    aws.String("cluster"),
+		ClusterEnabled:        aws.Bool(true),
+		ConfigurationEndpoint: &types.Endpoint{Address: aws.String("clustercfg.cluster.abc123.use1.cache.amazonaws.com"), Port: aws.Int32(6379)},
+		NodeGroups:            []types.NodeGroup{{NodeGroupId: aws.String("0001")}, {NodeGroupId: aws.String("0002")}},
+	})
+	assert.Equal(t, "clustercfg.cluster.abc123.use1.cache.amazonaws.com:6379", clusterModeEnabled.Endpoint())
+	assert.Empty(t, clusterModeEnabled.PrimaryEndpoint)
+}
+
+func TestRespCommand(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		reader := bufio.NewReader(server)
+		replies := []string{"+OK\r\n", "$5\r\nhello\r\n", "$-1\r\n", ":1\r\n", "-WRONGPASS invalid username-password pair\r\n"}
+		for _, reply := range replies {
+			// Each command is an array of 1 to 3 bulk strings in these tests
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			for i := 0; i < 2*int(header[1]-'0'); i++ {
+				if _, err := reader.ReadString('\n'); err != nil {
+					return
+				}
+			}
+			if _, err := server.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+	}()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(client), bufio.NewWriter(client))
+
+	reply, err := respCommandE(rw, "cache:6379", "SET", "key", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+
+	reply, err = respCommandE(rw, "cache:6379", "GET", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", reply)
+
+	reply, err = respCommandE(rw, "cache:6379", "GET", "missing")
+	require.NoError(t, err)
+	assert.Empty(t, reply)
+
+	reply, err = respCommandE(rw, "cache:6379", "DEL", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "1", reply)
+
+	_, err = respCommandE(rw, "cache:6379", "AUTH", "wrong")
+	assert.Equal(t, ElastiCacheCommandError{Address: "cache:6379", Message: "WRONGPASS invalid username-password pair"}, err)
+}
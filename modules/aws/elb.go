@@ -0,0 +1,323 @@
+package aws
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TargetHealth is the health of a target registered in a target group.
+type TargetHealth struct {
+	Id          string // The ID of the target: an instance ID, an IP address, or a Lambda function ARN
+	Port        int32  // The port of the target
+	State       string // The health state of the target, e.g., healthy, unhealthy, or initial
+	Reason      string // The reason code of the state, if not healthy, e.g., Target.FailedHealthChecks
+	Description string // The description of the reason
+}
+
+// ListenerRule is a rule of a load balancer listener.
+type ListenerRule struct {
+	Arn        string                  // The ARN of the rule
+	Priority   string                  // The priority of the rule, or "default" for the default rule
+	IsDefault  bool                    // If the rule is the default rule of the listener
+	Conditions []ListenerRuleCondition // The conditions of the rule
+	Actions    []ListenerRuleAction    // The actions of the rule, sorted by order
+}
+
+// ListenerRuleCondition is a condition of a listener rule.
+type ListenerRuleCondition struct {
+	Field  string   // The field of the condition, e.g., host-header, path-pattern, or http-header
+	Values []string // The values of the condition. Those of http-header conditions are prefixed with "<header name>:", and those of query-string conditions are formatted as "key=value"
+}
+
+// ListenerRuleAction is an action of a listener rule.
+type ListenerRuleAction struct {
+	Type            string   // The type of the action, e.g., forward, redirect, or fixed-response
+	TargetGroupArns []string // The target groups of a forward action
+	StatusCode      string   // The status code of a redirect or fixed-response action, e.g., HTTP_301 or 503
+}
+
+// GetTargetHealth returns the health of the targets registered in the given target group.
+func GetTargetHealth(t testing.TestingT, region string, targetGroupArn string) []TargetHealth {
+	health, err := GetTargetHealthE(t, region, targetGroupArn)
+	require.NoError(t, err)
+	return health
+}
+
+// GetTargetHealthE returns the health of the targets registered in the given target group.
+func GetTargetHealthE(t testing.TestingT, region string, targetGroupArn string) ([]TargetHealth, error) {
+	client, err := NewElbV2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.DescribeTargetHealth(context.Background(), &elasticloadbalancingv2.DescribeTargetHealthInput{
+		TargetGroupArn: aws.String(targetGroupArn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	health := []TargetHealth{}
+	for _, description := range output.TargetHealthDescriptions {
+		health = append(health, newTargetHealth(description))
+	}
+	return health, nil
+}
+
+// newTargetHealth converts the given target health description.
+func newTargetHealth(description types.TargetHealthDescription) TargetHealth {
+	health := TargetHealth{}
+	if description.Target != nil {
+		health.Id = aws.ToString(description.Target.Id)
+		health.Port = aws.ToInt32(description.Target.Port)
+	}
+	if description.TargetHealth != nil {
+		health.State = string(description.TargetHealth.State)
+		health.Reason = string(description.TargetHealth.Reason)
+		health.Description = aws.ToString(description.TargetHealth.Description)
+	}
+	return health
+}
+
+// WaitForTargetGroupHealthy waits until all the targets of the given target group are healthy. See
+// WaitForTargetGroupHealthyE.
+func WaitForTargetGroupHealthy(t testing.TestingT, region string, targetGroupArn string, minTargets int, policy retry.Policy) []TargetHealth {
+	health, err := WaitForTargetGroupHealthyE(t, region, targetGroupArn, minTargets, policy)
+	require.NoError(t, err)
+	return health
+}
+
+// WaitForTargetGroupHealthyE waits until the given target group has at least minTargets registered targets and all of
+// them are healthy, retrying according to the given policy (DefaultWaitPolicy if nil), and returns their health.
+// Targets that are being deregistered (draining) are ignored.
+func WaitForTargetGroupHealthyE(t testing.TestingT, region string, targetGroupArn string, minTargets int, policy retry.Policy) ([]TargetHealth, error) {
+	return waitForE(t,
+		fmt.Sprintf("Waiting for the targets of target group %s to be healthy", targetGroupArn),
+		func() ([]TargetHealth, error) { return GetTargetHealthE(t, region, targetGroupArn) },
+		func(health []TargetHealth) bool { return areTargetsHealthy(health, minTargets) },
+		policy,
+	)
+}
+
+// areTargetsHealthy returns true if there are at least minTargets targets, not counting draining ones, and all of them
+// are healthy.
+func areTargetsHealthy(health []TargetHealth, minTargets int) bool {
+	count := 0
+	for _, target := range health {
+		switch target.State {
+		case string(types.TargetHealthStateEnumDraining):
+			continue
+		case string(types.TargetHealthStateEnumHealthy):
+			count++
+		default:
+			return false
+		}
+	}
+	return count >= minTargets
+}
+
+// GetListenerRules returns the rules of the given load balancer listener.
+func GetListenerRules(t testing.TestingT, region string, listenerArn string) []ListenerRule {
+	rules, err := GetListenerRulesE(t, region, listenerArn)
+	require.NoError(t, err)
+	return rules
+}
+
+// GetListenerRulesE returns the rules of the given load balancer listener, including the default rule, with their
+// conditions and actions, e.g., to check that requests for a host are forwarded to the right target group.
+func GetListenerRulesE(t testing.TestingT, region string, listenerArn string) ([]ListenerRule, error) {
+	client, err := NewElbV2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []ListenerRule{}
+	input := &elasticloadbalancingv2.DescribeRulesInput{ListenerArn: aws.String(listenerArn)}
+	for {
+		output, err := client.DescribeRules(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range output.Rules {
+			rules = append(rules, newListenerRule(rule))
+		}
+		if output.NextMarker == nil {
+			return rules, nil
+		}
+		input.Marker = output.NextMarker
+	}
+}
+
+// newListenerRule converts the given listener rule.
+func newListenerRule(rule types.Rule) ListenerRule {
+	result := ListenerRule{
+		Arn:        aws.ToString(rule.RuleArn),
+		Priority:   aws.ToString(rule.Priority),
+		IsDefault:  aws.ToBool(rule.IsDefault),
+		Conditions: []ListenerRuleCondition{},
+		Actions:    []ListenerRuleAction{},
+	}
+
+	for _, condition := range rule.Conditions {
+		result.Conditions = append(result.Conditions, newListenerRuleCondition(condition))
+	}
+
+	actions := make([]types.Action, len(rule.Actions))
+	copy(actions, rule.Actions)
+	// The order is optional for single actions
+	sort.SliceStable(actions, func(i, j int) bool { return aws.ToInt32(actions[i].Order) < aws.ToInt32(actions[j].Order) })
+	for _, action := range actions {
+		result.Actions = append(result.Actions, newListenerRuleAction(action))
+	}
+	return result
+}
+
+// newListenerRuleCondition converts the given rule condition, whose values are in a field that depends on its type.
+func newListenerRuleCondition(condition types.RuleCondition) ListenerRuleCondition {
+	result := ListenerRuleCondition{Field: aws.ToString(condition.Field), Values: []string{}}
+	switch {
+	case condition.HostHeaderConfig != nil:
+		result.Values = append(result.Values, condition.HostHeaderConfig.Values...)
+	case condition.PathPatternConfig != nil:
+		result.Values = append(result.Values, condition.PathPatternConfig.Values...)
+	case condition.HttpRequestMethodConfig != nil:
+		result.Values = append(result.Values, condition.HttpRequestMethodConfig.Values...)
+	case condition.SourceIpConfig != nil:
+		result.Values = append(result.Values, condition.SourceIpConfig.Values...)
+	case condition.HttpHeaderConfig != nil:
+		for _, value := range condition.HttpHeaderConfig.Values {
+			result.Values = append(result.Values, aws.ToString(condition.HttpHeaderConfig.HttpHeaderName)+":"+value)
+		}
+	case condition.QueryStringConfig != nil:
+		for _, pair := range condition.QueryStringConfig.Values {
+			result.Values = append(result.Values, aws.ToString(pair.Key)+"="+aws.ToString(pair.Value))
+		}
+	default:
+		result.Values = append(result.Values, condition.Values...)
+	}
+	return result
+}
+
+// newListenerRuleAction converts the given rule action.
+func newListenerRuleAction(action types.Action) ListenerRuleAction {
+	result := ListenerRuleAction{Type: string(action.Type), TargetGroupArns: []string{}}
+	if action.ForwardConfig != nil {
+		for _, targetGroup := range action.ForwardConfig.TargetGroups {
+			result.TargetGroupArns = append(result.TargetGroupArns, aws.ToString(targetGroup.TargetGroupArn))
+		}
+	} else if action.TargetGroupArn != nil {
+		result.TargetGroupArns = append(result.TargetGroupArns, aws.ToString(action.TargetGroupArn))
+	}
+	if action.RedirectConfig != nil {
+		result.StatusCode = string(action.RedirectConfig.StatusCode)
+	}
+	if action.FixedResponseConfig != nil {
+		result.StatusCode = aws.ToString(action.FixedResponseConfig.StatusCode)
+	}
+	return result
+}
+
+// GetLoadBalancerDnsName returns the DNS name of the given load balancer.
+func GetLoadBalancerDnsName(t testing.TestingT, region string, loadBalancerArn string) string {
+	dnsName, err := GetLoadBalancerDnsNameE(t, region, loadBalancerArn)
+	require.NoError(t, err)
+	return dnsName
+}
+
+// GetLoadBalancerDnsNameE returns the DNS name of the given load balancer.
+func GetLoadBalancerDnsNameE(t testing.TestingT, region string, loadBalancerArn string) (string, error) {
+	client, err := NewElbV2ClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.DescribeLoadBalancers(context.Background(), &elasticloadbalancingv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: []string{loadBalancerArn},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(output.LoadBalancers) != 1 {
+		return "", fmt.Errorf("expected to find 1 load balancer %s but found %d", loadBalancerArn, len(output.LoadBalancers))
+	}
+	return aws.ToString(output.LoadBalancers[0].DNSName), nil
+}
+
+// WaitForLoadBalancerResponse sends GET requests to the given path of the given load balancer until it responds with
+// the expected status and body. See WaitForLoadBalancerResponseE.
+func WaitForLoadBalancerResponse(t testing.TestingT, region string, loadBalancerArn string, path string, tlsConfig *tls.Config, expectedStatus int, expectedBody string, policy retry.Policy) string {
+	body, err := WaitForLoadBalancerResponseE(t, region, loadBalancerArn, path, tlsConfig, expectedStatus, expectedBody, policy)
+	require.NoError(t, err)
+	return body
+}
+
+// WaitForLoadBalancerResponseE sends GET requests to the given path (e.g., /health) of the given load balancer, over
+// HTTPS with the given TLS configuration, or over HTTP if it is nil, until it responds with the expected status and a
+// body containing the expected text, retrying according to the given policy (DefaultWaitPolicy if nil), and returns
+// the body. This accounts for the time it takes for the DNS name of a new load balancer to resolve and for its targets
+// to pass health checks. Note that the certificate of an HTTPS listener is usually not issued for the DNS name of the
+// load balancer, so the TLS configuration may need to skip verification.
+func WaitForLoadBalancerResponseE(t testing.TestingT, region string, loadBalancerArn string, path string, tlsConfig *tls.Config, expectedStatus int, expectedBody string, policy retry.Policy) (string, error) {
+	dnsName, err := GetLoadBalancerDnsNameE(t, region, loadBalancerArn)
+	if err != nil {
+		return "", err
+	}
+
+	url := loadBalancerURL(dnsName, path, tlsConfig != nil)
+	type response struct {
+		status int
+		body   string
+	}
+	result, err := waitForE(t,
+		fmt.Sprintf("Waiting for %s to respond with status %d", url, expectedStatus),
+		func() (response, error) {
+			status, body, err := http_helper.HttpGetE(t, url, tlsConfig)
+			return response{status: status, body: body}, err
+		},
+		func(result response) bool {
+			return result.status == expectedStatus && strings.Contains(result.body, expectedBody)
+		},
+		policy,
+	)
+	return result.body, err
+}
+
+// loadBalancerURL returns the URL of the given path of a load balancer with the given DNS name.
+func loadBalancerURL(dnsName string, path string, https bool) string {
+	scheme := "http"
+	if https {
+		scheme = "https"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, dnsName, path)
+}
+
+// NewElbV2Client creates an Elastic Load Balancing v2 (ALB, NLB, and GWLB) client.
+func NewElbV2Client(t testing.TestingT, region string) *elasticloadbalancingv2.Client {
+	client, err := NewElbV2ClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewElbV2ClientE creates an Elastic Load Balancing v2 (ALB, NLB, and GWLB) client.
+func NewElbV2ClientE(t testing.TestingT, region string) (*elasticloadbalancingv2.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return elasticloadbalancingv2.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAreTargetsHealthy(t *testing.T) {
+	t.Parallel()
+
+	healthy := TargetHealth{Id: "i-1", Port: 80, State: "healthy"}
+	draining := TargetHealth{Id: "i-2", Port: 80, State: "draining"}
+	initial := TargetHealth{Id: "i-3", Port: 80, State: "initial", Reason: "Elb.RegistrationInProgress"}
+
+	assert.True(t, areTargetsHealthy([]TargetHealth{healthy, draining}, 1))
+	assert.False(t, areTargetsHealthy([]TargetHealth{healthy, draining}, 2))
+	assert.False(t, areTargetsHealthy([]TargetHealth{healthy, initial}, 1))
+	assert.False(t, areTargetsHealthy(nil, 1))
+	assert.True(t, areTargetsHealthy(nil, 0))
+}
+
+func TestNewListenerRule(t *testing.T) {
+	t.Parallel()
+
+	rule := newListenerRule(types.Rule{
+		RuleArn:  aws.String("arn:rule"),
+		Priority: aws.String("10"),
+		Conditions: []types.RuleCondition{
+			{Field: aws.String("host-header"), HostHeaderConfig: &types.HostHeaderConditionConfig{Values: []string{"api.example.com"}}},
+			{Field: aws.String("http-header"), HttpHeaderConfig: &types.HttpHeaderConditionConfig{HttpHeaderName: aws.String("X-Env"), Values: []string{"test"}}},
+			{Field: aws.String("query-string"), QueryStringConfig: &types.QueryStringConditionConfig{Values: []types.QueryStringKeyValuePair{{Key: aws.String("v"), Value: aws.String("2")}}}},
+		},
+		Actions: []types.Action{
+			{
+				Type:  types.ActionTypeEnumForward,
+				Order: aws.Int32(2),
+				ForwardConfig: &types.ForwardActionConfig{TargetGroups: []types.TargetGroupTuple{
+					{TargetGroupArn: aws.String("arn:tg-blue"), Weight: aws.Int32(90)},
+					{TargetGroupArn: aws.String("arn:tg-green"), Weight: aws.Int32(10)},
+				}},
+			},
+			{Type: types.ActionTypeEnumAuthenticateOidc, Order: aws.Int32(1)},
+		},
+	})
+
+	assert.Equal(t, "10", rule.Priority)
+	assert.False(t, rule.IsDefault)
+	assert.Equal(t, []ListenerRuleCondition{
+		{Field: "host-header", Values: []string{"api.example.com"}},
+		{Field: "http-header", Values: []string{"X-Env:test"}},
+		{Field: "query-string", Values: []string{"v=2"}},
+	}, rule.Conditions)
+	assert.Equal(t, []ListenerRuleAction{
+		{Type: "authenticate-oidc", TargetGroupArns: []string{}},
+		{Type: "forward", TargetGroupArns: []string{"arn:tg-blue", "arn:tg-green"}},
+	}, rule.Actions)
+
+	defaultRule := newListenerRule(types.Rule{
+		Priority:  aws.String("default"),
+		IsDefault: aws.Bool(true),
+		Actions: []types.Action{
+			{Type: types.ActionTypeEnumFixedResponse, FixedResponseConfig: &types.FixedResponseActionConfig{StatusCode: aws.String("404")}},
+		},
+	})
+	assert.True(t, defaultRule.IsDefault)
+	assert.Empty(t, defaultRule.Conditions)
+	assert.Equal(t, []ListenerRuleAction{{Type: "fixed-response", TargetGroupArns: []string{}, StatusCode: "404"}}, defaultRule.Actions)
+}
+
+func TestLoadBalancerURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "http://lb-1.us-east-1.elb.amazonaws.com/health", loadBalancerURL("lb-1.us-east-1.elb.amazonaws.com", "health", false))
+	assert.Equal(t, "https://lb-1.us-east-1.elb.amazonaws.com/", loadBalancerURL("lb-1.us-east-1.elb.amazonaws.com", "/", true))
+}
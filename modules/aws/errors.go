@@ -2,6 +2,7 @@ package aws
 
 import (
 	"fmt"
+	"strings"
 )
 
 // IpForEc2InstanceNotFound is an error that occurs when the IP for an EC2 instance is not found.
@@ -129,3 +130,95 @@ func (err NoRdsInstanceTypeError) Error() string {
 		err.DatabaseEngineVersion,
 	)
 }
+
+// UnsupportedCleanupResourceType is returned when a resource of a type that CleanupRegisteredResources doesn't know how
+// to delete was registered for cleanup.
+type UnsupportedCleanupResourceType string
+
+func (err UnsupportedCleanupResourceType) Error() string {
+	return fmt.Sprintf("cleaning up resources of type %s is not supported", string(err))
+}
+
+// WaitConditionNotMet is returned by WaitForE when the condition it waits for is not met yet.
+type WaitConditionNotMet struct {
+	Description string
+}
+
+func (err WaitConditionNotMet) Error() string {
+	return fmt.Sprintf("condition not met yet: %s", err.Description)
+}
+
+// UnsupportedRdsEngine is returned when connecting to an RDS instance whose database engine has no supported driver.
+type UnsupportedRdsEngine string
+
+func (err UnsupportedRdsEngine) Error() string {
+	return fmt.Sprintf("connecting to RDS instances with engine %s is not supported", string(err))
+}
+
+// LogsInsightsQueryFailed is returned when a CloudWatch Logs Insights query fails, is cancelled, or times out.
+type LogsInsightsQueryFailed struct {
+	QueryID string
+	Status  string
+}
+
+func (err LogsInsightsQueryFailed) Error() string {
+	return fmt.Sprintf("Logs Insights query %s did not complete: %s", err.QueryID, err.Status)
+}
+
+// IamPolicySimulationMismatch is returned when the IAM policy simulator doesn't allow (or deny) the expected actions.
+type IamPolicySimulationMismatch struct {
+	Principal     string
+	ExpectAllowed bool
+	Mismatches    []string
+}
+
+func (err IamPolicySimulationMismatch) Error() string {
+	expected := "denied"
+	if err.ExpectAllowed {
+		expected = "allowed"
+	}
+	return fmt.Sprintf("expected the following actions to be %s for %s: %s", expected, err.Principal, strings.Join(err.Mismatches, "; "))
+}
+
+// Ec2MetadataRequestFailed is returned when fetching instance metadata on an EC2 instance fails, e.g., because the
+// metadata endpoint is disabled or the path doesn't exist.
+type Ec2MetadataRequestFailed struct {
+	InstanceID string
+	Path       string
+	ExitCode   int64
+	Stderr     string
+}
+
+func (err Ec2MetadataRequestFailed) Error() string {
+	return fmt.Sprintf("failed to fetch instance metadata %s on EC2 instance %s (exit code %d): %s", err.Path, err.InstanceID, err.ExitCode, err.Stderr)
+}
+
+// Ec2CapacityUnavailable is returned when none of the given instance types has capacity in any of the given subnets.
+type Ec2CapacityUnavailable struct {
+	Region        string
+	InstanceTypes []string
+	Underlying    error
+}
+
+func (err Ec2CapacityUnavailable) Error() string {
+	return fmt.Sprintf("no capacity for any of the instance types %v in %s: %v", err.InstanceTypes, err.Region, err.Underlying)
+}
+
+func (err Ec2CapacityUnavailable) Unwrap() error {
+	return err.Underlying
+}
+
+// SpotInstanceInterrupted is returned when AWS interrupts a spot instance a test depends on.
+type SpotInstanceInterrupted struct {
+	InstanceID string
+	Region     string
+	Attempts   int
+	Reason     string
+}
+
+func (err SpotInstanceInterrupted) Error() string {
+	if err.Attempts > 0 {
+		return fmt.Sprintf("spot instance %s in %s was interrupted, after %d attempts: %s", err.InstanceID, err.Region, err.Attempts, err.Reason)
+	}
+	return fmt.Sprintf("spot instance %s in %s was interrupted: %s", err.InstanceID, err.Region, err.Reason)
+}
@@ -0,0 +1,316 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// EventBridgeEvent is an event to put on an EventBridge event bus.
+type EventBridgeEvent struct {
+	Source     string   // The source of the event, e.g., com.mycompany.orders
+	DetailType string   // The type of the event, e.g., OrderCreated
+	Detail     string   // The JSON detail of the event. Defaults to {}.
+	Resources  []string // The ARNs of the resources the event is about, if any
+}
+
+// PutEventBridgeEvent puts the given event on the given event bus and returns the ID of the event.
+func PutEventBridgeEvent(t testing.TestingT, region string, busName string, event EventBridgeEvent) string {
+	eventID, err := PutEventBridgeEventE(t, region, busName, event)
+	require.NoError(t, err)
+	return eventID
+}
+
+// PutEventBridgeEventE puts the given event on the given event bus (default if empty) and returns the ID of the event.
+func PutEventBridgeEventE(t testing.TestingT, region string, busName string, event EventBridgeEvent) (string, error) {
+	client, err := NewEventBridgeClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.PutEvents(context.Background(), &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String(eventBusNameOrDefault(busName)),
+			Source:       aws.String(event.Source),
+			DetailType:   aws.String(event.DetailType),
+			Detail:       aws.String(eventBridgeDetailOrDefault(event.Detail)),
+			Resources:    event.Resources,
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(output.Entries) == 0 {
+		return "", fmt.Errorf("no result for the event put on event bus %s", eventBusNameOrDefault(busName))
+	}
+	if entry := output.Entries[0]; entry.ErrorCode != nil {
+		return "", fmt.Errorf("failed to put event on event bus %s: %s: %s", eventBusNameOrDefault(busName), aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+
+	eventID := aws.ToString(output.Entries[0].EventId)
+	logger.Default.Logf(t, "Put event %s on event bus %s", eventID, eventBusNameOrDefault(busName))
+	return eventID, nil
+}
+
+// EventBridgePatternMatches returns true if the given event pattern matches the given event.
+func EventBridgePatternMatches(t testing.TestingT, region string, pattern string, event EventBridgeEvent) bool {
+	matches, err := EventBridgePatternMatchesE(t, region, pattern, event)
+	require.NoError(t, err)
+	return matches
+}
+
+// EventBridgePatternMatchesE returns true if the given event pattern matches the given event, according to the
+// TestEventPattern API. The fields of the event that the API requires but that the given event doesn't set (id,
+// account, time, and region) are filled in with sample values.
+func EventBridgePatternMatchesE(t testing.TestingT, region string, pattern string, event EventBridgeEvent) (bool, error) {
+	client, err := NewEventBridgeClientE(t, region)
+	if err != nil {
+		return false, err
+	}
+	accountID, err := GetAccountIdE(t)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := client.TestEventPattern(context.Background(), &eventbridge.TestEventPatternInput{
+		EventPattern: aws.String(pattern),
+		Event:        aws.String(eventBridgeTestEvent(event, accountID, region, time.Now())),
+	})
+	if err != nil {
+		return false, err
+	}
+	return output.Result, nil
+}
+
+// GetEventBridgeRulePattern returns the event pattern of the given rule of the given event bus.
+func GetEventBridgeRulePattern(t testing.TestingT, region string, busName string, ruleName string) string {
+	pattern, err := GetEventBridgeRulePatternE(t, region, busName, ruleName)
+	require.NoError(t, err)
+	return pattern
+}
+
+// GetEventBridgeRulePatternE returns the event pattern of the given rule of the given event bus (default if empty).
+func GetEventBridgeRulePatternE(t testing.TestingT, region string, busName string, ruleName string) (string, error) {
+	rule, err := describeEventBridgeRuleE(t, region, busName, ruleName)
+	if err != nil {
+		return "", err
+	}
+	if rule.EventPattern == nil {
+		return "", fmt.Errorf("rule %s of event bus %s has no event pattern", ruleName, eventBusNameOrDefault(busName))
+	}
+	return aws.ToString(rule.EventPattern), nil
+}
+
+// AssertEventBridgeRuleMatches checks that the event pattern of the given rule matches the given event.
+func AssertEventBridgeRuleMatches(t testing.TestingT, region string, busName string, ruleName string, event EventBridgeEvent) {
+	matches, err := eventBridgeRuleMatchesE(t, region, busName, ruleName, event)
+	if assert.NoError(t, err) {
+		assert.Truef(t, matches, "Rule %s does not match event %+v", ruleName, event)
+	}
+}
+
+// AssertEventBridgeRuleDoesNotMatch checks that the event pattern of the given rule does not match the given event.
+func AssertEventBridgeRuleDoesNotMatch(t testing.TestingT, region string, busName string, ruleName string, event EventBridgeEvent) {
+	matches, err := eventBridgeRuleMatchesE(t, region, busName, ruleName, event)
+	if assert.NoError(t, err) {
+		assert.Falsef(t, matches, "Rule %s matches event %+v", ruleName, event)
+	}
+}
+
+// eventBridgeRuleMatchesE returns true if the event pattern of the given rule matches the given event.
+func eventBridgeRuleMatchesE(t testing.TestingT, region string, busName string, ruleName string, event EventBridgeEvent) (bool, error) {
+	pattern, err := GetEventBridgeRulePatternE(t, region, busName, ruleName)
+	if err != nil {
+		return false, err
+	}
+	return EventBridgePatternMatchesE(t, region, pattern, event)
+}
+
+// VerifyEventBridgeDelivery checks that the given event, put on the given event bus, is delivered by the given rule.
+// See VerifyEventBridgeDeliveryE.
+func VerifyEventBridgeDelivery(t testing.TestingT, region string, busName string, ruleName string, event EventBridgeEvent, timeout time.Duration) {
+	if err := VerifyEventBridgeDeliveryE(t, region, busName, ruleName, event, timeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// VerifyEventBridgeDeliveryE checks that the given event, put on the given event bus (default if empty), is delivered
+// by the given rule: it creates a temporary SQS queue, allows the rule to send messages to it, adds it as a target of
+// the rule, puts the event, and waits up to the given timeout for the queue to receive it. The target and queue are
+// deleted at the end, whether the delivery succeeded or not. Note that a rule with an input transformer applies it to
+// the temporary target too, so the event is recognized by its ID only if the transformed input includes it.
+func VerifyEventBridgeDeliveryE(t testing.TestingT, region string, busName string, ruleName string, event EventBridgeEvent, timeout time.Duration) error {
+	client, err := NewEventBridgeClientE(t, region)
+	if err != nil {
+		return err
+	}
+	sqsClient, err := NewSqsClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	rule, err := describeEventBridgeRuleE(t, region, busName, ruleName)
+	if err != nil {
+		return err
+	}
+	ruleArn := aws.ToString(rule.Arn)
+
+	queueURL, err := CreateRandomQueueE(t, region, "terratest-eventbridge")
+	if err != nil {
+		return err
+	}
+	defer DeleteQueueE(t, region, queueURL)
+
+	queueArn, err := GetQueueArnE(t, region, queueURL)
+	if err != nil {
+		return err
+	}
+	_, err = sqsClient.SetQueueAttributes(context.Background(), &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): sqsQueuePolicyForSource(queueArn, "events.amazonaws.com", ruleArn)},
+	})
+	if err != nil {
+		return err
+	}
+
+	targetID := "terratest-" + random.UniqueId()
+	logger.Default.Logf(t, "Adding queue %s as target %s of EventBridge rule %s", queueArn, targetID, ruleArn)
+	targets, err := client.PutTargets(context.Background(), &eventbridge.PutTargetsInput{
+		Rule:         aws.String(ruleName),
+		EventBusName: aws.String(eventBusNameOrDefault(busName)),
+		Targets:      []types.Target{{Id: aws.String(targetID), Arn: aws.String(queueArn)}},
+	})
+	if err != nil {
+		return err
+	}
+	if targets.FailedEntryCount > 0 {
+		return fmt.Errorf("failed to add queue %s as target of rule %s: %s", queueArn, ruleArn, aws.ToString(targets.FailedEntries[0].ErrorMessage))
+	}
+	defer client.RemoveTargets(context.Background(), &eventbridge.RemoveTargetsInput{
+		Rule:         aws.String(ruleName),
+		EventBusName: aws.String(eventBusNameOrDefault(busName)),
+		Ids:          []string{targetID},
+	})
+
+	eventID, err := PutEventBridgeEventE(t, region, busName, event)
+	if err != nil {
+		return err
+	}
+
+	sleepBetweenRetries := 5 * time.Second
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for the delivery of event %s by EventBridge rule %s to %s", eventID, ruleArn, queueArn),
+		func() ([]sqstypes.Message, error) {
+			result, err := sqsClient.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+				QueueUrl:            aws.String(queueURL),
+				MaxNumberOfMessages: maxSqsBatchSize,
+				WaitTimeSeconds:     1,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result.Messages, nil
+		},
+		func(messages []sqstypes.Message) bool {
+			for _, received := range messages {
+				if eventBridgeEventID(aws.ToString(received.Body)) == eventID {
+					return true
+				}
+			}
+			return false
+		},
+		fixedWaitPolicy(int(timeout/sleepBetweenRetries), sleepBetweenRetries),
+	)
+	return err
+}
+
+// describeEventBridgeRuleE returns the given rule of the given event bus (default if empty).
+func describeEventBridgeRuleE(t testing.TestingT, region string, busName string, ruleName string) (*eventbridge.DescribeRuleOutput, error) {
+	client, err := NewEventBridgeClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.DescribeRule(context.Background(), &eventbridge.DescribeRuleInput{
+		Name:         aws.String(ruleName),
+		EventBusName: aws.String(eventBusNameOrDefault(busName)),
+	})
+}
+
+// eventBridgeTestEvent returns the given event in the form the TestEventPattern API expects, with sample values for
+// the fields the event doesn't set.
+func eventBridgeTestEvent(event EventBridgeEvent, accountID string, region string, now time.Time) string {
+	resources := event.Resources
+	if resources == nil {
+		resources = []string{}
+	}
+	out, _ := json.Marshal(map[string]interface{}{
+		"id":          "00000000-0000-0000-0000-000000000000",
+		"version":     "0",
+		"account":     accountID,
+		"region":      region,
+		"time":        now.UTC().Format(time.RFC3339),
+		"source":      event.Source,
+		"detail-type": event.DetailType,
+		"resources":   resources,
+		"detail":      json.RawMessage(eventBridgeDetailOrDefault(event.Detail)),
+	})
+	return string(out)
+}
+
+// eventBridgeEventID returns the ID of the given event delivered by EventBridge, or the empty string if the message is
+// not an event.
+func eventBridgeEventID(body string) string {
+	var event struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal([]byte(body), &event) != nil {
+		return ""
+	}
+	return event.ID
+}
+
+// eventBridgeDetailOrDefault returns the given event detail, or an empty JSON object if it's empty.
+func eventBridgeDetailOrDefault(detail string) string {
+	if detail == "" {
+		return "{}"
+	}
+	return detail
+}
+
+// eventBusNameOrDefault returns the given event bus name, or the name of the default event bus if it's empty.
+func eventBusNameOrDefault(busName string) string {
+	if busName == "" {
+		return "default"
+	}
+	return busName
+}
+
+// NewEventBridgeClient creates an EventBridge client.
+func NewEventBridgeClient(t testing.TestingT, region string) *eventbridge.Client {
+	client, err := NewEventBridgeClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewEventBridgeClientE creates an EventBridge client.
+func NewEventBridgeClientE(t testing.TestingT, region string) (*eventbridge.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return eventbridge.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,38 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBridgeTestEvent(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	event := eventBridgeTestEvent(EventBridgeEvent{Source: "com.example.orders", DetailType: "OrderCreated", Detail: `{"total": 42}`}, "123456789012", "us-east-1", now)
+
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(event), &parsed))
+	assert.Equal(t, "com.example.orders", parsed["source"])
+	assert.Equal(t, "OrderCreated", parsed["detail-type"])
+	assert.Equal(t, "123456789012", parsed["account"])
+	assert.Equal(t, "us-east-1", parsed["region"])
+	assert.Equal(t, "2024-05-06T07:08:09Z", parsed["time"])
+	assert.Equal(t, []interface{}{}, parsed["resources"])
+	assert.Equal(t, map[string]interface{}{"total": float64(42)}, parsed["detail"])
+
+	event = eventBridgeTestEvent(EventBridgeEvent{Source: "com.example.orders", DetailType: "OrderCreated"}, "123456789012", "us-east-1", now)
+	require.NoError(t, json.Unmarshal([]byte(event), &parsed))
+	assert.Equal(t, map[string]interface{}{}, parsed["detail"])
+}
+
+func TestEventBridgeEventID(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "6a7e8feb-b491-4cf7-a9f1-bf3703467718", eventBridgeEventID(`{"version": "0", "id": "6a7e8feb-b491-4cf7-a9f1-bf3703467718", "source": "com.example.orders"}`))
+	assert.Empty(t, eventBridgeEventID("hello"))
+}
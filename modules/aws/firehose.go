@@ -0,0 +1,201 @@
+package aws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// FirehoseS3Destination is the S3 destination of a Firehose delivery stream.
+type FirehoseS3Destination struct {
+	Bucket string // The name of the destination bucket
+	Prefix string // The custom prefix of the delivered objects, if any. Objects go under YYYY/MM/DD/HH/ (UTC) otherwise.
+}
+
+// PutFirehoseRecord puts a record with the given data to the given Firehose delivery stream.
+func PutFirehoseRecord(t testing.TestingT, region string, deliveryStreamName string, data string) {
+	err := PutFirehoseRecordE(t, region, deliveryStreamName, data)
+	require.NoError(t, err)
+}
+
+// PutFirehoseRecordE puts a record with the given data to the given Firehose delivery stream. Firehose concatenates
+// records as they are, so add a trailing newline to the data to deliver newline-delimited records.
+func PutFirehoseRecordE(t testing.TestingT, region string, deliveryStreamName string, data string) error {
+	client, err := NewFirehoseClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutRecord(context.Background(), &firehose.PutRecordInput{
+		DeliveryStreamName: aws.String(deliveryStreamName),
+		Record:             &types.Record{Data: []byte(data)},
+	})
+	return err
+}
+
+// GetFirehoseS3Destination returns the S3 destination of the given Firehose delivery stream.
+func GetFirehoseS3Destination(t testing.TestingT, region string, deliveryStreamName string) FirehoseS3Destination {
+	destination, err := GetFirehoseS3DestinationE(t, region, deliveryStreamName)
+	require.NoError(t, err)
+	return destination
+}
+
+// GetFirehoseS3DestinationE returns the S3 destination of the given Firehose delivery stream.
+func GetFirehoseS3DestinationE(t testing.TestingT, region string, deliveryStreamName string) (FirehoseS3Destination, error) {
+	client, err := NewFirehoseClientE(t, region)
+	if err != nil {
+		return FirehoseS3Destination{}, err
+	}
+
+	output, err := client.DescribeDeliveryStream(context.Background(), &firehose.DescribeDeliveryStreamInput{
+		DeliveryStreamName: aws.String(deliveryStreamName),
+	})
+	if err != nil {
+		return FirehoseS3Destination{}, err
+	}
+
+	for _, destination := range output.DeliveryStreamDescription.Destinations {
+		if extended := destination.ExtendedS3DestinationDescription; extended != nil {
+			return FirehoseS3Destination{Bucket: bucketNameFromArn(aws.ToString(extended.BucketARN)), Prefix: aws.ToString(extended.Prefix)}, nil
+		}
+		if s3Destination := destination.S3DestinationDescription; s3Destination != nil {
+			return FirehoseS3Destination{Bucket: bucketNameFromArn(aws.ToString(s3Destination.BucketARN)), Prefix: aws.ToString(s3Destination.Prefix)}, nil
+		}
+	}
+	return FirehoseS3Destination{}, NewNotFoundError("S3 destination of Firehose delivery stream", deliveryStreamName, region)
+}
+
+// WaitForFirehoseDeliveryToS3 waits for an object containing the given text to be delivered to the S3 destination of
+// the given Firehose delivery stream. See WaitForFirehoseDeliveryToS3E.
+func WaitForFirehoseDeliveryToS3(t testing.TestingT, region string, deliveryStreamName string, expectedContent string, policy retry.Policy) string {
+	key, err := WaitForFirehoseDeliveryToS3E(t, region, deliveryStreamName, expectedContent, policy)
+	require.NoError(t, err)
+	return key
+}
+
+// WaitForFirehoseDeliveryToS3E waits for an object containing the given text to be delivered to the S3 destination of
+// the given Firehose delivery stream, retrying according to the given policy (DefaultWaitPolicy if nil), and returns
+// the key of that object. GZIP-compressed objects are decompressed. Objects are looked up under the static part of the
+// custom prefix of the destination, or under the default prefix of the current hour otherwise, so records delivered
+// around the top of the hour may be missed by the default prefix. Since Firehose buffers records for up to 15
+// minutes, use a policy matching the buffering hints of the delivery stream.
+func WaitForFirehoseDeliveryToS3E(t testing.TestingT, region string, deliveryStreamName string, expectedContent string, policy retry.Policy) (string, error) {
+	destination, err := GetFirehoseS3DestinationE(t, region, deliveryStreamName)
+	if err != nil {
+		return "", err
+	}
+	client, err := NewS3ClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	return waitForE(t,
+		fmt.Sprintf("Waiting for Firehose delivery stream %s to deliver %q to s3://%s", deliveryStreamName, expectedContent, destination.Bucket),
+		func() (string, error) {
+			prefix := firehoseS3ListPrefix(destination.Prefix, time.Now())
+			paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+				Bucket: aws.String(destination.Bucket),
+				Prefix: aws.String(prefix),
+			})
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(context.Background())
+				if err != nil {
+					return "", err
+				}
+				for _, object := range page.Contents {
+					contents, err := getFirehoseObjectContentsE(client, destination.Bucket, aws.ToString(object.Key))
+					if err != nil {
+						return "", err
+					}
+					if strings.Contains(contents, expectedContent) {
+						return aws.ToString(object.Key), nil
+					}
+				}
+			}
+			return "", nil
+		},
+		func(key string) bool { return key != "" },
+		policy,
+	)
+}
+
+// getFirehoseObjectContentsE returns the contents of the given object delivered by Firehose, decompressing it if it
+// is GZIP-compressed.
+func getFirehoseObjectContentsE(client *s3.Client, bucket string, key string) (string, error) {
+	output, err := client.GetObject(context.Background(), &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return "", err
+	}
+	defer output.Body.Close()
+
+	body, err := io.ReadAll(output.Body)
+	if err != nil {
+		return "", err
+	}
+	return decompressFirehoseObject(body)
+}
+
+// decompressFirehoseObject returns the given object contents, decompressed if they start with the GZIP magic number.
+func decompressFirehoseObject(body []byte) (string, error) {
+	if !bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		return string(body), nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(contents), nil
+}
+
+// firehoseS3ListPrefix returns the S3 prefix to list the objects delivered by Firehose with the given custom prefix at
+// the given time: the part of the custom prefix before its first expression (e.g., !{timestamp:yyyy}), or the default
+// YYYY/MM/DD/HH/ prefix in UTC if there is no custom prefix.
+func firehoseS3ListPrefix(customPrefix string, now time.Time) string {
+	if customPrefix == "" {
+		return now.UTC().Format("2006/01/02/15/")
+	}
+	if index := strings.Index(customPrefix, "!{"); index >= 0 {
+		return customPrefix[:index]
+	}
+	return customPrefix
+}
+
+// bucketNameFromArn returns the name of the S3 bucket with the given ARN, e.g., arn:aws:s3:::my-bucket.
+func bucketNameFromArn(bucketArn string) string {
+	return bucketArn[strings.LastIndex(bucketArn, ":")+1:]
+}
+
+// NewFirehoseClient creates a Firehose client.
+func NewFirehoseClient(t testing.TestingT, region string) *firehose.Client {
+	client, err := NewFirehoseClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewFirehoseClientE creates a Firehose client.
+func NewFirehoseClientE(t testing.TestingT, region string) (*firehose.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return firehose.NewFromConfig(*sess), nil
+}
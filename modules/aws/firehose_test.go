@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirehoseS3ListPrefix(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 4, 5, 6, 7, 0, time.FixedZone("UTC+2", 2*60*60))
+
+	assert.Equal(t, "2024/03/04/03/", firehoseS3ListPrefix("", now))
+	assert.Equal(t, "events/", firehoseS3ListPrefix("events/", now))
+	assert.Equal(t, "events/year=", firehoseS3ListPrefix("events/year=!{timestamp:yyyy}/", now))
+}
+
+func TestDecompressFirehoseObject(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(`{"id":1}`))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	contents, err := decompressFirehoseObject(compressed.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, contents)
+
+	contents, err = decompressFirehoseObject([]byte(`{"id":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":2}`, contents)
+}
+
+func TestBucketNameFromArn(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "my-bucket", bucketNameFromArn("arn:aws:s3:::my-bucket"))
+}
@@ -24,7 +24,7 @@ func GetIamCurrentUserName(t testing.TestingT) string {
 
 // GetIamCurrentUserNameE gets the username for the current IAM user.
 func GetIamCurrentUserNameE(t testing.TestingT) (string, error) {
-	iamClient, err := NewIamClientE(t, defaultRegion)
+	iamClient, err := NewIamClientE(t, getDefaultRegion())
 	if err != nil {
 		return "", err
 	}
@@ -48,7 +48,7 @@ func GetIamCurrentUserArn(t testing.TestingT) string {
 
 // GetIamCurrentUserArnE gets the ARN for the current IAM user.
 func GetIamCurrentUserArnE(t testing.TestingT) (string, error) {
-	iamClient, err := NewIamClientE(t, defaultRegion)
+	iamClient, err := NewIamClientE(t, getDefaultRegion())
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,175 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// SimulateIamPrincipalPolicy simulates the given actions on the given resources (all resources if empty) with the
+// policies of the given IAM user, group, or role, and returns the evaluation results. This will fail the test if
+// there is an error.
+func SimulateIamPrincipalPolicy(t testing.TestingT, region string, principalArn string, actions []string, resourceArns []string) []types.EvaluationResult {
+	results, err := SimulateIamPrincipalPolicyE(t, region, principalArn, actions, resourceArns)
+	require.NoError(t, err)
+	return results
+}
+
+// SimulateIamPrincipalPolicyE simulates the given actions on the given resources (all resources if empty) with the
+// policies of the given IAM user, group, or role, and returns the evaluation results. Note that resource policies,
+// such as S3 bucket policies, are not taken into account.
+func SimulateIamPrincipalPolicyE(t testing.TestingT, region string, principalArn string, actions []string, resourceArns []string) ([]types.EvaluationResult, error) {
+	iamClient, err := NewIamClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []types.EvaluationResult{}
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(iamClient, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalArn),
+		ActionNames:     actions,
+		ResourceArns:    resourceArns,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page.EvaluationResults...)
+	}
+	return results, nil
+}
+
+// SimulateIamCustomPolicy simulates the given actions on the given resources (all resources if empty) with the given
+// policy (JSON) documents, and returns the evaluation results. This will fail the test if there is an error.
+func SimulateIamCustomPolicy(t testing.TestingT, region string, policyDocuments []string, actions []string, resourceArns []string) []types.EvaluationResult {
+	results, err := SimulateIamCustomPolicyE(t, region, policyDocuments, actions, resourceArns)
+	require.NoError(t, err)
+	return results
+}
+
+// SimulateIamCustomPolicyE simulates the given actions on the given resources (all resources if empty) with the given
+// policy (JSON) documents, e.g., ones rendered by Terraform, and returns the evaluation results.
+func SimulateIamCustomPolicyE(t testing.TestingT, region string, policyDocuments []string, actions []string, resourceArns []string) ([]types.EvaluationResult, error) {
+	iamClient, err := NewIamClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []types.EvaluationResult{}
+	paginator := iam.NewSimulateCustomPolicyPaginator(iamClient, &iam.SimulateCustomPolicyInput{
+		PolicyInputList: policyDocuments,
+		ActionNames:     actions,
+		ResourceArns:    resourceArns,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page.EvaluationResults...)
+	}
+	return results, nil
+}
+
+// AssertIamPolicyAllows checks that the policies of the given IAM user, group, or role allow all the given actions on
+// all the given resources (all resources if empty), and fails the test if they don't.
+func AssertIamPolicyAllows(t testing.TestingT, region string, principalArn string, actions []string, resourceArns []string) {
+	err := AssertIamPolicyAllowsE(t, region, principalArn, actions, resourceArns)
+	require.NoError(t, err)
+}
+
+// AssertIamPolicyAllowsE checks that the policies of the given IAM user, group, or role allow all the given actions on
+// all the given resources (all resources if empty), using the IAM policy simulator, so without actually performing
+// them, and returns an error if they don't.
+func AssertIamPolicyAllowsE(t testing.TestingT, region string, principalArn string, actions []string, resourceArns []string) error {
+	results, err := SimulateIamPrincipalPolicyE(t, region, principalArn, actions, resourceArns)
+	if err != nil {
+		return err
+	}
+	return checkIamSimulationDecisions(principalArn, results, true)
+}
+
+// AssertIamPolicyDenies checks that the policies of the given IAM user, group, or role deny all the given actions on
+// all the given resources (all resources if empty), and fails the test if they don't.
+func AssertIamPolicyDenies(t testing.TestingT, region string, principalArn string, actions []string, resourceArns []string) {
+	err := AssertIamPolicyDeniesE(t, region, principalArn, actions, resourceArns)
+	require.NoError(t, err)
+}
+
+// AssertIamPolicyDeniesE checks that the policies of the given IAM user, group, or role deny, explicitly or
+// implicitly, all the given actions on all the given resources (all resources if empty), using the IAM policy
+// simulator, so without actually attempting them, and returns an error if they don't.
+func AssertIamPolicyDeniesE(t testing.TestingT, region string, principalArn string, actions []string, resourceArns []string) error {
+	results, err := SimulateIamPrincipalPolicyE(t, region, principalArn, actions, resourceArns)
+	if err != nil {
+		return err
+	}
+	return checkIamSimulationDecisions(principalArn, results, false)
+}
+
+// AssertIamCustomPolicyAllows checks that the given policy (JSON) documents allow all the given actions on all the
+// given resources (all resources if empty), and fails the test if they don't.
+func AssertIamCustomPolicyAllows(t testing.TestingT, region string, policyDocuments []string, actions []string, resourceArns []string) {
+	err := AssertIamCustomPolicyAllowsE(t, region, policyDocuments, actions, resourceArns)
+	require.NoError(t, err)
+}
+
+// AssertIamCustomPolicyAllowsE checks that the given policy (JSON) documents allow all the given actions on all the
+// given resources (all resources if empty), and returns an error if they don't.
+func AssertIamCustomPolicyAllowsE(t testing.TestingT, region string, policyDocuments []string, actions []string, resourceArns []string) error {
+	results, err := SimulateIamCustomPolicyE(t, region, policyDocuments, actions, resourceArns)
+	if err != nil {
+		return err
+	}
+	return checkIamSimulationDecisions("custom policy", results, true)
+}
+
+// AssertIamCustomPolicyDenies checks that the given policy (JSON) documents deny all the given actions on all the
+// given resources (all resources if empty), and fails the test if they don't.
+func AssertIamCustomPolicyDenies(t testing.TestingT, region string, policyDocuments []string, actions []string, resourceArns []string) {
+	err := AssertIamCustomPolicyDeniesE(t, region, policyDocuments, actions, resourceArns)
+	require.NoError(t, err)
+}
+
+// AssertIamCustomPolicyDeniesE checks that the given policy (JSON) documents deny, explicitly or implicitly, all the
+// given actions on all the given resources (all resources if empty), and returns an error if they don't.
+func AssertIamCustomPolicyDeniesE(t testing.TestingT, region string, policyDocuments []string, actions []string, resourceArns []string) error {
+	results, err := SimulateIamCustomPolicyE(t, region, policyDocuments, actions, resourceArns)
+	if err != nil {
+		return err
+	}
+	return checkIamSimulationDecisions("custom policy", results, false)
+}
+
+// checkIamSimulationDecisions returns an IamPolicySimulationMismatch error listing the action and resource pairs of
+// the given evaluation results that are not allowed (or not denied, if expectAllowed is false).
+func checkIamSimulationDecisions(principal string, results []types.EvaluationResult, expectAllowed bool) error {
+	mismatches := []string{}
+	check := func(action string, resource string, decision types.PolicyEvaluationDecisionType) {
+		if (decision == types.PolicyEvaluationDecisionTypeAllowed) != expectAllowed {
+			mismatches = append(mismatches, fmt.Sprintf("%s on %s: %s", action, resource, decision))
+		}
+	}
+
+	for _, result := range results {
+		action := aws.ToString(result.EvalActionName)
+		if len(result.ResourceSpecificResults) == 0 {
+			check(action, aws.ToString(result.EvalResourceName), result.EvalDecision)
+			continue
+		}
+		for _, resourceResult := range result.ResourceSpecificResults {
+			check(action, aws.ToString(resourceResult.EvalResourceName), resourceResult.EvalResourceDecision)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return IamPolicySimulationMismatch{Principal: principal, ExpectAllowed: expectAllowed, Mismatches: mismatches}
+	}
+	return nil
+}
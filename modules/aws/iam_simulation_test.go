@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIamSimulationDecisions(t *testing.T) {
+	t.Parallel()
+
+	results := []types.EvaluationResult{
+		{
+			EvalActionName:   aws.String("s3:GetObject"),
+			EvalResourceName: aws.String("*"),
+			EvalDecision:     types.PolicyEvaluationDecisionTypeAllowed,
+		},
+		{
+			EvalActionName: aws.String("s3:PutObject"),
+			EvalDecision:   types.PolicyEvaluationDecisionTypeImplicitDeny,
+			ResourceSpecificResults: []types.ResourceSpecificResult{
+				{EvalResourceName: aws.String("arn:aws:s3:::allowed/*"), EvalResourceDecision: types.PolicyEvaluationDecisionTypeAllowed},
+				{EvalResourceName: aws.String("arn:aws:s3:::denied/*"), EvalResourceDecision: types.PolicyEvaluationDecisionTypeExplicitDeny},
+			},
+		},
+	}
+
+	err := checkIamSimulationDecisions("arn:aws:iam::123456789012:role/app", results, true)
+	require.Error(t, err)
+	assert.Equal(t, IamPolicySimulationMismatch{
+		Principal:     "arn:aws:iam::123456789012:role/app",
+		ExpectAllowed: true,
+		Mismatches:    []string{"s3:PutObject on arn:aws:s3:::denied/*: explicitDeny"},
+	}, err)
+
+	err = checkIamSimulationDecisions("arn:aws:iam::123456789012:role/app", results, false)
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"s3:GetObject on *: allowed",
+		"s3:PutObject on arn:aws:s3:::allowed/*: allowed",
+	}, err.(IamPolicySimulationMismatch).Mismatches)
+
+	assert.NoError(t, checkIamSimulationDecisions("custom policy", results[:1], true))
+	assert.NoError(t, checkIamSimulationDecisions("custom policy", nil, false))
+}
@@ -64,6 +64,7 @@ func ImportEC2KeyPairE(t testing.TestingT, region string, name string, keyPair *
 		return nil, err
 	}
 
+	RegisterForCleanup(t, CleanupEc2KeyPair, region, name)
 	return &Ec2Keypair{Name: name, Region: region, KeyPair: keyPair}, nil
 }
 
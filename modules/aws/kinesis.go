@@ -0,0 +1,233 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// KinesisRecord is a record of a Kinesis data stream.
+type KinesisRecord struct {
+	ShardId        string // The ID of the shard the record is in
+	SequenceNumber string // The sequence number of the record within its shard
+	PartitionKey   string // The partition key of the record
+	Data           string // The data of the record
+}
+
+// PutKinesisRecord puts a record with the given partition key and data to the given Kinesis stream.
+func PutKinesisRecord(t testing.TestingT, region string, streamName string, partitionKey string, data string) KinesisRecord {
+	record, err := PutKinesisRecordE(t, region, streamName, partitionKey, data)
+	require.NoError(t, err)
+	return record
+}
+
+// PutKinesisRecordE puts a record with the given partition key and data to the given Kinesis stream, and returns the
+// record with the shard and sequence number it was assigned.
+func PutKinesisRecordE(t testing.TestingT, region string, streamName string, partitionKey string, data string) (KinesisRecord, error) {
+	client, err := NewKinesisClientE(t, region)
+	if err != nil {
+		return KinesisRecord{}, err
+	}
+
+	output, err := client.PutRecord(context.Background(), &kinesis.PutRecordInput{
+		StreamName:   aws.String(streamName),
+		PartitionKey: aws.String(partitionKey),
+		Data:         []byte(data),
+	})
+	if err != nil {
+		return KinesisRecord{}, err
+	}
+
+	logger.Default.Logf(t, "Put record %s to shard %s of Kinesis stream %s", aws.ToString(output.SequenceNumber), aws.ToString(output.ShardId), streamName)
+	return KinesisRecord{
+		ShardId:        aws.ToString(output.ShardId),
+		SequenceNumber: aws.ToString(output.SequenceNumber),
+		PartitionKey:   partitionKey,
+		Data:           data,
+	}, nil
+}
+
+// GetKinesisStreamShardIds returns the IDs of the shards of the given Kinesis stream.
+func GetKinesisStreamShardIds(t testing.TestingT, region string, streamName string) []string {
+	shardIDs, err := GetKinesisStreamShardIdsE(t, region, streamName)
+	require.NoError(t, err)
+	return shardIDs
+}
+
+// GetKinesisStreamShardIdsE returns the IDs of the shards of the given Kinesis stream, including closed shards that
+// may still hold records.
+func GetKinesisStreamShardIdsE(t testing.TestingT, region string, streamName string) ([]string, error) {
+	client, err := NewKinesisClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	shardIDs := []string{}
+	input := &kinesis.ListShardsInput{StreamName: aws.String(streamName)}
+	for {
+		output, err := client.ListShards(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		for _, shard := range output.Shards {
+			shardIDs = append(shardIDs, aws.ToString(shard.ShardId))
+		}
+		if output.NextToken == nil {
+			return shardIDs, nil
+		}
+		// The stream name must not be set along with the next token
+		input = &kinesis.ListShardsInput{NextToken: output.NextToken}
+	}
+}
+
+// GetKinesisShardRecords reads the records of the given shard of a Kinesis stream. See GetKinesisShardRecordsE.
+func GetKinesisShardRecords(t testing.TestingT, region string, streamName string, shardID string, afterSequenceNumber string) []KinesisRecord {
+	records, err := GetKinesisShardRecordsE(t, region, streamName, shardID, afterSequenceNumber)
+	require.NoError(t, err)
+	return records
+}
+
+// GetKinesisShardRecordsE reads the records of the given shard of a Kinesis stream, following the shard iterators
+// until it reaches the tip of the shard. If afterSequenceNumber is empty, it reads from the oldest record of the shard
+// (TRIM_HORIZON). Otherwise, it reads the records after the one with that sequence number.
+func GetKinesisShardRecordsE(t testing.TestingT, region string, streamName string, shardID string, afterSequenceNumber string) ([]KinesisRecord, error) {
+	client, err := NewKinesisClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	iteratorInput := &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(streamName),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	}
+	if afterSequenceNumber != "" {
+		iteratorInput.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		iteratorInput.StartingSequenceNumber = aws.String(afterSequenceNumber)
+	}
+	iteratorOutput, err := client.GetShardIterator(context.Background(), iteratorInput)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []KinesisRecord{}
+	iterator := iteratorOutput.ShardIterator
+	for iterator != nil {
+		output, err := client.GetRecords(context.Background(), &kinesis.GetRecordsInput{ShardIterator: iterator})
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, newKinesisRecords(shardID, output.Records)...)
+
+		// Stop at the tip of an open shard. The next iterator of a closed shard is nil once it has been fully read.
+		if len(output.Records) == 0 && aws.ToInt64(output.MillisBehindLatest) == 0 {
+			break
+		}
+		iterator = output.NextShardIterator
+	}
+	return records, nil
+}
+
+// GetKinesisStreamRecords reads the records of all the shards of the given Kinesis stream. See
+// GetKinesisStreamRecordsE.
+func GetKinesisStreamRecords(t testing.TestingT, region string, streamName string) []KinesisRecord {
+	records, err := GetKinesisStreamRecordsE(t, region, streamName)
+	require.NoError(t, err)
+	return records
+}
+
+// GetKinesisStreamRecordsE reads the records of all the shards of the given Kinesis stream, from the oldest record of
+// each shard to its tip.
+func GetKinesisStreamRecordsE(t testing.TestingT, region string, streamName string) ([]KinesisRecord, error) {
+	shardIDs, err := GetKinesisStreamShardIdsE(t, region, streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []KinesisRecord{}
+	for _, shardID := range shardIDs {
+		shardRecords, err := GetKinesisShardRecordsE(t, region, streamName, shardID, "")
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, shardRecords...)
+	}
+	return records, nil
+}
+
+// WaitForKinesisRecord waits for a record containing the given data to be in the given Kinesis stream. See
+// WaitForKinesisRecordE.
+func WaitForKinesisRecord(t testing.TestingT, region string, streamName string, expectedData string, policy retry.Policy) KinesisRecord {
+	record, err := WaitForKinesisRecordE(t, region, streamName, expectedData, policy)
+	require.NoError(t, err)
+	return record
+}
+
+// WaitForKinesisRecordE waits for a record whose data contains the given text to be in the given Kinesis stream,
+// reading all its shards and retrying according to the given policy (DefaultWaitPolicy if nil), and returns the first
+// matching record. This is useful to check that a producer under test delivers to the stream.
+func WaitForKinesisRecordE(t testing.TestingT, region string, streamName string, expectedData string, policy retry.Policy) (KinesisRecord, error) {
+	records, err := waitForE(t,
+		fmt.Sprintf("Waiting for a record containing %q in Kinesis stream %s", expectedData, streamName),
+		func() ([]KinesisRecord, error) { return GetKinesisStreamRecordsE(t, region, streamName) },
+		func(records []KinesisRecord) bool {
+			_, found := findKinesisRecord(records, expectedData)
+			return found
+		},
+		policy,
+	)
+	if err != nil {
+		return KinesisRecord{}, err
+	}
+	record, _ := findKinesisRecord(records, expectedData)
+	return record, nil
+}
+
+// findKinesisRecord returns the first of the given records whose data contains the given text.
+func findKinesisRecord(records []KinesisRecord, expectedData string) (KinesisRecord, bool) {
+	for _, record := range records {
+		if strings.Contains(record.Data, expectedData) {
+			return record, true
+		}
+	}
+	return KinesisRecord{}, false
+}
+
+// newKinesisRecords converts the given records of the given shard.
+func newKinesisRecords(shardID string, records []types.Record) []KinesisRecord {
+	result := []KinesisRecord{}
+	for _, record := range records {
+		result = append(result, KinesisRecord{
+			ShardId:        shardID,
+			SequenceNumber: aws.ToString(record.SequenceNumber),
+			PartitionKey:   aws.ToString(record.PartitionKey),
+			Data:           string(record.Data),
+		})
+	}
+	return result
+}
+
+// NewKinesisClient creates a Kinesis client.
+func NewKinesisClient(t testing.TestingT, region string) *kinesis.Client {
+	client, err := NewKinesisClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewKinesisClientE creates a Kinesis client.
+func NewKinesisClientE(t testing.TestingT, region string) (*kinesis.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return kinesis.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,25 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindKinesisRecord(t *testing.T) {
+	t.Parallel()
+
+	records := newKinesisRecords("shardId-000000000000", []types.Record{
+		{SequenceNumber: aws.String("1"), PartitionKey: aws.String("a"), Data: []byte(`{"order":1}`)},
+		{SequenceNumber: aws.String("2"), PartitionKey: aws.String("b"), Data: []byte(`{"order":2}`)},
+	})
+
+	record, found := findKinesisRecord(records, `"order":2`)
+	assert.True(t, found)
+	assert.Equal(t, KinesisRecord{ShardId: "shardId-000000000000", SequenceNumber: "2", PartitionKey: "b", Data: `{"order":2}`}, record)
+
+	_, found = findKinesisRecord(records, `"order":3`)
+	assert.False(t, found)
+}
@@ -1,13 +1,38 @@
 package aws
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
 )
 
+// KmsKeyPolicy is a parsed KMS key policy.
+type KmsKeyPolicy struct {
+	Version   string
+	Statement []KmsKeyPolicyStatement
+}
+
+// KmsKeyPolicyStatement is a statement of a KMS key policy. Principals are indexed by type (e.g., AWS or Service), and
+// the "*" principal is stored as {"AWS": ["*"]}. Single values of Principal, Action, and Resource are stored as lists
+// of one value.
+type KmsKeyPolicyStatement struct {
+	Sid       string
+	Effect    string
+	Principal map[string][]string
+	Action    []string
+	Resource  []string
+	Condition map[string]map[string]interface{}
+}
+
 // GetCmkArn gets the ARN of a KMS Customer Master Key (CMK) in the given region with the given ID. The ID can be an alias, such
 // as "alias/my-cmk".
 func GetCmkArn(t testing.TestingT, region string, cmkID string) string {
@@ -37,6 +62,243 @@ func GetCmkArnE(t testing.TestingT, region string, cmkID string) (string, error)
 	return *result.KeyMetadata.Arn, nil
 }
 
+// AssertKmsEncryptDecryptRoundTrip checks that the current principal can encrypt random data with the given CMK and
+// decrypt it back. This will fail the test if it can't.
+func AssertKmsEncryptDecryptRoundTrip(t testing.TestingT, region string, cmkID string) {
+	if err := AssertKmsEncryptDecryptRoundTripE(t, region, cmkID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertKmsEncryptDecryptRoundTripE checks that the current principal can encrypt random data with the given CMK and
+// decrypt it back, which proves that the key policy and grants allow it to use the key, and returns an error if it
+// can't. The ID can be an alias, such as "alias/my-cmk".
+func AssertKmsEncryptDecryptRoundTripE(t testing.TestingT, region string, cmkID string) error {
+	kmsClient, err := NewKmsClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	plaintext := []byte("terratest-" + random.UniqueId())
+	encrypted, err := kmsClient.Encrypt(context.Background(), &kms.EncryptInput{
+		KeyId:     aws.String(cmkID),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := kmsClient.Decrypt(context.Background(), &kms.DecryptInput{
+		KeyId:          encrypted.KeyId,
+		CiphertextBlob: encrypted.CiphertextBlob,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(plaintext, decrypted.Plaintext) {
+		return fmt.Errorf("data decrypted with CMK %s does not match the data encrypted with it", cmkID)
+	}
+	return nil
+}
+
+// GetKmsKeyRotationEnabled returns true if automatic rotation is enabled for the given CMK.
+func GetKmsKeyRotationEnabled(t testing.TestingT, region string, cmkID string) bool {
+	enabled, err := GetKmsKeyRotationEnabledE(t, region, cmkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enabled
+}
+
+// GetKmsKeyRotationEnabledE returns true if automatic rotation is enabled for the given CMK. The ID can be an alias,
+// such as "alias/my-cmk".
+func GetKmsKeyRotationEnabledE(t testing.TestingT, region string, cmkID string) (bool, error) {
+	kmsClient, err := NewKmsClientE(t, region)
+	if err != nil {
+		return false, err
+	}
+
+	// GetKeyRotationStatus doesn't accept aliases
+	cmkArn, err := GetCmkArnE(t, region, cmkID)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := kmsClient.GetKeyRotationStatus(context.Background(), &kms.GetKeyRotationStatusInput{
+		KeyId: aws.String(cmkArn),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.KeyRotationEnabled, nil
+}
+
+// AssertKmsKeyRotationEnabled checks that automatic rotation is enabled for the given CMK.
+func AssertKmsKeyRotationEnabled(t testing.TestingT, region string, cmkID string) {
+	enabled, err := GetKmsKeyRotationEnabledE(t, region, cmkID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, enabled, "Automatic rotation is not enabled for CMK %s", cmkID)
+	}
+}
+
+// GetKmsKeyPolicy gets and parses the key policy of the given CMK.
+func GetKmsKeyPolicy(t testing.TestingT, region string, cmkID string) KmsKeyPolicy {
+	policy, err := GetKmsKeyPolicyE(t, region, cmkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy
+}
+
+// GetKmsKeyPolicyE gets and parses the key policy of the given CMK. The ID can be an alias, such as "alias/my-cmk".
+func GetKmsKeyPolicyE(t testing.TestingT, region string, cmkID string) (KmsKeyPolicy, error) {
+	kmsClient, err := NewKmsClientE(t, region)
+	if err != nil {
+		return KmsKeyPolicy{}, err
+	}
+
+	// GetKeyPolicy doesn't accept aliases
+	cmkArn, err := GetCmkArnE(t, region, cmkID)
+	if err != nil {
+		return KmsKeyPolicy{}, err
+	}
+
+	result, err := kmsClient.GetKeyPolicy(context.Background(), &kms.GetKeyPolicyInput{
+		KeyId:      aws.String(cmkArn),
+		PolicyName: aws.String("default"),
+	})
+	if err != nil {
+		return KmsKeyPolicy{}, err
+	}
+
+	return parseKmsKeyPolicy(aws.ToString(result.Policy))
+}
+
+// AssertKmsKeyPolicyAllows checks that a statement of the key policy of the given CMK allows the given principal (an
+// ARN, such as that of a role, or a service, such as logs.amazonaws.com) to perform the given action (e.g.,
+// kms:Decrypt). Statements with the "*" principal or wildcard actions (e.g., kms:* or kms:Decrypt*) match, but
+// conditions and explicit denies are not evaluated.
+func AssertKmsKeyPolicyAllows(t testing.TestingT, region string, cmkID string, principal string, action string) {
+	policy, err := GetKmsKeyPolicyE(t, region, cmkID)
+	if assert.NoError(t, err) {
+		assert.NotEmptyf(t, policy.FindStatements("Allow", principal, action), "Key policy of CMK %s does not allow %s to perform %s", cmkID, principal, action)
+	}
+}
+
+// AssertKmsKeyPolicyDenies checks that a statement of the key policy of the given CMK explicitly denies the given
+// principal to perform the given action. See AssertKmsKeyPolicyAllows.
+func AssertKmsKeyPolicyDenies(t testing.TestingT, region string, cmkID string, principal string, action string) {
+	policy, err := GetKmsKeyPolicyE(t, region, cmkID)
+	if assert.NoError(t, err) {
+		assert.NotEmptyf(t, policy.FindStatements("Deny", principal, action), "Key policy of CMK %s does not deny %s to perform %s", cmkID, principal, action)
+	}
+}
+
+// FindStatements returns the statements of the policy with the given effect (Allow or Deny) that apply to the given
+// principal and action, either explicitly or through wildcards.
+func (policy KmsKeyPolicy) FindStatements(effect string, principal string, action string) []KmsKeyPolicyStatement {
+	statements := []KmsKeyPolicyStatement{}
+	for _, statement := range policy.Statement {
+		if strings.EqualFold(statement.Effect, effect) && statement.hasPrincipal(principal) && statement.hasAction(action) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// hasPrincipal returns true if the statement applies to the given principal, of any type.
+func (statement KmsKeyPolicyStatement) hasPrincipal(principal string) bool {
+	for _, principals := range statement.Principal {
+		for _, candidate := range principals {
+			if candidate == "*" || candidate == principal {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasAction returns true if the statement applies to the given action. Actions are case insensitive.
+func (statement KmsKeyPolicyStatement) hasAction(action string) bool {
+	for _, pattern := range statement.Action {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(action)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON parses a key policy statement, whose principals, actions, and resources can be single values or lists.
+func (statement *KmsKeyPolicyStatement) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Sid       string
+		Effect    string
+		Principal json.RawMessage
+		Action    json.RawMessage
+		Resource  json.RawMessage
+		Condition map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	statement.Sid = raw.Sid
+	statement.Effect = raw.Effect
+	statement.Condition = raw.Condition
+	statement.Principal = map[string][]string{}
+
+	var err error
+	if statement.Action, err = stringOrStringList(raw.Action); err != nil {
+		return err
+	}
+	if statement.Resource, err = stringOrStringList(raw.Resource); err != nil {
+		return err
+	}
+
+	var anyone string
+	if json.Unmarshal(raw.Principal, &anyone) == nil {
+		statement.Principal["AWS"] = []string{anyone}
+		return nil
+	}
+	var principals map[string]json.RawMessage
+	if len(raw.Principal) > 0 {
+		if err := json.Unmarshal(raw.Principal, &principals); err != nil {
+			return err
+		}
+	}
+	for principalType, value := range principals {
+		if statement.Principal[principalType], err = stringOrStringList(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringOrStringList parses the given JSON value, which can be a string or a list of strings.
+func stringOrStringList(data json.RawMessage) ([]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var value string
+	if json.Unmarshal(data, &value) == nil {
+		return []string{value}, nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseKmsKeyPolicy parses the given key policy (JSON) document.
+func parseKmsKeyPolicy(document string) (KmsKeyPolicy, error) {
+	var policy KmsKeyPolicy
+	err := json.Unmarshal([]byte(document), &policy)
+	return policy, err
+}
+
 // NewKmsClient creates a KMS client.
 func NewKmsClient(t testing.TestingT, region string) *kms.Client {
 	client, err := NewKmsClientE(t, region)
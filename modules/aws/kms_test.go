@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKmsKeyPolicy = `{
+  "Version": "2012-10-17",
+  "Id": "key-default-1",
+  "Statement": [
+    {
+      "Sid": "Enable IAM User Permissions",
+      "Effect": "Allow",
+      "Principal": {"AWS": "arn:aws:iam::123456789012:root"},
+      "Action": "kms:*",
+      "Resource": "*"
+    },
+    {
+      "Sid": "Allow CloudWatch Logs",
+      "Effect": "Allow",
+      "Principal": {"Service": ["logs.us-east-1.amazonaws.com"]},
+      "Action": ["kms:Encrypt*", "kms:Decrypt*", "kms:GenerateDataKey*"],
+      "Resource": "*",
+      "Condition": {"ArnLike": {"kms:EncryptionContext:aws:logs:arn": "arn:aws:logs:us-east-1:123456789012:*"}}
+    },
+    {
+      "Effect": "Deny",
+      "Principal": "*",
+      "Action": "kms:ScheduleKeyDeletion",
+      "Resource": "*"
+    }
+  ]
+}`
+
+func TestParseKmsKeyPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := parseKmsKeyPolicy(testKmsKeyPolicy)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2012-10-17", policy.Version)
+	require.Len(t, policy.Statement, 3)
+	assert.Equal(t, KmsKeyPolicyStatement{
+		Sid:       "Enable IAM User Permissions",
+		Effect:    "Allow",
+		Principal: map[string][]string{"AWS": {"arn:aws:iam::123456789012:root"}},
+		Action:    []string{"kms:*"},
+		Resource:  []string{"*"},
+	}, policy.Statement[0])
+	assert.Equal(t, []string{"logs.us-east-1.amazonaws.com"}, policy.Statement[1].Principal["Service"])
+	assert.Equal(t, "arn:aws:logs:us-east-1:123456789012:*", policy.Statement[1].Condition["ArnLike"]["kms:EncryptionContext:aws:logs:arn"])
+	assert.Equal(t, map[string][]string{"AWS": {"*"}}, policy.Statement[2].Principal)
+
+	_, err = parseKmsKeyPolicy(`{"Statement": [{"Action": 1}]}`)
+	assert.Error(t, err)
+}
+
+func TestKmsKeyPolicyFindStatements(t *testing.T) {
+	t.Parallel()
+
+	policy, err := parseKmsKeyPolicy(testKmsKeyPolicy)
+	require.NoError(t, err)
+
+	assert.Len(t, policy.FindStatements("Allow", "arn:aws:iam::123456789012:root", "kms:CreateGrant"), 1)
+	assert.Len(t, policy.FindStatements("Allow", "logs.us-east-1.amazonaws.com", "kms:decrypt"), 1)
+	assert.Empty(t, policy.FindStatements("Allow", "logs.us-east-1.amazonaws.com", "kms:CreateGrant"))
+	assert.Empty(t, policy.FindStatements("Allow", "arn:aws:iam::123456789012:role/app", "kms:Decrypt"))
+	assert.Len(t, policy.FindStatements("Deny", "arn:aws:iam::123456789012:role/app", "kms:ScheduleKeyDeletion"), 1)
+}
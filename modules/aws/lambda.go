@@ -2,12 +2,18 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -98,7 +104,7 @@ func InvokeFunctionE(t testing.TestingT, region, functionName string, payload in
 	}
 
 	if out.FunctionError != nil {
-		return out.Payload, &FunctionError{Message: *out.FunctionError, StatusCode: out.StatusCode, Payload: out.Payload}
+		return out.Payload, newFunctionError(*out.FunctionError, out.StatusCode, out.Payload)
 	}
 
 	return out.Payload, nil
@@ -165,16 +171,262 @@ func InvokeFunctionWithParamsE(t testing.TestingT, region, functionName string,
 	return &lambdaOutput, nil
 }
 
+// FunctionError is returned when a lambda function is invoked successfully, but the function itself fails.
 type FunctionError struct {
+	// The kind of error, i.e., "Handled" or "Unhandled".
 	Message    string
 	StatusCode int32
 	Payload    []byte
+
+	// The error reported by the runtime in the payload, if it could be parsed.
+	ErrorType    string
+	ErrorMessage string
+	StackTrace   []string
 }
 
 func (err *FunctionError) Error() string {
 	return fmt.Sprintf("%q error with status code %d invoking lambda function: %q", err.Message, err.StatusCode, err.Payload)
 }
 
+// newFunctionError returns a FunctionError for the given function error and payload, parsing the error object that the
+// lambda runtimes return as payload (e.g., {"errorType": "...", "errorMessage": "...", "stackTrace": [...]}).
+func newFunctionError(functionError string, statusCode int32, payload []byte) *FunctionError {
+	err := &FunctionError{Message: functionError, StatusCode: statusCode, Payload: payload}
+
+	var runtimeError struct {
+		ErrorType    string          `json:"errorType"`
+		ErrorMessage string          `json:"errorMessage"`
+		StackTrace   json.RawMessage `json:"stackTrace"`
+	}
+	if json.Unmarshal(payload, &runtimeError) != nil {
+		return err
+	}
+	err.ErrorType = runtimeError.ErrorType
+	err.ErrorMessage = runtimeError.ErrorMessage
+	err.StackTrace = parseStackTrace(runtimeError.StackTrace)
+	return err
+}
+
+// parseStackTrace returns the lines of the given stack trace. Most runtimes (e.g., Node.js and Go) return a list of
+// strings, but Python returns a list of formatted lines, and older runtimes a list of [file, line, function, code]
+// lists.
+func parseStackTrace(stackTrace json.RawMessage) []string {
+	lines := []string{}
+	if json.Unmarshal(stackTrace, &lines) == nil {
+		for i := range lines {
+			lines[i] = strings.TrimRight(lines[i], "\n")
+		}
+		return lines
+	}
+
+	lines = []string{}
+	frames := [][]interface{}{}
+	if json.Unmarshal(stackTrace, &frames) == nil {
+		for _, frame := range frames {
+			parts := make([]string, 0, len(frame))
+			for _, part := range frame {
+				parts = append(parts, fmt.Sprint(part))
+			}
+			lines = append(lines, strings.Join(parts, " "))
+		}
+	}
+	return lines
+}
+
+// LambdaInvocation contains the result of a lambda function invoked by InvokeFunctionWithResponse().
+type LambdaInvocation struct {
+	// The ID of the invocation, which identifies its logs.
+	RequestID string
+
+	// The version of the function that was executed.
+	ExecutedVersion string
+
+	// The HTTP status code of the invocation.
+	StatusCode int32
+
+	// The raw response of the function, or the error object if the function failed.
+	Payload []byte
+
+	// The last 4 KB of the logs of the invocation.
+	LogTail string
+}
+
+// InvokeFunctionWithResponse invokes a lambda function synchronously with the given request, converted to JSON, and
+// unmarshals the JSON response of the function into the given response (a pointer, or nil to ignore the response).
+// This will fail the test if the invocation or the function fails.
+func InvokeFunctionWithResponse(t testing.TestingT, region, functionName string, request interface{}, response interface{}) *LambdaInvocation {
+	out, err := InvokeFunctionWithResponseE(t, region, functionName, request, response)
+	require.NoError(t, err)
+	return out
+}
+
+// InvokeFunctionWithResponseE invokes a lambda function synchronously with the given request, converted to JSON, and
+// unmarshals the JSON response of the function into the given response (a pointer, or nil to ignore the response).
+// If the function itself fails, the invocation is returned along with a *FunctionError, which contains the error type,
+// message, and stack trace reported by the function. The invocation contains the tail of the logs in both cases, and
+// its request ID can be passed to GetLambdaInvocationLogsE to get its full logs.
+func InvokeFunctionWithResponseE(t testing.TestingT, region, functionName string, request interface{}, response interface{}) (*LambdaInvocation, error) {
+	lambdaClient, err := NewLambdaClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	invokeInput := &lambda.InvokeInput{
+		FunctionName:   aws.String(functionName),
+		InvocationType: types.InvocationTypeRequestResponse,
+		LogType:        types.LogTypeTail,
+	}
+	if request != nil {
+		invokeInput.Payload, err = json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := lambdaClient.Invoke(context.Background(), invokeInput)
+	if err != nil {
+		return nil, err
+	}
+
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(out.ResultMetadata)
+	invocation := &LambdaInvocation{
+		RequestID:       requestID,
+		ExecutedVersion: aws.ToString(out.ExecutedVersion),
+		StatusCode:      out.StatusCode,
+		Payload:         out.Payload,
+	}
+	if out.LogResult != nil {
+		logTail, err := base64.StdEncoding.DecodeString(*out.LogResult)
+		if err != nil {
+			return invocation, err
+		}
+		invocation.LogTail = string(logTail)
+	}
+
+	if out.FunctionError != nil {
+		return invocation, newFunctionError(*out.FunctionError, out.StatusCode, out.Payload)
+	}
+	if response != nil && len(out.Payload) > 0 {
+		if err := json.Unmarshal(out.Payload, response); err != nil {
+			return invocation, err
+		}
+	}
+	return invocation, nil
+}
+
+// GetLambdaInvocationLogs returns the CloudWatch log messages of the invocation of the given lambda function with the
+// given request ID, from its START to its REPORT line. This will fail the test if the logs are not found.
+func GetLambdaInvocationLogs(t testing.TestingT, region, functionName string, requestID string, policy retry.Policy) []string {
+	out, err := GetLambdaInvocationLogsE(t, region, functionName, requestID, policy)
+	require.NoError(t, err)
+	return out
+}
+
+// GetLambdaInvocationLogsE returns the CloudWatch log messages of the invocation of the given lambda function with the
+// given request ID, from its START to its REPORT line. As logs reach CloudWatch a few seconds after the invocation,
+// this retries according to the given policy (DefaultWaitPolicy if nil) until the REPORT line is found.
+func GetLambdaInvocationLogsE(t testing.TestingT, region, functionName string, requestID string, policy retry.Policy) ([]string, error) {
+	lambdaClient, err := NewLambdaClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+	logsClient, err := NewCloudWatchLogsClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	function, err := lambdaClient.GetFunctionConfiguration(context.Background(), &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(functionName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	logGroupName := "/aws/lambda/" + aws.ToString(function.FunctionName)
+	if function.LoggingConfig != nil && aws.ToString(function.LoggingConfig.LogGroup) != "" {
+		logGroupName = aws.ToString(function.LoggingConfig.LogGroup)
+	}
+
+	messages, err := waitForE(t,
+		fmt.Sprintf("Waiting for the logs of invocation %s of lambda function %s", requestID, functionName),
+		func() ([]string, error) {
+			return filterLogEventsE(logsClient, logGroupName, fmt.Sprintf("%q", "RequestId: "+requestID))
+		},
+		func(messages []string) bool {
+			_, complete := invocationLogLines(messages, requestID)
+			return complete
+		},
+		policy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, _ := invocationLogLines(messages, requestID)
+	return lines, nil
+}
+
+// filterLogEventsE returns the messages of all the log events of the given log group whose stream contains an event that
+// matches the given filter pattern.
+func filterLogEventsE(client *cloudwatchlogs.Client, logGroupName string, filterPattern string) ([]string, error) {
+	streams := map[string]bool{}
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(logGroupName),
+		FilterPattern: aws.String(filterPattern),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page.Events {
+			streams[aws.ToString(event.LogStreamName)] = true
+		}
+	}
+	if len(streams) == 0 {
+		return nil, nil
+	}
+
+	streamNames := make([]string, 0, len(streams))
+	for name := range streams {
+		streamNames = append(streamNames, name)
+	}
+	messages := []string{}
+	paginator = cloudwatchlogs.NewFilterLogEventsPaginator(client, &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:   aws.String(logGroupName),
+		LogStreamNames: streamNames,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page.Events {
+			messages = append(messages, aws.ToString(event.Message))
+		}
+	}
+	return messages, nil
+}
+
+// invocationLogLines returns the given log messages of the invocation with the given request ID, from its START to its
+// REPORT line, and whether the REPORT line was found.
+func invocationLogLines(messages []string, requestID string) ([]string, bool) {
+	lines := []string{}
+	started := false
+	for _, message := range messages {
+		if strings.HasPrefix(message, "START RequestId: "+requestID) {
+			started = true
+		}
+		if !started {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(message, "\n"))
+		if strings.HasPrefix(message, "REPORT RequestId: "+requestID) {
+			return lines, true
+		}
+	}
+	return lines, false
+}
+
 // NewLambdaClient creates a new Lambda client.
 func NewLambdaClient(t testing.TestingT, region string) *lambda.Client {
 	client, err := NewLambdaClientE(t, region)
@@ -15,3 +15,45 @@ func TestFunctionError(t *testing.T) {
 	require.Contains(t, err.Error(), "123")
 	require.Contains(t, err.Error(), "payload")
 }
+
+func TestNewFunctionError(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(`{"errorType": "TypeError", "errorMessage": "x is undefined", "stackTrace": ["TypeError: x is undefined", "    at handler (/var/task/index.js:3:9)"]}`)
+	err := newFunctionError("Unhandled", 200, payload)
+	require.Equal(t, "Unhandled", err.Message)
+	require.Equal(t, "TypeError", err.ErrorType)
+	require.Equal(t, "x is undefined", err.ErrorMessage)
+	require.Equal(t, []string{"TypeError: x is undefined", "    at handler (/var/task/index.js:3:9)"}, err.StackTrace)
+
+	// Python stack traces are formatted lines, and legacy ones lists of frames
+	err = newFunctionError("Unhandled", 200, []byte(`{"errorType": "KeyError", "stackTrace": ["  File \"/var/task/app.py\", line 2, in handler\n"]}`))
+	require.Equal(t, []string{`  File "/var/task/app.py", line 2, in handler`}, err.StackTrace)
+	err = newFunctionError("Unhandled", 200, []byte(`{"stackTrace": [["/var/task/app.py", 2, "handler", "raise"]]}`))
+	require.Equal(t, []string{"/var/task/app.py 2 handler raise"}, err.StackTrace)
+
+	// Payloads that are not error objects are kept as is
+	err = newFunctionError("Unhandled", 200, []byte("Task timed out"))
+	require.Empty(t, err.ErrorType)
+	require.Equal(t, []byte("Task timed out"), err.Payload)
+}
+
+func TestInvocationLogLines(t *testing.T) {
+	t.Parallel()
+
+	messages := []string{
+		"START RequestId: other Version: $LATEST\n",
+		"REPORT RequestId: other Duration: 1 ms\n",
+		"START RequestId: abc Version: $LATEST\n",
+		"2024-01-01T00:00:00Z abc INFO hello\n",
+	}
+	lines, complete := invocationLogLines(messages, "abc")
+	require.False(t, complete)
+	require.Equal(t, []string{"START RequestId: abc Version: $LATEST", "2024-01-01T00:00:00Z abc INFO hello"}, lines)
+
+	messages = append(messages, "END RequestId: abc\n", "REPORT RequestId: abc Duration: 2 ms\n", "START RequestId: next\n")
+	lines, complete = invocationLogLines(messages, "abc")
+	require.True(t, complete)
+	require.Len(t, lines, 4)
+	require.Equal(t, "REPORT RequestId: abc Duration: 2 ms", lines[3])
+}
@@ -0,0 +1,122 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// The AWS partitions, i.e., the groups of regions isolated from each other, with their own accounts and credentials.
+const (
+	PartitionAws      = "aws"        // The commercial regions
+	PartitionAwsUsGov = "aws-us-gov" // The AWS GovCloud (US) regions
+	PartitionAwsCn    = "aws-cn"     // The China regions
+	PartitionAwsIso   = "aws-iso"    // The US ISO regions
+	PartitionAwsIsoB  = "aws-iso-b"  // The US ISOB regions
+)
+
+// partitionRegionPrefixes maps the prefixes of the region names of the partitions other than aws to their partition.
+// Longer prefixes come first, as us-isob- also starts with us-iso.
+var partitionRegionPrefixes = []struct {
+	prefix    string
+	partition string
+}{
+	{"us-gov-", PartitionAwsUsGov},
+	{"cn-", PartitionAwsCn},
+	{"us-isob-", PartitionAwsIsoB},
+	{"us-iso-", PartitionAwsIso},
+}
+
+// partitionDefaults are the DNS suffix of the endpoints of each partition, and the region used for the API calls that
+// are not specific to a region, such as looking up the account ID.
+var partitionDefaults = map[string]struct {
+	dnsSuffix     string
+	defaultRegion string
+}{
+	PartitionAws:      {"amazonaws.com", defaultRegion},
+	PartitionAwsUsGov: {"amazonaws.com", "us-gov-west-1"},
+	PartitionAwsCn:    {"amazonaws.com.cn", "cn-north-1"},
+	PartitionAwsIso:   {"c2s.ic.gov", "us-iso-east-1"},
+	PartitionAwsIsoB:  {"sc2s.sgov.gov", "us-isob-east-1"},
+}
+
+// GetPartitionForRegion returns the partition of the given region, e.g., aws-us-gov for us-gov-west-1.
+func GetPartitionForRegion(region string) string {
+	for _, candidate := range partitionRegionPrefixes {
+		if strings.HasPrefix(region, candidate.prefix) {
+			return candidate.partition
+		}
+	}
+	return PartitionAws
+}
+
+// GetPartitionForArn returns the partition of the given ARN, e.g., aws-cn for arn:aws-cn:s3:::my-bucket.
+func GetPartitionForArn(arn string) (string, error) {
+	parts := strings.SplitN(arn, ":", 3)
+	if len(parts) < 3 || parts[0] != "arn" {
+		return "", fmt.Errorf("invalid ARN %q", arn)
+	}
+	return parts[1], nil
+}
+
+// GetCurrentPartition returns the partition of the current credentials. See GetCurrentPartitionE.
+func GetCurrentPartition(t testing.TestingT) string {
+	partition, err := GetCurrentPartitionE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return partition
+}
+
+// GetCurrentPartitionE returns the partition of the current credentials, from the ARN of the caller identity.
+func GetCurrentPartitionE(t testing.TestingT) (string, error) {
+	stsClient, err := NewStsClientE(t, getDefaultRegion())
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := stsClient.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return GetPartitionForArn(aws.ToString(identity.Arn))
+}
+
+// GetDnsSuffixForRegion returns the DNS suffix of the endpoints of the given region, e.g., amazonaws.com.cn for
+// cn-north-1.
+func GetDnsSuffixForRegion(region string) string {
+	return partitionDefaults[GetPartitionForRegion(region)].dnsSuffix
+}
+
+// GetServiceEndpoint returns the URL of the standard endpoint of the given service (e.g., ssm) in the given region,
+// such as https://ssm.cn-north-1.amazonaws.com.cn.
+func GetServiceEndpoint(service string, region string) string {
+	return fmt.Sprintf("https://%s.%s.%s", service, region, GetDnsSuffixForRegion(region))
+}
+
+// NewArn returns the ARN of the given resource of the given service, in the partition of the given region. The region
+// and account ID are left empty in the ARN if empty, as for S3 buckets or IAM roles.
+func NewArn(service string, region string, accountID string, resource string) string {
+	partition := PartitionAws
+	if region != "" {
+		partition = GetPartitionForRegion(region)
+	}
+	return fmt.Sprintf("arn:%s:%s:%s:%s:%s", partition, service, region, accountID, resource)
+}
+
+// getDefaultRegion returns the region used for the API calls that are not specific to a region: the default region of
+// the partition of the region configured in the environment (TERRATEST_REGION, AWS_REGION, or AWS_DEFAULT_REGION), or
+// us-east-1 if none is configured.
+func getDefaultRegion() string {
+	for _, envVarName := range []string{regionOverrideEnvVarName, "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(envVarName); region != "" {
+			return partitionDefaults[GetPartitionForRegion(region)].defaultRegion
+		}
+	}
+	return defaultRegion
+}
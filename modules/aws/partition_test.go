@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPartitionForRegion(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, PartitionAws, GetPartitionForRegion("us-east-1"))
+	assert.Equal(t, PartitionAws, GetPartitionForRegion("eu-west-2"))
+	assert.Equal(t, PartitionAwsUsGov, GetPartitionForRegion("us-gov-west-1"))
+	assert.Equal(t, PartitionAwsCn, GetPartitionForRegion("cn-northwest-1"))
+	assert.Equal(t, PartitionAwsIso, GetPartitionForRegion("us-iso-east-1"))
+	assert.Equal(t, PartitionAwsIsoB, GetPartitionForRegion("us-isob-east-1"))
+}
+
+func TestGetPartitionForArn(t *testing.T) {
+	t.Parallel()
+
+	partition, err := GetPartitionForArn("arn:aws-cn:s3:::my-bucket")
+	require.NoError(t, err)
+	assert.Equal(t, PartitionAwsCn, partition)
+
+	partition, err = GetPartitionForArn("arn:aws-us-gov:iam::123456789012:role/test")
+	require.NoError(t, err)
+	assert.Equal(t, PartitionAwsUsGov, partition)
+
+	_, err = GetPartitionForArn("not-an-arn")
+	assert.Error(t, err)
+}
+
+func TestGetServiceEndpointAndNewArn(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "amazonaws.com.cn", GetDnsSuffixForRegion("cn-north-1"))
+	assert.Equal(t, "https://ssm.us-east-1.amazonaws.com", GetServiceEndpoint("ssm", "us-east-1"))
+	assert.Equal(t, "https://ssm.us-gov-west-1.amazonaws.com", GetServiceEndpoint("ssm", "us-gov-west-1"))
+	assert.Equal(t, "https://ssm.cn-north-1.amazonaws.com.cn", GetServiceEndpoint("ssm", "cn-north-1"))
+
+	assert.Equal(t, "arn:aws-cn:sqs:cn-north-1:123456789012:my-queue", NewArn("sqs", "cn-north-1", "123456789012", "my-queue"))
+	assert.Equal(t, "arn:aws:s3:::my-bucket", NewArn("s3", "", "", "my-bucket"))
+}
+
+func TestFilterRegionsByPartition(t *testing.T) {
+	t.Parallel()
+
+	regions := []string{"us-east-1", "us-gov-west-1", "cn-north-1", "us-gov-east-1", "eu-west-1"}
+	assert.Equal(t, []string{"us-gov-west-1", "us-gov-east-1"}, filterRegionsByPartition(regions, PartitionAwsUsGov))
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, filterRegionsByPartition(regions, PartitionAws))
+	assert.Empty(t, filterRegionsByPartition(regions, PartitionAwsIso))
+}
+
+func TestGetDefaultRegion(t *testing.T) {
+	t.Setenv(regionOverrideEnvVarName, "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	assert.Equal(t, defaultRegion, getDefaultRegion())
+
+	t.Setenv("AWS_DEFAULT_REGION", "cn-northwest-1")
+	assert.Equal(t, "cn-north-1", getDefaultRegion())
+
+	t.Setenv("AWS_REGION", "us-gov-east-1")
+	assert.Equal(t, "us-gov-west-1", getDefaultRegion())
+
+	t.Setenv(regionOverrideEnvVarName, "eu-west-1")
+	assert.Equal(t, defaultRegion, getDefaultRegion())
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AuroraClusterEndpoints contains the endpoints and instances of an Aurora cluster.
+type AuroraClusterEndpoints struct {
+	// The cluster endpoint, which always points to the writer instance.
+	WriterEndpoint string
+
+	// The reader endpoint, which load balances connections across the reader instances.
+	ReaderEndpoint string
+
+	Port int32
+
+	// The identifier of the writer instance.
+	WriterInstanceID string
+
+	// The identifiers of the reader instances, sorted alphabetically.
+	ReaderInstanceIDs []string
+}
+
+// GetAuroraCluster returns the details of the given Aurora cluster.
+func GetAuroraCluster(t testing.TestingT, clusterID string, awsRegion string) *types.DBCluster {
+	cluster, err := GetAuroraClusterE(t, clusterID, awsRegion)
+	require.NoError(t, err)
+	return cluster
+}
+
+// GetAuroraClusterE returns the details of the given Aurora cluster.
+func GetAuroraClusterE(t testing.TestingT, clusterID string, awsRegion string) (*types.DBCluster, error) {
+	rdsClient, err := NewRdsClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := rdsClient.DescribeDBClusters(context.Background(), &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(clusterID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.DBClusters) != 1 {
+		return nil, fmt.Errorf("expected to find 1 RDS cluster %s in region %s, but found %d", clusterID, awsRegion, len(output.DBClusters))
+	}
+	return &output.DBClusters[0], nil
+}
+
+// WaitForAuroraClusterAvailable waits until the given Aurora cluster and all its instances are available, retrying
+// according to the given policy (DefaultWaitPolicy if nil). This will fail the test if they never are.
+func WaitForAuroraClusterAvailable(t testing.TestingT, clusterID string, awsRegion string, policy retry.Policy) *types.DBCluster {
+	cluster, err := WaitForAuroraClusterAvailableE(t, clusterID, awsRegion, policy)
+	require.NoError(t, err)
+	return cluster
+}
+
+// WaitForAuroraClusterAvailableE waits until the given Aurora cluster and all its instances are available, retrying
+// according to the given policy (DefaultWaitPolicy if nil).
+func WaitForAuroraClusterAvailableE(t testing.TestingT, clusterID string, awsRegion string, policy retry.Policy) (*types.DBCluster, error) {
+	rdsClient, err := NewRdsClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	type clusterState struct {
+		cluster   *types.DBCluster
+		instances []types.DBInstance
+	}
+	state, err := waitForE(t,
+		fmt.Sprintf("Waiting for Aurora cluster %s to be available", clusterID),
+		func() (clusterState, error) {
+			cluster, err := GetAuroraClusterE(t, clusterID, awsRegion)
+			if err != nil {
+				return clusterState{}, err
+			}
+			instances, err := rdsClient.DescribeDBInstances(context.Background(), &rds.DescribeDBInstancesInput{
+				Filters: []types.Filter{{Name: aws.String("db-cluster-id"), Values: []string{clusterID}}},
+			})
+			if err != nil {
+				return clusterState{}, err
+			}
+			return clusterState{cluster: cluster, instances: instances.DBInstances}, nil
+		},
+		func(state clusterState) bool { return isAuroraClusterAvailable(state.cluster, state.instances) },
+		policy,
+	)
+	return state.cluster, err
+}
+
+// isAuroraClusterAvailable returns true if the given cluster and all the given instances are available.
+func isAuroraClusterAvailable(cluster *types.DBCluster, instances []types.DBInstance) bool {
+	if aws.ToString(cluster.Status) != "available" {
+		return false
+	}
+	for _, instance := range instances {
+		if aws.ToString(instance.DBInstanceStatus) != "available" {
+			return false
+		}
+	}
+	return true
+}
+
+// GetAuroraClusterEndpoints returns the endpoints and the writer and reader instances of the given Aurora cluster.
+func GetAuroraClusterEndpoints(t testing.TestingT, clusterID string, awsRegion string) AuroraClusterEndpoints {
+	endpoints, err := GetAuroraClusterEndpointsE(t, clusterID, awsRegion)
+	require.NoError(t, err)
+	return endpoints
+}
+
+// GetAuroraClusterEndpointsE returns the endpoints and the writer and reader instances of the given Aurora cluster.
+func GetAuroraClusterEndpointsE(t testing.TestingT, clusterID string, awsRegion string) (AuroraClusterEndpoints, error) {
+	cluster, err := GetAuroraClusterE(t, clusterID, awsRegion)
+	if err != nil {
+		return AuroraClusterEndpoints{}, err
+	}
+	return newAuroraClusterEndpoints(cluster), nil
+}
+
+// newAuroraClusterEndpoints returns the endpoints and the writer and reader instances of the given cluster.
+func newAuroraClusterEndpoints(cluster *types.DBCluster) AuroraClusterEndpoints {
+	endpoints := AuroraClusterEndpoints{
+		WriterEndpoint:    aws.ToString(cluster.Endpoint),
+		ReaderEndpoint:    aws.ToString(cluster.ReaderEndpoint),
+		Port:              aws.ToInt32(cluster.Port),
+		ReaderInstanceIDs: []string{},
+	}
+	for _, member := range cluster.DBClusterMembers {
+		if aws.ToBool(member.IsClusterWriter) {
+			endpoints.WriterInstanceID = aws.ToString(member.DBInstanceIdentifier)
+		} else {
+			endpoints.ReaderInstanceIDs = append(endpoints.ReaderInstanceIDs, aws.ToString(member.DBInstanceIdentifier))
+		}
+	}
+	sort.Strings(endpoints.ReaderInstanceIDs)
+	return endpoints
+}
+
+// FailoverAuroraCluster triggers a failover of the given Aurora cluster to the given reader instance (or to a reader
+// chosen by Aurora if empty), waits until another instance is the writer and the cluster is available again, and
+// returns the new writer instance. This will fail the test if the failover fails.
+func FailoverAuroraCluster(t testing.TestingT, clusterID string, awsRegion string, targetInstanceID string, policy retry.Policy) string {
+	writer, err := FailoverAuroraClusterE(t, clusterID, awsRegion, targetInstanceID, policy)
+	require.NoError(t, err)
+	return writer
+}
+
+// FailoverAuroraClusterE triggers a failover of the given Aurora cluster to the given reader instance (or to a reader
+// chosen by Aurora if empty), waits, according to the given policy (DefaultWaitPolicy if nil), until another instance
+// is the writer and the cluster is available again, and returns the new writer instance.
+func FailoverAuroraClusterE(t testing.TestingT, clusterID string, awsRegion string, targetInstanceID string, policy retry.Policy) (string, error) {
+	rdsClient, err := NewRdsClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	before, err := GetAuroraClusterEndpointsE(t, clusterID, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	input := &rds.FailoverDBClusterInput{DBClusterIdentifier: aws.String(clusterID)}
+	if targetInstanceID != "" {
+		input.TargetDBInstanceIdentifier = aws.String(targetInstanceID)
+	}
+	if _, err := rdsClient.FailoverDBCluster(context.Background(), input); err != nil {
+		return "", err
+	}
+
+	cluster, err := waitForE(t,
+		fmt.Sprintf("Waiting for Aurora cluster %s to fail over from %s", clusterID, before.WriterInstanceID),
+		func() (*types.DBCluster, error) { return GetAuroraClusterE(t, clusterID, awsRegion) },
+		func(cluster *types.DBCluster) bool {
+			writer := newAuroraClusterEndpoints(cluster).WriterInstanceID
+			return aws.ToString(cluster.Status) == "available" && writer != "" && writer != before.WriterInstanceID
+		},
+		policy,
+	)
+	if err != nil {
+		return "", err
+	}
+	return newAuroraClusterEndpoints(cluster).WriterInstanceID, nil
+}
+
+// AssertAuroraServerlessV2Scaling checks that the given Aurora cluster has a serverless v2 scaling configuration with
+// the given minimum and maximum capacities, in Aurora capacity units (ACUs).
+func AssertAuroraServerlessV2Scaling(t testing.TestingT, clusterID string, awsRegion string, expectedMinCapacity float64, expectedMaxCapacity float64) {
+	cluster, err := GetAuroraClusterE(t, clusterID, awsRegion)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scaling := cluster.ServerlessV2ScalingConfiguration
+	if !assert.NotNilf(t, scaling, "Aurora cluster %s has no serverless v2 scaling configuration", clusterID) {
+		return
+	}
+	assert.Equalf(t, expectedMinCapacity, aws.ToFloat64(scaling.MinCapacity), "Unexpected minimum capacity of Aurora cluster %s", clusterID)
+	assert.Equalf(t, expectedMaxCapacity, aws.ToFloat64(scaling.MaxCapacity), "Unexpected maximum capacity of Aurora cluster %s", clusterID)
+}
@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuroraClusterEndpoints(t *testing.T) {
+	t.Parallel()
+
+	cluster := &types.DBCluster{
+		Endpoint:       aws.String("my-cluster.cluster-abc.us-east-1.rds.amazonaws.com"),
+		ReaderEndpoint: aws.String("my-cluster.cluster-ro-abc.us-east-1.rds.amazonaws.com"),
+		Port:           aws.Int32(5432),
+		DBClusterMembers: []types.DBClusterMember{
+			{DBInstanceIdentifier: aws.String("my-cluster-2"), IsClusterWriter: aws.Bool(false)},
+			{DBInstanceIdentifier: aws.String("my-cluster-0"), IsClusterWriter: aws.Bool(true)},
+			{DBInstanceIdentifier: aws.String("my-cluster-1"), IsClusterWriter: aws.Bool(false)},
+		},
+	}
+
+	assert.Equal(t, AuroraClusterEndpoints{
+		WriterEndpoint:    "my-cluster.cluster-abc.us-east-1.rds.amazonaws.com",
+		ReaderEndpoint:    "my-cluster.cluster-ro-abc.us-east-1.rds.amazonaws.com",
+		Port:              5432,
+		WriterInstanceID:  "my-cluster-0",
+		ReaderInstanceIDs: []string{"my-cluster-1", "my-cluster-2"},
+	}, newAuroraClusterEndpoints(cluster))
+}
+
+func TestIsAuroraClusterAvailable(t *testing.T) {
+	t.Parallel()
+
+	available := &types.DBCluster{Status: aws.String("available")}
+	instances := []types.DBInstance{{DBInstanceStatus: aws.String("available")}, {DBInstanceStatus: aws.String("creating")}}
+
+	assert.False(t, isAuroraClusterAvailable(&types.DBCluster{Status: aws.String("failing-over")}, nil))
+	assert.False(t, isAuroraClusterAvailable(available, instances))
+	assert.True(t, isAuroraClusterAvailable(available, instances[:1]))
+}
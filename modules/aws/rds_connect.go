@@ -0,0 +1,285 @@
+package aws
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/smithy-go"
+	"github.com/go-sql-driver/mysql"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rdsConnectPolicy is how long GetRdsConnectionE waits for the database to accept connections, e.g., while a new
+// security group rule propagates.
+var rdsConnectPolicy = retry.BackoffPolicy{MaxRetries: 10, InitialDelay: 3 * time.Second, Multiplier: 1}
+
+// RdsConnectionOptions configures how GetRdsConnectionE connects to an RDS instance.
+type RdsConnectionOptions struct {
+	// The database to connect to. Defaults to the initial database of the instance, or to "postgres" for Postgres
+	// instances without one.
+	DatabaseName string
+
+	// The user to connect as. Defaults to the master user of the instance.
+	Username string
+
+	// The password of the user. Ignored if UseIamAuth is set.
+	Password string
+
+	// Authenticate with an IAM auth token for the AWS identity of the test instead of a password. This requires IAM
+	// database authentication to be enabled on the instance, and the user to be granted the rds_iam role (Postgres)
+	// or to be identified with AWSAuthenticationPlugin (MySQL). Connections use TLS, as required by IAM auth.
+	UseIamAuth bool
+
+	// Add an ingress rule for the public IP of the test runner to the first VPC security group of the instance. The
+	// rule is registered for cleanup, so call CleanupRegisteredResources to remove it at the end of the test.
+	AllowTestRunnerIngress bool
+}
+
+// GetRdsConnection returns a connection to the given MySQL, MariaDB, or Postgres RDS instance, after checking that the
+// instance accepts it. This will fail the test if there is an error. Close the connection at the end of the test.
+func GetRdsConnection(t testing.TestingT, dbInstanceID string, awsRegion string, options RdsConnectionOptions) *sql.DB {
+	db, err := GetRdsConnectionE(t, dbInstanceID, awsRegion, options)
+	require.NoError(t, err)
+	return db
+}
+
+// GetRdsConnectionE returns a connection to the given MySQL, MariaDB, or Postgres RDS instance, after checking that the
+// instance accepts it. Close the connection at the end of the test.
+func GetRdsConnectionE(t testing.TestingT, dbInstanceID string, awsRegion string, options RdsConnectionOptions) (*sql.DB, error) {
+	instance, err := GetRdsInstanceDetailsE(t, dbInstanceID, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Endpoint == nil {
+		return nil, fmt.Errorf("RDS instance %s has no endpoint yet", dbInstanceID)
+	}
+	host := aws.ToString(instance.Endpoint.Address)
+	port := aws.ToInt32(instance.Endpoint.Port)
+
+	if options.AllowTestRunnerIngress {
+		if err := allowTestRunnerIngressToRdsInstanceE(t, instance, awsRegion); err != nil {
+			return nil, err
+		}
+	}
+
+	username := options.Username
+	if username == "" {
+		username = aws.ToString(instance.MasterUsername)
+	}
+	password := options.Password
+	if options.UseIamAuth {
+		sess, err := NewAuthenticatedSession(awsRegion)
+		if err != nil {
+			return nil, err
+		}
+		endpoint := net.JoinHostPort(host, strconv.Itoa(int(port)))
+		password, err = auth.BuildAuthToken(context.Background(), endpoint, awsRegion, username, sess.Credentials)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	driverName, dataSourceName, err := rdsDataSourceName(aws.ToString(instance.Engine), host, port, username, password, databaseNameForRds(instance, options), options.UseIamAuth)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Connecting to RDS instance %s as %s", dbInstanceID, username)
+	_, err = retry.DoWithPolicyE(t, description, rdsConnectPolicy, func() (string, error) {
+		return "", db.PingContext(context.Background())
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// databaseNameForRds returns the database to connect to on the given instance.
+func databaseNameForRds(instance *types.DBInstance, options RdsConnectionOptions) string {
+	if options.DatabaseName != "" {
+		return options.DatabaseName
+	}
+	if name := aws.ToString(instance.DBName); name != "" {
+		return name
+	}
+	if strings.Contains(aws.ToString(instance.Engine), "postgres") {
+		return "postgres"
+	}
+	return ""
+}
+
+// rdsDataSourceName returns the database/sql driver name and data source name to connect to an RDS instance with the
+// given engine (e.g., mysql, mariadb, postgres, aurora-mysql, or aurora-postgresql).
+func rdsDataSourceName(engine string, host string, port int32, username string, password string, databaseName string, useTLS bool) (string, string, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(int(port)))
+
+	switch {
+	case strings.Contains(engine, "mysql"), engine == "mariadb":
+		config := mysql.NewConfig()
+		config.User = username
+		config.Passwd = password
+		config.Net = "tcp"
+		config.Addr = address
+		config.DBName = databaseName
+		if useTLS {
+			config.TLSConfig = "true"
+			// IAM auth tokens are sent as clear text passwords, which is safe over TLS
+			config.AllowCleartextPasswords = true
+		}
+		return "mysql", config.FormatDSN(), nil
+	case strings.Contains(engine, "postgres"):
+		dataSourceName := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(username, password),
+			Host:   address,
+			Path:   "/" + databaseName,
+		}
+		if useTLS {
+			dataSourceName.RawQuery = "sslmode=require"
+		}
+		return "pgx", dataSourceName.String(), nil
+	default:
+		return "", "", UnsupportedRdsEngine(engine)
+	}
+}
+
+// allowTestRunnerIngressToRdsInstanceE adds an ingress rule for the public IP of the test runner on the port of the
+// given instance to its first VPC security group, and registers it for cleanup.
+func allowTestRunnerIngressToRdsInstanceE(t testing.TestingT, instance *types.DBInstance, awsRegion string) error {
+	if len(instance.VpcSecurityGroups) == 0 {
+		return fmt.Errorf("RDS instance %s has no VPC security group", aws.ToString(instance.DBInstanceIdentifier))
+	}
+	groupID := aws.ToString(instance.VpcSecurityGroups[0].VpcSecurityGroupId)
+
+	ip, err := http_helper.GetTestRunnerPublicIPE(t)
+	if err != nil {
+		return err
+	}
+
+	client, err := NewEc2ClientE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+	logger.Default.Logf(t, "Allowing %s to connect to RDS instance %s through security group %s", ip, aws.ToString(instance.DBInstanceIdentifier), groupID)
+	output, err := client.AuthorizeSecurityGroupIngress(context.Background(), &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId: aws.String(groupID),
+		IpPermissions: []ec2types.IpPermission{{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   instance.Endpoint.Port,
+			ToPort:     instance.Endpoint.Port,
+			IpRanges: []ec2types.IpRange{{
+				CidrIp:      aws.String(ip + "/32"),
+				Description: aws.String("Terratest " + t.Name()),
+			}},
+		}},
+	})
+	if err != nil {
+		// The rule already exists, e.g., because another test created it
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidPermission.Duplicate" {
+			return nil
+		}
+		return err
+	}
+
+	for _, rule := range output.SecurityGroupRules {
+		RegisterForCleanup(t, CleanupSecurityGroupRule, awsRegion, groupID+"/"+aws.ToString(rule.SecurityGroupRuleId))
+	}
+	return nil
+}
+
+// RdsTableExistsE returns true if the given table exists in the given schema (the database for MySQL) of the database
+// that the given connection, returned by GetRdsConnectionE, is connected to.
+func RdsTableExistsE(t testing.TestingT, db *sql.DB, schemaName string, tableName string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2"
+	if isMySqlConnection(db) {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?"
+	}
+
+	var count int
+	if err := db.QueryRowContext(context.Background(), query, schemaName, tableName).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AssertRdsTableExists checks that the given table exists in the given schema (the database for MySQL) of the database
+// that the given connection, returned by GetRdsConnection, is connected to.
+func AssertRdsTableExists(t testing.TestingT, db *sql.DB, schemaName string, tableName string) {
+	exists, err := RdsTableExistsE(t, db, schemaName, tableName)
+	if assert.NoError(t, err) {
+		assert.Truef(t, exists, "Table %s does not exist in schema %s", tableName, schemaName)
+	}
+}
+
+// GetRdsRowCount returns the number of rows in the given table (optionally qualified with its schema, e.g.,
+// public.users) of the database that the given connection, returned by GetRdsConnection, is connected to.
+func GetRdsRowCount(t testing.TestingT, db *sql.DB, tableName string) int64 {
+	count, err := GetRdsRowCountE(t, db, tableName)
+	require.NoError(t, err)
+	return count
+}
+
+// GetRdsRowCountE returns the number of rows in the given table (optionally qualified with its schema, e.g.,
+// public.users) of the database that the given connection, returned by GetRdsConnectionE, is connected to.
+func GetRdsRowCountE(t testing.TestingT, db *sql.DB, tableName string) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM " + quoteSqlIdentifier(tableName, isMySqlConnection(db))
+	if err := db.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// AssertRdsRowCount checks that the given table of the database that the given connection, returned by
+// GetRdsConnection, is connected to has the expected number of rows.
+func AssertRdsRowCount(t testing.TestingT, db *sql.DB, tableName string, expectedCount int64) {
+	count, err := GetRdsRowCountE(t, db, tableName)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedCount, count, "Unexpected number of rows in table %s", tableName)
+	}
+}
+
+// isMySqlConnection returns true if the given connection uses the MySQL driver, whose placeholders and identifier
+// quotes differ from the Postgres ones.
+func isMySqlConnection(db *sql.DB) bool {
+	_, isMySql := db.Driver().(*mysql.MySQLDriver)
+	return isMySql
+}
+
+// quoteSqlIdentifier quotes each part of the given, optionally schema qualified, identifier, with backticks for MySQL
+// and double quotes otherwise.
+func quoteSqlIdentifier(identifier string, mySql bool) string {
+	quote := `"`
+	if mySql {
+		quote = "`"
+	}
+
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
@@ -43,12 +43,20 @@ var stableRegions = []string{
 	"eu-north-1",     // Launched 2018
 }
 
+// stableRegionsByPartition are the stable regions of each partition.
+var stableRegionsByPartition = map[string][]string{
+	PartitionAws:      stableRegions,
+	PartitionAwsUsGov: {"us-gov-west-1", "us-gov-east-1"},
+	PartitionAwsCn:    {"cn-north-1", "cn-northwest-1"},
+}
+
 // GetRandomStableRegion gets a randomly chosen AWS region that is considered stable. Like GetRandomRegion, you can
 // further restrict the stable region list using approvedRegions and forbiddenRegions. We consider stable regions to be
-// those that have been around for at least 1 year.
+// those that have been around for at least 1 year. The region is picked from the partition of the region configured
+// in the environment (e.g., the GovCloud regions if AWS_REGION is us-gov-west-1), or from the aws partition otherwise.
 // Note that regions in the approvedRegions list that are not considered stable are ignored.
 func GetRandomStableRegion(t testing.TestingT, approvedRegions []string, forbiddenRegions []string) string {
-	regionsToPickFrom := stableRegions
+	regionsToPickFrom := stableRegionsByPartition[GetPartitionForRegion(getDefaultRegion())]
 	if len(approvedRegions) > 0 {
 		regionsToPickFrom = collections.ListIntersection(regionsToPickFrom, approvedRegions)
 	}
@@ -96,6 +104,51 @@ func GetRandomRegionE(t testing.TestingT, approvedRegions []string, forbiddenReg
 	return region, nil
 }
 
+// GetRandomRegionForPartition gets a randomly chosen AWS region of the given partition (e.g., PartitionAwsUsGov). See
+// GetRandomRegionForPartitionE.
+func GetRandomRegionForPartition(t testing.TestingT, partition string, approvedRegions []string, forbiddenRegions []string) string {
+	region, err := GetRandomRegionForPartitionE(t, partition, approvedRegions, forbiddenRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return region
+}
+
+// GetRandomRegionForPartitionE gets a randomly chosen AWS region of the given partition (e.g., PartitionAwsUsGov),
+// like GetRandomRegionE. If approvedRegions is empty, the regions to pick from are fetched with DescribeRegions in the
+// default region of the partition, so the current credentials must be valid in that partition.
+func GetRandomRegionForPartitionE(t testing.TestingT, partition string, approvedRegions []string, forbiddenRegions []string) (string, error) {
+	regionsToPickFrom := approvedRegions
+	if len(regionsToPickFrom) == 0 {
+		defaults, knownPartition := partitionDefaults[partition]
+		if !knownPartition {
+			return "", fmt.Errorf("unknown AWS partition %s", partition)
+		}
+		allRegions, err := getAwsRegionsE(t, defaults.defaultRegion)
+		if err != nil {
+			return "", err
+		}
+		regionsToPickFrom = allRegions
+	}
+
+	regionsToPickFrom = filterRegionsByPartition(regionsToPickFrom, partition)
+	if len(regionsToPickFrom) == 0 {
+		return "", fmt.Errorf("no region of partition %s to pick from", partition)
+	}
+	return GetRandomRegionE(t, regionsToPickFrom, forbiddenRegions)
+}
+
+// filterRegionsByPartition returns the given regions that are in the given partition.
+func filterRegionsByPartition(regions []string, partition string) []string {
+	filtered := []string{}
+	for _, region := range regions {
+		if GetPartitionForRegion(region) == partition {
+			filtered = append(filtered, region)
+		}
+	}
+	return filtered
+}
+
 // GetAllAwsRegions gets the list of AWS regions available in this account.
 func GetAllAwsRegions(t testing.TestingT) []string {
 	out, err := GetAllAwsRegionsE(t)
@@ -105,11 +158,17 @@ func GetAllAwsRegions(t testing.TestingT) []string {
 	return out
 }
 
-// GetAllAwsRegionsE gets the list of AWS regions available in this account.
+// GetAllAwsRegionsE gets the list of AWS regions available in this account, in the partition of the region configured
+// in the environment (see GetRandomStableRegion).
 func GetAllAwsRegionsE(t testing.TestingT) ([]string, error) {
+	return getAwsRegionsE(t, getDefaultRegion())
+}
+
+// getAwsRegionsE gets the list of AWS regions available in this account, in the partition of the given region.
+func getAwsRegionsE(t testing.TestingT, partitionRegion string) ([]string, error) {
 	logger.Default.Logf(t, "Looking up all AWS regions available in this account")
 
-	ec2Client, err := NewEc2ClientE(t, defaultRegion)
+	ec2Client, err := NewEc2ClientE(t, partitionRegion)
 	if err != nil {
 		return nil, err
 	}
@@ -169,31 +228,35 @@ func GetRegionsForService(t testing.TestingT, serviceName string) []string {
 	return out
 }
 
-// GetRegionsForServiceE gets all AWS regions in which a service is available and returns errors.
+// GetRegionsForServiceE gets all AWS regions in which a service is available and returns errors. Only the regions of
+// the partition of the region configured in the environment are returned (see GetRandomStableRegion).
 // See https://docs.aws.amazon.com/systems-manager/latest/userguide/parameter-store-public-parameters-global-infrastructure.html
 func GetRegionsForServiceE(t testing.TestingT, serviceName string) ([]string, error) {
-	// These values are available in any region, defaulting to us-east-1 since it's the oldest
-	ssmClient, err := NewSsmClientE(t, "us-east-1")
+	// These values are available in any region, defaulting to the oldest region of the partition
+	partitionRegion := getDefaultRegion()
+	ssmClient, err := NewSsmClientE(t, partitionRegion)
 
 	if err != nil {
 		return nil, err
 	}
 
 	paramPath := "/aws/service/global-infrastructure/services/%s/regions"
-	resp, err := ssmClient.GetParametersByPath(context.Background(), &ssm.GetParametersByPathInput{
+	paginator := ssm.NewGetParametersByPathPaginator(ssmClient, &ssm.GetParametersByPathInput{
 		Path: aws.String(fmt.Sprintf(paramPath, serviceName)),
 	})
 
-	if err != nil {
-		return nil, err
-	}
-
 	var availableRegions []string
-	for _, p := range resp.Parameters {
-		availableRegions = append(availableRegions, *p.Value)
+	for paginator.HasMorePages() {
+		resp, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Parameters {
+			availableRegions = append(availableRegions, *p.Value)
+		}
 	}
 
-	return availableRegions, nil
+	return filterRegionsByPartition(availableRegions, GetPartitionForRegion(partitionRegion)), nil
 }
 
 // GetRandomRegionForService retrieves a list of AWS regions in which a service is available
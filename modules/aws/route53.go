@@ -3,12 +3,17 @@ package aws
 import (
 	"context"
 	"fmt"
+	"net"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	dns_helper "github.com/gruntwork-io/terratest/modules/dns-helper"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -46,6 +51,215 @@ func GetRoute53RecordE(t *testing.T, hostedZoneID, recordName, recordType, awsRe
 	return nil, fmt.Errorf("record not found")
 }
 
+// GetRoute53Records returns all the records of the given hosted zone.
+func GetRoute53Records(t *testing.T, hostedZoneID, awsRegion string) []types.ResourceRecordSet {
+	records, err := GetRoute53RecordsE(t, hostedZoneID, awsRegion)
+	require.NoError(t, err)
+
+	return records
+}
+
+// GetRoute53RecordsE returns all the records of the given hosted zone.
+func GetRoute53RecordsE(t *testing.T, hostedZoneID, awsRegion string) ([]types.ResourceRecordSet, error) {
+	route53Client, err := NewRoute53ClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []types.ResourceRecordSet{}
+	input := &route53.ListResourceRecordSetsInput{HostedZoneId: &hostedZoneID}
+	for {
+		o, err := route53Client.ListResourceRecordSets(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, o.ResourceRecordSets...)
+		if !o.IsTruncated {
+			return records, nil
+		}
+		input.StartRecordName = o.NextRecordName
+		input.StartRecordType = o.NextRecordType
+		input.StartRecordIdentifier = o.NextRecordIdentifier
+	}
+}
+
+// AssertRoute53RecordValues checks that the given record of the given hosted zone has exactly the given values, in any
+// order, e.g., the IP addresses of an A record or the quoted strings of a TXT record.
+func AssertRoute53RecordValues(t *testing.T, hostedZoneID, recordName, recordType string, expectedValues []string, awsRegion string) {
+	record, err := GetRoute53RecordE(t, hostedZoneID, recordName, recordType, awsRegion)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.ElementsMatchf(t, expectedValues, route53RecordValues(record), "Unexpected values of %s record %s", recordType, recordName)
+}
+
+// AssertRoute53AliasTarget checks that the given record of the given hosted zone is an alias to the given DNS name,
+// e.g., the DNS name of a load balancer or a CloudFront distribution. The comparison ignores case, the trailing dot,
+// and the "dualstack." prefix that Route 53 adds to the DNS names of load balancers.
+func AssertRoute53AliasTarget(t *testing.T, hostedZoneID, recordName, recordType, expectedDNSName string, awsRegion string) {
+	record, err := GetRoute53RecordE(t, hostedZoneID, recordName, recordType, awsRegion)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NotNilf(t, record.AliasTarget, "%s record %s is not an alias", recordType, recordName) {
+		return
+	}
+	assert.Equalf(t, normalizeAliasDNSName(expectedDNSName), normalizeAliasDNSName(aws.ToString(record.AliasTarget.DNSName)), "Unexpected alias target of %s record %s", recordType, recordName)
+}
+
+// GetRoute53NameServers returns the authoritative name servers of the given public hosted zone.
+func GetRoute53NameServers(t *testing.T, hostedZoneID, awsRegion string) []string {
+	nameServers, err := GetRoute53NameServersE(t, hostedZoneID, awsRegion)
+	require.NoError(t, err)
+
+	return nameServers
+}
+
+// GetRoute53NameServersE returns the authoritative name servers of the given public hosted zone. Private hosted zones
+// have none: their records can only be resolved by the DNS resolver of the VPCs they are associated with.
+func GetRoute53NameServersE(t *testing.T, hostedZoneID, awsRegion string) ([]string, error) {
+	route53Client, err := NewRoute53ClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := route53Client.GetHostedZone(context.Background(), &route53.GetHostedZoneInput{Id: &hostedZoneID})
+	if err != nil {
+		return nil, err
+	}
+	if o.DelegationSet == nil || len(o.DelegationSet.NameServers) == 0 {
+		return nil, fmt.Errorf("hosted zone %s has no name servers: private hosted zones must be resolved with the DNS resolver of one of their VPCs", hostedZoneID)
+	}
+
+	return o.DelegationSet.NameServers, nil
+}
+
+// GetVpcDnsResolverAddress returns the address of the DNS resolver of the given VPC. See GetVpcDnsResolverAddressE.
+func GetVpcDnsResolverAddress(t *testing.T, vpcID, awsRegion string) string {
+	address, err := GetVpcDnsResolverAddressE(t, vpcID, awsRegion)
+	require.NoError(t, err)
+
+	return address
+}
+
+// GetVpcDnsResolverAddressE returns the address of the DNS resolver of the given VPC, which is the base of its primary
+// CIDR block plus two. This resolver, which resolves the records of the private hosted zones associated with the VPC,
+// is only reachable from within the VPC, so tests using it must run there, e.g., on a bastion or in CodeBuild.
+func GetVpcDnsResolverAddressE(t *testing.T, vpcID, awsRegion string) (string, error) {
+	vpc, err := GetVpcByIdE(t, vpcID, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	return vpcDnsResolverAddress(aws.ToString(vpc.CidrBlock))
+}
+
+// vpcDnsResolverAddress returns the address of the DNS resolver of a VPC with the given primary CIDR block.
+func vpcDnsResolverAddress(cidrBlock string) (string, error) {
+	_, network, err := net.ParseCIDR(cidrBlock)
+	if err != nil {
+		return "", err
+	}
+	ip := network.IP.To4()
+	if ip == nil {
+		return "", fmt.Errorf("%s is not an IPv4 CIDR block", cidrBlock)
+	}
+
+	address := make(net.IP, len(ip))
+	copy(address, ip)
+	address[3] += 2
+	return address.String(), nil
+}
+
+// WaitForRoute53Record waits until the given record resolves to the given values. See WaitForRoute53RecordE.
+func WaitForRoute53Record(t *testing.T, hostedZoneID, recordName, recordType string, expectedValues []string, resolvers []string, awsRegion string, policy retry.Policy) dns_helper.DNSAnswers {
+	answers, err := WaitForRoute53RecordE(t, hostedZoneID, recordName, recordType, expectedValues, resolvers, awsRegion, policy)
+	require.NoError(t, err)
+
+	return answers
+}
+
+// WaitForRoute53RecordE waits until the given record resolves, on every one of the given resolvers, to exactly the
+// given values in any order (or to any value if expectedValues is empty, e.g., for aliases), retrying according to the
+// given policy (DefaultWaitPolicy if nil), and returns the answers of the first resolver. If no resolvers are given,
+// the authoritative name servers of the hosted zone are used, which is how to check that a change has propagated
+// without waiting for the caches of recursive resolvers to expire. For private hosted zones, pass the resolver of the
+// VPC (see GetVpcDnsResolverAddressE). Supported record types are those of dns_helper.DNSLookupE.
+func WaitForRoute53RecordE(t *testing.T, hostedZoneID, recordName, recordType string, expectedValues []string, resolvers []string, awsRegion string, policy retry.Policy) (dns_helper.DNSAnswers, error) {
+	if len(resolvers) == 0 {
+		nameServers, err := GetRoute53NameServersE(t, hostedZoneID, awsRegion)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = nameServers
+	}
+
+	query := dns_helper.DNSQuery{Type: recordType, Name: recordName}
+	answers, err := waitForE(t,
+		fmt.Sprintf("Waiting for %s record %s to resolve on %s", recordType, recordName, strings.Join(resolvers, ", ")),
+		func() ([]dns_helper.DNSAnswers, error) {
+			answers := []dns_helper.DNSAnswers{}
+			for _, resolver := range resolvers {
+				resolverAnswers, err := dns_helper.DNSLookupE(t, query, []string{resolver})
+				if err != nil {
+					return nil, err
+				}
+				answers = append(answers, resolverAnswers)
+			}
+			return answers, nil
+		},
+		func(answers []dns_helper.DNSAnswers) bool {
+			for _, resolverAnswers := range answers {
+				if !dnsAnswersMatch(resolverAnswers, expectedValues) {
+					return false
+				}
+			}
+			return true
+		},
+		policy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return answers[0], nil
+}
+
+// dnsAnswersMatch returns true if the values of the given answers are exactly the expected ones, in any order, or if
+// there are any answers and no expected values.
+func dnsAnswersMatch(answers dns_helper.DNSAnswers, expectedValues []string) bool {
+	if len(expectedValues) == 0 {
+		return len(answers) > 0
+	}
+
+	values := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		values = append(values, strings.TrimSuffix(answer.Value, "."))
+	}
+	expected := make([]string, 0, len(expectedValues))
+	for _, value := range expectedValues {
+		expected = append(expected, strings.TrimSuffix(value, "."))
+	}
+	sort.Strings(values)
+	sort.Strings(expected)
+	return strings.Join(values, "\n") == strings.Join(expected, "\n")
+}
+
+// route53RecordValues returns the values of the given (non-alias) record.
+func route53RecordValues(record *types.ResourceRecordSet) []string {
+	values := make([]string, 0, len(record.ResourceRecords))
+	for _, resourceRecord := range record.ResourceRecords {
+		values = append(values, aws.ToString(resourceRecord.Value))
+	}
+	return values
+}
+
+// normalizeAliasDNSName returns the given alias target DNS name in lower case, without trailing dot or "dualstack."
+// prefix.
+func normalizeAliasDNSName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return strings.TrimPrefix(name, "dualstack.")
+}
+
 // NewRoute53Client creates a route 53 client.
 func NewRoute53Client(t *testing.T, region string) *route53.Client {
 	c, err := NewRoute53ClientE(t, region)
@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	dns_helper "github.com/gruntwork-io/terratest/modules/dns-helper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -84,4 +85,47 @@ func TestRoute53Record(t *testing.T) {
 		assert.Nil(t, route53Record)
 	})
 
+	t.Run("RecordValues", func(t *testing.T) {
+		records := GetRoute53Records(t, *hostedZone.HostedZone.Id, region)
+		assert.Len(t, records, 3) // SOA, NS, and A
+		AssertRoute53RecordValues(t, *hostedZone.HostedZone.Id, recordName, "A", []string{"127.0.0.1"}, region)
+	})
+
+	t.Run("Propagation", func(t *testing.T) {
+		answers := WaitForRoute53Record(t, *hostedZone.HostedZone.Id, recordName, "A", []string{"127.0.0.1"}, nil, region, nil)
+		assert.Equal(t, "127.0.0.1", answers[0].Value)
+	})
+}
+
+func TestDnsAnswersMatch(t *testing.T) {
+	t.Parallel()
+
+	answers := dns_helper.DNSAnswers{{Type: "A", Value: "10.0.0.2"}, {Type: "A", Value: "10.0.0.1"}}
+	assert.True(t, dnsAnswersMatch(answers, []string{"10.0.0.1", "10.0.0.2"}))
+	assert.True(t, dnsAnswersMatch(answers, nil))
+	assert.False(t, dnsAnswersMatch(answers, []string{"10.0.0.1"}))
+	assert.False(t, dnsAnswersMatch(nil, nil))
+	assert.True(t, dnsAnswersMatch(dns_helper.DNSAnswers{{Type: "CNAME", Value: "example.com."}}, []string{"example.com"}))
+}
+
+func TestVpcDnsResolverAddress(t *testing.T) {
+	t.Parallel()
+
+	address, err := vpcDnsResolverAddress("10.20.0.0/16")
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.0.2", address)
+
+	address, err = vpcDnsResolverAddress("172.31.5.7/20")
+	require.NoError(t, err)
+	assert.Equal(t, "172.31.0.2", address)
+
+	_, err = vpcDnsResolverAddress("not-a-cidr")
+	assert.Error(t, err)
+}
+
+func TestNormalizeAliasDNSName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "my-lb-123.us-east-1.elb.amazonaws.com", normalizeAliasDNSName("dualstack.My-LB-123.us-east-1.elb.amazonaws.com."))
+	assert.Equal(t, "d111111abcdef8.cloudfront.net", normalizeAliasDNSName("d111111abcdef8.cloudfront.net"))
 }
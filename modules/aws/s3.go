@@ -13,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -180,8 +181,12 @@ func CreateS3BucketE(t testing.TestingT, region string, name string) error {
 		}
 	}
 
-	_, err = s3Client.CreateBucket(context.Background(), params)
-	return err
+	if _, err := s3Client.CreateBucket(context.Background(), params); err != nil {
+		return err
+	}
+
+	RegisterForCleanup(t, CleanupS3Bucket, region, name)
+	return nil
 }
 
 // PutS3BucketPolicy applies an IAM resource policy to a given S3 bucket to create its bucket policy
@@ -529,6 +534,257 @@ func AssertS3BucketPolicyExistsE(t testing.TestingT, region string, bucketName s
 	return nil
 }
 
+// S3PublicAccessBlock is the public access block configuration of an S3 bucket.
+type S3PublicAccessBlock struct {
+	BlockPublicAcls       bool // Whether new public ACLs are rejected
+	IgnorePublicAcls      bool // Whether existing public ACLs are ignored
+	BlockPublicPolicy     bool // Whether bucket policies granting public access are rejected
+	RestrictPublicBuckets bool // Whether access through a public bucket policy is restricted to AWS services and the bucket owner's account
+}
+
+// S3LifecycleRule is a rule of the lifecycle configuration of an S3 bucket.
+type S3LifecycleRule struct {
+	Id                              string // The ID of the rule
+	Enabled                         bool   // Whether the rule is enabled
+	ExpirationDays                  int32  // The number of days after which current objects expire, if set
+	NoncurrentVersionExpirationDays int32  // The number of days after which noncurrent versions expire, if set
+	AbortIncompleteMultipartDays    int32  // The number of days after which incomplete multipart uploads are aborted, if set
+}
+
+// S3ObjectLockConfiguration is the object lock configuration of an S3 bucket.
+type S3ObjectLockConfiguration struct {
+	Enabled bool   // Whether object lock is enabled
+	Mode    string // The default retention mode, GOVERNANCE or COMPLIANCE, if there is a default retention
+	Days    int32  // The default retention period in days, if set
+	Years   int32  // The default retention period in years, if set
+}
+
+// GetS3BucketPublicAccessBlock returns the public access block configuration of the given bucket.
+func GetS3BucketPublicAccessBlock(t testing.TestingT, awsRegion string, bucket string) S3PublicAccessBlock {
+	block, err := GetS3BucketPublicAccessBlockE(t, awsRegion, bucket)
+	require.NoError(t, err)
+	return block
+}
+
+// GetS3BucketPublicAccessBlockE returns the public access block configuration of the given bucket. A bucket without
+// a configuration returns an error with the NoSuchPublicAccessBlockConfiguration code.
+func GetS3BucketPublicAccessBlockE(t testing.TestingT, awsRegion string, bucket string) (S3PublicAccessBlock, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return S3PublicAccessBlock{}, err
+	}
+
+	res, err := s3Client.GetPublicAccessBlock(context.Background(), &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return S3PublicAccessBlock{}, err
+	}
+
+	config := res.PublicAccessBlockConfiguration
+	return S3PublicAccessBlock{
+		BlockPublicAcls:       aws.ToBool(config.BlockPublicAcls),
+		IgnorePublicAcls:      aws.ToBool(config.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.ToBool(config.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.ToBool(config.RestrictPublicBuckets),
+	}, nil
+}
+
+// AssertS3BucketPublicAccessBlocked checks that all four settings of the public access block of the given bucket are
+// enabled.
+func AssertS3BucketPublicAccessBlocked(t testing.TestingT, awsRegion string, bucket string) {
+	block, err := GetS3BucketPublicAccessBlockE(t, awsRegion, bucket)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, S3PublicAccessBlock{true, true, true, true}, block, "Public access is not fully blocked on bucket %s", bucket)
+	}
+}
+
+// AssertS3BucketPolicyAllows checks that a statement of the policy of the given bucket allows the given principal to
+// perform the given action, with the same matching rules as AssertKmsKeyPolicyAllows.
+func AssertS3BucketPolicyAllows(t testing.TestingT, awsRegion string, bucket string, principal string, action string) {
+	assertS3BucketPolicyHasStatement(t, awsRegion, bucket, "Allow", principal, action)
+}
+
+// AssertS3BucketPolicyDenies checks that a statement of the policy of the given bucket explicitly denies the given
+// principal to perform the given action, with the same matching rules as AssertKmsKeyPolicyAllows.
+func AssertS3BucketPolicyDenies(t testing.TestingT, awsRegion string, bucket string, principal string, action string) {
+	assertS3BucketPolicyHasStatement(t, awsRegion, bucket, "Deny", principal, action)
+}
+
+// assertS3BucketPolicyHasStatement checks that the policy of the given bucket has a statement with the given effect for
+// the given principal and action.
+func assertS3BucketPolicyHasStatement(t testing.TestingT, awsRegion string, bucket string, effect string, principal string, action string) {
+	policyJSON, err := GetS3BucketPolicyE(t, awsRegion, bucket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	policy, err := parseKmsKeyPolicy(policyJSON)
+	if assert.NoError(t, err) {
+		assert.NotEmptyf(t, policy.FindStatements(effect, principal, action), "No statement of the policy of bucket %s has effect %s for %s on %s", bucket, effect, principal, action)
+	}
+}
+
+// AssertS3BucketPolicyDeniesInsecureTransport checks that the policy of the given bucket denies requests that don't use
+// TLS, i.e., that it has a Deny statement for all principals with the condition {"Bool": {"aws:SecureTransport":
+// "false"}}.
+func AssertS3BucketPolicyDeniesInsecureTransport(t testing.TestingT, awsRegion string, bucket string) {
+	policyJSON, err := GetS3BucketPolicyE(t, awsRegion, bucket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	policy, err := parseKmsKeyPolicy(policyJSON)
+	if assert.NoError(t, err) {
+		assert.Truef(t, deniesInsecureTransport(policy), "The policy of bucket %s does not deny requests without TLS", bucket)
+	}
+}
+
+// deniesInsecureTransport returns true if the given policy has a Deny statement for all principals on condition that
+// aws:SecureTransport is false.
+func deniesInsecureTransport(policy KmsKeyPolicy) bool {
+	for _, statement := range policy.FindStatements("Deny", "*", "s3:GetObject") {
+		if fmt.Sprint(statement.Condition["Bool"]["aws:SecureTransport"]) == "false" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetS3ObjectVersions returns the IDs of the versions of the given object. See GetS3ObjectVersionsE.
+func GetS3ObjectVersions(t testing.TestingT, awsRegion string, bucket string, key string) []string {
+	versions, err := GetS3ObjectVersionsE(t, awsRegion, bucket, key)
+	require.NoError(t, err)
+	return versions
+}
+
+// GetS3ObjectVersionsE returns the IDs of the versions of the given object, latest first, not including delete
+// markers.
+func GetS3ObjectVersionsE(t testing.TestingT, awsRegion string, bucket string, key string) ([]string, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	paginator := s3.NewListObjectVersionsPaginator(s3Client, &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(key),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range page.Versions {
+			if aws.ToString(version.Key) == key {
+				versions = append(versions, aws.ToString(version.VersionId))
+			}
+		}
+	}
+	return versions, nil
+}
+
+// GetS3BucketLifecycleRules returns the rules of the lifecycle configuration of the given bucket.
+func GetS3BucketLifecycleRules(t testing.TestingT, awsRegion string, bucket string) []S3LifecycleRule {
+	rules, err := GetS3BucketLifecycleRulesE(t, awsRegion, bucket)
+	require.NoError(t, err)
+	return rules
+}
+
+// GetS3BucketLifecycleRulesE returns the rules of the lifecycle configuration of the given bucket. A bucket without a
+// lifecycle configuration returns an error with the NoSuchLifecycleConfiguration code.
+func GetS3BucketLifecycleRulesE(t testing.TestingT, awsRegion string, bucket string) ([]S3LifecycleRule, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s3Client.GetBucketLifecycleConfiguration(context.Background(), &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return nil, err
+	}
+	return newS3LifecycleRules(res.Rules), nil
+}
+
+// AssertS3BucketLifecycleRuleEnabled checks that the lifecycle configuration of the given bucket has an enabled rule
+// with the given ID.
+func AssertS3BucketLifecycleRuleEnabled(t testing.TestingT, awsRegion string, bucket string, ruleID string) {
+	rules, err := GetS3BucketLifecycleRulesE(t, awsRegion, bucket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, rule := range rules {
+		if rule.Id == ruleID {
+			assert.Truef(t, rule.Enabled, "Lifecycle rule %s of bucket %s is disabled", ruleID, bucket)
+			return
+		}
+	}
+	assert.Failf(t, "Lifecycle rule not found", "Bucket %s has no lifecycle rule %s", bucket, ruleID)
+}
+
+// newS3LifecycleRules converts the given lifecycle rules.
+func newS3LifecycleRules(rules []types.LifecycleRule) []S3LifecycleRule {
+	result := []S3LifecycleRule{}
+	for _, rule := range rules {
+		converted := S3LifecycleRule{
+			Id:      aws.ToString(rule.ID),
+			Enabled: rule.Status == types.ExpirationStatusEnabled,
+		}
+		if rule.Expiration != nil {
+			converted.ExpirationDays = aws.ToInt32(rule.Expiration.Days)
+		}
+		if rule.NoncurrentVersionExpiration != nil {
+			converted.NoncurrentVersionExpirationDays = aws.ToInt32(rule.NoncurrentVersionExpiration.NoncurrentDays)
+		}
+		if rule.AbortIncompleteMultipartUpload != nil {
+			converted.AbortIncompleteMultipartDays = aws.ToInt32(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+		}
+		result = append(result, converted)
+	}
+	return result
+}
+
+// GetS3BucketObjectLockConfiguration returns the object lock configuration of the given bucket.
+func GetS3BucketObjectLockConfiguration(t testing.TestingT, awsRegion string, bucket string) S3ObjectLockConfiguration {
+	config, err := GetS3BucketObjectLockConfigurationE(t, awsRegion, bucket)
+	require.NoError(t, err)
+	return config
+}
+
+// GetS3BucketObjectLockConfigurationE returns the object lock configuration of the given bucket. A bucket without
+// object lock returns an error with the ObjectLockConfigurationNotFoundError code.
+func GetS3BucketObjectLockConfigurationE(t testing.TestingT, awsRegion string, bucket string) (S3ObjectLockConfiguration, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return S3ObjectLockConfiguration{}, err
+	}
+
+	res, err := s3Client.GetObjectLockConfiguration(context.Background(), &s3.GetObjectLockConfigurationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return S3ObjectLockConfiguration{}, err
+	}
+	return newS3ObjectLockConfiguration(res.ObjectLockConfiguration), nil
+}
+
+// AssertS3BucketObjectLockEnabled checks that object lock is enabled on the given bucket.
+func AssertS3BucketObjectLockEnabled(t testing.TestingT, awsRegion string, bucket string) {
+	config, err := GetS3BucketObjectLockConfigurationE(t, awsRegion, bucket)
+	if assert.NoError(t, err) {
+		assert.Truef(t, config.Enabled, "Object lock is not enabled on bucket %s", bucket)
+	}
+}
+
+// newS3ObjectLockConfiguration converts the given object lock configuration.
+func newS3ObjectLockConfiguration(config *types.ObjectLockConfiguration) S3ObjectLockConfiguration {
+	if config == nil {
+		return S3ObjectLockConfiguration{}
+	}
+	result := S3ObjectLockConfiguration{Enabled: config.ObjectLockEnabled == types.ObjectLockEnabledEnabled}
+	if config.Rule != nil && config.Rule.DefaultRetention != nil {
+		result.Mode = string(config.Rule.DefaultRetention.Mode)
+		result.Days = aws.ToInt32(config.Rule.DefaultRetention.Days)
+		result.Years = aws.ToInt32(config.Rule.DefaultRetention.Years)
+	}
+	return result
+}
+
 // NewS3Client creates an S3 client.
 func NewS3Client(t testing.TestingT, region string) *s3.Client {
 	client, err := NewS3ClientE(t, region)
@@ -544,7 +800,7 @@ func NewS3ClientE(t testing.TestingT, region string) (*s3.Client, error) {
 		return nil, err
 	}
 
-	return s3.NewFromConfig(*sess), nil
+	return s3.NewFromConfig(*sess, s3PathStyleForCustomEndpoint(sess)), nil
 }
 
 // NewS3Uploader creates an S3 Uploader.
@@ -561,7 +817,7 @@ func NewS3UploaderE(t testing.TestingT, region string) (*manager.Uploader, error
 		return nil, err
 	}
 
-	return manager.NewUploader(s3.NewFromConfig(*sess)), nil
+	return manager.NewUploader(s3.NewFromConfig(*sess, s3PathStyleForCustomEndpoint(sess))), nil
 }
 
 // S3AccessLoggingNotEnabledErr is a custom error that occurs when acess logging hasn't been enabled on the S3 Bucket
@@ -573,3 +829,13 @@ type S3AccessLoggingNotEnabledErr struct {
 func (err S3AccessLoggingNotEnabledErr) Error() string {
 	return fmt.Sprintf("Server Acess Logging hasn't been enabled for S3 Bucket %s in region %s", err.OriginBucket, err.Region)
 }
+
+// s3PathStyleForCustomEndpoint returns an option for S3 clients that enables path-style addressing if the given config
+// has a custom endpoint, as emulators such as LocalStack and moto don't support virtual hosted-style bucket names.
+func s3PathStyleForCustomEndpoint(cfg *aws.Config) func(*s3.Options) {
+	return func(options *s3.Options) {
+		if cfg.BaseEndpoint != nil {
+			options.UsePathStyle = true
+		}
+	}
+}
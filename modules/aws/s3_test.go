@@ -335,3 +335,54 @@ func TestS3ObjectContents(t *testing.T) {
 
 	assert.Equal(t, body, []byte(storedBody))
 }
+
+func TestDeniesInsecureTransport(t *testing.T) {
+	t.Parallel()
+
+	policy, err := parseKmsKeyPolicy(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:*",
+			"Resource": ["arn:aws:s3:::my-bucket", "arn:aws:s3:::my-bucket/*"],
+			"Condition": {"Bool": {"aws:SecureTransport": "false"}}
+		}]
+	}`)
+	require.NoError(t, err)
+	assert.True(t, deniesInsecureTransport(policy))
+
+	policy, err = parseKmsKeyPolicy(`{
+		"Version": "2012-10-17",
+		"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::my-bucket/*"}]
+	}`)
+	require.NoError(t, err)
+	assert.False(t, deniesInsecureTransport(policy))
+}
+
+func TestNewS3LifecycleRulesAndObjectLockConfiguration(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []S3LifecycleRule{
+		{Id: "expire-logs", Enabled: true, ExpirationDays: 30, AbortIncompleteMultipartDays: 7},
+		{Id: "noncurrent", NoncurrentVersionExpirationDays: 90},
+	}, newS3LifecycleRules([]types.LifecycleRule{
+		{
+			ID:                             aws.String("expire-logs"),
+			Status:                         types.ExpirationStatusEnabled,
+			Expiration:                     &types.LifecycleExpiration{Days: aws.Int32(30)},
+			AbortIncompleteMultipartUpload: &types.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int32(7)},
+		},
+		{
+			ID:                          aws.String("noncurrent"),
+			Status:                      types.ExpirationStatusDisabled,
+			NoncurrentVersionExpiration: &types.NoncurrentVersionExpiration{NoncurrentDays: aws.Int32(90)},
+		},
+	}))
+
+	assert.Equal(t, S3ObjectLockConfiguration{Enabled: true, Mode: "COMPLIANCE", Days: 10}, newS3ObjectLockConfiguration(&types.ObjectLockConfiguration{
+		ObjectLockEnabled: types.ObjectLockEnabledEnabled,
+		Rule:              &types.ObjectLockRule{DefaultRetention: &types.DefaultRetention{Mode: types.ObjectLockRetentionModeCompliance, Days: aws.Int32(10)}},
+	}))
+	assert.Equal(t, S3ObjectLockConfiguration{}, newS3ObjectLockConfiguration(nil))
+}
@@ -0,0 +1,301 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// UploadS3File uploads the given local file to the given bucket and key. See UploadS3FileE.
+func UploadS3File(t testing.TestingT, awsRegion string, bucket string, key string, localPath string) {
+	err := UploadS3FileE(t, awsRegion, bucket, key, localPath)
+	require.NoError(t, err)
+}
+
+// UploadS3FileE uploads the given local file to the given bucket and key. Files larger than the part size of the
+// uploader (5 MiB) are uploaded with a multipart upload, in parallel parts.
+func UploadS3FileE(t testing.TestingT, awsRegion string, bucket string, key string, localPath string) error {
+	uploader, err := NewS3UploaderE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = uploader.Upload(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   file,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Default.Logf(t, "Uploaded %s to s3://%s/%s", localPath, bucket, key)
+	return nil
+}
+
+// DownloadS3File downloads the given object to the given local file. See DownloadS3FileE.
+func DownloadS3File(t testing.TestingT, awsRegion string, bucket string, key string, localPath string) {
+	err := DownloadS3FileE(t, awsRegion, bucket, key, localPath)
+	require.NoError(t, err)
+}
+
+// DownloadS3FileE downloads the given object to the given local file, creating or truncating it. Large objects are
+// downloaded in parallel ranged requests.
+func DownloadS3FileE(t testing.TestingT, awsRegion string, bucket string, key string, localPath string) error {
+	downloader, err := NewS3DownloaderE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := downloader.Download(context.Background(), file, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+	logger.Default.Logf(t, "Downloaded %d bytes from s3://%s/%s to %s", size, bucket, key, localPath)
+	return nil
+}
+
+// SyncLocalDirToS3 uploads the files of the given local directory to the given bucket. See SyncLocalDirToS3E.
+func SyncLocalDirToS3(t testing.TestingT, awsRegion string, localDir string, bucket string, prefix string) []string {
+	keys, err := SyncLocalDirToS3E(t, awsRegion, localDir, bucket, prefix)
+	require.NoError(t, err)
+	return keys
+}
+
+// SyncLocalDirToS3E uploads the files of the given local directory and its subdirectories to the given bucket, under
+// the given prefix (e.g., site/), and returns the keys of the uploaded objects. Files whose object already exists with
+// the same size and, for objects that were not uploaded in multiple parts, the same MD5 checksum, are skipped. Objects
+// without a matching local file are not deleted.
+func SyncLocalDirToS3E(t testing.TestingT, awsRegion string, localDir string, bucket string, prefix string) ([]string, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]s3ObjectSummary{}
+	paginator := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			existing[aws.ToString(object.Key)] = s3ObjectSummary{Size: aws.ToInt64(object.Size), ETag: aws.ToString(object.ETag)}
+		}
+	}
+
+	uploaded := []string{}
+	err = filepath.WalkDir(localDir, func(localPath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(localDir, localPath)
+		if err != nil {
+			return err
+		}
+		key := s3SyncKey(prefix, relPath)
+
+		if object, exists := existing[key]; exists {
+			upToDate, err := isS3ObjectUpToDate(object, localPath)
+			if err != nil {
+				return err
+			}
+			if upToDate {
+				return nil
+			}
+		}
+		if err := UploadS3FileE(t, awsRegion, bucket, key, localPath); err != nil {
+			return err
+		}
+		uploaded = append(uploaded, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uploaded, nil
+}
+
+// s3ObjectSummary is the size and ETag of an existing object, to compare with a local file.
+type s3ObjectSummary struct {
+	Size int64
+	ETag string
+}
+
+// s3SyncKey returns the key of the object for the file with the given path, relative to the synced directory.
+func s3SyncKey(prefix string, relPath string) string {
+	return path.Join(prefix, filepath.ToSlash(relPath))
+}
+
+// isS3ObjectUpToDate returns true if the given object has the size and, unless it was uploaded in multiple parts (in
+// which case its ETag is not the MD5 checksum of its contents), the MD5 checksum of the given local file.
+func isS3ObjectUpToDate(object s3ObjectSummary, localPath string) (bool, error) {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() != object.Size {
+		return false, nil
+	}
+
+	etag := strings.Trim(object.ETag, `"`)
+	if strings.Contains(etag, "-") {
+		return true, nil
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)) == etag, nil
+}
+
+// GetS3PresignedGetUrl returns a presigned URL to download the given object. See GetS3PresignedGetUrlE.
+func GetS3PresignedGetUrl(t testing.TestingT, awsRegion string, bucket string, key string, expires time.Duration) string {
+	url, err := GetS3PresignedGetUrlE(t, awsRegion, bucket, key, expires)
+	require.NoError(t, err)
+	return url
+}
+
+// GetS3PresignedGetUrlE returns a URL that allows anyone to download the given object until the given duration has
+// passed, signed with the current credentials.
+func GetS3PresignedGetUrlE(t testing.TestingT, awsRegion string, bucket string, key string, expires time.Duration) (string, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := s3.NewPresignClient(s3Client).PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
+// GetS3PresignedPutUrl returns a presigned URL to upload the given object. See GetS3PresignedPutUrlE.
+func GetS3PresignedPutUrl(t testing.TestingT, awsRegion string, bucket string, key string, expires time.Duration) string {
+	url, err := GetS3PresignedPutUrlE(t, awsRegion, bucket, key, expires)
+	require.NoError(t, err)
+	return url
+}
+
+// GetS3PresignedPutUrlE returns a URL that allows anyone to upload the given object with a PUT request until the given
+// duration has passed, signed with the current credentials.
+func GetS3PresignedPutUrlE(t testing.TestingT, awsRegion string, bucket string, key string, expires time.Duration) (string, error) {
+	s3Client, err := NewS3ClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := s3.NewPresignClient(s3Client).PresignPutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
+// VerifyS3PresignedUrls checks that presigned URLs of the given object work. See VerifyS3PresignedUrlsE.
+func VerifyS3PresignedUrls(t testing.TestingT, awsRegion string, bucket string, key string) {
+	err := VerifyS3PresignedUrlsE(t, awsRegion, bucket, key)
+	require.NoError(t, err)
+}
+
+// VerifyS3PresignedUrlsE checks that presigned URLs of the given object work: it uploads random contents with a
+// presigned PUT URL, and downloads them back with a presigned GET URL, without credentials. This proves that the
+// current principal can presign requests for the object, e.g., that the bucket policy doesn't deny them.
+func VerifyS3PresignedUrlsE(t testing.TestingT, awsRegion string, bucket string, key string) error {
+	putURL, err := GetS3PresignedPutUrlE(t, awsRegion, bucket, key, 5*time.Minute)
+	if err != nil {
+		return err
+	}
+	getURL, err := GetS3PresignedGetUrlE(t, awsRegion, bucket, key, 5*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	contents := "terratest-" + time.Now().UTC().Format(time.RFC3339Nano)
+	status, body, err := http_helper.HTTPDoWithOptionsE(t, http_helper.HttpDoOptions{
+		Method:  http.MethodPut,
+		Url:     putURL,
+		Body:    strings.NewReader(contents),
+		Timeout: 30,
+	})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("upload to presigned URL of s3://%s/%s returned status %d: %s", bucket, key, status, body)
+	}
+
+	status, body, err = http_helper.HttpGetE(t, getURL, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || body != contents {
+		return fmt.Errorf("download from presigned URL of s3://%s/%s returned status %d and body %q, expected %q", bucket, key, status, body, contents)
+	}
+	return nil
+}
+
+// NewS3Downloader creates an S3 Downloader.
+func NewS3Downloader(t testing.TestingT, region string) *manager.Downloader {
+	downloader, err := NewS3DownloaderE(t, region)
+	require.NoError(t, err)
+	return downloader
+}
+
+// NewS3DownloaderE creates an S3 Downloader.
+func NewS3DownloaderE(t testing.TestingT, region string) (*manager.Downloader, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.NewDownloader(s3.NewFromConfig(*sess, s3PathStyleForCustomEndpoint(sess))), nil
+}
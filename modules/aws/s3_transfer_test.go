@@ -0,0 +1,42 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3SyncKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "site/css/main.css", s3SyncKey("site/", filepath.Join("css", "main.css")))
+	assert.Equal(t, "index.html", s3SyncKey("", "index.html"))
+}
+
+func TestIsS3ObjectUpToDate(t *testing.T) {
+	t.Parallel()
+
+	localPath := filepath.Join(t.TempDir(), "hello.txt")
+	require.NoError(t, os.WriteFile(localPath, []byte("hello"), 0644))
+
+	// The MD5 checksum of "hello"
+	upToDate, err := isS3ObjectUpToDate(s3ObjectSummary{Size: 5, ETag: `"5d41402abc4b2a76b9719d911017c592"`}, localPath)
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	upToDate, err = isS3ObjectUpToDate(s3ObjectSummary{Size: 5, ETag: `"00000000000000000000000000000000"`}, localPath)
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	upToDate, err = isS3ObjectUpToDate(s3ObjectSummary{Size: 6, ETag: `"5d41402abc4b2a76b9719d911017c592"`}, localPath)
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	// Multipart uploads can only be compared by size
+	upToDate, err = isS3ObjectUpToDate(s3ObjectSummary{Size: 5, ETag: `"0123456789abcdef0123456789abcdef-2"`}, localPath)
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+}
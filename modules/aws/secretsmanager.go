@@ -2,14 +2,25 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// The staging labels that Secrets Manager attaches to the versions of a secret.
+const (
+	SecretVersionStageCurrent  = "AWSCURRENT"
+	SecretVersionStagePrevious = "AWSPREVIOUS"
+	SecretVersionStagePending  = "AWSPENDING"
+)
+
 // CreateSecretStringWithDefaultKey creates a new secret in Secrets Manager using the default "aws/secretsmanager" KMS key and returns the secret ARN
 func CreateSecretStringWithDefaultKey(t testing.TestingT, awsRegion, description, name, secretString string) string {
 	arn, err := CreateSecretStringWithDefaultKeyE(t, awsRegion, description, name, secretString)
@@ -79,6 +90,42 @@ func PutSecretStringE(t testing.TestingT, awsRegion, id string, secretString str
 	return err
 }
 
+// GetSecretValueJSON takes the friendly name or ARN of a secret and unmarshals its JSON value into the given value
+func GetSecretValueJSON(t testing.TestingT, awsRegion, id string, out interface{}) {
+	err := GetSecretValueJSONE(t, awsRegion, id, out)
+	require.NoError(t, err)
+}
+
+// GetSecretValueJSONE takes the friendly name or ARN of a secret and unmarshals its JSON value into the given value,
+// e.g., a pointer to a struct with username and password fields for a database credentials secret
+func GetSecretValueJSONE(t testing.TestingT, awsRegion, id string, out interface{}) error {
+	secretString, err := GetSecretValueE(t, awsRegion, id)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(secretString), out); err != nil {
+		return fmt.Errorf("value of secret %s is not valid JSON for %T: %w", id, out, err)
+	}
+	return nil
+}
+
+// PutSecretJSON updates a secret in Secrets Manager to the JSON encoding of the given value
+func PutSecretJSON(t testing.TestingT, awsRegion, id string, value interface{}) {
+	err := PutSecretJSONE(t, awsRegion, id, value)
+	require.NoError(t, err)
+}
+
+// PutSecretJSONE updates a secret in Secrets Manager to the JSON encoding of the given value
+func PutSecretJSONE(t testing.TestingT, awsRegion, id string, value interface{}) error {
+	secretString, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return PutSecretStringE(t, awsRegion, id, string(secretString))
+}
+
 // DeleteSecret deletes a secret. If forceDelete is true, the secret will be deleted after a short delay. If forceDelete is false, the secret will be deleted after a 30-day recovery window.
 func DeleteSecret(t testing.TestingT, awsRegion, id string, forceDelete bool) {
 	err := DeleteSecretE(t, awsRegion, id, forceDelete)
@@ -99,6 +146,173 @@ func DeleteSecretE(t testing.TestingT, awsRegion, id string, forceDelete bool) e
 	return err
 }
 
+// GetSecretVersionStages returns the staging labels of the versions of a secret, by version ID
+func GetSecretVersionStages(t testing.TestingT, awsRegion, id string) map[string][]string {
+	stages, err := GetSecretVersionStagesE(t, awsRegion, id)
+	require.NoError(t, err)
+	return stages
+}
+
+// GetSecretVersionStagesE returns the staging labels (e.g., AWSCURRENT) of the versions of a secret, by version ID.
+// Versions without staging labels, which Secrets Manager deletes eventually, are included with no labels.
+func GetSecretVersionStagesE(t testing.TestingT, awsRegion, id string) (map[string][]string, error) {
+	client, err := NewSecretsManagerClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := map[string][]string{}
+	paginator := secretsmanager.NewListSecretVersionIdsPaginator(client, &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(id),
+		IncludeDeprecated: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range page.Versions {
+			stages[aws.ToString(version.VersionId)] = version.VersionStages
+		}
+	}
+
+	return stages, nil
+}
+
+// GetSecretVersionIdForStage returns the ID of the version of a secret that has the given staging label
+func GetSecretVersionIdForStage(t testing.TestingT, awsRegion, id string, stage string) string {
+	versionID, err := GetSecretVersionIdForStageE(t, awsRegion, id, stage)
+	require.NoError(t, err)
+	return versionID
+}
+
+// GetSecretVersionIdForStageE returns the ID of the version of a secret that has the given staging label (e.g.,
+// SecretVersionStageCurrent), or a NotFoundError if no version has it
+func GetSecretVersionIdForStageE(t testing.TestingT, awsRegion, id string, stage string) (string, error) {
+	stages, err := GetSecretVersionStagesE(t, awsRegion, id)
+	if err != nil {
+		return "", err
+	}
+
+	versionID, found := secretVersionForStage(stages, stage)
+	if !found {
+		return "", NewNotFoundError("secret version with stage "+stage, id, awsRegion)
+	}
+	return versionID, nil
+}
+
+// AssertSecretVersionStage checks that the given version of a secret has the given staging label
+func AssertSecretVersionStage(t testing.TestingT, awsRegion, id string, versionID string, stage string) {
+	stages, err := GetSecretVersionStagesE(t, awsRegion, id)
+	if assert.NoError(t, err) {
+		assert.Containsf(t, stages[versionID], stage, "Version %s of secret %s does not have stage %s", versionID, id, stage)
+	}
+}
+
+// secretVersionForStage returns the ID of the version that has the given staging label, which is on one version at
+// most.
+func secretVersionForStage(stages map[string][]string, stage string) (string, bool) {
+	for versionID, versionStages := range stages {
+		for _, versionStage := range versionStages {
+			if versionStage == stage {
+				return versionID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// GetSecretRotationEnabled returns true if automatic rotation is enabled for a secret
+func GetSecretRotationEnabled(t testing.TestingT, awsRegion, id string) bool {
+	enabled, err := GetSecretRotationEnabledE(t, awsRegion, id)
+	require.NoError(t, err)
+	return enabled
+}
+
+// GetSecretRotationEnabledE returns true if automatic rotation is enabled for a secret
+func GetSecretRotationEnabledE(t testing.TestingT, awsRegion, id string) (bool, error) {
+	client, err := NewSecretsManagerClientE(t, awsRegion)
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := client.DescribeSecret(context.Background(), &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return aws.ToBool(secret.RotationEnabled), nil
+}
+
+// AssertSecretRotationEnabled checks that automatic rotation is enabled for a secret
+func AssertSecretRotationEnabled(t testing.TestingT, awsRegion, id string) {
+	enabled, err := GetSecretRotationEnabledE(t, awsRegion, id)
+	if assert.NoError(t, err) {
+		assert.Truef(t, enabled, "Automatic rotation is not enabled for secret %s", id)
+	}
+}
+
+// RotateSecret starts a rotation of a secret with its configured rotation function
+func RotateSecret(t testing.TestingT, awsRegion, id string) {
+	err := RotateSecretE(t, awsRegion, id)
+	require.NoError(t, err)
+}
+
+// RotateSecretE starts a rotation of a secret with its configured rotation function. Use WaitForSecretRotationE to
+// wait for the rotation to complete.
+func RotateSecretE(t testing.TestingT, awsRegion, id string) error {
+	logger.Default.Logf(t, "Rotating secret with ID %s", id)
+
+	client, err := NewSecretsManagerClientE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RotateSecret(context.Background(), &secretsmanager.RotateSecretInput{
+		SecretId: aws.String(id),
+	})
+
+	return err
+}
+
+// WaitForSecretRotation waits for the rotation of a secret to complete and returns the ID of its new current version
+func WaitForSecretRotation(t testing.TestingT, awsRegion, id string, previousVersionID string, policy retry.Policy) string {
+	versionID, err := WaitForSecretRotationE(t, awsRegion, id, previousVersionID, policy)
+	require.NoError(t, err)
+	return versionID
+}
+
+// WaitForSecretRotationE waits, according to the given policy (DefaultWaitPolicy if nil), until the rotation of a
+// secret has completed, i.e., until a version other than the given previously current one has the AWSCURRENT label
+// and no version has the AWSPENDING label, and returns the ID of the new current version. Get the previous version ID
+// with GetSecretVersionIdForStageE before starting the rotation.
+func WaitForSecretRotationE(t testing.TestingT, awsRegion, id string, previousVersionID string, policy retry.Policy) (string, error) {
+	stages, err := waitForE(t,
+		fmt.Sprintf("Waiting for the rotation of secret %s to complete", id),
+		func() (map[string][]string, error) { return GetSecretVersionStagesE(t, awsRegion, id) },
+		func(stages map[string][]string) bool { return isSecretRotationComplete(stages, previousVersionID) },
+		policy,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	versionID, _ := secretVersionForStage(stages, SecretVersionStageCurrent)
+	return versionID, nil
+}
+
+// isSecretRotationComplete returns true if a version other than the given previously current one is current, and no
+// version is pending.
+func isSecretRotationComplete(stages map[string][]string, previousVersionID string) bool {
+	if _, pending := secretVersionForStage(stages, SecretVersionStagePending); pending {
+		return false
+	}
+	current, found := secretVersionForStage(stages, SecretVersionStageCurrent)
+	return found && current != previousVersionID
+}
+
 // NewSecretsManagerClient creates a new SecretsManager client.
 func NewSecretsManagerClient(t testing.TestingT, region string) *secretsmanager.Client {
 	client, err := NewSecretsManagerClientE(t, region)
@@ -27,6 +27,54 @@ func TestSecretsManagerMethods(t *testing.T) {
 
 	storedValueAfterUpdate := GetSecretValue(t, region, secretARN)
 	assert.Equal(t, secretUpdatedValue, storedValueAfterUpdate)
+
+	previousVersionID := GetSecretVersionIdForStage(t, region, secretARN, SecretVersionStageCurrent)
+
+	type credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	PutSecretJSON(t, region, secretARN, credentials{Username: "admin", Password: "hunter2"})
+
+	var storedCredentials credentials
+	GetSecretValueJSON(t, region, secretARN, &storedCredentials)
+	assert.Equal(t, credentials{Username: "admin", Password: "hunter2"}, storedCredentials)
+
+	AssertSecretVersionStage(t, region, secretARN, previousVersionID, SecretVersionStagePrevious)
+	assert.False(t, GetSecretRotationEnabled(t, region, secretARN))
+}
+
+func TestSecretVersionForStage(t *testing.T) {
+	t.Parallel()
+
+	stages := map[string][]string{
+		"v1": {},
+		"v2": {SecretVersionStagePrevious},
+		"v3": {SecretVersionStageCurrent, "custom"},
+	}
+
+	versionID, found := secretVersionForStage(stages, SecretVersionStageCurrent)
+	assert.True(t, found)
+	assert.Equal(t, "v3", versionID)
+
+	_, found = secretVersionForStage(stages, SecretVersionStagePending)
+	assert.False(t, found)
+}
+
+func TestIsSecretRotationComplete(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, isSecretRotationComplete(map[string][]string{
+		"v1": {SecretVersionStageCurrent},
+		"v2": {SecretVersionStagePending},
+	}, "v1"))
+	assert.False(t, isSecretRotationComplete(map[string][]string{
+		"v1": {SecretVersionStageCurrent},
+	}, "v1"))
+	assert.True(t, isSecretRotationComplete(map[string][]string{
+		"v1": {SecretVersionStagePrevious},
+		"v2": {SecretVersionStageCurrent},
+	}, "v1"))
 }
 
 func deleteSecret(t *testing.T, region, id string) {
@@ -36,6 +36,7 @@ func CreateSnsTopicE(t testing.TestingT, region string, snsTopicName string) (st
 		return "", err
 	}
 
+	RegisterForCleanup(t, CleanupSnsTopic, region, aws.ToString(output.TopicArn))
 	return aws.ToString(output.TopicArn), err
 }
 
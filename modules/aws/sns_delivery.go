@@ -0,0 +1,174 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// snsDeliveryMessageGroupID is the message group of the messages published to FIFO topics by VerifySnsDeliveryE.
+const snsDeliveryMessageGroupID = "terratest"
+
+// VerifySnsDelivery checks that a message published to the given SNS topic is delivered to a subscriber. See
+// VerifySnsDeliveryE.
+func VerifySnsDelivery(t testing.TestingT, region string, topicArn string, message string, timeout time.Duration) {
+	if err := VerifySnsDeliveryE(t, region, topicArn, message, timeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// VerifySnsDeliveryE checks that a message published to the given SNS topic is delivered to a subscriber: it creates a
+// temporary SQS queue (a FIFO one for FIFO topics), allows the topic to send messages to it, subscribes it to the topic
+// with raw message delivery (confirming the subscription if needed, e.g., for topics of another account), publishes the
+// given message, and waits up to the given timeout for the queue to receive it. The subscription and queue are deleted
+// at the end, whether the delivery succeeded or not.
+func VerifySnsDeliveryE(t testing.TestingT, region string, topicArn string, message string, timeout time.Duration) error {
+	snsClient, err := NewSnsClientE(t, region)
+	if err != nil {
+		return err
+	}
+	sqsClient, err := NewSqsClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	isFifo := strings.HasSuffix(topicArn, ".fifo")
+	var queueURL string
+	if isFifo {
+		queueURL, err = CreateRandomFifoQueueE(t, region, "terratest-sns")
+	} else {
+		queueURL, err = CreateRandomQueueE(t, region, "terratest-sns")
+	}
+	if err != nil {
+		return err
+	}
+	defer DeleteQueueE(t, region, queueURL)
+
+	attributes, err := sqsClient.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return err
+	}
+	queueArn := attributes.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+
+	_, err = sqsClient.SetQueueAttributes(context.Background(), &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): snsToSqsQueuePolicy(queueArn, topicArn)},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Default.Logf(t, "Subscribing queue %s to SNS topic %s", queueArn, topicArn)
+	subscription, err := snsClient.Subscribe(context.Background(), &sns.SubscribeInput{
+		TopicArn:              aws.String(topicArn),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(queueArn),
+		Attributes:            map[string]string{"RawMessageDelivery": "true"},
+		ReturnSubscriptionArn: true,
+	})
+	if err != nil {
+		return err
+	}
+	subscriptionArn := aws.ToString(subscription.SubscriptionArn)
+	defer func() {
+		if strings.HasPrefix(subscriptionArn, "arn:") {
+			snsClient.Unsubscribe(context.Background(), &sns.UnsubscribeInput{SubscriptionArn: aws.String(subscriptionArn)})
+		}
+	}()
+
+	sleepBetweenRetries := 5 * time.Second
+	policy := fixedWaitPolicy(int(timeout/sleepBetweenRetries), sleepBetweenRetries)
+	receive := func() ([]sqstypes.Message, error) {
+		result, err := sqsClient.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueURL),
+			MaxNumberOfMessages: maxSqsBatchSize,
+			WaitTimeSeconds:     1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.Messages, nil
+	}
+
+	if !strings.HasPrefix(subscriptionArn, "arn:") {
+		var token string
+		_, err = waitForE(t,
+			fmt.Sprintf("Waiting for the confirmation of the subscription of %s to SNS topic %s", queueArn, topicArn),
+			receive,
+			func(messages []sqstypes.Message) bool {
+				for _, received := range messages {
+					if confirmationToken, isConfirmation := snsSubscriptionConfirmationToken(aws.ToString(received.Body)); isConfirmation {
+						token = confirmationToken
+						return true
+					}
+				}
+				return false
+			},
+			policy,
+		)
+		if err != nil {
+			return err
+		}
+
+		confirmation, err := snsClient.ConfirmSubscription(context.Background(), &sns.ConfirmSubscriptionInput{
+			TopicArn: aws.String(topicArn),
+			Token:    aws.String(token),
+		})
+		if err != nil {
+			return err
+		}
+		subscriptionArn = aws.ToString(confirmation.SubscriptionArn)
+	}
+
+	publish := &sns.PublishInput{TopicArn: aws.String(topicArn), Message: aws.String(message)}
+	if isFifo {
+		publish.MessageGroupId = aws.String(snsDeliveryMessageGroupID)
+	}
+	if _, err := snsClient.Publish(context.Background(), publish); err != nil {
+		return err
+	}
+
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for the delivery of message %s from SNS topic %s to %s", message, topicArn, queueArn),
+		receive,
+		func(messages []sqstypes.Message) bool {
+			for _, received := range messages {
+				if aws.ToString(received.Body) == message {
+					return true
+				}
+			}
+			return false
+		},
+		policy,
+	)
+	return err
+}
+
+// snsToSqsQueuePolicy returns a queue policy that allows the given SNS topic to send messages to the given queue.
+func snsToSqsQueuePolicy(queueArn string, topicArn string) string {
+	return sqsQueuePolicyForSource(queueArn, "sns.amazonaws.com", topicArn)
+}
+
+// snsSubscriptionConfirmationToken returns the token of the given message if it's an SNS subscription confirmation.
+func snsSubscriptionConfirmationToken(body string) (string, bool) {
+	var message struct {
+		Type  string
+		Token string
+	}
+	if json.Unmarshal([]byte(body), &message) != nil || message.Type != "SubscriptionConfirmation" {
+		return "", false
+	}
+	return message.Token, true
+}
@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnsToSqsQueuePolicy(t *testing.T) {
+	t.Parallel()
+
+	var policy struct {
+		Statement []struct {
+			Resource  string
+			Condition map[string]map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(snsToSqsQueuePolicy("arn:aws:sqs:us-east-1:123456789012:q", "arn:aws:sns:us-east-1:123456789012:topic")), &policy))
+	require.Len(t, policy.Statement, 1)
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:q", policy.Statement[0].Resource)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:topic", policy.Statement[0].Condition["ArnEquals"]["aws:SourceArn"])
+}
+
+func TestSnsSubscriptionConfirmationToken(t *testing.T) {
+	t.Parallel()
+
+	token, isConfirmation := snsSubscriptionConfirmationToken(`{"Type": "SubscriptionConfirmation", "Token": "abc", "TopicArn": "arn:aws:sns:us-east-1:123456789012:topic"}`)
+	assert.True(t, isConfirmation)
+	assert.Equal(t, "abc", token)
+
+	_, isConfirmation = snsSubscriptionConfirmationToken(`{"Type": "Notification", "Message": "hello"}`)
+	assert.False(t, isConfirmation)
+	_, isConfirmation = snsSubscriptionConfirmationToken("hello")
+	assert.False(t, isConfirmation)
+}
@@ -2,9 +2,11 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -47,6 +49,7 @@ func CreateRandomQueueE(t testing.TestingT, awsRegion string, prefix string) (st
 		return "", err
 	}
 
+	RegisterForCleanup(t, CleanupSqsQueue, awsRegion, aws.ToString(queue.QueueUrl))
 	return aws.ToString(queue.QueueUrl), nil
 }
 
@@ -87,6 +90,7 @@ func CreateRandomFifoQueueE(t testing.TestingT, awsRegion string, prefix string)
 		return "", err
 	}
 
+	RegisterForCleanup(t, CleanupSqsQueue, awsRegion, aws.ToString(queue.QueueUrl))
 	return aws.ToString(queue.QueueUrl), nil
 }
 
@@ -255,6 +259,321 @@ func WaitForQueueMessage(t testing.TestingT, awsRegion string, queueURL string,
 	return QueueMessageResponse{Error: ReceiveMessageTimeout{QueueUrl: queueURL, TimeoutSec: timeout}}
 }
 
+// SqsMessage is a message to send to an SQS queue.
+type SqsMessage struct {
+	Body string
+
+	// The message group of the message. Required for FIFO queues.
+	MessageGroupID string
+
+	// The deduplication ID of the message. Only for FIFO queues, and required if content based deduplication is
+	// disabled.
+	DeduplicationID string
+}
+
+// maxSqsBatchSize is the maximum number of messages that can be sent or received in a single SQS call.
+const maxSqsBatchSize = 10
+
+// SendMessageToFifoQueueWithDeduplicationId sends the given message to the FIFO SQS queue with the given URL, with the
+// given message group and deduplication IDs.
+func SendMessageToFifoQueueWithDeduplicationId(t testing.TestingT, awsRegion string, queueURL string, message string, messageGroupID string, deduplicationID string) {
+	err := SendMessageToFifoQueueWithDeduplicationIdE(t, awsRegion, queueURL, message, messageGroupID, deduplicationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// SendMessageToFifoQueueWithDeduplicationIdE sends the given message to the FIFO SQS queue with the given URL, with the
+// given message group and deduplication IDs.
+func SendMessageToFifoQueueWithDeduplicationIdE(t testing.TestingT, awsRegion string, queueURL string, message string, messageGroupID string, deduplicationID string) error {
+	return SendMessageBatchToQueueE(t, awsRegion, queueURL, []SqsMessage{{Body: message, MessageGroupID: messageGroupID, DeduplicationID: deduplicationID}})
+}
+
+// SendMessageBatchToQueue sends the given messages to the SQS queue with the given URL, in batches of 10.
+func SendMessageBatchToQueue(t testing.TestingT, awsRegion string, queueURL string, messages []SqsMessage) {
+	err := SendMessageBatchToQueueE(t, awsRegion, queueURL, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// SendMessageBatchToQueueE sends the given messages to the SQS queue with the given URL, in batches of 10. If SQS
+// rejects some of the messages, a SendMessageBatchFailed error listing them is returned.
+func SendMessageBatchToQueueE(t testing.TestingT, awsRegion string, queueURL string, messages []SqsMessage) error {
+	logger.Default.Logf(t, "Sending %d messages to queue %s", len(messages), queueURL)
+	sqsClient, err := NewSqsClientE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+
+	failed := SendMessageBatchFailed{}
+	for start := 0; start < len(messages); start += maxSqsBatchSize {
+		end := start + maxSqsBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+
+		res, err := sqsClient.SendMessageBatch(context.Background(), &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(queueURL),
+			Entries:  sqsBatchEntries(messages[start:end], start),
+		})
+		if err != nil {
+			return err
+		}
+		for _, entry := range res.Failed {
+			failed = append(failed, fmt.Sprintf("message %s: %s", aws.ToString(entry.Id), aws.ToString(entry.Message)))
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// sqsBatchEntries returns the SendMessageBatch entries for the given messages, with IDs that are the index of each
+// message in the full list, starting at the given offset.
+func sqsBatchEntries(messages []SqsMessage, offset int) []types.SendMessageBatchRequestEntry {
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(messages))
+	for i, message := range messages {
+		entry := types.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(offset + i)),
+			MessageBody: aws.String(message.Body),
+		}
+		if message.MessageGroupID != "" {
+			entry.MessageGroupId = aws.String(message.MessageGroupID)
+		}
+		if message.DeduplicationID != "" {
+			entry.MessageDeduplicationId = aws.String(message.DeduplicationID)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ReceiveMessagesFromQueue receives up to maxMessages messages from the SQS queue with the given URL. See
+// ReceiveMessagesFromQueueE.
+func ReceiveMessagesFromQueue(t testing.TestingT, awsRegion string, queueURL string, maxMessages int, waitTimeSec int) []QueueMessageResponse {
+	messages, err := ReceiveMessagesFromQueueE(t, awsRegion, queueURL, maxMessages, waitTimeSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+// ReceiveMessagesFromQueueE receives up to maxMessages messages from the SQS queue with the given URL, in batches of 10,
+// waiting up to waitTimeSec seconds (at most 20) for each batch. It stops early when the queue has no more messages.
+// The messages are not deleted: use DeleteMessageFromQueueE with their receipt handles.
+func ReceiveMessagesFromQueueE(t testing.TestingT, awsRegion string, queueURL string, maxMessages int, waitTimeSec int) ([]QueueMessageResponse, error) {
+	sqsClient, err := NewSqsClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []QueueMessageResponse{}
+	for len(messages) < maxMessages {
+		batchSize := maxMessages - len(messages)
+		if batchSize > maxSqsBatchSize {
+			batchSize = maxSqsBatchSize
+		}
+
+		result, err := sqsClient.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueURL),
+			MaxNumberOfMessages: int32(batchSize),
+			WaitTimeSeconds:     int32(waitTimeSec),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Messages) == 0 {
+			break
+		}
+		for _, message := range result.Messages {
+			messages = append(messages, QueueMessageResponse{ReceiptHandle: aws.ToString(message.ReceiptHandle), MessageBody: aws.ToString(message.Body)})
+		}
+	}
+	logger.Default.Logf(t, "Received %d messages from queue %s", len(messages), queueURL)
+	return messages, nil
+}
+
+// PurgeQueue deletes all the messages in the SQS queue with the given URL.
+func PurgeQueue(t testing.TestingT, awsRegion string, queueURL string) {
+	err := PurgeQueueE(t, awsRegion, queueURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// PurgeQueueE deletes all the messages in the SQS queue with the given URL. Note that SQS allows a single purge per
+// queue every 60 seconds, and that the purge may take up to 60 seconds to complete.
+func PurgeQueueE(t testing.TestingT, awsRegion string, queueURL string) error {
+	logger.Default.Logf(t, "Purging SQS queue %s", queueURL)
+	sqsClient, err := NewSqsClientE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+	_, err = sqsClient.PurgeQueue(context.Background(), &sqs.PurgeQueueInput{QueueUrl: aws.String(queueURL)})
+	return err
+}
+
+// GetDeadLetterQueueUrl returns the URL of the dead-letter queue configured in the redrive policy of the SQS queue with
+// the given URL.
+func GetDeadLetterQueueUrl(t testing.TestingT, awsRegion string, queueURL string) string {
+	url, err := GetDeadLetterQueueUrlE(t, awsRegion, queueURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return url
+}
+
+// GetDeadLetterQueueUrlE returns the URL of the dead-letter queue configured in the redrive policy of the SQS queue with
+// the given URL.
+func GetDeadLetterQueueUrlE(t testing.TestingT, awsRegion string, queueURL string) (string, error) {
+	sqsClient, err := NewSqsClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	attributes, err := sqsClient.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameRedrivePolicy},
+	})
+	if err != nil {
+		return "", err
+	}
+	queueName, accountID, err := deadLetterQueueFromRedrivePolicy(attributes.Attributes[string(types.QueueAttributeNameRedrivePolicy)])
+	if err != nil {
+		return "", fmt.Errorf("queue %s: %w", queueURL, err)
+	}
+
+	queue, err := sqsClient.GetQueueUrl(context.Background(), &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(queueName),
+		QueueOwnerAWSAccountId: aws.String(accountID),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(queue.QueueUrl), nil
+}
+
+// deadLetterQueueFromRedrivePolicy returns the name and account ID of the dead-letter queue of the given redrive policy,
+// e.g., {"deadLetterTargetArn": "arn:aws:sqs:us-east-1:123456789012:my-dlq", "maxReceiveCount": 3}.
+func deadLetterQueueFromRedrivePolicy(redrivePolicy string) (string, string, error) {
+	if redrivePolicy == "" {
+		return "", "", fmt.Errorf("no redrive policy")
+	}
+	var policy struct {
+		DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+	}
+	if err := json.Unmarshal([]byte(redrivePolicy), &policy); err != nil {
+		return "", "", err
+	}
+
+	parts := strings.Split(policy.DeadLetterTargetArn, ":")
+	if len(parts) != 6 {
+		return "", "", fmt.Errorf("invalid dead-letter queue ARN %q", policy.DeadLetterTargetArn)
+	}
+	return parts[5], parts[4], nil
+}
+
+// WaitForMessageInDeadLetterQueue waits until a message with the given body is in the dead-letter queue of the SQS
+// queue with the given URL. See WaitForMessageInDeadLetterQueueE.
+func WaitForMessageInDeadLetterQueue(t testing.TestingT, awsRegion string, queueURL string, messageBody string, timeout time.Duration) QueueMessageResponse {
+	message, err := WaitForMessageInDeadLetterQueueE(t, awsRegion, queueURL, messageBody, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+// WaitForMessageInDeadLetterQueueE waits up to the given timeout until a message with the given body is in the
+// dead-letter queue configured in the redrive policy of the SQS queue with the given URL, e.g., to check that a
+// poisoned message that the consumer under test fails to process is moved there after maxReceiveCount attempts. The
+// messages of the dead-letter queue are not deleted, but are hidden from other consumers for the visibility timeout of
+// the queue.
+func WaitForMessageInDeadLetterQueueE(t testing.TestingT, awsRegion string, queueURL string, messageBody string, timeout time.Duration) (QueueMessageResponse, error) {
+	deadLetterQueueURL, err := GetDeadLetterQueueUrlE(t, awsRegion, queueURL)
+	if err != nil {
+		return QueueMessageResponse{}, err
+	}
+	sqsClient, err := NewSqsClientE(t, awsRegion)
+	if err != nil {
+		return QueueMessageResponse{}, err
+	}
+
+	sleepBetweenRetries := 5 * time.Second
+	found := QueueMessageResponse{}
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for message %s in dead-letter queue %s", messageBody, deadLetterQueueURL),
+		func() ([]types.Message, error) {
+			result, err := sqsClient.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+				QueueUrl:            aws.String(deadLetterQueueURL),
+				MaxNumberOfMessages: maxSqsBatchSize,
+				WaitTimeSeconds:     1,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result.Messages, nil
+		},
+		func(messages []types.Message) bool {
+			for _, message := range messages {
+				if aws.ToString(message.Body) == messageBody {
+					found = QueueMessageResponse{ReceiptHandle: aws.ToString(message.ReceiptHandle), MessageBody: messageBody}
+					return true
+				}
+			}
+			return false
+		},
+		fixedWaitPolicy(int(timeout/sleepBetweenRetries), sleepBetweenRetries),
+	)
+	return found, err
+}
+
+// GetQueueArn returns the ARN of the SQS queue with the given URL.
+func GetQueueArn(t testing.TestingT, awsRegion string, queueURL string) string {
+	arn, err := GetQueueArnE(t, awsRegion, queueURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return arn
+}
+
+// GetQueueArnE returns the ARN of the SQS queue with the given URL.
+func GetQueueArnE(t testing.TestingT, awsRegion string, queueURL string) (string, error) {
+	sqsClient, err := NewSqsClientE(t, awsRegion)
+	if err != nil {
+		return "", err
+	}
+
+	attributes, err := sqsClient.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", err
+	}
+	return attributes.Attributes[string(types.QueueAttributeNameQueueArn)], nil
+}
+
+// sqsQueuePolicyForSource returns a queue policy that allows the given AWS service (e.g., sns.amazonaws.com) to send
+// messages to the given queue on behalf of the resource with the given ARN only.
+func sqsQueuePolicyForSource(queueArn string, servicePrincipal string, sourceArn string) string {
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": servicePrincipal},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueArn,
+			"Condition": map[string]interface{}{
+				"ArnEquals": map[string]string{"aws:SourceArn": sourceArn},
+			},
+		}},
+	}
+	out, _ := json.Marshal(policy)
+	return string(out)
+}
+
 // NewSqsClient creates a new SQS client.
 func NewSqsClient(t testing.TestingT, region string) *sqs.Client {
 	client, err := NewSqsClientE(t, region)
@@ -283,3 +602,10 @@ type ReceiveMessageTimeout struct {
 func (err ReceiveMessageTimeout) Error() string {
 	return fmt.Sprintf("Failed to receive messages on %s within %s seconds", err.QueueUrl, strconv.Itoa(err.TimeoutSec))
 }
+
+// SendMessageBatchFailed is an error that occurs if SQS rejects some of the messages of a batch.
+type SendMessageBatchFailed []string
+
+func (err SendMessageBatchFailed) Error() string {
+	return fmt.Sprintf("Failed to send %d messages: %s", len(err), strings.Join(err, "; "))
+}
@@ -86,3 +86,31 @@ func deleteQueue(t *testing.T, region string, url string) {
 	DeleteQueue(t, region, url)
 	assert.False(t, queueExists(t, region, url))
 }
+
+func TestSqsBatchEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := sqsBatchEntries([]SqsMessage{
+		{Body: "a"},
+		{Body: "b", MessageGroupID: "group", DeduplicationID: "dedup"},
+	}, 10)
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "10", aws.ToString(entries[0].Id))
+	assert.Nil(t, entries[0].MessageGroupId)
+	assert.Equal(t, "11", aws.ToString(entries[1].Id))
+	assert.Equal(t, "group", aws.ToString(entries[1].MessageGroupId))
+	assert.Equal(t, "dedup", aws.ToString(entries[1].MessageDeduplicationId))
+}
+
+func TestDeadLetterQueueFromRedrivePolicy(t *testing.T) {
+	t.Parallel()
+
+	name, accountID, err := deadLetterQueueFromRedrivePolicy(`{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:my-dlq","maxReceiveCount":3}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dlq", name)
+	assert.Equal(t, "123456789012", accountID)
+
+	_, _, err = deadLetterQueueFromRedrivePolicy("")
+	assert.Error(t, err)
+}
@@ -140,19 +140,11 @@ func WaitForSsmInstanceWithClientE(t testing.TestingT, client *ssm.Client, insta
 			},
 		},
 	}
-	_, err := retry.DoWithRetryE(t, description, maxRetries, timeBetweenRetries, func() (string, error) {
-		resp, err := client.GetInventory(context.Background(), input)
-
-		if err != nil {
-			return "", err
-		}
-
-		if len(resp.Entities) != 1 {
-			return "", fmt.Errorf("%s is not in the SSM inventory", instanceID)
-		}
-
-		return "", nil
-	})
+	_, err := waitForE(t, description,
+		func() (*ssm.GetInventoryOutput, error) { return client.GetInventory(context.Background(), input) },
+		func(resp *ssm.GetInventoryOutput) bool { return len(resp.Entities) == 1 },
+		fixedWaitPolicy(maxRetries, timeBetweenRetries),
+	)
 
 	return err
 }
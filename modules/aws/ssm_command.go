@@ -0,0 +1,264 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ssmPortForwardingPolicy is how long StartSsmPortForwardingSessionE waits for the local port to accept connections.
+var ssmPortForwardingPolicy = retry.BackoffPolicy{MaxRetries: 30, InitialDelay: time.Second, Multiplier: 1}
+
+// RunSsmCommand runs the given shell commands on the given EC2 instance through SSM Run Command and returns their
+// output and exit code. See RunSsmCommandE.
+func RunSsmCommand(t testing.TestingT, awsRegion string, instanceID string, commands []string, timeout time.Duration) *CommandOutput {
+	result, err := RunSsmCommandE(t, awsRegion, instanceID, commands, timeout)
+	require.NoError(t, err)
+	return result
+}
+
+// RunSsmCommandE runs the given shell commands on the given EC2 instance through SSM Run Command, with the
+// AWS-RunShellScript document, waits up to the given timeout for them to complete, and returns their output and exit
+// code. Unlike CheckSsmCommandE, a non-zero exit code is not an error, so that tests can assert on it; an error is
+// only returned if the commands could not run to completion, e.g., if the instance is not managed by SSM or the
+// timeout is exceeded. Note that SSM truncates stdout and stderr to 24,000 characters.
+func RunSsmCommandE(t testing.TestingT, awsRegion string, instanceID string, commands []string, timeout time.Duration) (*CommandOutput, error) {
+	results, err := RunSsmCommandOnInstancesE(t, awsRegion, []string{instanceID}, commands, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return results[instanceID], nil
+}
+
+// RunSsmCommandOnInstances runs the given shell commands on the given EC2 instances through SSM Run Command and
+// returns their output and exit code, by instance ID. See RunSsmCommandOnInstancesE.
+func RunSsmCommandOnInstances(t testing.TestingT, awsRegion string, instanceIDs []string, commands []string, timeout time.Duration) map[string]*CommandOutput {
+	results, err := RunSsmCommandOnInstancesE(t, awsRegion, instanceIDs, commands, timeout)
+	require.NoError(t, err)
+	return results
+}
+
+// RunSsmCommandOnInstancesE runs the given shell commands on the given EC2 instances (e.g., all the instances of an
+// ASG) through a single SSM Run Command, waits up to the given timeout for them to complete on every instance, and
+// returns their output and exit code, by instance ID. See RunSsmCommandE.
+func RunSsmCommandOnInstancesE(t testing.TestingT, awsRegion string, instanceIDs []string, commands []string, timeout time.Duration) (map[string]*CommandOutput, error) {
+	client, err := NewSsmClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Default.Logf(t, "Running commands %v on EC2 instances %v through SSM", commands, instanceIDs)
+	sent, err := client.SendCommand(context.Background(), &ssm.SendCommandInput{
+		Comment:      aws.String("Terratest SSM"),
+		DocumentName: aws.String("AWS-RunShellScript"),
+		InstanceIds:  instanceIDs,
+		Parameters:   map[string][]string{"commands": commands},
+	})
+	if err != nil {
+		return nil, err
+	}
+	commandID := aws.ToString(sent.Command.CommandId)
+
+	timeBetweenRetries := 2 * time.Second
+	results := map[string]*CommandOutput{}
+	for _, instanceID := range instanceIDs {
+		invocation, err := waitForE(t,
+			fmt.Sprintf("Waiting for SSM command %s to complete on %s", commandID, instanceID),
+			func() (*ssm.GetCommandInvocationOutput, error) {
+				invocation, err := client.GetCommandInvocation(context.Background(), &ssm.GetCommandInvocationInput{
+					CommandId:  aws.String(commandID),
+					InstanceId: aws.String(instanceID),
+				})
+				// The invocation takes a moment to appear after the command is sent
+				var notYet *types.InvocationDoesNotExist
+				if errors.As(err, &notYet) {
+					return nil, nil
+				}
+				return invocation, err
+			},
+			func(invocation *ssm.GetCommandInvocationOutput) bool {
+				return invocation != nil && isSsmCommandInvocationDone(invocation.Status)
+			},
+			fixedWaitPolicy(int(timeout/timeBetweenRetries)+1, timeBetweenRetries),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &CommandOutput{
+			Stdout:   aws.ToString(invocation.StandardOutputContent),
+			Stderr:   aws.ToString(invocation.StandardErrorContent),
+			ExitCode: int64(invocation.ResponseCode),
+		}
+		if err := ssmCommandInvocationError(invocation.Status, aws.ToString(invocation.StatusDetails), invocation.ResponseCode); err != nil {
+			return nil, fmt.Errorf("SSM command %s did not run to completion on %s: %w (stderr: %s)", commandID, instanceID, err, result.Stderr)
+		}
+		results[instanceID] = result
+	}
+
+	return results, nil
+}
+
+// isSsmCommandInvocationDone returns true if a command invocation with the given status won't make progress anymore.
+func isSsmCommandInvocationDone(status types.CommandInvocationStatus) bool {
+	switch status {
+	case types.CommandInvocationStatusSuccess, types.CommandInvocationStatusFailed, types.CommandInvocationStatusCancelled, types.CommandInvocationStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// ssmCommandInvocationError returns an error if a command invocation with the given final status did not run to
+// completion. Failed invocations whose commands exited with a non-zero code ran to completion; those that could not
+// be delivered or run have a response code of -1.
+func ssmCommandInvocationError(status types.CommandInvocationStatus, statusDetails string, responseCode int32) error {
+	switch status {
+	case types.CommandInvocationStatusSuccess:
+		return nil
+	case types.CommandInvocationStatusFailed:
+		if responseCode >= 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("status %s (%s)", status, statusDetails)
+}
+
+// SsmPortForwardingSession is a Session Manager port forwarding session, started by StartSsmPortForwardingSessionE,
+// that forwards connections to localhost:LocalPort.
+type SsmPortForwardingSession struct {
+	LocalPort int
+
+	sessionID string
+	region    string
+	cmd       *exec.Cmd
+}
+
+// Close stops forwarding the port and terminates the session.
+func (session *SsmPortForwardingSession) Close(t testing.TestingT) {
+	logger.Default.Logf(t, "Terminating SSM session %s", session.sessionID)
+	if session.cmd.Process != nil {
+		session.cmd.Process.Kill()
+		session.cmd.Wait()
+	}
+	if client, err := NewSsmClientE(t, session.region); err == nil {
+		client.TerminateSession(context.Background(), &ssm.TerminateSessionInput{SessionId: aws.String(session.sessionID)})
+	}
+}
+
+// StartSsmPortForwardingSession starts forwarding a local port through the given EC2 instance with Session Manager.
+// See StartSsmPortForwardingSessionE.
+func StartSsmPortForwardingSession(t testing.TestingT, awsRegion string, instanceID string, remoteHost string, remotePort int, localPort int) *SsmPortForwardingSession {
+	session, err := StartSsmPortForwardingSessionE(t, awsRegion, instanceID, remoteHost, remotePort, localPort)
+	require.NoError(t, err)
+	return session
+}
+
+// StartSsmPortForwardingSessionE starts forwarding the given local port (a free one if 0) to the given port of the
+// given EC2 instance, or, if remoteHost is not empty, to the given port of that host (e.g., an RDS endpoint) through
+// the instance, with Session Manager, and waits until the local port accepts connections. This lets tests reach
+// services in VPCs without SSH or public ingress. As for the AWS CLI, the session-manager-plugin binary must be
+// installed on the test runner. Call Close on the returned session when done.
+func StartSsmPortForwardingSessionE(t testing.TestingT, awsRegion string, instanceID string, remoteHost string, remotePort int, localPort int) (*SsmPortForwardingSession, error) {
+	client, err := NewSsmClientE(t, awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	if localPort == 0 {
+		if localPort, err = getFreeLocalPortE(); err != nil {
+			return nil, err
+		}
+	}
+
+	input := &ssm.StartSessionInput{
+		Target:       aws.String(instanceID),
+		DocumentName: aws.String("AWS-StartPortForwardingSession"),
+		Parameters: map[string][]string{
+			"portNumber":      {strconv.Itoa(remotePort)},
+			"localPortNumber": {strconv.Itoa(localPort)},
+		},
+	}
+	if remoteHost != "" {
+		input.DocumentName = aws.String("AWS-StartPortForwardingSessionToRemoteHost")
+		input.Parameters["host"] = []string{remoteHost}
+	}
+	output, err := client.StartSession(context.Background(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run the session the same way the AWS CLI does. The command line isn't logged, as it contains the session token.
+	target := instanceID
+	if remoteHost != "" {
+		target = remoteHost
+	}
+	logger.Default.Logf(t, "Forwarding localhost:%d to %s:%d through EC2 instance %s with SSM session %s", localPort, target, remotePort, instanceID, aws.ToString(output.SessionId))
+	sessionJSON, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	session := &SsmPortForwardingSession{
+		LocalPort: localPort,
+		sessionID: aws.ToString(output.SessionId),
+		region:    awsRegion,
+		cmd: exec.Command("session-manager-plugin",
+			string(sessionJSON),
+			awsRegion,
+			"StartSession",
+			"",
+			string(parameters),
+			GetServiceEndpoint("ssm", awsRegion),
+		),
+	}
+	if err := session.cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort("localhost", strconv.Itoa(localPort))
+	_, err = waitForE(t,
+		fmt.Sprintf("Waiting for %s to accept connections", address),
+		func() (bool, error) {
+			conn, err := net.DialTimeout("tcp", address, time.Second)
+			if err != nil {
+				return false, err
+			}
+			conn.Close()
+			return true, nil
+		},
+		func(connected bool) bool { return connected },
+		ssmPortForwardingPolicy,
+	)
+	if err != nil {
+		session.Close(t)
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// getFreeLocalPortE returns a TCP port that is free on localhost.
+func getFreeLocalPortE() (int, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
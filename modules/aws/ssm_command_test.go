@@ -0,0 +1,38 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSsmCommandInvocationDone(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, isSsmCommandInvocationDone(types.CommandInvocationStatusPending))
+	assert.False(t, isSsmCommandInvocationDone(types.CommandInvocationStatusInProgress))
+	assert.False(t, isSsmCommandInvocationDone(types.CommandInvocationStatusDelayed))
+	assert.False(t, isSsmCommandInvocationDone(types.CommandInvocationStatusCancelling))
+	assert.True(t, isSsmCommandInvocationDone(types.CommandInvocationStatusSuccess))
+	assert.True(t, isSsmCommandInvocationDone(types.CommandInvocationStatusFailed))
+	assert.True(t, isSsmCommandInvocationDone(types.CommandInvocationStatusTimedOut))
+}
+
+func TestSsmCommandInvocationError(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ssmCommandInvocationError(types.CommandInvocationStatusSuccess, "Success", 0))
+	assert.NoError(t, ssmCommandInvocationError(types.CommandInvocationStatusFailed, "Failed", 2))
+	assert.Error(t, ssmCommandInvocationError(types.CommandInvocationStatusFailed, "Undeliverable", -1))
+	assert.Error(t, ssmCommandInvocationError(types.CommandInvocationStatusTimedOut, "ExecutionTimedOut", -1))
+	assert.Error(t, ssmCommandInvocationError(types.CommandInvocationStatusCancelled, "Cancelled", -1))
+}
+
+func TestGetFreeLocalPort(t *testing.T) {
+	t.Parallel()
+
+	port, err := getFreeLocalPortE()
+	assert.NoError(t, err)
+	assert.Greater(t, port, 0)
+}
@@ -0,0 +1,242 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// StepFunctionsExecution is the result of a Step Functions execution.
+type StepFunctionsExecution struct {
+	ExecutionArn string                      // The ARN of the execution
+	Status       string                      // The final status of the execution, e.g., SUCCEEDED, FAILED, TIMED_OUT, or ABORTED
+	Output       string                      // The JSON output of the execution, if it succeeded
+	Error        string                      // The error code of the execution, if it failed
+	Cause        string                      // The cause of the error, if the execution failed
+	Events       []StepFunctionsHistoryEvent // The history of the execution. Always empty for synchronous Express executions.
+}
+
+// StepFunctionsHistoryEvent is an event of the history of a Step Functions execution.
+type StepFunctionsHistoryEvent struct {
+	Id        int64  // The ID of the event, in the order of the history
+	Type      string // The type of the event, e.g., TaskStateEntered, TaskFailed, or LambdaFunctionScheduled
+	StateName string // The name of the state the event belongs to, if any
+}
+
+// StatesEntered returns the names of the states the execution entered, in order. A state appears once per time it was
+// entered, e.g., once per iteration of a Map state.
+func (execution StepFunctionsExecution) StatesEntered() []string {
+	states := []string{}
+	for _, event := range execution.Events {
+		if isStateEnteredEvent(event.Type) {
+			states = append(states, event.StateName)
+		}
+	}
+	return states
+}
+
+// CountEvents returns the number of events of the given type that belong to the given state. For example, the number
+// of retries of a Task state invoking a Lambda function is CountEvents("MyTask", "LambdaFunctionFailed").
+func (execution StepFunctionsExecution) CountEvents(stateName string, eventType string) int {
+	count := 0
+	for _, event := range execution.Events {
+		if event.StateName == stateName && event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+// StartExecutionAndWait starts an execution of the given Standard state machine with the given input, which is
+// converted to JSON, and waits for it to complete. See StartExecutionAndWaitE.
+func StartExecutionAndWait(t testing.TestingT, region string, stateMachineArn string, input interface{}) *StepFunctionsExecution {
+	execution, err := StartExecutionAndWaitE(t, region, stateMachineArn, input)
+	require.NoError(t, err)
+	return execution
+}
+
+// StartExecutionAndWaitE starts an execution of the given Standard state machine with the given input, which is
+// converted to JSON, waits for it to complete according to DefaultWaitPolicy, and returns its final status, output,
+// and history. An execution that fails is not an error: check the Status of the result. Use StartSyncExecutionE for
+// Express state machines.
+func StartExecutionAndWaitE(t testing.TestingT, region string, stateMachineArn string, input interface{}) (*StepFunctionsExecution, error) {
+	executionArn, err := StartExecutionE(t, region, stateMachineArn, input)
+	if err != nil {
+		return nil, err
+	}
+	return WaitForExecutionE(t, region, executionArn, nil)
+}
+
+// StartExecution starts an execution of the given state machine with the given input, which is converted to JSON, and
+// returns the ARN of the execution.
+func StartExecution(t testing.TestingT, region string, stateMachineArn string, input interface{}) string {
+	executionArn, err := StartExecutionE(t, region, stateMachineArn, input)
+	require.NoError(t, err)
+	return executionArn
+}
+
+// StartExecutionE starts an execution of the given state machine with the given input, which is converted to JSON, and
+// returns the ARN of the execution.
+func StartExecutionE(t testing.TestingT, region string, stateMachineArn string, input interface{}) (string, error) {
+	client, err := NewSfnClientE(t, region)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.StartExecution(context.Background(), &sfn.StartExecutionInput{
+		StateMachineArn: aws.String(stateMachineArn),
+		Input:           aws.String(string(payload)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.ExecutionArn), nil
+}
+
+// WaitForExecution waits for the given execution of a Standard state machine to complete. See WaitForExecutionE.
+func WaitForExecution(t testing.TestingT, region string, executionArn string, policy retry.Policy) *StepFunctionsExecution {
+	execution, err := WaitForExecutionE(t, region, executionArn, policy)
+	require.NoError(t, err)
+	return execution
+}
+
+// WaitForExecutionE waits for the given execution of a Standard state machine to complete, retrying according to the
+// given policy (DefaultWaitPolicy if nil), and returns its final status, output, and history.
+func WaitForExecutionE(t testing.TestingT, region string, executionArn string, policy retry.Policy) (*StepFunctionsExecution, error) {
+	client, err := NewSfnClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := waitForE(t,
+		fmt.Sprintf("Waiting for Step Functions execution %s to complete", executionArn),
+		func() (*sfn.DescribeExecutionOutput, error) {
+			return client.DescribeExecution(context.Background(), &sfn.DescribeExecutionInput{ExecutionArn: aws.String(executionArn)})
+		},
+		func(output *sfn.DescribeExecutionOutput) bool {
+			return output.Status != types.ExecutionStatusRunning && output.Status != types.ExecutionStatusPendingRedrive
+		},
+		policy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []types.HistoryEvent
+	paginator := sfn.NewGetExecutionHistoryPaginator(client, &sfn.GetExecutionHistoryInput{ExecutionArn: aws.String(executionArn)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page.Events...)
+	}
+
+	return &StepFunctionsExecution{
+		ExecutionArn: executionArn,
+		Status:       string(output.Status),
+		Output:       aws.ToString(output.Output),
+		Error:        aws.ToString(output.Error),
+		Cause:        aws.ToString(output.Cause),
+		Events:       newStepFunctionsHistoryEvents(events),
+	}, nil
+}
+
+// StartSyncExecution runs the given Express state machine synchronously with the given input, which is converted to
+// JSON. See StartSyncExecutionE.
+func StartSyncExecution(t testing.TestingT, region string, stateMachineArn string, input interface{}) *StepFunctionsExecution {
+	execution, err := StartSyncExecutionE(t, region, stateMachineArn, input)
+	require.NoError(t, err)
+	return execution
+}
+
+// StartSyncExecutionE runs the given Express state machine synchronously with the given input, which is converted to
+// JSON, and returns its final status and output. The history of Express executions is not available from the API, so
+// the Events of the result are empty. An execution that fails is not an error: check the Status of the result.
+func StartSyncExecutionE(t testing.TestingT, region string, stateMachineArn string, input interface{}) (*StepFunctionsExecution, error) {
+	client, err := NewSfnClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.StartSyncExecution(context.Background(), &sfn.StartSyncExecutionInput{
+		StateMachineArn: aws.String(stateMachineArn),
+		Input:           aws.String(string(payload)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StepFunctionsExecution{
+		ExecutionArn: aws.ToString(output.ExecutionArn),
+		Status:       string(output.Status),
+		Output:       aws.ToString(output.Output),
+		Error:        aws.ToString(output.Error),
+		Cause:        aws.ToString(output.Cause),
+		Events:       []StepFunctionsHistoryEvent{},
+	}, nil
+}
+
+// newStepFunctionsHistoryEvents converts the given history events, attributing each event to the state it belongs to
+// by following the chain of previous events back to the last state entered, and stopping at the exit of that state. This works for the parallel branches of
+// Parallel and Map states too, since each branch has its own chain.
+func newStepFunctionsHistoryEvents(events []types.HistoryEvent) []StepFunctionsHistoryEvent {
+	stateNames := map[int64]string{}
+	result := []StepFunctionsHistoryEvent{}
+	for _, event := range events {
+		stateName := stateNames[event.PreviousEventId]
+		if event.StateEnteredEventDetails != nil {
+			stateName = aws.ToString(event.StateEnteredEventDetails.Name)
+		}
+		stateNames[event.Id] = stateName
+		if event.StateExitedEventDetails != nil {
+			stateNames[event.Id] = ""
+		}
+		result = append(result, StepFunctionsHistoryEvent{
+			Id:        event.Id,
+			Type:      string(event.Type),
+			StateName: stateName,
+		})
+	}
+	return result
+}
+
+// isStateEnteredEvent returns true if the given event type is the entry into a state, e.g., TaskStateEntered.
+func isStateEnteredEvent(eventType string) bool {
+	return strings.HasSuffix(eventType, "StateEntered")
+}
+
+// NewSfnClient creates a Step Functions client.
+func NewSfnClient(t testing.TestingT, region string) *sfn.Client {
+	client, err := NewSfnClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewSfnClientE creates a Step Functions client.
+func NewSfnClientE(t testing.TestingT, region string) (*sfn.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return sfn.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,34 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStepFunctionsHistoryEvents(t *testing.T) {
+	t.Parallel()
+
+	events := []types.HistoryEvent{
+		{Id: 1, Type: types.HistoryEventTypeExecutionStarted},
+		{Id: 2, PreviousEventId: 1, Type: types.HistoryEventTypeTaskStateEntered, StateEnteredEventDetails: &types.StateEnteredEventDetails{Name: aws.String("Process")}},
+		{Id: 3, PreviousEventId: 2, Type: types.HistoryEventTypeLambdaFunctionScheduled},
+		{Id: 4, PreviousEventId: 3, Type: types.HistoryEventTypeLambdaFunctionFailed},
+		{Id: 5, PreviousEventId: 4, Type: types.HistoryEventTypeLambdaFunctionScheduled},
+		{Id: 6, PreviousEventId: 5, Type: types.HistoryEventTypeLambdaFunctionSucceeded},
+		{Id: 7, PreviousEventId: 6, Type: types.HistoryEventTypeTaskStateExited, StateExitedEventDetails: &types.StateExitedEventDetails{Name: aws.String("Process")}},
+		{Id: 8, PreviousEventId: 7, Type: types.HistoryEventTypeSucceedStateEntered, StateEnteredEventDetails: &types.StateEnteredEventDetails{Name: aws.String("Done")}},
+		{Id: 9, PreviousEventId: 8, Type: types.HistoryEventTypeSucceedStateExited, StateExitedEventDetails: &types.StateExitedEventDetails{Name: aws.String("Done")}},
+		{Id: 10, PreviousEventId: 9, Type: types.HistoryEventTypeExecutionSucceeded},
+	}
+
+	execution := StepFunctionsExecution{Events: newStepFunctionsHistoryEvents(events)}
+
+	assert.Equal(t, []string{"Process", "Done"}, execution.StatesEntered())
+	assert.Equal(t, 1, execution.CountEvents("Process", "LambdaFunctionFailed"))
+	assert.Equal(t, 2, execution.CountEvents("Process", "LambdaFunctionScheduled"))
+	assert.Equal(t, 1, execution.CountEvents("", "ExecutionSucceeded"))
+	assert.Equal(t, "Done", execution.Events[8].StateName)
+}
@@ -0,0 +1,199 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// maxTaggingApiArns is the maximum number of ARNs that can be passed to a single GetResources call.
+const maxTaggingApiArns = 100
+
+// FindResourcesByTags returns the ARNs of the resources in the given region that have all the given tags, grouped by
+// service (e.g., "ec2", "s3"). An empty tag value matches any value of the tag.
+func FindResourcesByTags(t testing.TestingT, region string, tags map[string]string) map[string][]string {
+	arns, err := FindResourcesByTagsE(t, region, tags)
+	require.NoError(t, err)
+	return arns
+}
+
+// FindResourcesByTagsE returns the ARNs of the resources in the given region that have all the given tags, grouped by
+// service (e.g., "ec2", "s3"). An empty tag value matches any value of the tag. This uses the Resource Groups Tagging
+// API, so it finds resources of all the services that support tagging, but not resources that were never tagged.
+func FindResourcesByTagsE(t testing.TestingT, region string, tags map[string]string) (map[string][]string, error) {
+	client, err := NewResourceGroupsTaggingClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []types.TagFilter{}
+	for key, value := range tags {
+		filter := types.TagFilter{Key: aws.String(key)}
+		if value != "" {
+			filter.Values = []string{value}
+		}
+		filters = append(filters, filter)
+	}
+
+	arnsByService := map[string][]string{}
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(client, &resourcegroupstaggingapi.GetResourcesInput{TagFilters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, mapping := range page.ResourceTagMappingList {
+			arn := aws.ToString(mapping.ResourceARN)
+			service := serviceFromArn(arn)
+			arnsByService[service] = append(arnsByService[service], arn)
+		}
+	}
+
+	for service := range arnsByService {
+		sort.Strings(arnsByService[service])
+	}
+	return arnsByService, nil
+}
+
+// GetTagsForResources returns the tags of each of the given resource ARNs. Resources that have no tags are returned
+// with an empty map.
+func GetTagsForResources(t testing.TestingT, region string, arns []string) map[string]map[string]string {
+	tags, err := GetTagsForResourcesE(t, region, arns)
+	require.NoError(t, err)
+	return tags
+}
+
+// GetTagsForResourcesE returns the tags of each of the given resource ARNs. Resources that have no tags are returned
+// with an empty map.
+func GetTagsForResourcesE(t testing.TestingT, region string, arns []string) (map[string]map[string]string, error) {
+	tags, err := getReturnedTagsE(t, region, arns)
+	if err != nil {
+		return nil, err
+	}
+	for _, arn := range arns {
+		if _, returned := tags[arn]; !returned {
+			tags[arn] = map[string]string{}
+		}
+	}
+	return tags, nil
+}
+
+// getReturnedTagsE returns the tags of the given resource ARNs that the Resource Groups Tagging API returns. It doesn't
+// return resources that were never tagged, resources that don't support tagging (e.g., SNS topic subscriptions), or
+// resources in other regions, and there is no way to tell these apart.
+func getReturnedTagsE(t testing.TestingT, region string, arns []string) (map[string]map[string]string, error) {
+	client, err := NewResourceGroupsTaggingClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]map[string]string{}
+	for start := 0; start < len(arns); start += maxTaggingApiArns {
+		end := start + maxTaggingApiArns
+		if end > len(arns) {
+			end = len(arns)
+		}
+
+		paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(client, &resourcegroupstaggingapi.GetResourcesInput{ResourceARNList: arns[start:end]})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			for _, mapping := range page.ResourceTagMappingList {
+				resourceTags := map[string]string{}
+				for _, tag := range mapping.Tags {
+					resourceTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+				}
+				tags[aws.ToString(mapping.ResourceARN)] = resourceTags
+			}
+		}
+	}
+	return tags, nil
+}
+
+// AssertResourcesHaveTags checks that each of the given resource ARNs (e.g., the ARNs of all the resources created by
+// terraform apply) has all the expected tags, failing the test if any resource misses a tag or has a different value.
+// An empty expected value only checks that the tag is set.
+//
+// Only the resources returned by the Resource Groups Tagging API are checked. The others (resources that don't support
+// tagging, such as SNS topic subscriptions, resources in other regions, but also resources that were never tagged) are
+// skipped and logged, as the API can't tell them apart.
+func AssertResourcesHaveTags(t testing.TestingT, region string, arns []string, expectedTags map[string]string) {
+	tags, err := getReturnedTagsE(t, region, arns)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if skipped := arnsNotReturned(arns, tags); len(skipped) > 0 {
+		logger.Default.Logf(t, "Not checking the tags of resources that the tagging API in %s doesn't return: %s", region, strings.Join(skipped, ", "))
+	}
+
+	problems := missingTags(tags, expectedTags)
+	assert.Emptyf(t, problems, "Resources are missing expected tags:\n%s", strings.Join(problems, "\n"))
+}
+
+// missingTags returns a description of each expected tag that one of the given resources misses, sorted by ARN.
+func missingTags(tags map[string]map[string]string, expectedTags map[string]string) []string {
+	problems := []string{}
+	for arn, resourceTags := range tags {
+		for key, expectedValue := range expectedTags {
+			value, hasTag := resourceTags[key]
+			switch {
+			case !hasTag:
+				problems = append(problems, fmt.Sprintf("%s: tag %s is missing", arn, key))
+			case expectedValue != "" && value != expectedValue:
+				problems = append(problems, fmt.Sprintf("%s: tag %s is %q instead of %q", arn, key, value, expectedValue))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// arnsNotReturned returns the given ARNs that have no entry in tags, sorted alphabetically.
+func arnsNotReturned(arns []string, tags map[string]map[string]string) []string {
+	notReturned := []string{}
+	for _, arn := range arns {
+		if _, returned := tags[arn]; !returned {
+			notReturned = append(notReturned, arn)
+		}
+	}
+	sort.Strings(notReturned)
+	return notReturned
+}
+
+// serviceFromArn returns the service of the given ARN, e.g., "ec2" for arn:aws:ec2:us-east-1:123456789012:instance/i-1.
+func serviceFromArn(arn string) string {
+	parts := strings.SplitN(arn, ":", 4)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// NewResourceGroupsTaggingClient creates a new Resource Groups Tagging API client.
+func NewResourceGroupsTaggingClient(t testing.TestingT, region string) *resourcegroupstaggingapi.Client {
+	client, err := NewResourceGroupsTaggingClientE(t, region)
+	require.NoError(t, err)
+	return client
+}
+
+// NewResourceGroupsTaggingClientE creates a new Resource Groups Tagging API client.
+func NewResourceGroupsTaggingClientE(t testing.TestingT, region string) (*resourcegroupstaggingapi.Client, error) {
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return resourcegroupstaggingapi.NewFromConfig(*sess), nil
+}
@@ -0,0 +1,42 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceFromArn(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "ec2", serviceFromArn("arn:aws:ec2:us-east-1:123456789012:instance/i-1"))
+	assert.Equal(t, "s3", serviceFromArn("arn:aws:s3:::my-bucket"))
+	assert.Equal(t, "", serviceFromArn("not-an-arn"))
+}
+
+func TestMissingTags(t *testing.T) {
+	t.Parallel()
+
+	tags := map[string]map[string]string{
+		"arn:aws:s3:::a":  {"Owner": "team", "Env": "test"},
+		"arn:aws:s3:::b":  {"Owner": "other"},
+		"arn:aws:sqs:::c": {},
+	}
+	expectedTags := map[string]string{"Owner": "team", "Env": ""}
+
+	assert.Equal(t, []string{
+		"arn:aws:s3:::b: tag Env is missing",
+		`arn:aws:s3:::b: tag Owner is "other" instead of "team"`,
+		"arn:aws:sqs:::c: tag Env is missing",
+		"arn:aws:sqs:::c: tag Owner is missing",
+	}, missingTags(tags, expectedTags))
+}
+
+func TestArnsNotReturned(t *testing.T) {
+	t.Parallel()
+
+	tags := map[string]map[string]string{"arn:aws:sns:us-east-1:123456789012:topic": {"Owner": "team"}}
+	arns := []string{"arn:aws:sns:us-east-1:123456789012:topic:sub", "arn:aws:sns:us-east-1:123456789012:topic", "arn:aws:s3:::a"}
+
+	assert.Equal(t, []string{"arn:aws:s3:::a", "arn:aws:sns:us-east-1:123456789012:topic:sub"}, arnsNotReturned(arns, tags))
+}
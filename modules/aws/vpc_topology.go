@@ -0,0 +1,487 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RouteTable is a route table of a VPC.
+type RouteTable struct {
+	Id        string            // The ID of the route table
+	VpcId     string            // The ID of the VPC of the route table
+	Main      bool              // If the route table is the main one of the VPC, used by the subnets without explicit association
+	SubnetIds []string          // The IDs of the subnets explicitly associated with the route table
+	Routes    []Route           // The routes of the route table
+	Tags      map[string]string // The tags associated with the route table
+}
+
+// Route is a route of a route table.
+type Route struct {
+	Destination string // The destination IPv4 CIDR block, IPv6 CIDR block, or prefix list ID
+	TargetId    string // The ID of the target, e.g., local, igw-..., nat-..., tgw-..., pcx-..., or vpce-...
+	State       string // The state of the route: active or blackhole
+}
+
+// NatGateway is a NAT gateway.
+type NatGateway struct {
+	Id               string            // The ID of the NAT gateway
+	VpcId            string            // The ID of the VPC of the NAT gateway
+	SubnetId         string            // The ID of the subnet the NAT gateway is in
+	State            string            // The state of the NAT gateway, e.g., available
+	ConnectivityType string            // public or private
+	PublicIps        []string          // The Elastic IP addresses of the NAT gateway
+	Tags             map[string]string // The tags associated with the NAT gateway
+}
+
+// InternetGateway is an internet gateway.
+type InternetGateway struct {
+	Id     string            // The ID of the internet gateway
+	VpcIds []string          // The IDs of the VPCs the internet gateway is attached to
+	Tags   map[string]string // The tags associated with the internet gateway
+}
+
+// VpcEndpoint is a VPC endpoint.
+type VpcEndpoint struct {
+	Id                string   // The ID of the VPC endpoint
+	ServiceName       string   // The name of the service, e.g., com.amazonaws.us-east-1.s3
+	Type              string   // Gateway, Interface, or GatewayLoadBalancer
+	State             string   // The state of the VPC endpoint, e.g., available
+	SubnetIds         []string // The IDs of the subnets of an interface endpoint
+	RouteTableIds     []string // The IDs of the route tables of a gateway endpoint
+	PrivateDnsEnabled bool     // If the private DNS names of the service resolve to an interface endpoint
+}
+
+// VpcPeeringConnection is a VPC peering connection.
+type VpcPeeringConnection struct {
+	Id             string // The ID of the peering connection
+	RequesterVpcId string // The ID of the VPC that requested the peering connection
+	AccepterVpcId  string // The ID of the VPC that accepted the peering connection
+	Status         string // The status of the peering connection, e.g., active or pending-acceptance
+}
+
+// FlowLog is a flow log of a VPC, subnet, or network interface.
+type FlowLog struct {
+	Id                 string // The ID of the flow log
+	ResourceId         string // The ID of the resource the flow log captures the traffic of
+	TrafficType        string // ACCEPT, REJECT, or ALL
+	LogDestinationType string // cloud-watch-logs, s3, or kinesis-data-firehose
+	LogDestination     string // The ARN of the destination, or the name of the log group
+	Status             string // The status of the flow log, e.g., ACTIVE
+}
+
+// GetRouteTablesForVpc fetches the route tables of the given VPC.
+func GetRouteTablesForVpc(t testing.TestingT, vpcID string, region string) []RouteTable {
+	routeTables, err := GetRouteTablesForVpcE(t, vpcID, region)
+	require.NoError(t, err)
+	return routeTables
+}
+
+// GetRouteTablesForVpcE fetches the route tables of the given VPC.
+func GetRouteTablesForVpcE(t testing.TestingT, vpcID string, region string) ([]RouteTable, error) {
+	return getRouteTablesE(t, region, []types.Filter{generateVpcIdFilter(vpcID)})
+}
+
+// GetRouteTableForSubnet fetches the route table used by the given subnet.
+func GetRouteTableForSubnet(t testing.TestingT, subnetID string, region string) RouteTable {
+	routeTable, err := GetRouteTableForSubnetE(t, subnetID, region)
+	require.NoError(t, err)
+	return routeTable
+}
+
+// GetRouteTableForSubnetE fetches the route table used by the given subnet: the one explicitly associated with it, or
+// the main route table of its VPC.
+func GetRouteTableForSubnetE(t testing.TestingT, subnetID string, region string) (RouteTable, error) {
+	routeTables, err := getRouteTablesE(t, region, []types.Filter{{Name: aws.String("association.subnet-id"), Values: []string{subnetID}}})
+	if err != nil {
+		return RouteTable{}, err
+	}
+
+	if len(routeTables) == 0 {
+		// Subnets not explicitly associated with any route table are implicitly associated with the main route table
+		implicit, err := getImplicitRouteTableForSubnetE(t, subnetID, region)
+		if err != nil {
+			return RouteTable{}, err
+		}
+		for _, routeTable := range implicit.RouteTables {
+			routeTables = append(routeTables, newRouteTable(routeTable))
+		}
+	}
+
+	if len(routeTables) != 1 {
+		return RouteTable{}, fmt.Errorf("expected to find one route table for subnet %s but found %d", subnetID, len(routeTables))
+	}
+	return routeTables[0], nil
+}
+
+// AssertSubnetRoutesTo checks that the route table of the given subnet routes the given destination (e.g., 0.0.0.0/0)
+// to the given target (e.g., the ID of a NAT gateway) and fails the test if it does not.
+func AssertSubnetRoutesTo(t testing.TestingT, subnetID string, destination string, targetID string, region string) {
+	err := AssertSubnetRoutesToE(t, subnetID, destination, targetID, region)
+	require.NoError(t, err)
+}
+
+// AssertSubnetRoutesToE checks that the route table of the given subnet routes the given destination (an IPv4 or IPv6
+// CIDR block, or a prefix list ID) to the given target (e.g., the ID of a NAT gateway, or "local") with an active
+// route, and returns an error if it does not.
+func AssertSubnetRoutesToE(t testing.TestingT, subnetID string, destination string, targetID string, region string) error {
+	routeTable, err := GetRouteTableForSubnetE(t, subnetID, region)
+	if err != nil {
+		return err
+	}
+
+	route, found := routeTable.RouteTo(destination)
+	if !found {
+		return fmt.Errorf("route table %s of subnet %s has no route to %s", routeTable.Id, subnetID, destination)
+	}
+	if route.TargetId != targetID || route.State != string(types.RouteStateActive) {
+		return fmt.Errorf("route table %s of subnet %s routes %s to %s (%s), not %s", routeTable.Id, subnetID, destination, route.TargetId, route.State, targetID)
+	}
+	return nil
+}
+
+// RouteTo returns the route of the route table for the given destination, if any.
+func (routeTable RouteTable) RouteTo(destination string) (Route, bool) {
+	for _, route := range routeTable.Routes {
+		if route.Destination == destination {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// getRouteTablesE fetches the route tables matching the given filters.
+func getRouteTablesE(t testing.TestingT, region string, filters []types.Filter) ([]RouteTable, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	routeTables := []RouteTable{}
+	paginator := ec2.NewDescribeRouteTablesPaginator(client, &ec2.DescribeRouteTablesInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, routeTable := range page.RouteTables {
+			routeTables = append(routeTables, newRouteTable(routeTable))
+		}
+	}
+	return routeTables, nil
+}
+
+// newRouteTable converts the given EC2 route table.
+func newRouteTable(routeTable types.RouteTable) RouteTable {
+	result := RouteTable{
+		Id:        aws.ToString(routeTable.RouteTableId),
+		VpcId:     aws.ToString(routeTable.VpcId),
+		SubnetIds: []string{},
+		Routes:    []Route{},
+		Tags:      ec2TagsToMap(routeTable.Tags),
+	}
+	for _, association := range routeTable.Associations {
+		if aws.ToBool(association.Main) {
+			result.Main = true
+		}
+		if association.SubnetId != nil {
+			result.SubnetIds = append(result.SubnetIds, aws.ToString(association.SubnetId))
+		}
+	}
+	for _, route := range routeTable.Routes {
+		result.Routes = append(result.Routes, newRoute(route))
+	}
+	return result
+}
+
+// newRoute converts the given EC2 route, whose target is in one of several fields depending on its type.
+func newRoute(route types.Route) Route {
+	destination := aws.ToString(route.DestinationCidrBlock)
+	if destination == "" {
+		destination = aws.ToString(route.DestinationIpv6CidrBlock)
+	}
+	if destination == "" {
+		destination = aws.ToString(route.DestinationPrefixListId)
+	}
+
+	var target string
+	for _, candidate := range []*string{
+		route.GatewayId,
+		route.NatGatewayId,
+		route.TransitGatewayId,
+		route.VpcPeeringConnectionId,
+		route.EgressOnlyInternetGatewayId,
+		route.NetworkInterfaceId,
+		route.InstanceId,
+		route.LocalGatewayId,
+		route.CarrierGatewayId,
+		route.CoreNetworkArn,
+	} {
+		if aws.ToString(candidate) != "" {
+			target = aws.ToString(candidate)
+			break
+		}
+	}
+
+	return Route{Destination: destination, TargetId: target, State: string(route.State)}
+}
+
+// GetNatGatewaysForVpc fetches the NAT gateways of the given VPC.
+func GetNatGatewaysForVpc(t testing.TestingT, vpcID string, region string) []NatGateway {
+	natGateways, err := GetNatGatewaysForVpcE(t, vpcID, region)
+	require.NoError(t, err)
+	return natGateways
+}
+
+// GetNatGatewaysForVpcE fetches the NAT gateways of the given VPC, including deleted ones, which remain visible for
+// about an hour.
+func GetNatGatewaysForVpcE(t testing.TestingT, vpcID string, region string) ([]NatGateway, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	natGateways := []NatGateway{}
+	paginator := ec2.NewDescribeNatGatewaysPaginator(client, &ec2.DescribeNatGatewaysInput{Filter: []types.Filter{generateVpcIdFilter(vpcID)}})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, natGateway := range page.NatGateways {
+			publicIps := []string{}
+			for _, address := range natGateway.NatGatewayAddresses {
+				if address.PublicIp != nil {
+					publicIps = append(publicIps, aws.ToString(address.PublicIp))
+				}
+			}
+			natGateways = append(natGateways, NatGateway{
+				Id:               aws.ToString(natGateway.NatGatewayId),
+				VpcId:            aws.ToString(natGateway.VpcId),
+				SubnetId:         aws.ToString(natGateway.SubnetId),
+				State:            string(natGateway.State),
+				ConnectivityType: string(natGateway.ConnectivityType),
+				PublicIps:        publicIps,
+				Tags:             ec2TagsToMap(natGateway.Tags),
+			})
+		}
+	}
+	return natGateways, nil
+}
+
+// GetInternetGatewayForVpc fetches the internet gateway attached to the given VPC.
+func GetInternetGatewayForVpc(t testing.TestingT, vpcID string, region string) InternetGateway {
+	internetGateway, err := GetInternetGatewayForVpcE(t, vpcID, region)
+	require.NoError(t, err)
+	return internetGateway
+}
+
+// GetInternetGatewayForVpcE fetches the internet gateway attached to the given VPC, or returns a NotFoundError if
+// there is none.
+func GetInternetGatewayForVpcE(t testing.TestingT, vpcID string, region string) (InternetGateway, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return InternetGateway{}, err
+	}
+
+	output, err := client.DescribeInternetGateways(context.Background(), &ec2.DescribeInternetGatewaysInput{
+		Filters: []types.Filter{{Name: aws.String("attachment.vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return InternetGateway{}, err
+	}
+	if len(output.InternetGateways) == 0 {
+		return InternetGateway{}, NewNotFoundError("internet gateway for VPC", vpcID, region)
+	}
+
+	internetGateway := output.InternetGateways[0]
+	vpcIds := []string{}
+	for _, attachment := range internetGateway.Attachments {
+		vpcIds = append(vpcIds, aws.ToString(attachment.VpcId))
+	}
+	return InternetGateway{
+		Id:     aws.ToString(internetGateway.InternetGatewayId),
+		VpcIds: vpcIds,
+		Tags:   ec2TagsToMap(internetGateway.Tags),
+	}, nil
+}
+
+// GetVpcEndpointsForVpc fetches the VPC endpoints of the given VPC.
+func GetVpcEndpointsForVpc(t testing.TestingT, vpcID string, region string) []VpcEndpoint {
+	endpoints, err := GetVpcEndpointsForVpcE(t, vpcID, region)
+	require.NoError(t, err)
+	return endpoints
+}
+
+// GetVpcEndpointsForVpcE fetches the VPC endpoints of the given VPC.
+func GetVpcEndpointsForVpcE(t testing.TestingT, vpcID string, region string) ([]VpcEndpoint, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []VpcEndpoint{}
+	paginator := ec2.NewDescribeVpcEndpointsPaginator(client, &ec2.DescribeVpcEndpointsInput{Filters: []types.Filter{generateVpcIdFilter(vpcID)}})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, endpoint := range page.VpcEndpoints {
+			endpoints = append(endpoints, VpcEndpoint{
+				Id:                aws.ToString(endpoint.VpcEndpointId),
+				ServiceName:       aws.ToString(endpoint.ServiceName),
+				Type:              string(endpoint.VpcEndpointType),
+				State:             string(endpoint.State),
+				SubnetIds:         endpoint.SubnetIds,
+				RouteTableIds:     endpoint.RouteTableIds,
+				PrivateDnsEnabled: aws.ToBool(endpoint.PrivateDnsEnabled),
+			})
+		}
+	}
+	return endpoints, nil
+}
+
+// AssertVpcHasEndpoint checks that the given VPC has an available endpoint for the given service (e.g.,
+// com.amazonaws.us-east-1.s3).
+func AssertVpcHasEndpoint(t testing.TestingT, vpcID string, serviceName string, region string) {
+	endpoints, err := GetVpcEndpointsForVpcE(t, vpcID, region)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.ServiceName == serviceName && endpoint.State == "available" {
+			return
+		}
+	}
+	assert.Failf(t, "VPC endpoint not found", "VPC %s has no available endpoint for %s: %v", vpcID, serviceName, endpoints)
+}
+
+// GetVpcPeeringConnectionsForVpc fetches the peering connections that the given VPC requested or accepted.
+func GetVpcPeeringConnectionsForVpc(t testing.TestingT, vpcID string, region string) []VpcPeeringConnection {
+	connections, err := GetVpcPeeringConnectionsForVpcE(t, vpcID, region)
+	require.NoError(t, err)
+	return connections
+}
+
+// GetVpcPeeringConnectionsForVpcE fetches the peering connections that the given VPC requested or accepted.
+func GetVpcPeeringConnectionsForVpcE(t testing.TestingT, vpcID string, region string) ([]VpcPeeringConnection, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := []VpcPeeringConnection{}
+	for _, filterName := range []string{"requester-vpc-info.vpc-id", "accepter-vpc-info.vpc-id"} {
+		paginator := ec2.NewDescribeVpcPeeringConnectionsPaginator(client, &ec2.DescribeVpcPeeringConnectionsInput{
+			Filters: []types.Filter{{Name: aws.String(filterName), Values: []string{vpcID}}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			for _, connection := range page.VpcPeeringConnections {
+				result := VpcPeeringConnection{Id: aws.ToString(connection.VpcPeeringConnectionId)}
+				if connection.RequesterVpcInfo != nil {
+					result.RequesterVpcId = aws.ToString(connection.RequesterVpcInfo.VpcId)
+				}
+				if connection.AccepterVpcInfo != nil {
+					result.AccepterVpcId = aws.ToString(connection.AccepterVpcInfo.VpcId)
+				}
+				if connection.Status != nil {
+					result.Status = string(connection.Status.Code)
+				}
+				connections = append(connections, result)
+			}
+		}
+	}
+	return connections, nil
+}
+
+// AssertVpcPeeredWith checks that there is an active peering connection between the given VPCs, in either direction.
+func AssertVpcPeeredWith(t testing.TestingT, vpcID string, peerVpcID string, region string) {
+	connections, err := GetVpcPeeringConnectionsForVpcE(t, vpcID, region)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, connection := range connections {
+		peers := (connection.RequesterVpcId == vpcID && connection.AccepterVpcId == peerVpcID) ||
+			(connection.RequesterVpcId == peerVpcID && connection.AccepterVpcId == vpcID)
+		if peers && connection.Status == string(types.VpcPeeringConnectionStateReasonCodeActive) {
+			return
+		}
+	}
+	assert.Failf(t, "VPC peering connection not found", "No active peering connection between VPCs %s and %s: %v", vpcID, peerVpcID, connections)
+}
+
+// GetFlowLogsForResource fetches the flow logs of the given VPC, subnet, or network interface.
+func GetFlowLogsForResource(t testing.TestingT, resourceID string, region string) []FlowLog {
+	flowLogs, err := GetFlowLogsForResourceE(t, resourceID, region)
+	require.NoError(t, err)
+	return flowLogs
+}
+
+// GetFlowLogsForResourceE fetches the flow logs of the given VPC, subnet, or network interface.
+func GetFlowLogsForResourceE(t testing.TestingT, resourceID string, region string) ([]FlowLog, error) {
+	client, err := NewEc2ClientE(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	flowLogs := []FlowLog{}
+	paginator := ec2.NewDescribeFlowLogsPaginator(client, &ec2.DescribeFlowLogsInput{
+		Filter: []types.Filter{{Name: aws.String("resource-id"), Values: []string{resourceID}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, flowLog := range page.FlowLogs {
+			destination := aws.ToString(flowLog.LogDestination)
+			if destination == "" {
+				destination = aws.ToString(flowLog.LogGroupName)
+			}
+			flowLogs = append(flowLogs, FlowLog{
+				Id:                 aws.ToString(flowLog.FlowLogId),
+				ResourceId:         aws.ToString(flowLog.ResourceId),
+				TrafficType:        string(flowLog.TrafficType),
+				LogDestinationType: string(flowLog.LogDestinationType),
+				LogDestination:     destination,
+				Status:             aws.ToString(flowLog.FlowLogStatus),
+			})
+		}
+	}
+	return flowLogs, nil
+}
+
+// AssertFlowLogsEnabled checks that the given VPC, subnet, or network interface has an active flow log capturing all
+// traffic.
+func AssertFlowLogsEnabled(t testing.TestingT, resourceID string, region string) {
+	flowLogs, err := GetFlowLogsForResourceE(t, resourceID, region)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, flowLog := range flowLogs {
+		if flowLog.Status == "ACTIVE" && flowLog.TrafficType == string(types.TrafficTypeAll) {
+			return
+		}
+	}
+	assert.Failf(t, "Flow log not found", "%s has no active flow log capturing all traffic: %v", resourceID, flowLogs)
+}
+
+// ec2TagsToMap converts the given EC2 tags to a map.
+func ec2TagsToMap(tags []types.Tag) map[string]string {
+	result := map[string]string{}
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRouteTable(t *testing.T) {
+	t.Parallel()
+
+	routeTable := newRouteTable(types.RouteTable{
+		RouteTableId: aws.String("rtb-1"),
+		VpcId:        aws.String("vpc-1"),
+		Associations: []types.RouteTableAssociation{
+			{SubnetId: aws.String("subnet-1")},
+			{SubnetId: aws.String("subnet-2")},
+		},
+		Routes: []types.Route{
+			{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local"), State: types.RouteStateActive},
+			{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1"), State: types.RouteStateActive},
+			{DestinationIpv6CidrBlock: aws.String("::/0"), EgressOnlyInternetGatewayId: aws.String("eigw-1"), State: types.RouteStateActive},
+			{DestinationPrefixListId: aws.String("pl-1"), GatewayId: aws.String("vpce-1"), State: types.RouteStateActive},
+			{DestinationCidrBlock: aws.String("172.16.0.0/16"), VpcPeeringConnectionId: aws.String("pcx-1"), State: types.RouteStateBlackhole},
+		},
+		Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("private")}},
+	})
+
+	assert.Equal(t, "rtb-1", routeTable.Id)
+	assert.Equal(t, "vpc-1", routeTable.VpcId)
+	assert.False(t, routeTable.Main)
+	assert.Equal(t, []string{"subnet-1", "subnet-2"}, routeTable.SubnetIds)
+	assert.Equal(t, map[string]string{"Name": "private"}, routeTable.Tags)
+	assert.Equal(t, []Route{
+		{Destination: "10.0.0.0/16", TargetId: "local", State: "active"},
+		{Destination: "0.0.0.0/0", TargetId: "nat-1", State: "active"},
+		{Destination: "::/0", TargetId: "eigw-1", State: "active"},
+		{Destination: "pl-1", TargetId: "vpce-1", State: "active"},
+		{Destination: "172.16.0.0/16", TargetId: "pcx-1", State: "blackhole"},
+	}, routeTable.Routes)
+
+	route, found := routeTable.RouteTo("0.0.0.0/0")
+	assert.True(t, found)
+	assert.Equal(t, "nat-1", route.TargetId)
+	_, found = routeTable.RouteTo("192.168.0.0/16")
+	assert.False(t, found)
+
+	mainRouteTable := newRouteTable(types.RouteTable{Associations: []types.RouteTableAssociation{{Main: aws.Bool(true)}}})
+	assert.True(t, mainRouteTable.Main)
+	assert.Empty(t, mainRouteTable.SubnetIds)
+}
+
+func TestRouteTablesOfDefaultVpc(t *testing.T) {
+	t.Parallel()
+
+	region := GetRandomStableRegion(t, nil, nil)
+	vpc := GetDefaultVpc(t, region)
+
+	routeTables := GetRouteTablesForVpc(t, vpc.Id, region)
+	assert.NotEmpty(t, routeTables)
+
+	internetGateway := GetInternetGatewayForVpc(t, vpc.Id, region)
+	assert.Contains(t, internetGateway.VpcIds, vpc.Id)
+
+	// The subnets of the default VPC use its main route table, which routes to its internet gateway
+	AssertSubnetRoutesTo(t, vpc.Subnets[0].Id, "0.0.0.0/0", internetGateway.Id, region)
+}
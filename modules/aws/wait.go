@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// DefaultWaitPolicy is the retry policy used by WaitFor when no policy is given: it checks the condition every 5
+// seconds, for up to 5 minutes.
+var DefaultWaitPolicy retry.Policy = retry.BackoffPolicy{MaxRetries: 60, InitialDelay: 5 * time.Second, Multiplier: 1}
+
+// WaitFor calls describe until predicate returns true for its result, and returns that result. Describe errors and
+// unmet conditions are retried according to the given policy (DefaultWaitPolicy if nil), unless describe returns a
+// retry.FatalError. This will fail the test if the condition is never met. For example, to wait for an ASG to scale
+// out:
+//
+//	aws.WaitFor(t,
+//		func() (aws.AsgCapacityInfo, error) { return aws.GetCapacityInfoForAsgE(t, asgName, region) },
+//		func(info aws.AsgCapacityInfo) bool { return info.CurrentCapacity >= 3 },
+//		retry.BackoffPolicy{MaxRetries: 10, InitialDelay: 10 * time.Second, MaxDelay: time.Minute},
+//	)
+func WaitFor[T any](t testing.TestingT, describe func() (T, error), predicate func(T) bool, policy retry.Policy) T {
+	result, err := WaitForE(t, describe, predicate, policy)
+	require.NoError(t, err)
+	return result
+}
+
+// WaitForE calls describe until predicate returns true for its result, and returns that result. Describe errors and
+// unmet conditions are retried according to the given policy (DefaultWaitPolicy if nil), unless describe returns a
+// retry.FatalError. If the policy gives up, the error it returns (e.g., a retry.MaxRetriesExceeded) is returned.
+func WaitForE[T any](t testing.TestingT, describe func() (T, error), predicate func(T) bool, policy retry.Policy) (T, error) {
+	return waitForE(t, "Waiting for AWS condition", describe, predicate, policy)
+}
+
+// waitForE is WaitForE with the given description for the logs and errors.
+func waitForE[T any](t testing.TestingT, description string, describe func() (T, error), predicate func(T) bool, policy retry.Policy) (T, error) {
+	if policy == nil {
+		policy = DefaultWaitPolicy
+	}
+
+	var result T
+	_, err := retry.DoWithPolicyE(t, description, policy, func() (string, error) {
+		out, err := describe()
+		if err != nil {
+			return "", err
+		}
+		if !predicate(out) {
+			return "", WaitConditionNotMet{Description: description}
+		}
+		result = out
+		return "", nil
+	})
+	return result, err
+}
+
+// fixedWaitPolicy returns the policy equivalent to the maxRetries and sleepBetweenRetries args of retry.DoWithRetryE.
+func fixedWaitPolicy(maxRetries int, sleepBetweenRetries time.Duration) retry.Policy {
+	return retry.BackoffPolicy{MaxRetries: maxRetries, InitialDelay: sleepBetweenRetries, Multiplier: 1}
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForE(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	describe := func() (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("throttled")
+		}
+		return calls, nil
+	}
+
+	result, err := WaitForE(t, describe, func(n int) bool { return n >= 3 }, retry.BackoffPolicy{MaxRetries: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result)
+	assert.Equal(t, 3, calls)
+}
+
+func TestWaitForEConditionNeverMet(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	describe := func() (string, error) {
+		calls++
+		return "pending", nil
+	}
+
+	_, err := WaitForE(t, describe, func(state string) bool { return state == "available" }, retry.BackoffPolicy{MaxRetries: 2})
+	assert.IsType(t, retry.MaxRetriesExceeded{}, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestWaitForEFatalError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	describe := func() (string, error) {
+		calls++
+		return "", retry.FatalError{Underlying: errors.New("access denied")}
+	}
+
+	_, err := WaitForE(t, describe, func(string) bool { return true }, retry.BackoffPolicy{MaxRetries: 2})
+	assert.IsType(t, retry.FatalError{}, err)
+	assert.Equal(t, 1, calls)
+}
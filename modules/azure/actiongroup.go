@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/preview/preview/monitor/mgmt/insights"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,7 +12,7 @@ import (
 // ruleName - required to find the ActionGroupResource.
 // resGroupName - use an empty string if you have the AZURE_RES_GROUP_NAME environment variable set
 // subscriptionId - use an empty string if you have the ARM_SUBSCRIPTION_ID environment variable set
-func GetActionGroupResource(t *testing.T, ruleName string, resGroupName string, subscriptionID string) *insights.ActionGroupResource {
+func GetActionGroupResource(t *testing.T, ruleName string, resGroupName string, subscriptionID string) *armmonitor.ActionGroupResource {
 	actionGroupResource, err := GetActionGroupResourceE(ruleName, resGroupName, subscriptionID)
 	require.NoError(t, err)
 
@@ -23,7 +23,7 @@ func GetActionGroupResource(t *testing.T, ruleName string, resGroupName string,
 // ruleName - required to find the ActionGroupResource.
 // resGroupName - use an empty string if you have the AZURE_RES_GROUP_NAME environment variable set
 // subscriptionId - use an empty string if you have the ARM_SUBSCRIPTION_ID environment variable set
-func GetActionGroupResourceE(ruleName string, resGroupName string, subscriptionID string) (*insights.ActionGroupResource, error) {
+func GetActionGroupResourceE(ruleName string, resGroupName string, subscriptionID string) (*armmonitor.ActionGroupResource, error) {
 	rgName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
 		return nil, err
@@ -34,29 +34,15 @@ func GetActionGroupResourceE(ruleName string, resGroupName string, subscriptionI
 		return nil, err
 	}
 
-	actionGroup, err := client.Get(context.Background(), rgName, ruleName)
+	actionGroup, err := client.Get(context.Background(), rgName, ruleName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &actionGroup, nil
+	return &actionGroup.ActionGroupResource, nil
 }
 
 // TODO: remove in next version
-func getActionGroupClient(subscriptionID string) (*insights.ActionGroupsClient, error) {
-	subID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	metricAlertsClient := insights.NewActionGroupsClient(subID)
-
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	metricAlertsClient.Authorizer = *authorizer
-
-	return &metricAlertsClient, nil
+func getActionGroupClient(subscriptionID string) (*armmonitor.ActionGroupsClient, error) {
+	return CreateActionGroupClient(subscriptionID)
 }
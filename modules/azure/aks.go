@@ -3,41 +3,175 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-11-01/containerservice"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v6"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
 	"github.com/gruntwork-io/terratest/modules/testing"
 )
 
+// aksAADServerID is the application ID of the Azure Kubernetes Service AAD Server, which AKS managed Azure AD
+// clusters accept tokens for.
+const aksAADServerID = "6dae42f8-4368-4678-94ff-3960e28e3630"
+
 // GetManagedClustersClientE is a helper function that will setup an Azure ManagedClusters client on your behalf
-func GetManagedClustersClientE(subscriptionID string) (*containerservice.ManagedClustersClient, error) {
+func GetManagedClustersClientE(subscriptionID string) (*armcontainerservice.ManagedClustersClient, error) {
 	// Create a cluster client
-	client, err := CreateManagedClustersClientE(subscriptionID)
+	return CreateManagedClustersClientE(subscriptionID)
+}
+
+// GetManagedClusterE will return ManagedCluster
+func GetManagedClusterE(t testing.TestingT, resourceGroupName, clusterName, subscriptionID string) (*armcontainerservice.ManagedCluster, error) {
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
-
-	// setup authorizer
-	authorizer, err := NewAuthorizer()
+	client, err := GetManagedClustersClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	managedCluster, err := client.Get(context.Background(), resourceGroupName, clusterName, nil)
 	if err != nil {
 		return nil, err
 	}
+	return &managedCluster.ManagedCluster, nil
+}
 
-	client.Authorizer = *authorizer
-	return &client, nil
+// NewKubectlOptionsForAksCluster returns KubectlOptions to use the given AKS cluster with the user credentials of the
+// Azure identity of the test. This will fail the test if there is an error.
+func NewKubectlOptionsForAksCluster(t testing.TestingT, resourceGroupName string, clusterName string, subscriptionID string) *k8s.KubectlOptions {
+	options, err := NewKubectlOptionsForAksClusterE(t, resourceGroupName, clusterName, subscriptionID)
+	require.NoError(t, err)
+	return options
 }
 
-// GetManagedClusterE will return ManagedCluster
-func GetManagedClusterE(t testing.TestingT, resourceGroupName, clusterName, subscriptionID string) (*containerservice.ManagedCluster, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+// NewKubectlOptionsForAksClusterE returns KubectlOptions to use the given AKS cluster (e.g., one created by terraform)
+// with the user credentials of the Azure identity of the test, without shelling out to `az aks get-credentials`. For
+// Azure AD enabled clusters, the token kubelogin would fetch is requested with NewTokenCredentialE instead, so kubelogin
+// does not need to be installed. Note that the token expires after about an hour: call this function again to refresh
+// it in long running tests. The namespace of the returned options is empty; set it as needed.
+func NewKubectlOptionsForAksClusterE(t testing.TestingT, resourceGroupName string, clusterName string, subscriptionID string) (*k8s.KubectlOptions, error) {
+	return NewKubectlOptionsForAksClusterWithOptionsE(t, resourceGroupName, clusterName, subscriptionID, authOptionsFromEnv())
+}
+
+// NewKubectlOptionsForAksClusterWithOptionsE returns KubectlOptions to use the given AKS cluster like
+// NewKubectlOptionsForAksClusterE, with the user credentials of the Azure identity authenticated according to the
+// given AuthOptions.
+func NewKubectlOptionsForAksClusterWithOptionsE(t testing.TestingT, resourceGroupName string, clusterName string, subscriptionID string, authOptions AuthOptions) (*k8s.KubectlOptions, error) {
+	client, err := NewArmClientWithOptionsE(subscriptionID, authOptions, armcontainerservice.NewManagedClustersClient)
 	if err != nil {
 		return nil, err
 	}
+
+	resp, err := client.ListClusterUserCredentials(context.Background(), resourceGroupName, clusterName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newKubectlOptionsFromAksCredentialsE(t, clusterName, resp.CredentialResults, authOptions)
+}
+
+// NewKubectlOptionsForAksClusterAdmin returns KubectlOptions to use the given AKS cluster with its cluster admin
+// credentials. This will fail the test if there is an error.
+func NewKubectlOptionsForAksClusterAdmin(t testing.TestingT, resourceGroupName string, clusterName string, subscriptionID string) *k8s.KubectlOptions {
+	options, err := NewKubectlOptionsForAksClusterAdminE(t, resourceGroupName, clusterName, subscriptionID)
+	require.NoError(t, err)
+	return options
+}
+
+// NewKubectlOptionsForAksClusterAdminE returns KubectlOptions to use the given AKS cluster with its cluster admin
+// credentials, i.e., the same as `az aks get-credentials --admin`. This fails for clusters that have local accounts
+// disabled.
+func NewKubectlOptionsForAksClusterAdminE(t testing.TestingT, resourceGroupName string, clusterName string, subscriptionID string) (*k8s.KubectlOptions, error) {
 	client, err := GetManagedClustersClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
-	managedCluster, err := client.Get(context.Background(), resourceGroupName, clusterName)
+
+	resp, err := client.ListClusterAdminCredentials(context.Background(), resourceGroupName, clusterName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newKubectlOptionsFromAksCredentialsE(t, clusterName, resp.CredentialResults, authOptionsFromEnv())
+}
+
+// newKubectlOptionsFromAksCredentialsE writes the first kubeconfig of the given AKS credentials to a temp file, with
+// the Azure AD auth replaced by a token requested with the given AuthOptions, and returns KubectlOptions for its
+// current context.
+func newKubectlOptionsFromAksCredentialsE(t testing.TestingT, clusterName string, credentials armcontainerservice.CredentialResults, authOptions AuthOptions) (*k8s.KubectlOptions, error) {
+	if len(credentials.Kubeconfigs) == 0 || credentials.Kubeconfigs[0] == nil {
+		return nil, NewNotFoundError("Kubeconfig", clusterName, "AKS cluster credentials")
+	}
+
+	config, err := clientcmd.Load(credentials.Kubeconfigs[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	err = replaceAksAADAuthE(config, func(serverID string) (string, error) {
+		return getAksAADTokenE(serverID, authOptions)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &managedCluster, nil
+
+	configData, err := clientcmd.Write(*config)
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := k8s.StoreConfigToTempFileE(t, string(configData))
+	if err != nil {
+		return nil, err
+	}
+
+	return k8s.NewKubectlOptions(config.CurrentContext, configPath, ""), nil
+}
+
+// replaceAksAADAuthE replaces the kubelogin exec plugin and the legacy azure auth provider, which AKS uses in the user
+// credentials of Azure AD enabled clusters, with a bearer token for the server ID they are configured with.
+func replaceAksAADAuthE(config *api.Config, getToken func(serverID string) (string, error)) error {
+	for _, authInfo := range config.AuthInfos {
+		serverID := ""
+		switch {
+		case authInfo.Exec != nil:
+			serverID = aksAADServerID
+			for i, arg := range authInfo.Exec.Args {
+				if arg == "--server-id" && i+1 < len(authInfo.Exec.Args) {
+					serverID = authInfo.Exec.Args[i+1]
+				}
+			}
+		case authInfo.AuthProvider != nil && authInfo.AuthProvider.Name == "azure":
+			serverID = authInfo.AuthProvider.Config["apiserver-id"]
+			if serverID == "" {
+				serverID = aksAADServerID
+			}
+		default:
+			continue
+		}
+
+		token, err := getToken(serverID)
+		if err != nil {
+			return err
+		}
+		authInfo.Exec = nil
+		authInfo.AuthProvider = nil
+		authInfo.Token = token
+	}
+	return nil
+}
+
+// getAksAADTokenE returns a token for the given AKS AAD server ID, for the Azure identity authenticated according to
+// the given AuthOptions.
+func getAksAADTokenE(serverID string, authOptions AuthOptions) (string, error) {
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{serverID + "/.default"}})
+	if err != nil {
+		return "", err
+	}
+	return token.Token, nil
 }
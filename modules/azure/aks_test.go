@@ -0,0 +1,50 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestReplaceAksAADAuth(t *testing.T) {
+	t.Parallel()
+
+	config := api.NewConfig()
+	config.AuthInfos["exec"] = &api.AuthInfo{Exec: &api.ExecConfig{
+		Command: "kubelogin",
+		Args:    []string{"get-token", "--environment", "AzurePublicCloud", "--server-id", "custom-server-id", "--login", "devicecode"},
+	}}
+	config.AuthInfos["exec-default"] = &api.AuthInfo{Exec: &api.ExecConfig{Command: "kubelogin", Args: []string{"get-token"}}}
+	config.AuthInfos["auth-provider"] = &api.AuthInfo{AuthProvider: &api.AuthProviderConfig{
+		Name:   "azure",
+		Config: map[string]string{"apiserver-id": "legacy-server-id"},
+	}}
+	config.AuthInfos["admin"] = &api.AuthInfo{ClientCertificateData: []byte("cert"), Token: "admin-token"}
+
+	err := replaceAksAADAuthE(config, func(serverID string) (string, error) {
+		return "token-for-" + serverID, nil
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, config.AuthInfos["exec"].Exec)
+	assert.Equal(t, "token-for-custom-server-id", config.AuthInfos["exec"].Token)
+	assert.Equal(t, "token-for-"+aksAADServerID, config.AuthInfos["exec-default"].Token)
+	assert.Nil(t, config.AuthInfos["auth-provider"].AuthProvider)
+	assert.Equal(t, "token-for-legacy-server-id", config.AuthInfos["auth-provider"].Token)
+	assert.Equal(t, "admin-token", config.AuthInfos["admin"].Token)
+}
+
+func TestNewKubectlOptionsForAksCluster(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewKubectlOptionsForAksClusterE(t, "", "", "")
+	require.Error(t, err)
+}
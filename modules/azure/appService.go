@@ -2,12 +2,26 @@ package azure
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/services/web/mgmt/2019-08-01/web"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice/v4"
 	"github.com/stretchr/testify/require"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/retry"
 )
 
+// keyVaultReferenceRegexp matches the Key Vault references App Service resolves in app settings and connection
+// strings, e.g., @Microsoft.KeyVault(SecretUri=https://myvault.vault.azure.net/secrets/mysecret/) or
+// @Microsoft.KeyVault(VaultName=myvault;SecretName=mysecret).
+var keyVaultReferenceRegexp = regexp.MustCompile(`^@Microsoft\.KeyVault\((.*)\)$`)
+
 // AppExists indicates whether the specified application exists.
 // This function would fail the test if there is an error.
 func AppExists(t *testing.T, appName string, resourceGroupName string, subscriptionID string) bool {
@@ -31,7 +45,7 @@ func AppExistsE(appName string, resourceGroupName string, subscriptionID string)
 
 // GetAppService gets the App service object
 // This function would fail the test if there is an error.
-func GetAppService(t *testing.T, appName string, resGroupName string, subscriptionID string) *web.Site {
+func GetAppService(t *testing.T, appName string, resGroupName string, subscriptionID string) *armappservice.Site {
 	site, err := GetAppServiceE(appName, resGroupName, subscriptionID)
 	require.NoError(t, err)
 
@@ -39,7 +53,7 @@ func GetAppService(t *testing.T, appName string, resGroupName string, subscripti
 }
 
 // GetAppServiceE gets the App service object
-func GetAppServiceE(appName string, resGroupName string, subscriptionID string) (*web.Site, error) {
+func GetAppServiceE(appName string, resGroupName string, subscriptionID string) (*armappservice.Site, error) {
 	rgName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
 		return nil, err
@@ -50,28 +64,367 @@ func GetAppServiceE(appName string, resGroupName string, subscriptionID string)
 		return nil, err
 	}
 
-	resource, err := client.Get(context.Background(), rgName, appName)
+	resource, err := client.Get(context.Background(), rgName, appName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &resource, nil
+	return &resource.Site, nil
 }
 
-func GetAppServiceClientE(subscriptionID string) (*web.AppsClient, error) {
-	// Create an Apps client
-	appsClient, err := CreateAppServiceClientE(subscriptionID)
+// AppServiceIdentity is the managed identity configuration of an App Service or Function App.
+type AppServiceIdentity struct {
+	// The identity type, e.g., SystemAssigned, UserAssigned or "SystemAssigned, UserAssigned". Empty if the app has no
+	// managed identity.
+	Type string
+
+	// The principal ID of the system-assigned identity, if any.
+	PrincipalID string
+
+	// The resource IDs of the user-assigned identities.
+	UserAssignedIdentityIDs []string
+}
+
+// GetAppServiceAppSettings gets the app settings of the given app, with Key Vault references resolved to the values of
+// the secrets they refer to.
+// This function would fail the test if there is an error.
+func GetAppServiceAppSettings(t *testing.T, appName string, resGroupName string, subscriptionID string) map[string]string {
+	settings, err := GetAppServiceAppSettingsE(appName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return settings
+}
+
+// GetAppServiceAppSettingsE gets the app settings of the given app, with Key Vault references resolved to the values
+// of the secrets they refer to. Resolving references requires permission to get the secrets.
+func GetAppServiceAppSettingsE(appName string, resGroupName string, subscriptionID string) (map[string]string, error) {
+	return GetAppServiceSlotAppSettingsE(appName, "", resGroupName, subscriptionID)
+}
+
+// GetAppServiceSlotAppSettings gets the app settings of the given deployment slot of the given app, with Key Vault
+// references resolved.
+// This function would fail the test if there is an error.
+func GetAppServiceSlotAppSettings(t *testing.T, appName string, slotName string, resGroupName string, subscriptionID string) map[string]string {
+	settings, err := GetAppServiceSlotAppSettingsE(appName, slotName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return settings
+}
+
+// GetAppServiceSlotAppSettingsE gets the app settings of the given deployment slot of the given app, with Key Vault
+// references resolved. Pass an empty slot name for the production slot.
+func GetAppServiceSlotAppSettingsE(appName string, slotName string, resGroupName string, subscriptionID string) (map[string]string, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := GetAppServiceClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings armappservice.StringDictionary
+	if slotName == "" {
+		resp, err := client.ListApplicationSettings(context.Background(), rgName, appName, nil)
+		if err != nil {
+			return nil, err
+		}
+		settings = resp.StringDictionary
+	} else {
+		resp, err := client.ListApplicationSettingsSlot(context.Background(), rgName, appName, slotName, nil)
+		if err != nil {
+			return nil, err
+		}
+		settings = resp.StringDictionary
+	}
+
+	resolved := map[string]string{}
+	for name, value := range settings.Properties {
+		resolved[name], err = resolveKeyVaultReferenceE(safePtrToString(value))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// GetAppServiceConnectionStrings gets the values of the connection strings of the given app, with Key Vault references
+// resolved to the values of the secrets they refer to.
+// This function would fail the test if there is an error.
+func GetAppServiceConnectionStrings(t *testing.T, appName string, resGroupName string, subscriptionID string) map[string]string {
+	connectionStrings, err := GetAppServiceConnectionStringsE(appName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return connectionStrings
+}
+
+// GetAppServiceConnectionStringsE gets the values of the connection strings of the given app, with Key Vault
+// references resolved to the values of the secrets they refer to.
+func GetAppServiceConnectionStringsE(appName string, resGroupName string, subscriptionID string) (map[string]string, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := GetAppServiceClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
+	resp, err := client.ListConnectionStrings(context.Background(), rgName, appName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Attach authorizer to the client
-	appsClient.Authorizer = *authorizer
-	return appsClient, nil
+	resolved := map[string]string{}
+	for name, connectionString := range resp.Properties {
+		if connectionString == nil {
+			continue
+		}
+		resolved[name], err = resolveKeyVaultReferenceE(safePtrToString(connectionString.Value))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// GetAppServiceSlotNames gets the names of the deployment slots of the given app, excluding the production slot.
+// This function would fail the test if there is an error.
+func GetAppServiceSlotNames(t *testing.T, appName string, resGroupName string, subscriptionID string) []string {
+	slotNames, err := GetAppServiceSlotNamesE(appName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return slotNames
+}
+
+// GetAppServiceSlotNamesE gets the names of the deployment slots of the given app, excluding the production slot.
+func GetAppServiceSlotNamesE(appName string, resGroupName string, subscriptionID string) ([]string, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := GetAppServiceClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	slotNames := []string{}
+	pager := client.NewListSlotsPager(rgName, appName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range page.Value {
+			if slot == nil {
+				continue
+			}
+			// Slots are named <app name>/<slot name>
+			name := safePtrToString(slot.Name)
+			slotNames = append(slotNames, name[strings.LastIndex(name, "/")+1:])
+		}
+	}
+	return slotNames, nil
+}
+
+// GetAppServiceIdentity gets the managed identity configuration of the given app.
+// This function would fail the test if there is an error.
+func GetAppServiceIdentity(t *testing.T, appName string, resGroupName string, subscriptionID string) AppServiceIdentity {
+	identity, err := GetAppServiceIdentityE(appName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return identity
+}
+
+// GetAppServiceIdentityE gets the managed identity configuration of the given app.
+func GetAppServiceIdentityE(appName string, resGroupName string, subscriptionID string) (AppServiceIdentity, error) {
+	site, err := GetAppServiceE(appName, resGroupName, subscriptionID)
+	if err != nil {
+		return AppServiceIdentity{}, err
+	}
+	return toAppServiceIdentity(site.Identity), nil
+}
+
+// toAppServiceIdentity converts the given managed service identity to an AppServiceIdentity.
+func toAppServiceIdentity(identity *armappservice.ManagedServiceIdentity) AppServiceIdentity {
+	result := AppServiceIdentity{UserAssignedIdentityIDs: []string{}}
+	if identity == nil {
+		return result
+	}
+
+	result.Type = safeEnumPtrToString(identity.Type)
+	if result.Type == string(armappservice.ManagedServiceIdentityTypeNone) {
+		result.Type = ""
+	}
+	result.PrincipalID = safePtrToString(identity.PrincipalID)
+	for id := range identity.UserAssignedIdentities {
+		result.UserAssignedIdentityIDs = append(result.UserAssignedIdentityIDs, id)
+	}
+	return result
+}
+
+// GetAppServiceDefaultHostName gets the default host name of the given app, e.g., myapp.azurewebsites.net.
+// This function would fail the test if there is an error.
+func GetAppServiceDefaultHostName(t *testing.T, appName string, resGroupName string, subscriptionID string) string {
+	hostName, err := GetAppServiceDefaultHostNameE(appName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return hostName
+}
+
+// GetAppServiceDefaultHostNameE gets the default host name of the given app, e.g., myapp.azurewebsites.net.
+func GetAppServiceDefaultHostNameE(appName string, resGroupName string, subscriptionID string) (string, error) {
+	site, err := GetAppServiceE(appName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	if site.Properties == nil || site.Properties.DefaultHostName == nil {
+		return "", NewNotFoundError("App Service default host name", appName, resGroupName)
+	}
+	return *site.Properties.DefaultHostName, nil
+}
+
+// InvokeAppServiceWithRetry sends GET requests to the given path of the given app until it responds with the expected
+// status code, and returns the body of the response.
+// This function would fail the test if there is an error.
+func InvokeAppServiceWithRetry(t *testing.T, appName string, path string, resGroupName string, subscriptionID string, expectedStatus int, retries int, sleepBetweenRetries time.Duration) string {
+	body, err := InvokeAppServiceWithRetryE(t, appName, path, resGroupName, subscriptionID, expectedStatus, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return body
+}
+
+// InvokeAppServiceWithRetryE sends GET requests to the given path (e.g., /health) of the given app until it responds
+// with the expected status code, and returns the body of the response. App Service apps often return errors for a
+// while after a deployment, until the app has started.
+func InvokeAppServiceWithRetryE(t *testing.T, appName string, path string, resGroupName string, subscriptionID string, expectedStatus int, retries int, sleepBetweenRetries time.Duration) (string, error) {
+	hostName, err := GetAppServiceDefaultHostNameE(appName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	return getWithRetryE(t, fmt.Sprintf("https://%s/%s", hostName, strings.TrimPrefix(path, "/")), nil, expectedStatus, retries, sleepBetweenRetries)
+}
+
+// InvokeFunctionWithRetry sends GET requests to the given HTTP triggered function of the given Function App until it
+// responds with the expected status code, and returns the body of the response.
+// This function would fail the test if there is an error.
+func InvokeFunctionWithRetry(t *testing.T, appName string, functionName string, resGroupName string, subscriptionID string, expectedStatus int, retries int, sleepBetweenRetries time.Duration) string {
+	body, err := InvokeFunctionWithRetryE(t, appName, functionName, resGroupName, subscriptionID, expectedStatus, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return body
+}
+
+// InvokeFunctionWithRetryE sends GET requests to the given HTTP triggered function of the given Function App, at the
+// default /api/<function name> route and authenticated with the default function key of the app, until it responds
+// with the expected status code, and returns the body of the response.
+func InvokeFunctionWithRetryE(t *testing.T, appName string, functionName string, resGroupName string, subscriptionID string, expectedStatus int, retries int, sleepBetweenRetries time.Duration) (string, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return "", err
+	}
+
+	hostName, err := GetAppServiceDefaultHostNameE(appName, rgName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := GetAppServiceClientE(subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	keys, err := client.ListHostKeys(context.Background(), rgName, appName, nil)
+	if err != nil {
+		return "", err
+	}
+
+	headers := map[string]string{}
+	if key := safePtrToString(keys.FunctionKeys["default"]); key != "" {
+		headers["x-functions-key"] = key
+	}
+	return getWithRetryE(t, fmt.Sprintf("https://%s/api/%s", hostName, url.PathEscape(functionName)), headers, expectedStatus, retries, sleepBetweenRetries)
+}
+
+// getWithRetryE sends GET requests with the given headers to the given endpoint until it responds with the expected
+// status code, and returns the body of the response.
+func getWithRetryE(t *testing.T, endpoint string, headers map[string]string, expectedStatus int, retries int, sleepBetweenRetries time.Duration) (string, error) {
+	return retry.DoWithRetryE(t, fmt.Sprintf("HTTP GET to %s", endpoint), retries, sleepBetweenRetries, func() (string, error) {
+		status, body, err := http_helper.HTTPDoE(t, http.MethodGet, endpoint, nil, headers, nil)
+		if err != nil {
+			return "", err
+		}
+		if status != expectedStatus {
+			return "", fmt.Errorf("expected status code %d from %s, but got %d: %s", expectedStatus, endpoint, status, body)
+		}
+		return body, nil
+	})
+}
+
+// resolveKeyVaultReferenceE returns the value of the secret the given Key Vault reference refers to, or the given
+// value itself if it is not a Key Vault reference.
+func resolveKeyVaultReferenceE(value string) (string, error) {
+	keyVaultName, secretName, secretVersion, isReference, err := parseKeyVaultReference(value)
+	if err != nil || !isReference {
+		return value, err
+	}
+
+	client, err := GetKeyVaultSecretsClientE(keyVaultName)
+	if err != nil {
+		return "", err
+	}
+	secret, err := client.GetSecret(context.Background(), secretName, secretVersion, nil)
+	if err != nil {
+		return "", err
+	}
+	return safePtrToString(secret.Value), nil
+}
+
+// parseKeyVaultReference parses the vault name, secret name and, optionally, the secret version out of the given Key
+// Vault reference. isReference is false if the value is not a Key Vault reference.
+func parseKeyVaultReference(value string) (keyVaultName string, secretName string, secretVersion string, isReference bool, err error) {
+	match := keyVaultReferenceRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return "", "", "", false, nil
+	}
+
+	params := map[string]string{}
+	for _, param := range strings.Split(match[1], ";") {
+		name, paramValue, found := strings.Cut(param, "=")
+		if found {
+			params[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(paramValue)
+		}
+	}
+
+	if secretURI, ok := params["secreturi"]; ok {
+		// SecretUri=https://<vault name>.<key vault suffix>/secrets/<secret name>[/<version>]
+		parsed, err := url.Parse(secretURI)
+		if err != nil {
+			return "", "", "", true, err
+		}
+		pathParts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		if len(pathParts) < 2 || pathParts[0] != "secrets" {
+			return "", "", "", true, NewFailedToParseError("Key Vault reference", value)
+		}
+		keyVaultName = strings.Split(parsed.Hostname(), ".")[0]
+		secretName = pathParts[1]
+		if len(pathParts) > 2 {
+			secretVersion = pathParts[2]
+		}
+		return keyVaultName, secretName, secretVersion, true, nil
+	}
+
+	keyVaultName, secretName = params["vaultname"], params["secretname"]
+	if keyVaultName == "" || secretName == "" {
+		return "", "", "", true, NewFailedToParseError("Key Vault reference", value)
+	}
+	return keyVaultName, secretName, params["secretversion"], true, nil
+}
+
+// GetAppServiceClientE is a helper function that will setup an App Service (web apps) client on your behalf
+func GetAppServiceClientE(subscriptionID string) (*armappservice.WebAppsClient, error) {
+	// Create an Apps client
+	return CreateAppServiceClientE(subscriptionID)
 }
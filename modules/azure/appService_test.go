@@ -9,6 +9,8 @@ package azure
 import (
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice/v4"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,3 +49,80 @@ func TestGetAppServiceClientE(t *testing.T) {
 	_, err := GetAppServiceClientE(subscriptionID)
 	require.NoError(t, err)
 }
+
+func TestGetAppServiceAppSettingsE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetAppServiceAppSettingsE("", "", "")
+	require.Error(t, err)
+}
+
+func TestGetAppServiceSlotNamesE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetAppServiceSlotNamesE("", "", "")
+	require.Error(t, err)
+}
+
+func TestInvokeFunctionWithRetryE(t *testing.T) {
+	t.Parallel()
+
+	_, err := InvokeFunctionWithRetryE(t, "", "", "", "", 200, 1, 0)
+	require.Error(t, err)
+}
+
+func TestParseKeyVaultReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		value           string
+		wantVaultName   string
+		wantSecretName  string
+		wantVersion     string
+		wantIsReference bool
+		wantErr         bool
+	}{
+		{name: "PlainValue", value: "plain-value"},
+		{name: "SecretUri", value: "@Microsoft.KeyVault(SecretUri=https://myvault.vault.azure.net/secrets/mysecret/)", wantVaultName: "myvault", wantSecretName: "mysecret", wantIsReference: true},
+		{name: "SecretUriWithVersion", value: "@Microsoft.KeyVault(SecretUri=https://myvault.vault.azure.net/secrets/mysecret/ec96f02080254f109c51a1f14cdb1931)", wantVaultName: "myvault", wantSecretName: "mysecret", wantVersion: "ec96f02080254f109c51a1f14cdb1931", wantIsReference: true},
+		{name: "VaultNameAndSecretName", value: "@Microsoft.KeyVault(VaultName=myvault;SecretName=mysecret)", wantVaultName: "myvault", wantSecretName: "mysecret", wantIsReference: true},
+		{name: "VaultNameAndSecretNameWithVersion", value: "@Microsoft.KeyVault(VaultName=myvault; SecretName=mysecret; SecretVersion=v1)", wantVaultName: "myvault", wantSecretName: "mysecret", wantVersion: "v1", wantIsReference: true},
+		{name: "MissingSecretName", value: "@Microsoft.KeyVault(VaultName=myvault)", wantIsReference: true, wantErr: true},
+		{name: "InvalidSecretUri", value: "@Microsoft.KeyVault(SecretUri=https://myvault.vault.azure.net/keys/mykey)", wantIsReference: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vaultName, secretName, version, isReference, err := parseKeyVaultReference(tt.value)
+			assert.Equal(t, tt.wantIsReference, isReference)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVaultName, vaultName)
+			assert.Equal(t, tt.wantSecretName, secretName)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+}
+
+func TestToAppServiceIdentity(t *testing.T) {
+	t.Parallel()
+
+	identityType := armappservice.ManagedServiceIdentityTypeSystemAssignedUserAssigned
+	principalID := "00000000-0000-0000-0000-000000000000"
+	identity := toAppServiceIdentity(&armappservice.ManagedServiceIdentity{
+		Type:        &identityType,
+		PrincipalID: &principalID,
+		UserAssignedIdentities: map[string]*armappservice.UserAssignedIdentity{
+			"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id": {},
+		},
+	})
+	assert.Equal(t, "SystemAssigned, UserAssigned", identity.Type)
+	assert.Equal(t, principalID, identity.PrincipalID)
+	assert.Len(t, identity.UserAssignedIdentityIDs, 1)
+
+	assert.Equal(t, AppServiceIdentity{UserAssignedIdentityIDs: []string{}}, toAppServiceIdentity(nil))
+}
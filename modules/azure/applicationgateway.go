@@ -0,0 +1,392 @@
+package azure
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ApplicationGatewayListener summarizes an HTTP listener of an Application Gateway.
+type ApplicationGatewayListener struct {
+	Name         string
+	Protocol     string // Http or Https
+	FrontendPort int32
+	HostNames    []string // Empty for basic listeners, which accept any host name
+}
+
+// ApplicationGatewayRoutingRule summarizes a request routing rule of an Application Gateway. Path-based rules route
+// through a URL path map instead of a backend pool, and redirecting rules through a redirect configuration.
+type ApplicationGatewayRoutingRule struct {
+	Name                      string
+	RuleType                  string // Basic or PathBasedRouting
+	Priority                  int32
+	ListenerName              string
+	BackendPoolName           string
+	BackendSettingsName       string
+	URLPathMapName            string
+	RedirectConfigurationName string
+}
+
+// ApplicationGatewayBackendPool summarizes a backend address pool of an Application Gateway.
+type ApplicationGatewayBackendPool struct {
+	Name      string
+	Addresses []string // The FQDNs and IP addresses of the backends
+}
+
+// ApplicationGatewayBackendServerHealth is the health of a backend server of an Application Gateway, as reported by
+// its health probes for the given backend settings.
+type ApplicationGatewayBackendServerHealth struct {
+	BackendPoolName     string
+	BackendSettingsName string
+	Address             string
+	Health              string // Up, Down, Partial, Draining or Unknown
+	HealthProbeLog      string // Why the server is unhealthy, if it is
+}
+
+// GetApplicationGateway gets the specified Application Gateway. This function would fail the test if there is an error.
+func GetApplicationGateway(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) *armnetwork.ApplicationGateway {
+	gateway, err := GetApplicationGatewayE(gatewayName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return gateway
+}
+
+// GetApplicationGatewayE gets the specified Application Gateway.
+func GetApplicationGatewayE(gatewayName string, resGroupName string, subscriptionID string) (*armnetwork.ApplicationGateway, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreateApplicationGatewaysClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(context.Background(), resGroupName, gatewayName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.ApplicationGateway, nil
+}
+
+// GetApplicationGatewayListeners gets the HTTP listeners of the specified Application Gateway.
+// This function would fail the test if there is an error.
+func GetApplicationGatewayListeners(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) []ApplicationGatewayListener {
+	listeners, err := GetApplicationGatewayListenersE(gatewayName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return listeners
+}
+
+// GetApplicationGatewayListenersE gets the HTTP listeners of the specified Application Gateway.
+func GetApplicationGatewayListenersE(gatewayName string, resGroupName string, subscriptionID string) ([]ApplicationGatewayListener, error) {
+	gateway, err := GetApplicationGatewayE(gatewayName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return applicationGatewayListeners(gateway), nil
+}
+
+// applicationGatewayListeners summarizes the HTTP listeners of the given Application Gateway.
+func applicationGatewayListeners(gateway *armnetwork.ApplicationGateway) []ApplicationGatewayListener {
+	listeners := []ApplicationGatewayListener{}
+	if gateway.Properties == nil {
+		return listeners
+	}
+
+	ports := map[string]int32{}
+	for _, port := range gateway.Properties.FrontendPorts {
+		if port.Properties != nil {
+			ports[strings.ToLower(safePtrToString(port.ID))] = safePtrToInt32(port.Properties.Port)
+		}
+	}
+
+	for _, listener := range gateway.Properties.HTTPListeners {
+		summary := ApplicationGatewayListener{Name: safePtrToString(listener.Name), HostNames: []string{}}
+		if props := listener.Properties; props != nil {
+			summary.Protocol = safeEnumPtrToString(props.Protocol)
+			if props.FrontendPort != nil {
+				summary.FrontendPort = ports[strings.ToLower(safePtrToString(props.FrontendPort.ID))]
+			}
+			if props.HostName != nil {
+				summary.HostNames = append(summary.HostNames, *props.HostName)
+			}
+			summary.HostNames = append(summary.HostNames, safePtrToList(props.HostNames)...)
+		}
+		listeners = append(listeners, summary)
+	}
+
+	return listeners
+}
+
+// GetApplicationGatewayRoutingRules gets the request routing rules of the specified Application Gateway.
+// This function would fail the test if there is an error.
+func GetApplicationGatewayRoutingRules(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) []ApplicationGatewayRoutingRule {
+	rules, err := GetApplicationGatewayRoutingRulesE(gatewayName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return rules
+}
+
+// GetApplicationGatewayRoutingRulesE gets the request routing rules of the specified Application Gateway.
+func GetApplicationGatewayRoutingRulesE(gatewayName string, resGroupName string, subscriptionID string) ([]ApplicationGatewayRoutingRule, error) {
+	gateway, err := GetApplicationGatewayE(gatewayName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return applicationGatewayRoutingRules(gateway), nil
+}
+
+// applicationGatewayRoutingRules summarizes the request routing rules of the given Application Gateway.
+func applicationGatewayRoutingRules(gateway *armnetwork.ApplicationGateway) []ApplicationGatewayRoutingRule {
+	rules := []ApplicationGatewayRoutingRule{}
+	if gateway.Properties == nil {
+		return rules
+	}
+
+	for _, rule := range gateway.Properties.RequestRoutingRules {
+		summary := ApplicationGatewayRoutingRule{Name: safePtrToString(rule.Name)}
+		if props := rule.Properties; props != nil {
+			summary.RuleType = safeEnumPtrToString(props.RuleType)
+			summary.Priority = safePtrToInt32(props.Priority)
+			summary.ListenerName = subResourceName(props.HTTPListener)
+			summary.BackendPoolName = subResourceName(props.BackendAddressPool)
+			summary.BackendSettingsName = subResourceName(props.BackendHTTPSettings)
+			summary.URLPathMapName = subResourceName(props.URLPathMap)
+			summary.RedirectConfigurationName = subResourceName(props.RedirectConfiguration)
+		}
+		rules = append(rules, summary)
+	}
+
+	return rules
+}
+
+// GetApplicationGatewayBackendPools gets the backend address pools of the specified Application Gateway.
+// This function would fail the test if there is an error.
+func GetApplicationGatewayBackendPools(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) []ApplicationGatewayBackendPool {
+	pools, err := GetApplicationGatewayBackendPoolsE(gatewayName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return pools
+}
+
+// GetApplicationGatewayBackendPoolsE gets the backend address pools of the specified Application Gateway.
+func GetApplicationGatewayBackendPoolsE(gatewayName string, resGroupName string, subscriptionID string) ([]ApplicationGatewayBackendPool, error) {
+	gateway, err := GetApplicationGatewayE(gatewayName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return applicationGatewayBackendPools(gateway), nil
+}
+
+// applicationGatewayBackendPools summarizes the backend address pools of the given Application Gateway.
+func applicationGatewayBackendPools(gateway *armnetwork.ApplicationGateway) []ApplicationGatewayBackendPool {
+	pools := []ApplicationGatewayBackendPool{}
+	if gateway.Properties == nil {
+		return pools
+	}
+
+	for _, pool := range gateway.Properties.BackendAddressPools {
+		summary := ApplicationGatewayBackendPool{Name: safePtrToString(pool.Name), Addresses: []string{}}
+		if pool.Properties != nil {
+			for _, address := range pool.Properties.BackendAddresses {
+				if address.Fqdn != nil {
+					summary.Addresses = append(summary.Addresses, *address.Fqdn)
+				} else {
+					summary.Addresses = append(summary.Addresses, safePtrToString(address.IPAddress))
+				}
+			}
+		}
+		pools = append(pools, summary)
+	}
+
+	return pools
+}
+
+// GetApplicationGatewayBackendHealth gets the health of the backend servers of the specified Application Gateway.
+// This function would fail the test if there is an error.
+func GetApplicationGatewayBackendHealth(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) []ApplicationGatewayBackendServerHealth {
+	health, err := GetApplicationGatewayBackendHealthE(gatewayName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return health
+}
+
+// GetApplicationGatewayBackendHealthE gets the health of the backend servers of the specified Application Gateway, as
+// reported by its health probes. Azure runs the probes on demand, so this can take a minute.
+func GetApplicationGatewayBackendHealthE(gatewayName string, resGroupName string, subscriptionID string) ([]ApplicationGatewayBackendServerHealth, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreateApplicationGatewaysClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	poller, err := client.BeginBackendHealth(context.Background(), resGroupName, gatewayName, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := poller.PollUntilDone(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return applicationGatewayBackendHealth(resp.ApplicationGatewayBackendHealth), nil
+}
+
+// applicationGatewayBackendHealth flattens the given backend health of an Application Gateway.
+func applicationGatewayBackendHealth(health armnetwork.ApplicationGatewayBackendHealth) []ApplicationGatewayBackendServerHealth {
+	servers := []ApplicationGatewayBackendServerHealth{}
+	for _, pool := range health.BackendAddressPools {
+		poolName := ""
+		if pool.BackendAddressPool != nil {
+			poolName = GetNameFromResourceID(safePtrToString(pool.BackendAddressPool.ID))
+		}
+		for _, settings := range pool.BackendHTTPSettingsCollection {
+			settingsName := ""
+			if settings.BackendHTTPSettings != nil {
+				settingsName = GetNameFromResourceID(safePtrToString(settings.BackendHTTPSettings.ID))
+			}
+			for _, server := range settings.Servers {
+				servers = append(servers, ApplicationGatewayBackendServerHealth{
+					BackendPoolName:     poolName,
+					BackendSettingsName: settingsName,
+					Address:             safePtrToString(server.Address),
+					Health:              safeEnumPtrToString(server.Health),
+					HealthProbeLog:      safePtrToString(server.HealthProbeLog),
+				})
+			}
+		}
+	}
+
+	return servers
+}
+
+// AssertApplicationGatewayBackendsHealthy checks that the specified Application Gateway has backend servers and that
+// all of them are healthy.
+func AssertApplicationGatewayBackendsHealthy(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) {
+	servers, err := GetApplicationGatewayBackendHealthE(gatewayName, resGroupName, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NotEmptyf(t, servers, "Application Gateway %s has no backend servers", gatewayName)
+	for _, server := range servers {
+		assert.Equalf(t, string(armnetwork.ApplicationGatewayBackendHealthServerHealthUp), server.Health, "Backend %s of pool %s of Application Gateway %s is not healthy: %s", server.Address, server.BackendPoolName, gatewayName, server.HealthProbeLog)
+	}
+}
+
+// GetApplicationGatewayWAFMode gets the WAF mode of the specified Application Gateway.
+// See GetApplicationGatewayWAFModeE. This function would fail the test if there is an error.
+func GetApplicationGatewayWAFMode(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string) string {
+	mode, err := GetApplicationGatewayWAFModeE(gatewayName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return mode
+}
+
+// GetApplicationGatewayWAFModeE gets the WAF mode, Prevention or Detection, of the specified Application Gateway, from
+// its WAF policy, or from its legacy WAF configuration if it has no policy.
+func GetApplicationGatewayWAFModeE(gatewayName string, resGroupName string, subscriptionID string) (string, error) {
+	gateway, err := GetApplicationGatewayE(gatewayName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	props := gateway.Properties
+	if props == nil {
+		return "", NewNotFoundError("WAF policy", gatewayName, resGroupName)
+	}
+
+	if props.FirewallPolicy != nil && props.FirewallPolicy.ID != nil {
+		policyID, err := arm.ParseResourceID(*props.FirewallPolicy.ID)
+		if err != nil {
+			return "", err
+		}
+		client, err := CreateApplicationGatewayWAFPoliciesClientE(policyID.SubscriptionID)
+		if err != nil {
+			return "", err
+		}
+		policy, err := client.Get(context.Background(), policyID.ResourceGroupName, policyID.Name, nil)
+		if err != nil {
+			return "", err
+		}
+		if policy.Properties == nil || policy.Properties.PolicySettings == nil {
+			return "", NewNotFoundError("WAF policy settings", policyID.Name, policyID.ResourceGroupName)
+		}
+		return safeEnumPtrToString(policy.Properties.PolicySettings.Mode), nil
+	}
+
+	if props.WebApplicationFirewallConfiguration != nil {
+		return safeEnumPtrToString(props.WebApplicationFirewallConfiguration.FirewallMode), nil
+	}
+
+	return "", NewNotFoundError("WAF policy", gatewayName, resGroupName)
+}
+
+// AssertApplicationGatewayWAFMode checks that the WAF of the specified Application Gateway runs in the expected mode,
+// Prevention or Detection.
+func AssertApplicationGatewayWAFMode(t testing.TestingT, gatewayName string, resGroupName string, subscriptionID string, expectedMode string) {
+	mode, err := GetApplicationGatewayWAFModeE(gatewayName, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, strings.EqualFold(expectedMode, mode), "WAF of Application Gateway %s is in %s mode, expected %s", gatewayName, mode, expectedMode)
+	}
+}
+
+// WaitForFrontendToReachBackend sends GET requests to the given URL until the expected backend responds, and returns
+// the body of the response. See WaitForFrontendToReachBackendE.
+func WaitForFrontendToReachBackend(t testing.TestingT, frontendURL string, tlsConfig *tls.Config, expectedBackendText string, retries int, sleepBetweenRetries time.Duration) string {
+	body, err := WaitForFrontendToReachBackendE(t, frontendURL, tlsConfig, expectedBackendText, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return body
+}
+
+// WaitForFrontendToReachBackendE sends GET requests to the given URL of an Application Gateway or Front Door
+// frontend until it responds with 200 OK and a body containing the given text, e.g., the name of the backend the
+// request should be routed to, and returns the body of the response. Pass a TLS config to trust the certificate of an
+// HTTPS listener that isn't publicly trusted.
+func WaitForFrontendToReachBackendE(t testing.TestingT, frontendURL string, tlsConfig *tls.Config, expectedBackendText string, retries int, sleepBetweenRetries time.Duration) (string, error) {
+	description := fmt.Sprintf("Waiting for %s to reach the expected backend", frontendURL)
+
+	return retry.DoWithRetryE(t, description, retries, sleepBetweenRetries, func() (string, error) {
+		status, body, err := http_helper.HTTPDoE(t, http.MethodGet, frontendURL, nil, nil, tlsConfig)
+		if err != nil {
+			return "", err
+		}
+		if status != http.StatusOK {
+			return "", fmt.Errorf("expected status code %d from %s, but got %d", http.StatusOK, frontendURL, status)
+		}
+		if !strings.Contains(body, expectedBackendText) {
+			return "", fmt.Errorf("response from %s does not contain %q", frontendURL, expectedBackendText)
+		}
+		return body, nil
+	})
+}
+
+// subResourceName returns the name of the given sub-resource, or "" if it is nil.
+func subResourceName(subResource *armnetwork.SubResource) string {
+	if subResource == nil {
+		return ""
+	}
+
+	return GetNameFromResourceID(safePtrToString(subResource.ID))
+}
@@ -0,0 +1,103 @@
+//go:build azure || (azureslim && network)
+// +build azure azureslim,network
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const appGatewayID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/agw"
+
+func TestApplicationGatewayListenersAndRules(t *testing.T) {
+	t.Parallel()
+
+	gateway := &armnetwork.ApplicationGateway{Properties: &armnetwork.ApplicationGatewayPropertiesFormat{
+		FrontendPorts: []*armnetwork.ApplicationGatewayFrontendPort{
+			{ID: to.Ptr(appGatewayID + "/frontendPorts/https"), Properties: &armnetwork.ApplicationGatewayFrontendPortPropertiesFormat{Port: to.Ptr[int32](443)}},
+		},
+		HTTPListeners: []*armnetwork.ApplicationGatewayHTTPListener{{
+			Name: to.Ptr("https-listener"),
+			Properties: &armnetwork.ApplicationGatewayHTTPListenerPropertiesFormat{
+				Protocol:     to.Ptr(armnetwork.ApplicationGatewayProtocolHTTPS),
+				FrontendPort: &armnetwork.SubResource{ID: to.Ptr(appGatewayID + "/FrontendPorts/https")},
+				HostNames:    to.SliceOfPtrs("www.example.com"),
+			},
+		}},
+		RequestRoutingRules: []*armnetwork.ApplicationGatewayRequestRoutingRule{{
+			Name: to.Ptr("rule"),
+			Properties: &armnetwork.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+				RuleType:            to.Ptr(armnetwork.ApplicationGatewayRequestRoutingRuleTypeBasic),
+				Priority:            to.Ptr[int32](100),
+				HTTPListener:        &armnetwork.SubResource{ID: to.Ptr(appGatewayID + "/httpListeners/https-listener")},
+				BackendAddressPool:  &armnetwork.SubResource{ID: to.Ptr(appGatewayID + "/backendAddressPools/web")},
+				BackendHTTPSettings: &armnetwork.SubResource{ID: to.Ptr(appGatewayID + "/backendHttpSettingsCollection/http")},
+			},
+		}},
+		BackendAddressPools: []*armnetwork.ApplicationGatewayBackendAddressPool{{
+			Name: to.Ptr("web"),
+			Properties: &armnetwork.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+				BackendAddresses: []*armnetwork.ApplicationGatewayBackendAddress{{IPAddress: to.Ptr("10.0.2.4")}, {Fqdn: to.Ptr("app.azurewebsites.net")}},
+			},
+		}},
+	}}
+
+	assert.Equal(t, []ApplicationGatewayListener{{Name: "https-listener", Protocol: "Https", FrontendPort: 443, HostNames: []string{"www.example.com"}}}, applicationGatewayListeners(gateway))
+	assert.Equal(t, []ApplicationGatewayRoutingRule{{
+		Name:                "rule",
+		RuleType:            "Basic",
+		Priority:            100,
+		ListenerName:        "https-listener",
+		BackendPoolName:     "web",
+		BackendSettingsName: "http",
+	}}, applicationGatewayRoutingRules(gateway))
+	assert.Equal(t, []ApplicationGatewayBackendPool{{Name: "web", Addresses: []string{"10.0.2.4", "app.azurewebsites.net"}}}, applicationGatewayBackendPools(gateway))
+}
+
+func TestApplicationGatewayBackendHealth(t *testing.T) {
+	t.Parallel()
+
+	health := applicationGatewayBackendHealth(armnetwork.ApplicationGatewayBackendHealth{
+		BackendAddressPools: []*armnetwork.ApplicationGatewayBackendHealthPool{{
+			BackendAddressPool: &armnetwork.ApplicationGatewayBackendAddressPool{ID: to.Ptr(appGatewayID + "/backendAddressPools/web")},
+			BackendHTTPSettingsCollection: []*armnetwork.ApplicationGatewayBackendHealthHTTPSettings{{
+				BackendHTTPSettings: &armnetwork.ApplicationGatewayBackendHTTPSettings{ID: to.Ptr(appGatewayID + "/backendHttpSettingsCollection/http")},
+				Servers: []*armnetwork.ApplicationGatewayBackendHealthServer{
+					{Address: to.Ptr("10.0.2.4"), Health: to.Ptr(armnetwork.ApplicationGatewayBackendHealthServerHealthUp)},
+					{Address: to.Ptr("10.0.2.5"), Health: to.Ptr(armnetwork.ApplicationGatewayBackendHealthServerHealthDown), HealthProbeLog: to.Ptr("Connection timed out")},
+				},
+			}},
+		}},
+	})
+
+	assert.Equal(t, []ApplicationGatewayBackendServerHealth{
+		{BackendPoolName: "web", BackendSettingsName: "http", Address: "10.0.2.4", Health: "Up"},
+		{BackendPoolName: "web", BackendSettingsName: "http", Address: "10.0.2.5", Health: "Down", HealthProbeLog: "Connection timed out"},
+	}, health)
+}
+
+func TestWaitForFrontendToReachBackend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("served by backend-blue"))
+	}))
+	defer server.Close()
+
+	body, err := WaitForFrontendToReachBackendE(t, server.URL, nil, "backend-blue", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "served by backend-blue", body)
+
+	_, err = WaitForFrontendToReachBackendE(t, server.URL, nil, "backend-green", 1, 0)
+	require.Error(t, err)
+}
@@ -1,11 +1,13 @@
 package azure
 
 import (
+	"context"
+	"fmt"
 	"os"
 
-	"github.com/Azure/go-autorest/autorest"
-	az "github.com/Azure/go-autorest/autorest/azure"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/gruntwork-io/terratest/modules/environment"
 )
 
 const (
@@ -15,27 +17,173 @@ const (
 	// AuthFromEnvTenant is an env variable supported by the Azure SDK
 	AuthFromEnvTenant = "AZURE_TENANT_ID"
 
-	// AuthFromFile is an env variable supported by the Azure SDK
-	AuthFromFile = "AZURE_AUTH_LOCATION"
+	// AuthFromEnvClientSecret is an env variable supported by the Azure SDK
+	AuthFromEnvClientSecret = "AZURE_CLIENT_SECRET"
+
+	// AuthFromEnvFederatedTokenFile is an env variable supported by the Azure SDK, which AKS workload identity sets to
+	// the path of the projected service account token
+	AuthFromEnvFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+
+	// AuthMethodEnvName is an env variable custom to Terratest through which the AuthMethod to authenticate with may
+	// be passed
+	AuthMethodEnvName = "TERRATEST_AZURE_AUTH_METHOD"
+
+	// AuthManagedIdentityResourceIDEnvName is an env variable custom to Terratest through which the resource ID of a
+	// user-assigned managed identity may be passed
+	AuthManagedIdentityResourceIDEnvName = "TERRATEST_AZURE_MANAGED_IDENTITY_RESOURCE_ID"
+
+	// federatedTokenAudience is the audience Microsoft Entra ID expects in federated identity tokens
+	federatedTokenAudience = "api://AzureADTokenExchange"
+)
+
+// AuthMethod is the method to authenticate to Azure with.
+type AuthMethod string
+
+const (
+	// AuthMethodDefault uses the default credential chain of the Azure SDK: environment variables, workload identity,
+	// managed identity, and finally the Azure CLI.
+	AuthMethodDefault AuthMethod = "default"
+
+	// AuthMethodClientSecret authenticates as a service principal with a client secret.
+	AuthMethodClientSecret AuthMethod = "client-secret"
+
+	// AuthMethodAzureCLI authenticates as the user or identity logged in to the Azure CLI.
+	AuthMethodAzureCLI AuthMethod = "azure-cli"
+
+	// AuthMethodManagedIdentity authenticates as the system-assigned managed identity of the host, or as a
+	// user-assigned managed identity if a client or resource ID is set.
+	AuthMethodManagedIdentity AuthMethod = "managed-identity"
+
+	// AuthMethodWorkloadIdentity authenticates as a service principal or user-assigned managed identity with a
+	// federated identity token: either the token file AKS workload identity projects into pods, or an OIDC token
+	// requested from GitHub Actions.
+	AuthMethodWorkloadIdentity AuthMethod = "workload-identity"
 )
 
-// NewAuthorizer creates an Azure authorizer adhering to standard auth mechanisms provided by the Azure Go SDK
-// See Azure Go Auth docs here: https://docs.microsoft.com/en-us/go/azure/azure-sdk-go-authorization
-func NewAuthorizer() (*autorest.Authorizer, error) {
-	// Carry out env var lookups
-	_, clientIDExists := os.LookupEnv(AuthFromEnvClient)
-	_, tenantIDExists := os.LookupEnv(AuthFromEnvTenant)
-	_, fileAuthSet := os.LookupEnv(AuthFromFile)
-
-	// Execute logic to return an authorizer from the correct method
-	if clientIDExists && tenantIDExists {
-		authorizer, err := auth.NewAuthorizerFromEnvironment()
-		return &authorizer, err
-	} else if fileAuthSet {
-		authorizer, err := auth.NewAuthorizerFromFile(az.PublicCloud.ResourceManagerEndpoint)
-		return &authorizer, err
-	} else {
-		authorizer, err := auth.NewAuthorizerFromCLI()
-		return &authorizer, err
+// AuthOptions are the options to authenticate to Azure with.
+type AuthOptions struct {
+	// The method to authenticate with. Defaults to AuthMethodDefault.
+	Method AuthMethod
+
+	// The tenant to authenticate in. Defaults to AZURE_TENANT_ID.
+	TenantID string
+
+	// The client ID of the service principal or user-assigned managed identity to authenticate as. Defaults to
+	// AZURE_CLIENT_ID, except for AuthMethodManagedIdentity, where it is only used if set explicitly, since the
+	// system-assigned identity is used otherwise.
+	ClientID string
+
+	// The client secret to authenticate with for AuthMethodClientSecret. Defaults to AZURE_CLIENT_SECRET.
+	ClientSecret string
+
+	// The resource ID of the user-assigned managed identity to authenticate as for AuthMethodManagedIdentity. Only one
+	// of ClientID and ManagedIdentityResourceID may be set.
+	ManagedIdentityResourceID string
+
+	// The path of the federated identity token file for AuthMethodWorkloadIdentity. Defaults to
+	// AZURE_FEDERATED_TOKEN_FILE. If neither is set, the token is requested from GitHub Actions, which requires the
+	// id-token: write permission on the job.
+	FederatedTokenFile string
+}
+
+// NewTokenCredentialE creates an Azure token credential adhering to the standard auth mechanisms provided by the Azure
+// SDK for Go: the credential chain tries environment variables (AZURE_CLIENT_ID, AZURE_TENANT_ID and a secret or
+// certificate), workload identity, managed identity, and finally the Azure CLI. Set TERRATEST_AZURE_AUTH_METHOD to
+// one of the AuthMethod values to use a specific method instead. The credential authenticates against the Azure
+// environment that is currently setup (or "Public", if none is setup).
+// See Azure Go Auth docs here: https://learn.microsoft.com/azure/developer/go/azure-sdk-authentication
+func NewTokenCredentialE() (azcore.TokenCredential, error) {
+	return NewTokenCredentialWithOptionsE(authOptionsFromEnv())
+}
+
+// NewTokenCredentialWithOptionsE creates an Azure token credential authenticated according to the given options,
+// against the Azure environment that is currently setup (or "Public", if none is setup).
+func NewTokenCredentialWithOptionsE(authOptions AuthOptions) (azcore.TokenCredential, error) {
+	clientCloudConfig, err := getClientCloudConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientOptions := azcore.ClientOptions{Cloud: clientCloudConfig}
+
+	switch authOptions.Method {
+	case "", AuthMethodDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      authOptions.TenantID,
+		})
+
+	case AuthMethodClientSecret:
+		return azidentity.NewClientSecretCredential(
+			valueOrEnv(authOptions.TenantID, AuthFromEnvTenant),
+			valueOrEnv(authOptions.ClientID, AuthFromEnvClient),
+			valueOrEnv(authOptions.ClientSecret, AuthFromEnvClientSecret),
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions},
+		)
+
+	case AuthMethodAzureCLI:
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: authOptions.TenantID})
+
+	case AuthMethodManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		switch {
+		case authOptions.ClientID != "" && authOptions.ManagedIdentityResourceID != "":
+			return nil, fmt.Errorf("only one of ClientID and ManagedIdentityResourceID may be set to authenticate with a managed identity")
+		case authOptions.ClientID != "":
+			options.ID = azidentity.ClientID(authOptions.ClientID)
+		case authOptions.ManagedIdentityResourceID != "":
+			options.ID = azidentity.ResourceID(authOptions.ManagedIdentityResourceID)
+		}
+		return azidentity.NewManagedIdentityCredential(options)
+
+	case AuthMethodWorkloadIdentity:
+		tenantID := valueOrEnv(authOptions.TenantID, AuthFromEnvTenant)
+		clientID := valueOrEnv(authOptions.ClientID, AuthFromEnvClient)
+		if tokenFile := valueOrEnv(authOptions.FederatedTokenFile, AuthFromEnvFederatedTokenFile); tokenFile != "" {
+			return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+				ClientOptions: clientOptions,
+				TenantID:      tenantID,
+				ClientID:      clientID,
+				TokenFilePath: tokenFile,
+			})
+		}
+		if !environment.IsGitHubActionsIDTokenAvailable() {
+			return nil, fmt.Errorf("no federated token file was set in FederatedTokenFile or %s, and %s is not set to request an OIDC token from GitHub Actions", AuthFromEnvFederatedTokenFile, environment.GitHubActionsIDTokenRequestURLEnvName)
+		}
+		return azidentity.NewClientAssertionCredential(tenantID, clientID, getGitHubActionsIDTokenE, &azidentity.ClientAssertionCredentialOptions{ClientOptions: clientOptions})
+
+	default:
+		return nil, fmt.Errorf("unknown Azure auth method %q", authOptions.Method)
+	}
+}
+
+// authOptionsFromEnv returns the AuthOptions set in the TERRATEST_AZURE_AUTH_METHOD and
+// TERRATEST_AZURE_MANAGED_IDENTITY_RESOURCE_ID environment variables. The other options default to the environment
+// variables of the Azure SDK when they are used.
+func authOptionsFromEnv() AuthOptions {
+	return AuthOptions{
+		Method:                    AuthMethod(os.Getenv(AuthMethodEnvName)),
+		ManagedIdentityResourceID: os.Getenv(AuthManagedIdentityResourceIDEnvName),
+	}
+}
+
+// authOptionsOrEnv returns the given AuthOptions, or the AuthOptions set in the environment variables if it is nil.
+func authOptionsOrEnv(authOptions *AuthOptions) AuthOptions {
+	if authOptions == nil {
+		return authOptionsFromEnv()
+	}
+	return *authOptions
+}
+
+// getGitHubActionsIDTokenE requests an OIDC token for Microsoft Entra ID from GitHub Actions. It is called each time
+// the credential needs a new access token, since the OIDC tokens of GitHub Actions expire after a few minutes.
+func getGitHubActionsIDTokenE(ctx context.Context) (string, error) {
+	return environment.GetGitHubActionsIDTokenE(ctx, federatedTokenAudience)
+}
+
+// valueOrEnv returns value if it is set, or the value of the given environment variable otherwise.
+func valueOrEnv(value string, envName string) string {
+	if value != "" {
+		return value
 	}
+	return os.Getenv(envName)
 }
@@ -0,0 +1,99 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/gruntwork-io/terratest/modules/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthOptionsFromEnv(t *testing.T) {
+	t.Setenv(AuthMethodEnvName, string(AuthMethodManagedIdentity))
+	t.Setenv(AuthManagedIdentityResourceIDEnvName, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id")
+
+	authOptions := authOptionsFromEnv()
+	assert.Equal(t, AuthMethodManagedIdentity, authOptions.Method)
+	assert.Equal(t, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id", authOptions.ManagedIdentityResourceID)
+	assert.Empty(t, authOptions.ClientID)
+}
+
+func TestAuthOptionsOrEnv(t *testing.T) {
+	t.Setenv(AuthMethodEnvName, string(AuthMethodAzureCLI))
+
+	assert.Equal(t, AuthMethodAzureCLI, authOptionsOrEnv(nil).Method)
+	assert.Equal(t, AuthMethodWorkloadIdentity, authOptionsOrEnv(&AuthOptions{Method: AuthMethodWorkloadIdentity}).Method)
+}
+
+func TestNewTokenCredentialWithOptions(t *testing.T) {
+	t.Setenv(AzureEnvironmentEnvName, "AzurePublicCloud")
+	t.Setenv(environment.GitHubActionsIDTokenRequestURLEnvName, "")
+	t.Setenv(AuthFromEnvFederatedTokenFile, "")
+
+	tests := []struct {
+		name        string
+		authOptions AuthOptions
+		wantType    interface{}
+		wantErr     bool
+	}{
+		{name: "Default", authOptions: AuthOptions{}, wantType: &azidentity.DefaultAzureCredential{}},
+		{name: "ClientSecret", authOptions: AuthOptions{Method: AuthMethodClientSecret, TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}, wantType: &azidentity.ClientSecretCredential{}},
+		{name: "AzureCLI", authOptions: AuthOptions{Method: AuthMethodAzureCLI}, wantType: &azidentity.AzureCLICredential{}},
+		{name: "SystemAssignedManagedIdentity", authOptions: AuthOptions{Method: AuthMethodManagedIdentity}, wantType: &azidentity.ManagedIdentityCredential{}},
+		{name: "UserAssignedManagedIdentity", authOptions: AuthOptions{Method: AuthMethodManagedIdentity, ClientID: "client"}, wantType: &azidentity.ManagedIdentityCredential{}},
+		{name: "ManagedIdentityClientAndResourceID", authOptions: AuthOptions{Method: AuthMethodManagedIdentity, ClientID: "client", ManagedIdentityResourceID: "id"}, wantErr: true},
+		{name: "WorkloadIdentityTokenFile", authOptions: AuthOptions{Method: AuthMethodWorkloadIdentity, TenantID: "tenant", ClientID: "client", FederatedTokenFile: "/var/run/secrets/azure/tokens/azure-identity-token"}, wantType: &azidentity.WorkloadIdentityCredential{}},
+		{name: "WorkloadIdentityNoToken", authOptions: AuthOptions{Method: AuthMethodWorkloadIdentity, TenantID: "tenant", ClientID: "client"}, wantErr: true},
+		{name: "UnknownMethod", authOptions: AuthOptions{Method: "foo"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := NewTokenCredentialWithOptionsE(tt.authOptions)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.wantType, cred)
+		})
+	}
+}
+
+func TestNewTokenCredentialWithOptionsGitHubActions(t *testing.T) {
+	t.Setenv(AzureEnvironmentEnvName, "AzurePublicCloud")
+	t.Setenv(AuthFromEnvFederatedTokenFile, "")
+	t.Setenv(environment.GitHubActionsIDTokenRequestURLEnvName, "https://token.actions.githubusercontent.com/request?api-version=2.0")
+
+	cred, err := NewTokenCredentialWithOptionsE(AuthOptions{Method: AuthMethodWorkloadIdentity, TenantID: "tenant", ClientID: "client"})
+	require.NoError(t, err)
+	assert.IsType(t, &azidentity.ClientAssertionCredential{}, cred)
+}
+
+func TestGetGitHubActionsIDToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2.0", r.URL.Query().Get("api-version"))
+		assert.Equal(t, federatedTokenAudience, r.URL.Query().Get("audience"))
+		assert.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"count": 1, "value": "id-token"}`)
+	}))
+	defer server.Close()
+
+	t.Setenv(environment.GitHubActionsIDTokenRequestURLEnvName, server.URL+"?api-version=2.0")
+	t.Setenv(environment.GitHubActionsIDTokenRequestTokenEnvName, "request-token")
+
+	token, err := getGitHubActionsIDTokenE(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "id-token", token)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -45,13 +45,13 @@ func CheckAvailabilitySetContainsVME(t testing.TestingT, vmName string, avsName
 	}
 
 	// Get the Availability Set
-	avs, err := client.Get(context.Background(), resGroupName, avsName)
+	avs, err := client.Get(context.Background(), resGroupName, avsName, nil)
 	if err != nil {
 		return false, err
 	}
 
 	// Check if the VM is found in the AVS VM collection and return true
-	for _, vm := range *avs.VirtualMachines {
+	for _, vm := range avs.Properties.VirtualMachines {
 		// VM IDs are always ALL CAPS in this property so ignoring case
 		if strings.EqualFold(vmName, GetNameFromResourceID(*vm.ID)) {
 			return true, nil
@@ -76,7 +76,7 @@ func GetAvailabilitySetVMNamesInCapsE(t testing.TestingT, avsName string, resGro
 		return nil, err
 	}
 
-	avs, err := client.Get(context.Background(), resGroupName, avsName)
+	avs, err := client.Get(context.Background(), resGroupName, avsName, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +84,7 @@ func GetAvailabilitySetVMNamesInCapsE(t testing.TestingT, avsName string, resGro
 	vms := []string{}
 
 	// Get the names for all VMs in the Availability Set
-	for _, vm := range *avs.VirtualMachines {
+	for _, vm := range avs.Properties.VirtualMachines {
 		// IDs are returned in ALL CAPS for this property
 		if vmName := GetNameFromResourceID(*vm.ID); len(vmName) > 0 {
 			vms = append(vms, vmName)
@@ -108,11 +108,11 @@ func GetAvailabilitySetFaultDomainCountE(t testing.TestingT, avsName string, res
 	if err != nil {
 		return -1, err
 	}
-	return *avs.PlatformFaultDomainCount, nil
+	return *avs.Properties.PlatformFaultDomainCount, nil
 }
 
 // GetAvailabilitySetE gets an Availability Set in the specified Azure Resource Group
-func GetAvailabilitySetE(t testing.TestingT, avsName string, resGroupName string, subscriptionID string) (*compute.AvailabilitySet, error) {
+func GetAvailabilitySetE(t testing.TestingT, avsName string, resGroupName string, subscriptionID string) (*armcompute.AvailabilitySet, error) {
 	// Validate resource group name and subscription ID
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -126,32 +126,17 @@ func GetAvailabilitySetE(t testing.TestingT, avsName string, resGroupName string
 	}
 
 	// Get the Availability Set
-	avs, err := client.Get(context.Background(), resGroupName, avsName)
+	avs, err := client.Get(context.Background(), resGroupName, avsName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &avs, nil
+	return &avs.AvailabilitySet, nil
 }
 
 // GetAvailabilitySetClientE gets a new Availability Set client in the specified Azure Subscription
 // TODO: remove in next version
-func GetAvailabilitySetClientE(subscriptionID string) (*compute.AvailabilitySetsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetAvailabilitySetClientE(subscriptionID string) (*armcompute.AvailabilitySetsClient, error) {
 	// Get the Availability Set client
-	client := compute.NewAvailabilitySetsClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return &client, nil
+	return CreateAvailabilitySetClientE(subscriptionID)
 }
@@ -1,7 +1,7 @@
 /*
 
-This file implements an Azure client factory that automatically handles setting up Base URI
-values for sovereign cloud support. Note the list of clients below is not initially exhaustive;
+This file implements an Azure client factory that automatically handles setting up the Azure Resource Manager
+endpoint and credentials for sovereign cloud support. Note the list of clients below is not initially exhaustive;
 rather, additional clients will be added as-needed.
 
 */
@@ -11,38 +11,43 @@ package azure
 // snippet-tag-start::client_factory_example.imports
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
 	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/frontdoor/mgmt/frontdoor"
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/mysql/mgmt/mysql"
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/privatedns/mgmt/privatedns"
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/resources/mgmt/resources"
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/sql/mgmt/sql"
-	"github.com/Azure/azure-sdk-for-go/profiles/preview/cosmos-db/mgmt/documentdb"
-	"github.com/Azure/azure-sdk-for-go/profiles/preview/preview/monitor/mgmt/insights"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appcontainers/armappcontainers/v3"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice/v4"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerregistry/armcontainerregistry"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/cosmos/armcosmos/v3"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/datafactory/armdatafactory/v8"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/eventhub/armeventhub"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/frontdoor/armfrontdoor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/keyvault/armkeyvault"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/operationalinsights/armoperationalinsights"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/policyinsights/armpolicyinsights"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/recoveryservices/armrecoveryservices"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/recoveryservices/armrecoveryservicesbackup/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
-	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
-	"github.com/Azure/azure-sdk-for-go/services/containerinstance/mgmt/2018-10-01/containerinstance"
-	"github.com/Azure/azure-sdk-for-go/services/containerregistry/mgmt/2019-05-01/containerregistry"
-	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-11-01/containerservice"
-	"github.com/Azure/azure-sdk-for-go/services/datafactory/mgmt/2018-06-01/datafactory"
-	kvmng "github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2016-10-01/keyvault"
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
-	sqlmi "github.com/Azure/azure-sdk-for-go/services/preview/sql/mgmt/v3.0/sql"
-	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-06-01/subscriptions"
-	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2019-06-01/storage"
-	"github.com/Azure/azure-sdk-for-go/services/synapse/mgmt/2020-12-01/synapse"
-	"github.com/Azure/azure-sdk-for-go/services/web/mgmt/2019-08-01/web"
-	autorestAzure "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/servicebus/armservicebus"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/sql/armsql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/synapse/armsynapse"
 )
 
 // snippet-tag-end::client_factory_example.imports
@@ -51,918 +56,510 @@ const (
 	// AzureEnvironmentEnvName is the name of the Azure environment to use. Set to one of the following:
 	//
 	// "AzureChinaCloud":        ChinaCloud
-	// "AzureGermanCloud":       GermanCloud
 	// "AzurePublicCloud":       PublicCloud
 	// "AzureUSGovernmentCloud": USGovernmentCloud
 	// "AzureStackCloud":		 Azure stack
 	AzureEnvironmentEnvName = "AZURE_ENVIRONMENT"
 
-	// ResourceManagerEndpointName is the name of the ResourceManagerEndpoint field in the Environment struct.
-	ResourceManagerEndpointName = "ResourceManagerEndpoint"
+	// AzureEnvironmentFilepathEnvName is the path to the JSON file describing the Azure Stack environment to use when
+	// AzureEnvironmentEnvName is set to "AzureStackCloud".
+	AzureEnvironmentFilepathEnvName = "AZURE_ENVIRONMENT_FILEPATH"
+
+	// azurePublicCloudName is the name of the Azure environment used when none is configured.
+	azurePublicCloudName = "AzurePublicCloud"
 )
 
 // ClientType describes the type of client a module can create.
 type ClientType int
 
-// CreateSubscriptionsClientE returns a virtual machines client instance configured with the correct BaseURI depending on
+// azureEnvironment holds the endpoints of an Azure cloud environment: the azcore cloud configuration used by the
+// ARM clients and credentials, plus the data plane DNS suffixes.
+type azureEnvironment struct {
+	Name                     string
+	Cloud                    cloud.Configuration
+	KeyVaultDNSSuffix        string
+	StorageEndpointSuffix    string
+	ServiceBusEndpointSuffix string
+	MicrosoftGraphEndpoint   string
+}
+
+// azureEnvironments are the well-known Azure cloud environments, keyed by their upper-cased name.
+var azureEnvironments = map[string]azureEnvironment{
+	"AZUREPUBLICCLOUD": {
+		Name:                     "AzurePublicCloud",
+		Cloud:                    cloud.AzurePublic,
+		KeyVaultDNSSuffix:        "vault.azure.net",
+		StorageEndpointSuffix:    "core.windows.net",
+		ServiceBusEndpointSuffix: "servicebus.windows.net",
+		MicrosoftGraphEndpoint:   "https://graph.microsoft.com",
+	},
+	"AZUREUSGOVERNMENTCLOUD": {
+		Name:                     "AzureUSGovernmentCloud",
+		Cloud:                    cloud.AzureGovernment,
+		KeyVaultDNSSuffix:        "vault.usgovcloudapi.net",
+		StorageEndpointSuffix:    "core.usgovcloudapi.net",
+		ServiceBusEndpointSuffix: "servicebus.usgovcloudapi.net",
+		MicrosoftGraphEndpoint:   "https://graph.microsoft.us",
+	},
+	"AZURECHINACLOUD": {
+		Name:                     "AzureChinaCloud",
+		Cloud:                    cloud.AzureChina,
+		KeyVaultDNSSuffix:        "vault.azure.cn",
+		StorageEndpointSuffix:    "core.chinacloudapi.cn",
+		ServiceBusEndpointSuffix: "servicebus.chinacloudapi.cn",
+		MicrosoftGraphEndpoint:   "https://microsoftgraph.chinacloudapi.cn",
+	},
+}
+
+// azureStackEnvironmentFile is the subset of the Azure Stack environment JSON file (the same format the Azure CLI
+// and the legacy go-autorest SDK read) used by this module.
+type azureStackEnvironmentFile struct {
+	Name                     string `json:"name"`
+	ActiveDirectoryEndpoint  string `json:"activeDirectoryEndpoint"`
+	ResourceManagerEndpoint  string `json:"resourceManagerEndpoint"`
+	TokenAudience            string `json:"tokenAudience"`
+	KeyVaultDNSSuffix        string `json:"keyVaultDNSSuffix"`
+	StorageEndpointSuffix    string `json:"storageEndpointSuffix"`
+	ServiceBusEndpointSuffix string `json:"serviceBusEndpointSuffix"`
+}
+
+// CreateSubscriptionsClientE returns a subscriptions client instance configured with the correct endpoint depending on
 // the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateSubscriptionsClientE() (subscriptions.Client, error) {
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return subscriptions.Client{}, err
-	}
-
-	// Create correct client based on type passed
-	return subscriptions.NewClientWithBaseURI(baseURI), nil
+func CreateSubscriptionsClientE() (*armsubscriptions.Client, error) {
+	return newArmTenantClientE(armsubscriptions.NewClient)
 }
 
 // snippet-tag-start::client_factory_example.CreateClient
 
-// CreateVirtualMachinesClientE returns a virtual machines client instance configured with the correct BaseURI depending on
+// CreateVirtualMachinesClientE returns a virtual machines client instance configured with the correct endpoint depending on
 // the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateVirtualMachinesClientE(subscriptionID string) (*compute.VirtualMachinesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create correct client based on type passed
-	vmClient := compute.NewVirtualMachinesClientWithBaseURI(baseURI, subscriptionID)
-
-	return &vmClient, nil
+func CreateVirtualMachinesClientE(subscriptionID string) (*armcompute.VirtualMachinesClient, error) {
+	return newArmClientE(subscriptionID, armcompute.NewVirtualMachinesClient)
 }
 
 // snippet-tag-end::client_factory_example.CreateClient
 
-// CreateManagedClustersClientE returns a virtual machines client instance configured with the correct BaseURI depending on
+// CreateManagedClustersClientE returns a managed clusters client instance configured with the correct endpoint depending on
 // the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateManagedClustersClientE(subscriptionID string) (containerservice.ManagedClustersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return containerservice.ManagedClustersClient{}, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return containerservice.ManagedClustersClient{}, err
-	}
-
-	// Create correct client based on type passed
-	return containerservice.NewManagedClustersClientWithBaseURI(baseURI, subscriptionID), nil
+func CreateManagedClustersClientE(subscriptionID string) (*armcontainerservice.ManagedClustersClient, error) {
+	return newArmClientE(subscriptionID, armcontainerservice.NewManagedClustersClient)
 }
 
-// CreateCosmosDBAccountClientE is a helper function that will setup a CosmosDB account client with the correct BaseURI depending on
+// CreateCosmosDBAccountClientE is a helper function that will setup a CosmosDB account client with the correct endpoint depending on
 // the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateCosmosDBAccountClientE(subscriptionID string) (*documentdb.DatabaseAccountsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a CosmosDB client
-	cosmosClient := documentdb.NewDatabaseAccountsClientWithBaseURI(baseURI, subscriptionID)
-
-	return &cosmosClient, nil
+func CreateCosmosDBAccountClientE(subscriptionID string) (*armcosmos.DatabaseAccountsClient, error) {
+	return newArmClientE(subscriptionID, armcosmos.NewDatabaseAccountsClient)
 }
 
-// CreateCosmosDBSQLClientE is a helper function that will setup a CosmosDB SQL client with the correct BaseURI depending on
+// CreateCosmosDBSQLClientE is a helper function that will setup a CosmosDB SQL client with the correct endpoint depending on
 // the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateCosmosDBSQLClientE(subscriptionID string) (*documentdb.SQLResourcesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a CosmosDB client
-	cosmosClient := documentdb.NewSQLResourcesClientWithBaseURI(baseURI, subscriptionID)
-
-	return &cosmosClient, nil
+func CreateCosmosDBSQLClientE(subscriptionID string) (*armcosmos.SQLResourcesClient, error) {
+	return newArmClientE(subscriptionID, armcosmos.NewSQLResourcesClient)
 }
 
-// CreateKeyVaultManagementClientE is a helper function that will setup a key vault management client with the correct BaseURI depending on
+// CreateKeyVaultManagementClientE is a helper function that will setup a key vault management client with the correct endpoint depending on
 // the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateKeyVaultManagementClientE(subscriptionID string) (*kvmng.VaultsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// create keyvault management clinet
-	vaultClient := kvmng.NewVaultsClientWithBaseURI(baseURI, subscriptionID)
-
-	return &vaultClient, nil
+func CreateKeyVaultManagementClientE(subscriptionID string) (*armkeyvault.VaultsClient, error) {
+	return newArmClientE(subscriptionID, armkeyvault.NewVaultsClient)
 }
 
 // CreateStorageAccountClientE creates a storage account client.
-func CreateStorageAccountClientE(subscriptionID string) (*storage.AccountsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	storageAccountClient := storage.NewAccountsClientWithBaseURI(baseURI, subscriptionID)
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	storageAccountClient.Authorizer = *authorizer
-	return &storageAccountClient, nil
+func CreateStorageAccountClientE(subscriptionID string) (*armstorage.AccountsClient, error) {
+	return newArmClientE(subscriptionID, armstorage.NewAccountsClient)
 }
 
 // CreateStorageBlobContainerClientE creates a storage container client.
-func CreateStorageBlobContainerClientE(subscriptionID string) (*storage.BlobContainersClient, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	blobContainerClient := storage.NewBlobContainersClientWithBaseURI(baseURI, subscriptionID)
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	blobContainerClient.Authorizer = *authorizer
-	return &blobContainerClient, nil
+func CreateStorageBlobContainerClientE(subscriptionID string) (*armstorage.BlobContainersClient, error) {
+	return newArmClientE(subscriptionID, armstorage.NewBlobContainersClient)
 }
 
 // CreateStorageFileSharesClientE creates a storage file share client.
-func CreateStorageFileSharesClientE(subscriptionID string) (*storage.FileSharesClient, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	fileShareClient := storage.NewFileSharesClientWithBaseURI(baseURI, subscriptionID)
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	fileShareClient.Authorizer = *authorizer
-	return &fileShareClient, nil
+func CreateStorageFileSharesClientE(subscriptionID string) (*armstorage.FileSharesClient, error) {
+	return newArmClientE(subscriptionID, armstorage.NewFileSharesClient)
 }
 
-// CreateAvailabilitySetClientE creates a new Availability Set client in the specified Azure Subscription
-func CreateAvailabilitySetClientE(subscriptionID string) (*compute.AvailabilitySetsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
+// CreateVirtualMachineScaleSetsClientE returns a virtual machine scale sets client instance configured with the correct
+// endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateVirtualMachineScaleSetsClientE(subscriptionID string) (*armcompute.VirtualMachineScaleSetsClient, error) {
+	return newArmClientE(subscriptionID, armcompute.NewVirtualMachineScaleSetsClient)
+}
 
-	// Get the Availability Set client
-	client := compute.NewAvailabilitySetsClientWithBaseURI(baseURI, subscriptionID)
+// CreateVirtualMachineScaleSetVMsClientE returns a client for the instances of virtual machine scale sets configured
+// with the correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateVirtualMachineScaleSetVMsClientE(subscriptionID string) (*armcompute.VirtualMachineScaleSetVMsClient, error) {
+	return newArmClientE(subscriptionID, armcompute.NewVirtualMachineScaleSetVMsClient)
+}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
+// CreateVirtualMachineScaleSetRollingUpgradesClientE returns a client for the rolling upgrades of virtual machine scale
+// sets configured with the correct endpoint depending on the Azure environment that is currently setup (or "Public",
+// if none is setup).
+func CreateVirtualMachineScaleSetRollingUpgradesClientE(subscriptionID string) (*armcompute.VirtualMachineScaleSetRollingUpgradesClient, error) {
+	return newArmClientE(subscriptionID, armcompute.NewVirtualMachineScaleSetRollingUpgradesClient)
+}
 
-	return &client, nil
+// CreateAvailabilitySetClientE creates a new Availability Set client in the specified Azure Subscription
+func CreateAvailabilitySetClientE(subscriptionID string) (*armcompute.AvailabilitySetsClient, error) {
+	return newArmClientE(subscriptionID, armcompute.NewAvailabilitySetsClient)
 }
 
 // CreateResourceGroupClientE gets a resource group client in a subscription
-func CreateResourceGroupClientE(subscriptionID string) (*resources.GroupsClient, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	resourceGroupClient := resources.NewGroupsClientWithBaseURI(baseURI, subscriptionID)
-
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	resourceGroupClient.Authorizer = *authorizer
-	return &resourceGroupClient, nil
+func CreateResourceGroupClientE(subscriptionID string) (*armresources.ResourceGroupsClient, error) {
+	return newArmClientE(subscriptionID, armresources.NewResourceGroupsClient)
 }
 
 // CreateSQLServerClient is a helper function that will create and setup a sql server client
-func CreateSQLServerClient(subscriptionID string) (*sql.ServersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a sql server client
-	sqlClient := sql.NewServersClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	sqlClient.Authorizer = *authorizer
+func CreateSQLServerClient(subscriptionID string) (*armsql.ServersClient, error) {
+	return newArmClientE(subscriptionID, armsql.NewServersClient)
+}
 
-	return &sqlClient, nil
+// CreateSQLFirewallRulesClientE is a helper function that will create and setup a sql server firewall rules client
+func CreateSQLFirewallRulesClientE(subscriptionID string) (*armsql.FirewallRulesClient, error) {
+	return newArmClientE(subscriptionID, armsql.NewFirewallRulesClient)
 }
 
 // CreateSQLMangedInstanceClient is a helper function that will create and setup a sql server client
-func CreateSQLMangedInstanceClient(subscriptionID string) (*sqlmi.ManagedInstancesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a sql server client
-	sqlmiClient := sqlmi.NewManagedInstancesClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	sqlmiClient.Authorizer = *authorizer
-
-	return &sqlmiClient, nil
+func CreateSQLMangedInstanceClient(subscriptionID string) (*armsql.ManagedInstancesClient, error) {
+	return newArmClientE(subscriptionID, armsql.NewManagedInstancesClient)
 }
 
 // CreateSQLMangedDatabasesClient is a helper function that will create and setup a sql server client
-func CreateSQLMangedDatabasesClient(subscriptionID string) (*sqlmi.ManagedDatabasesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a sql server client
-	sqlmidbClient := sqlmi.NewManagedDatabasesClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	sqlmidbClient.Authorizer = *authorizer
-
-	return &sqlmidbClient, nil
+func CreateSQLMangedDatabasesClient(subscriptionID string) (*armsql.ManagedDatabasesClient, error) {
+	return newArmClientE(subscriptionID, armsql.NewManagedDatabasesClient)
 }
 
 // CreateDatabaseClient is a helper function that will create and setup a SQL DB client
-func CreateDatabaseClient(subscriptionID string) (*sql.DatabasesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a sql DB client
-	sqlDBClient := sql.NewDatabasesClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	sqlDBClient.Authorizer = *authorizer
-
-	return &sqlDBClient, nil
+func CreateDatabaseClient(subscriptionID string) (*armsql.DatabasesClient, error) {
+	return newArmClientE(subscriptionID, armsql.NewDatabasesClient)
 }
 
 // CreateMySQLServerClientE is a helper function that will setup a mysql server client.
-func CreateMySQLServerClientE(subscriptionID string) (*mysql.ServersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a mysql server client
-	mysqlClient := mysql.NewServersClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	mysqlClient.Authorizer = *authorizer
-
-	return &mysqlClient, nil
-}
-
-// CreateDisksClientE returns a new Disks client in the specified Azure Subscription
-func CreateDisksClientE(subscriptionID string) (*compute.DisksClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Get the Disks client
-	client := compute.NewDisksClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	client.Authorizer = *authorizer
-
-	return &client, nil
-}
-
-func CreateActionGroupClient(subscriptionID string) (*insights.ActionGroupsClient, error) {
-	subID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	metricAlertsClient := insights.NewActionGroupsClientWithBaseURI(baseURI, subID)
-
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	metricAlertsClient.Authorizer = *authorizer
-
-	return &metricAlertsClient, nil
-}
-
-// CreateVMInsightsClientE gets a VM Insights client
-func CreateVMInsightsClientE(subscriptionID string) (*insights.VMInsightsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	client := insights.NewVMInsightsClientWithBaseURI(baseURI, subscriptionID)
-
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	client.Authorizer = *authorizer
-
-	return &client, nil
-}
-
-// CreateActivityLogAlertsClientE gets an Action Groups client in the specified Azure Subscription
-func CreateActivityLogAlertsClientE(subscriptionID string) (*insights.ActivityLogAlertsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	// Get the Action Groups client
-	client := insights.NewActivityLogAlertsClientWithBaseURI(baseURI, subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	client.Authorizer = *authorizer
-
-	return &client, nil
+func CreateMySQLServerClientE(subscriptionID string) (*armmysql.ServersClient, error) {
+	return newArmClientE(subscriptionID, armmysql.NewServersClient)
 }
 
-// CreateDiagnosticsSettingsClientE returns a diagnostics settings client
-func CreateDiagnosticsSettingsClientE(subscriptionID string) (*insights.DiagnosticSettingsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
-
-	client := insights.NewDiagnosticSettingsClientWithBaseURI(baseURI, subscriptionID)
-
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	client.Authorizer = *authorizer
-
-	return &client, nil
-}
-
-// CreateNsgDefaultRulesClientE returns an NSG default (platform) rules client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateNsgDefaultRulesClientE(subscriptionID string) (*network.DefaultSecurityRulesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create new client
-	nsgClient := network.NewDefaultSecurityRulesClientWithBaseURI(baseURI, subscriptionID)
-	return &nsgClient, nil
+// CreateMySQLDatabasesClientE is a helper function that will setup a mysql DB client.
+func CreateMySQLDatabasesClientE(subscriptionID string) (*armmysql.DatabasesClient, error) {
+	return newArmClientE(subscriptionID, armmysql.NewDatabasesClient)
 }
 
-// CreateNsgCustomRulesClientE returns an NSG custom (user) rules client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateNsgCustomRulesClientE(subscriptionID string) (*network.SecurityRulesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create new client
-	nsgClient := network.NewSecurityRulesClientWithBaseURI(baseURI, subscriptionID)
-	return &nsgClient, nil
+// CreatePostgreSQLServerClientE is a helper function that will setup a postgresql server client.
+func CreatePostgreSQLServerClientE(subscriptionID string) (*armpostgresql.ServersClient, error) {
+	return newArmClientE(subscriptionID, armpostgresql.NewServersClient)
 }
 
-// CreateNewNetworkInterfacesClientE returns an NIC client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateNewNetworkInterfacesClientE(subscriptionID string) (*network.InterfacesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
-
-	// create client
-	nicClient := network.NewInterfacesClientWithBaseURI(baseURI, subscriptionID)
-	return &nicClient, nil
+// CreatePostgreSQLDatabasesClientE is a helper function that will setup a postgresql DB client.
+func CreatePostgreSQLDatabasesClientE(subscriptionID string) (*armpostgresql.DatabasesClient, error) {
+	return newArmClientE(subscriptionID, armpostgresql.NewDatabasesClient)
 }
 
-// CreateNewNetworkInterfaceIPConfigurationClientE returns an NIC IP configuration client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateNewNetworkInterfaceIPConfigurationClientE(subscriptionID string) (*network.InterfaceIPConfigurationsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
-
-	// create client
-	ipConfigClient := network.NewInterfaceIPConfigurationsClientWithBaseURI(baseURI, subscriptionID)
-	return &ipConfigClient, nil
+// CreateDisksClientE returns a new Disks client in the specified Azure Subscription
+func CreateDisksClientE(subscriptionID string) (*armcompute.DisksClient, error) {
+	return newArmClientE(subscriptionID, armcompute.NewDisksClient)
 }
 
-// CreatePublicIPAddressesClientE returns a public IP address client instance configured with the correct BaseURI depending on
-// the Azure environment that is currently setup (or "Public", if none is setup).
-func CreatePublicIPAddressesClientE(subscriptionID string) (*network.PublicIPAddressesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
+// CreateActionGroupClient creates a new Action Group client in the specified Azure Subscription
+func CreateActionGroupClient(subscriptionID string) (*armmonitor.ActionGroupsClient, error) {
+	return newArmClientE(subscriptionID, armmonitor.NewActionGroupsClient)
+}
+
+// CreateVMInsightsClientE gets a VM Insights client. VM Insights are scoped by resource URI, so the subscription ID
+// is only validated.
+func CreateVMInsightsClientE(subscriptionID string) (*armmonitor.VMInsightsClient, error) {
+	if _, err := getTargetAzureSubscription(subscriptionID); err != nil {
 		return nil, err
 	}
+	return newArmTenantClientE(armmonitor.NewVMInsightsClient)
+}
 
-	// Create client
-	client := network.NewPublicIPAddressesClientWithBaseURI(baseURI, subscriptionID)
-	return &client, nil
+// CreateAutoscaleSettingsClientE returns an autoscale settings client in the specified Azure Subscription
+func CreateAutoscaleSettingsClientE(subscriptionID string) (*armmonitor.AutoscaleSettingsClient, error) {
+	return newArmClientE(subscriptionID, armmonitor.NewAutoscaleSettingsClient)
 }
 
-// CreateLoadBalancerClientE returns a load balancer client instance configured with the correct BaseURI depending on
-// the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateLoadBalancerClientE(subscriptionID string) (*network.LoadBalancersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateActivityLogAlertsClientE gets an Action Groups client in the specified Azure Subscription
+func CreateActivityLogAlertsClientE(subscriptionID string) (*armmonitor.ActivityLogAlertsClient, error) {
+	return newArmClientE(subscriptionID, armmonitor.NewActivityLogAlertsClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
+// CreateMetricsClientE returns an Azure Monitor metrics client in the specified Azure Subscription. Metrics are
+// queried by resource URI, so any resource ID in the subscription may be passed to the client.
+func CreateMetricsClientE(subscriptionID string) (*armmonitor.MetricsClient, error) {
+	return newArmClientE(subscriptionID, armmonitor.NewMetricsClient)
+}
+
+// CreateDiagnosticsSettingsClientE returns a diagnostics settings client. Diagnostic settings are scoped by resource
+// URI, so the subscription ID is only validated.
+func CreateDiagnosticsSettingsClientE(subscriptionID string) (*armmonitor.DiagnosticSettingsClient, error) {
+	if _, err := getTargetAzureSubscription(subscriptionID); err != nil {
 		return nil, err
 	}
+	return newArmTenantClientE(armmonitor.NewDiagnosticSettingsClient)
+}
 
-	// create LB client
-	client := network.NewLoadBalancersClientWithBaseURI(baseURI, subscriptionID)
-	return &client, nil
+// CreateNsgDefaultRulesClientE returns an NSG default (platform) rules client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNsgDefaultRulesClientE(subscriptionID string) (*armnetwork.DefaultSecurityRulesClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewDefaultSecurityRulesClient)
 }
 
-// CreateNewSubnetClientE returns a Subnet client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateNewSubnetClientE(subscriptionID string) (*network.SubnetsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateNsgCustomRulesClientE returns an NSG custom (user) rules client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNsgCustomRulesClientE(subscriptionID string) (*armnetwork.SecurityRulesClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewSecurityRulesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// CreateNewNetworkInterfacesClientE returns an NIC client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNewNetworkInterfacesClientE(subscriptionID string) (*armnetwork.InterfacesClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewInterfacesClient)
+}
 
-	// create client
-	subnetClient := network.NewSubnetsClientWithBaseURI(baseURI, subscriptionID)
-	return &subnetClient, nil
+// CreateNewNetworkInterfaceIPConfigurationClientE returns an NIC IP configuration client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNewNetworkInterfaceIPConfigurationClientE(subscriptionID string) (*armnetwork.InterfaceIPConfigurationsClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewInterfaceIPConfigurationsClient)
 }
 
-// CreateNewVirtualNetworkClientE returns a Virtual Network client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateNewVirtualNetworkClientE(subscriptionID string) (*network.VirtualNetworksClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreatePublicIPAddressesClientE returns a public IP address client instance configured with the correct endpoint depending on
+// the Azure environment that is currently setup (or "Public", if none is setup).
+func CreatePublicIPAddressesClientE(subscriptionID string) (*armnetwork.PublicIPAddressesClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewPublicIPAddressesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// CreateNetworkManagementClientE returns a network management client instance, which implements the operations that
+// are not tied to a network resource type (e.g., DNS name availability checks), configured with the correct endpoint
+// depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNetworkManagementClientE(subscriptionID string) (*armnetwork.ManagementClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewManagementClient)
+}
 
-	// create client
-	vnetClient := network.NewVirtualNetworksClientWithBaseURI(baseURI, subscriptionID)
-	return &vnetClient, nil
+// CreateLoadBalancerClientE returns a load balancer client instance configured with the correct endpoint depending on
+// the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateLoadBalancerClientE(subscriptionID string) (*armnetwork.LoadBalancersClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewLoadBalancersClient)
 }
 
-// CreateAppServiceClientE returns an App service client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateAppServiceClientE(subscriptionID string) (*web.AppsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateLoadBalancerFrontendIPConfigClientE returns a load balancer frontend IP configuration client instance
+// configured with the correct endpoint depending on the Azure environment that is currently setup (or "Public", if
+// none is setup).
+func CreateLoadBalancerFrontendIPConfigClientE(subscriptionID string) (*armnetwork.LoadBalancerFrontendIPConfigurationsClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewLoadBalancerFrontendIPConfigurationsClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// CreateNewSubnetClientE returns a Subnet client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNewSubnetClientE(subscriptionID string) (*armnetwork.SubnetsClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewSubnetsClient)
+}
 
-	// create client
-	appsClient := web.NewAppsClientWithBaseURI(baseURI, subscriptionID)
-	return &appsClient, nil
+// CreateNewVirtualNetworkClientE returns a Virtual Network client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateNewVirtualNetworkClientE(subscriptionID string) (*armnetwork.VirtualNetworksClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewVirtualNetworksClient)
 }
 
-// CreateContainerRegistryClientE returns an ACR client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateContainerRegistryClientE(subscriptionID string) (*containerregistry.RegistriesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateRouteTablesClientE returns a Route Table client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateRouteTablesClientE(subscriptionID string) (*armnetwork.RouteTablesClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewRouteTablesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// CreatePrivateEndpointsClientE returns a Private Endpoints client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreatePrivateEndpointsClientE(subscriptionID string) (*armnetwork.PrivateEndpointsClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewPrivateEndpointsClient)
+}
 
-	// create client
-	registryClient := containerregistry.NewRegistriesClientWithBaseURI(baseURI, subscriptionID)
-	return &registryClient, nil
+// CreatePrivateDNSZoneGroupsClientE returns a client for the private DNS zone groups of Private Endpoints configured
+// with the correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreatePrivateDNSZoneGroupsClientE(subscriptionID string) (*armnetwork.PrivateDNSZoneGroupsClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewPrivateDNSZoneGroupsClient)
 }
 
-// CreateContainerInstanceClientE returns an ACI client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateContainerInstanceClientE(subscriptionID string) (*containerinstance.ContainerGroupsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateApplicationGatewaysClientE returns an Application Gateways client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateApplicationGatewaysClientE(subscriptionID string) (*armnetwork.ApplicationGatewaysClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewApplicationGatewaysClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// CreateApplicationGatewayWAFPoliciesClientE returns a client for the WAF policies of Application Gateways
+// configured with the correct endpoint depending on the Azure environment that is currently setup (or "Public", if
+// none is setup).
+func CreateApplicationGatewayWAFPoliciesClientE(subscriptionID string) (*armnetwork.WebApplicationFirewallPoliciesClient, error) {
+	return newArmClientE(subscriptionID, armnetwork.NewWebApplicationFirewallPoliciesClient)
+}
 
-	// create client
-	instanceClient := containerinstance.NewContainerGroupsClientWithBaseURI(baseURI, subscriptionID)
-	return &instanceClient, nil
+// CreateAppServiceClientE returns an App service client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateAppServiceClientE(subscriptionID string) (*armappservice.WebAppsClient, error) {
+	return newArmClientE(subscriptionID, armappservice.NewWebAppsClient)
 }
 
-// CreateFrontDoorClientE returns an AFD client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateFrontDoorClientE(subscriptionID string) (*frontdoor.FrontDoorsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateContainerRegistryClientE returns an ACR client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateContainerRegistryClientE(subscriptionID string) (*armcontainerregistry.RegistriesClient, error) {
+	return newArmClientE(subscriptionID, armcontainerregistry.NewRegistriesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// CreateContainerInstanceClientE returns an ACI client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateContainerInstanceClientE(subscriptionID string) (*armcontainerinstance.ContainerGroupsClient, error) {
+	return newArmClientE(subscriptionID, armcontainerinstance.NewContainerGroupsClient)
+}
 
-	// create client
-	client := frontdoor.NewFrontDoorsClientWithBaseURI(baseURI, subscriptionID)
-	return &client, nil
+// CreateFrontDoorClientE returns an AFD client instance configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateFrontDoorClientE(subscriptionID string) (*armfrontdoor.FrontDoorsClient, error) {
+	return newArmClientE(subscriptionID, armfrontdoor.NewFrontDoorsClient)
 }
 
 // CreateFrontDoorFrontendEndpointClientE returns an AFD Frontend Endpoints client instance configured with the
-// correct BaseURI depending on the Azure environment that is currently setup (or "Public", if none is setup).
-func CreateFrontDoorFrontendEndpointClientE(subscriptionID string) (*frontdoor.FrontendEndpointsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
-	if err != nil {
-		return nil, err
-	}
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateFrontDoorFrontendEndpointClientE(subscriptionID string) (*armfrontdoor.FrontendEndpointsClient, error) {
+	return newArmClientE(subscriptionID, armfrontdoor.NewFrontendEndpointsClient)
+}
 
-	// create client
-	client := frontdoor.NewFrontendEndpointsClientWithBaseURI(baseURI, subscriptionID)
-	return &client, nil
+// CreateFrontDoorWAFPoliciesClientE returns a client for the WAF policies of AFD configured with the
+// correct endpoint depending on the Azure environment that is currently setup (or "Public", if none is setup).
+func CreateFrontDoorWAFPoliciesClientE(subscriptionID string) (*armfrontdoor.PoliciesClient, error) {
+	return newArmClientE(subscriptionID, armfrontdoor.NewPoliciesClient)
 }
 
 // CreateSynapseWorkspaceClientE is a helper function that will setup a synapse client.
-func CreateSynapseWorkspaceClientE(subscriptionID string) (*synapse.WorkspacesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+func CreateSynapseWorkspaceClientE(subscriptionID string) (*armsynapse.WorkspacesClient, error) {
+	return newArmClientE(subscriptionID, armsynapse.NewWorkspacesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
+// CreateSynapseSqlPoolClientE is a helper function that will setup a synapse client.
+func CreateSynapseSqlPoolClientE(subscriptionID string) (*armsynapse.SQLPoolsClient, error) {
+	return newArmClientE(subscriptionID, armsynapse.NewSQLPoolsClient)
+}
 
-	// Create a synapse client
-	synapseWorkspaceClient := synapse.NewWorkspacesClientWithBaseURI(baseURI, subscriptionID)
+// CreateDataFactoriesClientE is a helper function that will setup a data factory client.
+func CreateDataFactoriesClientE(subscriptionID string) (*armdatafactory.FactoriesClient, error) {
+	return newArmClientE(subscriptionID, armdatafactory.NewFactoriesClient)
+}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
+// CreateRoleAssignmentsClientE returns a role assignments client. Role assignments are listed by scope, so the
+// client can list those of any scope the caller can read.
+func CreateRoleAssignmentsClientE(subscriptionID string) (*armauthorization.RoleAssignmentsClient, error) {
+	return newArmClientE(subscriptionID, armauthorization.NewRoleAssignmentsClient)
+}
 
-	// Attach authorizer to the client
-	synapseWorkspaceClient.Authorizer = *authorizer
+// CreateRoleDefinitionsClientE returns a role definitions client. Role definitions are scoped by resource ID, so no
+// subscription is needed.
+func CreateRoleDefinitionsClientE() (*armauthorization.RoleDefinitionsClient, error) {
+	return newArmTenantClientE(armauthorization.NewRoleDefinitionsClient)
+}
 
-	return &synapseWorkspaceClient, nil
+// CreatePolicyStatesClientE returns a policy states client. Policy states are queried and evaluated by scope, so no
+// subscription is needed.
+func CreatePolicyStatesClientE() (*armpolicyinsights.PolicyStatesClient, error) {
+	return newArmTenantClientE(armpolicyinsights.NewPolicyStatesClient)
 }
 
-// CreateSynapseSqlPoolClientE is a helper function that will setup a synapse client.
-func CreateSynapseSqlPoolClientE(subscriptionID string) (*synapse.SQLPoolsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreatePrivateDnsZonesClientE is a helper function that will setup a private DNS zone client.
+func CreatePrivateDnsZonesClientE(subscriptionID string) (*armprivatedns.PrivateZonesClient, error) {
+	return newArmClientE(subscriptionID, armprivatedns.NewPrivateZonesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
+// CreatePrivateDnsRecordSetsClientE is a helper function that will setup a private DNS record sets client.
+func CreatePrivateDnsRecordSetsClientE(subscriptionID string) (*armprivatedns.RecordSetsClient, error) {
+	return newArmClientE(subscriptionID, armprivatedns.NewRecordSetsClient)
+}
 
-	// Create a synapse client
-	synapseSqlPoolClient := synapse.NewSQLPoolsClientWithBaseURI(baseURI, subscriptionID)
+// CreatePrivateDnsVirtualNetworkLinksClientE is a helper function that will setup a private DNS zone virtual network
+// links client.
+func CreatePrivateDnsVirtualNetworkLinksClientE(subscriptionID string) (*armprivatedns.VirtualNetworkLinksClient, error) {
+	return newArmClientE(subscriptionID, armprivatedns.NewVirtualNetworkLinksClient)
+}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
+// CreateDnsRecordSetsClientE is a helper function that will setup a public DNS record sets client.
+func CreateDnsRecordSetsClientE(subscriptionID string) (*armdns.RecordSetsClient, error) {
+	return newArmClientE(subscriptionID, armdns.NewRecordSetsClient)
+}
 
-	// Attach authorizer to the client
-	synapseSqlPoolClient.Authorizer = *authorizer
+// CreateEventHubsClientE is a helper function that will setup an Event Hubs client.
+func CreateEventHubsClientE(subscriptionID string) (*armeventhub.EventHubsClient, error) {
+	return newArmClientE(subscriptionID, armeventhub.NewEventHubsClient)
+}
 
-	return &synapseSqlPoolClient, nil
+// CreateEventHubNamespacesClientE is a helper function that will setup an Event Hubs namespaces client.
+func CreateEventHubNamespacesClientE(subscriptionID string) (*armeventhub.NamespacesClient, error) {
+	return newArmClientE(subscriptionID, armeventhub.NewNamespacesClient)
 }
 
-// CreateDataFactoriesClientE is a helper function that will setup a synapse client.
-func CreateDataFactoriesClientE(subscriptionID string) (*datafactory.FactoriesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateLogAnalyticsWorkspacesClientE is a helper function that will setup a Log Analytics workspaces client.
+func CreateLogAnalyticsWorkspacesClientE(subscriptionID string) (*armoperationalinsights.WorkspacesClient, error) {
+	return newArmClientE(subscriptionID, armoperationalinsights.NewWorkspacesClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
-	if err != nil {
-		return nil, err
-	}
+// CreateRecoveryServicesVaultsClientE is a helper function that will setup a recovery services vaults client.
+func CreateRecoveryServicesVaultsClientE(subscriptionID string) (*armrecoveryservices.VaultsClient, error) {
+	return newArmClientE(subscriptionID, armrecoveryservices.NewVaultsClient)
+}
 
-	// Create a synapse client
-	dataFactoryClient := datafactory.NewFactoriesClientWithBaseURI(baseURI, subscriptionID)
+// CreateRecoveryServicesBackupPoliciesClientE is a helper function that will setup a recovery services backup
+// policies client.
+func CreateRecoveryServicesBackupPoliciesClientE(subscriptionID string) (*armrecoveryservicesbackup.BackupPoliciesClient, error) {
+	return newArmClientE(subscriptionID, armrecoveryservicesbackup.NewBackupPoliciesClient)
+}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
+// CreateRecoveryServicesBackupProtectedItemsClientE is a helper function that will setup a recovery services backup
+// protected items client.
+func CreateRecoveryServicesBackupProtectedItemsClientE(subscriptionID string) (*armrecoveryservicesbackup.BackupProtectedItemsClient, error) {
+	return newArmClientE(subscriptionID, armrecoveryservicesbackup.NewBackupProtectedItemsClient)
+}
 
-	// Attach authorizer to the client
-	dataFactoryClient.Authorizer = *authorizer
+// CreateServiceBusNamespacesClientE is a helper function that will setup a Service Bus namespaces client.
+func CreateServiceBusNamespacesClientE(subscriptionID string) (*armservicebus.NamespacesClient, error) {
+	return newArmClientE(subscriptionID, armservicebus.NewNamespacesClient)
+}
 
-	return &dataFactoryClient, nil
+// CreateServiceBusQueuesClientE is a helper function that will setup a Service Bus queues client.
+func CreateServiceBusQueuesClientE(subscriptionID string) (*armservicebus.QueuesClient, error) {
+	return newArmClientE(subscriptionID, armservicebus.NewQueuesClient)
 }
 
-// CreatePrivateDnsZonesClientE is a helper function that will setup a private DNS zone client.
-func CreatePrivateDnsZonesClientE(subscriptionID string) (*privatedns.PrivateZonesClient, error) {
-	// Validate Azure subscription ID
-	subID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
+// CreateServiceBusTopicsClientE is a helper function that will setup a Service Bus topics client.
+func CreateServiceBusTopicsClientE(subscriptionID string) (*armservicebus.TopicsClient, error) {
+	return newArmClientE(subscriptionID, armservicebus.NewTopicsClient)
+}
+
+// CreateServiceBusSubscriptionsClientE is a helper function that will setup a Service Bus topic subscriptions client.
+func CreateServiceBusSubscriptionsClientE(subscriptionID string) (*armservicebus.SubscriptionsClient, error) {
+	return newArmClientE(subscriptionID, armservicebus.NewSubscriptionsClient)
+}
 
-	// Lookup environment URI
-	baseURI, err := getBaseURI()
+// CreateManagedEnvironmentsClientE is a helper function that will setup a Container Apps managed environments client.
+func CreateManagedEnvironmentsClientE(subscriptionID string) (*armappcontainers.ManagedEnvironmentsClient, error) {
+	clientFactory, err := getArmAppContainersClientFactory(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
+	client := clientFactory.NewManagedEnvironmentsClient()
+	return client, nil
+}
 
-	// Create a private DNS zone client
-	privateZonesClient := privatedns.NewPrivateZonesClientWithBaseURI(baseURI, subID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
+// CreateResourcesClientE is a helper function that will setup a generic resources client.
+func CreateResourcesClientE(subscriptionID string) (*armresources.Client, error) {
+	clientFactory, err := getArmResourcesClientFactory(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
-
-	// Attach authorizer to the client
-	privateZonesClient.Authorizer = *authorizer
-
-	return &privateZonesClient, nil
+	return clientFactory.NewClient(), nil
 }
 
-func CreateManagedEnvironmentsClientE(subscriptionID string) (*armappcontainers.ManagedEnvironmentsClient, error) {
-	clientFactory, err := getArmAppContainersClientFactory(subscriptionID)
+// CreateProvidersClientE is a helper function that will setup a resource providers client.
+func CreateProvidersClientE(subscriptionID string) (*armresources.ProvidersClient, error) {
+	clientFactory, err := getArmResourcesClientFactory(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
-	client := clientFactory.NewManagedEnvironmentsClient()
-	return client, nil
+	return clientFactory.NewProvidersClient(), nil
 }
 
+// CreateResourceGroupClientV2E is a helper function that will setup a resource groups client.
 func CreateResourceGroupClientV2E(subscriptionID string) (*armresources.ResourceGroupsClient, error) {
 	clientFactory, err := getArmResourcesClientFactory(subscriptionID)
 	if err != nil {
@@ -971,6 +568,7 @@ func CreateResourceGroupClientV2E(subscriptionID string) (*armresources.Resource
 	return clientFactory.NewResourceGroupsClient(), nil
 }
 
+// CreateContainerAppsClientE is a helper function that will setup a Container Apps client.
 func CreateContainerAppsClientE(subscriptionID string) (*armappcontainers.ContainerAppsClient, error) {
 	clientFactory, err := getArmAppContainersClientFactory(subscriptionID)
 	if err != nil {
@@ -980,6 +578,7 @@ func CreateContainerAppsClientE(subscriptionID string) (*armappcontainers.Contai
 	return client, nil
 }
 
+// CreateContainerAppJobsClientE is a helper function that will setup a Container App Jobs client.
 func CreateContainerAppJobsClientE(subscriptionID string) (*armappcontainers.JobsClient, error) {
 	clientFactory, err := getArmAppContainersClientFactory(subscriptionID)
 	if err != nil {
@@ -990,16 +589,40 @@ func CreateContainerAppJobsClientE(subscriptionID string) (*armappcontainers.Job
 }
 
 // GetKeyVaultURISuffixE returns the proper KeyVault URI suffix for the configured Azure environment.
-// This function would fail the test if there is an error.
 func GetKeyVaultURISuffixE() (string, error) {
-	envName := getDefaultEnvironmentName()
-	env, err := autorestAzure.EnvironmentFromName(envName)
+	env, err := getAzureEnvironmentE()
 	if err != nil {
 		return "", err
 	}
 	return env.KeyVaultDNSSuffix, nil
 }
 
+// GetServiceBusEndpointSuffixE returns the DNS suffix of Service Bus and Event Hubs namespaces for the configured
+// Azure environment, e.g., servicebus.windows.net.
+func GetServiceBusEndpointSuffixE() (string, error) {
+	env, err := getAzureEnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	if env.ServiceBusEndpointSuffix == "" {
+		return "", fmt.Errorf("the %s environment has no Service Bus endpoint suffix", env.Name)
+	}
+	return env.ServiceBusEndpointSuffix, nil
+}
+
+// GetMicrosoftGraphEndpointE returns the Microsoft Graph endpoint for the configured Azure environment. Azure Stack
+// environments have none.
+func GetMicrosoftGraphEndpointE() (string, error) {
+	env, err := getAzureEnvironmentE()
+	if err != nil {
+		return "", err
+	}
+	if env.MicrosoftGraphEndpoint == "" {
+		return "", fmt.Errorf("the %s environment has no Microsoft Graph endpoint", env.Name)
+	}
+	return env.MicrosoftGraphEndpoint, nil
+}
+
 // getDefaultEnvironmentName returns either a configured Azure environment name, or the public default
 func getDefaultEnvironmentName() string {
 	envName, exists := os.LookupEnv(AzureEnvironmentEnvName)
@@ -1008,118 +631,148 @@ func getDefaultEnvironmentName() string {
 		return envName
 	}
 
-	return autorestAzure.PublicCloud.Name
+	return azurePublicCloudName
 }
 
-// getEnvironmentEndpointE returns the endpoint identified by the endpoint name parameter.
-func getEnvironmentEndpointE(endpointName string) (string, error) {
+// getAzureEnvironmentE returns the endpoints of the Azure environment that is currently setup (or "Public", if none
+// is setup).
+func getAzureEnvironmentE() (azureEnvironment, error) {
 	envName := getDefaultEnvironmentName()
-	env, err := autorestAzure.EnvironmentFromName(envName)
-	if err != nil {
-		return "", err
+	if env, ok := azureEnvironments[strings.ToUpper(envName)]; ok {
+		return env, nil
 	}
-	return getFieldValue(&env, endpointName), nil
+	if strings.EqualFold(envName, "AzureStackCloud") {
+		return getAzureStackEnvironmentE()
+	}
+	return azureEnvironment{},
+		fmt.Errorf("no cloud environment matching the name: %s. "+
+			"Available values are: "+
+			"AzurePublicCloud (default), "+
+			"AzureUSGovernmentCloud, "+
+			"AzureChinaCloud or "+
+			"AzureStackCloud",
+			envName)
 }
 
-// getFieldValue gets the field identified by the field parameter from the passed Environment struct
-func getFieldValue(env *autorestAzure.Environment, field string) string {
-	structValue := reflect.ValueOf(env)
-	fieldVal := reflect.Indirect(structValue).FieldByName(field)
-	return fieldVal.String()
-}
+// getAzureStackEnvironmentE reads the Azure Stack environment from the JSON file at AZURE_ENVIRONMENT_FILEPATH.
+func getAzureStackEnvironmentE() (azureEnvironment, error) {
+	path, exists := os.LookupEnv(AzureEnvironmentFilepathEnvName)
+	if !exists || path == "" {
+		return azureEnvironment{}, fmt.Errorf("%s must be set to the path of the Azure Stack environment file", AzureEnvironmentFilepathEnvName)
+	}
 
-// getBaseURI gets the base URI endpoint.
-func getBaseURI() (string, error) {
-	// Lookup environment URI
-	baseURI, err := getEnvironmentEndpointE(ResourceManagerEndpointName)
+	contents, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return azureEnvironment{}, err
 	}
-	return baseURI, nil
+
+	var file azureStackEnvironmentFile
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return azureEnvironment{}, fmt.Errorf("failed to parse the Azure Stack environment file %s: %w", path, err)
+	}
+
+	return azureEnvironment{
+		Name: file.Name,
+		Cloud: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: file.ActiveDirectoryEndpoint,
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {
+					Audience: file.TokenAudience,
+					Endpoint: strings.TrimSuffix(file.ResourceManagerEndpoint, "/"),
+				},
+			},
+		},
+		KeyVaultDNSSuffix:        file.KeyVaultDNSSuffix,
+		StorageEndpointSuffix:    file.StorageEndpointSuffix,
+		ServiceBusEndpointSuffix: file.ServiceBusEndpointSuffix,
+	}, nil
 }
 
-// getArmResourcesClientFactory gets an arm resources client factory
-func getArmResourcesClientFactory(subscriptionID string) (*armresources.ClientFactory, error) {
-	targetSubscriptionID, err := getTargetAzureSubscription(subscriptionID)
+// getClientCloudConfig returns the azcore cloud configuration of the Azure environment that is currently setup.
+func getClientCloudConfig() (cloud.Configuration, error) {
+	env, err := getAzureEnvironmentE()
 	if err != nil {
-		return nil, err
+		return cloud.Configuration{}, err
 	}
+	return env.Cloud, nil
+}
+
+// getArmClientOptionsE returns the ARM client options for the Azure environment that is currently setup.
+func getArmClientOptionsE() (*arm.ClientOptions, error) {
 	clientCloudConfig, err := getClientCloudConfig()
 	if err != nil {
 		return nil, err
 	}
-	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
-		ClientOptions: azcore.ClientOptions{
-			Cloud: clientCloudConfig,
-		},
-	})
-	if err != nil {
-		return nil, err
-	}
-	return armresources.NewClientFactory(targetSubscriptionID, cred, &arm.ClientOptions{
+	return &arm.ClientOptions{
 		ClientOptions: policy.ClientOptions{
 			Cloud: clientCloudConfig,
 		},
-	})
+	}, nil
 }
 
-// getArmAppContainersClientFactory gets an arm app containers client factory
-func getArmAppContainersClientFactory(subscriptionID string) (*armappcontainers.ClientFactory, error) {
-	targetSubscriptionID, err := getTargetAzureSubscription(subscriptionID)
+// newArmClientE creates a subscription scoped ARM client with the given constructor, authenticated with
+// NewTokenCredentialE and configured for the Azure environment that is currently setup.
+func newArmClientE[T any](subscriptionID string, newClient func(string, azcore.TokenCredential, *arm.ClientOptions) (T, error)) (T, error) {
+	return NewArmClientWithOptionsE(subscriptionID, authOptionsFromEnv(), newClient)
+}
+
+// NewArmClientWithOptionsE creates a subscription scoped ARM client with the given constructor (e.g.,
+// armcompute.NewVirtualMachinesClient), authenticated according to the given AuthOptions and configured for the Azure
+// environment that is currently setup. Use this to pick the auth method per test rather than through env variables.
+func NewArmClientWithOptionsE[T any](subscriptionID string, authOptions AuthOptions, newClient func(string, azcore.TokenCredential, *arm.ClientOptions) (T, error)) (T, error) {
+	var client T
+
+	// Validate Azure subscription ID
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
 	if err != nil {
-		return nil, err
+		return client, err
 	}
-	clientCloudConfig, err := getClientCloudConfig()
+
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
 	if err != nil {
-		return nil, err
+		return client, err
 	}
-	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
-		ClientOptions: azcore.ClientOptions{
-			Cloud: clientCloudConfig,
-		},
-	})
+
+	options, err := getArmClientOptionsE()
 	if err != nil {
-		return nil, err
+		return client, err
 	}
-	return armappcontainers.NewClientFactory(targetSubscriptionID, cred, &arm.ClientOptions{
-		ClientOptions: policy.ClientOptions{
-			Cloud: clientCloudConfig,
-		},
-	})
+
+	return newClient(subscriptionID, cred, options)
 }
 
-func getClientCloudConfig() (cloud.Configuration, error) {
-	envName := getDefaultEnvironmentName()
-	switch strings.ToUpper(envName) {
-	case "AZURECHINACLOUD":
-		return cloud.AzureChina, nil
-	case "AZUREUSGOVERNMENTCLOUD":
-		return cloud.AzureGovernment, nil
-	case "AZUREPUBLICCLOUD":
-		return cloud.AzurePublic, nil
-	case "AZURESTACKCLOUD":
-		env, err := autorestAzure.EnvironmentFromName(envName)
-		if err != nil {
-			return cloud.Configuration{}, err
-		}
-		c := cloud.Configuration{
-			ActiveDirectoryAuthorityHost: env.ActiveDirectoryEndpoint,
-			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
-				cloud.ResourceManager: {
-					Audience: env.TokenAudience,
-					Endpoint: env.ResourceManagerEndpoint,
-				},
-			},
-		}
-		return c, nil
-	default:
-		return cloud.Configuration{},
-			fmt.Errorf("no cloud environment matching the name: %s. "+
-				"Available values are: "+
-				"AzurePublicCloud (default), "+
-				"AzureUSGovernmentCloud, "+
-				"AzureChinaCloud or "+
-				"AzureStackCloud",
-				envName)
+// newArmTenantClientE creates an ARM client that is not scoped to a subscription (e.g., the subscriptions client) with
+// the given constructor, authenticated with NewTokenCredentialE and configured for the Azure environment that is
+// currently setup.
+func newArmTenantClientE[T any](newClient func(azcore.TokenCredential, *arm.ClientOptions) (T, error)) (T, error) {
+	return NewArmTenantClientWithOptionsE(authOptionsFromEnv(), newClient)
+}
+
+// NewArmTenantClientWithOptionsE creates an ARM client that is not scoped to a subscription (e.g.,
+// armsubscriptions.NewClient), authenticated according to the given AuthOptions and configured for the Azure
+// environment that is currently setup.
+func NewArmTenantClientWithOptionsE[T any](authOptions AuthOptions, newClient func(azcore.TokenCredential, *arm.ClientOptions) (T, error)) (T, error) {
+	var client T
+
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return client, err
 	}
+
+	options, err := getArmClientOptionsE()
+	if err != nil {
+		return client, err
+	}
+
+	return newClient(cred, options)
+}
+
+// getArmResourcesClientFactory gets an arm resources client factory
+func getArmResourcesClientFactory(subscriptionID string) (*armresources.ClientFactory, error) {
+	return newArmClientE(subscriptionID, armresources.NewClientFactory)
+}
+
+// getArmAppContainersClientFactory gets an arm app containers client factory
+func getArmAppContainersClientFactory(subscriptionID string) (*armappcontainers.ClientFactory, error) {
+	return newArmClientE(subscriptionID, armappcontainers.NewClientFactory)
 }
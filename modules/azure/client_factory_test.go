@@ -7,10 +7,12 @@ package azure
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
-	autorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/monitor/azquery"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,6 +23,10 @@ const publicCloudEnvName = "AzurePublicCloud"
 const chinaCloudEnvName = "AzureChinaCloud"
 const germanyCloudEnvName = "AzureGermanCloud"
 
+const publicCloudResourceManagerEndpoint = "https://management.azure.com/"
+const govCloudResourceManagerEndpoint = "https://management.usgovcloudapi.net/"
+const chinaCloudResourceManagerEndpoint = "https://management.chinacloudapi.cn/"
+
 func TestDefaultEnvIsPublicWhenNotSet(t *testing.T) {
 	// save any current env value and restore on exit
 	originalEnv := os.Getenv(AzureEnvironmentEnvName)
@@ -33,7 +39,7 @@ func TestDefaultEnvIsPublicWhenNotSet(t *testing.T) {
 	env := getDefaultEnvironmentName()
 
 	// Make sure it's public cloud
-	assert.Equal(t, autorest.PublicCloud.Name, env)
+	assert.Equal(t, publicCloudEnvName, env)
 }
 
 func TestDefaultEnvSetToGov(t *testing.T) {
@@ -48,7 +54,103 @@ func TestDefaultEnvSetToGov(t *testing.T) {
 	env := getDefaultEnvironmentName()
 
 	// Make sure it's public cloud
-	assert.Equal(t, autorest.USGovernmentCloud.Name, env)
+	assert.Equal(t, govCloudEnvName, env)
+}
+
+func TestAzureStackEnvironmentReadFromFile(t *testing.T) {
+	// save any current env values and restore on exit
+	originalEnv := os.Getenv(AzureEnvironmentEnvName)
+	defer os.Setenv(AzureEnvironmentEnvName, originalEnv)
+	originalFilepath := os.Getenv(AzureEnvironmentFilepathEnvName)
+	defer os.Setenv(AzureEnvironmentFilepathEnvName, originalFilepath)
+
+	envFile := filepath.Join(t.TempDir(), "azurestack.json")
+	require.NoError(t, os.WriteFile(envFile, []byte(`{
+		"name": "AzureStackCloud",
+		"activeDirectoryEndpoint": "https://login.local.azurestack.external/",
+		"resourceManagerEndpoint": "https://management.local.azurestack.external/",
+		"tokenAudience": "https://management.local.azurestack.external/",
+		"keyVaultDNSSuffix": "vault.local.azurestack.external",
+		"storageEndpointSuffix": "local.azurestack.external",
+		"serviceBusEndpointSuffix": "servicebus.local.azurestack.external"
+	}`), 0o600))
+
+	os.Setenv(AzureEnvironmentEnvName, "AzureStackCloud")
+	os.Setenv(AzureEnvironmentFilepathEnvName, envFile)
+
+	client, err := CreateVirtualMachinesClientE("")
+	require.NoError(t, err)
+	assertArmClientEndpoint(t, client, "https://management.local.azurestack.external/")
+
+	suffix, err := GetKeyVaultURISuffixE()
+	require.NoError(t, err)
+	assert.Equal(t, "vault.local.azurestack.external", suffix)
+
+	suffix, err = GetServiceBusEndpointSuffixE()
+	require.NoError(t, err)
+	assert.Equal(t, "servicebus.local.azurestack.external", suffix)
+
+	_, err = GetMicrosoftGraphEndpointE()
+	require.Error(t, err)
+}
+
+func TestDataPlaneEndpointsSetCorrectly(t *testing.T) {
+	var cases = []struct {
+		EnvironmentName              string
+		ExpectedKeyVaultSuffix       string
+		ExpectedStorageSuffix        string
+		ExpectedServiceBusSuffix     string
+		ExpectedGraphEndpoint        string
+		ExpectedLogAnalyticsEndpoint string
+	}{
+		{publicCloudEnvName, "vault.azure.net", "core.windows.net", "servicebus.windows.net", "https://graph.microsoft.com", "https://api.loganalytics.io/v1"},
+		{govCloudEnvName, "vault.usgovcloudapi.net", "core.usgovcloudapi.net", "servicebus.usgovcloudapi.net", "https://graph.microsoft.us", "https://api.loganalytics.us/v1"},
+		{chinaCloudEnvName, "vault.azure.cn", "core.chinacloudapi.cn", "servicebus.chinacloudapi.cn", "https://microsoftgraph.chinacloudapi.cn", "https://api.loganalytics.azure.cn/v1"},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.EnvironmentName, func(t *testing.T) {
+			t.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+
+			keyVaultSuffix, err := GetKeyVaultURISuffixE()
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExpectedKeyVaultSuffix, keyVaultSuffix)
+
+			storageSuffix, err := GetStorageURISuffixE()
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExpectedStorageSuffix, storageSuffix)
+
+			serviceBusSuffix, err := GetServiceBusEndpointSuffixE()
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExpectedServiceBusSuffix, serviceBusSuffix)
+
+			graphEndpoint, err := GetMicrosoftGraphEndpointE()
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExpectedGraphEndpoint, graphEndpoint)
+
+			// The Log Analytics query client reads its endpoint from the cloud configuration
+			clientCloudConfig, err := getClientCloudConfig()
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExpectedLogAnalyticsEndpoint, clientCloudConfig.Services[azquery.ServiceNameLogs].Endpoint)
+		})
+	}
+}
+
+func TestResourceNotFoundErrorExists(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, ResourceNotFoundErrorExists(&azcore.ResponseError{StatusCode: 404}))
+	assert.True(t, ResourceNotFoundErrorExists(&azcore.ResponseError{StatusCode: 400, ErrorCode: "ResourceNotFound"}))
+	assert.False(t, ResourceNotFoundErrorExists(&azcore.ResponseError{StatusCode: 403, ErrorCode: "AuthorizationFailed"}))
+	assert.False(t, ResourceNotFoundErrorExists(nil))
+}
+
+// assertArmClientEndpoint checks the resource manager endpoint an ARM client was created for. Not ideal, but to get
+// the endpoint we need to access the internal field of the client.
+func assertArmClientEndpoint(t *testing.T, client interface{}, expectedEndpoint string) {
+	field := reflect.ValueOf(client).Elem().FieldByName("internal").Elem().FieldByName("ep")
+	assert.Equal(t, expectedEndpoint, field.String()+"/")
 }
 
 func TestSubscriptionClientBaseURISetCorrectly(t *testing.T) {
@@ -56,11 +158,12 @@ func TestSubscriptionClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/SubscriptionClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/SubscriptionClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/SubscriptionClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/SubscriptionClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/SubscriptionClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/SubscriptionClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/SubscriptionClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/SubscriptionClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -73,14 +176,23 @@ func TestSubscriptionClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a SubscriptionClient client
 			client, err := CreateSubscriptionsClientE()
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -92,11 +204,12 @@ func TestVMClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/VMClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/VMClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/VMClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/VMClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/VMClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/VMClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/VMClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/VMClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -109,14 +222,23 @@ func TestVMClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a VMClient client
 			client, err := CreateVirtualMachinesClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -128,11 +250,12 @@ func TestManagedClustersClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/ManagedClustersClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/ManagedClustersClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/ManagedClustersClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/ManagedClustersClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/ManagedClustersClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/ManagedClustersClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/ManagedClustersClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/ManagedClustersClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -145,14 +268,23 @@ func TestManagedClustersClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a ManagedClustersClient client
 			client, err := CreateManagedClustersClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -162,11 +294,12 @@ func TestCosmosDBAccountClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/CosmosDBAccountClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/CosmosDBAccountClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/CosmosDBAccountClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/CosmosDBAccountClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/CosmosDBAccountClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/CosmosDBAccountClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/CosmosDBAccountClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/CosmosDBAccountClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -179,14 +312,23 @@ func TestCosmosDBAccountClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a CosmosDBAccountClient client
 			client, err := CreateCosmosDBAccountClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -196,11 +338,12 @@ func TestCosmosDBSQLClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/CosmosDBAccountClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/CosmosDBAccountClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/CosmosDBAccountClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/CosmosDBAccountClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/CosmosDBSQLClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/CosmosDBSQLClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/CosmosDBSQLClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/CosmosDBSQLClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -213,27 +356,38 @@ func TestCosmosDBSQLClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a CosmosDBSQLClient client
 			client, err := CreateCosmosDBSQLClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
+
 func TestPublicIPAddressesClientBaseURISetCorrectly(t *testing.T) {
 	var cases = []struct {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/CosmosDBAccountClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/CosmosDBAccountClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/CosmosDBAccountClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/CosmosDBAccountClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/PublicIPAddressesClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/PublicIPAddressesClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/PublicIPAddressesClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/PublicIPAddressesClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -246,27 +400,38 @@ func TestPublicIPAddressesClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a PublicIPAddressesClient client
 			client, err := CreatePublicIPAddressesClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
+
 func TestLoadBalancerClientBaseURISetCorrectly(t *testing.T) {
 	var cases = []struct {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/CosmosDBAccountClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/CosmosDBAccountClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/CosmosDBAccountClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/CosmosDBAccountClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/LoadBalancerClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/LoadBalancerClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/LoadBalancerClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/LoadBalancerClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -279,14 +444,23 @@ func TestLoadBalancerClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a VM client
+			// Get a LoadBalancerClient client
 			client, err := CreateLoadBalancerClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -296,11 +470,12 @@ func TestFrontDoorClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/FrontDoorClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/FrontDoorClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/FrontDoorClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/FrontDoorClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/FrontDoorClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/FrontDoorClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/FrontDoorClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/FrontDoorClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -313,14 +488,23 @@ func TestFrontDoorClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a Front Door client
+			// Get a FrontDoorClient client
 			client, err := CreateFrontDoorClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -330,11 +514,12 @@ func TestFrontDoorFrontendEndpointClientBaseURISetCorrectly(t *testing.T) {
 		CaseName        string
 		EnvironmentName string
 		ExpectedBaseURI string
+		ExpectErr       bool
 	}{
-		{"GovCloud/FrontDoorClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint},
-		{"PublicCloud/FrontDoorClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint},
-		{"ChinaCloud/FrontDoorClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint},
-		{"GermanCloud/FrontDoorClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint},
+		{"GovCloud/FrontDoorFrontendEndpointClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/FrontDoorFrontendEndpointClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/FrontDoorFrontendEndpointClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/FrontDoorFrontendEndpointClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -347,14 +532,23 @@ func TestFrontDoorFrontendEndpointClientBaseURISetCorrectly(t *testing.T) {
 		tt := tt
 		t.Run(tt.CaseName, func(t *testing.T) {
 			// Override env setting
-			os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			if tt.EnvironmentName != "" {
+				os.Setenv(AzureEnvironmentEnvName, tt.EnvironmentName)
+			} else {
+				os.Unsetenv(AzureEnvironmentEnvName)
+			}
 
-			// Get a AFD frontend endpoint client
+			// Get a FrontDoorFrontendEndpointClient client
 			client, err := CreateFrontDoorFrontendEndpointClientE("")
-			require.NoError(t, err)
+			if tt.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, client)
 
-			// Check for correct ARM URI
-			assert.Equal(t, tt.ExpectedBaseURI, client.BaseURI)
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
+			}
 		})
 	}
 }
@@ -366,11 +560,11 @@ func TestCreateManagedEnvironmentsClientEEndpointURISetCorrectly(t *testing.T) {
 		ExpectedBaseURI string
 		ExpectErr       bool
 	}{
-		{"Default/ManagedEnvironmentsClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint, false},
-		{"PublicCloud/ManagedEnvironmentsClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint, false},
-		{"GovCloud/ManagedEnvironmentsClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint, false},
-		{"ChinaCloud/ManagedEnvironmentsClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint, false},
-		{"GermanCloud/ManagedEnvironmentsClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint, true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
+		{"Default/ManagedEnvironmentsClient", "", publicCloudResourceManagerEndpoint, false},
+		{"GovCloud/ManagedEnvironmentsClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/ManagedEnvironmentsClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/ManagedEnvironmentsClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/ManagedEnvironmentsClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -396,9 +590,9 @@ func TestCreateManagedEnvironmentsClientEEndpointURISetCorrectly(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, client)
-				// Not ideal, but to get the base URI we need to access the internal field
-				field := reflect.ValueOf(client).Elem().FieldByName("internal").Elem().FieldByName("ep")
-				assert.Equal(t, field.String()+"/", tt.ExpectedBaseURI)
+
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
 			}
 		})
 	}
@@ -411,11 +605,11 @@ func TestCreateContainerAppsClientEEndpointURISetCorrectly(t *testing.T) {
 		ExpectedBaseURI string
 		ExpectErr       bool
 	}{
-		{"Default/ContainerAppsClient", "", autorest.PublicCloud.ResourceManagerEndpoint, false},
-		{"PublicCloud/ContainerAppsClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint, false},
-		{"GovCloud/ContainerAppsClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint, false},
-		{"ChinaCloud/ContainerAppsClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint, false},
-		{"GermanCloud/ContainerAppsClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint, true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
+		{"Default/ContainerAppsClient", "", publicCloudResourceManagerEndpoint, false},
+		{"GovCloud/ContainerAppsClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/ContainerAppsClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/ContainerAppsClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/ContainerAppsClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -434,16 +628,16 @@ func TestCreateContainerAppsClientEEndpointURISetCorrectly(t *testing.T) {
 				os.Unsetenv(AzureEnvironmentEnvName)
 			}
 
-			// Get a ManagedEnvironmentsClient client
+			// Get a ContainerAppsClient client
 			client, err := CreateContainerAppsClientE("")
 			if tt.ExpectErr {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, client)
-				// Not ideal, but to get the base URI we need to access the internal field
-				field := reflect.ValueOf(client).Elem().FieldByName("internal").Elem().FieldByName("ep")
-				assert.Equal(t, field.String()+"/", tt.ExpectedBaseURI)
+
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
 			}
 		})
 	}
@@ -456,11 +650,11 @@ func TestCreateContainerAppJobsClientEEndpointURISetCorrectly(t *testing.T) {
 		ExpectedBaseURI string
 		ExpectErr       bool
 	}{
-		{"Default/ContainerAppsClient", "", autorest.PublicCloud.ResourceManagerEndpoint, false},
-		{"PublicCloud/ContainerAppsClient", publicCloudEnvName, autorest.PublicCloud.ResourceManagerEndpoint, false},
-		{"GovCloud/ContainerAppsClient", govCloudEnvName, autorest.USGovernmentCloud.ResourceManagerEndpoint, false},
-		{"ChinaCloud/ContainerAppsClient", chinaCloudEnvName, autorest.ChinaCloud.ResourceManagerEndpoint, false},
-		{"GermanCloud/ContainerAppsClient", germanyCloudEnvName, autorest.GermanCloud.ResourceManagerEndpoint, true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
+		{"Default/ContainerAppJobsClient", "", publicCloudResourceManagerEndpoint, false},
+		{"GovCloud/ContainerAppJobsClient", govCloudEnvName, govCloudResourceManagerEndpoint, false},
+		{"PublicCloud/ContainerAppJobsClient", publicCloudEnvName, publicCloudResourceManagerEndpoint, false},
+		{"ChinaCloud/ContainerAppJobsClient", chinaCloudEnvName, chinaCloudResourceManagerEndpoint, false},
+		{"GermanCloud/ContainerAppJobsClient", germanyCloudEnvName, "", true}, //GermanCloud is deleted as of 2021-10-21 https://learn.microsoft.com/en-us/previous-versions/azure/germany/germany-welcome
 	}
 
 	// save any current env value and restore on exit
@@ -479,16 +673,16 @@ func TestCreateContainerAppJobsClientEEndpointURISetCorrectly(t *testing.T) {
 				os.Unsetenv(AzureEnvironmentEnvName)
 			}
 
-			// Get a ManagedEnvironmentsClient client
+			// Get a ContainerAppJobsClient client
 			client, err := CreateContainerAppJobsClientE("")
 			if tt.ExpectErr {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, client)
-				// Not ideal, but to get the base URI we need to access the internal field
-				field := reflect.ValueOf(client).Elem().FieldByName("internal").Elem().FieldByName("ep")
-				assert.Equal(t, field.String()+"/", tt.ExpectedBaseURI)
+
+				// Check for correct ARM URI
+				assertArmClientEndpoint(t, client, tt.ExpectedBaseURI)
 			}
 		})
 	}
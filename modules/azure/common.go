@@ -65,10 +65,31 @@ func safePtrToInt32(raw *int32) int32 {
 	return *raw
 }
 
-// safePtrToList converts a []string pointer to a non-pointer []string value, or to initialization of an empty slice if the pointer is nil.
-func safePtrToList(raw *[]string) []string {
+// safePtrToBool converts a bool pointer to a non-pointer bool value, or to false if the pointer is nil.
+func safePtrToBool(raw *bool) bool {
 	if raw == nil {
-		return []string{}
+		return false
 	}
 	return *raw
 }
+
+// safeEnumPtrToString converts a pointer to a string-based SDK enum to a non-pointer string value, or to "" if the
+// pointer is nil.
+func safeEnumPtrToString[T ~string](raw *T) string {
+	if raw == nil {
+		return ""
+	}
+	return string(*raw)
+}
+
+// safePtrToList converts a []*string value to a non-pointer []string value, skipping nil entries. A nil input results
+// in an empty slice.
+func safePtrToList(raw []*string) []string {
+	list := []string{}
+	for _, item := range raw {
+		if item != nil {
+			list = append(list, *item)
+		}
+	}
+	return list
+}
@@ -89,6 +89,37 @@ func TestSafePtrToString(t *testing.T) {
 	assert.Equal(t, "Test", stringResult)
 }
 
+func TestSafePtrToBool(t *testing.T) {
+	// When given a nil, should always return false
+	assert.False(t, safePtrToBool(nil))
+
+	// When given a bool, should just de-ref and return
+	boolValue := true
+	assert.True(t, safePtrToBool(&boolValue))
+}
+
+func TestSafeEnumPtrToString(t *testing.T) {
+	type testEnum string
+
+	// When given a nil, should always return an empty string
+	var nilPtr *testEnum = nil
+	assert.Equal(t, "", safeEnumPtrToString(nilPtr))
+
+	// When given an enum value, should de-ref and convert to a plain string
+	enumValue := testEnum("Allow")
+	assert.Equal(t, "Allow", safeEnumPtrToString(&enumValue))
+}
+
+func TestSafePtrToList(t *testing.T) {
+	// When given a nil, should always return an empty slice
+	assert.Equal(t, []string{}, safePtrToList(nil))
+
+	// When given a list of pointers, should de-ref each entry and skip nils
+	first := "10.0.0.0/16"
+	second := "10.1.0.0/16"
+	assert.Equal(t, []string{first, second}, safePtrToList([]*string{&first, nil, &second}))
+}
+
 func TestSafePtrToInt32(t *testing.T) {
 	// When given a nil, should always return an zero value int32
 	var nilPtr *int32 = nil
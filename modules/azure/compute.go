@@ -3,20 +3,21 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
 // GetVirtualMachineClient is a helper function that will setup an Azure Virtual Machine client on your behalf.
-func GetVirtualMachineClient(t testing.TestingT, subscriptionID string) *compute.VirtualMachinesClient {
+func GetVirtualMachineClient(t testing.TestingT, subscriptionID string) *armcompute.VirtualMachinesClient {
 	vmClient, err := GetVirtualMachineClientE(subscriptionID)
 	require.NoError(t, err)
 	return vmClient
 }
 
 // GetVirtualMachineClientE is a helper function that will setup an Azure Virtual Machine client on your behalf.
-func GetVirtualMachineClientE(subscriptionID string) (*compute.VirtualMachinesClient, error) {
+func GetVirtualMachineClientE(subscriptionID string) (*armcompute.VirtualMachinesClient, error) {
 
 	// snippet-tag-start::client_factory_example.helper
 	// Create a VM client
@@ -26,14 +27,6 @@ func GetVirtualMachineClientE(subscriptionID string) (*compute.VirtualMachinesCl
 	}
 	// snippet-tag-end::client_factory_example.helper
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	vmClient.Authorizer = *authorizer
 	return vmClient, nil
 }
 
@@ -77,7 +70,7 @@ func GetVirtualMachineNicsE(vmName string, resGroupName string, subscriptionID s
 	}
 
 	// Get VM NIC(s); value always present, no nil checks needed.
-	vmNICs := *vm.NetworkProfile.NetworkInterfaces
+	vmNICs := vm.Properties.NetworkProfile.NetworkInterfaces
 
 	nics := make([]string, len(vmNICs))
 	for i, nic := range vmNICs {
@@ -109,7 +102,7 @@ func GetVirtualMachineManagedDisksE(vmName string, resGroupName string, subscrip
 	}
 
 	// Get VM attached Disks; value always present even if no disks attached, no nil check needed.
-	vmDisks := *vm.StorageProfile.DataDisks
+	vmDisks := vm.Properties.StorageProfile.DataDisks
 
 	// Get the Names of the attached Managed Disks
 	diskNames := make([]string, len(vmDisks))
@@ -138,7 +131,7 @@ func GetVirtualMachineOSDiskNameE(vmName string, resGroupName string, subscripti
 		return "", err
 	}
 
-	return *vm.StorageProfile.OsDisk.Name, nil
+	return *vm.Properties.StorageProfile.OSDisk.Name, nil
 }
 
 // GetVirtualMachineAvailabilitySetID gets the Availability Set ID of the specified Azure Virtual Machine.
@@ -159,12 +152,12 @@ func GetVirtualMachineAvailabilitySetIDE(vmName string, resGroupName string, sub
 	}
 
 	// Virtual Machine has no associated Availability Set
-	if vm.AvailabilitySet == nil {
+	if vm.Properties.AvailabilitySet == nil {
 		return "", nil
 	}
 
 	// Get ID from resource string
-	avs, err := GetNameFromResourceIDE(*vm.AvailabilitySet.ID)
+	avs, err := GetNameFromResourceIDE(*vm.Properties.AvailabilitySet.ID)
 	if err != nil {
 		return "", err
 	}
@@ -200,17 +193,17 @@ func GetVirtualMachineImageE(vmName string, resGroupName string, subscriptionID
 	}
 
 	// Populate VM Image; values always present, no nil checks needed
-	vmImage.Publisher = *vm.StorageProfile.ImageReference.Publisher
-	vmImage.Offer = *vm.StorageProfile.ImageReference.Offer
-	vmImage.SKU = *vm.StorageProfile.ImageReference.Sku
-	vmImage.Version = *vm.StorageProfile.ImageReference.Version
+	vmImage.Publisher = *vm.Properties.StorageProfile.ImageReference.Publisher
+	vmImage.Offer = *vm.Properties.StorageProfile.ImageReference.Offer
+	vmImage.SKU = *vm.Properties.StorageProfile.ImageReference.SKU
+	vmImage.Version = *vm.Properties.StorageProfile.ImageReference.Version
 
 	return vmImage, nil
 }
 
 // GetSizeOfVirtualMachine gets the Size Type of the specified Azure Virtual Machine.
 // This function would fail the test if there is an error.
-func GetSizeOfVirtualMachine(t testing.TestingT, vmName string, resGroupName string, subscriptionID string) compute.VirtualMachineSizeTypes {
+func GetSizeOfVirtualMachine(t testing.TestingT, vmName string, resGroupName string, subscriptionID string) armcompute.VirtualMachineSizeTypes {
 	size, err := GetSizeOfVirtualMachineE(vmName, resGroupName, subscriptionID)
 	require.NoError(t, err)
 
@@ -218,14 +211,14 @@ func GetSizeOfVirtualMachine(t testing.TestingT, vmName string, resGroupName str
 }
 
 // GetSizeOfVirtualMachineE gets the Size Type of the specified Azure Virtual Machine.
-func GetSizeOfVirtualMachineE(vmName string, resGroupName string, subscriptionID string) (compute.VirtualMachineSizeTypes, error) {
+func GetSizeOfVirtualMachineE(vmName string, resGroupName string, subscriptionID string) (armcompute.VirtualMachineSizeTypes, error) {
 	// Get VM Object
 	vm, err := GetVirtualMachineE(vmName, resGroupName, subscriptionID)
 	if err != nil {
 		return "", err
 	}
 
-	return vm.VirtualMachineProperties.HardwareProfile.VMSize, nil
+	return *vm.Properties.HardwareProfile.VMSize, nil
 }
 
 // GetVirtualMachineTags gets the Tags of the specified Virtual Machine as a map.
@@ -277,13 +270,15 @@ func ListVirtualMachinesForResourceGroupE(resourceGroupName string, subscription
 		return nil, err
 	}
 
-	vms, err := vmClient.List(context.Background(), resourceGroupName)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, v := range vms.Values() {
-		vmDetails = append(vmDetails, *v.Name)
+	pager := vmClient.NewListPager(resourceGroupName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			vmDetails = append(vmDetails, *v.Name)
+		}
 	}
 	return vmDetails, nil
 }
@@ -291,7 +286,7 @@ func ListVirtualMachinesForResourceGroupE(resourceGroupName string, subscription
 // GetVirtualMachinesForResourceGroup gets all Virtual Machine objects in the specified Resource Group. Each
 // VM Object represents the entire set of VM compute properties accessible by using the VM name as the map key.
 // This function would fail the test if there is an error.
-func GetVirtualMachinesForResourceGroup(t testing.TestingT, resGroupName string, subscriptionID string) map[string]compute.VirtualMachineProperties {
+func GetVirtualMachinesForResourceGroup(t testing.TestingT, resGroupName string, subscriptionID string) map[string]armcompute.VirtualMachineProperties {
 	vms, err := GetVirtualMachinesForResourceGroupE(resGroupName, subscriptionID)
 	require.NoError(t, err)
 	return vms
@@ -299,24 +294,25 @@ func GetVirtualMachinesForResourceGroup(t testing.TestingT, resGroupName string,
 
 // GetVirtualMachinesForResourceGroupE gets all Virtual Machine objects in the specified Resource Group. Each
 // VM Object represents the entire set of VM compute properties accessible by using the VM name as the map key.
-func GetVirtualMachinesForResourceGroupE(resourceGroupName string, subscriptionID string) (map[string]compute.VirtualMachineProperties, error) {
+func GetVirtualMachinesForResourceGroupE(resourceGroupName string, subscriptionID string) (map[string]armcompute.VirtualMachineProperties, error) {
 	// Create VM Client
 	vmClient, err := GetVirtualMachineClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the list of VMs in the Resource Group
-	vms, err := vmClient.List(context.Background(), resourceGroupName)
-	if err != nil {
-		return nil, err
-	}
-
 	// Get the VMs in the Resource Group.
-	vmDetails := make(map[string]compute.VirtualMachineProperties, len(vms.Values()))
-	for _, v := range vms.Values() {
-		// VM name and machine properties are required for each VM, no nill check required.
-		vmDetails[*v.Name] = *v.VirtualMachineProperties
+	vmDetails := make(map[string]armcompute.VirtualMachineProperties)
+	pager := vmClient.NewListPager(resourceGroupName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			// VM name and machine properties are required for each VM, no nill check required.
+			vmDetails[*v.Name] = *v.Properties
+		}
 	}
 	return vmDetails, nil
 }
@@ -327,12 +323,12 @@ func GetVirtualMachinesForResourceGroupE(resourceGroupName string, subscriptionI
 
 // Instance of the VM
 type Instance struct {
-	*compute.VirtualMachine
+	*armcompute.VirtualMachine
 }
 
 // GetVirtualMachineInstanceSize gets the size of the Virtual Machine.
-func (vm *Instance) GetVirtualMachineInstanceSize() compute.VirtualMachineSizeTypes {
-	return vm.VirtualMachineProperties.HardwareProfile.VMSize
+func (vm *Instance) GetVirtualMachineInstanceSize() armcompute.VirtualMachineSizeTypes {
+	return *vm.Properties.HardwareProfile.VMSize
 }
 
 // *********************** //
@@ -341,14 +337,14 @@ func (vm *Instance) GetVirtualMachineInstanceSize() compute.VirtualMachineSizeTy
 
 // GetVirtualMachine gets a Virtual Machine in the specified Azure Resource Group.
 // This function would fail the test if there is an error.
-func GetVirtualMachine(t testing.TestingT, vmName string, resGroupName string, subscriptionID string) *compute.VirtualMachine {
+func GetVirtualMachine(t testing.TestingT, vmName string, resGroupName string, subscriptionID string) *armcompute.VirtualMachine {
 	vm, err := GetVirtualMachineE(vmName, resGroupName, subscriptionID)
 	require.NoError(t, err)
 	return vm
 }
 
 // GetVirtualMachineE gets a Virtual Machine in the specified Azure Resource Group.
-func GetVirtualMachineE(vmName string, resGroupName string, subscriptionID string) (*compute.VirtualMachine, error) {
+func GetVirtualMachineE(vmName string, resGroupName string, subscriptionID string) (*armcompute.VirtualMachine, error) {
 	// Validate resource group name and subscription ID
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -361,10 +357,49 @@ func GetVirtualMachineE(vmName string, resGroupName string, subscriptionID strin
 		return nil, err
 	}
 
-	vm, err := client.Get(context.Background(), resGroupName, vmName, compute.InstanceView)
+	vm, err := client.Get(context.Background(), resGroupName, vmName, &armcompute.VirtualMachinesClientGetOptions{
+		Expand: to.Ptr(armcompute.InstanceViewTypesInstanceView),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &vm, nil
+	return &vm.VirtualMachine, nil
+}
+
+// RunCommandOnVirtualMachine runs the given script on the specified Azure Virtual Machine with the Run Command API.
+// See RunCommandOnVirtualMachineE.
+func RunCommandOnVirtualMachine(t testing.TestingT, vmName string, resGroupName string, subscriptionID string, commandID string, script []string) VMRunCommandResult {
+	result, err := RunCommandOnVirtualMachineE(vmName, resGroupName, subscriptionID, commandID, script)
+	require.NoError(t, err)
+
+	return result
+}
+
+// RunCommandOnVirtualMachineE runs the given script on the specified Azure Virtual Machine with the Run Command API,
+// and returns its output. The commandID is RunShellScript for Linux VMs and RunPowerShellScript for Windows VMs.
+func RunCommandOnVirtualMachineE(vmName string, resGroupName string, subscriptionID string, commandID string, script []string) (VMRunCommandResult, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	client, err := GetVirtualMachineClientE(subscriptionID)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	poller, err := client.BeginRunCommand(context.Background(), resGroupName, vmName, armcompute.RunCommandInput{
+		CommandID: &commandID,
+		Script:    to.SliceOfPtrs(script...),
+	}, nil)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+	resp, err := poller.PollUntilDone(context.Background(), nil)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	return parseRunCommandResult(resp.RunCommandResult), nil
 }
@@ -0,0 +1,407 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerregistry/armcontainerregistry"
+	"github.com/gruntwork-io/terratest/modules/docker"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// containerRegistryRefreshTokenUsername is the user name to log in to a container registry with an ACR refresh token.
+const containerRegistryRefreshTokenUsername = "00000000-0000-0000-0000-000000000000"
+
+// containerRegistryNextLinkRegexp matches the URL of the next page in the Link header of registry API responses.
+var containerRegistryNextLinkRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// ContainerRegistryCredentials are the credentials to log in to a container registry with Docker.
+type ContainerRegistryCredentials struct {
+	LoginServer string // The host name of the registry, e.g., myregistry.azurecr.io
+	Username    string // The user name, always 00000000-0000-0000-0000-000000000000
+	Password    string // The ACR refresh token for the Azure identity of the test, valid for 3 hours
+}
+
+// ContainerRegistryPolicies summarizes the retention, content trust and quarantine policies of a container registry.
+// These policies are only available on the Premium SKU.
+type ContainerRegistryPolicies struct {
+	RetentionEnabled    bool
+	RetentionDays       int32
+	ContentTrustEnabled bool
+	QuarantineEnabled   bool
+}
+
+// GetContainerRegistryCredentials gets the credentials to log in to the given container registry as the Azure identity
+// of the test. This function would fail the test if there is an error.
+func GetContainerRegistryCredentials(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) ContainerRegistryCredentials {
+	credentials, err := GetContainerRegistryCredentialsE(t, registryName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return credentials
+}
+
+// GetContainerRegistryCredentialsE gets the credentials to log in to the given container registry as the Azure
+// identity of the test, by exchanging a Microsoft Entra ID access token for an ACR refresh token. This works without
+// the admin user of the registry, but requires the identity to have a role on the registry, e.g., AcrPush.
+func GetContainerRegistryCredentialsE(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) (ContainerRegistryCredentials, error) {
+	return GetContainerRegistryCredentialsWithOptionsE(t, registryName, resGroupName, subscriptionID, authOptionsFromEnv())
+}
+
+// GetContainerRegistryCredentialsWithOptionsE gets the credentials to log in to the given container registry like
+// GetContainerRegistryCredentialsE, as the Azure identity authenticated according to the given AuthOptions.
+func GetContainerRegistryCredentialsWithOptionsE(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, authOptions AuthOptions) (ContainerRegistryCredentials, error) {
+	loginServer, err := getContainerRegistryLoginServerE(registryName, resGroupName, subscriptionID, authOptions)
+	if err != nil {
+		return ContainerRegistryCredentials{}, err
+	}
+
+	refreshToken, err := getContainerRegistryRefreshTokenE(loginServer, authOptions)
+	if err != nil {
+		return ContainerRegistryCredentials{}, err
+	}
+
+	return ContainerRegistryCredentials{
+		LoginServer: loginServer,
+		Username:    containerRegistryRefreshTokenUsername,
+		Password:    refreshToken,
+	}, nil
+}
+
+// DockerLoginToContainerRegistry logs Docker in to the given container registry as the Azure identity of the test.
+// This function would fail the test if there is an error.
+func DockerLoginToContainerRegistry(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) {
+	require.NoError(t, DockerLoginToContainerRegistryE(t, registryName, resGroupName, subscriptionID))
+}
+
+// DockerLoginToContainerRegistryE logs Docker in to the given container registry as the Azure identity of the test, so
+// that the docker module can push and pull its images. The token is passed on stdin so it doesn't show in the logs.
+func DockerLoginToContainerRegistryE(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) error {
+	credentials, err := GetContainerRegistryCredentialsE(t, registryName, resGroupName, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return dockerLoginToContainerRegistryE(t, credentials)
+}
+
+// dockerLoginToContainerRegistryE logs Docker in to a container registry with the given credentials.
+func dockerLoginToContainerRegistryE(t testing.TestingT, credentials ContainerRegistryCredentials) error {
+	logger.Default.Logf(t, "Logging in to container registry %s", credentials.LoginServer)
+	cmd := exec.Command("docker", "login", "--username", credentials.Username, "--password-stdin", credentials.LoginServer)
+	cmd.Stdin = strings.NewReader(credentials.Password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker login to %s failed: %w: %s", credentials.LoginServer, err, output)
+	}
+
+	return nil
+}
+
+// PushImageToContainerRegistry pushes the given local image to the given repository of the given container registry.
+// See PushImageToContainerRegistryE.
+func PushImageToContainerRegistry(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, localImage string, repository string, tag string) string {
+	imageURI, err := PushImageToContainerRegistryE(t, registryName, resGroupName, subscriptionID, localImage, repository, tag)
+	require.NoError(t, err)
+
+	return imageURI
+}
+
+// PushImageToContainerRegistryE logs in to the given container registry, tags the given local image (e.g., built with
+// docker.Build) with the given tag in the given repository, pushes it, and returns its URI.
+func PushImageToContainerRegistryE(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, localImage string, repository string, tag string) (string, error) {
+	credentials, err := GetContainerRegistryCredentialsE(t, registryName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	if err := dockerLoginToContainerRegistryE(t, credentials); err != nil {
+		return "", err
+	}
+
+	imageURI := fmt.Sprintf("%s/%s:%s", credentials.LoginServer, repository, tag)
+	err = shell.RunCommandE(t, shell.Command{
+		Command: "docker",
+		Args:    []string{"tag", localImage, imageURI},
+		Logger:  logger.Default,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := docker.PushE(t, logger.Default, imageURI); err != nil {
+		return "", err
+	}
+
+	return imageURI, nil
+}
+
+// PullImageFromContainerRegistry pulls the image with the given tag in the given repository of the given container
+// registry. See PullImageFromContainerRegistryE.
+func PullImageFromContainerRegistry(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, repository string, tag string) string {
+	imageURI, err := PullImageFromContainerRegistryE(t, registryName, resGroupName, subscriptionID, repository, tag)
+	require.NoError(t, err)
+
+	return imageURI
+}
+
+// PullImageFromContainerRegistryE logs in to the given container registry, pulls the image with the given tag in the
+// given repository, and returns its URI, e.g., to run it with docker.Run.
+func PullImageFromContainerRegistryE(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, repository string, tag string) (string, error) {
+	credentials, err := GetContainerRegistryCredentialsE(t, registryName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	if err := dockerLoginToContainerRegistryE(t, credentials); err != nil {
+		return "", err
+	}
+
+	imageURI := fmt.Sprintf("%s/%s:%s", credentials.LoginServer, repository, tag)
+	logger.Default.Logf(t, "Running 'docker pull' for image %s", imageURI)
+	err = shell.RunCommandE(t, shell.Command{
+		Command: "docker",
+		Args:    []string{"pull", imageURI},
+		Logger:  logger.Default,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return imageURI, nil
+}
+
+// ListContainerRegistryTags lists the tags of the given repository of the given container registry.
+// This function would fail the test if there is an error.
+func ListContainerRegistryTags(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, repository string) []string {
+	tags, err := ListContainerRegistryTagsE(t, registryName, resGroupName, subscriptionID, repository)
+	require.NoError(t, err)
+
+	return tags
+}
+
+// ListContainerRegistryTagsE lists the tags of the given repository of the given container registry.
+func ListContainerRegistryTagsE(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, repository string) ([]string, error) {
+	credentials, err := GetContainerRegistryCredentialsE(t, registryName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = postContainerRegistryFormE(credentials.LoginServer, "/oauth2/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"service":       {credentials.LoginServer},
+		"scope":         {fmt.Sprintf("repository:%s:pull", repository)},
+		"refresh_token": {credentials.Password},
+	}, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+	next := fmt.Sprintf("https://%s/v2/%s/tags/list", credentials.LoginServer, repository)
+	for next != "" {
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeContainerRegistryResponseE(resp, &page)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+
+		next, err = containerRegistryNextPageURLE(next, resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
+
+// GetContainerRegistryPolicies gets the retention, content trust and quarantine policies of the given container
+// registry. This function would fail the test if there is an error.
+func GetContainerRegistryPolicies(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) ContainerRegistryPolicies {
+	policies, err := GetContainerRegistryPoliciesE(registryName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return policies
+}
+
+// GetContainerRegistryPoliciesE gets the retention, content trust and quarantine policies of the given container
+// registry.
+func GetContainerRegistryPoliciesE(registryName string, resGroupName string, subscriptionID string) (ContainerRegistryPolicies, error) {
+	registry, err := GetContainerRegistryE(registryName, resGroupName, subscriptionID)
+	if err != nil {
+		return ContainerRegistryPolicies{}, err
+	}
+	if registry.Properties == nil || registry.Properties.Policies == nil {
+		return ContainerRegistryPolicies{}, nil
+	}
+
+	return newContainerRegistryPolicies(registry.Properties.Policies), nil
+}
+
+// newContainerRegistryPolicies summarizes the given registry policies.
+func newContainerRegistryPolicies(policies *armcontainerregistry.Policies) ContainerRegistryPolicies {
+	enabled := func(status *armcontainerregistry.PolicyStatus) bool {
+		return status != nil && *status == armcontainerregistry.PolicyStatusEnabled
+	}
+
+	summary := ContainerRegistryPolicies{}
+	if policies.RetentionPolicy != nil {
+		summary.RetentionEnabled = enabled(policies.RetentionPolicy.Status)
+		summary.RetentionDays = safePtrToInt32(policies.RetentionPolicy.Days)
+	}
+	if policies.TrustPolicy != nil {
+		summary.ContentTrustEnabled = enabled(policies.TrustPolicy.Status)
+	}
+	if policies.QuarantinePolicy != nil {
+		summary.QuarantineEnabled = enabled(policies.QuarantinePolicy.Status)
+	}
+
+	return summary
+}
+
+// AssertContainerRegistryRetentionDays checks that the given container registry retains untagged manifests for the
+// expected number of days.
+func AssertContainerRegistryRetentionDays(t testing.TestingT, registryName string, resGroupName string, subscriptionID string, expectedDays int32) {
+	policies, err := GetContainerRegistryPoliciesE(registryName, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, policies.RetentionEnabled, "Retention policy of container registry %s is not enabled", registryName)
+		assert.Equalf(t, expectedDays, policies.RetentionDays, "Unexpected retention days of container registry %s", registryName)
+	}
+}
+
+// AssertContainerRegistryContentTrustEnabled checks that content trust is enabled on the given container registry.
+func AssertContainerRegistryContentTrustEnabled(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) {
+	policies, err := GetContainerRegistryPoliciesE(registryName, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, policies.ContentTrustEnabled, "Content trust of container registry %s is not enabled", registryName)
+	}
+}
+
+// AssertContainerRegistryQuarantineEnabled checks that quarantine is enabled on the given container registry.
+func AssertContainerRegistryQuarantineEnabled(t testing.TestingT, registryName string, resGroupName string, subscriptionID string) {
+	policies, err := GetContainerRegistryPoliciesE(registryName, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, policies.QuarantineEnabled, "Quarantine of container registry %s is not enabled", registryName)
+	}
+}
+
+// getContainerRegistryLoginServerE returns the login server of the given container registry, read with the given
+// AuthOptions.
+func getContainerRegistryLoginServerE(registryName string, resGroupName string, subscriptionID string, authOptions AuthOptions) (string, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return "", err
+	}
+	client, err := NewArmClientWithOptionsE(subscriptionID, authOptions, armcontainerregistry.NewRegistriesClient)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Get(context.Background(), rgName, registryName, nil)
+	if err != nil {
+		return "", err
+	}
+	registry := resp.Registry
+	if registry.Properties == nil || registry.Properties.LoginServer == nil {
+		return "", fmt.Errorf("container registry %s has no login server yet", registryName)
+	}
+
+	return *registry.Properties.LoginServer, nil
+}
+
+// getContainerRegistryRefreshTokenE exchanges a Microsoft Entra ID access token, requested with the given AuthOptions,
+// for a refresh token of the container registry with the given login server.
+func getContainerRegistryRefreshTokenE(loginServer string, authOptions AuthOptions) (string, error) {
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return "", err
+	}
+	clientCloudConfig, err := getClientCloudConfig()
+	if err != nil {
+		return "", err
+	}
+	scope := strings.TrimSuffix(clientCloudConfig.Services[cloud.ResourceManager].Audience, "/") + "/.default"
+	accessToken, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = postContainerRegistryFormE(loginServer, "/oauth2/exchange", url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {loginServer},
+		"access_token": {accessToken.Token},
+	}, &token)
+	if err != nil {
+		return "", err
+	}
+
+	return token.RefreshToken, nil
+}
+
+// postContainerRegistryFormE posts the given form to the given path of the container registry with the given login
+// server and decodes the JSON response into result.
+func postContainerRegistryFormE(loginServer string, path string, form url.Values, result interface{}) error {
+	resp, err := http.PostForm("https://"+loginServer+path, form)
+	if err != nil {
+		return err
+	}
+
+	return decodeContainerRegistryResponseE(resp, result)
+}
+
+// decodeContainerRegistryResponseE decodes the JSON body of the given registry API response into result, and closes
+// it.
+func decodeContainerRegistryResponseE(resp *http.Response, result interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed: %s", resp.Request.URL.Redacted(), resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// containerRegistryNextPageURLE returns the absolute URL of the next page in the given Link header of a response to a
+// request to the given URL, or an empty string if there is no next page.
+func containerRegistryNextPageURLE(current string, linkHeader string) (string, error) {
+	match := containerRegistryNextLinkRegexp.FindStringSubmatch(linkHeader)
+	if match == nil {
+		return "", nil
+	}
+
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(match[1])
+	if err != nil {
+		return "", err
+	}
+
+	return next.String(), nil
+}
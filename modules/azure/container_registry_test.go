@@ -0,0 +1,53 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerregistry/armcontainerregistry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContainerRegistryPolicies(t *testing.T) {
+	t.Parallel()
+
+	enabled := armcontainerregistry.PolicyStatusEnabled
+	disabled := armcontainerregistry.PolicyStatusDisabled
+	days := int32(7)
+
+	policies := newContainerRegistryPolicies(&armcontainerregistry.Policies{
+		RetentionPolicy:  &armcontainerregistry.RetentionPolicy{Status: &enabled, Days: &days},
+		TrustPolicy:      &armcontainerregistry.TrustPolicy{Status: &disabled},
+		QuarantinePolicy: &armcontainerregistry.QuarantinePolicy{Status: &enabled},
+	})
+	assert.Equal(t, ContainerRegistryPolicies{RetentionEnabled: true, RetentionDays: 7, QuarantineEnabled: true}, policies)
+
+	assert.Equal(t, ContainerRegistryPolicies{}, newContainerRegistryPolicies(&armcontainerregistry.Policies{}))
+}
+
+func TestContainerRegistryNextPageURL(t *testing.T) {
+	t.Parallel()
+
+	current := "https://myregistry.azurecr.io/v2/app/tags/list"
+
+	next, err := containerRegistryNextPageURLE(current, `</v2/app/tags/list?last=v1&n=100>; rel="next"`)
+	require.NoError(t, err)
+	assert.Equal(t, "https://myregistry.azurecr.io/v2/app/tags/list?last=v1&n=100", next)
+
+	next, err = containerRegistryNextPageURLE(current, "")
+	require.NoError(t, err)
+	assert.Empty(t, next)
+}
+
+func TestGetContainerRegistryPoliciesE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetContainerRegistryPoliciesE("", "", "")
+	require.Error(t, err)
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/containerinstance/mgmt/2018-10-01/containerinstance"
-	"github.com/Azure/azure-sdk-for-go/services/containerregistry/mgmt/2019-05-01/containerregistry"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerregistry/armcontainerregistry"
 
 	"github.com/stretchr/testify/require"
 )
@@ -33,7 +33,7 @@ func ContainerRegistryExistsE(registryName string, resourceGroupName string, sub
 
 // GetContainerRegistry gets the container registry object
 // This function would fail the test if there is an error.
-func GetContainerRegistry(t *testing.T, registryName string, resGroupName string, subscriptionID string) *containerregistry.Registry {
+func GetContainerRegistry(t *testing.T, registryName string, resGroupName string, subscriptionID string) *armcontainerregistry.Registry {
 	resource, err := GetContainerRegistryE(registryName, resGroupName, subscriptionID)
 
 	require.NoError(t, err)
@@ -42,7 +42,7 @@ func GetContainerRegistry(t *testing.T, registryName string, resGroupName string
 }
 
 // GetContainerRegistryE gets the container registry object
-func GetContainerRegistryE(registryName string, resGroupName string, subscriptionID string) (*containerregistry.Registry, error) {
+func GetContainerRegistryE(registryName string, resGroupName string, subscriptionID string) (*armcontainerregistry.Registry, error) {
 	rgName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
 		return nil, err
@@ -53,31 +53,18 @@ func GetContainerRegistryE(registryName string, resGroupName string, subscriptio
 		return nil, err
 	}
 
-	resource, err := client.Get(context.Background(), rgName, registryName)
+	resource, err := client.Get(context.Background(), rgName, registryName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &resource, nil
+	return &resource.Registry, nil
 }
 
 // GetContainerRegistryClientE is a helper function that will setup an Azure Container Registry client on your behalf
-func GetContainerRegistryClientE(subscriptionID string) (*containerregistry.RegistriesClient, error) {
+func GetContainerRegistryClientE(subscriptionID string) (*armcontainerregistry.RegistriesClient, error) {
 	// Create an ACR client
-	registryClient, err := CreateContainerRegistryClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	registryClient.Authorizer = *authorizer
-	return registryClient, nil
+	return CreateContainerRegistryClientE(subscriptionID)
 }
 
 // ContainerInstanceExists indicates whether the specified container instance exists.
@@ -103,7 +90,7 @@ func ContainerInstanceExistsE(instanceName string, resourceGroupName string, sub
 
 // GetContainerInstance gets the container instance object
 // This function would fail the test if there is an error.
-func GetContainerInstance(t *testing.T, instanceName string, resGroupName string, subscriptionID string) *containerinstance.ContainerGroup {
+func GetContainerInstance(t *testing.T, instanceName string, resGroupName string, subscriptionID string) *armcontainerinstance.ContainerGroup {
 	instance, err := GetContainerInstanceE(instanceName, resGroupName, subscriptionID)
 
 	require.NoError(t, err)
@@ -112,7 +99,7 @@ func GetContainerInstance(t *testing.T, instanceName string, resGroupName string
 }
 
 // GetContainerInstanceE gets the container instance object
-func GetContainerInstanceE(instanceName string, resGroupName string, subscriptionID string) (*containerinstance.ContainerGroup, error) {
+func GetContainerInstanceE(instanceName string, resGroupName string, subscriptionID string) (*armcontainerinstance.ContainerGroup, error) {
 	rgName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
 		return nil, err
@@ -123,29 +110,16 @@ func GetContainerInstanceE(instanceName string, resGroupName string, subscriptio
 		return nil, err
 	}
 
-	instance, err := client.Get(context.Background(), rgName, instanceName)
+	instance, err := client.Get(context.Background(), rgName, instanceName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &instance, nil
+	return &instance.ContainerGroup, nil
 }
 
 // GetContainerInstanceClientE is a helper function that will setup an Azure Container Instance client on your behalf
-func GetContainerInstanceClientE(subscriptionID string) (*containerinstance.ContainerGroupsClient, error) {
+func GetContainerInstanceClientE(subscriptionID string) (*armcontainerinstance.ContainerGroupsClient, error) {
 	// Create an ACI client
-	instanceClient, err := CreateContainerInstanceClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	instanceClient.Authorizer = *authorizer
-	return instanceClient, nil
+	return CreateContainerInstanceClientE(subscriptionID)
 }
@@ -2,35 +2,24 @@ package azure
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/preview/cosmos-db/mgmt/documentdb"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/cosmos/armcosmos/v3"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
 // GetCosmosDBAccountClientE is a helper function that will setup a CosmosDB account client.
-func GetCosmosDBAccountClientE(subscriptionID string) (*documentdb.DatabaseAccountsClient, error) {
+func GetCosmosDBAccountClientE(subscriptionID string) (*armcosmos.DatabaseAccountsClient, error) {
 
 	// Create a CosmosDB client
-	cosmosClient, err := CreateCosmosDBAccountClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	cosmosClient.Authorizer = *authorizer
-
-	return cosmosClient, nil
+	return CreateCosmosDBAccountClientE(subscriptionID)
 }
 
 // GetCosmosDBAccountClient is a helper function that will setup a CosmosDB account client. This function would fail the test if there is an error.
-func GetCosmosDBAccountClient(t testing.TestingT, subscriptionID string) *documentdb.DatabaseAccountsClient {
+func GetCosmosDBAccountClient(t testing.TestingT, subscriptionID string) *armcosmos.DatabaseAccountsClient {
 	cosmosDBAccount, err := GetCosmosDBAccountClientE(subscriptionID)
 	require.NoError(t, err)
 
@@ -38,7 +27,7 @@ func GetCosmosDBAccountClient(t testing.TestingT, subscriptionID string) *docume
 }
 
 // GetCosmosDBAccount is a helper function that gets the database account. This function would fail the test if there is an error.
-func GetCosmosDBAccount(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) *documentdb.DatabaseAccountGetResults {
+func GetCosmosDBAccount(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) *armcosmos.DatabaseAccountGetResults {
 	cosmosDBAccount, err := GetCosmosDBAccountE(t, subscriptionID, resourceGroupName, accountName)
 	require.NoError(t, err)
 
@@ -46,7 +35,7 @@ func GetCosmosDBAccount(t testing.TestingT, subscriptionID string, resourceGroup
 }
 
 // GetCosmosDBAccountE is a helper function that gets the database account.
-func GetCosmosDBAccountE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) (*documentdb.DatabaseAccountGetResults, error) {
+func GetCosmosDBAccountE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) (*armcosmos.DatabaseAccountGetResults, error) {
 	// Create a CosmosDB client
 	cosmosClient, err := GetCosmosDBAccountClientE(subscriptionID)
 	if err != nil {
@@ -54,38 +43,24 @@ func GetCosmosDBAccountE(t testing.TestingT, subscriptionID string, resourceGrou
 	}
 
 	// Get the corresponding database account
-	cosmosDBAccount, err := cosmosClient.Get(context.Background(), resourceGroupName, accountName)
+	cosmosDBAccount, err := cosmosClient.Get(context.Background(), resourceGroupName, accountName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return DB
-	return &cosmosDBAccount, nil
+	return &cosmosDBAccount.DatabaseAccountGetResults, nil
 }
 
 // GetCosmosDBSQLClientE is a helper function that will setup a CosmosDB SQL client.
-func GetCosmosDBSQLClientE(subscriptionID string) (*documentdb.SQLResourcesClient, error) {
+func GetCosmosDBSQLClientE(subscriptionID string) (*armcosmos.SQLResourcesClient, error) {
 
 	// Create a CosmosDB client
-	cosmosClient, err := CreateCosmosDBSQLClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	cosmosClient.Authorizer = *authorizer
-
-	return cosmosClient, nil
+	return CreateCosmosDBSQLClientE(subscriptionID)
 }
 
 // GetCosmosDBSQLClient is a helper function that will setup a CosmosDB SQL client. This function would fail the test if there is an error.
-func GetCosmosDBSQLClient(t testing.TestingT, subscriptionID string) *documentdb.SQLResourcesClient {
+func GetCosmosDBSQLClient(t testing.TestingT, subscriptionID string) *armcosmos.SQLResourcesClient {
 	cosmosClient, err := GetCosmosDBSQLClientE(subscriptionID)
 	require.NoError(t, err)
 
@@ -93,7 +68,7 @@ func GetCosmosDBSQLClient(t testing.TestingT, subscriptionID string) *documentdb
 }
 
 // GetCosmosDBSQLDatabase is a helper function that gets a SQL database. This function would fail the test if there is an error.
-func GetCosmosDBSQLDatabase(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) *documentdb.SQLDatabaseGetResults {
+func GetCosmosDBSQLDatabase(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) *armcosmos.SQLDatabaseGetResults {
 	cosmosSQLDB, err := GetCosmosDBSQLDatabaseE(t, subscriptionID, resourceGroupName, accountName, databaseName)
 	require.NoError(t, err)
 
@@ -101,7 +76,7 @@ func GetCosmosDBSQLDatabase(t testing.TestingT, subscriptionID string, resourceG
 }
 
 // GetCosmosDBSQLDatabaseE is a helper function that gets a SQL database.
-func GetCosmosDBSQLDatabaseE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) (*documentdb.SQLDatabaseGetResults, error) {
+func GetCosmosDBSQLDatabaseE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) (*armcosmos.SQLDatabaseGetResults, error) {
 	// Create a CosmosDB client
 	cosmosClient, err := GetCosmosDBSQLClientE(subscriptionID)
 	if err != nil {
@@ -109,17 +84,17 @@ func GetCosmosDBSQLDatabaseE(t testing.TestingT, subscriptionID string, resource
 	}
 
 	// Get the corresponding database
-	cosmosSQLDB, err := cosmosClient.GetSQLDatabase(context.Background(), resourceGroupName, accountName, databaseName)
+	cosmosSQLDB, err := cosmosClient.GetSQLDatabase(context.Background(), resourceGroupName, accountName, databaseName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return DB
-	return &cosmosSQLDB, nil
+	return &cosmosSQLDB.SQLDatabaseGetResults, nil
 }
 
 // GetCosmosDBSQLContainer is a helper function that gets a SQL container. This function would fail the test if there is an error.
-func GetCosmosDBSQLContainer(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) *documentdb.SQLContainerGetResults {
+func GetCosmosDBSQLContainer(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) *armcosmos.SQLContainerGetResults {
 	cosmosSQLContainer, err := GetCosmosDBSQLContainerE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
 	require.NoError(t, err)
 
@@ -127,7 +102,7 @@ func GetCosmosDBSQLContainer(t testing.TestingT, subscriptionID string, resource
 }
 
 // GetCosmosDBSQLContainerE is a helper function that gets a SQL container.
-func GetCosmosDBSQLContainerE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) (*documentdb.SQLContainerGetResults, error) {
+func GetCosmosDBSQLContainerE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) (*armcosmos.SQLContainerGetResults, error) {
 	// Create a CosmosDB client
 	cosmosClient, err := GetCosmosDBSQLClientE(subscriptionID)
 	if err != nil {
@@ -135,17 +110,17 @@ func GetCosmosDBSQLContainerE(t testing.TestingT, subscriptionID string, resourc
 	}
 
 	// Get the corresponding SQL container
-	cosmosSQLContainer, err := cosmosClient.GetSQLContainer(context.Background(), resourceGroupName, accountName, databaseName, containerName)
+	cosmosSQLContainer, err := cosmosClient.GetSQLContainer(context.Background(), resourceGroupName, accountName, databaseName, containerName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return container
-	return &cosmosSQLContainer, nil
+	return &cosmosSQLContainer.SQLContainerGetResults, nil
 }
 
 // GetCosmosDBSQLDatabaseThroughput is a helper function that gets a SQL database throughput configuration. This function would fail the test if there is an error.
-func GetCosmosDBSQLDatabaseThroughput(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) *documentdb.ThroughputSettingsGetResults {
+func GetCosmosDBSQLDatabaseThroughput(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) *armcosmos.ThroughputSettingsGetResults {
 	cosmosSQLDBThroughput, err := GetCosmosDBSQLDatabaseThroughputE(t, subscriptionID, resourceGroupName, accountName, databaseName)
 	require.NoError(t, err)
 
@@ -153,7 +128,7 @@ func GetCosmosDBSQLDatabaseThroughput(t testing.TestingT, subscriptionID string,
 }
 
 // GetCosmosDBSQLDatabaseThroughputE is a helper function that gets a SQL database throughput configuration.
-func GetCosmosDBSQLDatabaseThroughputE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) (*documentdb.ThroughputSettingsGetResults, error) {
+func GetCosmosDBSQLDatabaseThroughputE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) (*armcosmos.ThroughputSettingsGetResults, error) {
 	// Create a CosmosDB client
 	cosmosClient, err := GetCosmosDBSQLClientE(subscriptionID)
 	if err != nil {
@@ -161,17 +136,17 @@ func GetCosmosDBSQLDatabaseThroughputE(t testing.TestingT, subscriptionID string
 	}
 
 	// Get the corresponding database throughput config
-	cosmosSQLDBThroughput, err := cosmosClient.GetSQLDatabaseThroughput(context.Background(), resourceGroupName, accountName, databaseName)
+	cosmosSQLDBThroughput, err := cosmosClient.GetSQLDatabaseThroughput(context.Background(), resourceGroupName, accountName, databaseName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return throughput config
-	return &cosmosSQLDBThroughput, nil
+	return &cosmosSQLDBThroughput.ThroughputSettingsGetResults, nil
 }
 
 // GetCosmosDBSQLContainerThroughput is a helper function that gets a SQL container throughput configuration. This function would fail the test if there is an error.
-func GetCosmosDBSQLContainerThroughput(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) *documentdb.ThroughputSettingsGetResults {
+func GetCosmosDBSQLContainerThroughput(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) *armcosmos.ThroughputSettingsGetResults {
 	cosmosSQLCtrThroughput, err := GetCosmosDBSQLContainerThroughputE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
 	require.NoError(t, err)
 
@@ -179,7 +154,7 @@ func GetCosmosDBSQLContainerThroughput(t testing.TestingT, subscriptionID string
 }
 
 // GetCosmosDBSQLContainerThroughputE is a helper function that gets a SQL container throughput configuration.
-func GetCosmosDBSQLContainerThroughputE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) (*documentdb.ThroughputSettingsGetResults, error) {
+func GetCosmosDBSQLContainerThroughputE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) (*armcosmos.ThroughputSettingsGetResults, error) {
 	// Create a CosmosDB client
 	cosmosClient, err := GetCosmosDBSQLClientE(subscriptionID)
 	if err != nil {
@@ -187,11 +162,320 @@ func GetCosmosDBSQLContainerThroughputE(t testing.TestingT, subscriptionID strin
 	}
 
 	// Get the corresponding container throughput config
-	cosmosSQLCtrThroughput, err := cosmosClient.GetSQLContainerThroughput(context.Background(), resourceGroupName, accountName, databaseName, containerName)
+	cosmosSQLCtrThroughput, err := cosmosClient.GetSQLContainerThroughput(context.Background(), resourceGroupName, accountName, databaseName, containerName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return throughput config
-	return &cosmosSQLCtrThroughput, nil
+	return &cosmosSQLCtrThroughput.ThroughputSettingsGetResults, nil
+}
+
+// CosmosDBThroughput is the throughput configuration of a Cosmos DB database or container.
+type CosmosDBThroughput struct {
+	// Whether the throughput scales automatically between 10% of MaxThroughput and MaxThroughput.
+	Autoscale bool
+
+	// The provisioned throughput in RU/s. With autoscale, this is the throughput the resource is currently scaled to.
+	Throughput int32
+
+	// The maximum throughput in RU/s with autoscale, otherwise 0.
+	MaxThroughput int32
+}
+
+// GetCosmosDBSQLDatabaseThroughputSettings is a helper function that gets the RU/s and autoscale settings of a SQL
+// database. This function would fail the test if there is an error.
+func GetCosmosDBSQLDatabaseThroughputSettings(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) CosmosDBThroughput {
+	throughput, err := GetCosmosDBSQLDatabaseThroughputSettingsE(t, subscriptionID, resourceGroupName, accountName, databaseName)
+	require.NoError(t, err)
+
+	return throughput
+}
+
+// GetCosmosDBSQLDatabaseThroughputSettingsE is a helper function that gets the RU/s and autoscale settings of a SQL
+// database.
+func GetCosmosDBSQLDatabaseThroughputSettingsE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string) (CosmosDBThroughput, error) {
+	settings, err := GetCosmosDBSQLDatabaseThroughputE(t, subscriptionID, resourceGroupName, accountName, databaseName)
+	if err != nil {
+		return CosmosDBThroughput{}, err
+	}
+	return toCosmosDBThroughput(settings), nil
+}
+
+// GetCosmosDBSQLContainerThroughputSettings is a helper function that gets the RU/s and autoscale settings of a SQL
+// container. This function would fail the test if there is an error.
+func GetCosmosDBSQLContainerThroughputSettings(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) CosmosDBThroughput {
+	throughput, err := GetCosmosDBSQLContainerThroughputSettingsE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
+	require.NoError(t, err)
+
+	return throughput
+}
+
+// GetCosmosDBSQLContainerThroughputSettingsE is a helper function that gets the RU/s and autoscale settings of a SQL
+// container.
+func GetCosmosDBSQLContainerThroughputSettingsE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) (CosmosDBThroughput, error) {
+	settings, err := GetCosmosDBSQLContainerThroughputE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
+	if err != nil {
+		return CosmosDBThroughput{}, err
+	}
+	return toCosmosDBThroughput(settings), nil
+}
+
+// toCosmosDBThroughput converts the given throughput settings to a CosmosDBThroughput.
+func toCosmosDBThroughput(settings *armcosmos.ThroughputSettingsGetResults) CosmosDBThroughput {
+	throughput := CosmosDBThroughput{}
+	if settings == nil || settings.Properties == nil || settings.Properties.Resource == nil {
+		return throughput
+	}
+
+	resource := settings.Properties.Resource
+	throughput.Throughput = safePtrToInt32(resource.Throughput)
+	if resource.AutoscaleSettings != nil && resource.AutoscaleSettings.MaxThroughput != nil {
+		throughput.Autoscale = true
+		throughput.MaxThroughput = *resource.AutoscaleSettings.MaxThroughput
+	}
+	return throughput
+}
+
+// GetCosmosDBAccountConsistencyLevel is a helper function that gets the default consistency level of the database
+// account (e.g., Session or Strong). This function would fail the test if there is an error.
+func GetCosmosDBAccountConsistencyLevel(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) string {
+	consistencyLevel, err := GetCosmosDBAccountConsistencyLevelE(t, subscriptionID, resourceGroupName, accountName)
+	require.NoError(t, err)
+
+	return consistencyLevel
+}
+
+// GetCosmosDBAccountConsistencyLevelE is a helper function that gets the default consistency level of the database
+// account (e.g., Session or Strong).
+func GetCosmosDBAccountConsistencyLevelE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) (string, error) {
+	cosmosDBAccount, err := GetCosmosDBAccountE(t, subscriptionID, resourceGroupName, accountName)
+	if err != nil {
+		return "", err
+	}
+
+	if cosmosDBAccount.Properties == nil || cosmosDBAccount.Properties.ConsistencyPolicy == nil {
+		return "", nil
+	}
+	return safeEnumPtrToString(cosmosDBAccount.Properties.ConsistencyPolicy.DefaultConsistencyLevel), nil
+}
+
+// CosmosDBGeoReplication is the geo-replication configuration of a Cosmos DB database account.
+type CosmosDBGeoReplication struct {
+	// The regions the account is replicated to, ordered by failover priority: the first is the write region, unless
+	// MultipleWriteLocations is set.
+	Locations []string
+
+	// Whether every region accepts writes.
+	MultipleWriteLocations bool
+
+	// Whether the account fails over to the next region automatically when the write region is unavailable.
+	AutomaticFailover bool
+}
+
+// GetCosmosDBAccountGeoReplication is a helper function that gets the replicated regions and failover configuration of
+// the database account. This function would fail the test if there is an error.
+func GetCosmosDBAccountGeoReplication(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) CosmosDBGeoReplication {
+	geoReplication, err := GetCosmosDBAccountGeoReplicationE(t, subscriptionID, resourceGroupName, accountName)
+	require.NoError(t, err)
+
+	return geoReplication
+}
+
+// GetCosmosDBAccountGeoReplicationE is a helper function that gets the replicated regions and failover configuration
+// of the database account.
+func GetCosmosDBAccountGeoReplicationE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) (CosmosDBGeoReplication, error) {
+	cosmosDBAccount, err := GetCosmosDBAccountE(t, subscriptionID, resourceGroupName, accountName)
+	if err != nil {
+		return CosmosDBGeoReplication{}, err
+	}
+	return toCosmosDBGeoReplication(cosmosDBAccount.Properties), nil
+}
+
+// toCosmosDBGeoReplication converts the given database account properties to a CosmosDBGeoReplication.
+func toCosmosDBGeoReplication(properties *armcosmos.DatabaseAccountGetProperties) CosmosDBGeoReplication {
+	geoReplication := CosmosDBGeoReplication{Locations: []string{}}
+	if properties == nil {
+		return geoReplication
+	}
+
+	locations := []*armcosmos.Location{}
+	for _, location := range properties.Locations {
+		if location != nil {
+			locations = append(locations, location)
+		}
+	}
+	sort.SliceStable(locations, func(i, j int) bool {
+		return safePtrToInt32(locations[i].FailoverPriority) < safePtrToInt32(locations[j].FailoverPriority)
+	})
+	for _, location := range locations {
+		geoReplication.Locations = append(geoReplication.Locations, safePtrToString(location.LocationName))
+	}
+
+	geoReplication.MultipleWriteLocations = properties.EnableMultipleWriteLocations != nil && *properties.EnableMultipleWriteLocations
+	geoReplication.AutomaticFailover = properties.EnableAutomaticFailover != nil && *properties.EnableAutomaticFailover
+	return geoReplication
+}
+
+// GetCosmosDBDataClient is a helper function that will setup a CosmosDB data plane client for the SQL API of the
+// database account. This function would fail the test if there is an error.
+func GetCosmosDBDataClient(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) *azcosmos.Client {
+	client, err := GetCosmosDBDataClientE(t, subscriptionID, resourceGroupName, accountName)
+	require.NoError(t, err)
+
+	return client
+}
+
+// GetCosmosDBDataClientE is a helper function that will setup a CosmosDB data plane client for the SQL API of the
+// database account. The client authenticates with the primary key of the account, or with Azure AD if the account
+// has local auth disabled, which requires a Cosmos DB data plane role such as Cosmos DB Built-in Data Contributor.
+func GetCosmosDBDataClientE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string) (*azcosmos.Client, error) {
+	return GetCosmosDBDataClientWithOptionsE(t, subscriptionID, resourceGroupName, accountName, authOptionsFromEnv())
+}
+
+// GetCosmosDBDataClientWithOptionsE is a helper function that will setup a CosmosDB data plane client like
+// GetCosmosDBDataClientE, reading the account and authenticating according to the given AuthOptions.
+func GetCosmosDBDataClientWithOptionsE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, authOptions AuthOptions) (*azcosmos.Client, error) {
+	cosmosClient, err := NewArmClientWithOptionsE(subscriptionID, authOptions, armcosmos.NewDatabaseAccountsClient)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cosmosClient.Get(context.Background(), resourceGroupName, accountName, nil)
+	if err != nil {
+		return nil, err
+	}
+	cosmosDBAccount := resp.DatabaseAccountGetResults
+	if cosmosDBAccount.Properties == nil || cosmosDBAccount.Properties.DocumentEndpoint == nil {
+		return nil, NewNotFoundError("CosmosDB document endpoint", accountName, resourceGroupName)
+	}
+	endpoint := *cosmosDBAccount.Properties.DocumentEndpoint
+
+	if cosmosDBAccount.Properties.DisableLocalAuth != nil && *cosmosDBAccount.Properties.DisableLocalAuth {
+		cred, err := NewTokenCredentialWithOptionsE(authOptions)
+		if err != nil {
+			return nil, err
+		}
+		return azcosmos.NewClient(endpoint, cred, nil)
+	}
+
+	keys, err := cosmosClient.ListKeys(context.Background(), resourceGroupName, accountName, nil)
+	if err != nil {
+		return nil, err
+	}
+	cred, err := azcosmos.NewKeyCredential(safePtrToString(keys.PrimaryMasterKey))
+	if err != nil {
+		return nil, err
+	}
+	return azcosmos.NewClientWithKey(endpoint, cred, nil)
+}
+
+// CreateCosmosDBSQLItem is a helper function that creates an item, marshalled as JSON, in a SQL container. This
+// function would fail the test if there is an error.
+func CreateCosmosDBSQLItem(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string, partitionKey string, item interface{}) {
+	err := CreateCosmosDBSQLItemE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName, partitionKey, item)
+	require.NoError(t, err)
+}
+
+// CreateCosmosDBSQLItemE is a helper function that creates an item, marshalled as JSON, in a SQL container. The item
+// must have an id and its partition key value must match the given partition key.
+func CreateCosmosDBSQLItemE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string, partitionKey string, item interface{}) error {
+	container, err := getCosmosDBSQLContainerDataClientE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
+	if err != nil {
+		return err
+	}
+
+	itemJSON, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = container.CreateItem(context.Background(), azcosmos.NewPartitionKeyString(partitionKey), itemJSON, nil)
+	return err
+}
+
+// GetCosmosDBSQLItem is a helper function that reads an item from a SQL container. This function would fail the test
+// if there is an error.
+func GetCosmosDBSQLItem(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string, partitionKey string, itemID string) map[string]interface{} {
+	item, err := GetCosmosDBSQLItemE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName, partitionKey, itemID)
+	require.NoError(t, err)
+
+	return item
+}
+
+// GetCosmosDBSQLItemE is a helper function that reads an item from a SQL container.
+func GetCosmosDBSQLItemE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string, partitionKey string, itemID string) (map[string]interface{}, error) {
+	container, err := getCosmosDBSQLContainerDataClientE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := container.ReadItem(context.Background(), azcosmos.NewPartitionKeyString(partitionKey), itemID, nil)
+	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return nil, NewNotFoundError("CosmosDB item", itemID, containerName)
+		}
+		return nil, err
+	}
+
+	item := map[string]interface{}{}
+	if err := json.Unmarshal(resp.Value, &item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// QueryCosmosDBSQLItems is a helper function that runs a SQL query against a SQL container and returns the matching
+// items. This function would fail the test if there is an error.
+func QueryCosmosDBSQLItems(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string, partitionKey string, query string, parameters map[string]interface{}) []map[string]interface{} {
+	items, err := QueryCosmosDBSQLItemsE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName, partitionKey, query, parameters)
+	require.NoError(t, err)
+
+	return items
+}
+
+// QueryCosmosDBSQLItemsE is a helper function that runs a SQL query (e.g., "SELECT * FROM c WHERE c.status = @status")
+// against a SQL container and returns the matching items. Pass an empty partition key to query across partitions,
+// which only supports queries the gateway can serve without aggregation or ordering across partitions.
+func QueryCosmosDBSQLItemsE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string, partitionKey string, query string, parameters map[string]interface{}) ([]map[string]interface{}, error) {
+	container, err := getCosmosDBSQLContainerDataClientE(t, subscriptionID, resourceGroupName, accountName, databaseName, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	pk := azcosmos.NewPartitionKey()
+	if partitionKey != "" {
+		pk = azcosmos.NewPartitionKeyString(partitionKey)
+	}
+
+	options := &azcosmos.QueryOptions{}
+	for name, value := range parameters {
+		options.QueryParameters = append(options.QueryParameters, azcosmos.QueryParameter{Name: name, Value: value})
+	}
+
+	items := []map[string]interface{}{}
+	pager := container.NewQueryItemsPager(query, pk, options)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, itemJSON := range page.Items {
+			item := map[string]interface{}{}
+			if err := json.Unmarshal(itemJSON, &item); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// getCosmosDBSQLContainerDataClientE is a helper function that will setup a CosmosDB data plane client for a SQL
+// container.
+func getCosmosDBSQLContainerDataClientE(t testing.TestingT, subscriptionID string, resourceGroupName string, accountName string, databaseName string, containerName string) (*azcosmos.ContainerClient, error) {
+	client, err := GetCosmosDBDataClientE(t, subscriptionID, resourceGroupName, accountName)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewContainer(databaseName, containerName)
 }
@@ -0,0 +1,62 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/cosmos/armcosmos/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToCosmosDBThroughput(t *testing.T) {
+	t.Parallel()
+
+	manual := &armcosmos.ThroughputSettingsGetResults{Properties: &armcosmos.ThroughputSettingsGetProperties{
+		Resource: &armcosmos.ThroughputSettingsGetPropertiesResource{Throughput: to.Ptr[int32](400)},
+	}}
+	assert.Equal(t, CosmosDBThroughput{Throughput: 400}, toCosmosDBThroughput(manual))
+
+	autoscale := &armcosmos.ThroughputSettingsGetResults{Properties: &armcosmos.ThroughputSettingsGetProperties{
+		Resource: &armcosmos.ThroughputSettingsGetPropertiesResource{
+			Throughput:        to.Ptr[int32](400),
+			AutoscaleSettings: &armcosmos.AutoscaleSettingsResource{MaxThroughput: to.Ptr[int32](4000)},
+		},
+	}}
+	assert.Equal(t, CosmosDBThroughput{Autoscale: true, Throughput: 400, MaxThroughput: 4000}, toCosmosDBThroughput(autoscale))
+
+	assert.Equal(t, CosmosDBThroughput{}, toCosmosDBThroughput(nil))
+}
+
+func TestToCosmosDBGeoReplication(t *testing.T) {
+	t.Parallel()
+
+	properties := &armcosmos.DatabaseAccountGetProperties{
+		Locations: []*armcosmos.Location{
+			{LocationName: to.Ptr("West US"), FailoverPriority: to.Ptr[int32](1)},
+			nil,
+			{LocationName: to.Ptr("East US"), FailoverPriority: to.Ptr[int32](0)},
+		},
+		EnableAutomaticFailover: to.Ptr(true),
+	}
+
+	geoReplication := toCosmosDBGeoReplication(properties)
+	assert.Equal(t, []string{"East US", "West US"}, geoReplication.Locations)
+	assert.True(t, geoReplication.AutomaticFailover)
+	assert.False(t, geoReplication.MultipleWriteLocations)
+
+	assert.Equal(t, CosmosDBGeoReplication{Locations: []string{}}, toCosmosDBGeoReplication(nil))
+}
+
+func TestGetCosmosDBSQLItem(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetCosmosDBSQLItemE(t, "", "", "", "", "", "", "")
+	require.Error(t, err)
+}
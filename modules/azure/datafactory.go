@@ -3,7 +3,7 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/datafactory/mgmt/2018-06-01/datafactory"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/datafactory/armdatafactory/v8"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -30,7 +30,7 @@ func DataFactoryExistsE(dataFactoryName string, resourceGroupName string, subscr
 
 // GetDataFactory is a helper function that gets the synapse workspace.
 // This function would fail the test if there is an error.
-func GetDataFactory(t testing.TestingT, resGroupName string, factoryName string, subscriptionID string) *datafactory.Factory {
+func GetDataFactory(t testing.TestingT, resGroupName string, factoryName string, subscriptionID string) *armdatafactory.Factory {
 	Workspace, err := GetDataFactoryE(subscriptionID, resGroupName, factoryName)
 	require.NoError(t, err)
 
@@ -38,7 +38,7 @@ func GetDataFactory(t testing.TestingT, resGroupName string, factoryName string,
 }
 
 // GetDataFactoryE is a helper function that gets the workspace.
-func GetDataFactoryE(subscriptionID string, resGroupName string, factoryName string) (*datafactory.Factory, error) {
+func GetDataFactoryE(subscriptionID string, resGroupName string, factoryName string) (*armdatafactory.Factory, error) {
 	// Create a datafactory client
 	datafactoryClient, err := CreateDataFactoriesClientE(subscriptionID)
 	if err != nil {
@@ -46,11 +46,11 @@ func GetDataFactoryE(subscriptionID string, resGroupName string, factoryName str
 	}
 
 	// Get the corresponding synapse workspace
-	dataFactory, err := datafactoryClient.Get(context.Background(), resGroupName, factoryName, "")
+	dataFactory, err := datafactoryClient.Get(context.Background(), resGroupName, factoryName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return synapse workspace
-	return &dataFactory, nil
+	return &dataFactory.Factory, nil
 }
@@ -3,7 +3,7 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -31,14 +31,14 @@ func DiskExistsE(diskName string, resGroupName string, subscriptionID string) (b
 
 // GetDisk returns a Disk in the specified Azure Resource Group
 // This function would fail the test if there is an error.
-func GetDisk(t testing.TestingT, diskName string, resGroupName string, subscriptionID string) *compute.Disk {
+func GetDisk(t testing.TestingT, diskName string, resGroupName string, subscriptionID string) *armcompute.Disk {
 	disk, err := GetDiskE(diskName, resGroupName, subscriptionID)
 	require.NoError(t, err)
 	return disk
 }
 
 // GetDiskE returns a Disk in the specified Azure Resource Group
-func GetDiskE(diskName string, resGroupName string, subscriptionID string) (*compute.Disk, error) {
+func GetDiskE(diskName string, resGroupName string, subscriptionID string) (*armcompute.Disk, error) {
 	// Validate resource group name and subscription ID
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -52,32 +52,17 @@ func GetDiskE(diskName string, resGroupName string, subscriptionID string) (*com
 	}
 
 	// Get the Disk
-	disk, err := client.Get(context.Background(), resGroupName, diskName)
+	disk, err := client.Get(context.Background(), resGroupName, diskName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &disk, nil
+	return &disk.Disk, nil
 }
 
 // GetDiskClientE returns a new Disk client in the specified Azure Subscription
 // TODO: remove in next major/minor version
-func GetDiskClientE(subscriptionID string) (*compute.DisksClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetDiskClientE(subscriptionID string) (*armcompute.DisksClient, error) {
 	// Get the Disk client
-	client := compute.NewDisksClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return &client, nil
+	return CreateDisksClientE(subscriptionID)
 }
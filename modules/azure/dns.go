@@ -0,0 +1,194 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dnsNameRegexp matches the host names that can be safely passed to a resolution script.
+var dnsNameRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// DNSRecordSet summarizes a record set of a public or private DNS zone. Values holds one entry per record in the
+// notation of zone files, e.g., "10.0.0.4" for A records, "10 mail.example.com" for MX records, or
+// "1 5 443 sip.example.com" for SRV records.
+type DNSRecordSet struct {
+	Name   string // The relative name of the record set, e.g., www or @ for the zone apex
+	Type   string // The record type, e.g., A, CNAME or TXT
+	TTL    int64
+	FQDN   string
+	Values []string
+}
+
+// ListDNSRecordSets lists the record sets of the specified public DNS zone.
+// This function would fail the test if there is an error.
+func ListDNSRecordSets(t testing.TestingT, zoneName string, resGroupName string, subscriptionID string) []DNSRecordSet {
+	recordSets, err := ListDNSRecordSetsE(zoneName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return recordSets
+}
+
+// ListDNSRecordSetsE lists the record sets of the specified public DNS zone.
+func ListDNSRecordSetsE(zoneName string, resGroupName string, subscriptionID string) ([]DNSRecordSet, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreateDnsRecordSetsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSets := []DNSRecordSet{}
+	pager := client.NewListAllByDNSZonePager(rgName, zoneName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, recordSet := range page.Value {
+			recordSets = append(recordSets, newDNSRecordSet(recordSet))
+		}
+	}
+
+	return recordSets, nil
+}
+
+// GetDNSRecordSet gets the record set with the given type and relative name in the specified public DNS zone.
+// This function would fail the test if there is an error.
+func GetDNSRecordSet(t testing.TestingT, zoneName string, recordType string, name string, resGroupName string, subscriptionID string) DNSRecordSet {
+	recordSet, err := GetDNSRecordSetE(zoneName, recordType, name, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return recordSet
+}
+
+// GetDNSRecordSetE gets the record set with the given type and relative name in the specified public DNS zone.
+func GetDNSRecordSetE(zoneName string, recordType string, name string, resGroupName string, subscriptionID string) (DNSRecordSet, error) {
+	recordSets, err := ListDNSRecordSetsE(zoneName, resGroupName, subscriptionID)
+	if err != nil {
+		return DNSRecordSet{}, err
+	}
+
+	return findDNSRecordSetE(recordSets, zoneName, recordType, name)
+}
+
+// AssertDNSRecordSetValues checks that the record set with the given type and relative name in the specified public
+// DNS zone has exactly the expected values, in any order.
+func AssertDNSRecordSetValues(t testing.TestingT, zoneName string, recordType string, name string, expectedValues []string, resGroupName string, subscriptionID string) {
+	recordSet, err := GetDNSRecordSetE(zoneName, recordType, name, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.ElementsMatchf(t, expectedValues, recordSet.Values, "Unexpected values of %s record %s in DNS zone %s", recordType, name, zoneName)
+	}
+}
+
+// findDNSRecordSetE returns the record set with the given type and relative name among the given record sets.
+func findDNSRecordSetE(recordSets []DNSRecordSet, zoneName string, recordType string, name string) (DNSRecordSet, error) {
+	for _, recordSet := range recordSets {
+		if strings.EqualFold(recordSet.Type, recordType) && strings.EqualFold(recordSet.Name, name) {
+			return recordSet, nil
+		}
+	}
+
+	return DNSRecordSet{}, NewNotFoundError(recordType+" record set", name, zoneName)
+}
+
+// newDNSRecordSet summarizes the given public DNS record set.
+func newDNSRecordSet(recordSet *armdns.RecordSet) DNSRecordSet {
+	summary := DNSRecordSet{
+		Name:   safePtrToString(recordSet.Name),
+		Type:   dnsRecordTypeFromResourceType(safePtrToString(recordSet.Type)),
+		Values: []string{},
+	}
+	props := recordSet.Properties
+	if props == nil {
+		return summary
+	}
+
+	if props.TTL != nil {
+		summary.TTL = *props.TTL
+	}
+	summary.FQDN = safePtrToString(props.Fqdn)
+	for _, record := range props.ARecords {
+		summary.Values = append(summary.Values, safePtrToString(record.IPv4Address))
+	}
+	for _, record := range props.AaaaRecords {
+		summary.Values = append(summary.Values, safePtrToString(record.IPv6Address))
+	}
+	if props.CnameRecord != nil {
+		summary.Values = append(summary.Values, safePtrToString(props.CnameRecord.Cname))
+	}
+	for _, record := range props.MxRecords {
+		summary.Values = append(summary.Values, fmt.Sprintf("%d %s", safePtrToInt32(record.Preference), safePtrToString(record.Exchange)))
+	}
+	for _, record := range props.NsRecords {
+		summary.Values = append(summary.Values, safePtrToString(record.Nsdname))
+	}
+	for _, record := range props.PtrRecords {
+		summary.Values = append(summary.Values, safePtrToString(record.Ptrdname))
+	}
+	for _, record := range props.SrvRecords {
+		summary.Values = append(summary.Values, fmt.Sprintf("%d %d %d %s", safePtrToInt32(record.Priority), safePtrToInt32(record.Weight), safePtrToInt32(record.Port), safePtrToString(record.Target)))
+	}
+	for _, record := range props.TxtRecords {
+		summary.Values = append(summary.Values, strings.Join(safePtrToList(record.Value), ""))
+	}
+	for _, record := range props.CaaRecords {
+		summary.Values = append(summary.Values, fmt.Sprintf("%d %s %s", safePtrToInt32(record.Flags), safePtrToString(record.Tag), safePtrToString(record.Value)))
+	}
+
+	return summary
+}
+
+// dnsRecordTypeFromResourceType returns the record type of the given record set resource type, e.g., A for
+// Microsoft.Network/dnszones/A.
+func dnsRecordTypeFromResourceType(resourceType string) string {
+	return resourceType[strings.LastIndex(resourceType, "/")+1:]
+}
+
+// WaitForDNSNameToResolveFromVirtualMachine waits for the given name to resolve on the specified Linux Virtual
+// Machine, and returns the addresses it resolves to. See WaitForDNSNameToResolveFromVirtualMachineE.
+func WaitForDNSNameToResolveFromVirtualMachine(t testing.TestingT, name string, vmName string, resGroupName string, subscriptionID string, maxRetries int, sleepBetweenRetries time.Duration) []string {
+	addresses, err := WaitForDNSNameToResolveFromVirtualMachineE(t, name, vmName, resGroupName, subscriptionID, maxRetries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return addresses
+}
+
+// WaitForDNSNameToResolveFromVirtualMachineE waits for the given name to resolve on the specified Linux Virtual
+// Machine, and returns the addresses it resolves to. The name is resolved with the Run Command API, so it uses the DNS
+// settings of the virtual network of the VM, including the private DNS zones linked to it, without network access to
+// the VM.
+func WaitForDNSNameToResolveFromVirtualMachineE(t testing.TestingT, name string, vmName string, resGroupName string, subscriptionID string, maxRetries int, sleepBetweenRetries time.Duration) ([]string, error) {
+	if !dnsNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid DNS name %q", name)
+	}
+	script := []string{fmt.Sprintf("getent ahosts %s | awk '{print $1}' | sort -u", name)}
+
+	description := fmt.Sprintf("Waiting for %s to resolve on VM %s", name, vmName)
+	output, err := retry.DoWithRetryE(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		result, err := RunCommandOnVirtualMachineE(vmName, resGroupName, subscriptionID, "RunShellScript", script)
+		if err != nil {
+			return "", err
+		}
+		if result.StdOut == "" {
+			return "", fmt.Errorf("%s does not resolve on VM %s yet", name, vmName)
+		}
+		return result.StdOut, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(output), nil
+}
@@ -0,0 +1,72 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDNSRecordSet(t *testing.T) {
+	t.Parallel()
+
+	recordSet := newDNSRecordSet(&armdns.RecordSet{
+		Name: to.Ptr("@"),
+		Type: to.Ptr("Microsoft.Network/dnszones/MX"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:  to.Ptr[int64](3600),
+			Fqdn: to.Ptr("example.com."),
+			MxRecords: []*armdns.MxRecord{
+				{Preference: to.Ptr[int32](10), Exchange: to.Ptr("mail1.example.com")},
+				{Preference: to.Ptr[int32](20), Exchange: to.Ptr("mail2.example.com")},
+			},
+		},
+	})
+	assert.Equal(t, DNSRecordSet{
+		Name:   "@",
+		Type:   "MX",
+		TTL:    3600,
+		FQDN:   "example.com.",
+		Values: []string{"10 mail1.example.com", "20 mail2.example.com"},
+	}, recordSet)
+
+	txt := newDNSRecordSet(&armdns.RecordSet{
+		Name: to.Ptr("_verify"),
+		Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+		Properties: &armdns.RecordSetProperties{
+			TxtRecords: []*armdns.TxtRecord{{Value: to.SliceOfPtrs("part1", "part2")}},
+		},
+	})
+	assert.Equal(t, []string{"part1part2"}, txt.Values)
+}
+
+func TestFindDNSRecordSet(t *testing.T) {
+	t.Parallel()
+
+	recordSets := []DNSRecordSet{
+		{Name: "www", Type: "A", Values: []string{"10.0.0.4"}},
+		{Name: "www", Type: "AAAA", Values: []string{"::1"}},
+	}
+
+	recordSet, err := findDNSRecordSetE(recordSets, "example.com", "aaaa", "WWW")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"::1"}, recordSet.Values)
+
+	_, err = findDNSRecordSetE(recordSets, "example.com", "CNAME", "www")
+	require.Error(t, err)
+}
+
+func TestWaitForDNSNameToResolveFromVirtualMachineRejectsInvalidNames(t *testing.T) {
+	t.Parallel()
+
+	_, err := WaitForDNSNameToResolveFromVirtualMachineE(t, "example.com; rm -rf /", "vm", "rg", "sub", 1, 0)
+	require.Error(t, err)
+}
@@ -1,10 +1,11 @@
 package azure
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
-	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 )
 
 // SubscriptionIDNotFound is an error that occurs when the Azure Subscription ID could not be found or was not provided
@@ -58,14 +59,12 @@ func NewNotFoundError(objectType string, objectID string, region string) NotFoun
 	return NotFoundError{objectType, objectID, region}
 }
 
-// ResourceNotFoundErrorExists checks the Service Error Code for the 'Resource Not Found' error
+// ResourceNotFoundErrorExists checks whether the error is an Azure 'Resource Not Found' response, i.e., a 404 or the
+// ResourceNotFound service error code
 func ResourceNotFoundErrorExists(err error) bool {
-	if err != nil {
-		if autorestError, ok := err.(autorest.DetailedError); ok {
-			if requestError, ok := autorestError.Original.(*azure.RequestError); ok {
-				return (requestError.ServiceError.Code == "ResourceNotFound")
-			}
-		}
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode == http.StatusNotFound || responseErr.ErrorCode == "ResourceNotFound"
 	}
 	return false
 }
@@ -0,0 +1,438 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/eventhub/armeventhub"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventHubReceiveTimeout is how long reading a partition waits for events it knows are there before giving up.
+const eventHubReceiveTimeout = 30 * time.Second
+
+// EventHubEvent is an event read from an Event Hub partition.
+type EventHubEvent struct {
+	PartitionID    string
+	SequenceNumber int64
+	EnqueuedTime   time.Time
+	Body           string
+	Properties     map[string]interface{}
+}
+
+// EventHubCaptureConfig summarizes the capture configuration of an Event Hub.
+type EventHubCaptureConfig struct {
+	Enabled                  bool
+	Encoding                 string // Avro or AvroDeflate
+	IntervalInSeconds        int32
+	SizeLimitInBytes         int32
+	StorageAccountResourceID string
+	BlobContainer            string
+	ArchiveNameFormat        string
+}
+
+// EventHubNamespaceNetworkRules summarizes the network rule set of an Event Hubs namespace.
+type EventHubNamespaceNetworkRules struct {
+	DefaultAction               string // Allow or Deny
+	PublicNetworkAccess         string // Enabled, Disabled or SecuredByPerimeter
+	TrustedServiceAccessEnabled bool
+	IPRules                     []string // The allowed IP addresses or CIDR ranges
+	SubnetIDs                   []string // The IDs of the allowed subnets
+}
+
+// SendEventHubEvents sends the given events to the specified Event Hub.
+// This function would fail the test if there is an error.
+func SendEventHubEvents(t testing.TestingT, namespace string, eventHubName string, bodies []string) {
+	require.NoError(t, SendEventHubEventsE(namespace, eventHubName, bodies))
+}
+
+// SendEventHubEventsE sends the given events to the specified Event Hub, in as few batches as possible, authenticated
+// with NewTokenCredentialE. The namespace is either the name of the Event Hubs namespace or its host name.
+func SendEventHubEventsE(namespace string, eventHubName string, bodies []string) error {
+	return SendEventHubEventsWithOptionsE(namespace, eventHubName, bodies, authOptionsFromEnv())
+}
+
+// SendEventHubEventsWithOptionsE sends the given events to the specified Event Hub like SendEventHubEventsE,
+// authenticated according to the given AuthOptions.
+func SendEventHubEventsWithOptionsE(namespace string, eventHubName string, bodies []string, authOptions AuthOptions) error {
+	host, err := getServiceBusNamespaceHostE(namespace)
+	if err != nil {
+		return err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return err
+	}
+
+	producer, err := azeventhubs.NewProducerClient(host, eventHubName, cred, nil)
+	if err != nil {
+		return err
+	}
+	defer producer.Close(context.Background())
+
+	ctx := context.Background()
+	batch, err := producer.NewEventDataBatch(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(bodies); {
+		err := batch.AddEventData(&azeventhubs.EventData{Body: []byte(bodies[i])}, nil)
+		switch {
+		case err == nil:
+			i++
+		case errors.Is(err, azeventhubs.ErrEventDataTooLarge) && batch.NumEvents() > 0:
+			if err := producer.SendEventDataBatch(ctx, batch, nil); err != nil {
+				return err
+			}
+			if batch, err = producer.NewEventDataBatch(ctx, nil); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+	if batch.NumEvents() == 0 {
+		return nil
+	}
+
+	return producer.SendEventDataBatch(ctx, batch, nil)
+}
+
+// ReadEventHubEvents reads the events of all the partitions of the specified Event Hub. See ReadEventHubEventsE.
+// This function would fail the test if there is an error.
+func ReadEventHubEvents(t testing.TestingT, namespace string, eventHubName string, consumerGroup string, since time.Time) []EventHubEvent {
+	events, err := ReadEventHubEventsE(namespace, eventHubName, consumerGroup, since)
+	require.NoError(t, err)
+
+	return events
+}
+
+// ReadEventHubEventsE reads the events of all the partitions of the specified Event Hub from the given consumer group
+// ($Default if empty), up to the last event enqueued when it starts. Only the events enqueued since the given time are
+// read, or all the retained events if it is zero. No checkpoints are stored, so reading doesn't affect other readers
+// of the consumer group, but it competes with any reader using an owner level (epoch), such as Azure Functions.
+func ReadEventHubEventsE(namespace string, eventHubName string, consumerGroup string, since time.Time) ([]EventHubEvent, error) {
+	return ReadEventHubEventsWithOptionsE(namespace, eventHubName, consumerGroup, since, authOptionsFromEnv())
+}
+
+// ReadEventHubEventsWithOptionsE reads the events of all the partitions of the specified Event Hub like
+// ReadEventHubEventsE, authenticated according to the given AuthOptions.
+func ReadEventHubEventsWithOptionsE(namespace string, eventHubName string, consumerGroup string, since time.Time, authOptions AuthOptions) ([]EventHubEvent, error) {
+	host, err := getServiceBusNamespaceHostE(namespace)
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	if consumerGroup == "" {
+		consumerGroup = azeventhubs.DefaultConsumerGroup
+	}
+
+	consumer, err := azeventhubs.NewConsumerClient(host, eventHubName, consumerGroup, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer consumer.Close(context.Background())
+
+	properties, err := consumer.GetEventHubProperties(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []EventHubEvent{}
+	for _, partitionID := range properties.PartitionIDs {
+		partitionEvents, err := readEventHubPartitionE(consumer, partitionID, since)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, partitionEvents...)
+	}
+
+	return events, nil
+}
+
+// readEventHubPartitionE reads the events of the given partition enqueued since the given time, up to the last event
+// enqueued when it starts.
+func readEventHubPartitionE(consumer *azeventhubs.ConsumerClient, partitionID string, since time.Time) ([]EventHubEvent, error) {
+	properties, err := consumer.GetPartitionProperties(context.Background(), partitionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if properties.IsEmpty || properties.LastEnqueuedOn.Before(since) {
+		return nil, nil
+	}
+
+	startPosition := azeventhubs.StartPosition{Earliest: to.Ptr(true)}
+	if !since.IsZero() {
+		startPosition = azeventhubs.StartPosition{EnqueuedTime: &since, Inclusive: true}
+	}
+	partitionClient, err := consumer.NewPartitionClient(partitionID, &azeventhubs.PartitionClientOptions{StartPosition: startPosition})
+	if err != nil {
+		return nil, err
+	}
+	defer partitionClient.Close(context.Background())
+
+	events := []EventHubEvent{}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), eventHubReceiveTimeout)
+		received, err := partitionClient.ReceiveEvents(ctx, 100, nil)
+		cancel()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		if len(received) == 0 {
+			return nil, fmt.Errorf("timed out reading partition %s up to sequence number %d", partitionID, properties.LastEnqueuedSequenceNumber)
+		}
+
+		for _, event := range received {
+			events = append(events, newEventHubEvent(partitionID, event))
+			if event.SequenceNumber >= properties.LastEnqueuedSequenceNumber {
+				return events, nil
+			}
+		}
+	}
+}
+
+// WaitForEventHubEvent waits for an event matching the given function to show up in the specified Event Hub, and
+// returns it. See WaitForEventHubEventE.
+func WaitForEventHubEvent(t testing.TestingT, namespace string, eventHubName string, consumerGroup string, since time.Time, matches func(EventHubEvent) bool, maxRetries int, sleepBetweenRetries time.Duration) EventHubEvent {
+	event, err := WaitForEventHubEventE(t, namespace, eventHubName, consumerGroup, since, matches, maxRetries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return event
+}
+
+// WaitForEventHubEventE waits for an event enqueued since the given time and matching the given function to show up
+// in the specified Event Hub, e.g., one sent by the system under test, and returns it. See ReadEventHubEventsE.
+func WaitForEventHubEventE(t testing.TestingT, namespace string, eventHubName string, consumerGroup string, since time.Time, matches func(EventHubEvent) bool, maxRetries int, sleepBetweenRetries time.Duration) (EventHubEvent, error) {
+	description := fmt.Sprintf("Waiting for a matching event in Event Hub %s", eventHubName)
+	event, err := retry.DoWithRetryInterfaceE(t, description, maxRetries, sleepBetweenRetries, func() (interface{}, error) {
+		events, err := ReadEventHubEventsE(namespace, eventHubName, consumerGroup, since)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if matches(event) {
+				return event, nil
+			}
+		}
+		return nil, fmt.Errorf("none of the %d events in Event Hub %s matches", len(events), eventHubName)
+	})
+	if err != nil {
+		return EventHubEvent{}, err
+	}
+
+	return event.(EventHubEvent), nil
+}
+
+// newEventHubEvent converts the given event received from the given partition.
+func newEventHubEvent(partitionID string, event *azeventhubs.ReceivedEventData) EventHubEvent {
+	converted := EventHubEvent{
+		PartitionID:    partitionID,
+		SequenceNumber: event.SequenceNumber,
+		Body:           string(event.Body),
+		Properties:     map[string]interface{}{},
+	}
+	if event.EnqueuedTime != nil {
+		converted.EnqueuedTime = *event.EnqueuedTime
+	}
+	for key, value := range event.Properties {
+		converted.Properties[key] = value
+	}
+
+	return converted
+}
+
+// GetEventHub gets the specified Event Hub. This function would fail the test if there is an error.
+func GetEventHub(t testing.TestingT, eventHubName string, namespace string, resGroupName string, subscriptionID string) *armeventhub.Eventhub {
+	eventHub, err := GetEventHubE(eventHubName, namespace, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return eventHub
+}
+
+// GetEventHubE gets the specified Event Hub.
+func GetEventHubE(eventHubName string, namespace string, resGroupName string, subscriptionID string) (*armeventhub.Eventhub, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreateEventHubsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(context.Background(), resGroupName, namespace, eventHubName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Eventhub, nil
+}
+
+// AssertEventHubPartitionCount checks that the specified Event Hub has the expected number of partitions.
+func AssertEventHubPartitionCount(t testing.TestingT, eventHubName string, namespace string, resGroupName string, subscriptionID string, expectedCount int64) {
+	eventHub, err := GetEventHubE(eventHubName, namespace, resGroupName, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var partitionCount int64
+	if eventHub.Properties != nil && eventHub.Properties.PartitionCount != nil {
+		partitionCount = *eventHub.Properties.PartitionCount
+	}
+	assert.Equalf(t, expectedCount, partitionCount, "Unexpected partition count of Event Hub %s", eventHubName)
+}
+
+// GetEventHubCaptureConfig gets the capture configuration of the specified Event Hub.
+// This function would fail the test if there is an error.
+func GetEventHubCaptureConfig(t testing.TestingT, eventHubName string, namespace string, resGroupName string, subscriptionID string) EventHubCaptureConfig {
+	config, err := GetEventHubCaptureConfigE(eventHubName, namespace, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return config
+}
+
+// GetEventHubCaptureConfigE gets the capture configuration of the specified Event Hub.
+func GetEventHubCaptureConfigE(eventHubName string, namespace string, resGroupName string, subscriptionID string) (EventHubCaptureConfig, error) {
+	eventHub, err := GetEventHubE(eventHubName, namespace, resGroupName, subscriptionID)
+	if err != nil {
+		return EventHubCaptureConfig{}, err
+	}
+	if eventHub.Properties == nil {
+		return EventHubCaptureConfig{}, nil
+	}
+
+	return newEventHubCaptureConfig(eventHub.Properties.CaptureDescription), nil
+}
+
+// AssertEventHubCaptureEnabled checks that the specified Event Hub captures its events to the given blob container
+// of the storage account with the given resource ID.
+func AssertEventHubCaptureEnabled(t testing.TestingT, eventHubName string, namespace string, resGroupName string, subscriptionID string, storageAccountResourceID string, blobContainer string) {
+	config, err := GetEventHubCaptureConfigE(eventHubName, namespace, resGroupName, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Truef(t, config.Enabled, "Capture is not enabled on Event Hub %s", eventHubName) {
+		assert.Truef(t, strings.EqualFold(storageAccountResourceID, config.StorageAccountResourceID), "Event Hub %s captures to storage account %s, expected %s", eventHubName, config.StorageAccountResourceID, storageAccountResourceID)
+		assert.Equalf(t, blobContainer, config.BlobContainer, "Unexpected capture container of Event Hub %s", eventHubName)
+	}
+}
+
+// newEventHubCaptureConfig summarizes the given capture description.
+func newEventHubCaptureConfig(capture *armeventhub.CaptureDescription) EventHubCaptureConfig {
+	config := EventHubCaptureConfig{}
+	if capture == nil {
+		return config
+	}
+
+	config.Enabled = safePtrToBool(capture.Enabled)
+	config.Encoding = safeEnumPtrToString(capture.Encoding)
+	config.IntervalInSeconds = safePtrToInt32(capture.IntervalInSeconds)
+	config.SizeLimitInBytes = safePtrToInt32(capture.SizeLimitInBytes)
+	if capture.Destination != nil && capture.Destination.Properties != nil {
+		config.StorageAccountResourceID = safePtrToString(capture.Destination.Properties.StorageAccountResourceID)
+		config.BlobContainer = safePtrToString(capture.Destination.Properties.BlobContainer)
+		config.ArchiveNameFormat = safePtrToString(capture.Destination.Properties.ArchiveNameFormat)
+	}
+
+	return config
+}
+
+// GetEventHubNamespaceNetworkRules gets the network rule set of the specified Event Hubs namespace.
+// This function would fail the test if there is an error.
+func GetEventHubNamespaceNetworkRules(t testing.TestingT, namespace string, resGroupName string, subscriptionID string) EventHubNamespaceNetworkRules {
+	rules, err := GetEventHubNamespaceNetworkRulesE(namespace, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return rules
+}
+
+// GetEventHubNamespaceNetworkRulesE gets the network rule set of the specified Event Hubs namespace.
+func GetEventHubNamespaceNetworkRulesE(namespace string, resGroupName string, subscriptionID string) (EventHubNamespaceNetworkRules, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return EventHubNamespaceNetworkRules{}, err
+	}
+
+	client, err := CreateEventHubNamespacesClientE(subscriptionID)
+	if err != nil {
+		return EventHubNamespaceNetworkRules{}, err
+	}
+
+	resp, err := client.GetNetworkRuleSet(context.Background(), resGroupName, namespace, nil)
+	if err != nil {
+		return EventHubNamespaceNetworkRules{}, err
+	}
+
+	return newEventHubNamespaceNetworkRules(resp.NetworkRuleSet), nil
+}
+
+// AssertEventHubNamespaceDefaultNetworkAction checks that the specified Event Hubs namespace has the expected default
+// network action, Allow or Deny, for the traffic no rule matches.
+func AssertEventHubNamespaceDefaultNetworkAction(t testing.TestingT, namespace string, resGroupName string, subscriptionID string, expectedAction string) {
+	rules, err := GetEventHubNamespaceNetworkRulesE(namespace, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, strings.EqualFold(expectedAction, rules.DefaultAction), "Event Hubs namespace %s has default network action %s, expected %s", namespace, rules.DefaultAction, expectedAction)
+	}
+}
+
+// AssertEventHubNamespaceAllowsIPRule checks that the specified Event Hubs namespace has a rule allowing the given IP
+// address or CIDR range.
+func AssertEventHubNamespaceAllowsIPRule(t testing.TestingT, namespace string, resGroupName string, subscriptionID string, ipMask string) {
+	rules, err := GetEventHubNamespaceNetworkRulesE(namespace, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Containsf(t, rules.IPRules, ipMask, "Event Hubs namespace %s has no rule allowing %s", namespace, ipMask)
+	}
+}
+
+// AssertEventHubNamespaceAllowsSubnet checks that the specified Event Hubs namespace has a rule allowing the subnet
+// with the given ID.
+func AssertEventHubNamespaceAllowsSubnet(t testing.TestingT, namespace string, resGroupName string, subscriptionID string, subnetID string) {
+	rules, err := GetEventHubNamespaceNetworkRulesE(namespace, resGroupName, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, id := range rules.SubnetIDs {
+		if strings.EqualFold(id, subnetID) {
+			return
+		}
+	}
+	assert.Failf(t, "Subnet not allowed", "Event Hubs namespace %s has no rule allowing subnet %s", namespace, subnetID)
+}
+
+// newEventHubNamespaceNetworkRules summarizes the given network rule set.
+func newEventHubNamespaceNetworkRules(ruleSet armeventhub.NetworkRuleSet) EventHubNamespaceNetworkRules {
+	rules := EventHubNamespaceNetworkRules{IPRules: []string{}, SubnetIDs: []string{}}
+	props := ruleSet.Properties
+	if props == nil {
+		return rules
+	}
+
+	rules.DefaultAction = safeEnumPtrToString(props.DefaultAction)
+	rules.PublicNetworkAccess = safeEnumPtrToString(props.PublicNetworkAccess)
+	rules.TrustedServiceAccessEnabled = safePtrToBool(props.TrustedServiceAccessEnabled)
+	for _, rule := range props.IPRules {
+		rules.IPRules = append(rules.IPRules, safePtrToString(rule.IPMask))
+	}
+	for _, rule := range props.VirtualNetworkRules {
+		if rule.Subnet != nil {
+			rules.SubnetIDs = append(rules.SubnetIDs, safePtrToString(rule.Subnet.ID))
+		}
+	}
+
+	return rules
+}
@@ -0,0 +1,104 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/eventhub/armeventhub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEventHubEvent(t *testing.T) {
+	t.Parallel()
+
+	enqueuedTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := newEventHubEvent("1", &azeventhubs.ReceivedEventData{
+		EventData: azeventhubs.EventData{
+			Body:       []byte(`{"id":42}`),
+			Properties: map[string]any{"source": "test"},
+		},
+		EnqueuedTime:   &enqueuedTime,
+		SequenceNumber: 7,
+	})
+
+	assert.Equal(t, EventHubEvent{
+		PartitionID:    "1",
+		SequenceNumber: 7,
+		EnqueuedTime:   enqueuedTime,
+		Body:           `{"id":42}`,
+		Properties:     map[string]interface{}{"source": "test"},
+	}, event)
+}
+
+func TestNewEventHubCaptureConfig(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, EventHubCaptureConfig{}, newEventHubCaptureConfig(nil))
+
+	config := newEventHubCaptureConfig(&armeventhub.CaptureDescription{
+		Enabled:           to.Ptr(true),
+		Encoding:          to.Ptr(armeventhub.EncodingCaptureDescriptionAvro),
+		IntervalInSeconds: to.Ptr[int32](300),
+		SizeLimitInBytes:  to.Ptr[int32](314572800),
+		Destination: &armeventhub.Destination{
+			Name: to.Ptr("EventHubArchive.AzureBlockBlob"),
+			Properties: &armeventhub.DestinationProperties{
+				StorageAccountResourceID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/capture"),
+				BlobContainer:            to.Ptr("events"),
+				ArchiveNameFormat:        to.Ptr("{Namespace}/{EventHub}/{PartitionId}/{Year}/{Month}/{Day}/{Hour}/{Minute}/{Second}"),
+			},
+		},
+	})
+
+	assert.Equal(t, EventHubCaptureConfig{
+		Enabled:                  true,
+		Encoding:                 "Avro",
+		IntervalInSeconds:        300,
+		SizeLimitInBytes:         314572800,
+		StorageAccountResourceID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/capture",
+		BlobContainer:            "events",
+		ArchiveNameFormat:        "{Namespace}/{EventHub}/{PartitionId}/{Year}/{Month}/{Day}/{Hour}/{Minute}/{Second}",
+	}, config)
+}
+
+func TestNewEventHubNamespaceNetworkRules(t *testing.T) {
+	t.Parallel()
+
+	rules := newEventHubNamespaceNetworkRules(armeventhub.NetworkRuleSet{
+		Properties: &armeventhub.NetworkRuleSetProperties{
+			DefaultAction:               to.Ptr(armeventhub.DefaultActionDeny),
+			PublicNetworkAccess:         to.Ptr(armeventhub.PublicNetworkAccessFlagEnabled),
+			TrustedServiceAccessEnabled: to.Ptr(true),
+			IPRules: []*armeventhub.NWRuleSetIPRules{
+				{IPMask: to.Ptr("203.0.113.0/24"), Action: to.Ptr(armeventhub.NetworkRuleIPActionAllow)},
+			},
+			VirtualNetworkRules: []*armeventhub.NWRuleSetVirtualNetworkRules{
+				{Subnet: &armeventhub.Subnet{ID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/app")}},
+			},
+		},
+	})
+
+	assert.Equal(t, EventHubNamespaceNetworkRules{
+		DefaultAction:               "Deny",
+		PublicNetworkAccess:         "Enabled",
+		TrustedServiceAccessEnabled: true,
+		IPRules:                     []string{"203.0.113.0/24"},
+		SubnetIDs:                   []string{"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/app"},
+	}, rules)
+}
+
+func TestGetEventHubE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetEventHubE("", "", "", "")
+	require.Error(t, err)
+}
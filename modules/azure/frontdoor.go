@@ -2,12 +2,61 @@ package azure
 
 import (
 	"context"
+	"strings"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/frontdoor/mgmt/frontdoor"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/frontdoor/armfrontdoor"
 	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// FrontDoorFrontend summarizes a frontend endpoint of a Front Door.
+type FrontDoorFrontend struct {
+	Name        string
+	HostName    string
+	WAFPolicyID string // Empty if the endpoint has no WAF policy
+}
+
+// FrontDoorRoutingRule summarizes a routing rule of a Front Door. Redirecting rules have no backend pool.
+type FrontDoorRoutingRule struct {
+	Name              string
+	Enabled           bool
+	FrontendNames     []string
+	PatternsToMatch   []string // e.g., /*
+	AcceptedProtocols []string // Http and/or Https
+	BackendPoolName   string
+}
+
+// FrontDoorBackendPool summarizes a backend pool of a Front Door.
+type FrontDoorBackendPool struct {
+	Name            string
+	HealthProbeName string
+	Backends        []FrontDoorBackend
+}
+
+// FrontDoorBackend summarizes a backend of a Front Door backend pool.
+type FrontDoorBackend struct {
+	Address    string
+	HostHeader string
+	HTTPPort   int32
+	HTTPSPort  int32
+	Priority   int32
+	Weight     int32
+	Enabled    bool
+}
+
+// FrontDoorHealthProbe summarizes the health probe settings of a Front Door.
+type FrontDoorHealthProbe struct {
+	Name              string
+	Enabled           bool
+	Path              string
+	Protocol          string // Http or Https
+	Method            string // GET or HEAD
+	IntervalInSeconds int32
+}
+
 // FrontDoorExists indicates whether the Front Door exists for the subscription.
 // This function would fail the test if there is an error.
 func FrontDoorExists(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) bool {
@@ -18,7 +67,7 @@ func FrontDoorExists(t testing.TestingT, frontDoorName string, resourceGroupName
 
 // GetFrontDoor gets a Front Door by name if it exists for the subscription.
 // This function would fail the test if there is an error.
-func GetFrontDoor(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) *frontdoor.FrontDoor {
+func GetFrontDoor(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) *armfrontdoor.FrontDoor {
 	fd, err := GetFrontDoorE(frontDoorName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return fd
@@ -34,7 +83,7 @@ func FrontDoorFrontendEndpointExists(t testing.TestingT, endpointName string, fr
 
 // GetFrontDoorFrontendEndpoint gets a frontend endpoint by name for the provided Front Door if it exists for the subscription.
 // This function would fail the test if there is an error.
-func GetFrontDoorFrontendEndpoint(t testing.TestingT, endpointName string, frontDoorName string, resourceGroupName string, subscriptionID string) *frontdoor.FrontendEndpoint {
+func GetFrontDoorFrontendEndpoint(t testing.TestingT, endpointName string, frontDoorName string, resourceGroupName string, subscriptionID string) *armfrontdoor.FrontendEndpoint {
 	ep, err := GetFrontDoorFrontendEndpointE(endpointName, frontDoorName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return ep
@@ -65,63 +114,300 @@ func FrontDoorFrontendEndpointExistsE(endpointName string, frontDoorName string,
 }
 
 // GetFrontDoorE gets the specified Front Door if it exists and may return an error.
-func GetFrontDoorE(frontDoorName, resoureGroupName, subscriptionID string) (*frontdoor.FrontDoor, error) {
+func GetFrontDoorE(frontDoorName, resoureGroupName, subscriptionID string) (*armfrontdoor.FrontDoor, error) {
 	client, err := GetFrontDoorClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	fd, err := client.Get(context.Background(), resoureGroupName, frontDoorName)
+	fd, err := client.Get(context.Background(), resoureGroupName, frontDoorName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &fd, nil
+	return &fd.FrontDoor, nil
 }
 
 // GetFrontDoorFrontendEndpointE gets the specified Frontend Endpoint for the provided Front Door if it exists and may return an error.
-func GetFrontDoorFrontendEndpointE(endpointName, frontDoorName, resourceGroupName, subscriptionID string) (*frontdoor.FrontendEndpoint, error) {
+func GetFrontDoorFrontendEndpointE(endpointName, frontDoorName, resourceGroupName, subscriptionID string) (*armfrontdoor.FrontendEndpoint, error) {
 	client, err := GetFrontDoorFrontendEndpointClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	endpoint, err := client.Get(context.Background(), resourceGroupName, frontDoorName, endpointName)
+	endpoint, err := client.Get(context.Background(), resourceGroupName, frontDoorName, endpointName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &endpoint, nil
+	return &endpoint.FrontendEndpoint, nil
 }
 
 // GetFrontDoorClientE return a front door client; otherwise error.
-func GetFrontDoorClientE(subscriptionID string) (*frontdoor.FrontDoorsClient, error) {
-	client, err := CreateFrontDoorClientE(subscriptionID)
+func GetFrontDoorClientE(subscriptionID string) (*armfrontdoor.FrontDoorsClient, error) {
+	return CreateFrontDoorClientE(subscriptionID)
+}
+
+// GetFrontDoorFrontendEndpointClientE returns a front door frontend endpoints client; otherwise error.
+func GetFrontDoorFrontendEndpointClientE(subscriptionID string) (*armfrontdoor.FrontendEndpointsClient, error) {
+	return CreateFrontDoorFrontendEndpointClientE(subscriptionID)
+}
+
+// GetFrontDoorFrontends gets the frontend endpoints of the specified Front Door.
+// This function would fail the test if there is an error.
+func GetFrontDoorFrontends(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) []FrontDoorFrontend {
+	frontends, err := GetFrontDoorFrontendsE(frontDoorName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return frontends
+}
+
+// GetFrontDoorFrontendsE gets the frontend endpoints of the specified Front Door.
+func GetFrontDoorFrontendsE(frontDoorName string, resourceGroupName string, subscriptionID string) ([]FrontDoorFrontend, error) {
+	fd, err := GetFrontDoorE(frontDoorName, resourceGroupName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	authorizer, err := NewAuthorizer()
+	frontends := []FrontDoorFrontend{}
+	if fd.Properties == nil {
+		return frontends, nil
+	}
+	for _, endpoint := range fd.Properties.FrontendEndpoints {
+		frontends = append(frontends, newFrontDoorFrontend(endpoint))
+	}
+	return frontends, nil
+}
+
+// GetFrontDoorRoutingRules gets the routing rules of the specified Front Door.
+// This function would fail the test if there is an error.
+func GetFrontDoorRoutingRules(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) []FrontDoorRoutingRule {
+	rules, err := GetFrontDoorRoutingRulesE(frontDoorName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return rules
+}
+
+// GetFrontDoorRoutingRulesE gets the routing rules of the specified Front Door.
+func GetFrontDoorRoutingRulesE(frontDoorName string, resourceGroupName string, subscriptionID string) ([]FrontDoorRoutingRule, error) {
+	fd, err := GetFrontDoorE(frontDoorName, resourceGroupName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	client.Authorizer = *authorizer
-	return client, nil
+	rules := []FrontDoorRoutingRule{}
+	if fd.Properties == nil {
+		return rules, nil
+	}
+	for _, rule := range fd.Properties.RoutingRules {
+		rules = append(rules, newFrontDoorRoutingRule(rule))
+	}
+	return rules, nil
 }
 
-// GetFrontDoorFrontendEndpointClientE returns a front door frontend endpoints client; otherwise error.
-func GetFrontDoorFrontendEndpointClientE(subscriptionID string) (*frontdoor.FrontendEndpointsClient, error) {
-	client, err := CreateFrontDoorFrontendEndpointClientE(subscriptionID)
+// GetFrontDoorBackendPools gets the backend pools of the specified Front Door.
+// This function would fail the test if there is an error.
+func GetFrontDoorBackendPools(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) []FrontDoorBackendPool {
+	pools, err := GetFrontDoorBackendPoolsE(frontDoorName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return pools
+}
+
+// GetFrontDoorBackendPoolsE gets the backend pools of the specified Front Door.
+func GetFrontDoorBackendPoolsE(frontDoorName string, resourceGroupName string, subscriptionID string) ([]FrontDoorBackendPool, error) {
+	fd, err := GetFrontDoorE(frontDoorName, resourceGroupName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	authorizer, err := NewAuthorizer()
+	pools := []FrontDoorBackendPool{}
+	if fd.Properties == nil {
+		return pools, nil
+	}
+	for _, pool := range fd.Properties.BackendPools {
+		pools = append(pools, newFrontDoorBackendPool(pool))
+	}
+	return pools, nil
+}
+
+// GetFrontDoorHealthProbes gets the health probe settings of the specified Front Door.
+// This function would fail the test if there is an error.
+func GetFrontDoorHealthProbes(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) []FrontDoorHealthProbe {
+	probes, err := GetFrontDoorHealthProbesE(frontDoorName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return probes
+}
+
+// GetFrontDoorHealthProbesE gets the health probe settings of the specified Front Door.
+func GetFrontDoorHealthProbesE(frontDoorName string, resourceGroupName string, subscriptionID string) ([]FrontDoorHealthProbe, error) {
+	fd, err := GetFrontDoorE(frontDoorName, resourceGroupName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	client.Authorizer = *authorizer
-	return client, nil
+	probes := []FrontDoorHealthProbe{}
+	if fd.Properties == nil {
+		return probes, nil
+	}
+	for _, probe := range fd.Properties.HealthProbeSettings {
+		probes = append(probes, newFrontDoorHealthProbe(probe))
+	}
+	return probes, nil
+}
+
+// GetFrontDoorBackendHealthPercentage gets the percentage of successful health probes of the specified Front Door.
+// See GetFrontDoorBackendHealthPercentageE. This function would fail the test if there is an error.
+func GetFrontDoorBackendHealthPercentage(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) float64 {
+	percentage, err := GetFrontDoorBackendHealthPercentageE(frontDoorName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return percentage
+}
+
+// GetFrontDoorBackendHealthPercentageE gets the percentage of successful health probes to the backends of the
+// specified Front Door over the last few minutes, from its BackendHealthPercentage metric. Front Door doesn't report
+// the health of each backend otherwise.
+func GetFrontDoorBackendHealthPercentageE(frontDoorName string, resourceGroupName string, subscriptionID string) (float64, error) {
+	fd, err := GetFrontDoorE(frontDoorName, resourceGroupName, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return GetResourceMetricLatestValueE(safePtrToString(fd.ID), "BackendHealthPercentage", "Average", 15*time.Minute, subscriptionID)
+}
+
+// AssertFrontDoorBackendsHealthy checks that all the health probes to the backends of the specified Front Door
+// succeeded over the last few minutes.
+func AssertFrontDoorBackendsHealthy(t testing.TestingT, frontDoorName string, resourceGroupName string, subscriptionID string) {
+	percentage, err := GetFrontDoorBackendHealthPercentageE(frontDoorName, resourceGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, float64(100), percentage, "Only %.1f%% of the health probes of Front Door %s succeeded", percentage, frontDoorName)
+	}
+}
+
+// GetFrontDoorWAFPolicyMode gets the mode of the WAF policy of the given frontend endpoint of a Front Door.
+// This function would fail the test if there is an error.
+func GetFrontDoorWAFPolicyMode(t testing.TestingT, endpointName string, frontDoorName string, resourceGroupName string, subscriptionID string) string {
+	mode, err := GetFrontDoorWAFPolicyModeE(endpointName, frontDoorName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return mode
+}
+
+// GetFrontDoorWAFPolicyModeE gets the mode, Prevention or Detection, of the WAF policy of the given frontend endpoint
+// of a Front Door.
+func GetFrontDoorWAFPolicyModeE(endpointName string, frontDoorName string, resourceGroupName string, subscriptionID string) (string, error) {
+	endpoint, err := GetFrontDoorFrontendEndpointE(endpointName, frontDoorName, resourceGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	policyResourceID := newFrontDoorFrontend(endpoint).WAFPolicyID
+	if policyResourceID == "" {
+		return "", NewNotFoundError("WAF policy", endpointName, frontDoorName)
+	}
+
+	policyID, err := arm.ParseResourceID(policyResourceID)
+	if err != nil {
+		return "", err
+	}
+	client, err := CreateFrontDoorWAFPoliciesClientE(policyID.SubscriptionID)
+	if err != nil {
+		return "", err
+	}
+	policy, err := client.Get(context.Background(), policyID.ResourceGroupName, policyID.Name, nil)
+	if err != nil {
+		return "", err
+	}
+	if policy.Properties == nil || policy.Properties.PolicySettings == nil {
+		return "", NewNotFoundError("WAF policy settings", policyID.Name, policyID.ResourceGroupName)
+	}
+
+	return safeEnumPtrToString(policy.Properties.PolicySettings.Mode), nil
+}
+
+// AssertFrontDoorWAFPolicyMode checks that the WAF policy of the given frontend endpoint of a Front Door runs in the
+// expected mode, Prevention or Detection.
+func AssertFrontDoorWAFPolicyMode(t testing.TestingT, endpointName string, frontDoorName string, resourceGroupName string, subscriptionID string, expectedMode string) {
+	mode, err := GetFrontDoorWAFPolicyModeE(endpointName, frontDoorName, resourceGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, strings.EqualFold(expectedMode, mode), "WAF policy of endpoint %s of Front Door %s is in %s mode, expected %s", endpointName, frontDoorName, mode, expectedMode)
+	}
+}
+
+// newFrontDoorFrontend summarizes the given frontend endpoint.
+func newFrontDoorFrontend(endpoint *armfrontdoor.FrontendEndpoint) FrontDoorFrontend {
+	frontend := FrontDoorFrontend{Name: safePtrToString(endpoint.Name)}
+	if endpoint.Properties == nil {
+		return frontend
+	}
+
+	frontend.HostName = safePtrToString(endpoint.Properties.HostName)
+	if link := endpoint.Properties.WebApplicationFirewallPolicyLink; link != nil {
+		frontend.WAFPolicyID = safePtrToString(link.ID)
+	}
+	return frontend
+}
+
+// newFrontDoorRoutingRule summarizes the given routing rule.
+func newFrontDoorRoutingRule(rule *armfrontdoor.RoutingRule) FrontDoorRoutingRule {
+	summary := FrontDoorRoutingRule{
+		Name:              safePtrToString(rule.Name),
+		FrontendNames:     []string{},
+		PatternsToMatch:   []string{},
+		AcceptedProtocols: []string{},
+	}
+	props := rule.Properties
+	if props == nil {
+		return summary
+	}
+
+	summary.Enabled = safeEnumPtrToString(props.EnabledState) != string(armfrontdoor.RoutingRuleEnabledStateDisabled)
+	for _, frontend := range props.FrontendEndpoints {
+		summary.FrontendNames = append(summary.FrontendNames, GetNameFromResourceID(safePtrToString(frontend.ID)))
+	}
+	summary.PatternsToMatch = safePtrToList(props.PatternsToMatch)
+	for _, protocol := range props.AcceptedProtocols {
+		summary.AcceptedProtocols = append(summary.AcceptedProtocols, safeEnumPtrToString(protocol))
+	}
+	if forwarding, ok := props.RouteConfiguration.(*armfrontdoor.ForwardingConfiguration); ok && forwarding.BackendPool != nil {
+		summary.BackendPoolName = GetNameFromResourceID(safePtrToString(forwarding.BackendPool.ID))
+	}
+	return summary
+}
+
+// newFrontDoorBackendPool summarizes the given backend pool.
+func newFrontDoorBackendPool(pool *armfrontdoor.BackendPool) FrontDoorBackendPool {
+	summary := FrontDoorBackendPool{Name: safePtrToString(pool.Name), Backends: []FrontDoorBackend{}}
+	props := pool.Properties
+	if props == nil {
+		return summary
+	}
+
+	if props.HealthProbeSettings != nil {
+		summary.HealthProbeName = GetNameFromResourceID(safePtrToString(props.HealthProbeSettings.ID))
+	}
+	for _, backend := range props.Backends {
+		summary.Backends = append(summary.Backends, FrontDoorBackend{
+			Address:    safePtrToString(backend.Address),
+			HostHeader: safePtrToString(backend.BackendHostHeader),
+			HTTPPort:   safePtrToInt32(backend.HTTPPort),
+			HTTPSPort:  safePtrToInt32(backend.HTTPSPort),
+			Priority:   safePtrToInt32(backend.Priority),
+			Weight:     safePtrToInt32(backend.Weight),
+			Enabled:    safeEnumPtrToString(backend.EnabledState) != string(armfrontdoor.BackendEnabledStateDisabled),
+		})
+	}
+	return summary
+}
+
+// newFrontDoorHealthProbe summarizes the given health probe settings.
+func newFrontDoorHealthProbe(probe *armfrontdoor.HealthProbeSettingsModel) FrontDoorHealthProbe {
+	summary := FrontDoorHealthProbe{Name: safePtrToString(probe.Name)}
+	props := probe.Properties
+	if props == nil {
+		return summary
+	}
+
+	summary.Enabled = safeEnumPtrToString(props.EnabledState) != string(armfrontdoor.HealthProbeEnabledDisabled)
+	summary.Path = safePtrToString(props.Path)
+	summary.Protocol = safeEnumPtrToString(props.Protocol)
+	summary.Method = safeEnumPtrToString(props.HealthProbeMethod)
+	summary.IntervalInSeconds = safePtrToInt32(props.IntervalInSeconds)
+	return summary
 }
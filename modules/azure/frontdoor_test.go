@@ -9,6 +9,9 @@ package azure
 import (
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/frontdoor/armfrontdoor"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,3 +73,56 @@ func TestGetFrontDoorFrontendEndpoint(t *testing.T) {
 	require.Nil(t, endpoint)
 	require.Error(t, err)
 }
+
+func TestNewFrontDoorRoutingRule(t *testing.T) {
+	t.Parallel()
+
+	fdID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/frontDoors/fd"
+	rule := newFrontDoorRoutingRule(&armfrontdoor.RoutingRule{
+		Name: to.Ptr("default"),
+		Properties: &armfrontdoor.RoutingRuleProperties{
+			EnabledState:      to.Ptr(armfrontdoor.RoutingRuleEnabledStateEnabled),
+			FrontendEndpoints: []*armfrontdoor.SubResource{{ID: to.Ptr(fdID + "/frontendEndpoints/www")}},
+			PatternsToMatch:   to.SliceOfPtrs("/*"),
+			AcceptedProtocols: []*armfrontdoor.FrontDoorProtocol{to.Ptr(armfrontdoor.FrontDoorProtocolHTTPS)},
+			RouteConfiguration: &armfrontdoor.ForwardingConfiguration{
+				ODataType:   to.Ptr("#Microsoft.Azure.FrontDoor.Models.FrontdoorForwardingConfiguration"),
+				BackendPool: &armfrontdoor.SubResource{ID: to.Ptr(fdID + "/backendPools/web")},
+			},
+		},
+	})
+
+	assert.Equal(t, FrontDoorRoutingRule{
+		Name:              "default",
+		Enabled:           true,
+		FrontendNames:     []string{"www"},
+		PatternsToMatch:   []string{"/*"},
+		AcceptedProtocols: []string{"Https"},
+		BackendPoolName:   "web",
+	}, rule)
+}
+
+func TestNewFrontDoorBackendPool(t *testing.T) {
+	t.Parallel()
+
+	pool := newFrontDoorBackendPool(&armfrontdoor.BackendPool{
+		Name: to.Ptr("web"),
+		Properties: &armfrontdoor.BackendPoolProperties{
+			HealthProbeSettings: &armfrontdoor.SubResource{ID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/frontDoors/fd/healthProbeSettings/probe")},
+			Backends: []*armfrontdoor.Backend{{
+				Address:      to.Ptr("app.azurewebsites.net"),
+				HTTPPort:     to.Ptr[int32](80),
+				HTTPSPort:    to.Ptr[int32](443),
+				Priority:     to.Ptr[int32](1),
+				Weight:       to.Ptr[int32](50),
+				EnabledState: to.Ptr(armfrontdoor.BackendEnabledStateDisabled),
+			}},
+		},
+	})
+
+	assert.Equal(t, FrontDoorBackendPool{
+		Name:            "web",
+		HealthProbeName: "probe",
+		Backends:        []FrontDoorBackend{{Address: "app.azurewebsites.net", HTTPPort: 80, HTTPSPort: 443, Priority: 1, Weight: 50}},
+	}, pool)
+}
@@ -2,15 +2,26 @@ package azure
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
-	"os"
+	"strings"
 	"testing"
 
-	kvauth "github.com/Azure/azure-sdk-for-go/services/keyvault/auth"
-	kvmng "github.com/Azure/azure-sdk-for-go/services/keyvault/mgmt/2016-10-01/keyvault"
-	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.0/keyvault"
-	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/keyvault/armkeyvault"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pkcs12"
+)
+
+const (
+	// keyVaultPemContentType and keyVaultPkcs12ContentType are the content types of the secret that backs a Key Vault
+	// certificate, which holds the certificate chain and the private key.
+	keyVaultPemContentType    = "application/x-pem-file"
+	keyVaultPkcs12ContentType = "application/x-pkcs12"
 )
 
 // KeyVaultSecretExists indicates whether a key vault secret exists; otherwise false
@@ -39,113 +50,369 @@ func KeyVaultCertificateExists(t *testing.T, keyVaultName string, certificateNam
 
 // KeyVaultCertificateExistsE indicates whether a certificate exists in key vault; otherwise false.
 func KeyVaultCertificateExistsE(keyVaultName, certificateName string) (bool, error) {
-	keyVaultSuffix, err := GetKeyVaultURISuffixE()
+	client, err := GetKeyVaultCertificatesClientE(keyVaultName)
 	if err != nil {
 		return false, err
 	}
-	client, err := GetKeyVaultClientE()
+
+	pager := client.NewListCertificatePropertiesVersionsPager(certificateName, nil)
+	if !pager.More() {
+		return false, nil
+	}
+	page, err := pager.NextPage(context.Background())
 	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return false, nil
+		}
 		return false, err
 	}
-	maxVersionsCount := int32(1)
-	versions, err := client.GetCertificateVersions(context.Background(),
-		fmt.Sprintf("https://%s.%s", keyVaultName, keyVaultSuffix),
-		certificateName,
-		&maxVersionsCount)
+	return len(page.Value) > 0, nil
+}
+
+// KeyVaultKeyExistsE indicates whether a key exists in the key vault; otherwise false.
+func KeyVaultKeyExistsE(keyVaultName, keyName string) (bool, error) {
+	client, err := GetKeyVaultKeysClientE(keyVaultName)
 	if err != nil {
 		return false, err
 	}
 
-	if len(versions.Values()) > 0 {
-		return true, nil
+	pager := client.NewListKeyPropertiesVersionsPager(keyName, nil)
+	if !pager.More() {
+		return false, nil
+	}
+	page, err := pager.NextPage(context.Background())
+	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return false, nil
+		}
+		return false, err
 	}
-	return false, nil
+	return len(page.Value) > 0, nil
 }
 
-// KeyVaultKeyExistsE indicates whether a key exists in the key vault; otherwise false.
-func KeyVaultKeyExistsE(keyVaultName, keyName string) (bool, error) {
-	keyVaultSuffix, err := GetKeyVaultURISuffixE()
+// KeyVaultSecretExistsE indicates whether a secret exists in the key vault; otherwise false.
+func KeyVaultSecretExistsE(keyVaultName, secretName string) (bool, error) {
+	client, err := GetKeyVaultSecretsClientE(keyVaultName)
 	if err != nil {
 		return false, err
 	}
-	client, err := GetKeyVaultClientE()
+
+	pager := client.NewListSecretPropertiesVersionsPager(secretName, nil)
+	if !pager.More() {
+		return false, nil
+	}
+	page, err := pager.NextPage(context.Background())
 	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return false, nil
+		}
 		return false, err
 	}
-	maxVersionsCount := int32(1)
-	versions, err := client.GetKeyVersions(context.Background(),
-		fmt.Sprintf("https://%s.%s", keyVaultName, keyVaultSuffix),
-		keyName,
-		&maxVersionsCount)
+	return len(page.Value) > 0, nil
+}
+
+// GetKeyVaultSecret gets the latest value of the given secret in the given key vault.
+// This function would fail the test if there is an error.
+func GetKeyVaultSecret(t *testing.T, keyVaultName string, secretName string) string {
+	value, err := GetKeyVaultSecretE(keyVaultName, secretName)
+	require.NoError(t, err)
+	return value
+}
+
+// GetKeyVaultSecretE gets the latest value of the given secret in the given key vault.
+func GetKeyVaultSecretE(keyVaultName string, secretName string) (string, error) {
+	client, err := GetKeyVaultSecretsClientE(keyVaultName)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	if len(versions.Values()) > 0 {
-		return true, nil
+	secret, err := client.GetSecret(context.Background(), secretName, "", nil)
+	if err != nil {
+		return "", err
 	}
-	return false, nil
+	return safePtrToString(secret.Value), nil
 }
 
-// KeyVaultSecretExistsE indicates whether a secret exists in the key vault; otherwise false.
-func KeyVaultSecretExistsE(keyVaultName, secretName string) (bool, error) {
-	client, err := GetKeyVaultClientE()
+// SetKeyVaultSecret sets the value of the given secret in the given key vault, creating a new version of the secret.
+// This function would fail the test if there is an error.
+func SetKeyVaultSecret(t *testing.T, keyVaultName string, secretName string, value string) {
+	err := SetKeyVaultSecretE(keyVaultName, secretName, value)
+	require.NoError(t, err)
+}
+
+// SetKeyVaultSecretE sets the value of the given secret in the given key vault, creating a new version of the secret.
+func SetKeyVaultSecretE(keyVaultName string, secretName string, value string) error {
+	client, err := GetKeyVaultSecretsClientE(keyVaultName)
 	if err != nil {
-		return false, err
+		return err
 	}
-	keyVaultSuffix, err := GetKeyVaultURISuffixE()
+
+	_, err = client.SetSecret(context.Background(), secretName, azsecrets.SetSecretParameters{Value: &value}, nil)
+	return err
+}
+
+// GetKeyVaultKey gets the latest version of the given key in the given key vault.
+// This function would fail the test if there is an error.
+func GetKeyVaultKey(t *testing.T, keyVaultName string, keyName string) *azkeys.KeyBundle {
+	key, err := GetKeyVaultKeyE(keyVaultName, keyName)
+	require.NoError(t, err)
+	return key
+}
+
+// GetKeyVaultKeyE gets the latest version of the given key in the given key vault.
+func GetKeyVaultKeyE(keyVaultName string, keyName string) (*azkeys.KeyBundle, error) {
+	client, err := GetKeyVaultKeysClientE(keyVaultName)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	maxVersionsCount := int32(1)
-	versions, err := client.GetSecretVersions(context.Background(),
-		fmt.Sprintf("https://%s.%s", keyVaultName, keyVaultSuffix),
-		secretName,
-		&maxVersionsCount)
+
+	key, err := client.GetKey(context.Background(), keyName, "", nil)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	return &key.KeyBundle, nil
+}
+
+// GetKeyVaultKeyType gets the type of the given key in the given key vault (e.g., RSA, RSA-HSM or EC).
+// This function would fail the test if there is an error.
+func GetKeyVaultKeyType(t *testing.T, keyVaultName string, keyName string) string {
+	keyType, err := GetKeyVaultKeyTypeE(keyVaultName, keyName)
+	require.NoError(t, err)
+	return keyType
+}
+
+// GetKeyVaultKeyTypeE gets the type of the given key in the given key vault (e.g., RSA, RSA-HSM or EC).
+func GetKeyVaultKeyTypeE(keyVaultName string, keyName string) (string, error) {
+	key, err := GetKeyVaultKeyE(keyVaultName, keyName)
+	if err != nil {
+		return "", err
+	}
+	if key.Key == nil {
+		return "", nil
+	}
+	return safeEnumPtrToString(key.Key.Kty), nil
+}
+
+// GetKeyVaultKeyOperations gets the operations the given key in the given key vault permits (e.g., encrypt or sign).
+// This function would fail the test if there is an error.
+func GetKeyVaultKeyOperations(t *testing.T, keyVaultName string, keyName string) []string {
+	operations, err := GetKeyVaultKeyOperationsE(keyVaultName, keyName)
+	require.NoError(t, err)
+	return operations
+}
+
+// GetKeyVaultKeyOperationsE gets the operations the given key in the given key vault permits (e.g., encrypt or sign).
+func GetKeyVaultKeyOperationsE(keyVaultName string, keyName string) ([]string, error) {
+	key, err := GetKeyVaultKeyE(keyVaultName, keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := []string{}
+	if key.Key != nil {
+		for _, operation := range key.Key.KeyOps {
+			if operation != nil {
+				operations = append(operations, string(*operation))
+			}
+		}
+	}
+	return operations, nil
+}
+
+// GetKeyVaultCertificate gets the latest version of the given certificate in the given key vault, parsed as X.509.
+// This function would fail the test if there is an error.
+func GetKeyVaultCertificate(t *testing.T, keyVaultName string, certificateName string) *x509.Certificate {
+	cert, err := GetKeyVaultCertificateE(keyVaultName, certificateName)
+	require.NoError(t, err)
+	return cert
+}
+
+// GetKeyVaultCertificateE gets the latest version of the given certificate in the given key vault, parsed as X.509.
+func GetKeyVaultCertificateE(keyVaultName string, certificateName string) (*x509.Certificate, error) {
+	client, err := GetKeyVaultCertificatesClientE(keyVaultName)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := client.GetCertificate(context.Background(), certificateName, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(cert.CER)
+}
+
+// GetKeyVaultCertificateChain gets the certificate chain of the given certificate in the given key vault, leaf first.
+// This function would fail the test if there is an error.
+func GetKeyVaultCertificateChain(t *testing.T, keyVaultName string, certificateName string) []*x509.Certificate {
+	chain, err := GetKeyVaultCertificateChainE(keyVaultName, certificateName)
+	require.NoError(t, err)
+	return chain
+}
+
+// GetKeyVaultCertificateChainE gets the certificate chain of the given certificate in the given key vault, leaf first.
+// The chain is read from the secret that backs the certificate, so the identity of the test needs permission to get
+// secrets in the vault.
+func GetKeyVaultCertificateChainE(keyVaultName string, certificateName string) ([]*x509.Certificate, error) {
+	client, err := GetKeyVaultSecretsClientE(keyVaultName)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.GetSecret(context.Background(), certificateName, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyVaultCertificateChainE(certificateName, safePtrToString(secret.ContentType), safePtrToString(secret.Value))
+}
+
+// ValidateKeyVaultCertificateChain verifies that the given certificate in the given key vault chains up to one of the
+// given roots through the intermediates stored with it, and is valid for the given DNS name if it is not empty.
+// This function would fail the test if there is an error.
+func ValidateKeyVaultCertificateChain(t *testing.T, keyVaultName string, certificateName string, roots *x509.CertPool, dnsName string) {
+	err := ValidateKeyVaultCertificateChainE(keyVaultName, certificateName, roots, dnsName)
+	require.NoError(t, err)
+}
+
+// ValidateKeyVaultCertificateChainE verifies that the given certificate in the given key vault chains up to one of the
+// given roots through the intermediates stored with it, and is valid for the given DNS name if it is not empty. Pass
+// nil roots to use the system roots.
+func ValidateKeyVaultCertificateChainE(keyVaultName string, certificateName string, roots *x509.CertPool, dnsName string) error {
+	chain, err := GetKeyVaultCertificateChainE(keyVaultName, certificateName)
+	if err != nil {
+		return err
 	}
+	return verifyCertificateChain(chain, roots, dnsName)
+}
 
-	if len(versions.Values()) > 0 {
-		return true, nil
+// parseKeyVaultCertificateChainE parses the certificates in the value of the secret that backs a Key Vault
+// certificate, which is either PEM or base64 encoded PKCS#12 depending on the content type of the certificate policy.
+func parseKeyVaultCertificateChainE(certificateName string, contentType string, value string) ([]*x509.Certificate, error) {
+	var blocks []*pem.Block
+	switch contentType {
+	case keyVaultPemContentType:
+		rest := []byte(value)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+	case keyVaultPkcs12ContentType:
+		pfxData, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		blocks, err = pkcs12.ToPEM(pfxData, "")
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, NewFailedToParseError("Key Vault certificate with content type "+contentType, certificateName)
+	}
+
+	chain := []*x509.Certificate{}
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, NewNotFoundError("Certificate", certificateName, "Key Vault certificate secret")
+	}
+	return chain, nil
+}
+
+// verifyCertificateChain verifies the first certificate of the given chain against the given roots, with the rest of
+// the chain as intermediates.
+func verifyCertificateChain(chain []*x509.Certificate, roots *x509.CertPool, dnsName string) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       dnsName,
+	})
+	return err
+}
+
+// GetKeyVaultSecretsClientE creates a Key Vault secrets data plane client for the given vault.
+func GetKeyVaultSecretsClientE(keyVaultName string) (*azsecrets.Client, error) {
+	return GetKeyVaultSecretsClientWithOptionsE(keyVaultName, authOptionsFromEnv())
+}
+
+// GetKeyVaultSecretsClientWithOptionsE creates a Key Vault secrets data plane client for the given vault, authenticated
+// according to the given AuthOptions.
+func GetKeyVaultSecretsClientWithOptionsE(keyVaultName string, authOptions AuthOptions) (*azsecrets.Client, error) {
+	vaultURL, err := getKeyVaultURLE(keyVaultName)
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
 	}
-	return false, nil
+	return azsecrets.NewClient(vaultURL, cred, nil)
+}
+
+// GetKeyVaultKeysClientE creates a Key Vault keys data plane client for the given vault.
+func GetKeyVaultKeysClientE(keyVaultName string) (*azkeys.Client, error) {
+	return GetKeyVaultKeysClientWithOptionsE(keyVaultName, authOptionsFromEnv())
 }
 
-// GetKeyVaultClientE creates a KeyVault client.
-func GetKeyVaultClientE() (*keyvault.BaseClient, error) {
-	kvClient := keyvault.New()
-	authorizer, err := NewKeyVaultAuthorizerE()
+// GetKeyVaultKeysClientWithOptionsE creates a Key Vault keys data plane client for the given vault, authenticated
+// according to the given AuthOptions.
+func GetKeyVaultKeysClientWithOptionsE(keyVaultName string, authOptions AuthOptions) (*azkeys.Client, error) {
+	vaultURL, err := getKeyVaultURLE(keyVaultName)
 	if err != nil {
 		return nil, err
 	}
-	kvClient.Authorizer = *authorizer
-	return &kvClient, nil
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return azkeys.NewClient(vaultURL, cred, nil)
 }
 
-// NewKeyVaultAuthorizerE will return dataplane Authorizer for KeyVault.
-func NewKeyVaultAuthorizerE() (*autorest.Authorizer, error) {
-	// Carry out env var lookups
-	_, clientIDExists := os.LookupEnv(AuthFromEnvClient)
-	_, tenantIDExists := os.LookupEnv(AuthFromEnvTenant)
-	_, fileAuthSet := os.LookupEnv(AuthFromFile)
+// GetKeyVaultCertificatesClientE creates a Key Vault certificates data plane client for the given vault.
+func GetKeyVaultCertificatesClientE(keyVaultName string) (*azcertificates.Client, error) {
+	return GetKeyVaultCertificatesClientWithOptionsE(keyVaultName, authOptionsFromEnv())
+}
 
-	// Execute logic to return an authorizer from the correct method
-	if clientIDExists && tenantIDExists {
-		authorizer, err := kvauth.NewAuthorizerFromEnvironment()
-		return &authorizer, err
-	} else if fileAuthSet {
-		authorizer, err := kvauth.NewAuthorizerFromFile()
-		return &authorizer, err
-	} else {
-		authorizer, err := kvauth.NewAuthorizerFromCLI()
-		return &authorizer, err
+// GetKeyVaultCertificatesClientWithOptionsE creates a Key Vault certificates data plane client for the given vault,
+// authenticated according to the given AuthOptions.
+func GetKeyVaultCertificatesClientWithOptionsE(keyVaultName string, authOptions AuthOptions) (*azcertificates.Client, error) {
+	vaultURL, err := getKeyVaultURLE(keyVaultName)
+	if err != nil {
+		return nil, err
 	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return azcertificates.NewClient(vaultURL, cred, nil)
+}
+
+// getKeyVaultURLE returns the data plane URL of the given vault in the Azure environment that is currently setup.
+func getKeyVaultURLE(keyVaultName string) (string, error) {
+	keyVaultSuffix, err := GetKeyVaultURISuffixE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s", keyVaultName, keyVaultSuffix), nil
 }
 
 // GetKeyVault is a helper function that gets the keyvault management object.
 // This function would fail the test if there is an error.
-func GetKeyVault(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) *kvmng.Vault {
+func GetKeyVault(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) *armkeyvault.Vault {
 	keyVault, err := GetKeyVaultE(t, resGroupName, keyVaultName, subscriptionID)
 	require.NoError(t, err)
 
@@ -153,7 +420,7 @@ func GetKeyVault(t *testing.T, resGroupName string, keyVaultName string, subscri
 }
 
 // GetKeyVaultE is a helper function that gets the keyvault management object.
-func GetKeyVaultE(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) (*kvmng.Vault, error) {
+func GetKeyVaultE(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) (*armkeyvault.Vault, error) {
 	// Create akey vault management client
 	vaultClient, err := GetKeyVaultManagementClientE(subscriptionID)
 	if err != nil {
@@ -161,31 +428,160 @@ func GetKeyVaultE(t *testing.T, resGroupName string, keyVaultName string, subscr
 	}
 
 	//Get the corresponding server client
-	keyVault, err := vaultClient.Get(context.Background(), resGroupName, keyVaultName)
+	keyVault, err := vaultClient.Get(context.Background(), resGroupName, keyVaultName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return keyvault
-	return &keyVault, nil
+	return &keyVault.Vault, nil
 }
 
 // GetKeyVaultManagementClientE is a helper function that will setup a key vault management client
-func GetKeyVaultManagementClientE(subscriptionID string) (*kvmng.VaultsClient, error) {
+func GetKeyVaultManagementClientE(subscriptionID string) (*armkeyvault.VaultsClient, error) {
 	// Create a keyvault management client
-	vaultClient, err := CreateKeyVaultManagementClientE(subscriptionID)
+	return CreateKeyVaultManagementClientE(subscriptionID)
+}
+
+// GetKeyVaultAccessPolicyPermissions gets the permissions the access policies of the given key vault grant to the
+// given object ID (a user, group or service principal).
+// This function would fail the test if there is an error.
+func GetKeyVaultAccessPolicyPermissions(t *testing.T, resGroupName string, keyVaultName string, objectID string, subscriptionID string) *armkeyvault.Permissions {
+	permissions, err := GetKeyVaultAccessPolicyPermissionsE(t, resGroupName, keyVaultName, objectID, subscriptionID)
+	require.NoError(t, err)
+	return permissions
+}
+
+// GetKeyVaultAccessPolicyPermissionsE gets the permissions the access policies of the given key vault grant to the
+// given object ID (a user, group or service principal).
+func GetKeyVaultAccessPolicyPermissionsE(t *testing.T, resGroupName string, keyVaultName string, objectID string, subscriptionID string) (*armkeyvault.Permissions, error) {
+	keyVault, err := GetKeyVaultE(t, resGroupName, keyVaultName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
+	if keyVault.Properties != nil {
+		for _, policy := range keyVault.Properties.AccessPolicies {
+			if policy != nil && strings.EqualFold(safePtrToString(policy.ObjectID), objectID) && policy.Permissions != nil {
+				return policy.Permissions, nil
+			}
+		}
+	}
+	return nil, NewNotFoundError("Access policy", objectID, keyVaultName)
+}
+
+// KeyVaultAccessPolicyAllows indicates whether the access policies of the given key vault grant the given object ID
+// all of the given secret, key and certificate permissions (e.g., "get" and "list"); otherwise false.
+// This function would fail the test if there is an error.
+func KeyVaultAccessPolicyAllows(t *testing.T, resGroupName string, keyVaultName string, objectID string, secretPermissions []string, keyPermissions []string, certificatePermissions []string, subscriptionID string) bool {
+	allowed, err := KeyVaultAccessPolicyAllowsE(t, resGroupName, keyVaultName, objectID, secretPermissions, keyPermissions, certificatePermissions, subscriptionID)
+	require.NoError(t, err)
+	return allowed
+}
+
+// KeyVaultAccessPolicyAllowsE indicates whether the access policies of the given key vault grant the given object ID
+// all of the given secret, key and certificate permissions (e.g., "get" and "list"); otherwise false.
+func KeyVaultAccessPolicyAllowsE(t *testing.T, resGroupName string, keyVaultName string, objectID string, secretPermissions []string, keyPermissions []string, certificatePermissions []string, subscriptionID string) (bool, error) {
+	permissions, err := GetKeyVaultAccessPolicyPermissionsE(t, resGroupName, keyVaultName, objectID, subscriptionID)
+	if _, ok := err.(NotFoundError); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return containsAllFold(permissionsToList(permissions.Secrets), secretPermissions) &&
+		containsAllFold(permissionsToList(permissions.Keys), keyPermissions) &&
+		containsAllFold(permissionsToList(permissions.Certificates), certificatePermissions), nil
+}
+
+// KeyVaultRBACAuthorizationEnabled indicates whether the given key vault authorizes data plane operations with Azure
+// RBAC instead of access policies; otherwise false.
+// This function would fail the test if there is an error.
+func KeyVaultRBACAuthorizationEnabled(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) bool {
+	enabled, err := KeyVaultRBACAuthorizationEnabledE(t, resGroupName, keyVaultName, subscriptionID)
+	require.NoError(t, err)
+	return enabled
+}
+
+// KeyVaultRBACAuthorizationEnabledE indicates whether the given key vault authorizes data plane operations with Azure
+// RBAC instead of access policies; otherwise false.
+func KeyVaultRBACAuthorizationEnabledE(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) (bool, error) {
+	keyVault, err := GetKeyVaultE(t, resGroupName, keyVaultName, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+	return keyVault.Properties != nil && keyVault.Properties.EnableRbacAuthorization != nil && *keyVault.Properties.EnableRbacAuthorization, nil
+}
+
+// GetKeyVaultNetworkAcls gets the network rules of the given key vault: the default action, the bypass and the
+// allowed IP and virtual network rules.
+// This function would fail the test if there is an error.
+func GetKeyVaultNetworkAcls(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) *armkeyvault.NetworkRuleSet {
+	networkAcls, err := GetKeyVaultNetworkAclsE(t, resGroupName, keyVaultName, subscriptionID)
+	require.NoError(t, err)
+	return networkAcls
+}
+
+// GetKeyVaultNetworkAclsE gets the network rules of the given key vault: the default action, the bypass and the
+// allowed IP and virtual network rules.
+func GetKeyVaultNetworkAclsE(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) (*armkeyvault.NetworkRuleSet, error) {
+	keyVault, err := GetKeyVaultE(t, resGroupName, keyVaultName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Attach authorizer to the client
-	vaultClient.Authorizer = *authorizer
+	if keyVault.Properties == nil || keyVault.Properties.NetworkACLs == nil {
+		// Vaults without network rules allow access from all networks
+		allow := armkeyvault.NetworkRuleActionAllow
+		return &armkeyvault.NetworkRuleSet{DefaultAction: &allow}, nil
+	}
+	return keyVault.Properties.NetworkACLs, nil
+}
+
+// GetKeyVaultAllowedIPRules gets the IP addresses and CIDR ranges the network rules of the given key vault allow.
+// This function would fail the test if there is an error.
+func GetKeyVaultAllowedIPRules(t *testing.T, resGroupName string, keyVaultName string, subscriptionID string) []string {
+	networkAcls := GetKeyVaultNetworkAcls(t, resGroupName, keyVaultName, subscriptionID)
+
+	ipRules := []string{}
+	for _, rule := range networkAcls.IPRules {
+		if rule != nil {
+			ipRules = append(ipRules, safePtrToString(rule.Value))
+		}
+	}
+	return ipRules
+}
 
-	return vaultClient, nil
+// permissionsToList converts the given Key Vault permission enums to strings.
+func permissionsToList[T ~string](permissions []*T) []string {
+	list := []string{}
+	for _, permission := range permissions {
+		if permission != nil {
+			list = append(list, string(*permission))
+		}
+	}
+	return list
+}
+
+// containsAllFold indicates whether list contains all of the wanted values, ignoring case, or "all".
+func containsAllFold(list []string, wanted []string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, "all") {
+			return true
+		}
+	}
+	for _, want := range wanted {
+		found := false
+		for _, item := range list {
+			if strings.EqualFold(item, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
@@ -1,8 +1,17 @@
 package azure
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,3 +57,108 @@ func TestGetKeyVault(t *testing.T) {
 	_, err := GetKeyVaultE(t, resGroupName, keyVaultName, subscriptionID)
 	require.Error(t, err)
 }
+
+func TestGetKeyVaultSecret(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetKeyVaultSecretE("fakeKeyVault", "fakeSecretName")
+	require.Error(t, err)
+}
+
+func TestGetKeyVaultKeyType(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetKeyVaultKeyTypeE("fakeKeyVault", "fakeKeyName")
+	require.Error(t, err)
+}
+
+func TestValidateKeyVaultCertificateChain(t *testing.T) {
+	t.Parallel()
+
+	err := ValidateKeyVaultCertificateChainE("fakeKeyVault", "fakeCertName", nil, "")
+	require.Error(t, err)
+}
+
+func TestGetKeyVaultAccessPolicyPermissions(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetKeyVaultAccessPolicyPermissionsE(t, "", "", "", "")
+	require.Error(t, err)
+}
+
+func TestGetKeyVaultNetworkAcls(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetKeyVaultNetworkAclsE(t, "", "", "")
+	require.Error(t, err)
+}
+
+func TestParseAndVerifyKeyVaultCertificateChain(t *testing.T) {
+	t.Parallel()
+
+	root, rootKey := createTestCertificate(t, "root", nil, nil, true)
+	intermediate, intermediateKey := createTestCertificate(t, "intermediate", root, rootKey, true)
+	leaf, _ := createTestCertificate(t, "www.example.com", intermediate, intermediateKey, false)
+
+	pemValue := ""
+	for _, cert := range []*x509.Certificate{leaf, intermediate} {
+		pemValue += string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	pemValue += string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("ignored")}))
+
+	chain, err := parseKeyVaultCertificateChainE("cert", keyVaultPemContentType, pemValue)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	assert.Equal(t, "www.example.com", chain[0].Subject.CommonName)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	require.NoError(t, verifyCertificateChain(chain, roots, "www.example.com"))
+	require.Error(t, verifyCertificateChain(chain, roots, "other.example.com"))
+	require.Error(t, verifyCertificateChain(chain[:1], roots, "www.example.com"))
+
+	_, err = parseKeyVaultCertificateChainE("cert", "application/json", pemValue)
+	require.Error(t, err)
+	_, err = parseKeyVaultCertificateChainE("cert", keyVaultPemContentType, "")
+	require.Error(t, err)
+}
+
+func TestContainsAllFold(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, containsAllFold([]string{"Get", "List"}, []string{"get", "list"}))
+	assert.True(t, containsAllFold([]string{"all"}, []string{"get", "purge"}))
+	assert.True(t, containsAllFold([]string{}, nil))
+	assert.False(t, containsAllFold([]string{"get"}, []string{"get", "list"}))
+}
+
+// createTestCertificate creates a certificate with the given common name, signed by the given parent, or self-signed
+// if parent is nil.
+func createTestCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{commonName}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
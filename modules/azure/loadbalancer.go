@@ -3,7 +3,7 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -44,8 +44,8 @@ func GetLoadBalancerFrontendIPConfigNamesE(loadBalancerName string, resourceGrou
 	}
 
 	// Get the Frontend IP Configurations
-	lbProps := lb.LoadBalancerPropertiesFormat
-	feConfigs := *lbProps.FrontendIPConfigurations
+	lbProps := lb.Properties
+	feConfigs := lbProps.FrontendIPConfigurations
 	if len(feConfigs) == 0 {
 		// No Frontend IP Configuration present
 		return nil, nil
@@ -77,7 +77,7 @@ func GetIPOfLoadBalancerFrontendIPConfigE(feConfigName string, loadBalancerName
 	}
 
 	// Get the Properties of the Frontend Configuration
-	feProps := *feConfig.FrontendIPConfigurationPropertiesFormat
+	feProps := *feConfig.Properties
 
 	// Check for the Public Type Frontend Config
 	if feProps.PublicIPAddress != nil {
@@ -100,14 +100,14 @@ func GetIPOfLoadBalancerFrontendIPConfigE(feConfigName string, loadBalancerName
 
 // GetLoadBalancerFrontendIPConfig gets the specified Load Balancer Frontend IP Configuration network resource.
 // This function would fail the test if there is an error.
-func GetLoadBalancerFrontendIPConfig(t testing.TestingT, feConfigName string, loadBalancerName string, resourceGroupName string, subscriptionID string) *network.FrontendIPConfiguration {
+func GetLoadBalancerFrontendIPConfig(t testing.TestingT, feConfigName string, loadBalancerName string, resourceGroupName string, subscriptionID string) *armnetwork.FrontendIPConfiguration {
 	lbFEConfig, err := GetLoadBalancerFrontendIPConfigE(feConfigName, loadBalancerName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return lbFEConfig
 }
 
 // GetLoadBalancerFrontendIPConfigE gets the specified Load Balancer Frontend IP Configuration network resource.
-func GetLoadBalancerFrontendIPConfigE(feConfigName string, loadBalancerName string, resourceGroupName string, subscriptionID string) (*network.FrontendIPConfiguration, error) {
+func GetLoadBalancerFrontendIPConfigE(feConfigName string, loadBalancerName string, resourceGroupName string, subscriptionID string) (*armnetwork.FrontendIPConfiguration, error) {
 	// Validate Azure Resource Group Name
 	resourceGroupName, err := getTargetAzureResourceGroupName(resourceGroupName)
 	if err != nil {
@@ -121,45 +121,30 @@ func GetLoadBalancerFrontendIPConfigE(feConfigName string, loadBalancerName stri
 	}
 
 	// Get the Load Balancer Frontend IP Configuration
-	lbc, err := client.Get(context.Background(), resourceGroupName, loadBalancerName, feConfigName)
+	lbc, err := client.Get(context.Background(), resourceGroupName, loadBalancerName, feConfigName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &lbc, nil
+	return &lbc.FrontendIPConfiguration, nil
 }
 
 // GetLoadBalancerFrontendIPConfigClientE gets a new Load Balancer Frontend IP Configuration client in the specified Azure Subscription.
-func GetLoadBalancerFrontendIPConfigClientE(subscriptionID string) (*network.LoadBalancerFrontendIPConfigurationsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetLoadBalancerFrontendIPConfigClientE(subscriptionID string) (*armnetwork.LoadBalancerFrontendIPConfigurationsClient, error) {
 	// Get the Load Balancer Frontend Configuration client
-	client := network.NewLoadBalancerFrontendIPConfigurationsClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return &client, nil
+	return CreateLoadBalancerFrontendIPConfigClientE(subscriptionID)
 }
 
 // GetLoadBalancer gets a Load Balancer network resource in the specified Azure Resource Group.
 // This function would fail the test if there is an error.
-func GetLoadBalancer(t testing.TestingT, loadBalancerName string, resourceGroupName string, subscriptionID string) *network.LoadBalancer {
+func GetLoadBalancer(t testing.TestingT, loadBalancerName string, resourceGroupName string, subscriptionID string) *armnetwork.LoadBalancer {
 	lb, err := GetLoadBalancerE(loadBalancerName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return lb
 }
 
 // GetLoadBalancerE gets a Load Balancer network resource in the specified Azure Resource Group.
-func GetLoadBalancerE(loadBalancerName string, resourceGroupName string, subscriptionID string) (*network.LoadBalancer, error) {
+func GetLoadBalancerE(loadBalancerName string, resourceGroupName string, subscriptionID string) (*armnetwork.LoadBalancer, error) {
 	// Validate Azure Resource Group Name
 	resourceGroupName, err := getTargetAzureResourceGroupName(resourceGroupName)
 	if err != nil {
@@ -173,28 +158,16 @@ func GetLoadBalancerE(loadBalancerName string, resourceGroupName string, subscri
 	}
 
 	// Get the Load Balancer
-	lb, err := client.Get(context.Background(), resourceGroupName, loadBalancerName, "")
+	lb, err := client.Get(context.Background(), resourceGroupName, loadBalancerName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &lb, nil
+	return &lb.LoadBalancer, nil
 }
 
 // GetLoadBalancerClientE gets a new Load Balancer client in the specified Azure Subscription.
-func GetLoadBalancerClientE(subscriptionID string) (*network.LoadBalancersClient, error) {
+func GetLoadBalancerClientE(subscriptionID string) (*armnetwork.LoadBalancersClient, error) {
 	// Get the Load Balancer client
-	client, err := CreateLoadBalancerClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return client, nil
+	return CreateLoadBalancerClientE(subscriptionID)
 }
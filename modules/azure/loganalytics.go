@@ -2,9 +2,16 @@ package azure
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/services/preview/operationalinsights/mgmt/2020-03-01-preview/operationalinsights"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/monitor/azquery"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/operationalinsights/armoperationalinsights"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -19,7 +26,7 @@ func LogAnalyticsWorkspaceExists(t testing.TestingT, workspaceName string, resou
 
 // GetLogAnalyticsWorkspace gets an operational insights workspace if it exists in a subscription.
 // This function would fail the test if there is an error.
-func GetLogAnalyticsWorkspace(t testing.TestingT, workspaceName string, resourceGroupName string, subscriptionID string) *operationalinsights.Workspace {
+func GetLogAnalyticsWorkspace(t testing.TestingT, workspaceName string, resourceGroupName string, subscriptionID string) *armoperationalinsights.Workspace {
 	ws, err := GetLogAnalyticsWorkspaceE(workspaceName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 
@@ -27,17 +34,17 @@ func GetLogAnalyticsWorkspace(t testing.TestingT, workspaceName string, resource
 }
 
 // GetLogAnalyticsWorkspaceE gets an operational insights workspace if it exists in a subscription.
-func GetLogAnalyticsWorkspaceE(workspaceName, resoureGroupName, subscriptionID string) (*operationalinsights.Workspace, error) {
+func GetLogAnalyticsWorkspaceE(workspaceName, resoureGroupName, subscriptionID string) (*armoperationalinsights.Workspace, error) {
 	client, err := GetLogAnalyticsWorkspacesClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	ws, err := client.Get(context.Background(), resoureGroupName, workspaceName)
+	ws, err := client.Get(context.Background(), resoureGroupName, workspaceName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &ws, nil
+	return &ws.Workspace, nil
 }
 
 // LogAnalyticsWorkspaceExistsE indicates whether the operatonal insights workspaces exists and may return an error.
@@ -53,20 +60,206 @@ func LogAnalyticsWorkspaceExistsE(workspaceName string, resourceGroupName string
 }
 
 // GetLogAnalyticsWorkspacesClientE return workspaces client; otherwise error.
-func GetLogAnalyticsWorkspacesClientE(subscriptionID string) (*operationalinsights.WorkspacesClient, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+func GetLogAnalyticsWorkspacesClientE(subscriptionID string) (*armoperationalinsights.WorkspacesClient, error) {
+	return CreateLogAnalyticsWorkspacesClientE(subscriptionID)
+}
+
+// LogAnalyticsRow is a row returned by a Log Analytics query, keyed by column name. Values are converted to the Go
+// type of their column: datetime to time.Time, int and long to int64, real and decimal to float64, bool to bool,
+// timespan to time.Duration, dynamic to the decoded JSON value, and everything else to string.
+type LogAnalyticsRow map[string]interface{}
+
+// QueryLogAnalytics runs the given KQL query against the Log Analytics workspace with the given workspace (customer)
+// ID over the last window of time, and returns the rows of the primary result table.
+// This function would fail the test if there is an error.
+func QueryLogAnalytics(t testing.TestingT, workspaceID string, kql string, window time.Duration) []LogAnalyticsRow {
+	rows, err := QueryLogAnalyticsE(t, workspaceID, kql, window)
+	require.NoError(t, err)
+
+	return rows
+}
+
+// QueryLogAnalyticsE runs the given KQL query against the Log Analytics workspace with the given workspace (customer)
+// ID over the last window of time, and returns the rows of the primary result table.
+func QueryLogAnalyticsE(t testing.TestingT, workspaceID string, kql string, window time.Duration) ([]LogAnalyticsRow, error) {
+	client, err := GetLogAnalyticsQueryClientE()
 	if err != nil {
-		fmt.Println("Workspace client error getting subscription")
 		return nil, err
 	}
 
-	client := operationalinsights.NewWorkspacesClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
+	end := time.Now().UTC()
+	timespan := azquery.NewTimeInterval(end.Add(-window), end)
+	resp, err := client.QueryWorkspace(context.Background(), workspaceID, azquery.Body{Query: &kql, Timespan: &timespan}, nil)
 	if err != nil {
-		fmt.Println("authorizer error")
 		return nil, err
 	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	if len(resp.Tables) == 0 {
+		return []LogAnalyticsRow{}, nil
+	}
+
+	return convertLogAnalyticsTableE(resp.Tables[0])
+}
+
+// QueryLogAnalyticsUntilRowMatches runs the given KQL query until it returns a row for which matches returns true, and
+// returns that row. Use it to wait for logs to be ingested, which usually takes a few minutes.
+// This function would fail the test if there is an error or no row matches within the given retries.
+func QueryLogAnalyticsUntilRowMatches(t testing.TestingT, workspaceID string, kql string, window time.Duration, matches func(LogAnalyticsRow) bool, maxRetries int, sleepBetweenRetries time.Duration) LogAnalyticsRow {
+	row, err := QueryLogAnalyticsUntilRowMatchesE(t, workspaceID, kql, window, matches, maxRetries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return row
+}
+
+// QueryLogAnalyticsUntilRowMatchesE runs the given KQL query until it returns a row for which matches returns true,
+// and returns that row. Use it to wait for logs to be ingested, which usually takes a few minutes.
+func QueryLogAnalyticsUntilRowMatchesE(t testing.TestingT, workspaceID string, kql string, window time.Duration, matches func(LogAnalyticsRow) bool, maxRetries int, sleepBetweenRetries time.Duration) (LogAnalyticsRow, error) {
+	description := fmt.Sprintf("Query Log Analytics workspace %s for a matching row", workspaceID)
+	row, err := retry.DoWithRetryInterfaceE(t, description, maxRetries, sleepBetweenRetries, func() (interface{}, error) {
+		rows, err := QueryLogAnalyticsE(t, workspaceID, kql, window)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if matches(row) {
+				return row, nil
+			}
+		}
+		return nil, fmt.Errorf("none of the %d rows returned by the query matched", len(rows))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return row.(LogAnalyticsRow), nil
+}
+
+// GetLogAnalyticsQueryClientE returns a Log Analytics query client for the Azure environment that is currently setup.
+// Azure Stack environments have no Log Analytics query endpoint.
+func GetLogAnalyticsQueryClientE() (*azquery.LogsClient, error) {
+	return GetLogAnalyticsQueryClientWithOptionsE(authOptionsFromEnv())
+}
+
+// GetLogAnalyticsQueryClientWithOptionsE returns a Log Analytics query client for the Azure environment that is
+// currently setup, authenticated according to the given AuthOptions.
+func GetLogAnalyticsQueryClientWithOptionsE(authOptions AuthOptions) (*azquery.LogsClient, error) {
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCloudConfig, err := getClientCloudConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return azquery.NewLogsClient(cred, &azquery.LogsClientOptions{
+		ClientOptions: azcore.ClientOptions{Cloud: clientCloudConfig},
+	})
+}
+
+// convertLogAnalyticsTableE converts the rows of the given query result table to LogAnalyticsRows.
+func convertLogAnalyticsTableE(table *azquery.Table) ([]LogAnalyticsRow, error) {
+	rows := make([]LogAnalyticsRow, 0, len(table.Rows))
+	for _, values := range table.Rows {
+		if len(values) != len(table.Columns) {
+			return nil, fmt.Errorf("query result row has %d values, but the table has %d columns", len(values), len(table.Columns))
+		}
+
+		row := LogAnalyticsRow{}
+		for i, column := range table.Columns {
+			value, err := convertLogAnalyticsValueE(safeEnumPtrToString(column.Type), values[i])
+			if err != nil {
+				return nil, NewFailedToParseError("Log Analytics column", safePtrToString(column.Name))
+			}
+			row[safePtrToString(column.Name)] = value
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// convertLogAnalyticsValueE converts a value decoded from the JSON of a query result to the Go type of its column.
+func convertLogAnalyticsValueE(columnType string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch azquery.LogsColumnType(columnType) {
+	case azquery.LogsColumnTypeDatetime:
+		return time.Parse(time.RFC3339Nano, fmt.Sprint(value))
+	case azquery.LogsColumnTypeInt, azquery.LogsColumnTypeLong:
+		if number, ok := value.(float64); ok {
+			return int64(number), nil
+		}
+		return strconv.ParseInt(fmt.Sprint(value), 10, 64)
+	case azquery.LogsColumnTypeReal, azquery.LogsColumnTypeDecimal:
+		if number, ok := value.(float64); ok {
+			return number, nil
+		}
+		return strconv.ParseFloat(fmt.Sprint(value), 64)
+	case azquery.LogsColumnTypeBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return strconv.ParseBool(fmt.Sprint(value))
+	case azquery.LogsColumnTypeTimespan:
+		return parseLogAnalyticsTimespanE(fmt.Sprint(value))
+	case azquery.LogsColumnTypeDynamic:
+		str, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(str), &decoded); err != nil {
+			return str, nil
+		}
+		return decoded, nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+// parseLogAnalyticsTimespanE parses a KQL timespan in the [-][d.]hh:mm:ss[.fffffff] format.
+func parseLogAnalyticsTimespanE(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	str := value
+	if strings.HasPrefix(str, "-") {
+		sign = -1
+		str = str[1:]
+	}
+
+	parts := strings.Split(str, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid timespan %q", value)
+	}
+
+	var days, hours int64
+	var err error
+	if dayParts := strings.SplitN(parts[0], ".", 2); len(dayParts) == 2 {
+		if days, err = strconv.ParseInt(dayParts[0], 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid timespan %q: %v", value, err)
+		}
+		parts[0] = dayParts[1]
+	}
+	if hours, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, fmt.Errorf("invalid timespan %q: %v", value, err)
+	}
+	minutes, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timespan %q: %v", value, err)
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timespan %q: %v", value, err)
+	}
 
-	client.Authorizer = *authorizer
-	return &client, nil
+	duration := time.Duration(days)*24*time.Hour +
+		time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second))
+	return sign * duration, nil
 }
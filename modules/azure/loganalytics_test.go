@@ -2,7 +2,9 @@ package azure
 
 import (
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/monitor/azquery"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,3 +30,68 @@ func TestGetLogAnalyticsWorkspaceE(t *testing.T) {
 	_, err := GetLogAnalyticsWorkspaceE(workspaceName, resourceGroupName, subscriptionID)
 	require.Error(t, err)
 }
+
+func TestConvertLogAnalyticsTable(t *testing.T) {
+	t.Parallel()
+
+	column := func(name string, columnType azquery.LogsColumnType) *azquery.Column {
+		return &azquery.Column{Name: &name, Type: &columnType}
+	}
+	table := &azquery.Table{
+		Columns: []*azquery.Column{
+			column("TimeGenerated", azquery.LogsColumnTypeDatetime),
+			column("Count", azquery.LogsColumnTypeLong),
+			column("Duration", azquery.LogsColumnTypeReal),
+			column("Success", azquery.LogsColumnTypeBool),
+			column("Elapsed", azquery.LogsColumnTypeTimespan),
+			column("Properties", azquery.LogsColumnTypeDynamic),
+			column("Message", azquery.LogsColumnTypeString),
+		},
+		Rows: []azquery.Row{
+			{"2024-05-01T10:00:00.5Z", float64(42), 1.5, true, "1.02:03:04.5", `{"key":"value"}`, "hello"},
+			{nil, nil, nil, nil, nil, nil, nil},
+		},
+	}
+
+	rows, err := convertLogAnalyticsTableE(table)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 500000000, time.UTC), rows[0]["TimeGenerated"])
+	assert.Equal(t, int64(42), rows[0]["Count"])
+	assert.Equal(t, 1.5, rows[0]["Duration"])
+	assert.Equal(t, true, rows[0]["Success"])
+	assert.Equal(t, 26*time.Hour+3*time.Minute+4500*time.Millisecond, rows[0]["Elapsed"])
+	assert.Equal(t, map[string]interface{}{"key": "value"}, rows[0]["Properties"])
+	assert.Equal(t, "hello", rows[0]["Message"])
+	assert.Nil(t, rows[1]["Count"])
+
+	table.Rows = []azquery.Row{{"2024-05-01T10:00:00Z"}}
+	_, err = convertLogAnalyticsTableE(table)
+	require.Error(t, err)
+}
+
+func TestParseLogAnalyticsTimespan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "00:05:00", want: 5 * time.Minute},
+		{value: "2.00:00:00", want: 48 * time.Hour},
+		{value: "-00:00:01.25", want: -1250 * time.Millisecond},
+		{value: "5 minutes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLogAnalyticsTimespanE(tt.value)
+		if tt.wantErr {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
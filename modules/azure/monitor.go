@@ -2,8 +2,11 @@ package azure
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/preview/preview/monitor/mgmt/insights"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -32,14 +35,14 @@ func DiagnosticSettingsResourceExistsE(diagnosticSettingsResourceName string, re
 
 // GetDiagnosticsSettingsResource gets the diagnostics settings for a specified resource
 // This function would fail the test if there is an error.
-func GetDiagnosticsSettingsResource(t testing.TestingT, name string, resourceURI string, subscriptionID string) *insights.DiagnosticSettingsResource {
+func GetDiagnosticsSettingsResource(t testing.TestingT, name string, resourceURI string, subscriptionID string) *armmonitor.DiagnosticSettingsResource {
 	resource, err := GetDiagnosticsSettingsResourceE(name, resourceURI, subscriptionID)
 	require.NoError(t, err)
 	return resource
 }
 
 // GetDiagnosticsSettingsResourceE gets the diagnostics settings for a specified resource
-func GetDiagnosticsSettingsResourceE(name string, resourceURI string, subscriptionID string) (*insights.DiagnosticSettingsResource, error) {
+func GetDiagnosticsSettingsResourceE(name string, resourceURI string, subscriptionID string) (*armmonitor.DiagnosticSettingsResource, error) {
 	// Validate Azure subscription ID
 	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
 	if err != nil {
@@ -51,37 +54,23 @@ func GetDiagnosticsSettingsResourceE(name string, resourceURI string, subscripti
 		return nil, err
 	}
 
-	settings, err := client.Get(context.Background(), resourceURI, name)
+	settings, err := client.Get(context.Background(), resourceURI, name, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &settings, nil
+	return &settings.DiagnosticSettingsResource, nil
 }
 
 // GetDiagnosticsSettingsClientE returns a diagnostics settings client
 // TODO: delete in next version
-func GetDiagnosticsSettingsClientE(subscriptionID string) (*insights.DiagnosticSettingsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	client := insights.NewDiagnosticSettingsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	client.Authorizer = *authorizer
-
-	return &client, nil
+func GetDiagnosticsSettingsClientE(subscriptionID string) (*armmonitor.DiagnosticSettingsClient, error) {
+	return CreateDiagnosticsSettingsClientE(subscriptionID)
 }
 
 // GetVMInsightsOnboardingStatus get diagnostics VM onboarding status
 // This function would fail the test if there is an error.
-func GetVMInsightsOnboardingStatus(t testing.TestingT, resourceURI string, subscriptionID string) *insights.VMInsightsOnboardingStatus {
+func GetVMInsightsOnboardingStatus(t testing.TestingT, resourceURI string, subscriptionID string) *armmonitor.VMInsightsOnboardingStatus {
 	status, err := GetVMInsightsOnboardingStatusE(t, resourceURI, subscriptionID)
 	require.NoError(t, err)
 
@@ -89,44 +78,29 @@ func GetVMInsightsOnboardingStatus(t testing.TestingT, resourceURI string, subsc
 }
 
 // GetVMInsightsOnboardingStatusE get diagnostics VM onboarding status
-func GetVMInsightsOnboardingStatusE(t testing.TestingT, resourceURI string, subscriptionID string) (*insights.VMInsightsOnboardingStatus, error) {
+func GetVMInsightsOnboardingStatusE(t testing.TestingT, resourceURI string, subscriptionID string) (*armmonitor.VMInsightsOnboardingStatus, error) {
 	client, err := CreateVMInsightsClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	status, err := client.GetOnboardingStatus(context.Background(), resourceURI)
+	status, err := client.GetOnboardingStatus(context.Background(), resourceURI, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &status, nil
+	return &status.VMInsightsOnboardingStatus, nil
 }
 
 // GetVMInsightsClientE gets a VM Insights client
 // TODO: delete in next version
-func GetVMInsightsClientE(t testing.TestingT, subscriptionID string) (*insights.VMInsightsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	client := insights.NewVMInsightsClient(subscriptionID)
-
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	client.Authorizer = *authorizer
-
-	return &client, nil
+func GetVMInsightsClientE(t testing.TestingT, subscriptionID string) (*armmonitor.VMInsightsClient, error) {
+	return CreateVMInsightsClientE(subscriptionID)
 }
 
 // GetActivityLogAlertResource gets a Action Group in the specified Azure Resource Group
 // This function would fail the test if there is an error.
-func GetActivityLogAlertResource(t testing.TestingT, activityLogAlertName string, resGroupName string, subscriptionID string) *insights.ActivityLogAlertResource {
+func GetActivityLogAlertResource(t testing.TestingT, activityLogAlertName string, resGroupName string, subscriptionID string) *armmonitor.ActivityLogAlertResource {
 	activityLogAlertResource, err := GetActivityLogAlertResourceE(activityLogAlertName, resGroupName, subscriptionID)
 	require.NoError(t, err)
 
@@ -134,7 +108,7 @@ func GetActivityLogAlertResource(t testing.TestingT, activityLogAlertName string
 }
 
 // GetActivityLogAlertResourceE gets a Action Group in the specified Azure Resource Group
-func GetActivityLogAlertResourceE(activityLogAlertName string, resGroupName string, subscriptionID string) (*insights.ActivityLogAlertResource, error) {
+func GetActivityLogAlertResourceE(activityLogAlertName string, resGroupName string, subscriptionID string) (*armmonitor.ActivityLogAlertResource, error) {
 	// Validate resource group name and subscription ID
 	_, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -148,33 +122,130 @@ func GetActivityLogAlertResourceE(activityLogAlertName string, resGroupName stri
 	}
 
 	// Get the Action Group
-	activityLogAlertResource, err := client.Get(context.Background(), resGroupName, activityLogAlertName)
+	activityLogAlertResource, err := client.Get(context.Background(), resGroupName, activityLogAlertName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &activityLogAlertResource, nil
+	return &activityLogAlertResource.ActivityLogAlertResource, nil
 }
 
 // GetActivityLogAlertsClientE gets an Action Groups client in the specified Azure Subscription
 // TODO: delete in next version
-func GetActivityLogAlertsClientE(subscriptionID string) (*insights.ActivityLogAlertsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+func GetActivityLogAlertsClientE(subscriptionID string) (*armmonitor.ActivityLogAlertsClient, error) {
+	// Get the Action Groups client
+	return CreateActivityLogAlertsClientE(subscriptionID)
+}
+
+// MetricDataPoint is an aggregated value of an Azure Monitor metric over one time grain.
+type MetricDataPoint struct {
+	TimeStamp time.Time
+	Value     float64
+}
+
+// GetResourceMetricValues gets the data points of the given metric of the resource with the given ID over the last
+// window of time, aggregated with the given aggregation (Average, Total, Minimum, Maximum or Count). Time grains
+// without data are omitted.
+// This function would fail the test if there is an error.
+func GetResourceMetricValues(t testing.TestingT, resourceID string, metricName string, aggregation string, window time.Duration, subscriptionID string) []MetricDataPoint {
+	values, err := GetResourceMetricValuesE(resourceID, metricName, aggregation, window, subscriptionID)
+	require.NoError(t, err)
+
+	return values
+}
+
+// GetResourceMetricValuesE gets the data points of the given metric of the resource with the given ID over the last
+// window of time, aggregated with the given aggregation (Average, Total, Minimum, Maximum or Count). Time grains
+// without data are omitted.
+func GetResourceMetricValuesE(resourceID string, metricName string, aggregation string, window time.Duration, subscriptionID string) ([]MetricDataPoint, error) {
+	client, err := CreateMetricsClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the Action Groups client
-	client := insights.NewActivityLogAlertsClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
+	end := time.Now().UTC()
+	timespan := fmt.Sprintf("%s/%s", end.Add(-window).Format(time.RFC3339), end.Format(time.RFC3339))
+	resp, err := client.List(context.Background(), resourceID, &armmonitor.MetricsClientListOptions{
+		Timespan:    &timespan,
+		Metricnames: &metricName,
+		Aggregation: &aggregation,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	client.Authorizer = *authorizer
+	return metricDataPointsE(resp.Value, metricName, aggregation)
+}
 
-	return &client, nil
+// GetResourceMetricLatestValue gets the most recent data point of the given metric of the resource with the given ID
+// over the last window of time, aggregated with the given aggregation (Average, Total, Minimum, Maximum or Count).
+// This function would fail the test if there is an error.
+func GetResourceMetricLatestValue(t testing.TestingT, resourceID string, metricName string, aggregation string, window time.Duration, subscriptionID string) float64 {
+	value, err := GetResourceMetricLatestValueE(resourceID, metricName, aggregation, window, subscriptionID)
+	require.NoError(t, err)
+
+	return value
+}
+
+// GetResourceMetricLatestValueE gets the most recent data point of the given metric of the resource with the given
+// ID over the last window of time, aggregated with the given aggregation (Average, Total, Minimum, Maximum or Count).
+func GetResourceMetricLatestValueE(resourceID string, metricName string, aggregation string, window time.Duration, subscriptionID string) (float64, error) {
+	values, err := GetResourceMetricValuesE(resourceID, metricName, aggregation, window, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest *MetricDataPoint
+	for i := range values {
+		if latest == nil || values[i].TimeStamp.After(latest.TimeStamp) {
+			latest = &values[i]
+		}
+	}
+	if latest == nil {
+		return 0, NewNotFoundError("Metric data", metricName, resourceID)
+	}
+
+	return latest.Value, nil
+}
+
+// metricDataPointsE returns the data points of the given metric in the given aggregation across all its time series.
+func metricDataPointsE(metrics []*armmonitor.Metric, metricName string, aggregation string) ([]MetricDataPoint, error) {
+	dataPoints := []MetricDataPoint{}
+	for _, metric := range metrics {
+		if metric == nil || metric.Name == nil || !strings.EqualFold(safePtrToString(metric.Name.Value), metricName) {
+			continue
+		}
+		for _, series := range metric.Timeseries {
+			for _, data := range series.Data {
+				value, err := metricAggregateValueE(data, aggregation)
+				if err != nil {
+					return nil, err
+				}
+				if value == nil || data.TimeStamp == nil {
+					continue
+				}
+				dataPoints = append(dataPoints, MetricDataPoint{TimeStamp: *data.TimeStamp, Value: *value})
+			}
+		}
+	}
+
+	return dataPoints, nil
+}
+
+// metricAggregateValueE returns the value of the given aggregation of a metric data point.
+func metricAggregateValueE(data *armmonitor.MetricValue, aggregation string) (*float64, error) {
+	switch strings.ToLower(aggregation) {
+	case "average":
+		return data.Average, nil
+	case "total":
+		return data.Total, nil
+	case "minimum":
+		return data.Minimum, nil
+	case "maximum":
+		return data.Maximum, nil
+	case "count":
+		return data.Count, nil
+	default:
+		return nil, fmt.Errorf("unknown metric aggregation %q", aggregation)
+	}
 }
@@ -5,7 +5,10 @@ package azure
 
 import (
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,3 +22,38 @@ func TestDiagnosticsSettingsResourceExists(t *testing.T) {
 	_, err := DiagnosticSettingsResourceExistsE(diagnosticsSettingResourceName, resGroupName, subscriptionID)
 	require.Error(t, err)
 }
+
+func TestMetricDataPoints(t *testing.T) {
+	t.Parallel()
+
+	name := "Requests"
+	otherName := "Errors"
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	total := 3.0
+	metrics := []*armmonitor.Metric{
+		{
+			Name: &armmonitor.LocalizableString{Value: &name},
+			Timeseries: []*armmonitor.TimeSeriesElement{
+				{Data: []*armmonitor.MetricValue{{TimeStamp: &first, Total: &total}, {TimeStamp: &second}}},
+			},
+		},
+		{
+			Name: &armmonitor.LocalizableString{Value: &otherName},
+			Timeseries: []*armmonitor.TimeSeriesElement{
+				{Data: []*armmonitor.MetricValue{{TimeStamp: &second, Total: &total}}},
+			},
+		},
+	}
+
+	dataPoints, err := metricDataPointsE(metrics, "requests", "Total")
+	require.NoError(t, err)
+	assert.Equal(t, []MetricDataPoint{{TimeStamp: first, Value: 3}}, dataPoints)
+
+	dataPoints, err = metricDataPointsE(metrics, "Requests", "Average")
+	require.NoError(t, err)
+	assert.Empty(t, dataPoints)
+
+	_, err = metricDataPointsE(metrics, "Requests", "Median")
+	require.Error(t, err)
+}
@@ -3,38 +3,21 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/mysql/mgmt/mysql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysql"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
 // GetMYSQLServerClientE is a helper function that will setup a mysql server client.
 // TODO: remove in next version
-func GetMYSQLServerClientE(subscriptionID string) (*mysql.ServersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetMYSQLServerClientE(subscriptionID string) (*armmysql.ServersClient, error) {
 	// Create a mysql server client
-	mysqlClient := mysql.NewServersClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	mysqlClient.Authorizer = *authorizer
-
-	return &mysqlClient, nil
+	return CreateMySQLServerClientE(subscriptionID)
 }
 
 // GetMYSQLServer is a helper function that gets the server.
 // This function would fail the test if there is an error.
-func GetMYSQLServer(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *mysql.Server {
+func GetMYSQLServer(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *armmysql.Server {
 	mysqlServer, err := GetMYSQLServerE(t, subscriptionID, resGroupName, serverName)
 	require.NoError(t, err)
 
@@ -42,7 +25,7 @@ func GetMYSQLServer(t testing.TestingT, resGroupName string, serverName string,
 }
 
 // GetMYSQLServerE is a helper function that gets the server.
-func GetMYSQLServerE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) (*mysql.Server, error) {
+func GetMYSQLServerE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) (*armmysql.Server, error) {
 	// Create a mySQl Server client
 	mysqlClient, err := CreateMySQLServerClientE(subscriptionID)
 	if err != nil {
@@ -50,41 +33,24 @@ func GetMYSQLServerE(t testing.TestingT, subscriptionID string, resGroupName str
 	}
 
 	// Get the corresponding server client
-	mysqlServer, err := mysqlClient.Get(context.Background(), resGroupName, serverName)
+	mysqlServer, err := mysqlClient.Get(context.Background(), resGroupName, serverName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return server
-	return &mysqlServer, nil
+	return &mysqlServer.Server, nil
 }
 
 // GetMYSQLDBClientE is a helper function that will setup a mysql DB client.
-func GetMYSQLDBClientE(subscriptionID string) (*mysql.DatabasesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetMYSQLDBClientE(subscriptionID string) (*armmysql.DatabasesClient, error) {
 	// Create a mysql db client
-	mysqlDBClient := mysql.NewDatabasesClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	mysqlDBClient.Authorizer = *authorizer
-
-	return &mysqlDBClient, nil
+	return CreateMySQLDatabasesClientE(subscriptionID)
 }
 
 // GetMYSQLDB is a helper function that gets the database.
 // This function would fail the test if there is an error.
-func GetMYSQLDB(t testing.TestingT, resGroupName string, serverName string, dbName string, subscriptionID string) *mysql.Database {
+func GetMYSQLDB(t testing.TestingT, resGroupName string, serverName string, dbName string, subscriptionID string) *armmysql.Database {
 	database, err := GetMYSQLDBE(t, subscriptionID, resGroupName, serverName, dbName)
 	require.NoError(t, err)
 
@@ -92,7 +58,7 @@ func GetMYSQLDB(t testing.TestingT, resGroupName string, serverName string, dbNa
 }
 
 // GetMYSQLDBE is a helper function that gets the database.
-func GetMYSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string, dbName string) (*mysql.Database, error) {
+func GetMYSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string, dbName string) (*armmysql.Database, error) {
 	// Create a mySQl db client
 	mysqldbClient, err := GetMYSQLDBClientE(subscriptionID)
 	if err != nil {
@@ -100,17 +66,17 @@ func GetMYSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string,
 	}
 
 	// Get the corresponding db client
-	mysqlDb, err := mysqldbClient.Get(context.Background(), resGroupName, serverName, dbName)
+	mysqlDb, err := mysqldbClient.Get(context.Background(), resGroupName, serverName, dbName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return DB
-	return &mysqlDb, nil
+	return &mysqlDb.Database, nil
 }
 
 // ListMySQLDB is a helper function that gets all databases per server.
-func ListMySQLDB(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) []mysql.Database {
+func ListMySQLDB(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) []armmysql.Database {
 	dblist, err := ListMySQLDBE(t, subscriptionID, resGroupName, serverName)
 	require.NoError(t, err)
 
@@ -118,7 +84,7 @@ func ListMySQLDB(t testing.TestingT, resGroupName string, serverName string, sub
 }
 
 // ListMySQLDBE is a helper function that gets all databases per server.
-func ListMySQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) ([]mysql.Database, error) {
+func ListMySQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) ([]armmysql.Database, error) {
 	// Create a mySQl db client
 	mysqldbClient, err := GetMYSQLDBClientE(subscriptionID)
 	if err != nil {
@@ -126,11 +92,18 @@ func ListMySQLDBE(t testing.TestingT, subscriptionID string, resGroupName string
 	}
 
 	// Get the corresponding db client
-	mysqlDbs, err := mysqldbClient.ListByServer(context.Background(), resGroupName, serverName)
-	if err != nil {
-		return nil, err
+	var mysqlDbs []armmysql.Database
+	pager := mysqldbClient.NewListByServerPager(resGroupName, serverName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range page.Value {
+			mysqlDbs = append(mysqlDbs, *db)
+		}
 	}
 
 	//Return DB lists
-	return *mysqlDbs.Value, nil
+	return mysqlDbs, nil
 }
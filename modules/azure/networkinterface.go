@@ -3,7 +3,7 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -49,8 +49,8 @@ func GetNetworkInterfacePrivateIPsE(nicName string, resGroupName string, subscri
 	}
 
 	// Get the Private IPs from each configuration
-	for _, IPConfiguration := range *nic.IPConfigurations {
-		privateIPs = append(privateIPs, *IPConfiguration.PrivateIPAddress)
+	for _, IPConfiguration := range nic.Properties.IPConfigurations {
+		privateIPs = append(privateIPs, *IPConfiguration.Properties.PrivateIPAddress)
 	}
 
 	return privateIPs, nil
@@ -75,13 +75,13 @@ func GetNetworkInterfacePublicIPsE(nicName string, resGroupName string, subscrip
 	}
 
 	// Get the Public IPs from each configuration available
-	for _, IPConfiguration := range *nic.IPConfigurations {
+	for _, IPConfiguration := range nic.Properties.IPConfigurations {
 		// Iterate each config, for successful configurations check for a Public Address reference.
 		// Not failing on errors as this is an optimistic accumulator.
 		nicConfig, err := GetNetworkInterfaceConfigurationE(nicName, *IPConfiguration.Name, resGroupName, subscriptionID)
 		if err == nil {
-			if nicConfig.PublicIPAddress != nil {
-				publicAddressID := GetNameFromResourceID(*nicConfig.PublicIPAddress.ID)
+			if nicConfig.Properties != nil && nicConfig.Properties.PublicIPAddress != nil {
+				publicAddressID := GetNameFromResourceID(*nicConfig.Properties.PublicIPAddress.ID)
 				publicIP, err := GetIPOfPublicIPAddressByNameE(publicAddressID, resGroupName, subscriptionID)
 				if err == nil {
 					publicIPs = append(publicIPs, publicIP)
@@ -94,7 +94,7 @@ func GetNetworkInterfacePublicIPsE(nicName string, resGroupName string, subscrip
 }
 
 // GetNetworkInterfaceConfigurationE gets a Network Interface Configuration in the specified Azure Resource Group.
-func GetNetworkInterfaceConfigurationE(nicName string, nicConfigName string, resGroupName string, subscriptionID string) (*network.InterfaceIPConfiguration, error) {
+func GetNetworkInterfaceConfigurationE(nicName string, nicConfigName string, resGroupName string, subscriptionID string) (*armnetwork.InterfaceIPConfiguration, error) {
 	// Validate Azure Resource Group
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -108,34 +108,22 @@ func GetNetworkInterfaceConfigurationE(nicName string, nicConfigName string, res
 	}
 
 	// Get the Network Interface
-	nicConfig, err := client.Get(context.Background(), resGroupName, nicName, nicConfigName)
+	nicConfig, err := client.Get(context.Background(), resGroupName, nicName, nicConfigName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &nicConfig, nil
+	return &nicConfig.InterfaceIPConfiguration, nil
 }
 
 // GetNetworkInterfaceConfigurationClientE creates a new Network Interface Configuration client in the specified Azure Subscription.
-func GetNetworkInterfaceConfigurationClientE(subscriptionID string) (*network.InterfaceIPConfigurationsClient, error) {
+func GetNetworkInterfaceConfigurationClientE(subscriptionID string) (*armnetwork.InterfaceIPConfigurationsClient, error) {
 	// Create a new client from client factory
-	client, err := CreateNewNetworkInterfaceIPConfigurationClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return client, nil
+	return CreateNewNetworkInterfaceIPConfigurationClientE(subscriptionID)
 }
 
 // GetNetworkInterfaceE gets a Network Interface in the specified Azure Resource Group.
-func GetNetworkInterfaceE(nicName string, resGroupName string, subscriptionID string) (*network.Interface, error) {
+func GetNetworkInterfaceE(nicName string, resGroupName string, subscriptionID string) (*armnetwork.Interface, error) {
 	// Validate Azure Resource Group
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -149,28 +137,16 @@ func GetNetworkInterfaceE(nicName string, resGroupName string, subscriptionID st
 	}
 
 	// Get the Network Interface
-	nic, err := client.Get(context.Background(), resGroupName, nicName, "")
+	nic, err := client.Get(context.Background(), resGroupName, nicName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &nic, nil
+	return &nic.Interface, nil
 }
 
 // GetNetworkInterfaceClientE creates a new Network Interface client in the specified Azure Subscription.
-func GetNetworkInterfaceClientE(subscriptionID string) (*network.InterfacesClient, error) {
+func GetNetworkInterfaceClientE(subscriptionID string) (*armnetwork.InterfacesClient, error) {
 	// Create new NIC client from client factory
-	client, err := CreateNewNetworkInterfacesClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return client, nil
+	return CreateNewNetworkInterfacesClientE(subscriptionID)
 }
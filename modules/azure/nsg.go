@@ -3,11 +3,13 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -40,7 +42,7 @@ type NsgRuleSummary struct {
 // defined on an network security group. Note that the "default" rules are those provided implicitly
 // by the Azure platform.
 // This function would fail the test if there is an error.
-func GetDefaultNsgRulesClient(t *testing.T, subscriptionID string) network.DefaultSecurityRulesClient {
+func GetDefaultNsgRulesClient(t *testing.T, subscriptionID string) *armnetwork.DefaultSecurityRulesClient {
 	client, err := GetDefaultNsgRulesClientE(subscriptionID)
 	require.NoError(t, err)
 	return client
@@ -49,28 +51,16 @@ func GetDefaultNsgRulesClient(t *testing.T, subscriptionID string) network.Defau
 // GetDefaultNsgRulesClientE returns a rules client which can be used to read the list of *default* security rules
 // defined on an network security group. Note that the "default" rules are those provided implicitly
 // by the Azure platform.
-func GetDefaultNsgRulesClientE(subscriptionID string) (network.DefaultSecurityRulesClient, error) {
+func GetDefaultNsgRulesClientE(subscriptionID string) (*armnetwork.DefaultSecurityRulesClient, error) {
 	// Get new default client from client factory
-	nsgClient, err := CreateNsgDefaultRulesClientE(subscriptionID)
-	if err != nil {
-		return network.DefaultSecurityRulesClient{}, err
-	}
-
-	// Get an authorizer
-	auth, err := NewAuthorizer()
-	if err != nil {
-		return network.DefaultSecurityRulesClient{}, err
-	}
-
-	nsgClient.Authorizer = *auth
-	return *nsgClient, nil
+	return CreateNsgDefaultRulesClientE(subscriptionID)
 }
 
 // GetCustomNsgRulesClient returns a rules client which can be used to read the list of *custom* security rules
 // defined on an network security group. Note that the "custom" rules are those defined by
 // end users.
 // This function would fail the test if there is an error.
-func GetCustomNsgRulesClient(t *testing.T, subscriptionID string) network.SecurityRulesClient {
+func GetCustomNsgRulesClient(t *testing.T, subscriptionID string) *armnetwork.SecurityRulesClient {
 	client, err := GetCustomNsgRulesClientE(subscriptionID)
 	require.NoError(t, err)
 	return client
@@ -79,21 +69,9 @@ func GetCustomNsgRulesClient(t *testing.T, subscriptionID string) network.Securi
 // GetCustomNsgRulesClientE returns a rules client which can be used to read the list of *custom* security rules
 // defined on an network security group. Note that the "custom" rules are those defined by
 // end users.
-func GetCustomNsgRulesClientE(subscriptionID string) (network.SecurityRulesClient, error) {
+func GetCustomNsgRulesClientE(subscriptionID string) (*armnetwork.SecurityRulesClient, error) {
 	// Get new custom rules client from client factory
-	nsgClient, err := CreateNsgCustomRulesClientE(subscriptionID)
-	if err != nil {
-		return network.SecurityRulesClient{}, err
-	}
-
-	// Get an authorizer
-	auth, err := NewAuthorizer()
-	if err != nil {
-		return network.SecurityRulesClient{}, err
-	}
-
-	nsgClient.Authorizer = *auth
-	return *nsgClient, nil
+	return CreateNsgCustomRulesClientE(subscriptionID)
 }
 
 // GetAllNSGRules returns an NsgRuleSummaryList instance containing the combined "default" and "custom" rules from a network
@@ -119,59 +97,123 @@ func GetAllNSGRulesE(resourceGroupName, nsgName, subscriptionID string) (NsgRule
 		return NsgRuleSummaryList{}, err
 	}
 
-	// Read all default (platform) rules.
-	defaultRuleList, err := defaultRulesClient.ListComplete(context.Background(), resourceGroupName, nsgName)
-	if err != nil {
-		return NsgRuleSummaryList{}, err
+	// Read all default (platform) rules and convert them to our summary type
+	boundDefaultRules := make([]NsgRuleSummary, 0)
+	defaultPager := defaultRulesClient.NewListPager(resourceGroupName, nsgName, nil)
+	for defaultPager.More() {
+		page, err := defaultPager.NextPage(context.Background())
+		if err != nil {
+			return NsgRuleSummaryList{}, err
+		}
+		boundDefaultRules = append(boundDefaultRules, bindRuleList(page.Value)...)
+	}
+
+	// Read any custom (user provided) rules and convert them to our summary type
+	boundCustomRules := make([]NsgRuleSummary, 0)
+	customPager := customRulesClient.NewListPager(resourceGroupName, nsgName, nil)
+	for customPager.More() {
+		page, err := customPager.NextPage(context.Background())
+		if err != nil {
+			return NsgRuleSummaryList{}, err
+		}
+		boundCustomRules = append(boundCustomRules, bindRuleList(page.Value)...)
 	}
 
-	// Read any custom (user provided) rules
-	customRuleList, err := customRulesClient.ListComplete(context.Background(), resourceGroupName, nsgName)
+	// Join the summarized lists and wrap in NsgRuleSummaryList struct
+	allRules := append(boundDefaultRules, boundCustomRules...)
+	ruleList := NsgRuleSummaryList{}
+	ruleList.SummarizedRules = allRules
+	return ruleList, nil
+}
+
+// GetNetworkInterfaceEffectiveNsgRules returns the effective security rules applied to the given network interface:
+// one NsgRuleSummaryList for each network security group associated with the interface or its subnet. Traffic has to
+// be allowed by each of them.
+func GetNetworkInterfaceEffectiveNsgRules(t *testing.T, nicName string, resourceGroupName string, subscriptionID string) []NsgRuleSummaryList {
+	results, err := GetNetworkInterfaceEffectiveNsgRulesE(nicName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return results
+}
+
+// GetNetworkInterfaceEffectiveNsgRulesE returns the effective security rules applied to the given network interface:
+// one NsgRuleSummaryList for each network security group associated with the interface or its subnet. Service tags
+// in the rules are expanded to the address prefixes they stand for. The interface must be attached to a running VM.
+func GetNetworkInterfaceEffectiveNsgRulesE(nicName string, resourceGroupName string, subscriptionID string) ([]NsgRuleSummaryList, error) {
+	resourceGroupName, err := getTargetAzureResourceGroupName(resourceGroupName)
 	if err != nil {
-		return NsgRuleSummaryList{}, err
+		return nil, err
 	}
 
-	// Convert the default list to our summary type
-	boundDefaultRules, err := bindRuleList(defaultRuleList)
+	client, err := GetNetworkInterfaceClientE(subscriptionID)
 	if err != nil {
-		return NsgRuleSummaryList{}, err
+		return nil, err
 	}
 
-	// Convert the custom list to our summary type
-	boundCustomRules, err := bindRuleList(customRuleList)
+	poller, err := client.BeginListEffectiveNetworkSecurityGroups(context.Background(), resourceGroupName, nicName, nil)
 	if err != nil {
-		return NsgRuleSummaryList{}, err
+		return nil, err
+	}
+	result, err := poller.PollUntilDone(context.Background(), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// Join the summarized lists and wrap in NsgRuleSummaryList struct
-	allRules := append(boundDefaultRules, boundCustomRules...)
-	ruleList := NsgRuleSummaryList{}
-	ruleList.SummarizedRules = allRules
-	return ruleList, nil
+	ruleLists := []NsgRuleSummaryList{}
+	for _, nsg := range result.Value {
+		if nsg == nil {
+			continue
+		}
+		ruleList := NsgRuleSummaryList{SummarizedRules: []NsgRuleSummary{}}
+		for _, rule := range nsg.EffectiveSecurityRules {
+			if rule != nil {
+				ruleList.SummarizedRules = append(ruleList.SummarizedRules, convertEffectiveRuleToNsgRuleSummary(rule))
+			}
+		}
+		ruleLists = append(ruleLists, ruleList)
+	}
+	return ruleLists, nil
+}
+
+// convertEffectiveRuleToNsgRuleSummary converts an effective security rule into a summarized struct, with the service
+// tags in its address prefixes expanded.
+func convertEffectiveRuleToNsgRuleSummary(rule *armnetwork.EffectiveNetworkSecurityRule) NsgRuleSummary {
+	summary := NsgRuleSummary{}
+	summary.Name = safePtrToString(rule.Name)
+	summary.Protocol = safeEnumPtrToString(rule.Protocol)
+	summary.SourcePortRange = safePtrToString(rule.SourcePortRange)
+	summary.SourcePortRanges = safePtrToList(rule.SourcePortRanges)
+	summary.DestinationPortRange = safePtrToString(rule.DestinationPortRange)
+	summary.DestinationPortRanges = safePtrToList(rule.DestinationPortRanges)
+	summary.SourceAddressPrefix = safePtrToString(rule.SourceAddressPrefix)
+	summary.SourceAdresssPrefixes = append(safePtrToList(rule.SourceAddressPrefixes), safePtrToList(rule.ExpandedSourceAddressPrefix)...)
+	summary.DestinationAddressPrefix = safePtrToString(rule.DestinationAddressPrefix)
+	summary.DestinationAddressPrefixes = append(safePtrToList(rule.DestinationAddressPrefixes), safePtrToList(rule.ExpandedDestinationAddressPrefix)...)
+	summary.Access = safeEnumPtrToString(rule.Access)
+	summary.Priority = safePtrToInt32(rule.Priority)
+	summary.Direction = safeEnumPtrToString(rule.Direction)
+	return summary
 }
 
 // bindRuleList takes a raw list of security rules from the SDK and converts them into a string-based
 // summary struct.
-func bindRuleList(source network.SecurityRuleListResultIterator) ([]NsgRuleSummary, error) {
+func bindRuleList(source []*armnetwork.SecurityRule) []NsgRuleSummary {
 	rules := make([]NsgRuleSummary, 0)
-	for source.NotDone() {
-		v := source.Value()
-		rules = append(rules, convertToNsgRuleSummary(v.Name, v.SecurityRulePropertiesFormat))
-		err := source.NextWithContext(context.Background())
-		if err != nil {
-			return []NsgRuleSummary{}, err
+	for _, v := range source {
+		if v == nil || v.Properties == nil {
+			continue
 		}
+		rules = append(rules, convertToNsgRuleSummary(v.Name, v.Properties))
 	}
-	return rules, nil
+	return rules
 }
 
 // convertToNsgRuleSummary converts the raw SDK security rule type into a summarized struct, flattening the
 // rules properties and name into a single, string-based struct.
-func convertToNsgRuleSummary(name *string, rule *network.SecurityRulePropertiesFormat) NsgRuleSummary {
+func convertToNsgRuleSummary(name *string, rule *armnetwork.SecurityRulePropertiesFormat) NsgRuleSummary {
 	summary := NsgRuleSummary{}
 	summary.Description = safePtrToString(rule.Description)
 	summary.Name = safePtrToString(name)
-	summary.Protocol = string(rule.Protocol)
+	summary.Protocol = safeEnumPtrToString(rule.Protocol)
 	summary.SourcePortRange = safePtrToString(rule.SourcePortRange)
 	summary.SourcePortRanges = safePtrToList(rule.SourcePortRanges)
 	summary.DestinationPortRange = safePtrToString(rule.DestinationPortRange)
@@ -180,9 +222,9 @@ func convertToNsgRuleSummary(name *string, rule *network.SecurityRulePropertiesF
 	summary.SourceAdresssPrefixes = safePtrToList(rule.SourceAddressPrefixes)
 	summary.DestinationAddressPrefix = safePtrToString(rule.DestinationAddressPrefix)
 	summary.DestinationAddressPrefixes = safePtrToList(rule.DestinationAddressPrefixes)
-	summary.Access = string(rule.Access)
+	summary.Access = safeEnumPtrToString(rule.Access)
 	summary.Priority = safePtrToInt32(rule.Priority)
-	summary.Direction = string(rule.Direction)
+	summary.Direction = safeEnumPtrToString(rule.Direction)
 	return summary
 }
 
@@ -197,6 +239,134 @@ func (summarizedRules *NsgRuleSummaryList) FindRuleByName(name string) NsgRuleSu
 	return NsgRuleSummary{}
 }
 
+// FindInboundRule returns the inbound rule that applies to traffic from the given source address (an IP address, a CIDR
+// range, or a service tag such as Internet) to the given destination port, i.e., the matching rule with the lowest
+// priority number. Protocols are not taken into account. Rules that use service tags only match if the source is the
+// same service tag, unless the rules are effective rules, where service tags are expanded to their address prefixes.
+func (summarizedRules *NsgRuleSummaryList) FindInboundRule(sourceAddress string, destinationPort string) (NsgRuleSummary, error) {
+	inboundRules := []NsgRuleSummary{}
+	for _, rule := range summarizedRules.SummarizedRules {
+		if strings.EqualFold(rule.Direction, string(armnetwork.SecurityRuleDirectionInbound)) {
+			inboundRules = append(inboundRules, rule)
+		}
+	}
+	sort.SliceStable(inboundRules, func(i, j int) bool { return inboundRules[i].Priority < inboundRules[j].Priority })
+
+	for _, rule := range inboundRules {
+		sourceMatches, err := ruleMatchesSourceAddress(rule, sourceAddress)
+		if err != nil {
+			return NsgRuleSummary{}, err
+		}
+		if !sourceMatches {
+			continue
+		}
+
+		portMatches, err := ruleMatchesDestinationPort(rule, destinationPort)
+		if err != nil {
+			return NsgRuleSummary{}, err
+		}
+		if portMatches {
+			return rule, nil
+		}
+	}
+	return NsgRuleSummary{}, NewNotFoundError("Inbound NSG rule", sourceAddress+" to port "+destinationPort, "network security group rules")
+}
+
+// AllowsInbound checks whether the rules allow traffic from the given source address to the given destination port.
+// Traffic that no rule matches is denied.
+func (summarizedRules *NsgRuleSummaryList) AllowsInbound(sourceAddress string, destinationPort string) (bool, error) {
+	rule, err := summarizedRules.FindInboundRule(sourceAddress, destinationPort)
+	if _, ok := err.(NotFoundError); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(rule.Access, string(armnetwork.SecurityRuleAccessAllow)), nil
+}
+
+// AssertNsgAllows checks that the given NSG rules (e.g., from GetAllNSGRules or
+// GetNetworkInterfaceEffectiveNsgRules) allow inbound traffic from the given source address to the given
+// destination port.
+func AssertNsgAllows(t *testing.T, nsg NsgRuleSummaryList, sourceAddress string, destinationPort string) {
+	allowed, err := nsg.AllowsInbound(sourceAddress, destinationPort)
+	if assert.NoError(t, err) {
+		assert.Truef(t, allowed, "NSG rules do not allow inbound traffic from %s to port %s", sourceAddress, destinationPort)
+	}
+}
+
+// AssertNsgDenies checks that the given NSG rules (e.g., from GetAllNSGRules or
+// GetNetworkInterfaceEffectiveNsgRules) deny inbound traffic from the given source address to the given destination
+// port.
+func AssertNsgDenies(t *testing.T, nsg NsgRuleSummaryList, sourceAddress string, destinationPort string) {
+	allowed, err := nsg.AllowsInbound(sourceAddress, destinationPort)
+	if assert.NoError(t, err) {
+		assert.Falsef(t, allowed, "NSG rules allow inbound traffic from %s to port %s", sourceAddress, destinationPort)
+	}
+}
+
+// ruleMatchesSourceAddress checks whether the source address prefixes of the rule include the given source address.
+func ruleMatchesSourceAddress(rule NsgRuleSummary, sourceAddress string) (bool, error) {
+	prefixes := rule.SourceAdresssPrefixes
+	if rule.SourceAddressPrefix != "" {
+		prefixes = append([]string{rule.SourceAddressPrefix}, prefixes...)
+	}
+
+	sourceNet, sourceIsNet := parseAddressPrefix(sourceAddress)
+	for _, prefix := range prefixes {
+		if prefix == "*" || strings.EqualFold(prefix, sourceAddress) {
+			return true, nil
+		}
+		prefixNet, prefixIsNet := parseAddressPrefix(prefix)
+		if sourceIsNet && prefixIsNet && addressPrefixContains(prefixNet, sourceNet) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ruleMatchesDestinationPort checks whether the destination port ranges of the rule include the given port.
+func ruleMatchesDestinationPort(rule NsgRuleSummary, port string) (bool, error) {
+	portRanges := rule.DestinationPortRanges
+	if rule.DestinationPortRange != "" {
+		portRanges = append([]string{rule.DestinationPortRange}, portRanges...)
+	}
+
+	for _, portRange := range portRanges {
+		allowed, err := portRangeAllowsPort(portRange, port)
+		if err != nil {
+			return false, err
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseAddressPrefix parses an IP address or CIDR range. The second return value is false if the prefix is neither,
+// e.g., a service tag.
+func parseAddressPrefix(prefix string) (*net.IPNet, bool) {
+	if _, ipNet, err := net.ParseCIDR(prefix); err == nil {
+		return ipNet, true
+	}
+	ip := net.ParseIP(prefix)
+	if ip == nil {
+		return nil, false
+	}
+	if ip.To4() != nil {
+		return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, true
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, true
+}
+
+// addressPrefixContains checks whether the outer address prefix contains the whole inner address prefix.
+func addressPrefixContains(outer *net.IPNet, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
 // AllowsDestinationPort checks to see if the rule allows a specific destination port. This is helpful when verifying
 // that a given rule is configured properly for a given port.
 func (summarizedRule *NsgRuleSummary) AllowsDestinationPort(t *testing.T, port string) bool {
@@ -226,7 +396,7 @@ func portRangeAllowsPort(portRange string, port string) (bool, error) {
 	}
 
 	// Decode user-provided port
-	portAsInt, parseErr := strconv.ParseInt(port, 10, 16)
+	portAsInt, parseErr := strconv.ParseUint(port, 10, 16)
 	if (parseErr != nil) && (port != "*") {
 		return false, parseErr
 	}
@@ -251,7 +421,7 @@ func parsePortRangeString(rangeString string) (uint16, uint16, error) {
 
 	// Check for range string that contains hyphen separator
 	if !strings.Contains(rangeString, "-") {
-		val, parseErr := strconv.ParseInt(rangeString, 10, 16)
+		val, parseErr := strconv.ParseUint(rangeString, 10, 16)
 		if parseErr != nil {
 			return 0, 0, parseErr
 		}
@@ -265,13 +435,13 @@ func parsePortRangeString(rangeString string) (uint16, uint16, error) {
 	}
 
 	// Assume the low port is listed first; parse it
-	lowVal, parseErr := strconv.ParseInt(parts[0], 10, 16)
+	lowVal, parseErr := strconv.ParseUint(parts[0], 10, 16)
 	if parseErr != nil {
 		return 0, 0, parseErr
 	}
 
 	// Assume the hi port is listed first; parse it
-	highVal, parseErr := strconv.ParseInt(parts[1], 10, 16)
+	highVal, parseErr := strconv.ParseUint(parts[1], 10, 16)
 	if parseErr != nil {
 		return 0, 0, parseErr
 	}
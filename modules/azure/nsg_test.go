@@ -13,7 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
 )
 
 func TestPortRangeParsing(t *testing.T) {
@@ -31,6 +31,8 @@ func TestPortRangeParsing(t *testing.T) {
 		{"-80", 0, 0, true},
 		{"-", 0, 0, true},
 		{"80-22", 22, 80, false},
+		{"0-65535", 0, 65535, false},
+		{"65536", 0, 0, true},
 	}
 
 	for _, tt := range cases {
@@ -48,7 +50,7 @@ func TestPortRangeParsing(t *testing.T) {
 func TestNsgRuleSummaryConversion(t *testing.T) {
 	// Quick test to make sure the safe nil handling is working
 	name := "test name"
-	sdkStruct := network.SecurityRulePropertiesFormat{}
+	sdkStruct := armnetwork.SecurityRulePropertiesFormat{}
 
 	// Verify the nil values were correctly defaulted to "" without a panic
 	result := convertToNsgRuleSummary(&name, &sdkStruct)
@@ -57,6 +59,10 @@ func TestNsgRuleSummaryConversion(t *testing.T) {
 	assert.Equal(t, "", result.DestinationPortRange)
 	assert.Equal(t, "", result.SourceAddressPrefix)
 	assert.Equal(t, "", result.DestinationAddressPrefix)
+	assert.Equal(t, "", result.Protocol)
+	assert.Equal(t, "", result.Access)
+	assert.Equal(t, "", result.Direction)
+	assert.Equal(t, []string{}, result.SourcePortRanges)
 	assert.Equal(t, int32(0), result.Priority)
 }
 
@@ -159,3 +165,52 @@ func TestFindSummarizedRule(t *testing.T) {
 		})
 	}
 }
+
+func TestFindInboundRule(t *testing.T) {
+	ruleList := NsgRuleSummaryList{SummarizedRules: []NsgRuleSummary{
+		{Name: "DenyAllInBound", SourceAddressPrefix: "*", DestinationPortRange: "*", Access: "Deny", Priority: 65500, Direction: "Inbound"},
+		{Name: "AllowVnetInBound", SourceAddressPrefix: "VirtualNetwork", DestinationPortRange: "*", Access: "Allow", Priority: 65000, Direction: "Inbound"},
+		{Name: "AllowSSHFromOffice", SourceAdresssPrefixes: []string{"203.0.113.0/24", "198.51.100.7"}, DestinationPortRange: "22", Access: "Allow", Priority: 100, Direction: "Inbound"},
+		{Name: "AllowHTTPS", SourceAddressPrefix: "Internet", DestinationPortRanges: []string{"443", "8443-8444"}, Access: "Allow", Priority: 200, Direction: "Inbound"},
+		{Name: "DenyHighPorts", SourceAddressPrefix: "0.0.0.0/0", DestinationPortRange: "40000-65535", Access: "Deny", Priority: 300, Direction: "Inbound"},
+		{Name: "AllowAllOutBound", SourceAddressPrefix: "*", DestinationPortRange: "*", Access: "Allow", Priority: 100, Direction: "Outbound"},
+	}}
+
+	var cases = []struct {
+		CaseName        string
+		SourceAddress   string
+		DestinationPort string
+		ExpectedRule    string
+		Allowed         bool
+	}{
+		{"SSH from office range", "203.0.113.0/25", "22", "AllowSSHFromOffice", true},
+		{"SSH from office IP", "198.51.100.7", "22", "AllowSSHFromOffice", true},
+		{"SSH from wider range", "203.0.112.0/23", "22", "DenyAllInBound", false},
+		{"SSH from elsewhere", "192.0.2.1", "22", "DenyAllInBound", false},
+		{"HTTPS from the internet tag", "Internet", "443", "AllowHTTPS", true},
+		{"Alternative HTTPS port", "internet", "8444", "AllowHTTPS", true},
+		{"High port", "192.0.2.1", "50000", "DenyHighPorts", false},
+		{"Virtual network", "VirtualNetwork", "3389", "AllowVnetInBound", true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.CaseName, func(t *testing.T) {
+			rule, err := ruleList.FindInboundRule(tt.SourceAddress, tt.DestinationPort)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExpectedRule, rule.Name)
+
+			allowed, err := ruleList.AllowsInbound(tt.SourceAddress, tt.DestinationPort)
+			require.NoError(t, err)
+			assert.Equal(t, tt.Allowed, allowed)
+		})
+	}
+
+	// Traffic that no rule matches is denied
+	emptyList := NsgRuleSummaryList{}
+	allowed, err := emptyList.AllowsInbound("192.0.2.1", "22")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	AssertNsgAllows(t, ruleList, "203.0.113.10", "22")
+	AssertNsgDenies(t, ruleList, "192.0.2.1", "22")
+}
@@ -0,0 +1,230 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/policyinsights/armpolicyinsights"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PolicyComplianceState summarizes the latest compliance state of a resource against a policy definition of a policy
+// assignment. Resources evaluated against a policy set (initiative) have one state per policy definition of the set.
+type PolicyComplianceState struct {
+	ResourceID           string
+	PolicyAssignmentID   string
+	PolicyAssignmentName string
+	PolicyDefinitionID   string
+	PolicyDefinitionName string
+	ComplianceState      string // Compliant, NonCompliant, Exempt or Unknown
+	Timestamp            time.Time
+}
+
+// TriggerSubscriptionPolicyComplianceScan triggers an on-demand policy compliance scan of the given subscription and
+// waits for it to complete. This function would fail the test if there is an error.
+func TriggerSubscriptionPolicyComplianceScan(t testing.TestingT, subscriptionID string) {
+	require.NoError(t, TriggerSubscriptionPolicyComplianceScanE(subscriptionID))
+}
+
+// TriggerSubscriptionPolicyComplianceScanE triggers an on-demand policy compliance scan of the given subscription and
+// waits for it to complete. Scans of a whole subscription can take several minutes, or more with many resources.
+func TriggerSubscriptionPolicyComplianceScanE(subscriptionID string) error {
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	client, err := CreatePolicyStatesClientE()
+	if err != nil {
+		return err
+	}
+
+	poller, err := client.BeginTriggerSubscriptionEvaluation(context.Background(), subscriptionID, nil)
+	if err != nil {
+		return err
+	}
+	_, err = poller.PollUntilDone(context.Background(), nil)
+
+	return err
+}
+
+// TriggerResourceGroupPolicyComplianceScan triggers an on-demand policy compliance scan of the given resource group
+// and waits for it to complete. This function would fail the test if there is an error.
+func TriggerResourceGroupPolicyComplianceScan(t testing.TestingT, resGroupName string, subscriptionID string) {
+	require.NoError(t, TriggerResourceGroupPolicyComplianceScanE(resGroupName, subscriptionID))
+}
+
+// TriggerResourceGroupPolicyComplianceScanE triggers an on-demand policy compliance scan of the given resource group
+// and waits for it to complete.
+func TriggerResourceGroupPolicyComplianceScanE(resGroupName string, subscriptionID string) error {
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	resGroupName, err = getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return err
+	}
+
+	client, err := CreatePolicyStatesClientE()
+	if err != nil {
+		return err
+	}
+
+	poller, err := client.BeginTriggerResourceGroupEvaluation(context.Background(), subscriptionID, resGroupName, nil)
+	if err != nil {
+		return err
+	}
+	_, err = poller.PollUntilDone(context.Background(), nil)
+
+	return err
+}
+
+// GetPolicyComplianceStates gets the latest policy compliance states of the resource with the given ID.
+// This function would fail the test if there is an error.
+func GetPolicyComplianceStates(t testing.TestingT, resourceID string) []PolicyComplianceState {
+	states, err := GetPolicyComplianceStatesE(resourceID)
+	require.NoError(t, err)
+
+	return states
+}
+
+// GetPolicyComplianceStatesE gets the latest policy compliance states of the resource with the given ID, one per
+// policy definition of each policy assignment that applies to it.
+func GetPolicyComplianceStatesE(resourceID string) ([]PolicyComplianceState, error) {
+	client, err := CreatePolicyStatesClientE()
+	if err != nil {
+		return nil, err
+	}
+
+	states := []PolicyComplianceState{}
+	pager := client.NewListQueryResultsForResourcePager(armpolicyinsights.PolicyStatesResourceLatest, resourceID, nil, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range page.Value {
+			states = append(states, newPolicyComplianceState(state))
+		}
+	}
+
+	return states, nil
+}
+
+// GetPolicyComplianceState gets the compliance state of the resource with the given ID against the given policy
+// assignment. See GetPolicyComplianceStateE. This function would fail the test if there is an error.
+func GetPolicyComplianceState(t testing.TestingT, resourceID string, policyAssignment string) string {
+	state, err := GetPolicyComplianceStateE(resourceID, policyAssignment)
+	require.NoError(t, err)
+
+	return state
+}
+
+// GetPolicyComplianceStateE gets the compliance state of the resource with the given ID against the given policy
+// assignment, by ID or name. A resource evaluated against a policy set is NonCompliant if it doesn't comply with one
+// of its policy definitions.
+func GetPolicyComplianceStateE(resourceID string, policyAssignment string) (string, error) {
+	states, err := GetPolicyComplianceStatesE(resourceID)
+	if err != nil {
+		return "", err
+	}
+
+	return policyAssignmentComplianceStateE(states, resourceID, policyAssignment)
+}
+
+// WaitForPolicyComplianceState waits for the resource with the given ID to have a known compliance state against the
+// given policy assignment, and returns it. See WaitForPolicyComplianceStateE.
+func WaitForPolicyComplianceState(t testing.TestingT, resourceID string, policyAssignment string, maxRetries int, sleepBetweenRetries time.Duration) string {
+	state, err := WaitForPolicyComplianceStateE(t, resourceID, policyAssignment, maxRetries, sleepBetweenRetries)
+	require.NoError(t, err)
+
+	return state
+}
+
+// WaitForPolicyComplianceStateE waits for the resource with the given ID to have a known compliance state against the
+// given policy assignment, by ID or name, and returns it. Compliance results of new resources and assignments only
+// show up after a scan, which may take a while even after TriggerResourceGroupPolicyComplianceScanE returns.
+func WaitForPolicyComplianceStateE(t testing.TestingT, resourceID string, policyAssignment string, maxRetries int, sleepBetweenRetries time.Duration) (string, error) {
+	description := fmt.Sprintf("Waiting for compliance state of %s against policy assignment %s", resourceID, policyAssignment)
+
+	return retry.DoWithRetryE(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		state, err := GetPolicyComplianceStateE(resourceID, policyAssignment)
+		if err != nil {
+			return "", err
+		}
+		if strings.EqualFold(state, string(armpolicyinsights.ComplianceStateUnknown)) {
+			return "", fmt.Errorf("compliance state of %s against policy assignment %s is still unknown", resourceID, policyAssignment)
+		}
+		return state, nil
+	})
+}
+
+// AssertPolicyComplianceState checks that the resource with the given ID has the expected compliance state, e.g.,
+// Compliant, against the given policy assignment, by ID or name.
+func AssertPolicyComplianceState(t testing.TestingT, resourceID string, policyAssignment string, expectedState string) {
+	state, err := GetPolicyComplianceStateE(resourceID, policyAssignment)
+	if assert.NoError(t, err) {
+		assert.Truef(t, strings.EqualFold(expectedState, state), "Resource %s is %s against policy assignment %s, expected %s", resourceID, state, policyAssignment, expectedState)
+	}
+}
+
+// AssertResourceCompliantWithPolicy checks that the resource with the given ID complies with the given policy
+// assignment, by ID or name.
+func AssertResourceCompliantWithPolicy(t testing.TestingT, resourceID string, policyAssignment string) {
+	AssertPolicyComplianceState(t, resourceID, policyAssignment, string(armpolicyinsights.ComplianceStateCompliant))
+}
+
+// AssertResourceNonCompliantWithPolicy checks that the resource with the given ID doesn't comply with the given
+// policy assignment, by ID or name.
+func AssertResourceNonCompliantWithPolicy(t testing.TestingT, resourceID string, policyAssignment string) {
+	AssertPolicyComplianceState(t, resourceID, policyAssignment, string(armpolicyinsights.ComplianceStateNonCompliant))
+}
+
+// newPolicyComplianceState summarizes the given policy state.
+func newPolicyComplianceState(state *armpolicyinsights.PolicyState) PolicyComplianceState {
+	summary := PolicyComplianceState{
+		ResourceID:           safePtrToString(state.ResourceID),
+		PolicyAssignmentID:   safePtrToString(state.PolicyAssignmentID),
+		PolicyAssignmentName: safePtrToString(state.PolicyAssignmentName),
+		PolicyDefinitionID:   safePtrToString(state.PolicyDefinitionID),
+		PolicyDefinitionName: safePtrToString(state.PolicyDefinitionName),
+		ComplianceState:      safePtrToString(state.ComplianceState),
+	}
+	if state.Timestamp != nil {
+		summary.Timestamp = *state.Timestamp
+	}
+
+	return summary
+}
+
+// policyAssignmentComplianceStateE returns the overall compliance state of a resource against the given policy
+// assignment, by ID or name, among the given states: NonCompliant if any of them is, then Compliant if any of them
+// is, then the state of the first one.
+func policyAssignmentComplianceStateE(states []PolicyComplianceState, resourceID string, policyAssignment string) (string, error) {
+	matching := []string{}
+	for _, state := range states {
+		if strings.EqualFold(state.PolicyAssignmentID, policyAssignment) || strings.EqualFold(state.PolicyAssignmentName, policyAssignment) {
+			matching = append(matching, state.ComplianceState)
+		}
+	}
+	if len(matching) == 0 {
+		return "", NewNotFoundError("Policy compliance state", policyAssignment, resourceID)
+	}
+
+	for _, precedence := range []armpolicyinsights.ComplianceState{armpolicyinsights.ComplianceStateNonCompliant, armpolicyinsights.ComplianceStateCompliant} {
+		for _, state := range matching {
+			if strings.EqualFold(state, string(precedence)) {
+				return string(precedence), nil
+			}
+		}
+	}
+
+	return matching[0], nil
+}
@@ -0,0 +1,68 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/policyinsights/armpolicyinsights"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policyTestResourceID = "/subscriptions/sub/resourcegroups/rg/providers/microsoft.storage/storageaccounts/mystorage"
+
+func TestNewPolicyComplianceState(t *testing.T) {
+	t.Parallel()
+
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := newPolicyComplianceState(&armpolicyinsights.PolicyState{
+		ResourceID:           to.Ptr(policyTestResourceID),
+		PolicyAssignmentID:   to.Ptr("/subscriptions/sub/providers/microsoft.authorization/policyassignments/require-https"),
+		PolicyAssignmentName: to.Ptr("require-https"),
+		PolicyDefinitionName: to.Ptr("404c3081-a854-4457-ae30-26a93ef643f9"),
+		ComplianceState:      to.Ptr("NonCompliant"),
+		Timestamp:            &timestamp,
+	})
+
+	assert.Equal(t, PolicyComplianceState{
+		ResourceID:           policyTestResourceID,
+		PolicyAssignmentID:   "/subscriptions/sub/providers/microsoft.authorization/policyassignments/require-https",
+		PolicyAssignmentName: "require-https",
+		PolicyDefinitionName: "404c3081-a854-4457-ae30-26a93ef643f9",
+		ComplianceState:      "NonCompliant",
+		Timestamp:            timestamp,
+	}, state)
+}
+
+func TestPolicyAssignmentComplianceState(t *testing.T) {
+	t.Parallel()
+
+	states := []PolicyComplianceState{
+		{PolicyAssignmentID: "/subscriptions/sub/providers/microsoft.authorization/policyassignments/require-https", PolicyAssignmentName: "require-https", ComplianceState: "Compliant"},
+		{PolicyAssignmentID: "/subscriptions/sub/providers/microsoft.authorization/policyassignments/baseline", PolicyAssignmentName: "baseline", ComplianceState: "Compliant"},
+		{PolicyAssignmentID: "/subscriptions/sub/providers/microsoft.authorization/policyassignments/baseline", PolicyAssignmentName: "baseline", ComplianceState: "NonCompliant"},
+		{PolicyAssignmentID: "/subscriptions/sub/providers/microsoft.authorization/policyassignments/tags", PolicyAssignmentName: "tags", ComplianceState: "Exempt"},
+	}
+
+	state, err := policyAssignmentComplianceStateE(states, policyTestResourceID, "/subscriptions/sub/providers/Microsoft.Authorization/policyAssignments/require-https")
+	require.NoError(t, err)
+	assert.Equal(t, "Compliant", state)
+
+	state, err = policyAssignmentComplianceStateE(states, policyTestResourceID, "baseline")
+	require.NoError(t, err)
+	assert.Equal(t, "NonCompliant", state)
+
+	state, err = policyAssignmentComplianceStateE(states, policyTestResourceID, "tags")
+	require.NoError(t, err)
+	assert.Equal(t, "Exempt", state)
+
+	_, err = policyAssignmentComplianceStateE(states, policyTestResourceID, "missing")
+	require.Error(t, err)
+}
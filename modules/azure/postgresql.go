@@ -3,37 +3,20 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresql"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
 // GetPostgreSQLServerClientE is a helper function that will setup a postgresql server client.
-func GetPostgreSQLServerClientE(subscriptionID string) (*postgresql.ServersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetPostgreSQLServerClientE(subscriptionID string) (*armpostgresql.ServersClient, error) {
 	// Create a postgresql server client
-	postgresqlClient := postgresql.NewServersClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	postgresqlClient.Authorizer = *authorizer
-
-	return &postgresqlClient, nil
+	return CreatePostgreSQLServerClientE(subscriptionID)
 }
 
 // GetPostgreSQLServer is a helper function that gets the server.
 // This function would fail the test if there is an error.
-func GetPostgreSQLServer(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *postgresql.Server {
+func GetPostgreSQLServer(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *armpostgresql.Server {
 	postgresqlServer, err := GetPostgreSQLServerE(t, subscriptionID, resGroupName, serverName)
 	require.NoError(t, err)
 
@@ -41,7 +24,7 @@ func GetPostgreSQLServer(t testing.TestingT, resGroupName string, serverName str
 }
 
 // GetPostgreSQLServerE is a helper function that gets the server.
-func GetPostgreSQLServerE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) (*postgresql.Server, error) {
+func GetPostgreSQLServerE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) (*armpostgresql.Server, error) {
 	// Create a postgresql Server client
 	postgresqlClient, err := GetPostgreSQLServerClientE(subscriptionID)
 	if err != nil {
@@ -49,41 +32,24 @@ func GetPostgreSQLServerE(t testing.TestingT, subscriptionID string, resGroupNam
 	}
 
 	// Get the corresponding server client
-	postgresqlServer, err := postgresqlClient.Get(context.Background(), resGroupName, serverName)
+	postgresqlServer, err := postgresqlClient.Get(context.Background(), resGroupName, serverName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return server
-	return &postgresqlServer, nil
+	return &postgresqlServer.Server, nil
 }
 
 // GetPostgreSQLDBClientE is a helper function that will setup a postgresql DB client.
-func GetPostgreSQLDBClientE(subscriptionID string) (*postgresql.DatabasesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetPostgreSQLDBClientE(subscriptionID string) (*armpostgresql.DatabasesClient, error) {
 	// Create a postgresql db client
-	postgresqlDBClient := postgresql.NewDatabasesClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	postgresqlDBClient.Authorizer = *authorizer
-
-	return &postgresqlDBClient, nil
+	return CreatePostgreSQLDatabasesClientE(subscriptionID)
 }
 
 // GetPostgreSQLDB is a helper function that gets the database.
 // This function would fail the test if there is an error.
-func GetPostgreSQLDB(t testing.TestingT, resGroupName string, serverName string, dbName string, subscriptionID string) *postgresql.Database {
+func GetPostgreSQLDB(t testing.TestingT, resGroupName string, serverName string, dbName string, subscriptionID string) *armpostgresql.Database {
 	database, err := GetPostgreSQLDBE(t, subscriptionID, resGroupName, serverName, dbName)
 	require.NoError(t, err)
 
@@ -91,7 +57,7 @@ func GetPostgreSQLDB(t testing.TestingT, resGroupName string, serverName string,
 }
 
 // GetPostgreSQLDBE is a helper function that gets the database.
-func GetPostgreSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string, dbName string) (*postgresql.Database, error) {
+func GetPostgreSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string, dbName string) (*armpostgresql.Database, error) {
 	// Create a postgresql db client
 	postgresqldbClient, err := GetPostgreSQLDBClientE(subscriptionID)
 	if err != nil {
@@ -99,17 +65,17 @@ func GetPostgreSQLDBE(t testing.TestingT, subscriptionID string, resGroupName st
 	}
 
 	// Get the corresponding db client
-	postgresqlDb, err := postgresqldbClient.Get(context.Background(), resGroupName, serverName, dbName)
+	postgresqlDb, err := postgresqldbClient.Get(context.Background(), resGroupName, serverName, dbName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return DB
-	return &postgresqlDb, nil
+	return &postgresqlDb.Database, nil
 }
 
 // ListPostgreSQLDB is a helper function that gets all databases per server.
-func ListPostgreSQLDB(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) []postgresql.Database {
+func ListPostgreSQLDB(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) []armpostgresql.Database {
 	dblist, err := ListPostgreSQLDBE(t, subscriptionID, resGroupName, serverName)
 	require.NoError(t, err)
 
@@ -117,7 +83,7 @@ func ListPostgreSQLDB(t testing.TestingT, subscriptionID string, resGroupName st
 }
 
 // ListPostgreSQLDBE is a helper function that gets all databases per server.
-func ListPostgreSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) ([]postgresql.Database, error) {
+func ListPostgreSQLDBE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) ([]armpostgresql.Database, error) {
 	// Create a postgresql db client
 	postgresqldbClient, err := GetPostgreSQLDBClientE(subscriptionID)
 	if err != nil {
@@ -125,11 +91,18 @@ func ListPostgreSQLDBE(t testing.TestingT, subscriptionID string, resGroupName s
 	}
 
 	// Get the corresponding db client
-	postgresqlDbs, err := postgresqldbClient.ListByServer(context.Background(), resGroupName, serverName)
-	if err != nil {
-		return nil, err
+	var postgresqlDbs []armpostgresql.Database
+	pager := postgresqldbClient.NewListByServerPager(resGroupName, serverName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range page.Value {
+			postgresqlDbs = append(postgresqlDbs, *db)
+		}
 	}
 
 	//Return DB lists
-	return *postgresqlDbs.Value, nil
+	return postgresqlDbs, nil
 }
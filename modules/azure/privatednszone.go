@@ -2,10 +2,23 @@ package azure
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/latest/privatedns/mgmt/privatedns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// PrivateDNSZoneVirtualNetworkLink summarizes a link between a private DNS zone and a virtual network.
+type PrivateDNSZoneVirtualNetworkLink struct {
+	Name                string
+	VirtualNetworkID    string
+	RegistrationEnabled bool   // Whether the VMs of the virtual network register their records in the zone
+	State               string // The state of the link, i.e., InProgress or Completed
+}
+
 // PrivateDNSZoneExistsE indicates whether the specified private DNS zone exists.
 func PrivateDNSZoneExistsE(zoneName string, resourceGroupName string, subscriptionID string) (bool, error) {
 	_, err := GetPrivateDNSZoneE(zoneName, resourceGroupName, subscriptionID)
@@ -19,7 +32,7 @@ func PrivateDNSZoneExistsE(zoneName string, resourceGroupName string, subscripti
 }
 
 // GetPrivateDNSZoneE gets the private DNS zone object
-func GetPrivateDNSZoneE(zoneName string, resGroupName string, subscriptionID string) (*privatedns.PrivateZone, error) {
+func GetPrivateDNSZoneE(zoneName string, resGroupName string, subscriptionID string) (*armprivatedns.PrivateZone, error) {
 	rgName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
 		return nil, err
@@ -30,10 +43,212 @@ func GetPrivateDNSZoneE(zoneName string, resGroupName string, subscriptionID str
 		return nil, err
 	}
 
-	zone, err := client.Get(context.Background(), rgName, zoneName)
+	zone, err := client.Get(context.Background(), rgName, zoneName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &zone.PrivateZone, nil
+}
+
+// ListPrivateDNSRecordSets lists the record sets of the specified private DNS zone.
+// This function would fail the test if there is an error.
+func ListPrivateDNSRecordSets(t testing.TestingT, zoneName string, resGroupName string, subscriptionID string) []DNSRecordSet {
+	recordSets, err := ListPrivateDNSRecordSetsE(zoneName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return recordSets
+}
+
+// ListPrivateDNSRecordSetsE lists the record sets of the specified private DNS zone.
+func ListPrivateDNSRecordSetsE(zoneName string, resGroupName string, subscriptionID string) ([]DNSRecordSet, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreatePrivateDnsRecordSetsClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &zone, nil
+	recordSets := []DNSRecordSet{}
+	pager := client.NewListPager(rgName, zoneName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, recordSet := range page.Value {
+			recordSets = append(recordSets, newPrivateDNSRecordSet(recordSet))
+		}
+	}
+
+	return recordSets, nil
+}
+
+// GetPrivateDNSRecordSet gets the record set with the given type and relative name in the specified private DNS zone.
+// This function would fail the test if there is an error.
+func GetPrivateDNSRecordSet(t testing.TestingT, zoneName string, recordType string, name string, resGroupName string, subscriptionID string) DNSRecordSet {
+	recordSet, err := GetPrivateDNSRecordSetE(zoneName, recordType, name, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return recordSet
+}
+
+// GetPrivateDNSRecordSetE gets the record set with the given type and relative name in the specified private DNS zone.
+func GetPrivateDNSRecordSetE(zoneName string, recordType string, name string, resGroupName string, subscriptionID string) (DNSRecordSet, error) {
+	recordSets, err := ListPrivateDNSRecordSetsE(zoneName, resGroupName, subscriptionID)
+	if err != nil {
+		return DNSRecordSet{}, err
+	}
+
+	return findDNSRecordSetE(recordSets, zoneName, recordType, name)
+}
+
+// AssertPrivateDNSRecordSetValues checks that the record set with the given type and relative name in the specified
+// private DNS zone has exactly the expected values, in any order.
+func AssertPrivateDNSRecordSetValues(t testing.TestingT, zoneName string, recordType string, name string, expectedValues []string, resGroupName string, subscriptionID string) {
+	recordSet, err := GetPrivateDNSRecordSetE(zoneName, recordType, name, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.ElementsMatchf(t, expectedValues, recordSet.Values, "Unexpected values of %s record %s in private DNS zone %s", recordType, name, zoneName)
+	}
+}
+
+// newPrivateDNSRecordSet summarizes the given private DNS record set.
+func newPrivateDNSRecordSet(recordSet *armprivatedns.RecordSet) DNSRecordSet {
+	summary := DNSRecordSet{
+		Name:   safePtrToString(recordSet.Name),
+		Type:   dnsRecordTypeFromResourceType(safePtrToString(recordSet.Type)),
+		Values: []string{},
+	}
+	props := recordSet.Properties
+	if props == nil {
+		return summary
+	}
+
+	if props.TTL != nil {
+		summary.TTL = *props.TTL
+	}
+	summary.FQDN = safePtrToString(props.Fqdn)
+	for _, record := range props.ARecords {
+		summary.Values = append(summary.Values, safePtrToString(record.IPv4Address))
+	}
+	for _, record := range props.AaaaRecords {
+		summary.Values = append(summary.Values, safePtrToString(record.IPv6Address))
+	}
+	if props.CnameRecord != nil {
+		summary.Values = append(summary.Values, safePtrToString(props.CnameRecord.Cname))
+	}
+	for _, record := range props.MxRecords {
+		summary.Values = append(summary.Values, fmt.Sprintf("%d %s", safePtrToInt32(record.Preference), safePtrToString(record.Exchange)))
+	}
+	for _, record := range props.PtrRecords {
+		summary.Values = append(summary.Values, safePtrToString(record.Ptrdname))
+	}
+	for _, record := range props.SrvRecords {
+		summary.Values = append(summary.Values, fmt.Sprintf("%d %d %d %s", safePtrToInt32(record.Priority), safePtrToInt32(record.Weight), safePtrToInt32(record.Port), safePtrToString(record.Target)))
+	}
+	for _, record := range props.TxtRecords {
+		summary.Values = append(summary.Values, strings.Join(safePtrToList(record.Value), ""))
+	}
+
+	return summary
+}
+
+// ListPrivateDNSZoneVirtualNetworkLinks lists the virtual network links of the specified private DNS zone.
+// This function would fail the test if there is an error.
+func ListPrivateDNSZoneVirtualNetworkLinks(t testing.TestingT, zoneName string, resGroupName string, subscriptionID string) []PrivateDNSZoneVirtualNetworkLink {
+	links, err := ListPrivateDNSZoneVirtualNetworkLinksE(zoneName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return links
+}
+
+// ListPrivateDNSZoneVirtualNetworkLinksE lists the virtual network links of the specified private DNS zone.
+func ListPrivateDNSZoneVirtualNetworkLinksE(zoneName string, resGroupName string, subscriptionID string) ([]PrivateDNSZoneVirtualNetworkLink, error) {
+	rgName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreatePrivateDnsVirtualNetworkLinksClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := []PrivateDNSZoneVirtualNetworkLink{}
+	pager := client.NewListPager(rgName, zoneName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range page.Value {
+			links = append(links, newPrivateDNSZoneVirtualNetworkLink(link))
+		}
+	}
+
+	return links, nil
+}
+
+// newPrivateDNSZoneVirtualNetworkLink summarizes the given virtual network link.
+func newPrivateDNSZoneVirtualNetworkLink(link *armprivatedns.VirtualNetworkLink) PrivateDNSZoneVirtualNetworkLink {
+	summary := PrivateDNSZoneVirtualNetworkLink{Name: safePtrToString(link.Name)}
+	if link.Properties == nil {
+		return summary
+	}
+
+	if link.Properties.VirtualNetwork != nil {
+		summary.VirtualNetworkID = safePtrToString(link.Properties.VirtualNetwork.ID)
+	}
+	summary.RegistrationEnabled = safePtrToBool(link.Properties.RegistrationEnabled)
+	summary.State = safeEnumPtrToString(link.Properties.VirtualNetworkLinkState)
+
+	return summary
+}
+
+// PrivateDNSZoneLinkedToVirtualNetwork indicates whether the specified private DNS zone has a completed link to the
+// virtual network with the given name. This function would fail the test if there is an error.
+func PrivateDNSZoneLinkedToVirtualNetwork(t testing.TestingT, zoneName string, vnetName string, resGroupName string, subscriptionID string) bool {
+	linked, err := PrivateDNSZoneLinkedToVirtualNetworkE(zoneName, vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return linked
+}
+
+// PrivateDNSZoneLinkedToVirtualNetworkE indicates whether the specified private DNS zone has a completed link to the
+// virtual network with the given name.
+func PrivateDNSZoneLinkedToVirtualNetworkE(zoneName string, vnetName string, resGroupName string, subscriptionID string) (bool, error) {
+	links, err := ListPrivateDNSZoneVirtualNetworkLinksE(zoneName, resGroupName, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	return privateDNSZoneLinksContainVirtualNetwork(links, vnetName), nil
+}
+
+// AssertPrivateDNSZoneLinkedToVirtualNetwork checks that the specified private DNS zone has a completed link to the
+// virtual network with the given name.
+func AssertPrivateDNSZoneLinkedToVirtualNetwork(t testing.TestingT, zoneName string, vnetName string, resGroupName string, subscriptionID string) {
+	linked, err := PrivateDNSZoneLinkedToVirtualNetworkE(zoneName, vnetName, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, linked, "Private DNS zone %s is not linked to virtual network %s", zoneName, vnetName)
+	}
+}
+
+// privateDNSZoneLinksContainVirtualNetwork returns true if one of the given links to the virtual network with the
+// given name is completed.
+func privateDNSZoneLinksContainVirtualNetwork(links []PrivateDNSZoneVirtualNetworkLink, vnetName string) bool {
+	for _, link := range links {
+		name, err := GetNameFromResourceIDE(link.VirtualNetworkID)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(name, vnetName) && link.State == string(armprivatedns.VirtualNetworkLinkStateCompleted) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -3,6 +3,9 @@ package azure
 import (
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,3 +36,52 @@ func TestPrivateDNSZoneExistsE(t *testing.T) {
 	_, err := GetPrivateDNSZoneE(subscriptionID, resGroupName, zoneName)
 	require.Error(t, err)
 }
+
+func TestNewPrivateDNSRecordSet(t *testing.T) {
+	t.Parallel()
+
+	recordSet := newPrivateDNSRecordSet(&armprivatedns.RecordSet{
+		Name: to.Ptr("db"),
+		Type: to.Ptr("Microsoft.Network/privateDnsZones/A"),
+		Properties: &armprivatedns.RecordSetProperties{
+			TTL:      to.Ptr[int64](10),
+			Fqdn:     to.Ptr("db.privatelink.database.windows.net."),
+			ARecords: []*armprivatedns.ARecord{{IPv4Address: to.Ptr("10.0.1.4")}},
+		},
+	})
+
+	assert.Equal(t, DNSRecordSet{
+		Name:   "db",
+		Type:   "A",
+		TTL:    10,
+		FQDN:   "db.privatelink.database.windows.net.",
+		Values: []string{"10.0.1.4"},
+	}, recordSet)
+}
+
+func TestPrivateDNSZoneLinksContainVirtualNetwork(t *testing.T) {
+	t.Parallel()
+
+	completed := armprivatedns.VirtualNetworkLinkStateCompleted
+	inProgress := armprivatedns.VirtualNetworkLinkStateInProgress
+	links := []PrivateDNSZoneVirtualNetworkLink{
+		newPrivateDNSZoneVirtualNetworkLink(&armprivatedns.VirtualNetworkLink{
+			Name: to.Ptr("hub"),
+			Properties: &armprivatedns.VirtualNetworkLinkProperties{
+				VirtualNetwork:          &armprivatedns.SubResource{ID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/hub-vnet")},
+				VirtualNetworkLinkState: &completed,
+			},
+		}),
+		newPrivateDNSZoneVirtualNetworkLink(&armprivatedns.VirtualNetworkLink{
+			Name: to.Ptr("spoke"),
+			Properties: &armprivatedns.VirtualNetworkLinkProperties{
+				VirtualNetwork:          &armprivatedns.SubResource{ID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/spoke-vnet")},
+				VirtualNetworkLinkState: &inProgress,
+			},
+		}),
+	}
+
+	assert.True(t, privateDNSZoneLinksContainVirtualNetwork(links, "hub-vnet"))
+	assert.False(t, privateDNSZoneLinksContainVirtualNetwork(links, "spoke-vnet"))
+	assert.False(t, privateDNSZoneLinksContainVirtualNetwork(links, "other-vnet"))
+}
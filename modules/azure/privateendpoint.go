@@ -0,0 +1,413 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// privateEndpointConnectionApproved is the status of approved private endpoint connections.
+const privateEndpointConnectionApproved = "Approved"
+
+// PrivateEndpointConnectionSummary summarizes a connection of a Private Endpoint to a Private Link resource.
+type PrivateEndpointConnectionSummary struct {
+	PrivateEndpointID string
+	TargetResourceID  string
+	GroupIDs          []string // The sub-resources the connection is to, e.g., blob or vault
+	Status            string   // Pending, Approved, Rejected or Disconnected
+	Description       string
+}
+
+// PrivateEndpointDNSRecord is a name a Private Endpoint must resolve to its private IP addresses.
+type PrivateEndpointDNSRecord struct {
+	FQDN        string
+	IPAddresses []string
+}
+
+// GetPrivateEndpoint gets a Private Endpoint in the specified Azure Resource Group.
+// This function would fail the test if there is an error.
+func GetPrivateEndpoint(t testing.TestingT, privateEndpointName string, resGroupName string, subscriptionID string) *armnetwork.PrivateEndpoint {
+	privateEndpoint, err := GetPrivateEndpointE(privateEndpointName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return privateEndpoint
+}
+
+// GetPrivateEndpointE gets a Private Endpoint in the specified Azure Resource Group.
+func GetPrivateEndpointE(privateEndpointName string, resGroupName string, subscriptionID string) (*armnetwork.PrivateEndpoint, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreatePrivateEndpointsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	privateEndpoint, err := client.Get(context.Background(), resGroupName, privateEndpointName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &privateEndpoint.PrivateEndpoint, nil
+}
+
+// ListPrivateEndpointConnectionsForResource lists the connections of the Private Endpoints in the given subscription
+// to the resource with the given ID. This function would fail the test if there is an error.
+func ListPrivateEndpointConnectionsForResource(t testing.TestingT, resourceID string, subscriptionID string) []PrivateEndpointConnectionSummary {
+	connections, err := ListPrivateEndpointConnectionsForResourceE(resourceID, subscriptionID)
+	require.NoError(t, err)
+
+	return connections
+}
+
+// ListPrivateEndpointConnectionsForResourceE lists the connections of the Private Endpoints in the given subscription
+// to the resource with the given ID, e.g., a storage account or key vault. This works for any Private Link resource
+// type, automatically approved or not.
+func ListPrivateEndpointConnectionsForResourceE(resourceID string, subscriptionID string) ([]PrivateEndpointConnectionSummary, error) {
+	client, err := CreatePrivateEndpointsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := []PrivateEndpointConnectionSummary{}
+	pager := client.NewListBySubscriptionPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, privateEndpoint := range page.Value {
+			for _, connection := range privateEndpointConnections(privateEndpoint) {
+				if strings.EqualFold(connection.TargetResourceID, resourceID) {
+					connections = append(connections, connection)
+				}
+			}
+		}
+	}
+
+	return connections, nil
+}
+
+// privateEndpointConnections summarizes the automatically and manually approved connections of the given Private
+// Endpoint.
+func privateEndpointConnections(privateEndpoint *armnetwork.PrivateEndpoint) []PrivateEndpointConnectionSummary {
+	connections := []PrivateEndpointConnectionSummary{}
+	if privateEndpoint.Properties == nil {
+		return connections
+	}
+
+	all := append([]*armnetwork.PrivateLinkServiceConnection{}, privateEndpoint.Properties.PrivateLinkServiceConnections...)
+	all = append(all, privateEndpoint.Properties.ManualPrivateLinkServiceConnections...)
+	for _, connection := range all {
+		if connection.Properties == nil {
+			continue
+		}
+		summary := PrivateEndpointConnectionSummary{
+			PrivateEndpointID: safePtrToString(privateEndpoint.ID),
+			TargetResourceID:  safePtrToString(connection.Properties.PrivateLinkServiceID),
+			GroupIDs:          safePtrToList(connection.Properties.GroupIDs),
+		}
+		if state := connection.Properties.PrivateLinkServiceConnectionState; state != nil {
+			summary.Status = safePtrToString(state.Status)
+			summary.Description = safePtrToString(state.Description)
+		}
+		connections = append(connections, summary)
+	}
+
+	return connections
+}
+
+// AssertPrivateEndpointConnectionsApproved checks that the resource with the given ID has at least one Private
+// Endpoint connection in the given subscription, and that all of them are approved.
+func AssertPrivateEndpointConnectionsApproved(t testing.TestingT, resourceID string, subscriptionID string) {
+	connections, err := ListPrivateEndpointConnectionsForResourceE(resourceID, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NotEmptyf(t, connections, "Resource %s has no private endpoint connections", resourceID)
+	for _, connection := range connections {
+		assert.Equalf(t, privateEndpointConnectionApproved, connection.Status, "Connection of private endpoint %s to resource %s is not approved: %s", connection.PrivateEndpointID, resourceID, connection.Description)
+	}
+}
+
+// GetPrivateEndpointDNSRecords gets the names the specified Private Endpoint must resolve to its private IPs.
+// This function would fail the test if there is an error.
+func GetPrivateEndpointDNSRecords(t testing.TestingT, privateEndpointName string, resGroupName string, subscriptionID string) []PrivateEndpointDNSRecord {
+	records, err := GetPrivateEndpointDNSRecordsE(privateEndpointName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return records
+}
+
+// GetPrivateEndpointDNSRecordsE gets the names the specified Private Endpoint must resolve to its private IPs: the
+// records of its private DNS zone groups, or its custom DNS configs if it has no zone group.
+func GetPrivateEndpointDNSRecordsE(privateEndpointName string, resGroupName string, subscriptionID string) ([]PrivateEndpointDNSRecord, error) {
+	privateEndpoint, err := GetPrivateEndpointE(privateEndpointName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	resGroupName, err = getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreatePrivateDNSZoneGroupsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneGroups := []*armnetwork.PrivateDNSZoneGroup{}
+	pager := client.NewListPager(privateEndpointName, resGroupName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		zoneGroups = append(zoneGroups, page.Value...)
+	}
+
+	return privateEndpointDNSRecords(privateEndpoint, zoneGroups), nil
+}
+
+// privateEndpointDNSRecords returns the records of the given private DNS zone groups, or the custom DNS configs of the
+// given Private Endpoint if there are none, since Azure only fills those in for endpoints without a zone group.
+func privateEndpointDNSRecords(privateEndpoint *armnetwork.PrivateEndpoint, zoneGroups []*armnetwork.PrivateDNSZoneGroup) []PrivateEndpointDNSRecord {
+	records := []PrivateEndpointDNSRecord{}
+	for _, zoneGroup := range zoneGroups {
+		if zoneGroup.Properties == nil {
+			continue
+		}
+		for _, zoneConfig := range zoneGroup.Properties.PrivateDNSZoneConfigs {
+			if zoneConfig.Properties == nil {
+				continue
+			}
+			for _, recordSet := range zoneConfig.Properties.RecordSets {
+				records = append(records, PrivateEndpointDNSRecord{
+					FQDN:        strings.TrimSuffix(safePtrToString(recordSet.Fqdn), "."),
+					IPAddresses: safePtrToList(recordSet.IPAddresses),
+				})
+			}
+		}
+	}
+	if len(records) > 0 || privateEndpoint.Properties == nil {
+		return records
+	}
+
+	for _, config := range privateEndpoint.Properties.CustomDNSConfigs {
+		records = append(records, PrivateEndpointDNSRecord{
+			FQDN:        strings.TrimSuffix(safePtrToString(config.Fqdn), "."),
+			IPAddresses: safePtrToList(config.IPAddresses),
+		})
+	}
+
+	return records
+}
+
+// AssertPrivateEndpointDNSRecordsExist checks that the given private DNS zone, e.g.,
+// privatelink.blob.core.windows.net, has an A record for each name of the specified Private Endpoint in the zone, e.g.,
+// mystorage.blob.core.windows.net, with the private IPs of the endpoint.
+func AssertPrivateEndpointDNSRecordsExist(t testing.TestingT, privateEndpointName string, resGroupName string, zoneName string, zoneResGroupName string, subscriptionID string) {
+	records, err := GetPrivateEndpointDNSRecordsE(privateEndpointName, resGroupName, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	found := false
+	for _, record := range records {
+		name, inZone := privateDNSRecordNameInZone(record.FQDN, zoneName)
+		if !inZone {
+			continue
+		}
+		found = true
+
+		recordSet, err := GetPrivateDNSRecordSetE(zoneName, "A", name, zoneResGroupName, subscriptionID)
+		if assert.NoError(t, err) {
+			assert.ElementsMatchf(t, record.IPAddresses, recordSet.Values, "Unexpected IPs of A record %s in private DNS zone %s", name, zoneName)
+		}
+	}
+	assert.Truef(t, found, "Private endpoint %s has no names in private DNS zone %s", privateEndpointName, zoneName)
+}
+
+// privateDNSRecordNameInZone returns the relative name of the record for the given FQDN in the given private DNS zone.
+// The FQDN is either in the zone (e.g., myvault.privatelink.vaultcore.azure.net, as in private DNS zone groups) or the
+// public name it shadows (e.g., mystorage.blob.core.windows.net for privatelink.blob.core.windows.net, as in custom
+// DNS configs).
+func privateDNSRecordNameInZone(fqdn string, zoneName string) (string, bool) {
+	fqdn = strings.ToLower(fqdn)
+	zoneName = strings.ToLower(strings.TrimSuffix(zoneName, "."))
+
+	for _, suffix := range []string{zoneName, strings.TrimPrefix(zoneName, "privatelink.")} {
+		if name := strings.TrimSuffix(fqdn, "."+suffix); name != fqdn && name != "" {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// PublicEndpointRejectsRequests indicates whether the public endpoint at the given URL rejects requests from the test
+// runner. See PublicEndpointRejectsRequestsE. This function would fail the test if there is an error.
+func PublicEndpointRejectsRequests(t testing.TestingT, endpointURL string) bool {
+	rejected, err := PublicEndpointRejectsRequestsE(endpointURL)
+	require.NoError(t, err)
+
+	return rejected
+}
+
+// PublicEndpointRejectsRequestsE indicates whether the public endpoint at the given URL, e.g.,
+// https://mystorage.blob.core.windows.net, rejects requests from the test runner: the connection fails, or the service
+// answers 403 Forbidden, which is how Azure services with public network access disabled answer unauthenticated
+// requests. Run it from outside the virtual network of the Private Endpoint.
+func PublicEndpointRejectsRequestsE(endpointURL string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, endpointURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, nil
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusForbidden, nil
+}
+
+// AssertPublicEndpointRejectsRequests checks that the public endpoint at the given URL rejects requests from the test
+// runner. See PublicEndpointRejectsRequestsE.
+func AssertPublicEndpointRejectsRequests(t testing.TestingT, endpointURL string) {
+	rejected, err := PublicEndpointRejectsRequestsE(endpointURL)
+	if assert.NoError(t, err) {
+		assert.Truef(t, rejected, "Public endpoint %s accepts requests", endpointURL)
+	}
+}
+
+// GetResourcePublicNetworkAccess gets the publicNetworkAccess property of the resource with the given ID.
+// This function would fail the test if there is an error.
+func GetResourcePublicNetworkAccess(t testing.TestingT, resourceID string, subscriptionID string) string {
+	access, err := GetResourcePublicNetworkAccessE(resourceID, subscriptionID)
+	require.NoError(t, err)
+
+	return access
+}
+
+// GetResourcePublicNetworkAccessE gets the publicNetworkAccess property, e.g., Enabled or Disabled, of the resource
+// with the given ID, for any resource type that has one. The resource is read with the latest stable API version of
+// its type.
+func GetResourcePublicNetworkAccessE(resourceID string, subscriptionID string) (string, error) {
+	properties, err := getResourcePropertiesE(resourceID, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	access, ok := properties["publicNetworkAccess"].(string)
+	if !ok {
+		return "", NewNotFoundError("publicNetworkAccess property", "Any", resourceID)
+	}
+
+	return access, nil
+}
+
+// AssertPublicNetworkAccessDisabled checks that public network access is disabled on the resource with the given ID.
+func AssertPublicNetworkAccessDisabled(t testing.TestingT, resourceID string, subscriptionID string) {
+	access, err := GetResourcePublicNetworkAccessE(resourceID, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, "Disabled", access, "Public network access of resource %s is not disabled", resourceID)
+	}
+}
+
+// getResourcePropertiesE reads the properties of the resource with the given ID with the latest stable API version
+// of its type.
+func getResourcePropertiesE(resourceID string, subscriptionID string) (map[string]interface{}, error) {
+	namespace, resourceType, err := parseResourceProviderType(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	providersClient, err := CreateProvidersClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := providersClient.Get(context.Background(), namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersion := ""
+	for _, providerType := range provider.ResourceTypes {
+		if strings.EqualFold(safePtrToString(providerType.ResourceType), resourceType) {
+			apiVersion = latestStableAPIVersion(safePtrToList(providerType.APIVersions))
+		}
+	}
+	if apiVersion == "" {
+		return nil, NewNotFoundError("API version", resourceType, namespace)
+	}
+
+	client, err := CreateResourcesClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := client.GetByID(context.Background(), resourceID, apiVersion, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, ok := resource.Properties.(map[string]interface{})
+	if !ok {
+		return nil, NewFailedToParseError("resource properties", resourceID)
+	}
+
+	return properties, nil
+}
+
+// parseResourceProviderType returns the provider namespace and resource type of the resource with the given ID, e.g.,
+// Microsoft.Sql and servers/databases for a SQL database.
+func parseResourceProviderType(resourceID string) (string, string, error) {
+	index := strings.LastIndex(strings.ToLower(resourceID), "/providers/")
+	if index < 0 {
+		return "", "", NewFailedToParseError("resource ID", resourceID)
+	}
+
+	segments := strings.Split(strings.Trim(resourceID[index+len("/providers/"):], "/"), "/")
+	if len(segments) < 3 || len(segments)%2 == 0 {
+		return "", "", NewFailedToParseError("resource ID", resourceID)
+	}
+
+	types := []string{}
+	for i := 1; i < len(segments); i += 2 {
+		types = append(types, segments[i])
+	}
+
+	return segments[0], strings.Join(types, "/"), nil
+}
+
+// latestStableAPIVersion returns the latest of the given API versions that isn't a preview, or the latest preview if
+// all of them are.
+func latestStableAPIVersion(apiVersions []string) string {
+	latest := ""
+	latestPreview := ""
+	for _, version := range apiVersions {
+		if strings.Contains(version, "preview") {
+			if version > latestPreview {
+				latestPreview = version
+			}
+		} else if version > latest {
+			latest = version
+		}
+	}
+	if latest == "" {
+		return latestPreview
+	}
+
+	return latest
+}
@@ -0,0 +1,154 @@
+//go:build azure || (azureslim && network)
+// +build azure azureslim,network
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const storageAccountID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/mystorage"
+
+func TestPrivateEndpointConnections(t *testing.T) {
+	t.Parallel()
+
+	privateEndpoint := &armnetwork.PrivateEndpoint{
+		ID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe"),
+		Properties: &armnetwork.PrivateEndpointProperties{
+			PrivateLinkServiceConnections: []*armnetwork.PrivateLinkServiceConnection{{
+				Properties: &armnetwork.PrivateLinkServiceConnectionProperties{
+					PrivateLinkServiceID:              to.Ptr(storageAccountID),
+					GroupIDs:                          to.SliceOfPtrs("blob"),
+					PrivateLinkServiceConnectionState: &armnetwork.PrivateLinkServiceConnectionState{Status: to.Ptr("Approved")},
+				},
+			}},
+			ManualPrivateLinkServiceConnections: []*armnetwork.PrivateLinkServiceConnection{{
+				Properties: &armnetwork.PrivateLinkServiceConnectionProperties{
+					PrivateLinkServiceID:              to.Ptr(storageAccountID),
+					GroupIDs:                          to.SliceOfPtrs("file"),
+					PrivateLinkServiceConnectionState: &armnetwork.PrivateLinkServiceConnectionState{Status: to.Ptr("Pending"), Description: to.Ptr("Awaiting approval")},
+				},
+			}},
+		},
+	}
+
+	connections := privateEndpointConnections(privateEndpoint)
+	require.Len(t, connections, 2)
+	assert.Equal(t, PrivateEndpointConnectionSummary{
+		PrivateEndpointID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/pe",
+		TargetResourceID:  storageAccountID,
+		GroupIDs:          []string{"blob"},
+		Status:            "Approved",
+	}, connections[0])
+	assert.Equal(t, "Pending", connections[1].Status)
+	assert.Equal(t, "Awaiting approval", connections[1].Description)
+}
+
+func TestPrivateEndpointDNSRecords(t *testing.T) {
+	t.Parallel()
+
+	privateEndpoint := &armnetwork.PrivateEndpoint{
+		Properties: &armnetwork.PrivateEndpointProperties{
+			CustomDNSConfigs: []*armnetwork.CustomDNSConfigPropertiesFormat{
+				{Fqdn: to.Ptr("mystorage.blob.core.windows.net"), IPAddresses: to.SliceOfPtrs("10.0.1.4")},
+			},
+		},
+	}
+	assert.Equal(t, []PrivateEndpointDNSRecord{{FQDN: "mystorage.blob.core.windows.net", IPAddresses: []string{"10.0.1.4"}}}, privateEndpointDNSRecords(privateEndpoint, nil))
+
+	zoneGroups := []*armnetwork.PrivateDNSZoneGroup{{
+		Properties: &armnetwork.PrivateDNSZoneGroupPropertiesFormat{
+			PrivateDNSZoneConfigs: []*armnetwork.PrivateDNSZoneConfig{{
+				Properties: &armnetwork.PrivateDNSZonePropertiesFormat{
+					RecordSets: []*armnetwork.RecordSet{
+						{Fqdn: to.Ptr("mystorage.privatelink.blob.core.windows.net."), IPAddresses: to.SliceOfPtrs("10.0.1.5")},
+					},
+				},
+			}},
+		},
+	}}
+	assert.Equal(t, []PrivateEndpointDNSRecord{{FQDN: "mystorage.privatelink.blob.core.windows.net", IPAddresses: []string{"10.0.1.5"}}}, privateEndpointDNSRecords(privateEndpoint, zoneGroups))
+}
+
+func TestPrivateDNSRecordNameInZone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fqdn     string
+		zoneName string
+		wantName string
+		wantOK   bool
+	}{
+		{fqdn: "myvault.privatelink.vaultcore.azure.net", zoneName: "privatelink.vaultcore.azure.net", wantName: "myvault", wantOK: true},
+		{fqdn: "mystorage.blob.core.windows.net", zoneName: "privatelink.blob.core.windows.net", wantName: "mystorage", wantOK: true},
+		{fqdn: "MyStorage.blob.core.windows.net", zoneName: "privatelink.blob.core.windows.net.", wantName: "mystorage", wantOK: true},
+		{fqdn: "mystorage.file.core.windows.net", zoneName: "privatelink.blob.core.windows.net", wantOK: false},
+		{fqdn: "blob.core.windows.net", zoneName: "privatelink.blob.core.windows.net", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		name, ok := privateDNSRecordNameInZone(tt.fqdn, tt.zoneName)
+		assert.Equal(t, tt.wantOK, ok, tt.fqdn)
+		assert.Equal(t, tt.wantName, name, tt.fqdn)
+	}
+}
+
+func TestParseResourceProviderType(t *testing.T) {
+	t.Parallel()
+
+	namespace, resourceType, err := parseResourceProviderType(storageAccountID)
+	require.NoError(t, err)
+	assert.Equal(t, "Microsoft.Storage", namespace)
+	assert.Equal(t, "storageAccounts", resourceType)
+
+	namespace, resourceType, err = parseResourceProviderType("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Sql/servers/srv/databases/db")
+	require.NoError(t, err)
+	assert.Equal(t, "Microsoft.Sql", namespace)
+	assert.Equal(t, "servers/databases", resourceType)
+
+	_, _, err = parseResourceProviderType("/subscriptions/sub/resourceGroups/rg")
+	require.Error(t, err)
+}
+
+func TestLatestStableAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "2023-05-01", latestStableAPIVersion([]string{"2024-01-01-preview", "2023-05-01", "2022-09-01"}))
+	assert.Equal(t, "2024-01-01-preview", latestStableAPIVersion([]string{"2023-01-01-preview", "2024-01-01-preview"}))
+}
+
+func TestPublicEndpointRejectsRequests(t *testing.T) {
+	t.Parallel()
+
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+	rejected, err := PublicEndpointRejectsRequestsE(forbidden.URL)
+	require.NoError(t, err)
+	assert.True(t, rejected)
+
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer open.Close()
+	rejected, err = PublicEndpointRejectsRequestsE(open.URL)
+	require.NoError(t, err)
+	assert.False(t, rejected)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	rejected, err = PublicEndpointRejectsRequestsE(closed.URL)
+	require.NoError(t, err)
+	assert.True(t, rejected)
+}
@@ -3,7 +3,7 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -45,7 +45,7 @@ func GetIPOfPublicIPAddressByNameE(publicAddressName string, resGroupName string
 		return "", err
 	}
 
-	return *pip.IPAddress, nil
+	return *pip.Properties.IPAddress, nil
 }
 
 // CheckPublicDNSNameAvailability checks whether a Domain Name in the cloudapp.azure.com zone
@@ -60,12 +60,12 @@ func CheckPublicDNSNameAvailability(t testing.TestingT, location string, domainN
 
 // CheckPublicDNSNameAvailabilityE checks whether a Domain Name in the cloudapp.azure.com zone is available for use.
 func CheckPublicDNSNameAvailabilityE(location string, domainNameLabel string, subscriptionID string) (bool, error) {
-	client, err := GetPublicIPAddressClientE(subscriptionID)
+	client, err := CreateNetworkManagementClientE(subscriptionID)
 	if err != nil {
 		return false, err
 	}
 
-	res, err := client.CheckDNSNameAvailability(context.Background(), location, domainNameLabel)
+	res, err := client.CheckDNSNameAvailability(context.Background(), location, domainNameLabel, nil)
 	if err != nil {
 		return false, err
 	}
@@ -74,7 +74,7 @@ func CheckPublicDNSNameAvailabilityE(location string, domainNameLabel string, su
 }
 
 // GetPublicIPAddressE gets a Public IP Addresses in the specified Azure Resource Group.
-func GetPublicIPAddressE(publicIPAddressName string, resGroupName string, subscriptionID string) (*network.PublicIPAddress, error) {
+func GetPublicIPAddressE(publicIPAddressName string, resGroupName string, subscriptionID string) (*armnetwork.PublicIPAddress, error) {
 	// Validate resource group name and subscription ID
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -88,27 +88,15 @@ func GetPublicIPAddressE(publicIPAddressName string, resGroupName string, subscr
 	}
 
 	// Get the Public IP Address
-	pip, err := client.Get(context.Background(), resGroupName, publicIPAddressName, "")
+	pip, err := client.Get(context.Background(), resGroupName, publicIPAddressName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &pip, nil
+	return &pip.PublicIPAddress, nil
 }
 
 // GetPublicIPAddressClientE creates a Public IP Addresses client in the specified Azure Subscription.
-func GetPublicIPAddressClientE(subscriptionID string) (*network.PublicIPAddressesClient, error) {
+func GetPublicIPAddressClientE(subscriptionID string) (*armnetwork.PublicIPAddressesClient, error) {
 	// Get the Public IP Address client from clientfactory
-	client, err := CreatePublicIPAddressesClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return client, nil
+	return CreatePublicIPAddressesClientE(subscriptionID)
 }
@@ -0,0 +1,241 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RoleAssignmentSummary summarizes a role assignment.
+type RoleAssignmentSummary struct {
+	ID               string
+	PrincipalID      string // The object ID of the assignee: the principal itself, or one of its groups
+	PrincipalType    string // e.g., User, Group or ServicePrincipal
+	RoleDefinitionID string
+	RoleName         string // e.g., Reader, or the name of a custom role
+	Scope            string
+}
+
+// ListRoleAssignmentsForPrincipal lists the role assignments of the given principal at, above or below the given
+// scope. See ListRoleAssignmentsForPrincipalE.
+func ListRoleAssignmentsForPrincipal(t testing.TestingT, principalID string, scope string, includeGroups bool, subscriptionID string) []RoleAssignmentSummary {
+	assignments, err := ListRoleAssignmentsForPrincipalE(principalID, scope, includeGroups, subscriptionID)
+	require.NoError(t, err)
+
+	return assignments
+}
+
+// ListRoleAssignmentsForPrincipalE lists the role assignments of the given principal at, above or below the given
+// scope, e.g., /subscriptions/{id}/resourceGroups/{name}, with their role names. If includeGroups is set, the
+// assignments of the groups the principal is a transitive member of are included too. This requires the identity of
+// the test to be allowed to read group memberships in Microsoft Graph, e.g., with GroupMember.Read.All.
+func ListRoleAssignmentsForPrincipalE(principalID string, scope string, includeGroups bool, subscriptionID string) ([]RoleAssignmentSummary, error) {
+	principalIDs := []string{principalID}
+	if includeGroups {
+		groupIDs, err := GetPrincipalTransitiveGroupIDsE(principalID)
+		if err != nil {
+			return nil, err
+		}
+		principalIDs = append(principalIDs, groupIDs...)
+	}
+
+	client, err := CreateRoleAssignmentsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	roleNames := map[string]string{}
+
+	assignments := []RoleAssignmentSummary{}
+	for _, id := range principalIDs {
+		pager := client.NewListForScopePager(scope, &armauthorization.RoleAssignmentsClientListForScopeOptions{
+			Filter: to.Ptr(fmt.Sprintf("principalId eq '%s'", id)),
+		})
+		for pager.More() {
+			page, err := pager.NextPage(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			for _, assignment := range page.Value {
+				summary := newRoleAssignmentSummary(assignment)
+				summary.RoleName, err = getRoleNameE(summary.RoleDefinitionID, roleNames)
+				if err != nil {
+					return nil, err
+				}
+				assignments = append(assignments, summary)
+			}
+		}
+	}
+
+	return assignments, nil
+}
+
+// newRoleAssignmentSummary summarizes the given role assignment, without its role name.
+func newRoleAssignmentSummary(assignment *armauthorization.RoleAssignment) RoleAssignmentSummary {
+	summary := RoleAssignmentSummary{ID: safePtrToString(assignment.ID)}
+	if assignment.Properties == nil {
+		return summary
+	}
+
+	summary.PrincipalID = safePtrToString(assignment.Properties.PrincipalID)
+	summary.PrincipalType = safeEnumPtrToString(assignment.Properties.PrincipalType)
+	summary.RoleDefinitionID = safePtrToString(assignment.Properties.RoleDefinitionID)
+	summary.Scope = safePtrToString(assignment.Properties.Scope)
+
+	return summary
+}
+
+// getRoleNameE returns the name of the role definition with the given ID, looking it up in the given cache first.
+func getRoleNameE(roleDefinitionID string, cache map[string]string) (string, error) {
+	if name, ok := cache[roleDefinitionID]; ok {
+		return name, nil
+	}
+
+	client, err := CreateRoleDefinitionsClientE()
+	if err != nil {
+		return "", err
+	}
+	definition, err := client.GetByID(context.Background(), roleDefinitionID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	name := ""
+	if definition.Properties != nil {
+		name = safePtrToString(definition.Properties.RoleName)
+	}
+	cache[roleDefinitionID] = name
+
+	return name, nil
+}
+
+// GetPrincipalTransitiveGroupIDs gets the object IDs of the groups the given principal is a direct or transitive
+// member of. This function would fail the test if there is an error.
+func GetPrincipalTransitiveGroupIDs(t testing.TestingT, principalID string) []string {
+	groupIDs, err := GetPrincipalTransitiveGroupIDsE(principalID)
+	require.NoError(t, err)
+
+	return groupIDs
+}
+
+// GetPrincipalTransitiveGroupIDsE gets the object IDs of the groups the given user, group or service principal is a
+// direct or transitive member of, with Microsoft Graph.
+func GetPrincipalTransitiveGroupIDsE(principalID string) ([]string, error) {
+	return GetPrincipalTransitiveGroupIDsWithOptionsE(principalID, authOptionsFromEnv())
+}
+
+// GetPrincipalTransitiveGroupIDsWithOptionsE gets the object IDs of the groups the given principal is a direct or
+// transitive member of, authenticating to Microsoft Graph according to the given AuthOptions.
+func GetPrincipalTransitiveGroupIDsWithOptionsE(principalID string, authOptions AuthOptions) ([]string, error) {
+	graphEndpoint, err := GetMicrosoftGraphEndpointE()
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{graphEndpoint + "/.default"}})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]bool{"securityEnabledOnly": false})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1.0/directoryObjects/%s/getMemberGroups", graphEndpoint, principalID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the groups of principal %s from Microsoft Graph: %s", principalID, resp.Status)
+	}
+
+	var groups struct {
+		Value []string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return nil, err
+	}
+
+	return groups.Value, nil
+}
+
+// PrincipalHasRoleAtScope indicates whether the given principal has the given role at the given scope. See
+// PrincipalHasRoleAtScopeE. This function would fail the test if there is an error.
+func PrincipalHasRoleAtScope(t testing.TestingT, principalID string, role string, scope string, includeGroups bool, subscriptionID string) bool {
+	hasRole, err := PrincipalHasRoleAtScopeE(principalID, role, scope, includeGroups, subscriptionID)
+	require.NoError(t, err)
+
+	return hasRole
+}
+
+// PrincipalHasRoleAtScopeE indicates whether the given principal has the given role, by name (e.g., Reader) or role
+// definition GUID, at the given scope, through an assignment at that scope or one it inherits from. If includeGroups
+// is set, the assignments of the groups the principal is a transitive member of count too.
+func PrincipalHasRoleAtScopeE(principalID string, role string, scope string, includeGroups bool, subscriptionID string) (bool, error) {
+	assignments, err := ListRoleAssignmentsForPrincipalE(principalID, scope, includeGroups, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	return roleAssignmentsGrantRoleAtScope(assignments, role, scope), nil
+}
+
+// AssertRoleAssigned checks that the given principal has the given role, by name or role definition GUID, at the
+// given scope, directly or through the groups it is a transitive member of.
+func AssertRoleAssigned(t testing.TestingT, principalID string, role string, scope string, subscriptionID string) {
+	hasRole, err := PrincipalHasRoleAtScopeE(principalID, role, scope, true, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, hasRole, "Principal %s does not have role %s at scope %s", principalID, role, scope)
+	}
+}
+
+// AssertRoleNotAssigned checks that the given principal doesn't have the given role, by name or role definition GUID,
+// at the given scope, neither directly nor through the groups it is a transitive member of.
+func AssertRoleNotAssigned(t testing.TestingT, principalID string, role string, scope string, subscriptionID string) {
+	hasRole, err := PrincipalHasRoleAtScopeE(principalID, role, scope, true, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Falsef(t, hasRole, "Principal %s has role %s at scope %s", principalID, role, scope)
+	}
+}
+
+// roleAssignmentsGrantRoleAtScope returns true if one of the given assignments grants the given role, by name or role
+// definition GUID, at the given scope.
+func roleAssignmentsGrantRoleAtScope(assignments []RoleAssignmentSummary, role string, scope string) bool {
+	for _, assignment := range assignments {
+		roleMatches := strings.EqualFold(assignment.RoleName, role) || strings.EqualFold(GetNameFromResourceID(assignment.RoleDefinitionID), role)
+		if roleMatches && scopeIncludes(assignment.Scope, scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scopeIncludes returns true if the given role assignment scope is the given scope or one of its ancestors.
+func scopeIncludes(assignmentScope string, scope string) bool {
+	assignmentScope = strings.ToLower(strings.TrimSuffix(assignmentScope, "/"))
+	scope = strings.ToLower(strings.TrimSuffix(scope, "/"))
+
+	return assignmentScope == "" || assignmentScope == scope || strings.HasPrefix(scope, assignmentScope+"/")
+}
@@ -0,0 +1,75 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const readerRoleDefinitionID = "/subscriptions/sub/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7"
+
+func TestNewRoleAssignmentSummary(t *testing.T) {
+	t.Parallel()
+
+	principalType := armauthorization.PrincipalTypeGroup
+	summary := newRoleAssignmentSummary(&armauthorization.RoleAssignment{
+		ID: to.Ptr("/subscriptions/sub/providers/Microsoft.Authorization/roleAssignments/id"),
+		Properties: &armauthorization.RoleAssignmentProperties{
+			PrincipalID:      to.Ptr("group"),
+			PrincipalType:    &principalType,
+			RoleDefinitionID: to.Ptr(readerRoleDefinitionID),
+			Scope:            to.Ptr("/subscriptions/sub"),
+		},
+	})
+
+	assert.Equal(t, RoleAssignmentSummary{
+		ID:               "/subscriptions/sub/providers/Microsoft.Authorization/roleAssignments/id",
+		PrincipalID:      "group",
+		PrincipalType:    "Group",
+		RoleDefinitionID: readerRoleDefinitionID,
+		Scope:            "/subscriptions/sub",
+	}, summary)
+}
+
+func TestRoleAssignmentsGrantRoleAtScope(t *testing.T) {
+	t.Parallel()
+
+	assignments := []RoleAssignmentSummary{
+		{RoleName: "Reader", RoleDefinitionID: readerRoleDefinitionID, Scope: "/subscriptions/sub/resourceGroups/rg"},
+	}
+
+	tests := []struct {
+		name  string
+		role  string
+		scope string
+		want  bool
+	}{
+		{name: "SameScope", role: "Reader", scope: "/subscriptions/sub/resourceGroups/rg", want: true},
+		{name: "RoleNameIgnoresCase", role: "reader", scope: "/subscriptions/sub/resourceGroups/RG", want: true},
+		{name: "RoleDefinitionGUID", role: "acdd72a7-3385-48ef-bd42-f606fba81ae7", scope: "/subscriptions/sub/resourceGroups/rg", want: true},
+		{name: "InheritedScope", role: "Reader", scope: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa", want: true},
+		{name: "ParentScope", role: "Reader", scope: "/subscriptions/sub", want: false},
+		{name: "SiblingScopeWithSamePrefix", role: "Reader", scope: "/subscriptions/sub/resourceGroups/rg2", want: false},
+		{name: "OtherRole", role: "Contributor", scope: "/subscriptions/sub/resourceGroups/rg", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, roleAssignmentsGrantRoleAtScope(assignments, tt.role, tt.scope))
+		})
+	}
+}
+
+func TestScopeIncludesRoot(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, scopeIncludes("/", "/subscriptions/sub"))
+}
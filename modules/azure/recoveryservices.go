@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/recoveryservices/mgmt/2016-06-01/recoveryservices"
-	"github.com/Azure/azure-sdk-for-go/services/recoveryservices/mgmt/2020-02-02/backup"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/recoveryservices/armrecoveryservices"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/recoveryservices/armrecoveryservicesbackup/v4"
 	"github.com/stretchr/testify/require"
 )
 
@@ -20,7 +20,7 @@ func RecoveryServicesVaultExists(t *testing.T, vaultName, resourceGroupName, sub
 
 // GetRecoveryServicesVaultBackupPolicyList returns a list of backup policies for the given vault.
 // This function would fail the test if there is an error.
-func GetRecoveryServicesVaultBackupPolicyList(t *testing.T, vaultName, resourceGroupName, subscriptionID string) map[string]backup.ProtectionPolicyResource {
+func GetRecoveryServicesVaultBackupPolicyList(t *testing.T, vaultName, resourceGroupName, subscriptionID string) map[string]armrecoveryservicesbackup.ProtectionPolicyResource {
 	list, err := GetRecoveryServicesVaultBackupPolicyListE(vaultName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return list
@@ -28,7 +28,7 @@ func GetRecoveryServicesVaultBackupPolicyList(t *testing.T, vaultName, resourceG
 
 // GetRecoveryServicesVaultBackupProtectedVMList returns a list of protected VM's on the given vault/policy.
 // This function would fail the test if there is an error.
-func GetRecoveryServicesVaultBackupProtectedVMList(t *testing.T, policyName, vaultName, resourceGroupName, subscriptionID string) map[string]backup.AzureIaaSComputeVMProtectedItem {
+func GetRecoveryServicesVaultBackupProtectedVMList(t *testing.T, policyName, vaultName, resourceGroupName, subscriptionID string) map[string]armrecoveryservicesbackup.AzureIaaSComputeVMProtectedItem {
 	list, err := GetRecoveryServicesVaultBackupProtectedVMListE(policyName, vaultName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return list
@@ -47,105 +47,80 @@ func RecoveryServicesVaultExistsE(vaultName, resourceGroupName, subscriptionID s
 }
 
 // GetRecoveryServicesVaultE returns a vault instance.
-func GetRecoveryServicesVaultE(vaultName, resourceGroupName, subscriptionID string) (*recoveryservices.Vault, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+func GetRecoveryServicesVaultE(vaultName, resourceGroupName, subscriptionID string) (*armrecoveryservices.Vault, error) {
+	resourceGroupName, err := getTargetAzureResourceGroupName((resourceGroupName))
 	if err != nil {
 		return nil, err
 	}
 
-	resourceGroupName, err2 := getTargetAzureResourceGroupName((resourceGroupName))
-	if err2 != nil {
-		return nil, err2
-	}
-
-	client := recoveryservices.NewVaultsClient(subscriptionID)
-	// setup auth and create request params
-	authorizer, err := NewAuthorizer()
+	client, err := CreateRecoveryServicesVaultsClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	client.Authorizer = *authorizer
-	vault, err := client.Get(context.Background(), resourceGroupName, vaultName)
+	vault, err := client.Get(context.Background(), resourceGroupName, vaultName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &vault, nil
+	return &vault.Vault, nil
 }
 
 // GetRecoveryServicesVaultBackupPolicyListE returns a list of backup policies for the given vault.
-func GetRecoveryServicesVaultBackupPolicyListE(vaultName, resourceGroupName, subscriptionID string) (map[string]backup.ProtectionPolicyResource, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+func GetRecoveryServicesVaultBackupPolicyListE(vaultName, resourceGroupName, subscriptionID string) (map[string]armrecoveryservicesbackup.ProtectionPolicyResource, error) {
+	resourceGroupName, err := getTargetAzureResourceGroupName(resourceGroupName)
 	if err != nil {
 		return nil, err
 	}
 
-	resourceGroupName, err2 := getTargetAzureResourceGroupName(resourceGroupName)
-	if err2 != nil {
-		return nil, err2
-	}
-
-	client := backup.NewPoliciesClient(subscriptionID)
-	// setup authorizer
-	authorizer, err := NewAuthorizer()
+	client, err := CreateRecoveryServicesBackupPoliciesClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	client.Authorizer = *authorizer
-	listIter, err := client.ListComplete(context.Background(), vaultName, resourceGroupName, "")
-	if err != nil {
-		return nil, err
-	}
-
-	policyMap := make(map[string]backup.ProtectionPolicyResource)
-	for listIter.NotDone() {
-		v := listIter.Value()
-		policyMap[*v.Name] = v
-		err := listIter.NextWithContext(context.Background())
+	policyMap := make(map[string]armrecoveryservicesbackup.ProtectionPolicyResource)
+	pager := client.NewListPager(vaultName, resourceGroupName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
 		if err != nil {
 			return nil, err
 		}
-
+		for _, v := range page.Value {
+			policyMap[*v.Name] = *v
+		}
 	}
 	return policyMap, nil
 }
 
 // GetRecoveryServicesVaultBackupProtectedVMListE returns a list of protected VM's on the given vault/policy.
-func GetRecoveryServicesVaultBackupProtectedVMListE(policyName, vaultName, resourceGroupName, subscriptionID string) (map[string]backup.AzureIaaSComputeVMProtectedItem, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+func GetRecoveryServicesVaultBackupProtectedVMListE(policyName, vaultName, resourceGroupName, subscriptionID string) (map[string]armrecoveryservicesbackup.AzureIaaSComputeVMProtectedItem, error) {
+	resourceGroupName, err := getTargetAzureResourceGroupName(resourceGroupName)
 	if err != nil {
 		return nil, err
 	}
 
-	resourceGroupName, err = getTargetAzureResourceGroupName(resourceGroupName)
+	client, err := CreateRecoveryServicesBackupProtectedItemsClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	client := backup.NewProtectedItemsGroupClient(subscriptionID)
-	// setup authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
 	// Build a filter string to narrow down results to just VM's
 	filter := fmt.Sprintf("backupManagementType eq 'AzureIaasVM' and itemType eq 'VM' and policyName eq '%s'", policyName)
-	listIter, err := client.ListComplete(context.Background(), vaultName, resourceGroupName, filter, "")
-	if err != nil {
-		return nil, err
-	}
+	pager := client.NewListPager(vaultName, resourceGroupName, &armrecoveryservicesbackup.BackupProtectedItemsClientListOptions{Filter: &filter})
+
 	// Prep the return container
-	vmList := make(map[string]backup.AzureIaaSComputeVMProtectedItem)
-	// First iterator check
-	for listIter.NotDone() {
-		currentVM, _ := listIter.Value().Properties.AsAzureIaaSComputeVMProtectedItem()
-		vmList[*currentVM.FriendlyName] = *currentVM
-		err := listIter.NextWithContext(context.Background())
+	vmList := make(map[string]armrecoveryservicesbackup.AzureIaaSComputeVMProtectedItem)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
 		if err != nil {
 			return nil, err
 		}
+		for _, item := range page.Value {
+			currentVM, ok := item.Properties.(*armrecoveryservicesbackup.AzureIaaSComputeVMProtectedItem)
+			if !ok {
+				continue
+			}
+			vmList[*currentVM.FriendlyName] = *currentVM
+		}
 	}
 	return vmList, nil
 }
@@ -149,16 +149,17 @@ func GetAllAzureRegionsE(t testing.TestingT, subscriptionID string) ([]string, e
 		return nil, err
 	}
 
-	// Get list of Azure locations
-	out, err := subscriptionClient.ListLocations(context.Background(), subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Populate a return slice
+	// Get list of Azure locations and populate a return slice
 	regions := []string{}
-	for _, region := range *out.Value {
-		regions = append(regions, *region.Name)
+	pager := subscriptionClient.NewListLocationsPager(subscriptionID, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, region := range page.Value {
+			regions = append(regions, *region.Name)
+		}
 	}
 
 	return regions, nil
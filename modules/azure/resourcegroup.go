@@ -2,13 +2,12 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2020-10-01/resources"
-	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,74 +44,66 @@ func GetResourceGroupE(resourceGroupName, subscriptionID string) (bool, error) {
 
 // GetResourceGroupClientE gets a resource group client in a subscription
 // TODO: remove in next version
-func GetResourceGroupClientE(subscriptionID string) (*resources.GroupsClient, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-	resourceGroupClient := resources.NewGroupsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	resourceGroupClient.Authorizer = *authorizer
-	return &resourceGroupClient, nil
+func GetResourceGroupClientE(subscriptionID string) (*armresources.ResourceGroupsClient, error) {
+	return CreateResourceGroupClientE(subscriptionID)
 }
 
 // GetAResourceGroup returns a resource group within a subscription
 // This function would fail the test if there is an error.
-func GetAResourceGroup(t *testing.T, resourceGroupName string, subscriptionID string) *resources.Group {
+func GetAResourceGroup(t *testing.T, resourceGroupName string, subscriptionID string) *armresources.ResourceGroup {
 	rg, err := GetAResourceGroupE(resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 	return rg
 }
 
 // GetAResourceGroupE gets a resource group within a subscription
-func GetAResourceGroupE(resourceGroupName, subscriptionID string) (*resources.Group, error) {
+func GetAResourceGroupE(resourceGroupName, subscriptionID string) (*armresources.ResourceGroup, error) {
 	client, err := CreateResourceGroupClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	rg, err := client.Get(context.Background(), resourceGroupName)
+	rg, err := client.Get(context.Background(), resourceGroupName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &rg, nil
+	return &rg.ResourceGroup, nil
 }
 
 // ListResourceGroupsByTag returns a resource group list within a subscription based on a tag key
 // This function would fail the test if there is an error.
-func ListResourceGroupsByTag(t *testing.T, tag, subscriptionID string) []resources.Group {
+func ListResourceGroupsByTag(t *testing.T, tag, subscriptionID string) []armresources.ResourceGroup {
 	rg, err := ListResourceGroupsByTagE(tag, subscriptionID)
 	require.NoError(t, err)
 	return rg
 }
 
 // ListResourceGroupsByTagE returns a resource group list within a subscription based on a tag key
-func ListResourceGroupsByTagE(tag string, subscriptionID string) ([]resources.Group, error) {
+func ListResourceGroupsByTagE(tag string, subscriptionID string) ([]armresources.ResourceGroup, error) {
 	client, err := CreateResourceGroupClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	rg, err := client.List(context.Background(), fmt.Sprintf("tagName eq '%s'", tag), nil)
-	if err != nil {
-		return nil, err
+	filter := fmt.Sprintf("tagName eq '%s'", tag)
+	rgs := []armresources.ResourceGroup{}
+	pager := client.NewListPager(&armresources.ResourceGroupsClientListOptions{Filter: &filter})
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, rg := range page.Value {
+			rgs = append(rgs, *rg)
+		}
 	}
-	return rg.Values(), nil
+	return rgs, nil
 }
 
 func resourceGroupNotFoundError(err error) bool {
-	if err != nil {
-		if autorestError, ok := err.(autorest.DetailedError); ok {
-			if requestError, ok := autorestError.Original.(*azure.RequestError); ok {
-				return (requestError.ServiceError.Code == "ResourceGroupNotFound")
-			}
-		}
-		if azcoreErr, ok := err.(*azcore.ResponseError); ok {
-			return azcoreErr.ErrorCode == "ResourceGroupNotFound"
-		}
+	var azcoreErr *azcore.ResponseError
+	if errors.As(err, &azcoreErr) {
+		return azcoreErr.ErrorCode == "ResourceGroupNotFound"
 	}
 	return false
 }
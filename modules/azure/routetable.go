@@ -0,0 +1,98 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// RouteSummary is a string-based (non-pointer) summary of a user defined route.
+type RouteSummary struct {
+	Name             string
+	AddressPrefix    string
+	NextHopType      string
+	NextHopIPAddress string
+}
+
+// GetRouteTableRoutes gets the user defined routes of the given route table.
+// This function would fail the test if there is an error.
+func GetRouteTableRoutes(t testing.TestingT, routeTableName string, resGroupName string, subscriptionID string) []RouteSummary {
+	routes, err := GetRouteTableRoutesE(routeTableName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return routes
+}
+
+// GetRouteTableRoutesE gets the user defined routes of the given route table.
+func GetRouteTableRoutesE(routeTableName string, resGroupName string, subscriptionID string) ([]RouteSummary, error) {
+	routeTable, err := GetRouteTableE(routeTableName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []RouteSummary{}
+	if routeTable.Properties == nil {
+		return routes, nil
+	}
+	for _, route := range routeTable.Properties.Routes {
+		if route == nil || route.Properties == nil {
+			continue
+		}
+		routes = append(routes, RouteSummary{
+			Name:             safePtrToString(route.Name),
+			AddressPrefix:    safePtrToString(route.Properties.AddressPrefix),
+			NextHopType:      safeEnumPtrToString(route.Properties.NextHopType),
+			NextHopIPAddress: safePtrToString(route.Properties.NextHopIPAddress),
+		})
+	}
+	return routes, nil
+}
+
+// GetRouteForAddressPrefix gets the user defined route of the given route table for the given address prefix, e.g.,
+// 0.0.0.0/0 to check that internet traffic is forced through a firewall.
+// This function would fail the test if there is an error.
+func GetRouteForAddressPrefix(t testing.TestingT, addressPrefix string, routeTableName string, resGroupName string, subscriptionID string) RouteSummary {
+	route, err := GetRouteForAddressPrefixE(addressPrefix, routeTableName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return route
+}
+
+// GetRouteForAddressPrefixE gets the user defined route of the given route table for the given address prefix.
+func GetRouteForAddressPrefixE(addressPrefix string, routeTableName string, resGroupName string, subscriptionID string) (RouteSummary, error) {
+	routes, err := GetRouteTableRoutesE(routeTableName, resGroupName, subscriptionID)
+	if err != nil {
+		return RouteSummary{}, err
+	}
+
+	for _, route := range routes {
+		if route.AddressPrefix == addressPrefix {
+			return route, nil
+		}
+	}
+	return RouteSummary{}, NewNotFoundError("Route", addressPrefix, routeTableName)
+}
+
+// GetRouteTableE gets the given route table.
+func GetRouteTableE(routeTableName string, resGroupName string, subscriptionID string) (*armnetwork.RouteTable, error) {
+	// Validate Azure Resource Group
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the client reference
+	client, err := CreateRouteTablesClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the Route Table
+	routeTable, err := client.Get(context.Background(), resGroupName, routeTableName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &routeTable.RouteTable, nil
+}
@@ -0,0 +1,32 @@
+//go:build azure || (azureslim && network)
+// +build azure azureslim,network
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+/*
+The below tests are currently stubbed out, with the expectation that they will throw errors.
+If/when methods can be mocked or Create/Delete APIs are added, these tests can be extended.
+*/
+
+func TestGetRouteTableE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetRouteTableE("", "", "")
+	require.Error(t, err)
+}
+
+func TestGetRouteForAddressPrefixE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetRouteForAddressPrefixE("0.0.0.0/0", "", "", "")
+	require.Error(t, err)
+}
@@ -4,68 +4,46 @@ import (
 	"context"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/servicebus/mgmt/2017-04-01/servicebus"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/servicebus/armservicebus"
 	"github.com/stretchr/testify/require"
 )
 
-func serviceBusNamespaceClientE(subscriptionID string) (*servicebus.NamespacesClient, error) {
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	nsClient := servicebus.NewNamespacesClient(subscriptionID)
-	nsClient.Authorizer = *authorizer
-	return &nsClient, nil
+func serviceBusNamespaceClientE(subscriptionID string) (*armservicebus.NamespacesClient, error) {
+	return CreateServiceBusNamespacesClientE(subscriptionID)
 }
 
-func serviceBusTopicClientE(subscriptionID string) (*servicebus.TopicsClient, error) {
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	tClient := servicebus.NewTopicsClient(subscriptionID)
-	tClient.Authorizer = *authorizer
-	return &tClient, nil
+func serviceBusTopicClientE(subscriptionID string) (*armservicebus.TopicsClient, error) {
+	return CreateServiceBusTopicsClientE(subscriptionID)
 }
 
-func serviceBusSubscriptionsClientE(subscriptionID string) (*servicebus.SubscriptionsClient, error) {
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	sClient := servicebus.NewSubscriptionsClient(subscriptionID)
-	sClient.Authorizer = *authorizer
-	return &sClient, nil
+func serviceBusSubscriptionsClientE(subscriptionID string) (*armservicebus.SubscriptionsClient, error) {
+	return CreateServiceBusSubscriptionsClientE(subscriptionID)
 }
 
 // ListServiceBusNamespaceE list all SB namespaces in all resource groups in the given subscription ID.
-func ListServiceBusNamespaceE(subscriptionID string) ([]servicebus.SBNamespace, error) {
+func ListServiceBusNamespaceE(subscriptionID string) ([]armservicebus.SBNamespace, error) {
 	nsClient, err := serviceBusNamespaceClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	iteratorSBNamespace, err := nsClient.ListComplete(context.Background())
-	if err != nil {
-		return nil, err
-	}
-
-	results := make([]servicebus.SBNamespace, 0)
-	for iteratorSBNamespace.NotDone() {
-		results = append(results, iteratorSBNamespace.Value())
-		if err := iteratorSBNamespace.Next(); err != nil {
+	results := make([]armservicebus.SBNamespace, 0)
+	pager := nsClient.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, namespace := range page.Value {
+			results = append(results, *namespace)
+		}
 	}
 
 	return results, nil
 }
 
 // ListServiceBusNamespace - list all SB namespaces in all resource groups in the given subscription ID. This function would fail the test if there is an error.
-func ListServiceBusNamespace(t *testing.T, subscriptionID string) []servicebus.SBNamespace {
+func ListServiceBusNamespace(t *testing.T, subscriptionID string) []armservicebus.SBNamespace {
 	results, err := ListServiceBusNamespaceE(subscriptionID)
 
 	require.NoError(t, err)
@@ -86,7 +64,7 @@ func ListServiceBusNamespaceNamesE(subscriptionID string) ([]string, error) {
 }
 
 // BuildNamespaceNamesList helper method to build namespace name list
-func BuildNamespaceNamesList(sbNamespace []servicebus.SBNamespace) []string {
+func BuildNamespaceNamesList(sbNamespace []armservicebus.SBNamespace) []string {
 	results := []string{}
 	for _, namespace := range sbNamespace {
 		results = append(results, *namespace.Name)
@@ -97,7 +75,7 @@ func BuildNamespaceNamesList(sbNamespace []servicebus.SBNamespace) []string {
 }
 
 // BuildNamespaceIdsList helper method to build namespace id list
-func BuildNamespaceIdsList(sbNamespace []servicebus.SBNamespace) []string {
+func BuildNamespaceIdsList(sbNamespace []armservicebus.SBNamespace) []string {
 	results := []string{}
 	for _, namespace := range sbNamespace {
 		results = append(results, *namespace.ID)
@@ -137,31 +115,29 @@ func ListServiceBusNamespaceIDs(t *testing.T, subscriptionID string) []string {
 }
 
 // ListServiceBusNamespaceByResourceGroupE list all SB namespaces in the given resource group.
-func ListServiceBusNamespaceByResourceGroupE(subscriptionID string, resourceGroup string) ([]servicebus.SBNamespace, error) {
+func ListServiceBusNamespaceByResourceGroupE(subscriptionID string, resourceGroup string) ([]armservicebus.SBNamespace, error) {
 	nsClient, err := serviceBusNamespaceClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	iteratorSBNamespace, err := nsClient.ListByResourceGroupComplete(context.Background(), resourceGroup)
-	if err != nil {
-		return nil, err
-	}
-
-	results := make([]servicebus.SBNamespace, 0)
-
-	for iteratorSBNamespace.NotDone() {
-		results = append(results, iteratorSBNamespace.Value())
-		if err := iteratorSBNamespace.Next(); err != nil {
+	results := make([]armservicebus.SBNamespace, 0)
+	pager := nsClient.NewListByResourceGroupPager(resourceGroup, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, namespace := range page.Value {
+			results = append(results, *namespace)
+		}
 	}
 
 	return results, nil
 }
 
 // ListServiceBusNamespaceByResourceGroup list all SB namespaces in the given resource group. This function would fail the test if there is an error.
-func ListServiceBusNamespaceByResourceGroup(t *testing.T, subscriptionID string, resourceGroup string) []servicebus.SBNamespace {
+func ListServiceBusNamespaceByResourceGroup(t *testing.T, subscriptionID string, resourceGroup string) []armservicebus.SBNamespace {
 	results, err := ListServiceBusNamespaceByResourceGroupE(subscriptionID, resourceGroup)
 	require.NoError(t, err)
 
@@ -215,19 +191,17 @@ func ListNamespaceAuthRulesE(subscriptionID string, namespace string, resourceGr
 	if err != nil {
 		return nil, err
 	}
-	iteratorNamespaceRules, err := nsClient.ListAuthorizationRulesComplete(
-		context.Background(), resourceGroup, namespace)
-
-	if err != nil {
-		return nil, err
-	}
 
 	results := []string{}
-	for iteratorNamespaceRules.NotDone() {
-		results = append(results, *(iteratorNamespaceRules.Value()).Name)
-		if err := iteratorNamespaceRules.Next(); err != nil {
+	pager := nsClient.NewListAuthorizationRulesPager(resourceGroup, namespace, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, rule := range page.Value {
+			results = append(results, *rule.Name)
+		}
 	}
 	return results, nil
 }
@@ -242,31 +216,29 @@ func ListNamespaceAuthRules(t *testing.T, subscriptionID string, namespace strin
 }
 
 // ListNamespaceTopicsE - authenticate topic client and enumerates all values, automatically crossing page boundaries as required.
-func ListNamespaceTopicsE(subscriptionID string, namespace string, resourceGroup string) ([]servicebus.SBTopic, error) {
+func ListNamespaceTopicsE(subscriptionID string, namespace string, resourceGroup string) ([]armservicebus.SBTopic, error) {
 	tClient, err := serviceBusTopicClientE(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	iteratorTopics, err := tClient.ListByNamespaceComplete(context.Background(), resourceGroup, namespace, nil, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	results := make([]servicebus.SBTopic, 0)
-
-	for iteratorTopics.NotDone() {
-		results = append(results, iteratorTopics.Value())
-		if err := iteratorTopics.Next(); err != nil {
+	results := make([]armservicebus.SBTopic, 0)
+	pager := tClient.NewListByNamespacePager(resourceGroup, namespace, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, topic := range page.Value {
+			results = append(results, *topic)
+		}
 	}
 
 	return results, nil
 }
 
 // ListNamespaceTopics - authenticate topic client and enumerates all values, automatically crossing page boundaries as required. This function would fail the test if there is an error.
-func ListNamespaceTopics(t *testing.T, subscriptionID string, namespace string, resourceGroup string) []servicebus.SBTopic {
+func ListNamespaceTopics(t *testing.T, subscriptionID string, namespace string, resourceGroup string) []armservicebus.SBTopic {
 	results, err := ListNamespaceTopicsE(subscriptionID, namespace, resourceGroup)
 	require.NoError(t, err)
 
@@ -274,30 +246,28 @@ func ListNamespaceTopics(t *testing.T, subscriptionID string, namespace string,
 }
 
 // ListTopicSubscriptionsE - authenticate subscriptions client and enumerates all values, automatically crossing page boundaries as required.
-func ListTopicSubscriptionsE(subscriptionID string, namespace string, resourceGroup string, topicName string) ([]servicebus.SBSubscription, error) {
+func ListTopicSubscriptionsE(subscriptionID string, namespace string, resourceGroup string, topicName string) ([]armservicebus.SBSubscription, error) {
 	sClient, err := serviceBusSubscriptionsClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-	iteratorSubscription, err := sClient.ListByTopicComplete(context.Background(), resourceGroup, namespace, topicName, nil, nil)
-
 	if err != nil {
 		return nil, err
 	}
 
-	results := make([]servicebus.SBSubscription, 0)
-
-	for iteratorSubscription.NotDone() {
-		results = append(results, iteratorSubscription.Value())
-		if err := iteratorSubscription.Next(); err != nil {
+	results := make([]armservicebus.SBSubscription, 0)
+	pager := sClient.NewListByTopicPager(resourceGroup, namespace, topicName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, subscription := range page.Value {
+			results = append(results, *subscription)
+		}
 	}
 	return results, nil
 }
 
 // ListTopicSubscriptions - authenticate subscriptions client and enumerates all values, automatically crossing page boundaries as required. This function would fail the test if there is an error.
-func ListTopicSubscriptions(t *testing.T, subscriptionID string, namespace string, resourceGroup string, topicName string) []servicebus.SBSubscription {
+func ListTopicSubscriptions(t *testing.T, subscriptionID string, namespace string, resourceGroup string, topicName string) []armservicebus.SBSubscription {
 	results, err := ListTopicSubscriptionsE(subscriptionID, namespace, resourceGroup, topicName)
 	require.NoError(t, err)
 
@@ -308,21 +278,20 @@ func ListTopicSubscriptions(t *testing.T, subscriptionID string, namespace strin
 // automatically crossing page boundaries as required.
 func ListTopicSubscriptionsNameE(subscriptionID string, namespace string, resourceGroup string, topicName string) ([]string, error) {
 	sClient, err := serviceBusSubscriptionsClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-	iteratorSubscription, err := sClient.ListByTopicComplete(context.Background(), resourceGroup, namespace, topicName, nil, nil)
-
 	if err != nil {
 		return nil, err
 	}
 
 	results := []string{}
-	for iteratorSubscription.NotDone() {
-		results = append(results, *(iteratorSubscription.Value()).Name)
-		if err := iteratorSubscription.Next(); err != nil {
+	pager := sClient.NewListByTopicPager(resourceGroup, namespace, topicName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, subscription := range page.Value {
+			results = append(results, *subscription.Name)
+		}
 	}
 	return results, nil
 }
@@ -343,19 +312,17 @@ func ListTopicAuthRulesE(subscriptionID string, namespace string, resourceGroup
 	if err != nil {
 		return nil, err
 	}
-	iteratorTopicsRules, err := tClient.ListAuthorizationRulesComplete(
-		context.Background(), resourceGroup, namespace, topicName)
-
-	if err != nil {
-		return nil, err
-	}
 
 	results := []string{}
-	for iteratorTopicsRules.NotDone() {
-		results = append(results, *(iteratorTopicsRules.Value()).Name)
-		if err := iteratorTopicsRules.Next(); err != nil {
+	pager := tClient.NewListAuthorizationRulesPager(resourceGroup, namespace, topicName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
 			return nil, err
 		}
+		for _, rule := range page.Value {
+			results = append(results, *rule.Name)
+		}
 	}
 	return results, nil
 }
@@ -0,0 +1,554 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/servicebus/armservicebus"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultServiceBusReceiveWait is how long receiving waits for messages when ServiceBusReceiveOptions.MaxWait is unset.
+const defaultServiceBusReceiveWait = 30 * time.Second
+
+// ServiceBusMessage is a message sent to or received from a Service Bus queue or topic subscription. The sequence
+// number, enqueued time, delivery count and dead-letter fields are only set on received messages.
+type ServiceBusMessage struct {
+	Body       string
+	MessageID  string
+	SessionID  string
+	Properties map[string]interface{}
+
+	SequenceNumber             int64
+	EnqueuedTime               time.Time
+	DeliveryCount              uint32
+	DeadLetterReason           string
+	DeadLetterErrorDescription string
+}
+
+// ServiceBusReceiveOptions configures how messages are received from or peeked at a queue or topic subscription.
+type ServiceBusReceiveOptions struct {
+	MaxMessages int           // The maximum number of messages to return, 1 if unset
+	MaxWait     time.Duration // How long to wait for messages when receiving, 30 seconds if unset
+	SessionID   string        // The session to receive from, for entities that require sessions
+	DeadLetter  bool          // Receive from the dead-letter queue of the entity instead, which doesn't use sessions
+	AuthOptions *AuthOptions  // How to authenticate, from the TERRATEST_AZURE_AUTH_METHOD environment variables if unset
+}
+
+// ServiceBusEntitySettings summarizes the settings of a Service Bus queue, topic or topic subscription. Topics don't
+// have session, dead-lettering or delivery count settings, and topic subscriptions don't have duplicate detection.
+type ServiceBusEntitySettings struct {
+	RequiresSession                     bool
+	RequiresDuplicateDetection          bool
+	DuplicateDetectionHistoryTimeWindow string // An ISO 8601 duration, e.g., PT10M
+	DeadLetteringOnMessageExpiration    bool
+	MaxDeliveryCount                    int32
+	ActiveMessageCount                  int64
+	DeadLetterMessageCount              int64
+}
+
+// serviceBusReceiver is implemented by both the receivers and the session receivers of azservicebus.
+type serviceBusReceiver interface {
+	ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	PeekMessages(ctx context.Context, maxMessageCount int, options *azservicebus.PeekMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	CompleteMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error
+	Close(ctx context.Context) error
+}
+
+// SendServiceBusMessages sends the given messages to the specified Service Bus queue or topic.
+// This function would fail the test if there is an error.
+func SendServiceBusMessages(t testing.TestingT, namespace string, queueOrTopic string, messages []ServiceBusMessage) {
+	require.NoError(t, SendServiceBusMessagesE(namespace, queueOrTopic, messages))
+}
+
+// SendServiceBusMessagesE sends the given messages to the specified Service Bus queue or topic, authenticated with
+// NewTokenCredentialE. The namespace is either the name of the Service Bus namespace or its host name.
+func SendServiceBusMessagesE(namespace string, queueOrTopic string, messages []ServiceBusMessage) error {
+	return SendServiceBusMessagesWithOptionsE(namespace, queueOrTopic, messages, authOptionsFromEnv())
+}
+
+// SendServiceBusMessagesWithOptionsE sends the given messages to the specified Service Bus queue or topic like
+// SendServiceBusMessagesE, authenticated according to the given AuthOptions.
+func SendServiceBusMessagesWithOptionsE(namespace string, queueOrTopic string, messages []ServiceBusMessage, authOptions AuthOptions) error {
+	client, err := newServiceBusClientE(namespace, authOptions)
+	if err != nil {
+		return err
+	}
+	defer client.Close(context.Background())
+
+	sender, err := client.NewSender(queueOrTopic, nil)
+	if err != nil {
+		return err
+	}
+	defer sender.Close(context.Background())
+
+	for _, message := range messages {
+		if err := sender.SendMessage(context.Background(), newServiceBusOutgoingMessage(message), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReceiveServiceBusQueueMessages receives messages from the specified Service Bus queue.
+// See ReceiveServiceBusQueueMessagesE. This function would fail the test if there is an error.
+func ReceiveServiceBusQueueMessages(t testing.TestingT, namespace string, queue string, options ServiceBusReceiveOptions) []ServiceBusMessage {
+	messages, err := ReceiveServiceBusQueueMessagesE(namespace, queue, options)
+	require.NoError(t, err)
+
+	return messages
+}
+
+// ReceiveServiceBusQueueMessagesE receives up to options.MaxMessages messages from the specified Service Bus queue,
+// waiting up to options.MaxWait for them, and completes them so they are removed from the queue.
+func ReceiveServiceBusQueueMessagesE(namespace string, queue string, options ServiceBusReceiveOptions) ([]ServiceBusMessage, error) {
+	return receiveServiceBusMessagesE(namespace, queue, "", options, false)
+}
+
+// ReceiveServiceBusSubscriptionMessages receives messages from the specified Service Bus topic subscription.
+// See ReceiveServiceBusSubscriptionMessagesE. This function would fail the test if there is an error.
+func ReceiveServiceBusSubscriptionMessages(t testing.TestingT, namespace string, topic string, subscription string, options ServiceBusReceiveOptions) []ServiceBusMessage {
+	messages, err := ReceiveServiceBusSubscriptionMessagesE(namespace, topic, subscription, options)
+	require.NoError(t, err)
+
+	return messages
+}
+
+// ReceiveServiceBusSubscriptionMessagesE receives up to options.MaxMessages messages from the specified Service Bus
+// topic subscription, waiting up to options.MaxWait for them, and completes them so they are removed from the
+// subscription.
+func ReceiveServiceBusSubscriptionMessagesE(namespace string, topic string, subscription string, options ServiceBusReceiveOptions) ([]ServiceBusMessage, error) {
+	return receiveServiceBusMessagesE(namespace, topic, subscription, options, false)
+}
+
+// PeekServiceBusQueueMessages peeks at messages of the specified Service Bus queue.
+// See PeekServiceBusQueueMessagesE. This function would fail the test if there is an error.
+func PeekServiceBusQueueMessages(t testing.TestingT, namespace string, queue string, options ServiceBusReceiveOptions) []ServiceBusMessage {
+	messages, err := PeekServiceBusQueueMessagesE(namespace, queue, options)
+	require.NoError(t, err)
+
+	return messages
+}
+
+// PeekServiceBusQueueMessagesE peeks at up to options.MaxMessages messages of the specified Service Bus queue, from
+// the oldest one, without removing or locking them. Peeking doesn't wait for messages, so options.MaxWait is ignored.
+func PeekServiceBusQueueMessagesE(namespace string, queue string, options ServiceBusReceiveOptions) ([]ServiceBusMessage, error) {
+	return receiveServiceBusMessagesE(namespace, queue, "", options, true)
+}
+
+// PeekServiceBusSubscriptionMessages peeks at messages of the specified Service Bus topic subscription.
+// See PeekServiceBusSubscriptionMessagesE. This function would fail the test if there is an error.
+func PeekServiceBusSubscriptionMessages(t testing.TestingT, namespace string, topic string, subscription string, options ServiceBusReceiveOptions) []ServiceBusMessage {
+	messages, err := PeekServiceBusSubscriptionMessagesE(namespace, topic, subscription, options)
+	require.NoError(t, err)
+
+	return messages
+}
+
+// PeekServiceBusSubscriptionMessagesE peeks at up to options.MaxMessages messages of the specified Service Bus topic
+// subscription, from the oldest one, without removing or locking them. Peeking doesn't wait for messages, so
+// options.MaxWait is ignored.
+func PeekServiceBusSubscriptionMessagesE(namespace string, topic string, subscription string, options ServiceBusReceiveOptions) ([]ServiceBusMessage, error) {
+	return receiveServiceBusMessagesE(namespace, topic, subscription, options, true)
+}
+
+// receiveServiceBusMessagesE receives or peeks at messages of the given queue, or of the given topic subscription if
+// subscription is set.
+func receiveServiceBusMessagesE(namespace string, queueOrTopic string, subscription string, options ServiceBusReceiveOptions, peek bool) ([]ServiceBusMessage, error) {
+	client, err := newServiceBusClientE(namespace, authOptionsOrEnv(options.AuthOptions))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close(context.Background())
+
+	receiver, err := newServiceBusReceiverE(client, queueOrTopic, subscription, options)
+	if err != nil {
+		return nil, err
+	}
+	defer receiver.Close(context.Background())
+
+	maxMessages := options.MaxMessages
+	if maxMessages <= 0 {
+		maxMessages = 1
+	}
+	if peek {
+		return peekServiceBusMessagesE(receiver, maxMessages)
+	}
+
+	maxWait := options.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultServiceBusReceiveWait
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), maxWait)
+	defer cancel()
+
+	messages := []ServiceBusMessage{}
+	for len(messages) < maxMessages {
+		received, err := receiver.ReceiveMessages(ctx, maxMessages-len(messages), nil)
+		for _, message := range received {
+			if err := receiver.CompleteMessage(context.Background(), message, nil); err != nil {
+				return nil, err
+			}
+			messages = append(messages, newServiceBusMessage(message))
+		}
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return messages, nil
+}
+
+// peekServiceBusMessagesE peeks at up to maxMessages messages with the given receiver.
+func peekServiceBusMessagesE(receiver serviceBusReceiver, maxMessages int) ([]ServiceBusMessage, error) {
+	messages := []ServiceBusMessage{}
+	for len(messages) < maxMessages {
+		peeked, err := receiver.PeekMessages(context.Background(), maxMessages-len(messages), nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(peeked) == 0 {
+			break
+		}
+		for _, message := range peeked {
+			messages = append(messages, newServiceBusMessage(message))
+		}
+	}
+
+	return messages, nil
+}
+
+// newServiceBusReceiverE returns a receiver for the given queue, or for the given topic subscription if subscription
+// is set, according to the given options.
+func newServiceBusReceiverE(client *azservicebus.Client, queueOrTopic string, subscription string, options ServiceBusReceiveOptions) (serviceBusReceiver, error) {
+	if options.SessionID != "" && !options.DeadLetter {
+		if subscription == "" {
+			return client.AcceptSessionForQueue(context.Background(), queueOrTopic, options.SessionID, nil)
+		}
+		return client.AcceptSessionForSubscription(context.Background(), queueOrTopic, subscription, options.SessionID, nil)
+	}
+
+	receiverOptions := &azservicebus.ReceiverOptions{}
+	if options.DeadLetter {
+		receiverOptions.SubQueue = azservicebus.SubQueueDeadLetter
+	}
+	if subscription == "" {
+		return client.NewReceiverForQueue(queueOrTopic, receiverOptions)
+	}
+	return client.NewReceiverForSubscription(queueOrTopic, subscription, receiverOptions)
+}
+
+// newServiceBusClientE returns a Service Bus client for the given namespace, authenticated according to the given
+// AuthOptions.
+func newServiceBusClientE(namespace string, authOptions AuthOptions) (*azservicebus.Client, error) {
+	host, err := getServiceBusNamespaceHostE(namespace)
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return azservicebus.NewClient(host, cred, nil)
+}
+
+// getServiceBusNamespaceHostE returns the host name of the given Service Bus or Event Hubs namespace, unless it
+// already is one.
+func getServiceBusNamespaceHostE(namespace string) (string, error) {
+	if strings.Contains(namespace, ".") {
+		return namespace, nil
+	}
+
+	suffix, err := GetServiceBusEndpointSuffixE()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s", namespace, suffix), nil
+}
+
+// newServiceBusOutgoingMessage converts the given message to the message type azservicebus sends.
+func newServiceBusOutgoingMessage(message ServiceBusMessage) *azservicebus.Message {
+	outgoing := &azservicebus.Message{
+		Body:                  []byte(message.Body),
+		ApplicationProperties: message.Properties,
+	}
+	if message.MessageID != "" {
+		outgoing.MessageID = &message.MessageID
+	}
+	if message.SessionID != "" {
+		outgoing.SessionID = &message.SessionID
+	}
+
+	return outgoing
+}
+
+// newServiceBusMessage converts the given received message.
+func newServiceBusMessage(message *azservicebus.ReceivedMessage) ServiceBusMessage {
+	converted := ServiceBusMessage{
+		Body:                       string(message.Body),
+		MessageID:                  message.MessageID,
+		SessionID:                  safePtrToString(message.SessionID),
+		Properties:                 map[string]interface{}{},
+		DeliveryCount:              message.DeliveryCount,
+		DeadLetterReason:           safePtrToString(message.DeadLetterReason),
+		DeadLetterErrorDescription: safePtrToString(message.DeadLetterErrorDescription),
+	}
+	if message.SequenceNumber != nil {
+		converted.SequenceNumber = *message.SequenceNumber
+	}
+	if message.EnqueuedTime != nil {
+		converted.EnqueuedTime = *message.EnqueuedTime
+	}
+	for key, value := range message.ApplicationProperties {
+		converted.Properties[key] = value
+	}
+
+	return converted
+}
+
+// GetServiceBusQueueSettings gets the settings of the specified Service Bus queue.
+// This function would fail the test if there is an error.
+func GetServiceBusQueueSettings(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, queue string) ServiceBusEntitySettings {
+	settings, err := GetServiceBusQueueSettingsE(subscriptionID, namespace, resourceGroup, queue)
+	require.NoError(t, err)
+
+	return settings
+}
+
+// GetServiceBusQueueSettingsE gets the settings of the specified Service Bus queue.
+func GetServiceBusQueueSettingsE(subscriptionID string, namespace string, resourceGroup string, queue string) (ServiceBusEntitySettings, error) {
+	resourceGroup, err := getTargetAzureResourceGroupName(resourceGroup)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	client, err := CreateServiceBusQueuesClientE(subscriptionID)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	resp, err := client.Get(context.Background(), resourceGroup, namespace, queue, nil)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	return newServiceBusQueueSettings(resp.SBQueue), nil
+}
+
+// GetServiceBusTopicSettings gets the settings of the specified Service Bus topic.
+// This function would fail the test if there is an error.
+func GetServiceBusTopicSettings(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, topic string) ServiceBusEntitySettings {
+	settings, err := GetServiceBusTopicSettingsE(subscriptionID, namespace, resourceGroup, topic)
+	require.NoError(t, err)
+
+	return settings
+}
+
+// GetServiceBusTopicSettingsE gets the settings of the specified Service Bus topic.
+func GetServiceBusTopicSettingsE(subscriptionID string, namespace string, resourceGroup string, topic string) (ServiceBusEntitySettings, error) {
+	resourceGroup, err := getTargetAzureResourceGroupName(resourceGroup)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	client, err := serviceBusTopicClientE(subscriptionID)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	resp, err := client.Get(context.Background(), resourceGroup, namespace, topic, nil)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	return newServiceBusTopicSettings(resp.SBTopic), nil
+}
+
+// GetServiceBusSubscriptionSettings gets the settings of the specified Service Bus topic subscription.
+// This function would fail the test if there is an error.
+func GetServiceBusSubscriptionSettings(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, topic string, subscription string) ServiceBusEntitySettings {
+	settings, err := GetServiceBusSubscriptionSettingsE(subscriptionID, namespace, resourceGroup, topic, subscription)
+	require.NoError(t, err)
+
+	return settings
+}
+
+// GetServiceBusSubscriptionSettingsE gets the settings of the specified Service Bus topic subscription.
+func GetServiceBusSubscriptionSettingsE(subscriptionID string, namespace string, resourceGroup string, topic string, subscription string) (ServiceBusEntitySettings, error) {
+	resourceGroup, err := getTargetAzureResourceGroupName(resourceGroup)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	client, err := serviceBusSubscriptionsClientE(subscriptionID)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	resp, err := client.Get(context.Background(), resourceGroup, namespace, topic, subscription, nil)
+	if err != nil {
+		return ServiceBusEntitySettings{}, err
+	}
+
+	return newServiceBusSubscriptionSettings(resp.SBSubscription), nil
+}
+
+// AssertServiceBusQueueSessionsEnabled checks that the specified Service Bus queue requires sessions.
+func AssertServiceBusQueueSessionsEnabled(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, queue string) {
+	settings, err := GetServiceBusQueueSettingsE(subscriptionID, namespace, resourceGroup, queue)
+	if assert.NoError(t, err) {
+		assert.Truef(t, settings.RequiresSession, "Service Bus queue %s doesn't require sessions", queue)
+	}
+}
+
+// AssertServiceBusSubscriptionSessionsEnabled checks that the specified Service Bus topic subscription requires
+// sessions.
+func AssertServiceBusSubscriptionSessionsEnabled(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, topic string, subscription string) {
+	settings, err := GetServiceBusSubscriptionSettingsE(subscriptionID, namespace, resourceGroup, topic, subscription)
+	if assert.NoError(t, err) {
+		assert.Truef(t, settings.RequiresSession, "Service Bus subscription %s of topic %s doesn't require sessions", subscription, topic)
+	}
+}
+
+// AssertServiceBusQueueDuplicateDetectionEnabled checks that the specified Service Bus queue detects duplicate
+// messages.
+func AssertServiceBusQueueDuplicateDetectionEnabled(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, queue string) {
+	settings, err := GetServiceBusQueueSettingsE(subscriptionID, namespace, resourceGroup, queue)
+	if assert.NoError(t, err) {
+		assert.Truef(t, settings.RequiresDuplicateDetection, "Service Bus queue %s doesn't detect duplicate messages", queue)
+	}
+}
+
+// AssertServiceBusTopicDuplicateDetectionEnabled checks that the specified Service Bus topic detects duplicate
+// messages.
+func AssertServiceBusTopicDuplicateDetectionEnabled(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, topic string) {
+	settings, err := GetServiceBusTopicSettingsE(subscriptionID, namespace, resourceGroup, topic)
+	if assert.NoError(t, err) {
+		assert.Truef(t, settings.RequiresDuplicateDetection, "Service Bus topic %s doesn't detect duplicate messages", topic)
+	}
+}
+
+// AssertServiceBusQueueDeadLetterCount checks that the dead-letter queue of the specified Service Bus queue holds the
+// expected number of messages. The count is refreshed by Azure every few seconds.
+func AssertServiceBusQueueDeadLetterCount(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, queue string, expectedCount int64) {
+	settings, err := GetServiceBusQueueSettingsE(subscriptionID, namespace, resourceGroup, queue)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedCount, settings.DeadLetterMessageCount, "Unexpected number of dead-lettered messages of Service Bus queue %s", queue)
+	}
+}
+
+// AssertServiceBusSubscriptionDeadLetterCount checks that the dead-letter queue of the specified Service Bus topic
+// subscription holds the expected number of messages. The count is refreshed by Azure every few seconds.
+func AssertServiceBusSubscriptionDeadLetterCount(t testing.TestingT, subscriptionID string, namespace string, resourceGroup string, topic string, subscription string, expectedCount int64) {
+	settings, err := GetServiceBusSubscriptionSettingsE(subscriptionID, namespace, resourceGroup, topic, subscription)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedCount, settings.DeadLetterMessageCount, "Unexpected number of dead-lettered messages of Service Bus subscription %s of topic %s", subscription, topic)
+	}
+}
+
+// AssertServiceBusQueueDeadLettered checks that a message with the given body is in the dead-letter queue of the
+// specified Service Bus queue, without removing it.
+func AssertServiceBusQueueDeadLettered(t testing.TestingT, namespace string, queue string, body string) {
+	messages, err := PeekServiceBusQueueMessagesE(namespace, queue, ServiceBusReceiveOptions{MaxMessages: 100, DeadLetter: true})
+	if assert.NoError(t, err) {
+		assert.Truef(t, serviceBusMessagesContainBody(messages, body), "No message with body %q in the dead-letter queue of Service Bus queue %s", body, queue)
+	}
+}
+
+// AssertServiceBusSubscriptionDeadLettered checks that a message with the given body is in the dead-letter queue of
+// the specified Service Bus topic subscription, without removing it.
+func AssertServiceBusSubscriptionDeadLettered(t testing.TestingT, namespace string, topic string, subscription string, body string) {
+	messages, err := PeekServiceBusSubscriptionMessagesE(namespace, topic, subscription, ServiceBusReceiveOptions{MaxMessages: 100, DeadLetter: true})
+	if assert.NoError(t, err) {
+		assert.Truef(t, serviceBusMessagesContainBody(messages, body), "No message with body %q in the dead-letter queue of Service Bus subscription %s of topic %s", body, subscription, topic)
+	}
+}
+
+// serviceBusMessagesContainBody returns true if one of the given messages has the given body.
+func serviceBusMessagesContainBody(messages []ServiceBusMessage, body string) bool {
+	for _, message := range messages {
+		if message.Body == body {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newServiceBusQueueSettings summarizes the settings of the given queue.
+func newServiceBusQueueSettings(queue armservicebus.SBQueue) ServiceBusEntitySettings {
+	settings := ServiceBusEntitySettings{}
+	props := queue.Properties
+	if props == nil {
+		return settings
+	}
+
+	settings.RequiresSession = safePtrToBool(props.RequiresSession)
+	settings.RequiresDuplicateDetection = safePtrToBool(props.RequiresDuplicateDetection)
+	settings.DuplicateDetectionHistoryTimeWindow = safePtrToString(props.DuplicateDetectionHistoryTimeWindow)
+	settings.DeadLetteringOnMessageExpiration = safePtrToBool(props.DeadLetteringOnMessageExpiration)
+	settings.MaxDeliveryCount = safePtrToInt32(props.MaxDeliveryCount)
+	settings.ActiveMessageCount, settings.DeadLetterMessageCount = serviceBusMessageCounts(props.CountDetails)
+
+	return settings
+}
+
+// newServiceBusTopicSettings summarizes the settings of the given topic.
+func newServiceBusTopicSettings(topic armservicebus.SBTopic) ServiceBusEntitySettings {
+	settings := ServiceBusEntitySettings{}
+	props := topic.Properties
+	if props == nil {
+		return settings
+	}
+
+	settings.RequiresDuplicateDetection = safePtrToBool(props.RequiresDuplicateDetection)
+	settings.DuplicateDetectionHistoryTimeWindow = safePtrToString(props.DuplicateDetectionHistoryTimeWindow)
+	settings.ActiveMessageCount, settings.DeadLetterMessageCount = serviceBusMessageCounts(props.CountDetails)
+
+	return settings
+}
+
+// newServiceBusSubscriptionSettings summarizes the settings of the given topic subscription.
+func newServiceBusSubscriptionSettings(subscription armservicebus.SBSubscription) ServiceBusEntitySettings {
+	settings := ServiceBusEntitySettings{}
+	props := subscription.Properties
+	if props == nil {
+		return settings
+	}
+
+	settings.RequiresSession = safePtrToBool(props.RequiresSession)
+	settings.DeadLetteringOnMessageExpiration = safePtrToBool(props.DeadLetteringOnMessageExpiration)
+	settings.MaxDeliveryCount = safePtrToInt32(props.MaxDeliveryCount)
+	settings.ActiveMessageCount, settings.DeadLetterMessageCount = serviceBusMessageCounts(props.CountDetails)
+
+	return settings
+}
+
+// serviceBusMessageCounts returns the active and dead-lettered message counts of the given count details.
+func serviceBusMessageCounts(details *armservicebus.MessageCountDetails) (int64, int64) {
+	if details == nil {
+		return 0, 0
+	}
+
+	var active, deadLetter int64
+	if details.ActiveMessageCount != nil {
+		active = *details.ActiveMessageCount
+	}
+	if details.DeadLetterMessageCount != nil {
+		deadLetter = *details.DeadLetterMessageCount
+	}
+
+	return active, deadLetter
+}
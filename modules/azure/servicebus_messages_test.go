@@ -0,0 +1,98 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/servicebus/armservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetServiceBusNamespaceHost(t *testing.T) {
+	t.Setenv(AzureEnvironmentEnvName, "AzureUSGovernmentCloud")
+
+	host, err := getServiceBusNamespaceHostE("mynamespace")
+	require.NoError(t, err)
+	assert.Equal(t, "mynamespace.servicebus.usgovcloudapi.net", host)
+
+	host, err = getServiceBusNamespaceHostE("mynamespace.servicebus.windows.net")
+	require.NoError(t, err)
+	assert.Equal(t, "mynamespace.servicebus.windows.net", host)
+}
+
+func TestNewServiceBusOutgoingMessage(t *testing.T) {
+	t.Parallel()
+
+	message := newServiceBusOutgoingMessage(ServiceBusMessage{Body: "hello", SessionID: "order-1", Properties: map[string]interface{}{"source": "test"}})
+	assert.Equal(t, []byte("hello"), message.Body)
+	assert.Equal(t, "order-1", *message.SessionID)
+	assert.Nil(t, message.MessageID)
+	assert.Equal(t, map[string]interface{}{"source": "test"}, message.ApplicationProperties)
+}
+
+func TestNewServiceBusMessage(t *testing.T) {
+	t.Parallel()
+
+	enqueuedTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	message := newServiceBusMessage(&azservicebus.ReceivedMessage{
+		Body:             []byte("hello"),
+		MessageID:        "42",
+		SessionID:        to.Ptr("order-1"),
+		DeliveryCount:    10,
+		SequenceNumber:   to.Ptr[int64](7),
+		EnqueuedTime:     &enqueuedTime,
+		DeadLetterReason: to.Ptr("MaxDeliveryCountExceeded"),
+	})
+
+	assert.Equal(t, ServiceBusMessage{
+		Body:             "hello",
+		MessageID:        "42",
+		SessionID:        "order-1",
+		Properties:       map[string]interface{}{},
+		SequenceNumber:   7,
+		EnqueuedTime:     enqueuedTime,
+		DeliveryCount:    10,
+		DeadLetterReason: "MaxDeliveryCountExceeded",
+	}, message)
+}
+
+func TestNewServiceBusQueueSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := newServiceBusQueueSettings(armservicebus.SBQueue{Properties: &armservicebus.SBQueueProperties{
+		RequiresSession:                     to.Ptr(true),
+		RequiresDuplicateDetection:          to.Ptr(true),
+		DuplicateDetectionHistoryTimeWindow: to.Ptr("PT10M"),
+		MaxDeliveryCount:                    to.Ptr[int32](5),
+		CountDetails: &armservicebus.MessageCountDetails{
+			ActiveMessageCount:     to.Ptr[int64](3),
+			DeadLetterMessageCount: to.Ptr[int64](1),
+		},
+	}})
+
+	assert.Equal(t, ServiceBusEntitySettings{
+		RequiresSession:                     true,
+		RequiresDuplicateDetection:          true,
+		DuplicateDetectionHistoryTimeWindow: "PT10M",
+		MaxDeliveryCount:                    5,
+		ActiveMessageCount:                  3,
+		DeadLetterMessageCount:              1,
+	}, settings)
+	assert.Equal(t, ServiceBusEntitySettings{}, newServiceBusSubscriptionSettings(armservicebus.SBSubscription{}))
+}
+
+func TestGetServiceBusQueueSettingsE(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetServiceBusQueueSettingsE("", "", "", "")
+	require.Error(t, err)
+}
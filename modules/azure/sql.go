@@ -3,38 +3,21 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/profiles/preview/sql/mgmt/sql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/sql/armsql"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
 // GetSQLServerClient is a helper function that will setup a sql server client
 // TODO: remove in next version
-func GetSQLServerClient(subscriptionID string) (*sql.ServersClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetSQLServerClient(subscriptionID string) (*armsql.ServersClient, error) {
 	// Create a sql server client
-	sqlClient := sql.NewServersClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	sqlClient.Authorizer = *authorizer
-
-	return &sqlClient, nil
+	return CreateSQLServerClient(subscriptionID)
 }
 
 // GetSQLServer is a helper function that gets the sql server object.
 // This function would fail the test if there is an error.
-func GetSQLServer(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *sql.Server {
+func GetSQLServer(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *armsql.Server {
 	sqlServer, err := GetSQLServerE(t, subscriptionID, resGroupName, serverName)
 	require.NoError(t, err)
 
@@ -42,7 +25,7 @@ func GetSQLServer(t testing.TestingT, resGroupName string, serverName string, su
 }
 
 // GetSQLServerE is a helper function that gets the sql server object.
-func GetSQLServerE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) (*sql.Server, error) {
+func GetSQLServerE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string) (*armsql.Server, error) {
 	// Create a SQl Server client
 	sqlClient, err := CreateSQLServerClient(subscriptionID)
 	if err != nil {
@@ -50,41 +33,24 @@ func GetSQLServerE(t testing.TestingT, subscriptionID string, resGroupName strin
 	}
 
 	//Get the corresponding server client
-	sqlServer, err := sqlClient.Get(context.Background(), resGroupName, serverName)
+	sqlServer, err := sqlClient.Get(context.Background(), resGroupName, serverName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return sql server
-	return &sqlServer, nil
+	return &sqlServer.Server, nil
 }
 
 // GetDatabaseClient  is a helper function that will setup a sql DB client
 // TODO: remove in next version
-func GetDatabaseClient(subscriptionID string) (*sql.DatabasesClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
+func GetDatabaseClient(subscriptionID string) (*armsql.DatabasesClient, error) {
 	// Create a sql DB client
-	sqlDBClient := sql.NewDatabasesClient(subscriptionID)
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-
-	// Attach authorizer to the client
-	sqlDBClient.Authorizer = *authorizer
-
-	return &sqlDBClient, nil
+	return CreateDatabaseClient(subscriptionID)
 }
 
 // ListSQLServerDatabases is a helper function that gets a list of databases on a sql server
-func ListSQLServerDatabases(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *[]sql.Database {
+func ListSQLServerDatabases(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) *[]armsql.Database {
 	dbList, err := ListSQLServerDatabasesE(t, resGroupName, serverName, subscriptionID)
 	require.NoError(t, err)
 
@@ -92,7 +58,7 @@ func ListSQLServerDatabases(t testing.TestingT, resGroupName string, serverName
 }
 
 // ListSQLServerDatabasesE is a helper function that gets a list of databases on a sql server
-func ListSQLServerDatabasesE(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) (*[]sql.Database, error) {
+func ListSQLServerDatabasesE(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) (*[]armsql.Database, error) {
 	// Create a SQl db client
 	sqlClient, err := CreateDatabaseClient(subscriptionID)
 	if err != nil {
@@ -100,18 +66,25 @@ func ListSQLServerDatabasesE(t testing.TestingT, resGroupName string, serverName
 	}
 
 	//Get the corresponding DB client
-	sqlDbs, err := sqlClient.ListByServer(context.Background(), resGroupName, serverName, "", "")
-	if err != nil {
-		return nil, err
+	sqlDbs := []armsql.Database{}
+	pager := sqlClient.NewListByServerPager(resGroupName, serverName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range page.Value {
+			sqlDbs = append(sqlDbs, *db)
+		}
 	}
 
 	// Return DB ID
-	return sqlDbs.Value, nil
+	return &sqlDbs, nil
 }
 
 // GetSQLDatabase is a helper function that gets the sql db.
 // This function would fail the test if there is an error.
-func GetSQLDatabase(t testing.TestingT, resGroupName string, serverName string, dbName string, subscriptionID string) *sql.Database {
+func GetSQLDatabase(t testing.TestingT, resGroupName string, serverName string, dbName string, subscriptionID string) *armsql.Database {
 	database, err := GetSQLDatabaseE(t, subscriptionID, resGroupName, serverName, dbName)
 	require.NoError(t, err)
 
@@ -119,7 +92,7 @@ func GetSQLDatabase(t testing.TestingT, resGroupName string, serverName string,
 }
 
 // GetSQLDatabaseE is a helper function that gets the sql db.
-func GetSQLDatabaseE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string, dbName string) (*sql.Database, error) {
+func GetSQLDatabaseE(t testing.TestingT, subscriptionID string, resGroupName string, serverName string, dbName string) (*armsql.Database, error) {
 	// Create a SQl db client
 	sqlClient, err := CreateDatabaseClient(subscriptionID)
 	if err != nil {
@@ -127,11 +100,11 @@ func GetSQLDatabaseE(t testing.TestingT, subscriptionID string, resGroupName str
 	}
 
 	//Get the corresponding DB client
-	sqlDb, err := sqlClient.Get(context.Background(), resGroupName, serverName, dbName, "")
+	sqlDb, err := sqlClient.Get(context.Background(), resGroupName, serverName, dbName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// Return DB
-	return &sqlDb, nil
+	return &sqlDb.Database, nil
 }
@@ -0,0 +1,352 @@
+package azure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/sql/armsql"
+	mssql "github.com/denisenkom/go-mssqldb"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// sqlServerPort is the port of Azure SQL servers and of the private endpoint of SQL Managed Instances.
+	sqlServerPort = 1433
+
+	// sqlManagedInstancePublicEndpointPort is the port of the public endpoint of SQL Managed Instances.
+	sqlManagedInstancePublicEndpointPort = 3342
+)
+
+// sqlConnectPolicy is how long the SQL connection helpers wait for the server to accept connections, e.g., while a
+// new firewall rule propagates.
+var sqlConnectPolicy = retry.BackoffPolicy{MaxRetries: 10, InitialDelay: 6 * time.Second, Multiplier: 1}
+
+// invalidSQLFirewallRuleNameChars matches the characters that are not allowed in SQL server firewall rule names.
+var invalidSQLFirewallRuleNameChars = regexp.MustCompile(`[<>*%&:\\/?]`)
+
+// SQLConnectionOptions configures how the SQL connection helpers connect to an Azure SQL server or Managed Instance.
+type SQLConnectionOptions struct {
+	// The database to connect to. Defaults to master.
+	DatabaseName string
+
+	// The SQL login to connect as. If empty, the connection authenticates with a Microsoft Entra ID access token for
+	// the Azure identity of the test instead, which requires that identity to be the Entra admin of the server or a
+	// contained database user.
+	Username string
+
+	// The password of the SQL login. Ignored if Username is empty.
+	Password string
+
+	// The options to request the Microsoft Entra ID access token with if Username is empty. Defaults to the
+	// TERRATEST_AZURE_AUTH_METHOD environment variables, like NewTokenCredentialE.
+	AuthOptions *AuthOptions
+
+	// Add a firewall rule for the public IP of the test runner to the SQL server. Remove it at the end of the test
+	// with DeleteSQLServerTestRunnerFirewallRule. Not supported for Managed Instances, whose access is controlled by
+	// the network security group of their subnet.
+	AllowTestRunnerFirewallRule bool
+
+	// Connect to the public endpoint of a Managed Instance, on port 3342, instead of its private endpoint.
+	UseManagedInstancePublicEndpoint bool
+}
+
+// GetSQLServerConnection returns a connection to the given Azure SQL server, after checking that the server accepts
+// it. This will fail the test if there is an error. Close the connection at the end of the test.
+func GetSQLServerConnection(t testing.TestingT, resGroupName string, serverName string, subscriptionID string, options SQLConnectionOptions) *sql.DB {
+	db, err := GetSQLServerConnectionE(t, resGroupName, serverName, subscriptionID, options)
+	require.NoError(t, err)
+
+	return db
+}
+
+// GetSQLServerConnectionE returns a connection to the given Azure SQL server, after checking that the server accepts
+// it. Close the connection at the end of the test.
+func GetSQLServerConnectionE(t testing.TestingT, resGroupName string, serverName string, subscriptionID string, options SQLConnectionOptions) (*sql.DB, error) {
+	if options.UseManagedInstancePublicEndpoint {
+		return nil, fmt.Errorf("UseManagedInstancePublicEndpoint is only supported for SQL Managed Instances")
+	}
+
+	server, err := GetSQLServerE(t, subscriptionID, resGroupName, serverName)
+	if err != nil {
+		return nil, err
+	}
+	if server.Properties == nil || server.Properties.FullyQualifiedDomainName == nil {
+		return nil, fmt.Errorf("SQL server %s has no fully qualified domain name yet", serverName)
+	}
+
+	if options.AllowTestRunnerFirewallRule {
+		if err := AddSQLServerTestRunnerFirewallRuleE(t, resGroupName, serverName, subscriptionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return openSQLConnectionE(t, *server.Properties.FullyQualifiedDomainName, sqlServerPort, options)
+}
+
+// GetSQLManagedInstanceConnection returns a connection to the given SQL Managed Instance, after checking that the
+// instance accepts it. This will fail the test if there is an error. Close the connection at the end of the test.
+func GetSQLManagedInstanceConnection(t testing.TestingT, resGroupName string, managedInstanceName string, subscriptionID string, options SQLConnectionOptions) *sql.DB {
+	db, err := GetSQLManagedInstanceConnectionE(t, resGroupName, managedInstanceName, subscriptionID, options)
+	require.NoError(t, err)
+
+	return db
+}
+
+// GetSQLManagedInstanceConnectionE returns a connection to the given SQL Managed Instance, after checking that the
+// instance accepts it. Close the connection at the end of the test.
+func GetSQLManagedInstanceConnectionE(t testing.TestingT, resGroupName string, managedInstanceName string, subscriptionID string, options SQLConnectionOptions) (*sql.DB, error) {
+	if options.AllowTestRunnerFirewallRule {
+		return nil, fmt.Errorf("AllowTestRunnerFirewallRule is not supported for SQL Managed Instances")
+	}
+
+	instance, err := GetManagedInstanceE(subscriptionID, resGroupName, managedInstanceName)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Properties == nil || instance.Properties.FullyQualifiedDomainName == nil {
+		return nil, fmt.Errorf("SQL Managed Instance %s has no fully qualified domain name yet", managedInstanceName)
+	}
+
+	host := *instance.Properties.FullyQualifiedDomainName
+	port := sqlServerPort
+	if options.UseManagedInstancePublicEndpoint {
+		if !safePtrToBool(instance.Properties.PublicDataEndpointEnabled) {
+			return nil, fmt.Errorf("the public endpoint of SQL Managed Instance %s is not enabled", managedInstanceName)
+		}
+		host = sqlManagedInstancePublicEndpointHost(host)
+		port = sqlManagedInstancePublicEndpointPort
+	}
+
+	return openSQLConnectionE(t, host, port, options)
+}
+
+// openSQLConnectionE opens a connection to the given host with the given options and waits for it to be accepted.
+func openSQLConnectionE(t testing.TestingT, host string, port int, options SQLConnectionOptions) (*sql.DB, error) {
+	databaseName := options.DatabaseName
+	if databaseName == "" {
+		databaseName = "master"
+	}
+	dataSourceName := sqlDataSourceName(host, port, options.Username, options.Password, databaseName)
+
+	var db *sql.DB
+	user := options.Username
+	if options.Username != "" {
+		var err error
+		if db, err = sql.Open("sqlserver", dataSourceName); err != nil {
+			return nil, err
+		}
+	} else {
+		cred, err := NewTokenCredentialWithOptionsE(authOptionsOrEnv(options.AuthOptions))
+		if err != nil {
+			return nil, err
+		}
+		scope, err := sqlTokenScopeE(host)
+		if err != nil {
+			return nil, err
+		}
+		connector, err := mssql.NewAccessTokenConnector(dataSourceName, func() (string, error) {
+			token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+			return token.Token, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		db = sql.OpenDB(connector)
+		user = "the Azure identity of the test"
+	}
+
+	description := fmt.Sprintf("Connecting to SQL database %s on %s as %s", databaseName, host, user)
+	_, err := retry.DoWithPolicyE(t, description, sqlConnectPolicy, func() (string, error) {
+		return "", db.PingContext(context.Background())
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// sqlDataSourceName returns the sqlserver data source name to connect to the given database. The username and
+// password are omitted if the username is empty, for connections authenticated with an access token.
+func sqlDataSourceName(host string, port int, username string, password string, databaseName string) string {
+	query := url.Values{}
+	query.Set("database", databaseName)
+	query.Set("encrypt", "true")
+
+	dataSourceName := url.URL{
+		Scheme:   "sqlserver",
+		Host:     net.JoinHostPort(host, strconv.Itoa(port)),
+		RawQuery: query.Encode(),
+	}
+	if username != "" {
+		dataSourceName.User = url.UserPassword(username, password)
+	}
+
+	return dataSourceName.String()
+}
+
+// sqlTokenScopeE returns the scope of access tokens for the SQL endpoint with the given host, which depends on the
+// Azure cloud of the server, e.g., https://database.windows.net/.default for myserver.database.windows.net.
+func sqlTokenScopeE(host string) (string, error) {
+	index := strings.Index(host, ".database.")
+	if index < 0 {
+		return "", NewFailedToParseError("SQL server host", host)
+	}
+
+	return "https://" + host[index+1:] + "/.default", nil
+}
+
+// sqlManagedInstancePublicEndpointHost returns the host of the public endpoint of the Managed Instance with the given
+// host, e.g., myinstance.public.dnszone.database.windows.net for myinstance.dnszone.database.windows.net.
+func sqlManagedInstancePublicEndpointHost(host string) string {
+	parts := strings.SplitN(host, ".", 2)
+	if len(parts) != 2 {
+		return host
+	}
+
+	return parts[0] + ".public." + parts[1]
+}
+
+// AddSQLServerTestRunnerFirewallRule adds a firewall rule for the public IP of the test runner to the given SQL server.
+// This function would fail the test if there is an error.
+func AddSQLServerTestRunnerFirewallRule(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) {
+	err := AddSQLServerTestRunnerFirewallRuleE(t, resGroupName, serverName, subscriptionID)
+	require.NoError(t, err)
+}
+
+// AddSQLServerTestRunnerFirewallRuleE adds a firewall rule for the public IP of the test runner to the given SQL
+// server. The rule is named after the test, so adding it again replaces it.
+func AddSQLServerTestRunnerFirewallRuleE(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) error {
+	ip, err := http_helper.GetTestRunnerPublicIPE(t)
+	if err != nil {
+		return err
+	}
+
+	client, err := CreateSQLFirewallRulesClientE(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	ruleName := sqlTestRunnerFirewallRuleName(t)
+	logger.Default.Logf(t, "Allowing %s to connect to SQL server %s through firewall rule %s", ip, serverName, ruleName)
+	_, err = client.CreateOrUpdate(context.Background(), resGroupName, serverName, ruleName, armsql.FirewallRule{
+		Properties: &armsql.ServerFirewallRuleProperties{
+			StartIPAddress: &ip,
+			EndIPAddress:   &ip,
+		},
+	}, nil)
+
+	return err
+}
+
+// DeleteSQLServerTestRunnerFirewallRule deletes the firewall rule added for the test runner by
+// AddSQLServerTestRunnerFirewallRule or GetSQLServerConnection. This function would fail the test if there is an error.
+func DeleteSQLServerTestRunnerFirewallRule(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) {
+	err := DeleteSQLServerTestRunnerFirewallRuleE(t, resGroupName, serverName, subscriptionID)
+	require.NoError(t, err)
+}
+
+// DeleteSQLServerTestRunnerFirewallRuleE deletes the firewall rule added for the test runner by
+// AddSQLServerTestRunnerFirewallRuleE or GetSQLServerConnectionE.
+func DeleteSQLServerTestRunnerFirewallRuleE(t testing.TestingT, resGroupName string, serverName string, subscriptionID string) error {
+	client, err := CreateSQLFirewallRulesClientE(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	ruleName := sqlTestRunnerFirewallRuleName(t)
+	logger.Default.Logf(t, "Deleting firewall rule %s of SQL server %s", ruleName, serverName)
+	_, err = client.Delete(context.Background(), resGroupName, serverName, ruleName, nil)
+	if err != nil && !ResourceNotFoundErrorExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// sqlTestRunnerFirewallRuleName returns the name of the firewall rule for the test runner of the given test.
+func sqlTestRunnerFirewallRuleName(t testing.TestingT) string {
+	name := "terratest-" + invalidSQLFirewallRuleNameChars.ReplaceAllString(t.Name(), "-")
+	if len(name) > 128 {
+		name = name[:128]
+	}
+
+	return name
+}
+
+// SQLTableExistsE returns true if the given table exists in the given schema of the database that the given
+// connection, returned by GetSQLServerConnectionE or GetSQLManagedInstanceConnectionE, is connected to.
+func SQLTableExistsE(t testing.TestingT, db *sql.DB, schemaName string, tableName string) (bool, error) {
+	query := "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2"
+
+	var count int
+	if err := db.QueryRowContext(context.Background(), query, schemaName, tableName).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// AssertSQLTableExists checks that the given table exists in the given schema of the database that the given
+// connection, returned by GetSQLServerConnection or GetSQLManagedInstanceConnection, is connected to.
+func AssertSQLTableExists(t testing.TestingT, db *sql.DB, schemaName string, tableName string) {
+	exists, err := SQLTableExistsE(t, db, schemaName, tableName)
+	if assert.NoError(t, err) {
+		assert.Truef(t, exists, "Table %s does not exist in schema %s", tableName, schemaName)
+	}
+}
+
+// GetSQLRowCount returns the number of rows in the given table (optionally qualified with its schema, e.g.,
+// dbo.users) of the database that the given connection is connected to.
+// This function would fail the test if there is an error.
+func GetSQLRowCount(t testing.TestingT, db *sql.DB, tableName string) int64 {
+	count, err := GetSQLRowCountE(t, db, tableName)
+	require.NoError(t, err)
+
+	return count
+}
+
+// GetSQLRowCountE returns the number of rows in the given table (optionally qualified with its schema, e.g.,
+// dbo.users) of the database that the given connection is connected to.
+func GetSQLRowCountE(t testing.TestingT, db *sql.DB, tableName string) (int64, error) {
+	var count int64
+	query := "SELECT COUNT_BIG(*) FROM " + quoteSQLServerIdentifier(tableName)
+	if err := db.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// AssertSQLRowCount checks that the given table of the database that the given connection is connected to has the
+// expected number of rows.
+func AssertSQLRowCount(t testing.TestingT, db *sql.DB, tableName string, expectedCount int64) {
+	count, err := GetSQLRowCountE(t, db, tableName)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedCount, count, "Unexpected number of rows in table %s", tableName)
+	}
+}
+
+// quoteSQLServerIdentifier quotes each part of the given, optionally schema qualified, identifier with brackets.
+func quoteSQLServerIdentifier(identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+	}
+
+	return strings.Join(parts, ".")
+}
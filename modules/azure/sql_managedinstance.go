@@ -3,7 +3,7 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/preview/sql/mgmt/v3.0/sql"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/sql/armsql"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -30,7 +30,7 @@ func SQLManagedInstanceExistsE(managedInstanceName string, resourceGroupName str
 
 // GetManagedInstance is a helper function that gets the sql server object.
 // This function would fail the test if there is an error.
-func GetManagedInstance(t testing.TestingT, resGroupName string, managedInstanceName string, subscriptionID string) *sql.ManagedInstance {
+func GetManagedInstance(t testing.TestingT, resGroupName string, managedInstanceName string, subscriptionID string) *armsql.ManagedInstance {
 	managedInstance, err := GetManagedInstanceE(subscriptionID, resGroupName, managedInstanceName)
 	require.NoError(t, err)
 
@@ -39,7 +39,7 @@ func GetManagedInstance(t testing.TestingT, resGroupName string, managedInstance
 
 // GetManagedInstanceDatabase is a helper function that gets the sql server object.
 // This function would fail the test if there is an error.
-func GetManagedInstanceDatabase(t testing.TestingT, resGroupName string, managedInstanceName string, databaseName string, subscriptionID string) *sql.ManagedDatabase {
+func GetManagedInstanceDatabase(t testing.TestingT, resGroupName string, managedInstanceName string, databaseName string, subscriptionID string) *armsql.ManagedDatabase {
 	managedDatabase, err := GetManagedInstanceDatabaseE(t, subscriptionID, resGroupName, managedInstanceName, databaseName)
 	require.NoError(t, err)
 
@@ -47,7 +47,7 @@ func GetManagedInstanceDatabase(t testing.TestingT, resGroupName string, managed
 }
 
 // GetManagedInstanceE is a helper function that gets the sql server object.
-func GetManagedInstanceE(subscriptionID string, resGroupName string, managedInstanceName string) (*sql.ManagedInstance, error) {
+func GetManagedInstanceE(subscriptionID string, resGroupName string, managedInstanceName string) (*armsql.ManagedInstance, error) {
 	// Create a SQl Server client
 	sqlmiClient, err := CreateSQLMangedInstanceClient(subscriptionID)
 	if err != nil {
@@ -55,17 +55,17 @@ func GetManagedInstanceE(subscriptionID string, resGroupName string, managedInst
 	}
 
 	//Get the corresponding server client
-	sqlmi, err := sqlmiClient.Get(context.Background(), resGroupName, managedInstanceName)
+	sqlmi, err := sqlmiClient.Get(context.Background(), resGroupName, managedInstanceName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return sql mi
-	return &sqlmi, nil
+	return &sqlmi.ManagedInstance, nil
 }
 
 // GetManagedInstanceDatabaseE is a helper function that gets the sql server object.
-func GetManagedInstanceDatabaseE(t testing.TestingT, subscriptionID string, resGroupName string, managedInstanceName string, databaseName string) (*sql.ManagedDatabase, error) {
+func GetManagedInstanceDatabaseE(t testing.TestingT, subscriptionID string, resGroupName string, managedInstanceName string, databaseName string) (*armsql.ManagedDatabase, error) {
 	// Create a SQlMI db client
 	sqlmiDbClient, err := CreateSQLMangedDatabasesClient(subscriptionID)
 	if err != nil {
@@ -73,11 +73,11 @@ func GetManagedInstanceDatabaseE(t testing.TestingT, subscriptionID string, resG
 	}
 
 	//Get the corresponding  client
-	sqlmidb, err := sqlmiDbClient.Get(context.Background(), resGroupName, managedInstanceName, databaseName)
+	sqlmidb, err := sqlmiDbClient.Get(context.Background(), resGroupName, managedInstanceName, databaseName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return sql mi db
-	return &sqlmidb, nil
+	return &sqlmidb.ManagedDatabase, nil
 }
@@ -3,10 +3,14 @@ package azure
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
-	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2019-06-01/storage"
-	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -92,7 +96,7 @@ func StorageAccountExistsE(storageAccountName, resourceGroupName, subscriptionID
 }
 
 // GetStorageAccountE gets a storage account; otherwise error.  See https://docs.microsoft.com/rest/api/storagerp/storageaccounts/getproperties for more information.
-func GetStorageAccountE(storageAccountName, resourceGroupName, subscriptionID string) (*storage.Account, error) {
+func GetStorageAccountE(storageAccountName, resourceGroupName, subscriptionID string) (*armstorage.Account, error) {
 	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
 	if err != nil {
 		return nil, err
@@ -130,7 +134,7 @@ func GetStorageBlobContainerPublicAccessE(containerName, storageAccountName, res
 		return false, err
 	}
 
-	return (string(container.PublicAccess) != "None"), nil
+	return (safeEnumPtrToString(container.ContainerProperties.PublicAccess) != "None"), nil
 }
 
 // GetStorageAccountKindE returns one of Storage, StorageV2, BlobStorage, FileStorage, or BlockBlobStorage.
@@ -140,7 +144,7 @@ func GetStorageAccountKindE(storageAccountName, resourceGroupName, subscriptionI
 	if err != nil {
 		return "", err
 	}
-	return string(storageAccount.Kind), nil
+	return safeEnumPtrToString(storageAccount.Kind), nil
 }
 
 // GetStorageAccountSkuTierE returns the storage account sku tier as Standard or Premium.
@@ -149,11 +153,11 @@ func GetStorageAccountSkuTierE(storageAccountName, resourceGroupName, subscripti
 	if err != nil {
 		return "", err
 	}
-	return string(storageAccount.Sku.Tier), nil
+	return safeEnumPtrToString(storageAccount.SKU.Tier), nil
 }
 
 // GetStorageBlobContainerE returns Blob container client.
-func GetStorageBlobContainerE(containerName, storageAccountName, resourceGroupName, subscriptionID string) (*storage.BlobContainer, error) {
+func GetStorageBlobContainerE(containerName, storageAccountName, resourceGroupName, subscriptionID string) (*armstorage.BlobContainer, error) {
 	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
 	if err != nil {
 		return nil, err
@@ -166,15 +170,15 @@ func GetStorageBlobContainerE(containerName, storageAccountName, resourceGroupNa
 	if err != nil {
 		return nil, err
 	}
-	container, err := client.Get(context.Background(), resourceGroupName, storageAccountName, containerName)
+	container, err := client.Get(context.Background(), resourceGroupName, storageAccountName, containerName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &container, nil
+	return &container.BlobContainer, nil
 }
 
 // GetStorageAccountPropertyE returns StorageAccount properties.
-func GetStorageAccountPropertyE(storageAccountName, resourceGroupName, subscriptionID string) (*storage.Account, error) {
+func GetStorageAccountPropertyE(storageAccountName, resourceGroupName, subscriptionID string) (*armstorage.Account, error) {
 	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
 	if err != nil {
 		return nil, err
@@ -187,15 +191,39 @@ func GetStorageAccountPropertyE(storageAccountName, resourceGroupName, subscript
 	if err != nil {
 		return nil, err
 	}
-	account, err := client.GetProperties(context.Background(), resourceGroupName, storageAccountName, "")
+	account, err := client.GetProperties(context.Background(), resourceGroupName, storageAccountName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &account, nil
+	return &account.Account, nil
+}
+
+// GetStorageAccountKeyE gets the value of the first access key of the given storage account, e.g., to sign SAS tokens.
+func GetStorageAccountKeyE(storageAccountName, resourceGroupName, subscriptionID string) (string, error) {
+	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	resourceGroupName, err = getTargetAzureResourceGroupName(resourceGroupName)
+	if err != nil {
+		return "", err
+	}
+	client, err := CreateStorageAccountClientE(subscriptionID)
+	if err != nil {
+		return "", err
+	}
+	keys, err := client.ListKeys(context.Background(), resourceGroupName, storageAccountName, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(keys.Keys) == 0 || keys.Keys[0] == nil || keys.Keys[0].Value == nil {
+		return "", NewNotFoundError("storage account key", storageAccountName, resourceGroupName)
+	}
+	return *keys.Keys[0].Value, nil
 }
 
 // GetStorageFileShare returns specified file share. This function would fail the test if there is an error.
-func GetStorageFileShare(t *testing.T, fileShareName, storageAccountName, resourceGroupName, subscriptionID string) *storage.FileShare {
+func GetStorageFileShare(t *testing.T, fileShareName, storageAccountName, resourceGroupName, subscriptionID string) *armstorage.FileShare {
 	fileSahre, err := GetStorageFileShareE(fileShareName, storageAccountName, resourceGroupName, subscriptionID)
 	require.NoError(t, err)
 
@@ -203,7 +231,7 @@ func GetStorageFileShare(t *testing.T, fileShareName, storageAccountName, resour
 }
 
 // GetStorageFileSharesE returns specified file share.
-func GetStorageFileShareE(fileShareName, storageAccountName, resourceGroupName, subscriptionID string) (*storage.FileShare, error) {
+func GetStorageFileShareE(fileShareName, storageAccountName, resourceGroupName, subscriptionID string) (*armstorage.FileShare, error) {
 	resourceGroupName, err2 := getTargetAzureResourceGroupName(resourceGroupName)
 	if err2 != nil {
 		return nil, err2
@@ -212,53 +240,28 @@ func GetStorageFileShareE(fileShareName, storageAccountName, resourceGroupName,
 	if err != nil {
 		return nil, err
 	}
-	fileShare, err := client.Get(context.Background(), resourceGroupName, storageAccountName, fileShareName, "stats")
+	fileShare, err := client.Get(context.Background(), resourceGroupName, storageAccountName, fileShareName, &armstorage.FileSharesClientGetOptions{Expand: to.Ptr("stats")})
 	if err != nil {
 		return nil, err
 	}
-	return &fileShare, nil
+	return &fileShare.FileShare, nil
 }
 
 // GetStorageAccountClientE creates a storage account client.
 // TODO: remove in next version
-func GetStorageAccountClientE(subscriptionID string) (*storage.AccountsClient, error) {
-	// Validate Azure subscription ID
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	storageAccountClient := storage.NewAccountsClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	storageAccountClient.Authorizer = *authorizer
-	return &storageAccountClient, nil
+func GetStorageAccountClientE(subscriptionID string) (*armstorage.AccountsClient, error) {
+	return CreateStorageAccountClientE(subscriptionID)
 }
 
 // GetStorageBlobContainerClientE creates a storage container client.
 // TODO: remove in next version
-func GetStorageBlobContainerClientE(subscriptionID string) (*storage.BlobContainersClient, error) {
-	subscriptionID, err := getTargetAzureSubscription(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	blobContainerClient := storage.NewBlobContainersClient(subscriptionID)
-	authorizer, err := NewAuthorizer()
-
-	if err != nil {
-		return nil, err
-	}
-	blobContainerClient.Authorizer = *authorizer
-	return &blobContainerClient, nil
+func GetStorageBlobContainerClientE(subscriptionID string) (*armstorage.BlobContainersClient, error) {
+	return CreateStorageBlobContainerClientE(subscriptionID)
 }
 
 // GetStorageURISuffixE returns the proper storage URI suffix for the configured Azure environment.
 func GetStorageURISuffixE() (string, error) {
-	envName := "AzurePublicCloud"
-	env, err := azure.EnvironmentFromName(envName)
+	env, err := getAzureEnvironmentE()
 	if err != nil {
 		return "", err
 	}
@@ -272,7 +275,7 @@ func GetStorageAccountPrimaryBlobEndpointE(storageAccountName, resourceGroupName
 		return "", err
 	}
 
-	return *storageAccount.AccountProperties.PrimaryEndpoints.Blob, nil
+	return *storageAccount.Properties.PrimaryEndpoints.Blob, nil
 }
 
 // GetStorageDNSStringE builds and returns the storage account dns string if the storage account exists.
@@ -291,3 +294,70 @@ func GetStorageDNSStringE(storageAccountName, resourceGroupName, subscriptionID
 
 	return "", NewNotFoundError("storage account", storageAccountName, "")
 }
+
+// storageBlobAPIVersion is the version of the blob storage REST API used to read blobs.
+const storageBlobAPIVersion = "2021-08-06"
+
+// GetStorageBlobContents reads the contents of the given blob, authenticating with Azure AD.
+// This function would fail the test if there is an error.
+func GetStorageBlobContents(t *testing.T, blobName string, containerName string, storageAccountName string) []byte {
+	contents, err := GetStorageBlobContentsE(blobName, containerName, storageAccountName, "")
+	require.NoError(t, err)
+	return contents
+}
+
+// GetStorageBlobContentsE reads the contents of the given blob. If sasToken is empty, the request is authenticated with
+// Azure AD using the default Azure credential chain, which requires a data plane role such as Storage Blob Data Reader.
+func GetStorageBlobContentsE(blobName string, containerName string, storageAccountName string, sasToken string) ([]byte, error) {
+	return GetStorageBlobContentsWithOptionsE(blobName, containerName, storageAccountName, sasToken, authOptionsFromEnv())
+}
+
+// GetStorageBlobContentsWithOptionsE reads the contents of the given blob like GetStorageBlobContentsE, but
+// authenticates according to the given AuthOptions if sasToken is empty.
+func GetStorageBlobContentsWithOptionsE(blobName string, containerName string, storageAccountName string, sasToken string, authOptions AuthOptions) ([]byte, error) {
+	storageSuffix, err := GetStorageURISuffixE()
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://%s.blob.%s/%s/%s", storageAccountName, storageSuffix, containerName, blobName)
+	if sasToken != "" {
+		url = url + "?" + strings.TrimPrefix(sasToken, "?")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", storageBlobAPIVersion)
+
+	if sasToken == "" {
+		cred, err := NewTokenCredentialWithOptionsE(authOptions)
+		if err != nil {
+			return nil, err
+		}
+		token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"https://storage.azure.com/.default"}})
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, NewNotFoundError("storage blob", containerName+"/"+blobName, "")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read blob %s in container %s of storage account %s: %s: %s", blobName, containerName, storageAccountName, resp.Status, string(body))
+	}
+	return body, nil
+}
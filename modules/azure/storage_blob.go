@@ -0,0 +1,276 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/stretchr/testify/require"
+)
+
+// UploadStorageBlob uploads the given contents to the given blob, replacing the blob if it exists.
+// This function would fail the test if there is an error.
+func UploadStorageBlob(t *testing.T, blobName string, containerName string, storageAccountName string, contents []byte) {
+	err := UploadStorageBlobE(blobName, containerName, storageAccountName, contents)
+	require.NoError(t, err)
+}
+
+// UploadStorageBlobE uploads the given contents to the given blob, replacing the blob if it exists. The request is
+// authenticated with Azure AD, which requires a data plane role such as Storage Blob Data Contributor.
+func UploadStorageBlobE(blobName string, containerName string, storageAccountName string, contents []byte) error {
+	client, err := GetStorageBlobServiceClientE(storageAccountName)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.UploadBuffer(context.Background(), containerName, blobName, contents, nil)
+	return err
+}
+
+// DownloadStorageBlob downloads the contents of the given blob.
+// This function would fail the test if there is an error.
+func DownloadStorageBlob(t *testing.T, blobName string, containerName string, storageAccountName string) []byte {
+	contents, err := DownloadStorageBlobE(blobName, containerName, storageAccountName)
+	require.NoError(t, err)
+	return contents
+}
+
+// DownloadStorageBlobE downloads the contents of the given blob. The request is authenticated with Azure AD, which
+// requires a data plane role such as Storage Blob Data Reader.
+func DownloadStorageBlobE(blobName string, containerName string, storageAccountName string) ([]byte, error) {
+	client, err := GetStorageBlobServiceClientE(storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DownloadStream(context.Background(), containerName, blobName, nil)
+	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return nil, NewNotFoundError("storage blob", containerName+"/"+blobName, storageAccountName)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// ListStorageBlobs lists the names of the blobs in the given container that start with the given prefix.
+// This function would fail the test if there is an error.
+func ListStorageBlobs(t *testing.T, containerName string, prefix string, storageAccountName string) []string {
+	blobNames, err := ListStorageBlobsE(containerName, prefix, storageAccountName)
+	require.NoError(t, err)
+	return blobNames
+}
+
+// ListStorageBlobsE lists the names of the blobs in the given container that start with the given prefix. Pass an
+// empty prefix to list all blobs.
+func ListStorageBlobsE(containerName string, prefix string, storageAccountName string) ([]string, error) {
+	client, err := GetStorageBlobServiceClientE(storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &azblob.ListBlobsFlatOptions{}
+	if prefix != "" {
+		options.Prefix = to.Ptr(prefix)
+	}
+
+	blobNames := []string{}
+	pager := client.NewListBlobsFlatPager(containerName, options)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if page.Segment == nil {
+			continue
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if blob != nil {
+				blobNames = append(blobNames, safePtrToString(blob.Name))
+			}
+		}
+	}
+	return blobNames, nil
+}
+
+// DeleteStorageBlob deletes the given blob and its snapshots.
+// This function would fail the test if there is an error.
+func DeleteStorageBlob(t *testing.T, blobName string, containerName string, storageAccountName string) {
+	err := DeleteStorageBlobE(blobName, containerName, storageAccountName)
+	require.NoError(t, err)
+}
+
+// DeleteStorageBlobE deletes the given blob and its snapshots.
+func DeleteStorageBlobE(blobName string, containerName string, storageAccountName string) error {
+	client, err := GetStorageBlobServiceClientE(storageAccountName)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteBlob(context.Background(), containerName, blobName, nil)
+	return err
+}
+
+// GenerateStorageBlobSasToken generates a SAS token for the given blob, signed with the first key of the storage
+// account, that grants the given permissions (e.g., "r" or "rw") until expiry.
+// This function would fail the test if there is an error.
+func GenerateStorageBlobSasToken(t *testing.T, blobName string, containerName string, storageAccountName string, resourceGroupName string, subscriptionID string, permissions string, expiry time.Time) string {
+	sasToken, err := GenerateStorageBlobSasTokenE(blobName, containerName, storageAccountName, resourceGroupName, subscriptionID, permissions, expiry)
+	require.NoError(t, err)
+	return sasToken
+}
+
+// GenerateStorageBlobSasTokenE generates a SAS token for the given blob, signed with the first key of the storage
+// account, that grants the given permissions (e.g., "r" or "rw") until expiry. The token can be passed to
+// GetStorageBlobContentsE, or appended to the blob URL for anonymous access. Listing the account keys requires a
+// management role such as Storage Account Key Operator.
+func GenerateStorageBlobSasTokenE(blobName string, containerName string, storageAccountName string, resourceGroupName string, subscriptionID string, permissions string, expiry time.Time) (string, error) {
+	accountKey, err := GetStorageAccountKeyE(storageAccountName, resourceGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	cred, err := azblob.NewSharedKeyCredential(storageAccountName, accountKey)
+	if err != nil {
+		return "", err
+	}
+
+	queryParams, err := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		ExpiryTime:    expiry.UTC(),
+		Permissions:   permissions,
+		ContainerName: containerName,
+		BlobName:      blobName,
+	}.SignWithSharedKey(cred)
+	if err != nil {
+		return "", err
+	}
+	return queryParams.Encode(), nil
+}
+
+// VerifyStorageSasToken verifies that the given SAS token grants all of the given permissions (e.g., "rw") and stays
+// valid for at least the given duration.
+// This function would fail the test if there is an error.
+func VerifyStorageSasToken(t *testing.T, sasToken string, permissions string, validFor time.Duration) {
+	err := VerifyStorageSasTokenE(sasToken, permissions, validFor)
+	require.NoError(t, err)
+}
+
+// VerifyStorageSasTokenE verifies that the given SAS token grants all of the given permissions (e.g., "rw") and stays
+// valid for at least the given duration. This only checks the parameters of the token; use it (e.g., with
+// GetStorageBlobContentsE) to check that the storage account accepts its signature.
+func VerifyStorageSasTokenE(sasToken string, permissions string, validFor time.Duration) error {
+	values, err := url.ParseQuery(strings.TrimPrefix(sasToken, "?"))
+	if err != nil {
+		return err
+	}
+	if values.Get("sig") == "" {
+		return NewFailedToParseError("SAS token without signature", sasToken)
+	}
+
+	grantedPermissions := values.Get("sp")
+	for _, permission := range permissions {
+		if !strings.ContainsRune(grantedPermissions, permission) {
+			return fmt.Errorf("SAS token grants permissions %q, which do not include %q", grantedPermissions, permission)
+		}
+	}
+
+	// The expiry is either a full UTC time or only a date
+	expiry, err := time.Parse(time.RFC3339, values.Get("se"))
+	if err != nil {
+		expiry, err = time.Parse(time.DateOnly, values.Get("se"))
+		if err != nil {
+			return NewFailedToParseError("SAS token expiry", values.Get("se"))
+		}
+	}
+	if remaining := time.Until(expiry); remaining < validFor {
+		return fmt.Errorf("SAS token expires at %s, which is less than %s from now", expiry, validFor)
+	}
+	return nil
+}
+
+// StorageStaticWebsiteEnabled indicates whether the static website of the given storage account is enabled; otherwise
+// false.
+// This function would fail the test if there is an error.
+func StorageStaticWebsiteEnabled(t *testing.T, storageAccountName string) bool {
+	enabled, err := StorageStaticWebsiteEnabledE(storageAccountName)
+	require.NoError(t, err)
+	return enabled
+}
+
+// StorageStaticWebsiteEnabledE indicates whether the static website of the given storage account is enabled;
+// otherwise false.
+func StorageStaticWebsiteEnabledE(storageAccountName string) (bool, error) {
+	client, err := GetStorageBlobServiceClientE(storageAccountName)
+	if err != nil {
+		return false, err
+	}
+
+	props, err := client.ServiceClient().GetProperties(context.Background(), nil)
+	if err != nil {
+		return false, err
+	}
+	return props.StaticWebsite != nil && props.StaticWebsite.Enabled != nil && *props.StaticWebsite.Enabled, nil
+}
+
+// GetStorageStaticWebsiteEndpoint gets the primary endpoint of the static website of the given storage account, which
+// can be checked with the http-helper module.
+// This function would fail the test if there is an error.
+func GetStorageStaticWebsiteEndpoint(t *testing.T, storageAccountName string, resourceGroupName string, subscriptionID string) string {
+	endpoint, err := GetStorageStaticWebsiteEndpointE(storageAccountName, resourceGroupName, subscriptionID)
+	require.NoError(t, err)
+	return endpoint
+}
+
+// GetStorageStaticWebsiteEndpointE gets the primary endpoint of the static website of the given storage account, which
+// can be checked with the http-helper module.
+func GetStorageStaticWebsiteEndpointE(storageAccountName string, resourceGroupName string, subscriptionID string) (string, error) {
+	storageAccount, err := GetStorageAccountPropertyE(storageAccountName, resourceGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if storageAccount.Properties == nil || storageAccount.Properties.PrimaryEndpoints == nil || storageAccount.Properties.PrimaryEndpoints.Web == nil {
+		return "", NewNotFoundError("static website endpoint", storageAccountName, resourceGroupName)
+	}
+	return *storageAccount.Properties.PrimaryEndpoints.Web, nil
+}
+
+// GetStorageBlobServiceClientE creates a blob storage data plane client for the given storage account, authenticated
+// with Azure AD.
+func GetStorageBlobServiceClientE(storageAccountName string) (*azblob.Client, error) {
+	return GetStorageBlobServiceClientWithOptionsE(storageAccountName, authOptionsFromEnv())
+}
+
+// GetStorageBlobServiceClientWithOptionsE creates a blob storage data plane client for the given storage account,
+// authenticated according to the given AuthOptions.
+func GetStorageBlobServiceClientWithOptionsE(storageAccountName string, authOptions AuthOptions) (*azblob.Client, error) {
+	serviceURL, err := getStorageServiceURLE(storageAccountName, "blob")
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewClient(serviceURL, cred, nil)
+}
+
+// getStorageServiceURLE returns the URL of the given service (blob, queue, table or file) of the given storage account
+// in the Azure environment that is currently setup.
+func getStorageServiceURLE(storageAccountName string, service string) (string, error) {
+	storageSuffix, err := GetStorageURISuffixE()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.%s.%s/", storageAccountName, service, storageSuffix), nil
+}
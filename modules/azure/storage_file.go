@@ -0,0 +1,85 @@
+package azure
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/file"
+	"github.com/stretchr/testify/require"
+)
+
+// UploadStorageFile uploads the given contents to the file at the given path in the given file share, replacing the
+// file if it exists. The parent directories must exist.
+// This function would fail the test if there is an error.
+func UploadStorageFile(t *testing.T, filePath string, fileShareName string, storageAccountName string, contents []byte) {
+	err := UploadStorageFileE(filePath, fileShareName, storageAccountName, contents)
+	require.NoError(t, err)
+}
+
+// UploadStorageFileE uploads the given contents to the file at the given path in the given file share, replacing the
+// file if it exists. The parent directories must exist. The request is authenticated with Azure AD, which requires a
+// data plane role such as Storage File Data Privileged Contributor.
+func UploadStorageFileE(filePath string, fileShareName string, storageAccountName string, contents []byte) error {
+	client, err := GetStorageFileClientE(filePath, fileShareName, storageAccountName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Create(context.Background(), int64(len(contents)), nil); err != nil {
+		return err
+	}
+	return client.UploadBuffer(context.Background(), contents, nil)
+}
+
+// DownloadStorageFile downloads the contents of the file at the given path in the given file share.
+// This function would fail the test if there is an error.
+func DownloadStorageFile(t *testing.T, filePath string, fileShareName string, storageAccountName string) []byte {
+	contents, err := DownloadStorageFileE(filePath, fileShareName, storageAccountName)
+	require.NoError(t, err)
+	return contents
+}
+
+// DownloadStorageFileE downloads the contents of the file at the given path in the given file share. The request is
+// authenticated with Azure AD, which requires a data plane role such as Storage File Data Privileged Reader.
+func DownloadStorageFileE(filePath string, fileShareName string, storageAccountName string) ([]byte, error) {
+	client, err := GetStorageFileClientE(filePath, fileShareName, storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DownloadStream(context.Background(), nil)
+	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return nil, NewNotFoundError("storage file", fileShareName+"/"+filePath, storageAccountName)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// GetStorageFileClientE creates a file share data plane client for the file at the given path in the given file
+// share, authenticated with Azure AD. File shares only accept Azure AD tokens with the backup intent, which requires
+// one of the privileged file data roles.
+func GetStorageFileClientE(filePath string, fileShareName string, storageAccountName string) (*file.Client, error) {
+	return GetStorageFileClientWithOptionsE(filePath, fileShareName, storageAccountName, authOptionsFromEnv())
+}
+
+// GetStorageFileClientWithOptionsE creates a file share data plane client for the file at the given path in the
+// given file share, authenticated according to the given AuthOptions.
+func GetStorageFileClientWithOptionsE(filePath string, fileShareName string, storageAccountName string, authOptions AuthOptions) (*file.Client, error) {
+	serviceURL, err := getStorageServiceURLE(storageAccountName, "file")
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return file.NewClient(serviceURL+fileShareName+"/"+filePath, cred, &file.ClientOptions{
+		FileRequestIntent: to.Ptr(file.ShareTokenIntentBackup),
+	})
+}
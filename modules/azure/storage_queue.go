@@ -0,0 +1,101 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azqueue"
+	"github.com/stretchr/testify/require"
+)
+
+// StorageQueueMessage is a message dequeued from a storage queue.
+type StorageQueueMessage struct {
+	ID            string
+	Text          string
+	DequeueCount  int64
+	PopReceipt    string
+	InsertionTime string
+}
+
+// EnqueueStorageQueueMessage adds a message with the given text to the given storage queue.
+// This function would fail the test if there is an error.
+func EnqueueStorageQueueMessage(t *testing.T, queueName string, storageAccountName string, text string) {
+	err := EnqueueStorageQueueMessageE(queueName, storageAccountName, text)
+	require.NoError(t, err)
+}
+
+// EnqueueStorageQueueMessageE adds a message with the given text to the given storage queue. The request is
+// authenticated with Azure AD, which requires a data plane role such as Storage Queue Data Message Sender.
+func EnqueueStorageQueueMessageE(queueName string, storageAccountName string, text string) error {
+	client, err := GetStorageQueueClientE(queueName, storageAccountName)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.EnqueueMessage(context.Background(), text, nil)
+	return err
+}
+
+// DequeueStorageQueueMessage removes the next message from the given storage queue and returns it, or nil if the
+// queue is empty.
+// This function would fail the test if there is an error.
+func DequeueStorageQueueMessage(t *testing.T, queueName string, storageAccountName string) *StorageQueueMessage {
+	message, err := DequeueStorageQueueMessageE(queueName, storageAccountName)
+	require.NoError(t, err)
+	return message
+}
+
+// DequeueStorageQueueMessageE removes the next message from the given storage queue and returns it, or nil if the
+// queue is empty. The message is deleted once read, so it is not delivered again. The request is authenticated with
+// Azure AD, which requires a data plane role such as Storage Queue Data Message Processor.
+func DequeueStorageQueueMessageE(queueName string, storageAccountName string) (*StorageQueueMessage, error) {
+	client, err := GetStorageQueueClientE(queueName, storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.DequeueMessage(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Messages) == 0 || resp.Messages[0] == nil {
+		return nil, nil
+	}
+
+	message := resp.Messages[0]
+	if _, err := client.DeleteMessage(context.Background(), safePtrToString(message.MessageID), safePtrToString(message.PopReceipt), nil); err != nil {
+		return nil, err
+	}
+
+	result := &StorageQueueMessage{
+		ID:         safePtrToString(message.MessageID),
+		Text:       safePtrToString(message.MessageText),
+		PopReceipt: safePtrToString(message.PopReceipt),
+	}
+	if message.DequeueCount != nil {
+		result.DequeueCount = *message.DequeueCount
+	}
+	if message.InsertionTime != nil {
+		result.InsertionTime = message.InsertionTime.String()
+	}
+	return result, nil
+}
+
+// GetStorageQueueClientE creates a storage queue data plane client for the given queue, authenticated with Azure AD.
+func GetStorageQueueClientE(queueName string, storageAccountName string) (*azqueue.QueueClient, error) {
+	return GetStorageQueueClientWithOptionsE(queueName, storageAccountName, authOptionsFromEnv())
+}
+
+// GetStorageQueueClientWithOptionsE creates a storage queue data plane client for the given queue, authenticated
+// according to the given AuthOptions.
+func GetStorageQueueClientWithOptionsE(queueName string, storageAccountName string, authOptions AuthOptions) (*azqueue.QueueClient, error) {
+	serviceURL, err := getStorageServiceURLE(storageAccountName, "queue")
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return azqueue.NewQueueClient(serviceURL+queueName, cred, nil)
+}
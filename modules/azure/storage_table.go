@@ -0,0 +1,145 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/aztables"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// storageTablePartitionKey and storageTableRowKey are the properties that identify a storage table entity.
+	storageTablePartitionKey = "PartitionKey"
+	storageTableRowKey       = "RowKey"
+)
+
+// UpsertStorageTableEntity inserts the given entity in the given storage table, or replaces it if an entity with the
+// same partition and row key exists.
+// This function would fail the test if there is an error.
+func UpsertStorageTableEntity(t *testing.T, tableName string, storageAccountName string, partitionKey string, rowKey string, properties map[string]interface{}) {
+	err := UpsertStorageTableEntityE(tableName, storageAccountName, partitionKey, rowKey, properties)
+	require.NoError(t, err)
+}
+
+// UpsertStorageTableEntityE inserts the given entity in the given storage table, or replaces it if an entity with the
+// same partition and row key exists. The request is authenticated with Azure AD, which requires a data plane role such
+// as Storage Table Data Contributor.
+func UpsertStorageTableEntityE(tableName string, storageAccountName string, partitionKey string, rowKey string, properties map[string]interface{}) error {
+	client, err := GetStorageTableClientE(tableName, storageAccountName)
+	if err != nil {
+		return err
+	}
+
+	entity, err := json.Marshal(newStorageTableEntity(partitionKey, rowKey, properties))
+	if err != nil {
+		return err
+	}
+
+	_, err = client.UpsertEntity(context.Background(), entity, &aztables.UpsertEntityOptions{UpdateMode: aztables.UpdateModeReplace})
+	return err
+}
+
+// GetStorageTableEntity gets the properties of the entity with the given partition and row key in the given storage
+// table.
+// This function would fail the test if there is an error.
+func GetStorageTableEntity(t *testing.T, tableName string, storageAccountName string, partitionKey string, rowKey string) map[string]interface{} {
+	properties, err := GetStorageTableEntityE(tableName, storageAccountName, partitionKey, rowKey)
+	require.NoError(t, err)
+	return properties
+}
+
+// GetStorageTableEntityE gets the properties of the entity with the given partition and row key in the given storage
+// table, including the PartitionKey, RowKey and Timestamp system properties.
+func GetStorageTableEntityE(tableName string, storageAccountName string, partitionKey string, rowKey string) (map[string]interface{}, error) {
+	client, err := GetStorageTableClientE(tableName, storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetEntity(context.Background(), partitionKey, rowKey, nil)
+	if err != nil {
+		if ResourceNotFoundErrorExists(err) {
+			return nil, NewNotFoundError("storage table entity", partitionKey+"/"+rowKey, tableName)
+		}
+		return nil, err
+	}
+
+	properties := map[string]interface{}{}
+	if err := json.Unmarshal(resp.Value, &properties); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+// StorageTableEntityExists indicates whether an entity with the given partition and row key exists in the given
+// storage table; otherwise false.
+// This function would fail the test if there is an error.
+func StorageTableEntityExists(t *testing.T, tableName string, storageAccountName string, partitionKey string, rowKey string) bool {
+	exists, err := StorageTableEntityExistsE(tableName, storageAccountName, partitionKey, rowKey)
+	require.NoError(t, err)
+	return exists
+}
+
+// StorageTableEntityExistsE indicates whether an entity with the given partition and row key exists in the given
+// storage table; otherwise false.
+func StorageTableEntityExistsE(tableName string, storageAccountName string, partitionKey string, rowKey string) (bool, error) {
+	_, err := GetStorageTableEntityE(tableName, storageAccountName, partitionKey, rowKey)
+	if err != nil {
+		if _, ok := err.(NotFoundError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteStorageTableEntity deletes the entity with the given partition and row key from the given storage table.
+// This function would fail the test if there is an error.
+func DeleteStorageTableEntity(t *testing.T, tableName string, storageAccountName string, partitionKey string, rowKey string) {
+	err := DeleteStorageTableEntityE(tableName, storageAccountName, partitionKey, rowKey)
+	require.NoError(t, err)
+}
+
+// DeleteStorageTableEntityE deletes the entity with the given partition and row key from the given storage table.
+func DeleteStorageTableEntityE(tableName string, storageAccountName string, partitionKey string, rowKey string) error {
+	client, err := GetStorageTableClientE(tableName, storageAccountName)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteEntity(context.Background(), partitionKey, rowKey, nil)
+	return err
+}
+
+// GetStorageTableClientE creates a storage table data plane client for the given table, authenticated with Azure AD.
+func GetStorageTableClientE(tableName string, storageAccountName string) (*aztables.Client, error) {
+	return GetStorageTableClientWithOptionsE(tableName, storageAccountName, authOptionsFromEnv())
+}
+
+// GetStorageTableClientWithOptionsE creates a storage table data plane client for the given table, authenticated
+// according to the given AuthOptions.
+func GetStorageTableClientWithOptionsE(tableName string, storageAccountName string, authOptions AuthOptions) (*aztables.Client, error) {
+	serviceURL, err := getStorageServiceURLE(storageAccountName, "table")
+	if err != nil {
+		return nil, err
+	}
+	cred, err := NewTokenCredentialWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return aztables.NewClient(serviceURL+tableName, cred, nil)
+}
+
+// newStorageTableEntity returns the given properties with the given partition and row key, which take precedence over
+// any PartitionKey or RowKey in the properties.
+func newStorageTableEntity(partitionKey string, rowKey string, properties map[string]interface{}) map[string]interface{} {
+	entity := map[string]interface{}{}
+	for name, value := range properties {
+		entity[name] = value
+	}
+	entity[storageTablePartitionKey] = partitionKey
+	entity[storageTableRowKey] = rowKey
+	return entity
+}
@@ -1,8 +1,11 @@
 package azure
 
 import (
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,3 +43,68 @@ func TestGetStorageDNSString(t *testing.T) {
 	_, err := GetStorageDNSStringE("", "", "")
 	require.Error(t, err)
 }
+
+func TestDownloadStorageBlob(t *testing.T) {
+	_, err := DownloadStorageBlobE("", "", "")
+	require.Error(t, err)
+}
+
+func TestListStorageBlobs(t *testing.T) {
+	_, err := ListStorageBlobsE("", "", "")
+	require.Error(t, err)
+}
+
+func TestGenerateStorageBlobSasToken(t *testing.T) {
+	_, err := GenerateStorageBlobSasTokenE("", "", "", "", "", "r", time.Now().Add(time.Hour))
+	require.Error(t, err)
+}
+
+func TestVerifyStorageSasToken(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC()
+	sasToken := url.Values{
+		"sp":  {"racw"},
+		"se":  {expiry.Format(time.RFC3339)},
+		"sr":  {"b"},
+		"sig": {"signature"},
+	}.Encode()
+
+	require.NoError(t, VerifyStorageSasTokenE(sasToken, "rw", 30*time.Minute))
+	require.NoError(t, VerifyStorageSasTokenE("?"+sasToken, "", 0))
+	require.Error(t, VerifyStorageSasTokenE(sasToken, "d", 0))
+	require.Error(t, VerifyStorageSasTokenE(sasToken, "r", 2*time.Hour))
+	require.Error(t, VerifyStorageSasTokenE("sp=r&se=2030-01-01", "r", 0))
+	require.NoError(t, VerifyStorageSasTokenE("sp=r&se=2999-01-01&sig=signature", "r", 0))
+}
+
+func TestStorageStaticWebsiteEnabled(t *testing.T) {
+	_, err := StorageStaticWebsiteEnabledE("")
+	require.Error(t, err)
+}
+
+func TestGetStorageStaticWebsiteEndpoint(t *testing.T) {
+	_, err := GetStorageStaticWebsiteEndpointE("", "", "")
+	require.Error(t, err)
+}
+
+func TestDequeueStorageQueueMessage(t *testing.T) {
+	_, err := DequeueStorageQueueMessageE("", "")
+	require.Error(t, err)
+}
+
+func TestGetStorageTableEntity(t *testing.T) {
+	_, err := GetStorageTableEntityE("", "", "", "")
+	require.Error(t, err)
+}
+
+func TestNewStorageTableEntity(t *testing.T) {
+	properties := map[string]interface{}{"Name": "value", "RowKey": "ignored"}
+	entity := newStorageTableEntity("partition", "row", properties)
+
+	assert.Equal(t, map[string]interface{}{"Name": "value", "PartitionKey": "partition", "RowKey": "row"}, entity)
+	assert.Equal(t, "ignored", properties["RowKey"])
+}
+
+func TestDownloadStorageFile(t *testing.T) {
+	_, err := DownloadStorageFileE("", "", "")
+	require.Error(t, err)
+}
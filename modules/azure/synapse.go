@@ -3,14 +3,14 @@ package azure
 import (
 	"context"
 
-	"github.com/Azure/azure-sdk-for-go/services/synapse/mgmt/2020-12-01/synapse"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/synapse/armsynapse"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
 // GetSynapseWorkspace is a helper function that gets the synapse workspace.
 // This function would fail the test if there is an error.
-func GetSynapseWorkspace(t testing.TestingT, resGroupName string, workspaceName string, subscriptionID string) *synapse.Workspace {
+func GetSynapseWorkspace(t testing.TestingT, resGroupName string, workspaceName string, subscriptionID string) *armsynapse.Workspace {
 	Workspace, err := GetSynapseWorkspaceE(t, subscriptionID, resGroupName, workspaceName)
 	require.NoError(t, err)
 
@@ -19,7 +19,7 @@ func GetSynapseWorkspace(t testing.TestingT, resGroupName string, workspaceName
 
 // GetSynapseSqlPool is a helper function that gets the synapse workspace.
 // This function would fail the test if there is an error.
-func GetSynapseSqlPool(t testing.TestingT, resGroupName string, workspaceName string, sqlPoolName string, subscriptionID string) *synapse.SQLPool {
+func GetSynapseSqlPool(t testing.TestingT, resGroupName string, workspaceName string, sqlPoolName string, subscriptionID string) *armsynapse.SQLPool {
 	SQLPool, err := GetSynapseSqlPoolE(t, subscriptionID, resGroupName, workspaceName, sqlPoolName)
 	require.NoError(t, err)
 
@@ -27,7 +27,7 @@ func GetSynapseSqlPool(t testing.TestingT, resGroupName string, workspaceName st
 }
 
 // GetSynapseWorkspaceE is a helper function that gets the workspace.
-func GetSynapseWorkspaceE(t testing.TestingT, subscriptionID string, resGroupName string, workspaceName string) (*synapse.Workspace, error) {
+func GetSynapseWorkspaceE(t testing.TestingT, subscriptionID string, resGroupName string, workspaceName string) (*armsynapse.Workspace, error) {
 	// Create a synapse client
 	synapseClient, err := CreateSynapseWorkspaceClientE(subscriptionID)
 	if err != nil {
@@ -35,17 +35,17 @@ func GetSynapseWorkspaceE(t testing.TestingT, subscriptionID string, resGroupNam
 	}
 
 	// Get the corresponding synapse workspace
-	synapseWorkspace, err := synapseClient.Get(context.Background(), resGroupName, workspaceName)
+	synapseWorkspace, err := synapseClient.Get(context.Background(), resGroupName, workspaceName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return synapse workspace
-	return &synapseWorkspace, nil
+	return &synapseWorkspace.Workspace, nil
 }
 
 // GetSynapseSqlPoolE is a helper function that gets the synapse sql pool.
-func GetSynapseSqlPoolE(t testing.TestingT, subscriptionID string, resGroupName string, workspaceName string, sqlPoolName string) (*synapse.SQLPool, error) {
+func GetSynapseSqlPoolE(t testing.TestingT, subscriptionID string, resGroupName string, workspaceName string, sqlPoolName string) (*armsynapse.SQLPool, error) {
 	// Create a synapse client
 	synapseSqlPoolClient, err := CreateSynapseSqlPoolClientE(subscriptionID)
 	if err != nil {
@@ -53,11 +53,11 @@ func GetSynapseSqlPoolE(t testing.TestingT, subscriptionID string, resGroupName
 	}
 
 	// Get the corresponding synapse workspace
-	synapseSqlPool, err := synapseSqlPoolClient.Get(context.Background(), resGroupName, workspaceName, sqlPoolName)
+	synapseSqlPool, err := synapseSqlPoolClient.Get(context.Background(), resGroupName, workspaceName, sqlPoolName, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	//Return synapse workspace
-	return &synapseSqlPool, nil
+	return &synapseSqlPool.SQLPool, nil
 }
@@ -3,8 +3,9 @@ package azure
 import (
 	"context"
 	"net"
+	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -74,7 +75,7 @@ func CheckSubnetContainsIPE(ipAddress string, subnetName string, vnetName string
 	}
 
 	// Get Subnet IP range, this required field is never nil therefore no exception handling required.
-	subnetPrefix := *subnet.AddressPrefix
+	subnetPrefix := *subnet.Properties.AddressPrefix
 
 	// Check if the IP is in the Subnet Range using the net package
 	_, ipNet, err := net.ParseCIDR(subnetPrefix)
@@ -103,16 +104,19 @@ func GetVirtualNetworkSubnetsE(vnetName string, resGroupName string, subscriptio
 		return subNetDetails, err
 	}
 
-	subnets, err := client.List(context.Background(), resGroupName, vnetName)
-	if err != nil {
-		return subNetDetails, err
-	}
+	pager := client.NewListPager(resGroupName, vnetName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return subNetDetails, err
+		}
 
-	for _, v := range subnets.Values() {
-		subnetName := v.Name
-		subNetAddressPrefix := v.AddressPrefix
+		for _, v := range page.Value {
+			subnetName := v.Name
+			subNetAddressPrefix := v.Properties.AddressPrefix
 
-		subNetDetails[string(*subnetName)] = string(*subNetAddressPrefix)
+			subNetDetails[string(*subnetName)] = string(*subNetAddressPrefix)
+		}
 	}
 	return subNetDetails, nil
 }
@@ -134,11 +138,11 @@ func GetVirtualNetworkDNSServerIPsE(vnetName string, resGroupName string, subscr
 		return nil, err
 	}
 
-	return *vnet.DhcpOptions.DNSServers, nil
+	return safePtrToList(vnet.Properties.DhcpOptions.DNSServers), nil
 }
 
 // GetSubnetE gets a subnet.
-func GetSubnetE(subnetName string, vnetName string, resGroupName string, subscriptionID string) (*network.Subnet, error) {
+func GetSubnetE(subnetName string, vnetName string, resGroupName string, subscriptionID string) (*armnetwork.Subnet, error) {
 	// Validate Azure Resource Group
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -152,34 +156,22 @@ func GetSubnetE(subnetName string, vnetName string, resGroupName string, subscri
 	}
 
 	// Get the Subnet
-	subnet, err := client.Get(context.Background(), resGroupName, vnetName, subnetName, "")
+	subnet, err := client.Get(context.Background(), resGroupName, vnetName, subnetName, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &subnet, nil
+	return &subnet.Subnet, nil
 }
 
 // GetSubnetClientE creates a subnet client.
-func GetSubnetClientE(subscriptionID string) (*network.SubnetsClient, error) {
+func GetSubnetClientE(subscriptionID string) (*armnetwork.SubnetsClient, error) {
 	// Create a new Subnet client from client factory
-	client, err := CreateNewSubnetClientE(subscriptionID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
-	if err != nil {
-		return nil, err
-	}
-	client.Authorizer = *authorizer
-
-	return client, nil
+	return CreateNewSubnetClientE(subscriptionID)
 }
 
 // GetVirtualNetworkE gets Virtual Network in the specified Azure Resource Group.
-func GetVirtualNetworkE(vnetName string, resGroupName string, subscriptionID string) (*network.VirtualNetwork, error) {
+func GetVirtualNetworkE(vnetName string, resGroupName string, subscriptionID string) (*armnetwork.VirtualNetwork, error) {
 	// Validate Azure Resource Group
 	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
 	if err != nil {
@@ -193,27 +185,196 @@ func GetVirtualNetworkE(vnetName string, resGroupName string, subscriptionID str
 	}
 
 	// Get the Virtual Network
-	vnet, err := client.Get(context.Background(), resGroupName, vnetName, "")
+	vnet, err := client.Get(context.Background(), resGroupName, vnetName, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &vnet, nil
+	return &vnet.VirtualNetwork, nil
 }
 
 // GetVirtualNetworksClientE creates a virtual network client in the specified Azure Subscription.
-func GetVirtualNetworksClientE(subscriptionID string) (*network.VirtualNetworksClient, error) {
+func GetVirtualNetworksClientE(subscriptionID string) (*armnetwork.VirtualNetworksClient, error) {
 	// Create a new Virtual Network client from client factory
-	client, err := CreateNewVirtualNetworkClientE(subscriptionID)
+	return CreateNewVirtualNetworkClientE(subscriptionID)
+}
+
+// VirtualNetworkPeeringSummary is a string-based (non-pointer) summary of a virtual network peering.
+type VirtualNetworkPeeringSummary struct {
+	Name                      string
+	RemoteVirtualNetworkID    string
+	PeeringState              string
+	AllowVirtualNetworkAccess bool
+	AllowForwardedTraffic     bool
+	AllowGatewayTransit       bool
+	UseRemoteGateways         bool
+}
+
+// GetVirtualNetworkPeerings gets the peerings of the given virtual network.
+// This function would fail the test if there is an error.
+func GetVirtualNetworkPeerings(t testing.TestingT, vnetName string, resGroupName string, subscriptionID string) []VirtualNetworkPeeringSummary {
+	peerings, err := GetVirtualNetworkPeeringsE(vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return peerings
+}
+
+// GetVirtualNetworkPeeringsE gets the peerings of the given virtual network.
+func GetVirtualNetworkPeeringsE(vnetName string, resGroupName string, subscriptionID string) ([]VirtualNetworkPeeringSummary, error) {
+	vnet, err := GetVirtualNetworkE(vnetName, resGroupName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create an authorizer
-	authorizer, err := NewAuthorizer()
+	peerings := []VirtualNetworkPeeringSummary{}
+	if vnet.Properties == nil {
+		return peerings, nil
+	}
+	for _, peering := range vnet.Properties.VirtualNetworkPeerings {
+		if peering == nil || peering.Properties == nil {
+			continue
+		}
+		summary := VirtualNetworkPeeringSummary{
+			Name:                      safePtrToString(peering.Name),
+			PeeringState:              safeEnumPtrToString(peering.Properties.PeeringState),
+			AllowVirtualNetworkAccess: safePtrToBool(peering.Properties.AllowVirtualNetworkAccess),
+			AllowForwardedTraffic:     safePtrToBool(peering.Properties.AllowForwardedTraffic),
+			AllowGatewayTransit:       safePtrToBool(peering.Properties.AllowGatewayTransit),
+			UseRemoteGateways:         safePtrToBool(peering.Properties.UseRemoteGateways),
+		}
+		if peering.Properties.RemoteVirtualNetwork != nil {
+			summary.RemoteVirtualNetworkID = safePtrToString(peering.Properties.RemoteVirtualNetwork.ID)
+		}
+		peerings = append(peerings, summary)
+	}
+	return peerings, nil
+}
+
+// VirtualNetworkPeeringConnected indicates whether the given virtual network has a peering to the given remote virtual
+// network in the Connected state; otherwise false.
+// This function would fail the test if there is an error.
+func VirtualNetworkPeeringConnected(t testing.TestingT, remoteVnetName string, vnetName string, resGroupName string, subscriptionID string) bool {
+	connected, err := VirtualNetworkPeeringConnectedE(remoteVnetName, vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return connected
+}
+
+// VirtualNetworkPeeringConnectedE indicates whether the given virtual network has a peering to the given remote
+// virtual network in the Connected state; otherwise false.
+func VirtualNetworkPeeringConnectedE(remoteVnetName string, vnetName string, resGroupName string, subscriptionID string) (bool, error) {
+	peerings, err := GetVirtualNetworkPeeringsE(vnetName, resGroupName, subscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, peering := range peerings {
+		if strings.EqualFold(GetNameFromResourceID(peering.RemoteVirtualNetworkID), remoteVnetName) {
+			return peering.PeeringState == string(armnetwork.VirtualNetworkPeeringStateConnected), nil
+		}
+	}
+	return false, nil
+}
+
+// GetSubnetServiceEndpoints gets the services (e.g., Microsoft.Storage) the given subnet has service endpoints for.
+// This function would fail the test if there is an error.
+func GetSubnetServiceEndpoints(t testing.TestingT, subnetName string, vnetName string, resGroupName string, subscriptionID string) []string {
+	services, err := GetSubnetServiceEndpointsE(subnetName, vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return services
+}
+
+// GetSubnetServiceEndpointsE gets the services (e.g., Microsoft.Storage) the given subnet has service endpoints for.
+func GetSubnetServiceEndpointsE(subnetName string, vnetName string, resGroupName string, subscriptionID string) ([]string, error) {
+	subnet, err := GetSubnetE(subnetName, vnetName, resGroupName, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
-	client.Authorizer = *authorizer
 
-	return client, nil
+	services := []string{}
+	if subnet.Properties == nil {
+		return services, nil
+	}
+	for _, endpoint := range subnet.Properties.ServiceEndpoints {
+		if endpoint != nil {
+			services = append(services, safePtrToString(endpoint.Service))
+		}
+	}
+	return services, nil
+}
+
+// GetSubnetDelegations gets the services (e.g., Microsoft.Web/serverFarms) the given subnet is delegated to.
+// This function would fail the test if there is an error.
+func GetSubnetDelegations(t testing.TestingT, subnetName string, vnetName string, resGroupName string, subscriptionID string) []string {
+	services, err := GetSubnetDelegationsE(subnetName, vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return services
+}
+
+// GetSubnetDelegationsE gets the services (e.g., Microsoft.Web/serverFarms) the given subnet is delegated to.
+func GetSubnetDelegationsE(subnetName string, vnetName string, resGroupName string, subscriptionID string) ([]string, error) {
+	subnet, err := GetSubnetE(subnetName, vnetName, resGroupName, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	services := []string{}
+	if subnet.Properties == nil {
+		return services, nil
+	}
+	for _, delegation := range subnet.Properties.Delegations {
+		if delegation != nil && delegation.Properties != nil {
+			services = append(services, safePtrToString(delegation.Properties.ServiceName))
+		}
+	}
+	return services, nil
+}
+
+// GetSubnetRouteTableName gets the name of the route table associated with the given subnet, or an empty string if
+// there is none.
+// This function would fail the test if there is an error.
+func GetSubnetRouteTableName(t testing.TestingT, subnetName string, vnetName string, resGroupName string, subscriptionID string) string {
+	routeTableName, err := GetSubnetRouteTableNameE(subnetName, vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return routeTableName
+}
+
+// GetSubnetRouteTableNameE gets the name of the route table associated with the given subnet, or an empty string if
+// there is none.
+func GetSubnetRouteTableNameE(subnetName string, vnetName string, resGroupName string, subscriptionID string) (string, error) {
+	subnet, err := GetSubnetE(subnetName, vnetName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if subnet.Properties == nil || subnet.Properties.RouteTable == nil || subnet.Properties.RouteTable.ID == nil {
+		return "", nil
+	}
+	return GetNameFromResourceIDE(*subnet.Properties.RouteTable.ID)
+}
+
+// GetSubnetNsgName gets the name of the network security group associated with the given subnet, or an empty string if
+// there is none.
+// This function would fail the test if there is an error.
+func GetSubnetNsgName(t testing.TestingT, subnetName string, vnetName string, resGroupName string, subscriptionID string) string {
+	nsgName, err := GetSubnetNsgNameE(subnetName, vnetName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return nsgName
+}
+
+// GetSubnetNsgNameE gets the name of the network security group associated with the given subnet, or an empty string
+// if there is none.
+func GetSubnetNsgNameE(subnetName string, vnetName string, resGroupName string, subscriptionID string) (string, error) {
+	subnet, err := GetSubnetE(subnetName, vnetName, resGroupName, subscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	if subnet.Properties == nil || subnet.Properties.NetworkSecurityGroup == nil || subnet.Properties.NetworkSecurityGroup.ID == nil {
+		return "", nil
+	}
+	return GetNameFromResourceIDE(*subnet.Properties.NetworkSecurityGroup.ID)
 }
@@ -104,3 +104,28 @@ func TestVirtualNetworkExistsE(t *testing.T) {
 
 	require.Error(t, err)
 }
+
+func TestGetVirtualNetworkPeeringsE(t *testing.T) {
+	t.Parallel()
+
+	vnetName := ""
+	rgName := ""
+	subID := ""
+
+	_, err := GetVirtualNetworkPeeringsE(vnetName, rgName, subID)
+
+	require.Error(t, err)
+}
+
+func TestGetSubnetDelegationsE(t *testing.T) {
+	t.Parallel()
+
+	subnetName := ""
+	vnetName := ""
+	rgName := ""
+	subID := ""
+
+	_, err := GetSubnetDelegationsE(subnetName, vnetName, rgName, subID)
+
+	require.Error(t, err)
+}
@@ -0,0 +1,352 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// VMSSInstance summarizes an instance of a Virtual Machine Scale Set.
+type VMSSInstance struct {
+	InstanceID         string
+	Name               string
+	ComputerName       string
+	ProvisioningState  string
+	PowerState         string // e.g., running, stopped or deallocated
+	LatestModelApplied bool
+}
+
+// VMSSAutoscaleCapacity is the instance capacity of the default profile of an autoscale setting.
+type VMSSAutoscaleCapacity struct {
+	Minimum int
+	Maximum int
+	Default int
+}
+
+// VMRunCommandResult is the output of a script run with the Run Command API.
+type VMRunCommandResult struct {
+	StdOut string
+	StdErr string
+}
+
+// GetVirtualMachineScaleSet gets a Virtual Machine Scale Set in the specified Azure Resource Group.
+// This function would fail the test if there is an error.
+func GetVirtualMachineScaleSet(t testing.TestingT, vmssName string, resGroupName string, subscriptionID string) *armcompute.VirtualMachineScaleSet {
+	vmss, err := GetVirtualMachineScaleSetE(vmssName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return vmss
+}
+
+// GetVirtualMachineScaleSetE gets a Virtual Machine Scale Set in the specified Azure Resource Group.
+func GetVirtualMachineScaleSetE(vmssName string, resGroupName string, subscriptionID string) (*armcompute.VirtualMachineScaleSet, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreateVirtualMachineScaleSetsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	vmss, err := client.Get(context.Background(), resGroupName, vmssName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vmss.VirtualMachineScaleSet, nil
+}
+
+// ListVMSSInstances lists the instances of the specified Virtual Machine Scale Set with their provisioning and power
+// states. This function would fail the test if there is an error.
+func ListVMSSInstances(t testing.TestingT, vmssName string, resGroupName string, subscriptionID string) []VMSSInstance {
+	instances, err := ListVMSSInstancesE(vmssName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return instances
+}
+
+// ListVMSSInstancesE lists the instances of the specified Virtual Machine Scale Set with their provisioning and power
+// states. Only scale sets with Uniform orchestration have instances in this API.
+func ListVMSSInstancesE(vmssName string, resGroupName string, subscriptionID string) ([]VMSSInstance, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := CreateVirtualMachineScaleSetVMsClientE(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []VMSSInstance{}
+	pager := client.NewListPager(resGroupName, vmssName, &armcompute.VirtualMachineScaleSetVMsClientListOptions{Expand: to.Ptr("instanceView")})
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range page.Value {
+			instances = append(instances, newVMSSInstance(vm))
+		}
+	}
+
+	return instances, nil
+}
+
+// newVMSSInstance summarizes the given scale set instance.
+func newVMSSInstance(vm *armcompute.VirtualMachineScaleSetVM) VMSSInstance {
+	instance := VMSSInstance{
+		InstanceID: safePtrToString(vm.InstanceID),
+		Name:       safePtrToString(vm.Name),
+	}
+	if vm.Properties == nil {
+		return instance
+	}
+
+	instance.ProvisioningState = safePtrToString(vm.Properties.ProvisioningState)
+	instance.LatestModelApplied = safePtrToBool(vm.Properties.LatestModelApplied)
+	if vm.Properties.OSProfile != nil {
+		instance.ComputerName = safePtrToString(vm.Properties.OSProfile.ComputerName)
+	}
+	if vm.Properties.InstanceView != nil {
+		for _, status := range vm.Properties.InstanceView.Statuses {
+			if code := safePtrToString(status.Code); strings.HasPrefix(code, "PowerState/") {
+				instance.PowerState = strings.TrimPrefix(code, "PowerState/")
+			}
+		}
+	}
+
+	return instance
+}
+
+// GetVMSSInstanceCount gets the number of instances of the specified Virtual Machine Scale Set.
+// This function would fail the test if there is an error.
+func GetVMSSInstanceCount(t testing.TestingT, vmssName string, resGroupName string, subscriptionID string) int {
+	count, err := GetVMSSInstanceCountE(vmssName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return count
+}
+
+// GetVMSSInstanceCountE gets the number of instances of the specified Virtual Machine Scale Set.
+func GetVMSSInstanceCountE(vmssName string, resGroupName string, subscriptionID string) (int, error) {
+	instances, err := ListVMSSInstancesE(vmssName, resGroupName, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(instances), nil
+}
+
+// WaitForVMSSRollingUpgrade waits for the latest rolling upgrade of the specified Virtual Machine Scale Set to
+// complete. This function would fail the test if there is an error or the upgrade doesn't complete in time.
+func WaitForVMSSRollingUpgrade(t testing.TestingT, vmssName string, resGroupName string, subscriptionID string, maxRetries int, sleepBetweenRetries time.Duration) {
+	err := WaitForVMSSRollingUpgradeE(t, vmssName, resGroupName, subscriptionID, maxRetries, sleepBetweenRetries)
+	require.NoError(t, err)
+}
+
+// WaitForVMSSRollingUpgradeE waits for the latest rolling upgrade of the specified Virtual Machine Scale Set to
+// complete. A cancelled or faulted upgrade is returned as an error right away.
+func WaitForVMSSRollingUpgradeE(t testing.TestingT, vmssName string, resGroupName string, subscriptionID string, maxRetries int, sleepBetweenRetries time.Duration) error {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return err
+	}
+
+	client, err := CreateVirtualMachineScaleSetRollingUpgradesClientE(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Waiting for the rolling upgrade of scale set %s to complete", vmssName)
+	_, err = retry.DoWithRetryE(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		resp, err := client.GetLatest(context.Background(), resGroupName, vmssName, nil)
+		if err != nil {
+			return "", err
+		}
+		return "", checkVMSSRollingUpgradeStatus(vmssName, resp.RollingUpgradeStatusInfo)
+	})
+
+	return err
+}
+
+// checkVMSSRollingUpgradeStatus returns nil if the given rolling upgrade completed, a retry.FatalError if it was
+// cancelled or faulted, and an error to retry on otherwise.
+func checkVMSSRollingUpgradeStatus(vmssName string, status armcompute.RollingUpgradeStatusInfo) error {
+	if status.Properties == nil || status.Properties.RunningStatus == nil || status.Properties.RunningStatus.Code == nil {
+		return fmt.Errorf("rolling upgrade of scale set %s has no status yet", vmssName)
+	}
+
+	code := *status.Properties.RunningStatus.Code
+	switch code {
+	case armcompute.RollingUpgradeStatusCodeCompleted:
+		return nil
+	case armcompute.RollingUpgradeStatusCodeCancelled, armcompute.RollingUpgradeStatusCodeFaulted:
+		message := ""
+		if status.Properties.Error != nil {
+			message = safePtrToString(status.Properties.Error.Message)
+		}
+		return retry.FatalError{Underlying: fmt.Errorf("rolling upgrade of scale set %s is %s: %s", vmssName, code, message)}
+	default:
+		return fmt.Errorf("rolling upgrade of scale set %s is %s", vmssName, code)
+	}
+}
+
+// GetVMSSAutoscaleCapacity gets the capacity of the default profile of the specified autoscale setting.
+// This function would fail the test if there is an error.
+func GetVMSSAutoscaleCapacity(t testing.TestingT, autoscaleSettingName string, resGroupName string, subscriptionID string) VMSSAutoscaleCapacity {
+	capacity, err := GetVMSSAutoscaleCapacityE(autoscaleSettingName, resGroupName, subscriptionID)
+	require.NoError(t, err)
+
+	return capacity
+}
+
+// GetVMSSAutoscaleCapacityE gets the capacity of the default profile of the specified autoscale setting, i.e., the
+// profile without a recurrence or fixed date, or the first profile if all have one.
+func GetVMSSAutoscaleCapacityE(autoscaleSettingName string, resGroupName string, subscriptionID string) (VMSSAutoscaleCapacity, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return VMSSAutoscaleCapacity{}, err
+	}
+
+	client, err := CreateAutoscaleSettingsClientE(subscriptionID)
+	if err != nil {
+		return VMSSAutoscaleCapacity{}, err
+	}
+
+	setting, err := client.Get(context.Background(), resGroupName, autoscaleSettingName, nil)
+	if err != nil {
+		return VMSSAutoscaleCapacity{}, err
+	}
+	if setting.Properties == nil {
+		return VMSSAutoscaleCapacity{}, NewNotFoundError("Autoscale profile", "Any", autoscaleSettingName)
+	}
+
+	return defaultAutoscaleCapacityE(autoscaleSettingName, setting.Properties.Profiles)
+}
+
+// defaultAutoscaleCapacityE returns the capacity of the default profile among the given autoscale profiles.
+func defaultAutoscaleCapacityE(autoscaleSettingName string, profiles []*armmonitor.AutoscaleProfile) (VMSSAutoscaleCapacity, error) {
+	var profile *armmonitor.AutoscaleProfile
+	for _, candidate := range profiles {
+		if candidate == nil {
+			continue
+		}
+		if profile == nil || (candidate.Recurrence == nil && candidate.FixedDate == nil) {
+			profile = candidate
+		}
+		if candidate.Recurrence == nil && candidate.FixedDate == nil {
+			break
+		}
+	}
+	if profile == nil || profile.Capacity == nil {
+		return VMSSAutoscaleCapacity{}, NewNotFoundError("Autoscale profile", "Any", autoscaleSettingName)
+	}
+
+	minimum, err := strconv.Atoi(safePtrToString(profile.Capacity.Minimum))
+	if err != nil {
+		return VMSSAutoscaleCapacity{}, NewFailedToParseError("Autoscale minimum capacity", autoscaleSettingName)
+	}
+	maximum, err := strconv.Atoi(safePtrToString(profile.Capacity.Maximum))
+	if err != nil {
+		return VMSSAutoscaleCapacity{}, NewFailedToParseError("Autoscale maximum capacity", autoscaleSettingName)
+	}
+	def, err := strconv.Atoi(safePtrToString(profile.Capacity.Default))
+	if err != nil {
+		return VMSSAutoscaleCapacity{}, NewFailedToParseError("Autoscale default capacity", autoscaleSettingName)
+	}
+
+	return VMSSAutoscaleCapacity{Minimum: minimum, Maximum: maximum, Default: def}, nil
+}
+
+// AssertVMSSInstanceCountWithinAutoscale checks that the number of instances of the specified Virtual Machine Scale
+// Set is within the minimum and maximum capacity of the default profile of the specified autoscale setting.
+func AssertVMSSInstanceCountWithinAutoscale(t testing.TestingT, vmssName string, autoscaleSettingName string, resGroupName string, subscriptionID string) {
+	count, err := GetVMSSInstanceCountE(vmssName, resGroupName, subscriptionID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	capacity, err := GetVMSSAutoscaleCapacityE(autoscaleSettingName, resGroupName, subscriptionID)
+	if assert.NoError(t, err) {
+		assert.Truef(t, count >= capacity.Minimum && count <= capacity.Maximum,
+			"Scale set %s has %d instances, outside the capacity of %d to %d of autoscale setting %s", vmssName, count, capacity.Minimum, capacity.Maximum, autoscaleSettingName)
+	}
+}
+
+// RunCommandOnVMSSInstance runs the given script on the specified instance of a Virtual Machine Scale Set with the
+// Run Command API. See RunCommandOnVMSSInstanceE.
+func RunCommandOnVMSSInstance(t testing.TestingT, vmssName string, instanceID string, resGroupName string, subscriptionID string, commandID string, script []string) VMRunCommandResult {
+	result, err := RunCommandOnVMSSInstanceE(vmssName, instanceID, resGroupName, subscriptionID, commandID, script)
+	require.NoError(t, err)
+
+	return result
+}
+
+// RunCommandOnVMSSInstanceE runs the given script on the specified instance of a Virtual Machine Scale Set with the
+// Run Command API, and returns its output. The commandID is RunShellScript for Linux instances and
+// RunPowerShellScript for Windows instances. This works without network access to the instance, but only one command
+// can run on an instance at a time.
+func RunCommandOnVMSSInstanceE(vmssName string, instanceID string, resGroupName string, subscriptionID string, commandID string, script []string) (VMRunCommandResult, error) {
+	resGroupName, err := getTargetAzureResourceGroupName(resGroupName)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	client, err := CreateVirtualMachineScaleSetVMsClientE(subscriptionID)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	poller, err := client.BeginRunCommand(context.Background(), resGroupName, vmssName, instanceID, armcompute.RunCommandInput{
+		CommandID: &commandID,
+		Script:    to.SliceOfPtrs(script...),
+	}, nil)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+	resp, err := poller.PollUntilDone(context.Background(), nil)
+	if err != nil {
+		return VMRunCommandResult{}, err
+	}
+
+	return parseRunCommandResult(resp.RunCommandResult), nil
+}
+
+// parseRunCommandResult extracts the output of a script from the given Run Command result. Windows instances report
+// stdout and stderr as separate statuses, while Linux instances report both in one message, in [stdout] and [stderr]
+// sections.
+func parseRunCommandResult(result armcompute.RunCommandResult) VMRunCommandResult {
+	output := VMRunCommandResult{}
+	for _, status := range result.Value {
+		code := safePtrToString(status.Code)
+		message := safePtrToString(status.Message)
+		switch {
+		case strings.HasPrefix(code, "ComponentStatus/StdOut/"):
+			output.StdOut = message
+		case strings.HasPrefix(code, "ComponentStatus/StdErr/"):
+			output.StdErr = message
+		default:
+			stdoutIndex := strings.Index(message, "[stdout]\n")
+			stderrIndex := strings.Index(message, "[stderr]\n")
+			if stdoutIndex < 0 || stderrIndex < stdoutIndex {
+				continue
+			}
+			output.StdOut = strings.TrimSpace(message[stdoutIndex+len("[stdout]\n") : stderrIndex])
+			output.StdErr = strings.TrimSpace(message[stderrIndex+len("[stderr]\n"):])
+		}
+	}
+
+	return output
+}
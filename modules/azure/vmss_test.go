@@ -0,0 +1,114 @@
+//go:build azure
+// +build azure
+
+// NOTE: We use build tags to differentiate azure testing because we currently do not have azure access setup for
+// CircleCI.
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVMSSInstance(t *testing.T) {
+	t.Parallel()
+
+	instance := newVMSSInstance(&armcompute.VirtualMachineScaleSetVM{
+		InstanceID: to.Ptr("3"),
+		Name:       to.Ptr("vmss_3"),
+		Properties: &armcompute.VirtualMachineScaleSetVMProperties{
+			ProvisioningState:  to.Ptr("Succeeded"),
+			LatestModelApplied: to.Ptr(true),
+			OSProfile:          &armcompute.OSProfile{ComputerName: to.Ptr("vmss000003")},
+			InstanceView: &armcompute.VirtualMachineScaleSetVMInstanceView{
+				Statuses: []*armcompute.InstanceViewStatus{
+					{Code: to.Ptr("ProvisioningState/succeeded")},
+					{Code: to.Ptr("PowerState/running")},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, VMSSInstance{
+		InstanceID:         "3",
+		Name:               "vmss_3",
+		ComputerName:       "vmss000003",
+		ProvisioningState:  "Succeeded",
+		PowerState:         "running",
+		LatestModelApplied: true,
+	}, instance)
+}
+
+func TestCheckVMSSRollingUpgradeStatus(t *testing.T) {
+	t.Parallel()
+
+	status := func(code armcompute.RollingUpgradeStatusCode) armcompute.RollingUpgradeStatusInfo {
+		return armcompute.RollingUpgradeStatusInfo{Properties: &armcompute.RollingUpgradeStatusInfoProperties{
+			RunningStatus: &armcompute.RollingUpgradeRunningStatus{Code: &code},
+		}}
+	}
+
+	assert.NoError(t, checkVMSSRollingUpgradeStatus("vmss", status(armcompute.RollingUpgradeStatusCodeCompleted)))
+
+	err := checkVMSSRollingUpgradeStatus("vmss", status(armcompute.RollingUpgradeStatusCodeRollingForward))
+	require.Error(t, err)
+	_, isFatal := err.(retry.FatalError)
+	assert.False(t, isFatal)
+	assert.IsType(t, retry.FatalError{}, checkVMSSRollingUpgradeStatus("vmss", status(armcompute.RollingUpgradeStatusCodeFaulted)))
+	assert.Error(t, checkVMSSRollingUpgradeStatus("vmss", armcompute.RollingUpgradeStatusInfo{}))
+}
+
+func TestDefaultAutoscaleCapacity(t *testing.T) {
+	t.Parallel()
+
+	profiles := []*armmonitor.AutoscaleProfile{
+		{
+			Capacity:   &armmonitor.ScaleCapacity{Minimum: to.Ptr("5"), Maximum: to.Ptr("10"), Default: to.Ptr("5")},
+			Recurrence: &armmonitor.Recurrence{},
+		},
+		{
+			Capacity: &armmonitor.ScaleCapacity{Minimum: to.Ptr("1"), Maximum: to.Ptr("3"), Default: to.Ptr("2")},
+		},
+	}
+
+	capacity, err := defaultAutoscaleCapacityE("autoscale", profiles)
+	require.NoError(t, err)
+	assert.Equal(t, VMSSAutoscaleCapacity{Minimum: 1, Maximum: 3, Default: 2}, capacity)
+
+	capacity, err = defaultAutoscaleCapacityE("autoscale", profiles[:1])
+	require.NoError(t, err)
+	assert.Equal(t, VMSSAutoscaleCapacity{Minimum: 5, Maximum: 10, Default: 5}, capacity)
+
+	_, err = defaultAutoscaleCapacityE("autoscale", nil)
+	require.Error(t, err)
+}
+
+func TestParseRunCommandResult(t *testing.T) {
+	t.Parallel()
+
+	linux := parseRunCommandResult(armcompute.RunCommandResult{Value: []*armcompute.InstanceViewStatus{{
+		Code:    to.Ptr("ProvisioningState/succeeded"),
+		Message: to.Ptr("Enable succeeded: \n[stdout]\nhello\n\n[stderr]\nwarning\n"),
+	}}})
+	assert.Equal(t, VMRunCommandResult{StdOut: "hello", StdErr: "warning"}, linux)
+
+	windows := parseRunCommandResult(armcompute.RunCommandResult{Value: []*armcompute.InstanceViewStatus{
+		{Code: to.Ptr("ComponentStatus/StdOut/succeeded"), Message: to.Ptr("hello")},
+		{Code: to.Ptr("ComponentStatus/StdErr/succeeded"), Message: to.Ptr("")},
+	}})
+	assert.Equal(t, VMRunCommandResult{StdOut: "hello"}, windows)
+}
+
+func TestListVMSSInstancesE(t *testing.T) {
+	t.Parallel()
+
+	_, err := ListVMSSInstancesE("", "", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,187 @@
+// Package cdktf allows to interact with CDK for Terraform (cdktf) apps, synthesizing them to Terraform configurations
+// that can be tested with the terraform module.
+package cdktf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// DefaultBinary is the name of the cdktf binary used if Options.Binary is not set
+	DefaultBinary = "cdktf"
+
+	// DefaultOutputDir is the folder, relative to the app folder, cdktf synthesizes to if Options.OutputDir is not set
+	DefaultOutputDir = "cdktf.out"
+
+	manifestFile = "manifest.json"
+)
+
+// Options are the options for cdktf.
+type Options struct {
+	AppDir    string            // The folder of the cdktf app, i.e., the folder containing cdktf.json
+	OutputDir string            // The folder to synthesize the stacks to. Relative paths are relative to AppDir. Defaults to cdktf.out.
+	Binary    string            // The cdktf binary to run. Defaults to cdktf.
+	EnvVars   map[string]string // Environment variables to set when running cdktf (e.g., to pass context to the app)
+	ExtraArgs []string          // Extra arguments passed to cdktf synth
+	Logger    *logger.Logger    // If set, use a non-default logger
+}
+
+// manifest is the subset of the manifest.json file written by cdktf synth that we need.
+type manifest struct {
+	Stacks map[string]struct {
+		WorkingDirectory string `json:"workingDirectory"`
+	} `json:"stacks"`
+}
+
+// Synth runs cdktf synth to synthesize the stacks of the app to Terraform configurations, and returns stdout/stderr.
+// This will fail the test if there is an error.
+func Synth(t testing.TestingT, options *Options) string {
+	out, err := SynthE(t, options)
+	require.NoError(t, err)
+	return out
+}
+
+// SynthE runs cdktf synth to synthesize the stacks of the app to Terraform configurations, and returns stdout/stderr.
+func SynthE(t testing.TestingT, options *Options) (string, error) {
+	binary := options.Binary
+	if binary == "" {
+		binary = DefaultBinary
+	}
+
+	args := append([]string{"synth", "--output", outputDir(options)}, options.ExtraArgs...)
+	cmd := shell.Command{
+		Command:    binary,
+		Args:       args,
+		WorkingDir: options.AppDir,
+		Env:        options.EnvVars,
+		Logger:     options.Logger,
+	}
+	return shell.RunCommandAndGetOutputE(t, cmd)
+}
+
+// GetStackDirs returns the folders the stacks of the app were synthesized to, keyed by the name of the stack. Synth
+// must have been called first. This will fail the test if there is an error.
+func GetStackDirs(t testing.TestingT, options *Options) map[string]string {
+	dirs, err := GetStackDirsE(t, options)
+	require.NoError(t, err)
+	return dirs
+}
+
+// GetStackDirsE returns the folders the stacks of the app were synthesized to, keyed by the name of the stack. Synth
+// must have been called first.
+func GetStackDirsE(t testing.TestingT, options *Options) (map[string]string, error) {
+	manifestPath := filepath.Join(outputDir(options), manifestFile)
+	contents, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed manifest
+	if err := json.Unmarshal(contents, &parsed); err != nil {
+		return nil, err
+	}
+
+	dirs := map[string]string{}
+	for name, stack := range parsed.Stacks {
+		dirs[name] = filepath.Join(outputDir(options), filepath.FromSlash(stack.WorkingDirectory))
+	}
+	return dirs, nil
+}
+
+// GetStackNames returns the sorted names of the stacks of the app. Synth must have been called first. This will fail
+// the test if there is an error.
+func GetStackNames(t testing.TestingT, options *Options) []string {
+	names, err := GetStackNamesE(t, options)
+	require.NoError(t, err)
+	return names
+}
+
+// GetStackNamesE returns the sorted names of the stacks of the app. Synth must have been called first.
+func GetStackNamesE(t testing.TestingT, options *Options) ([]string, error) {
+	dirs, err := GetStackDirsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetTerraformOptions returns terraform.Options pointed at the synthesized configuration of the given stack, so that
+// the helpers in the terraform module (e.g., terraform.InitAndApply, terraform.Output) can be used on it. Synth must
+// have been called first. This will fail the test if there is an error.
+func GetTerraformOptions(t testing.TestingT, options *Options, stack string) *terraform.Options {
+	terraformOptions, err := GetTerraformOptionsE(t, options, stack)
+	require.NoError(t, err)
+	return terraformOptions
+}
+
+// GetTerraformOptionsE returns terraform.Options pointed at the synthesized configuration of the given stack, so that
+// the helpers in the terraform module (e.g., terraform.InitAndApply, terraform.Output) can be used on it. Synth must
+// have been called first. Variables are not needed, as they are baked into the synthesized configuration; the
+// EnvVars and Logger of the cdktf options are carried over.
+func GetTerraformOptionsE(t testing.TestingT, options *Options, stack string) (*terraform.Options, error) {
+	dirs, err := GetStackDirsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, exists := dirs[stack]
+	if !exists {
+		return nil, StackNotFound{Stack: stack, OutputDir: outputDir(options)}
+	}
+
+	envVars := map[string]string{}
+	for key, value := range options.EnvVars {
+		envVars[key] = value
+	}
+
+	return &terraform.Options{
+		TerraformDir: dir,
+		EnvVars:      envVars,
+		Logger:       options.Logger,
+		NoColor:      true,
+	}, nil
+}
+
+// SynthAndGetTerraformOptions runs cdktf synth and returns terraform.Options pointed at the synthesized configuration
+// of the given stack. This will fail the test if there is an error.
+func SynthAndGetTerraformOptions(t testing.TestingT, options *Options, stack string) *terraform.Options {
+	terraformOptions, err := SynthAndGetTerraformOptionsE(t, options, stack)
+	require.NoError(t, err)
+	return terraformOptions
+}
+
+// SynthAndGetTerraformOptionsE runs cdktf synth and returns terraform.Options pointed at the synthesized
+// configuration of the given stack.
+func SynthAndGetTerraformOptionsE(t testing.TestingT, options *Options, stack string) (*terraform.Options, error) {
+	if _, err := SynthE(t, options); err != nil {
+		return nil, err
+	}
+	return GetTerraformOptionsE(t, options, stack)
+}
+
+// outputDir returns the folder the stacks are synthesized to.
+func outputDir(options *Options) string {
+	dir := options.OutputDir
+	if dir == "" {
+		dir = DefaultOutputDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(options.AppDir, dir)
+}
@@ -0,0 +1,48 @@
+package cdktf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `{
+  "version": "0.20.0",
+  "stacks": {
+    "network": {"name": "network", "workingDirectory": "stacks/network", "synthesizedStackPath": "stacks/network/cdk.tf.json"},
+    "app": {"name": "app", "workingDirectory": "stacks/app", "synthesizedStackPath": "stacks/app/cdk.tf.json"}
+  }
+}`
+
+func TestGetTerraformOptionsE(t *testing.T) {
+	t.Parallel()
+
+	appDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(appDir, DefaultOutputDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, DefaultOutputDir, manifestFile), []byte(testManifest), 0644))
+
+	options := &Options{AppDir: appDir, EnvVars: map[string]string{"ENV": "test"}}
+
+	names, err := GetStackNamesE(t, options)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", "network"}, names)
+
+	terraformOptions, err := GetTerraformOptionsE(t, options, "network")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(appDir, "cdktf.out", "stacks", "network"), terraformOptions.TerraformDir)
+	assert.Equal(t, map[string]string{"ENV": "test"}, terraformOptions.EnvVars)
+
+	_, err = GetTerraformOptionsE(t, options, "missing")
+	assert.Equal(t, StackNotFound{Stack: "missing", OutputDir: filepath.Join(appDir, "cdktf.out")}, err)
+}
+
+func TestOutputDir(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, filepath.Join("app", "cdktf.out"), outputDir(&Options{AppDir: "app"}))
+	assert.Equal(t, filepath.Join("app", "out"), outputDir(&Options{AppDir: "app", OutputDir: "out"}))
+	assert.Equal(t, "/tmp/out", outputDir(&Options{AppDir: "app", OutputDir: "/tmp/out"}))
+}
@@ -0,0 +1,13 @@
+package cdktf
+
+import "fmt"
+
+// StackNotFound is returned when the app doesn't have a stack with the given name.
+type StackNotFound struct {
+	Stack     string
+	OutputDir string
+}
+
+func (err StackNotFound) Error() string {
+	return fmt.Sprintf("stack %q not found in the cdktf manifest in %s", err.Stack, err.OutputDir)
+}
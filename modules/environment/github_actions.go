@@ -0,0 +1,64 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const (
+	// GitHubActionsIDTokenRequestURLEnvName and GitHubActionsIDTokenRequestTokenEnvName are set by GitHub Actions in
+	// jobs that have the id-token: write permission
+	GitHubActionsIDTokenRequestURLEnvName   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	GitHubActionsIDTokenRequestTokenEnvName = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+)
+
+// IsGitHubActionsIDTokenAvailable returns true if an OIDC token can be requested from GitHub Actions, i.e., if the test
+// runs in a GitHub Actions job that has the id-token: write permission.
+func IsGitHubActionsIDTokenAvailable() bool {
+	return os.Getenv(GitHubActionsIDTokenRequestURLEnvName) != ""
+}
+
+// GetGitHubActionsIDTokenE requests an OIDC token with the given audience from GitHub Actions, e.g., to exchange it for
+// cloud credentials with workload identity federation. The tokens of GitHub Actions expire after a few minutes, so
+// request a new one each time one is needed rather than caching it.
+func GetGitHubActionsIDTokenE(ctx context.Context, audience string) (string, error) {
+	if !IsGitHubActionsIDTokenAvailable() {
+		return "", fmt.Errorf("%s is not set: OIDC tokens can only be requested from GitHub Actions jobs that have the id-token: write permission", GitHubActionsIDTokenRequestURLEnvName)
+	}
+
+	requestURL, err := url.Parse(os.Getenv(GitHubActionsIDTokenRequestURLEnvName))
+	if err != nil {
+		return "", err
+	}
+	query := requestURL.Query()
+	query.Set("audience", audience)
+	requestURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv(GitHubActionsIDTokenRequestTokenEnvName))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request an OIDC token from GitHub Actions: %s", resp.Status)
+	}
+
+	var token struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.Value, nil
+}
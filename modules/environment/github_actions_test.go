@@ -0,0 +1,36 @@
+package environment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGitHubActionsIDToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "api://AzureADTokenExchange", r.URL.Query().Get("audience"))
+		assert.Equal(t, "1", r.URL.Query().Get("api-version"))
+		w.Write([]byte(`{"value": "id-token"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(GitHubActionsIDTokenRequestURLEnvName, server.URL+"?api-version=1")
+	t.Setenv(GitHubActionsIDTokenRequestTokenEnvName, "request-token")
+
+	token, err := GetGitHubActionsIDTokenE(context.Background(), "api://AzureADTokenExchange")
+	require.NoError(t, err)
+	assert.Equal(t, "id-token", token)
+}
+
+func TestGetGitHubActionsIDTokenNotAvailable(t *testing.T) {
+	t.Setenv(GitHubActionsIDTokenRequestURLEnvName, "")
+
+	assert.False(t, IsGitHubActionsIDTokenAvailable())
+	_, err := GetGitHubActionsIDTokenE(context.Background(), "api://AzureADTokenExchange")
+	assert.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/google/externalaccount"
+	"google.golang.org/api/idtoken"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+
+	"github.com/gruntwork-io/terratest/modules/environment"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	// WorkloadIdentityProviderEnvName is an env variable custom to Terratest through which the full resource name of a
+	// Workload Identity Federation provider may be passed
+	WorkloadIdentityProviderEnvName = "TERRATEST_GCP_WORKLOAD_IDENTITY_PROVIDER"
+
+	// OIDCTokenFileEnvName is an env variable custom to Terratest through which the path of a file holding the OIDC
+	// token to exchange with the Workload Identity Federation provider may be passed
+	OIDCTokenFileEnvName = "TERRATEST_GCP_OIDC_TOKEN_FILE"
+
+	// ImpersonateServiceAccountEnvName is an env variable supported by the Google Terraform provider, through which
+	// the email of a service account to impersonate may be passed
+	ImpersonateServiceAccountEnvName = "GOOGLE_IMPERSONATE_SERVICE_ACCOUNT"
+
+	// cloudPlatformScope is the OAuth2 scope that grants access to all GCP APIs the IAM roles of the identity allow
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// stsTokenURL is the Security Token Service endpoint that exchanges OIDC tokens for federated access tokens
+	stsTokenURL = "https://sts.googleapis.com/v1/token"
+
+	// jwtTokenType is the token type of OIDC tokens in Security Token Service requests
+	jwtTokenType = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// AuthOptions are the options to authenticate to GCP with. The zero value uses GOOGLE_OAUTH_ACCESS_TOKEN if it is set,
+// or the application default credentials otherwise.
+type AuthOptions struct {
+	// The path of a credentials file to authenticate with: a service account key, or an external account configuration
+	// for Workload Identity Federation, as created by `gcloud iam workload-identity-pools create-cred-config`. Only one
+	// of CredentialsFile and WorkloadIdentityProvider may be set.
+	CredentialsFile string
+
+	// The full resource name of a Workload Identity Federation provider, formatted like
+	// projects/<project-number>/locations/global/workloadIdentityPools/<pool>/providers/<provider>. When set, an OIDC
+	// token of the CI system is exchanged for a federated access token, without a credentials file.
+	WorkloadIdentityProvider string
+
+	// The path of the file holding the OIDC token to exchange with WorkloadIdentityProvider. If it is not set, the
+	// token is requested from GitHub Actions, which requires the id-token: write permission on the job.
+	OIDCTokenFile string
+
+	// The email of a service account to impersonate with the credentials above. The authenticated identity needs the
+	// Service Account Token Creator role on it.
+	ImpersonateServiceAccount string
+
+	// The chain of service accounts through which ImpersonateServiceAccount is impersonated, if any. Each of them
+	// needs the Service Account Token Creator role on the next one.
+	Delegates []string
+}
+
+// NewTokenSource returns a token source for the credentials the helpers of this module authenticate with. This will
+// fail the test if there is an error.
+func NewTokenSource(t testing.TestingT) oauth2.TokenSource {
+	ts, err := NewTokenSourceE(t)
+	require.NoError(t, err)
+	return ts
+}
+
+// NewTokenSourceE returns a token source for the credentials the helpers of this module authenticate with: the
+// AuthOptions set in the TERRATEST_GCP_WORKLOAD_IDENTITY_PROVIDER, TERRATEST_GCP_OIDC_TOKEN_FILE and
+// GOOGLE_IMPERSONATE_SERVICE_ACCOUNT environment variables, on top of GOOGLE_OAUTH_ACCESS_TOKEN or the application
+// default credentials. Note that the application default credentials already support Workload Identity Federation,
+// when GOOGLE_APPLICATION_CREDENTIALS points to an external account configuration.
+func NewTokenSourceE(t testing.TestingT) (oauth2.TokenSource, error) {
+	return NewTokenSourceWithOptionsE(authOptionsFromEnv())
+}
+
+// NewTokenSourceWithOptionsE returns a token source for the credentials configured by the given AuthOptions.
+func NewTokenSourceWithOptionsE(authOptions AuthOptions) (oauth2.TokenSource, error) {
+	ctx := context.Background()
+
+	ts, err := newBaseTokenSourceE(ctx, authOptions)
+	if err != nil {
+		return nil, err
+	}
+	if authOptions.ImpersonateServiceAccount == "" {
+		return ts, nil
+	}
+
+	return impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: authOptions.ImpersonateServiceAccount,
+		Delegates:       authOptions.Delegates,
+		Scopes:          []string{cloudPlatformScope},
+	}, option.WithTokenSource(ts))
+}
+
+// NewClientOptionsWithAuthE returns the options to pass to the constructor of a Cloud Client Library client (e.g.,
+// compute.NewInstancesRESTClient), so that it authenticates according to the given AuthOptions. Use this to pick the
+// credentials per test rather than through env variables.
+func NewClientOptionsWithAuthE(authOptions AuthOptions) ([]option.ClientOption, error) {
+	ts, err := NewTokenSourceWithOptionsE(authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+// NewIDTokenSource returns a token source of OIDC identity tokens with the given audience (e.g., the URL of a Cloud Run
+// service) for the credentials the helpers of this module authenticate with. This will fail the test if there is an
+// error.
+func NewIDTokenSource(t testing.TestingT, audience string) oauth2.TokenSource {
+	ts, err := NewIDTokenSourceE(t, audience)
+	require.NoError(t, err)
+	return ts
+}
+
+// NewIDTokenSourceE returns a token source of OIDC identity tokens with the given audience (e.g., the URL of a Cloud
+// Run service). If GOOGLE_IMPERSONATE_SERVICE_ACCOUNT is set, the tokens are issued for that service account through
+// the IAM Credentials API. Otherwise, they are issued for the application default credentials, which must be a service
+// account key or the metadata server, as Google does not issue identity tokens with custom audiences to user accounts.
+func NewIDTokenSourceE(t testing.TestingT, audience string) (oauth2.TokenSource, error) {
+	ctx := context.Background()
+
+	authOptions := authOptionsFromEnv()
+	if authOptions.ImpersonateServiceAccount == "" {
+		return idtoken.NewTokenSource(ctx, audience)
+	}
+
+	ts, err := newBaseTokenSourceE(ctx, authOptions)
+	if err != nil {
+		return nil, err
+	}
+	return impersonate.IDTokenSource(ctx, impersonate.IDTokenConfig{
+		Audience:        audience,
+		TargetPrincipal: authOptions.ImpersonateServiceAccount,
+		Delegates:       authOptions.Delegates,
+		IncludeEmail:    true,
+	}, option.WithTokenSource(ts))
+}
+
+// newBaseTokenSourceE returns a token source for the credentials of the given AuthOptions, before impersonation.
+func newBaseTokenSourceE(ctx context.Context, authOptions AuthOptions) (oauth2.TokenSource, error) {
+	switch {
+	case authOptions.CredentialsFile != "" && authOptions.WorkloadIdentityProvider != "":
+		return nil, fmt.Errorf("only one of CredentialsFile and WorkloadIdentityProvider may be set to authenticate to GCP")
+
+	case authOptions.CredentialsFile != "":
+		data, err := os.ReadFile(authOptions.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GCP credentials file %s: %v", authOptions.CredentialsFile, err)
+		}
+		creds, err := google.CredentialsFromJSON(ctx, data, cloudPlatformScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GCP credentials file %s: %v", authOptions.CredentialsFile, err)
+		}
+		return creds.TokenSource, nil
+
+	case authOptions.WorkloadIdentityProvider != "":
+		return newWorkloadIdentityTokenSourceE(ctx, authOptions.WorkloadIdentityProvider, authOptions.OIDCTokenFile)
+	}
+
+	if ts, ok := getStaticTokenSource(); ok {
+		return ts, nil
+	}
+	return google.DefaultTokenSource(ctx, cloudPlatformScope)
+}
+
+// newWorkloadIdentityTokenSourceE returns a token source that exchanges the OIDC token in the given file, or the OIDC
+// token of the GitHub Actions job if no file is given, for a federated access token of the given provider.
+func newWorkloadIdentityTokenSourceE(ctx context.Context, provider string, tokenFile string) (oauth2.TokenSource, error) {
+	config := externalaccount.Config{
+		Audience:         "//iam.googleapis.com/" + provider,
+		SubjectTokenType: jwtTokenType,
+		TokenURL:         stsTokenURL,
+		Scopes:           []string{cloudPlatformScope},
+	}
+
+	switch {
+	case tokenFile != "":
+		config.CredentialSource = &externalaccount.CredentialSource{File: tokenFile}
+	case environment.IsGitHubActionsIDTokenAvailable():
+		// This is the audience google-github-actions/auth requests tokens for, which providers accept by default
+		config.SubjectTokenSupplier = gitHubActionsTokenSupplier{audience: "https://iam.googleapis.com/" + provider}
+	default:
+		return nil, fmt.Errorf("no OIDC token file was set in OIDCTokenFile or %s, and %s is not set to request an OIDC token from GitHub Actions", OIDCTokenFileEnvName, environment.GitHubActionsIDTokenRequestURLEnvName)
+	}
+
+	return externalaccount.NewTokenSource(ctx, config)
+}
+
+// gitHubActionsTokenSupplier requests OIDC tokens with the given audience from GitHub Actions. It is called each time
+// a new federated access token is needed, since the OIDC tokens of GitHub Actions expire after a few minutes.
+type gitHubActionsTokenSupplier struct {
+	audience string
+}
+
+// SubjectToken implements externalaccount.SubjectTokenSupplier.
+func (s gitHubActionsTokenSupplier) SubjectToken(ctx context.Context, options externalaccount.SupplierOptions) (string, error) {
+	return environment.GetGitHubActionsIDTokenE(ctx, s.audience)
+}
+
+// authOptionsFromEnv returns the AuthOptions set in the TERRATEST_GCP_WORKLOAD_IDENTITY_PROVIDER,
+// TERRATEST_GCP_OIDC_TOKEN_FILE and GOOGLE_IMPERSONATE_SERVICE_ACCOUNT environment variables.
+func authOptionsFromEnv() AuthOptions {
+	return AuthOptions{
+		WorkloadIdentityProvider:  os.Getenv(WorkloadIdentityProviderEnvName),
+		OIDCTokenFile:             os.Getenv(OIDCTokenFileEnvName),
+		ImpersonateServiceAccount: os.Getenv(ImpersonateServiceAccountEnvName),
+	}
+}
+
+// getConfiguredTokenSourceE returns the token source the helpers of this module authenticate with, and false if they
+// should leave it to the client libraries to find the application default credentials.
+func getConfiguredTokenSourceE() (oauth2.TokenSource, bool, error) {
+	authOptions := authOptionsFromEnv()
+	if _, ok := getStaticTokenSource(); !ok && authOptions.WorkloadIdentityProvider == "" && authOptions.ImpersonateServiceAccount == "" {
+		return nil, false, nil
+	}
+
+	ts, err := NewTokenSourceWithOptionsE(authOptions)
+	if err != nil {
+		return nil, false, err
+	}
+	return ts, true, nil
+}
@@ -0,0 +1,99 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/google/externalaccount"
+
+	"github.com/gruntwork-io/terratest/modules/environment"
+)
+
+const testWorkloadIdentityProvider = "projects/123456789/locations/global/workloadIdentityPools/ci/providers/github"
+
+func TestNewTokenSourceWithOptionsRejectsConflictingCredentials(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewTokenSourceWithOptionsE(AuthOptions{CredentialsFile: "creds.json", WorkloadIdentityProvider: testWorkloadIdentityProvider})
+	require.Error(t, err)
+}
+
+func TestNewTokenSourceWithOptionsFromExternalAccountFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "external-account.json")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte(`{
+		"type": "external_account",
+		"audience": "//iam.googleapis.com/`+testWorkloadIdentityProvider+`",
+		"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"token_url": "https://sts.googleapis.com/v1/token",
+		"credential_source": {"file": "`+filepath.Join(dir, "oidc-token")+`"}
+	}`), 0o600))
+
+	ts, err := NewTokenSourceWithOptionsE(AuthOptions{CredentialsFile: credentialsFile})
+	require.NoError(t, err)
+	assert.NotNil(t, ts)
+
+	_, err = NewTokenSourceWithOptionsE(AuthOptions{CredentialsFile: filepath.Join(dir, "missing.json")})
+	require.Error(t, err)
+}
+
+func TestNewWorkloadIdentityTokenSource(t *testing.T) {
+	t.Setenv(environment.GitHubActionsIDTokenRequestURLEnvName, "")
+
+	_, err := newWorkloadIdentityTokenSourceE(context.Background(), testWorkloadIdentityProvider, "")
+	require.Error(t, err)
+
+	ts, err := newWorkloadIdentityTokenSourceE(context.Background(), testWorkloadIdentityProvider, filepath.Join(t.TempDir(), "oidc-token"))
+	require.NoError(t, err)
+	assert.NotNil(t, ts)
+}
+
+func TestGitHubActionsTokenSupplier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "https://iam.googleapis.com/"+testWorkloadIdentityProvider, r.URL.Query().Get("audience"))
+		_, _ = w.Write([]byte(`{"value": "oidc-token"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(environment.GitHubActionsIDTokenRequestURLEnvName, server.URL+"?api-version=2.0")
+	t.Setenv(environment.GitHubActionsIDTokenRequestTokenEnvName, "request-token")
+
+	supplier := gitHubActionsTokenSupplier{audience: "https://iam.googleapis.com/" + testWorkloadIdentityProvider}
+	token, err := supplier.SubjectToken(context.Background(), externalaccount.SupplierOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "oidc-token", token)
+}
+
+func TestGetConfiguredTokenSourceDefersToApplicationDefaultCredentials(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	t.Setenv(WorkloadIdentityProviderEnvName, "")
+	t.Setenv(ImpersonateServiceAccountEnvName, "")
+
+	_, ok, err := getConfiguredTokenSourceE()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "access-token")
+
+	ts, ok, err := getConfiguredTokenSourceE()
+	require.NoError(t, err)
+	require.True(t, ok)
+	token, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", token.AccessToken)
+}
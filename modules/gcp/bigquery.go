@@ -0,0 +1,356 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// The basic roles BigQuery reports in dataset access entries instead of the equivalent predefined IAM roles.
+var bigQueryBasicRoles = map[string]string{
+	"roles/bigquery.dataOwner":  string(bigquery.OwnerRole),
+	"roles/bigquery.dataEditor": string(bigquery.WriterRole),
+	"roles/bigquery.dataViewer": string(bigquery.ReaderRole),
+}
+
+// RunBigQueryQuery runs the given GoogleSQL query in the given project and returns its rows, each scanned into a T,
+// which can be a struct (whose fields are matched to columns by name, or by their `bigquery` tag), a
+// map[string]bigquery.Value, or a []bigquery.Value. This will fail the test if there is an error.
+func RunBigQueryQuery[T any](t testing.TestingT, projectID string, query string, params ...bigquery.QueryParameter) []T {
+	rows, err := RunBigQueryQueryE[T](t, projectID, query, params...)
+	require.NoError(t, err)
+	return rows
+}
+
+// RunBigQueryQueryE runs the given GoogleSQL query in the given project and returns its rows, each scanned into a T,
+// which can be a struct (whose fields are matched to columns by name, or by their `bigquery` tag), a
+// map[string]bigquery.Value, or a []bigquery.Value. Named parameters are referenced like @name in the query.
+func RunBigQueryQueryE[T any](t testing.TestingT, projectID string, query string, params ...bigquery.QueryParameter) ([]T, error) {
+	logger.Default.Logf(t, "Running BigQuery query in project %s: %s", projectID, query)
+
+	ctx := context.Background()
+	client, err := NewBigQueryClientE(t, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	q := client.Query(query)
+	q.Parameters = params
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []T{}
+	for {
+		var row T
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// GetBigQueryDatasetMetadata returns the metadata of the given BigQuery dataset, e.g. its location, labels and access
+// entries. This will fail the test if there is an error.
+func GetBigQueryDatasetMetadata(t testing.TestingT, projectID string, datasetID string) *bigquery.DatasetMetadata {
+	metadata, err := GetBigQueryDatasetMetadataE(t, projectID, datasetID)
+	require.NoError(t, err)
+	return metadata
+}
+
+// GetBigQueryDatasetMetadataE returns the metadata of the given BigQuery dataset, e.g. its location, labels and access
+// entries.
+func GetBigQueryDatasetMetadataE(t testing.TestingT, projectID string, datasetID string) (*bigquery.DatasetMetadata, error) {
+	logger.Default.Logf(t, "Getting metadata of BigQuery dataset %s in project %s", datasetID, projectID)
+
+	client, err := NewBigQueryClientE(t, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.Dataset(datasetID).Metadata(context.Background())
+}
+
+// GetBigQueryTableMetadata returns the metadata of the given BigQuery table, e.g. its schema, partitioning and
+// clustering. This will fail the test if there is an error.
+func GetBigQueryTableMetadata(t testing.TestingT, projectID string, datasetID string, tableID string) *bigquery.TableMetadata {
+	metadata, err := GetBigQueryTableMetadataE(t, projectID, datasetID, tableID)
+	require.NoError(t, err)
+	return metadata
+}
+
+// GetBigQueryTableMetadataE returns the metadata of the given BigQuery table, e.g. its schema, partitioning and
+// clustering.
+func GetBigQueryTableMetadataE(t testing.TestingT, projectID string, datasetID string, tableID string) (*bigquery.TableMetadata, error) {
+	logger.Default.Logf(t, "Getting metadata of BigQuery table %s.%s in project %s", datasetID, tableID, projectID)
+
+	client, err := NewBigQueryClientE(t, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.Dataset(datasetID).Table(tableID).Metadata(context.Background())
+}
+
+// AssertBigQueryDatasetTables checks that the given BigQuery dataset contains the given tables (and views), and fails
+// the test if it does not.
+func AssertBigQueryDatasetTables(t testing.TestingT, projectID string, datasetID string, expectedTableIDs []string) {
+	err := AssertBigQueryDatasetTablesE(t, projectID, datasetID, expectedTableIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertBigQueryDatasetTablesE checks that the given BigQuery dataset contains the given tables (and views), and
+// returns an error listing the missing ones if it does not. Other tables in the dataset are ignored.
+func AssertBigQueryDatasetTablesE(t testing.TestingT, projectID string, datasetID string, expectedTableIDs []string) error {
+	logger.Default.Logf(t, "Listing tables of BigQuery dataset %s in project %s", datasetID, projectID)
+
+	ctx := context.Background()
+	client, err := NewBigQueryClientE(t, projectID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	tableIDs := map[string]bool{}
+	it := client.Dataset(datasetID).Tables(ctx)
+	for {
+		table, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		tableIDs[table.TableID] = true
+	}
+
+	missing := []string{}
+	for _, tableID := range expectedTableIDs {
+		if !tableIDs[tableID] {
+			missing = append(missing, tableID)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("BigQuery dataset %s does not contain tables %s", datasetID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// AssertBigQueryTableSchema checks that the given BigQuery table has exactly the given columns, with the same names,
+// types and modes, in the same order, and fails the test if it does not.
+func AssertBigQueryTableSchema(t testing.TestingT, projectID string, datasetID string, tableID string, expectedSchema bigquery.Schema) {
+	err := AssertBigQueryTableSchemaE(t, projectID, datasetID, tableID, expectedSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertBigQueryTableSchemaE checks that the given BigQuery table has exactly the given columns, with the same names,
+// types and modes (REQUIRED or REPEATED), in the same order, including the columns nested in RECORD columns, and
+// returns an error listing the differences if it does not. Descriptions, policy tags and defaults are ignored.
+func AssertBigQueryTableSchemaE(t testing.TestingT, projectID string, datasetID string, tableID string, expectedSchema bigquery.Schema) error {
+	metadata, err := GetBigQueryTableMetadataE(t, projectID, datasetID, tableID)
+	if err != nil {
+		return err
+	}
+
+	if diffs := diffBigQuerySchemas("", expectedSchema, metadata.Schema); len(diffs) > 0 {
+		return fmt.Errorf("BigQuery table %s.%s has an unexpected schema: %s", datasetID, tableID, strings.Join(diffs, "; "))
+	}
+	return nil
+}
+
+// AssertBigQueryTablePartitioning checks that the given BigQuery table is partitioned by time on the given column
+// (empty for ingestion time partitioning), with the given granularity (e.g. bigquery.DayPartitioningType), and fails
+// the test if it is not.
+func AssertBigQueryTablePartitioning(t testing.TestingT, projectID string, datasetID string, tableID string, expectedType bigquery.TimePartitioningType, expectedField string) {
+	err := AssertBigQueryTablePartitioningE(t, projectID, datasetID, tableID, expectedType, expectedField)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertBigQueryTablePartitioningE checks that the given BigQuery table is partitioned by time on the given column
+// (empty for ingestion time partitioning), with the given granularity, and returns an error if it is not.
+func AssertBigQueryTablePartitioningE(t testing.TestingT, projectID string, datasetID string, tableID string, expectedType bigquery.TimePartitioningType, expectedField string) error {
+	metadata, err := GetBigQueryTableMetadataE(t, projectID, datasetID, tableID)
+	if err != nil {
+		return err
+	}
+
+	partitioning := metadata.TimePartitioning
+	switch {
+	case partitioning == nil:
+		return fmt.Errorf("BigQuery table %s.%s is not partitioned by time", datasetID, tableID)
+	case partitioning.Type != expectedType || partitioning.Field != expectedField:
+		return fmt.Errorf("BigQuery table %s.%s is partitioned by %s on %q, expected %s on %q", datasetID, tableID, partitioning.Type, partitioning.Field, expectedType, expectedField)
+	}
+	return nil
+}
+
+// AssertBigQueryTableClustering checks that the given BigQuery table is clustered on exactly the given columns, in
+// order, and fails the test if it is not.
+func AssertBigQueryTableClustering(t testing.TestingT, projectID string, datasetID string, tableID string, expectedFields []string) {
+	err := AssertBigQueryTableClusteringE(t, projectID, datasetID, tableID, expectedFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertBigQueryTableClusteringE checks that the given BigQuery table is clustered on exactly the given columns, in
+// order, and returns an error if it is not.
+func AssertBigQueryTableClusteringE(t testing.TestingT, projectID string, datasetID string, tableID string, expectedFields []string) error {
+	metadata, err := GetBigQueryTableMetadataE(t, projectID, datasetID, tableID)
+	if err != nil {
+		return err
+	}
+
+	var fields []string
+	if metadata.Clustering != nil {
+		fields = metadata.Clustering.Fields
+	}
+	if strings.Join(fields, ",") != strings.Join(expectedFields, ",") {
+		return fmt.Errorf("BigQuery table %s.%s is clustered on %v, expected %v", datasetID, tableID, fields, expectedFields)
+	}
+	return nil
+}
+
+// AssertBigQueryDatasetIAMMember checks that the given member (e.g. "serviceAccount:etl@my-project.iam.gserviceaccount.com"
+// or "group:analysts@example.com") is granted the given role (e.g. "roles/bigquery.dataViewer") on the given BigQuery
+// dataset, and fails the test if it is not.
+func AssertBigQueryDatasetIAMMember(t testing.TestingT, projectID string, datasetID string, role string, member string) {
+	err := AssertBigQueryDatasetIAMMemberE(t, projectID, datasetID, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertBigQueryDatasetIAMMemberE checks that the given member is granted the given role on the given BigQuery
+// dataset, and returns an error if it is not. Since BigQuery reports the dataOwner, dataEditor and dataViewer roles
+// as the OWNER, WRITER and READER basic roles in the access entries of datasets, either form may be given.
+func AssertBigQueryDatasetIAMMemberE(t testing.TestingT, projectID string, datasetID string, role string, member string) error {
+	metadata, err := GetBigQueryDatasetMetadataE(t, projectID, datasetID)
+	if err != nil {
+		return err
+	}
+
+	if !hasBigQueryAccessEntry(metadata.Access, role, member) {
+		return fmt.Errorf("BigQuery dataset %s does not grant role %s to %s", datasetID, role, member)
+	}
+	return nil
+}
+
+// NewBigQueryClient creates a new BigQuery client for the given project, which is used to run queries and make
+// BigQuery API calls. The caller is responsible for closing the client.
+func NewBigQueryClient(t testing.TestingT, projectID string) *bigquery.Client {
+	client, err := NewBigQueryClientE(t, projectID)
+	require.NoError(t, err)
+	return client
+}
+
+// NewBigQueryClientE creates a new BigQuery client for the given project, which is used to run queries and make
+// BigQuery API calls. Queries run, and are billed, in that project. The caller is responsible for closing the client.
+func NewBigQueryClientE(t testing.TestingT, projectID string) (*bigquery.Client, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return bigquery.NewClient(context.Background(), projectID, opts...)
+}
+
+// diffBigQuerySchemas returns the differences between the names, types and modes of the columns of the given schemas,
+// recursing into RECORD columns, whose names are prefixed with the given path.
+func diffBigQuerySchemas(path string, expected bigquery.Schema, actual bigquery.Schema) []string {
+	diffs := []string{}
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		switch {
+		case i >= len(actual):
+			diffs = append(diffs, fmt.Sprintf("missing column %s%s", path, expected[i].Name))
+			continue
+		case i >= len(expected):
+			diffs = append(diffs, fmt.Sprintf("unexpected column %s%s", path, actual[i].Name))
+			continue
+		}
+
+		e, a := expected[i], actual[i]
+		name := path + e.Name
+		if !strings.EqualFold(e.Name, a.Name) {
+			diffs = append(diffs, fmt.Sprintf("column %d is %s%s, expected %s", i, path, a.Name, name))
+			continue
+		}
+		if e.Type != a.Type {
+			diffs = append(diffs, fmt.Sprintf("column %s is %s, expected %s", name, a.Type, e.Type))
+		}
+		if e.Required != a.Required || e.Repeated != a.Repeated {
+			diffs = append(diffs, fmt.Sprintf("column %s is %s, expected %s", name, bigQueryFieldMode(a), bigQueryFieldMode(e)))
+		}
+		if e.Type == bigquery.RecordFieldType && a.Type == bigquery.RecordFieldType {
+			diffs = append(diffs, diffBigQuerySchemas(name+".", e.Schema, a.Schema)...)
+		}
+	}
+	return diffs
+}
+
+// bigQueryFieldMode returns the mode of the given column, as displayed by the BigQuery console.
+func bigQueryFieldMode(field *bigquery.FieldSchema) string {
+	switch {
+	case field.Repeated:
+		return "REPEATED"
+	case field.Required:
+		return "REQUIRED"
+	default:
+		return "NULLABLE"
+	}
+}
+
+// hasBigQueryAccessEntry returns true if the given dataset access entries grant the given role to the given member,
+// formatted like IAM members (e.g. "user:jane@example.com", "specialGroup:projectReaders" or "allUsers").
+func hasBigQueryAccessEntry(entries []*bigquery.AccessEntry, role string, member string) bool {
+	if basicRole, ok := bigQueryBasicRoles[role]; ok {
+		role = basicRole
+	}
+
+	entityType, entity := bigquery.IAMMemberEntity, member
+	if prefix, email, ok := strings.Cut(member, ":"); ok {
+		switch prefix {
+		case "user", "serviceAccount":
+			entityType, entity = bigquery.UserEmailEntity, email
+		case "group":
+			entityType, entity = bigquery.GroupEmailEntity, email
+		case "domain":
+			entityType, entity = bigquery.DomainEntity, email
+		case "specialGroup":
+			entityType, entity = bigquery.SpecialGroupEntity, email
+		}
+	}
+
+	for _, entry := range entries {
+		entryRole := string(entry.Role)
+		if basicRole, ok := bigQueryBasicRoles[entryRole]; ok {
+			entryRole = basicRole
+		}
+		if entryRole == role && entry.EntityType == entityType && strings.EqualFold(entry.Entity, entity) {
+			return true
+		}
+	}
+	return false
+}
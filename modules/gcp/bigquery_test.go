@@ -0,0 +1,60 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffBigQuerySchemas(t *testing.T) {
+	t.Parallel()
+
+	expected := bigquery.Schema{
+		{Name: "id", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "city", Type: bigquery.StringFieldType},
+		}},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+	}
+
+	assert.Empty(t, diffBigQuerySchemas("", expected, expected))
+
+	actual := bigquery.Schema{
+		{Name: "id", Type: bigquery.StringFieldType},
+		{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "city", Type: bigquery.StringFieldType},
+			{Name: "zip", Type: bigquery.StringFieldType},
+		}},
+	}
+	assert.Equal(t, []string{
+		"column id is STRING, expected INTEGER",
+		"column id is NULLABLE, expected REQUIRED",
+		"unexpected column address.zip",
+		"missing column tags",
+	}, diffBigQuerySchemas("", expected, actual))
+}
+
+func TestHasBigQueryAccessEntry(t *testing.T) {
+	t.Parallel()
+
+	entries := []*bigquery.AccessEntry{
+		{Role: bigquery.ReaderRole, EntityType: bigquery.UserEmailEntity, Entity: "etl@my-project.iam.gserviceaccount.com"},
+		{Role: bigquery.OwnerRole, EntityType: bigquery.SpecialGroupEntity, Entity: "projectOwners"},
+		{Role: "roles/bigquery.jobUser", EntityType: bigquery.GroupEmailEntity, Entity: "analysts@example.com"},
+		{Role: bigquery.ReaderRole, EntityType: bigquery.IAMMemberEntity, Entity: "allAuthenticatedUsers"},
+	}
+
+	assert.True(t, hasBigQueryAccessEntry(entries, "roles/bigquery.dataViewer", "serviceAccount:etl@my-project.iam.gserviceaccount.com"))
+	assert.True(t, hasBigQueryAccessEntry(entries, "READER", "serviceAccount:etl@my-project.iam.gserviceaccount.com"))
+	assert.True(t, hasBigQueryAccessEntry(entries, "roles/bigquery.dataOwner", "specialGroup:projectOwners"))
+	assert.True(t, hasBigQueryAccessEntry(entries, "roles/bigquery.jobUser", "group:analysts@example.com"))
+	assert.True(t, hasBigQueryAccessEntry(entries, "roles/bigquery.dataViewer", "allAuthenticatedUsers"))
+	assert.False(t, hasBigQueryAccessEntry(entries, "roles/bigquery.dataEditor", "serviceAccount:etl@my-project.iam.gserviceaccount.com"))
+	assert.False(t, hasBigQueryAccessEntry(entries, "roles/bigquery.dataViewer", "allUsers"))
+}
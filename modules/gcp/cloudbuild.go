@@ -147,7 +147,12 @@ func NewCloudBuildService(t testing.TestingT) *cloudbuild.Client {
 func NewCloudBuildServiceE(t testing.TestingT) (*cloudbuild.Client, error) {
 	ctx := context.Background()
 
-	service, err := cloudbuild.NewClient(ctx, withOptions()...)
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := cloudbuild.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,332 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	functions "cloud.google.com/go/functions/apiv2"
+	"cloud.google.com/go/functions/apiv2/functionspb"
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/logadmin"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// GetCloudFunction returns the (2nd gen) Cloud Function with the given name in the given region. This will fail the
+// test if there is an error.
+func GetCloudFunction(t testing.TestingT, projectID string, region string, functionName string) *functionspb.Function {
+	function, err := GetCloudFunctionE(t, projectID, region, functionName)
+	require.NoError(t, err)
+	return function
+}
+
+// GetCloudFunctionE returns the (2nd gen) Cloud Function with the given name in the given region.
+func GetCloudFunctionE(t testing.TestingT, projectID string, region string, functionName string) (*functionspb.Function, error) {
+	logger.Default.Logf(t, "Getting Cloud Function %s in region %s", functionName, region)
+
+	ctx := context.Background()
+	client, err := NewCloudFunctionsClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	req := &functionspb.GetFunctionRequest{
+		Name: fmt.Sprintf("projects/%s/locations/%s/functions/%s", projectID, region, functionName),
+	}
+	function, err := client.GetFunction(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("FunctionService.GetFunction(%s) got error: %v", req.Name, err)
+	}
+
+	return function, nil
+}
+
+// WaitForCloudFunctionActive waits until the given Cloud Function is deployed and active. This will fail the test if
+// the function is not active after the given number of retries.
+func WaitForCloudFunctionActive(t testing.TestingT, projectID string, region string, functionName string, retries int, sleepBetweenRetries time.Duration) {
+	err := WaitForCloudFunctionActiveE(t, projectID, region, functionName, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+}
+
+// WaitForCloudFunctionActiveE waits until the given Cloud Function is deployed and active.
+func WaitForCloudFunctionActiveE(t testing.TestingT, projectID string, region string, functionName string, retries int, sleepBetweenRetries time.Duration) error {
+	description := fmt.Sprintf("Waiting for Cloud Function %s to be active", functionName)
+	_, err := retry.DoWithRetryE(t, description, retries, sleepBetweenRetries, func() (string, error) {
+		function, err := GetCloudFunctionE(t, projectID, region, functionName)
+		if err != nil {
+			return "", err
+		}
+		if function.GetState() != functionspb.Function_ACTIVE {
+			return "", fmt.Errorf("Cloud Function %s is %s", functionName, function.GetState())
+		}
+		return "", nil
+	})
+	return err
+}
+
+// InvokeHTTPCloudFunction waits until the given HTTP triggered Cloud Function is active, then POSTs the given body to
+// it, retrying until it returns the expected status code, and returns the response body. This will fail the test if
+// there is an error.
+func InvokeHTTPCloudFunction(t testing.TestingT, projectID string, region string, functionName string, body []byte, expectedStatus int, retries int, sleepBetweenRetries time.Duration) string {
+	responseBody, err := InvokeHTTPCloudFunctionE(t, projectID, region, functionName, body, expectedStatus, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+	return responseBody
+}
+
+// InvokeHTTPCloudFunctionE waits until the given HTTP triggered Cloud Function is active, then POSTs the given body to
+// it, retrying until it returns the expected status code, and returns the response body. Like InvokeCloudRunServiceE,
+// the request carries an identity token for the URL of the function, which requires the identity of the test to have
+// the Cloud Run Invoker role on functions that do not allow unauthenticated invocations.
+func InvokeHTTPCloudFunctionE(t testing.TestingT, projectID string, region string, functionName string, body []byte, expectedStatus int, retries int, sleepBetweenRetries time.Duration) (string, error) {
+	if err := WaitForCloudFunctionActiveE(t, projectID, region, functionName, retries, sleepBetweenRetries); err != nil {
+		return "", err
+	}
+
+	function, err := GetCloudFunctionE(t, projectID, region, functionName)
+	if err != nil {
+		return "", err
+	}
+	functionURL := function.GetServiceConfig().GetUri()
+	if functionURL == "" {
+		return "", fmt.Errorf("Cloud Function %s has no URL", functionName)
+	}
+
+	ts, err := NewIDTokenSourceE(t, functionURL)
+	if err != nil {
+		return "", err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + token.AccessToken,
+		"Content-Type":  "application/json",
+	}
+	return http_helper.HTTPDoWithRetryE(t, http.MethodPost, functionURL, body, headers, expectedStatus, retries, sleepBetweenRetries, nil)
+}
+
+// TriggerCloudFunctionWithPubSub publishes the given message to the Pub/Sub topic that triggers the given Cloud
+// Function, and returns the ID Pub/Sub assigned to it. This will fail the test if there is an error.
+func TriggerCloudFunctionWithPubSub(t testing.TestingT, projectID string, region string, functionName string, message PubSubMessage) string {
+	id, err := TriggerCloudFunctionWithPubSubE(t, projectID, region, functionName, message)
+	require.NoError(t, err)
+	return id
+}
+
+// TriggerCloudFunctionWithPubSubE publishes the given message to the Pub/Sub topic that triggers the given Cloud
+// Function, and returns the ID Pub/Sub assigned to it. Use WaitForCloudFunctionLogEntryE to wait for the function to
+// process it.
+func TriggerCloudFunctionWithPubSubE(t testing.TestingT, projectID string, region string, functionName string, message PubSubMessage) (string, error) {
+	function, err := GetCloudFunctionE(t, projectID, region, functionName)
+	if err != nil {
+		return "", err
+	}
+
+	topicProjectID, topicID, err := parsePubSubTopicName(function.GetEventTrigger().GetPubsubTopic())
+	if err != nil {
+		return "", fmt.Errorf("Cloud Function %s is not triggered by a Pub/Sub topic: %v", functionName, err)
+	}
+
+	ids, err := PublishPubSubMessagesE(t, topicProjectID, topicID, []PubSubMessage{message})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// GetCloudFunctionLogEntries returns the Cloud Logging entries the given Cloud Function wrote since the given time
+// and that match the given additional filter (e.g., `severity>=ERROR`), if any, newest first. This will fail the test
+// if there is an error.
+func GetCloudFunctionLogEntries(t testing.TestingT, projectID string, region string, functionName string, since time.Time, filter string) []*logging.Entry {
+	entries, err := GetCloudFunctionLogEntriesE(t, projectID, region, functionName, since, filter)
+	require.NoError(t, err)
+	return entries
+}
+
+// GetCloudFunctionLogEntriesE returns the Cloud Logging entries the given Cloud Function wrote since the given time
+// and that match the given additional filter (e.g., `severity>=ERROR`), if any, newest first. This includes what the
+// function wrote to stdout and stderr, and the request logs of HTTP triggered functions.
+func GetCloudFunctionLogEntriesE(t testing.TestingT, projectID string, region string, functionName string, since time.Time, filter string) ([]*logging.Entry, error) {
+	logger.Default.Logf(t, "Getting log entries of Cloud Function %s since %s", functionName, since.Format(time.RFC3339))
+
+	ctx := context.Background()
+	client, err := NewLogAdminClientE(t, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	entries := []*logging.Entry{}
+	it := client.Entries(ctx, logadmin.Filter(cloudFunctionLogFilter(region, functionName, since, filter)), logadmin.NewestFirst())
+	for {
+		entry, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// WaitForCloudFunctionLogEntry waits until the given Cloud Function writes a log entry containing the given text,
+// e.g., a message the function logs once it has processed an event, since the given time, and returns it. This will
+// fail the test if no such entry is found after the given number of retries.
+func WaitForCloudFunctionLogEntry(t testing.TestingT, projectID string, region string, functionName string, since time.Time, text string, retries int, sleepBetweenRetries time.Duration) *logging.Entry {
+	entry, err := WaitForCloudFunctionLogEntryE(t, projectID, region, functionName, since, text, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+	return entry
+}
+
+// WaitForCloudFunctionLogEntryE waits until the given Cloud Function writes a log entry containing the given text
+// since the given time, and returns it. Log entries usually show up in Cloud Logging within a minute.
+func WaitForCloudFunctionLogEntryE(t testing.TestingT, projectID string, region string, functionName string, since time.Time, text string, retries int, sleepBetweenRetries time.Duration) (*logging.Entry, error) {
+	var found *logging.Entry
+
+	description := fmt.Sprintf("Waiting for Cloud Function %s to log %q", functionName, text)
+	_, err := retry.DoWithRetryE(t, description, retries, sleepBetweenRetries, func() (string, error) {
+		entries, err := GetCloudFunctionLogEntriesE(t, projectID, region, functionName, since, "")
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range entries {
+			if strings.Contains(GetLogEntryText(entry), text) {
+				found = entry
+				return "", nil
+			}
+		}
+		return "", fmt.Errorf("Cloud Function %s has not logged %q yet", functionName, text)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// AssertCloudFunctionNoErrorLogs checks that the given Cloud Function wrote no log entries with severity ERROR or
+// higher since the given time, and fails the test if it did.
+func AssertCloudFunctionNoErrorLogs(t testing.TestingT, projectID string, region string, functionName string, since time.Time) {
+	err := AssertCloudFunctionNoErrorLogsE(t, projectID, region, functionName, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertCloudFunctionNoErrorLogsE checks that the given Cloud Function wrote no log entries with severity ERROR or
+// higher since the given time, e.g., uncaught exceptions or crashes, and returns an error listing them if it did.
+func AssertCloudFunctionNoErrorLogsE(t testing.TestingT, projectID string, region string, functionName string, since time.Time) error {
+	entries, err := GetCloudFunctionLogEntriesE(t, projectID, region, functionName, since, "severity>=ERROR")
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, GetLogEntryText(entry))
+	}
+	return fmt.Errorf("Cloud Function %s logged %d errors: %s", functionName, len(entries), strings.Join(messages, "; "))
+}
+
+// GetLogEntryText returns the text of the given log entry: its text payload, the message field of its JSON payload
+// (which is where structured logs keep it), or its whole payload as JSON otherwise.
+func GetLogEntryText(entry *logging.Entry) string {
+	switch payload := entry.Payload.(type) {
+	case string:
+		return payload
+	case *structpb.Struct:
+		if message, ok := payload.GetFields()["message"]; ok {
+			return message.GetStringValue()
+		}
+		data, err := protojson.Marshal(payload)
+		if err != nil {
+			return payload.String()
+		}
+		return string(data)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(payload)
+	}
+}
+
+// NewCloudFunctionsClient creates a new Cloud Functions client, which is used to make Cloud Functions API calls. The
+// caller is responsible for closing the client.
+func NewCloudFunctionsClient(t testing.TestingT) *functions.FunctionClient {
+	client, err := NewCloudFunctionsClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewCloudFunctionsClientE creates a new Cloud Functions client, which is used to make Cloud Functions API calls. The
+// caller is responsible for closing the client.
+func NewCloudFunctionsClientE(t testing.TestingT) (*functions.FunctionClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return functions.NewFunctionClient(context.Background(), opts...)
+}
+
+// NewLogAdminClient creates a new Cloud Logging admin client for the given project, which is used to read log
+// entries. The caller is responsible for closing the client.
+func NewLogAdminClient(t testing.TestingT, projectID string) *logadmin.Client {
+	client, err := NewLogAdminClientE(t, projectID)
+	require.NoError(t, err)
+	return client
+}
+
+// NewLogAdminClientE creates a new Cloud Logging admin client for the given project, which is used to read log
+// entries. The caller is responsible for closing the client.
+func NewLogAdminClientE(t testing.TestingT, projectID string) (*logadmin.Client, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return logadmin.NewClient(context.Background(), projectID, opts...)
+}
+
+// cloudFunctionLogFilter returns the Cloud Logging filter for the log entries the given Cloud Function wrote since the
+// given time, which match the given additional filter, if any. 2nd gen functions log as the Cloud Run service that
+// backs them, which is named after the function in lower case, and 1st gen functions log as themselves.
+func cloudFunctionLogFilter(region string, functionName string, since time.Time, filter string) string {
+	resourceFilter := fmt.Sprintf(
+		`((resource.type="cloud_run_revision" AND resource.labels.service_name=%q AND resource.labels.location=%q) OR (resource.type="cloud_function" AND resource.labels.function_name=%q AND resource.labels.region=%q))`,
+		strings.ToLower(functionName), region, functionName, region,
+	)
+
+	logFilter := fmt.Sprintf(`%s AND timestamp>=%q`, resourceFilter, since.UTC().Format(time.RFC3339))
+	if filter != "" {
+		logFilter += " AND (" + filter + ")"
+	}
+	return logFilter
+}
+
+// parsePubSubTopicName returns the project and ID of the Pub/Sub topic with the given full name, formatted like
+// projects/<project>/topics/<topic>.
+func parsePubSubTopicName(name string) (string, string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != "projects" || parts[2] != "topics" {
+		return "", "", fmt.Errorf("invalid Pub/Sub topic name %q", name)
+	}
+	return parts[1], parts[3], nil
+}
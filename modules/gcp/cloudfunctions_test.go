@@ -0,0 +1,53 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCloudFunctionLogFilter(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t,
+		`((resource.type="cloud_run_revision" AND resource.labels.service_name="my-function" AND resource.labels.location="us-central1") OR (resource.type="cloud_function" AND resource.labels.function_name="My-Function" AND resource.labels.region="us-central1")) AND timestamp>="2024-11-01T12:00:00Z" AND (severity>=ERROR)`,
+		cloudFunctionLogFilter("us-central1", "My-Function", since, "severity>=ERROR"),
+	)
+}
+
+func TestGetLogEntryText(t *testing.T) {
+	t.Parallel()
+
+	structured, err := structpb.NewStruct(map[string]interface{}{"message": "processed event", "severity": "INFO"})
+	require.NoError(t, err)
+	other, err := structpb.NewStruct(map[string]interface{}{"count": 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", GetLogEntryText(&logging.Entry{Payload: "hello"}))
+	assert.Equal(t, "processed event", GetLogEntryText(&logging.Entry{Payload: structured}))
+	assert.JSONEq(t, `{"count": 1}`, GetLogEntryText(&logging.Entry{Payload: other}))
+	assert.Equal(t, "", GetLogEntryText(&logging.Entry{}))
+}
+
+func TestParsePubSubTopicName(t *testing.T) {
+	t.Parallel()
+
+	projectID, topicID, err := parsePubSubTopicName("projects/my-project/topics/my-topic")
+	require.NoError(t, err)
+	assert.Equal(t, "my-project", projectID)
+	assert.Equal(t, "my-topic", topicID)
+
+	_, _, err = parsePubSubTopicName("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,265 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	run "cloud.google.com/go/run/apiv2"
+	"cloud.google.com/go/run/apiv2/runpb"
+	"github.com/stretchr/testify/require"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// GetCloudRunService returns the Cloud Run service with the given name in the given region. This will fail the test if
+// there is an error.
+func GetCloudRunService(t testing.TestingT, projectID string, region string, serviceName string) *runpb.Service {
+	service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+	require.NoError(t, err)
+	return service
+}
+
+// GetCloudRunServiceE returns the Cloud Run service with the given name in the given region.
+func GetCloudRunServiceE(t testing.TestingT, projectID string, region string, serviceName string) (*runpb.Service, error) {
+	logger.Default.Logf(t, "Getting Cloud Run service %s in region %s", serviceName, region)
+
+	ctx := context.Background()
+	client, err := NewCloudRunServicesClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	req := &runpb.GetServiceRequest{
+		Name: fmt.Sprintf("projects/%s/locations/%s/services/%s", projectID, region, serviceName),
+	}
+	service, err := client.GetService(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Services.GetService(%s) got error: %v", req.Name, err)
+	}
+
+	return service, nil
+}
+
+// GetCloudRunServiceURL returns the URL the given Cloud Run service is served on, e.g.,
+// https://my-service-abcdefghij-uc.a.run.app. This will fail the test if there is an error.
+func GetCloudRunServiceURL(t testing.TestingT, projectID string, region string, serviceName string) string {
+	serviceURL, err := GetCloudRunServiceURLE(t, projectID, region, serviceName)
+	require.NoError(t, err)
+	return serviceURL
+}
+
+// GetCloudRunServiceURLE returns the URL the given Cloud Run service is served on, e.g.,
+// https://my-service-abcdefghij-uc.a.run.app.
+func GetCloudRunServiceURLE(t testing.TestingT, projectID string, region string, serviceName string) (string, error) {
+	service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+	if err != nil {
+		return "", err
+	}
+	if service.GetUri() == "" {
+		return "", fmt.Errorf("Cloud Run service %s has no URL yet", serviceName)
+	}
+
+	return service.GetUri(), nil
+}
+
+// GetCloudRunLatestRevision returns the name of the latest revision of the given Cloud Run service that is ready to
+// serve traffic. This will fail the test if there is an error.
+func GetCloudRunLatestRevision(t testing.TestingT, projectID string, region string, serviceName string) string {
+	revision, err := GetCloudRunLatestRevisionE(t, projectID, region, serviceName)
+	require.NoError(t, err)
+	return revision
+}
+
+// GetCloudRunLatestRevisionE returns the name of the latest revision of the given Cloud Run service that is ready to
+// serve traffic, e.g., my-service-00002-abc.
+func GetCloudRunLatestRevisionE(t testing.TestingT, projectID string, region string, serviceName string) (string, error) {
+	service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+	if err != nil {
+		return "", err
+	}
+	if service.GetLatestReadyRevision() == "" {
+		return "", fmt.Errorf("Cloud Run service %s has no ready revision yet", serviceName)
+	}
+
+	return cloudRunResourceID(service.GetLatestReadyRevision()), nil
+}
+
+// WaitForCloudRunServiceReady waits until the given Cloud Run service is ready, i.e., until its latest revision
+// serves all of its traffic. This will fail the test if the service is not ready after the given number of retries.
+func WaitForCloudRunServiceReady(t testing.TestingT, projectID string, region string, serviceName string, retries int, sleepBetweenRetries time.Duration) {
+	err := WaitForCloudRunServiceReadyE(t, projectID, region, serviceName, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+}
+
+// WaitForCloudRunServiceReadyE waits until the given Cloud Run service is ready, i.e., until its latest revision
+// serves all of its traffic.
+func WaitForCloudRunServiceReadyE(t testing.TestingT, projectID string, region string, serviceName string, retries int, sleepBetweenRetries time.Duration) error {
+	description := fmt.Sprintf("Waiting for Cloud Run service %s to be ready", serviceName)
+	_, err := retry.DoWithRetryE(t, description, retries, sleepBetweenRetries, func() (string, error) {
+		service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+		if err != nil {
+			return "", err
+		}
+		return "", cloudRunServiceReadyE(service)
+	})
+	return err
+}
+
+// InvokeCloudRunService waits until the given Cloud Run service is ready, then sends an HTTP request with the given
+// method and body to the given path of its URL, retrying until it returns the expected status code, and returns the
+// response body. This will fail the test if there is an error.
+func InvokeCloudRunService(t testing.TestingT, projectID string, region string, serviceName string, method string, path string, body []byte, expectedStatus int, retries int, sleepBetweenRetries time.Duration) string {
+	responseBody, err := InvokeCloudRunServiceE(t, projectID, region, serviceName, method, path, body, expectedStatus, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+	return responseBody
+}
+
+// InvokeCloudRunServiceE waits until the given Cloud Run service is ready, then sends an HTTP request with the given
+// method and body to the given path (e.g., /healthz) of its URL, retrying until it returns the expected status code,
+// and returns the response body. The request carries an identity token for the URL of the service (see
+// NewIDTokenSourceE), so that services which do not allow unauthenticated invocations accept it, as long as the
+// identity of the test has the Cloud Run Invoker role on them.
+func InvokeCloudRunServiceE(t testing.TestingT, projectID string, region string, serviceName string, method string, path string, body []byte, expectedStatus int, retries int, sleepBetweenRetries time.Duration) (string, error) {
+	if err := WaitForCloudRunServiceReadyE(t, projectID, region, serviceName, retries, sleepBetweenRetries); err != nil {
+		return "", err
+	}
+
+	serviceURL, err := GetCloudRunServiceURLE(t, projectID, region, serviceName)
+	if err != nil {
+		return "", err
+	}
+
+	ts, err := NewIDTokenSourceE(t, serviceURL)
+	if err != nil {
+		return "", err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + token.AccessToken}
+	requestURL := strings.TrimSuffix(serviceURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	return http_helper.HTTPDoWithRetryE(t, method, requestURL, body, headers, expectedStatus, retries, sleepBetweenRetries, nil)
+}
+
+// AssertCloudRunServiceScaling checks that the latest revision template of the given Cloud Run service scales between
+// the given minimum and maximum number of instances, and fails the test if it does not.
+func AssertCloudRunServiceScaling(t testing.TestingT, projectID string, region string, serviceName string, expectedMinInstances int32, expectedMaxInstances int32) {
+	err := AssertCloudRunServiceScalingE(t, projectID, region, serviceName, expectedMinInstances, expectedMaxInstances)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertCloudRunServiceScalingE checks that the latest revision template of the given Cloud Run service scales between
+// the given minimum and maximum number of instances, and returns an error if it does not.
+func AssertCloudRunServiceScalingE(t testing.TestingT, projectID string, region string, serviceName string, expectedMinInstances int32, expectedMaxInstances int32) error {
+	service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+	if err != nil {
+		return err
+	}
+
+	scaling := service.GetTemplate().GetScaling()
+	if scaling.GetMinInstanceCount() != expectedMinInstances || scaling.GetMaxInstanceCount() != expectedMaxInstances {
+		return fmt.Errorf("Cloud Run service %s scales between %d and %d instances, expected %d and %d", serviceName, scaling.GetMinInstanceCount(), scaling.GetMaxInstanceCount(), expectedMinInstances, expectedMaxInstances)
+	}
+	return nil
+}
+
+// AssertCloudRunServiceVpcConnector checks that the latest revision template of the given Cloud Run service sends its
+// egress traffic through the given Serverless VPC Access connector, formatted like
+// projects/<project>/locations/<region>/connectors/<connector>, with the given egress setting, and fails the test if
+// it does not.
+func AssertCloudRunServiceVpcConnector(t testing.TestingT, projectID string, region string, serviceName string, expectedConnector string, expectedEgress runpb.VpcAccess_VpcEgress) {
+	err := AssertCloudRunServiceVpcConnectorE(t, projectID, region, serviceName, expectedConnector, expectedEgress)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertCloudRunServiceVpcConnectorE checks that the latest revision template of the given Cloud Run service sends its
+// egress traffic through the given Serverless VPC Access connector with the given egress setting, and returns an error
+// if it does not.
+func AssertCloudRunServiceVpcConnectorE(t testing.TestingT, projectID string, region string, serviceName string, expectedConnector string, expectedEgress runpb.VpcAccess_VpcEgress) error {
+	service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+	if err != nil {
+		return err
+	}
+
+	vpcAccess := service.GetTemplate().GetVpcAccess()
+	if vpcAccess.GetConnector() != expectedConnector {
+		return fmt.Errorf("Cloud Run service %s uses VPC connector %q, expected %q", serviceName, vpcAccess.GetConnector(), expectedConnector)
+	}
+	if vpcAccess.GetEgress() != expectedEgress {
+		return fmt.Errorf("Cloud Run service %s has VPC egress %s, expected %s", serviceName, vpcAccess.GetEgress(), expectedEgress)
+	}
+	return nil
+}
+
+// AssertCloudRunServiceIngress checks that the given Cloud Run service accepts traffic from the given sources, e.g.,
+// runpb.IngressTraffic_INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER, and fails the test if it does not.
+func AssertCloudRunServiceIngress(t testing.TestingT, projectID string, region string, serviceName string, expectedIngress runpb.IngressTraffic) {
+	err := AssertCloudRunServiceIngressE(t, projectID, region, serviceName, expectedIngress)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertCloudRunServiceIngressE checks that the given Cloud Run service accepts traffic from the given sources, and
+// returns an error if it does not.
+func AssertCloudRunServiceIngressE(t testing.TestingT, projectID string, region string, serviceName string, expectedIngress runpb.IngressTraffic) error {
+	service, err := GetCloudRunServiceE(t, projectID, region, serviceName)
+	if err != nil {
+		return err
+	}
+
+	if service.GetIngress() != expectedIngress {
+		return fmt.Errorf("Cloud Run service %s has ingress %s, expected %s", serviceName, service.GetIngress(), expectedIngress)
+	}
+	return nil
+}
+
+// NewCloudRunServicesClient creates a new Cloud Run Services client, which is used to make Cloud Run API calls. The
+// caller is responsible for closing the client.
+func NewCloudRunServicesClient(t testing.TestingT) *run.ServicesClient {
+	client, err := NewCloudRunServicesClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewCloudRunServicesClientE creates a new Cloud Run Services client, which is used to make Cloud Run API calls. The
+// caller is responsible for closing the client.
+func NewCloudRunServicesClientE(t testing.TestingT) (*run.ServicesClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return run.NewServicesClient(context.Background(), opts...)
+}
+
+// cloudRunServiceReadyE returns an error unless the given Cloud Run service is done reconciling and its terminal
+// condition, which reflects whether its latest revision serves its traffic, succeeded.
+func cloudRunServiceReadyE(service *runpb.Service) error {
+	if service.GetReconciling() {
+		return fmt.Errorf("Cloud Run service %s is still being deployed", cloudRunResourceID(service.GetName()))
+	}
+
+	condition := service.GetTerminalCondition()
+	if condition.GetState() != runpb.Condition_CONDITION_SUCCEEDED {
+		return fmt.Errorf("Cloud Run service %s is not ready: %s %s", cloudRunResourceID(service.GetName()), condition.GetState(), condition.GetMessage())
+	}
+	return nil
+}
+
+// cloudRunResourceID returns the last segment of the given Cloud Run resource name, e.g., my-service-00002-abc for
+// projects/my-project/locations/us-central1/services/my-service/revisions/my-service-00002-abc.
+func cloudRunResourceID(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
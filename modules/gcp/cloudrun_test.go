@@ -0,0 +1,41 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"testing"
+
+	"cloud.google.com/go/run/apiv2/runpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudRunServiceReady(t *testing.T) {
+	t.Parallel()
+
+	name := "projects/my-project/locations/us-central1/services/my-service"
+
+	assert.NoError(t, cloudRunServiceReadyE(&runpb.Service{
+		Name:              name,
+		TerminalCondition: &runpb.Condition{State: runpb.Condition_CONDITION_SUCCEEDED},
+	}))
+	assert.Error(t, cloudRunServiceReadyE(&runpb.Service{
+		Name:              name,
+		Reconciling:       true,
+		TerminalCondition: &runpb.Condition{State: runpb.Condition_CONDITION_SUCCEEDED},
+	}))
+	assert.Error(t, cloudRunServiceReadyE(&runpb.Service{
+		Name:              name,
+		TerminalCondition: &runpb.Condition{State: runpb.Condition_CONDITION_FAILED, Message: "Revision failed to start"},
+	}))
+	assert.Error(t, cloudRunServiceReadyE(&runpb.Service{Name: name}))
+}
+
+func TestCloudRunResourceID(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "my-service-00002-abc", cloudRunResourceID("projects/my-project/locations/us-central1/services/my-service/revisions/my-service-00002-abc"))
+	assert.Equal(t, "my-service", cloudRunResourceID("my-service"))
+}
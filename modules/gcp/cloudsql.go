@@ -0,0 +1,321 @@
+package gcp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/cloudsqlconn"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cloudSQLConnectPolicy is how long GetCloudSQLConnectionE waits for the instance to accept connections, e.g., while a
+// new database user propagates.
+var cloudSQLConnectPolicy = retry.BackoffPolicy{MaxRetries: 10, InitialDelay: 3 * time.Second, Multiplier: 1}
+
+// cloudSQLMySQLDialers counts the dial functions registered with the MySQL driver, so that each connection gets its
+// own network name, as the driver only supports registering them globally.
+var cloudSQLMySQLDialers atomic.Int64
+
+// CloudSQLConnectionOptions configures how GetCloudSQLConnectionE connects to a Cloud SQL instance.
+type CloudSQLConnectionOptions struct {
+	// The database to connect to. Defaults to "postgres" for Postgres instances, and to no database for MySQL
+	// instances.
+	DatabaseName string
+
+	// The user to connect as. For IAM database authentication, this is the email of the IAM user, or the email of the
+	// service account without the .gserviceaccount.com suffix for Postgres and without the @ and domain for MySQL.
+	Username string
+
+	// The password of a built-in user. Ignored if UseIAMAuth is set.
+	Password string
+
+	// Authenticate with an OAuth2 token for the GCP identity of the test (see AuthOptions) instead of a password. This
+	// requires the cloudsql.iam_authentication flag to be set on the instance, and Username to be an IAM database user.
+	UseIAMAuth bool
+
+	// Connect to the private IP of the instance instead of its public IP, e.g., when running in the VPC of the
+	// instance. Instances without a public IP can only be reached this way.
+	UsePrivateIP bool
+}
+
+// GetCloudSQLConnection returns a connection to the given Postgres or MySQL Cloud SQL instance, formatted like
+// <project>:<region>:<instance>, after checking that the instance accepts it. This will fail the test if there is an
+// error. Close the connection at the end of the test.
+func GetCloudSQLConnection(t testing.TestingT, instanceConnectionName string, options CloudSQLConnectionOptions) *sql.DB {
+	db, err := GetCloudSQLConnectionE(t, instanceConnectionName, options)
+	require.NoError(t, err)
+	return db
+}
+
+// GetCloudSQLConnectionE returns a connection to the given Postgres or MySQL Cloud SQL instance, formatted like
+// <project>:<region>:<instance>, after checking that the instance accepts it. The connection goes through the Cloud SQL
+// Go Connector, which authorizes it with IAM and encrypts it with TLS, so neither authorized networks nor the Cloud SQL
+// Auth Proxy are needed. Close the connection at the end of the test.
+func GetCloudSQLConnectionE(t testing.TestingT, instanceConnectionName string, options CloudSQLConnectionOptions) (*sql.DB, error) {
+	if options.Username == "" {
+		return nil, fmt.Errorf("a Username is required to connect to Cloud SQL instance %s", instanceConnectionName)
+	}
+
+	dialerOptions, err := cloudSQLDialerOptionsE(options)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	dialer, err := cloudsqlconn.NewDialer(ctx, dialerOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	engineVersion, err := dialer.EngineVersion(ctx, instanceConnectionName)
+	if err != nil {
+		dialer.Close()
+		return nil, err
+	}
+
+	connector, err := newCloudSQLConnectorE(dialer, engineVersion, instanceConnectionName, options)
+	if err != nil {
+		dialer.Close()
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
+
+	description := fmt.Sprintf("Connecting to Cloud SQL instance %s as %s", instanceConnectionName, options.Username)
+	_, err = retry.DoWithPolicyE(t, description, cloudSQLConnectPolicy, func() (string, error) {
+		return "", db.PingContext(ctx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// cloudSQLDialerOptionsE returns the options of the Cloud SQL Go Connector dialer for the given connection options.
+func cloudSQLDialerOptionsE(options CloudSQLConnectionOptions) ([]cloudsqlconn.Option, error) {
+	var dialerOptions []cloudsqlconn.Option
+
+	ts, ok, err := getConfiguredTokenSourceE()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if options.UseIAMAuth {
+			dialerOptions = append(dialerOptions, cloudsqlconn.WithIAMAuthNTokenSources(ts, ts))
+		} else {
+			dialerOptions = append(dialerOptions, cloudsqlconn.WithTokenSource(ts))
+		}
+	}
+	if options.UseIAMAuth {
+		dialerOptions = append(dialerOptions, cloudsqlconn.WithIAMAuthN())
+	}
+	if options.UsePrivateIP {
+		dialerOptions = append(dialerOptions, cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()))
+	}
+	return dialerOptions, nil
+}
+
+// newCloudSQLConnectorE returns a database/sql connector that dials the given instance, whose engine version is like
+// POSTGRES_16 or MYSQL_8_0, through the given dialer.
+func newCloudSQLConnectorE(dialer *cloudsqlconn.Dialer, engineVersion string, instanceConnectionName string, options CloudSQLConnectionOptions) (driver.Connector, error) {
+	password := options.Password
+	if options.UseIAMAuth {
+		password = ""
+	}
+
+	switch {
+	case strings.HasPrefix(engineVersion, "POSTGRES"):
+		config, err := pgx.ParseConfig(cloudSQLPostgresDataSourceName(options.Username, password, cloudSQLDatabaseName(engineVersion, options)))
+		if err != nil {
+			return nil, err
+		}
+		config.DialFunc = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return dialer.Dial(ctx, instanceConnectionName)
+		}
+		return cloudSQLConnector{Connector: stdlib.GetConnector(*config), dialer: dialer}, nil
+
+	case strings.HasPrefix(engineVersion, "MYSQL"):
+		network := fmt.Sprintf("terratest-cloudsql-%d", cloudSQLMySQLDialers.Add(1))
+		mysql.RegisterDialContext(network, func(ctx context.Context, _ string) (net.Conn, error) {
+			return dialer.Dial(ctx, instanceConnectionName)
+		})
+
+		connector, err := mysql.NewConnector(cloudSQLMySQLConfig(network, instanceConnectionName, options.Username, password, options.DatabaseName))
+		if err != nil {
+			return nil, err
+		}
+		return cloudSQLConnector{Connector: connector, dialer: dialer}, nil
+
+	default:
+		return nil, fmt.Errorf("Cloud SQL instance %s runs %s, only Postgres and MySQL are supported", instanceConnectionName, engineVersion)
+	}
+}
+
+// cloudSQLConnector is a database/sql connector that closes its Cloud SQL Go Connector dialer when the sql.DB it was
+// opened with is closed.
+type cloudSQLConnector struct {
+	driver.Connector
+	dialer *cloudsqlconn.Dialer
+}
+
+// Close implements io.Closer, which sql.DB.Close calls on its connector.
+func (c cloudSQLConnector) Close() error {
+	return c.dialer.Close()
+}
+
+// cloudSQLDatabaseName returns the database to connect to on an instance with the given engine version.
+func cloudSQLDatabaseName(engineVersion string, options CloudSQLConnectionOptions) string {
+	if options.DatabaseName != "" {
+		return options.DatabaseName
+	}
+	if strings.HasPrefix(engineVersion, "POSTGRES") {
+		return "postgres"
+	}
+	return ""
+}
+
+// cloudSQLPostgresDataSourceName returns the pgx data source name to connect to a Cloud SQL Postgres instance. The host
+// is a placeholder, as the connection is dialed by the Cloud SQL Go Connector, which also takes care of TLS.
+func cloudSQLPostgresDataSourceName(username string, password string, databaseName string) string {
+	user := url.User(username)
+	if password != "" {
+		user = url.UserPassword(username, password)
+	}
+
+	dataSourceName := url.URL{
+		Scheme:   "postgres",
+		User:     user,
+		Host:     "localhost",
+		Path:     "/" + databaseName,
+		RawQuery: "sslmode=disable",
+	}
+	return dataSourceName.String()
+}
+
+// cloudSQLMySQLConfig returns the MySQL driver configuration to connect to a Cloud SQL MySQL instance through the dial
+// function registered for the given network.
+func cloudSQLMySQLConfig(network string, instanceConnectionName string, username string, password string, databaseName string) *mysql.Config {
+	config := mysql.NewConfig()
+	config.User = username
+	config.Passwd = password
+	config.Net = network
+	config.Addr = instanceConnectionName
+	config.DBName = databaseName
+	return config
+}
+
+// CloudSQLTableExistsE returns true if the given table exists in the given schema (the database for MySQL) of the
+// database that the given connection, returned by GetCloudSQLConnectionE, is connected to.
+func CloudSQLTableExistsE(t testing.TestingT, db *sql.DB, schemaName string, tableName string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2"
+	if isCloudSQLMySQLConnection(db) {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?"
+	}
+
+	var count int
+	if err := db.QueryRowContext(context.Background(), query, schemaName, tableName).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AssertCloudSQLTableExists checks that the given table exists in the given schema (the database for MySQL) of the
+// database that the given connection, returned by GetCloudSQLConnection, is connected to.
+func AssertCloudSQLTableExists(t testing.TestingT, db *sql.DB, schemaName string, tableName string) {
+	exists, err := CloudSQLTableExistsE(t, db, schemaName, tableName)
+	if assert.NoError(t, err) {
+		assert.Truef(t, exists, "Table %s does not exist in schema %s", tableName, schemaName)
+	}
+}
+
+// GetCloudSQLRowCount returns the number of rows in the given table (optionally qualified with its schema, e.g.,
+// public.users) of the database that the given connection, returned by GetCloudSQLConnection, is connected to.
+func GetCloudSQLRowCount(t testing.TestingT, db *sql.DB, tableName string) int64 {
+	count, err := GetCloudSQLRowCountE(t, db, tableName)
+	require.NoError(t, err)
+	return count
+}
+
+// GetCloudSQLRowCountE returns the number of rows in the given table (optionally qualified with its schema, e.g.,
+// public.users) of the database that the given connection, returned by GetCloudSQLConnectionE, is connected to.
+func GetCloudSQLRowCountE(t testing.TestingT, db *sql.DB, tableName string) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM " + quoteCloudSQLIdentifier(tableName, isCloudSQLMySQLConnection(db))
+	if err := db.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// AssertCloudSQLRowCount checks that the given table of the database that the given connection, returned by
+// GetCloudSQLConnection, is connected to has the expected number of rows.
+func AssertCloudSQLRowCount(t testing.TestingT, db *sql.DB, tableName string, expectedCount int64) {
+	count, err := GetCloudSQLRowCountE(t, db, tableName)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expectedCount, count, "Unexpected number of rows in table %s", tableName)
+	}
+}
+
+// GetCloudSQLQueryResult returns the first column of the first row returned by the given query, e.g.,
+// "SELECT version()", on the database that the given connection, returned by GetCloudSQLConnection, is connected to.
+func GetCloudSQLQueryResult(t testing.TestingT, db *sql.DB, query string, args ...interface{}) string {
+	result, err := GetCloudSQLQueryResultE(t, db, query, args...)
+	require.NoError(t, err)
+	return result
+}
+
+// GetCloudSQLQueryResultE returns the first column of the first row returned by the given query on the database that
+// the given connection, returned by GetCloudSQLConnectionE, is connected to.
+func GetCloudSQLQueryResultE(t testing.TestingT, db *sql.DB, query string, args ...interface{}) (string, error) {
+	logger.Default.Logf(t, "Running query on Cloud SQL: %s", query)
+
+	var result sql.NullString
+	if err := db.QueryRowContext(context.Background(), query, args...).Scan(&result); err != nil {
+		return "", err
+	}
+	return result.String, nil
+}
+
+// AssertCloudSQLQueryResult checks that the first column of the first row returned by the given query on the database
+// that the given connection, returned by GetCloudSQLConnection, is connected to has the expected value.
+func AssertCloudSQLQueryResult(t testing.TestingT, db *sql.DB, expected string, query string, args ...interface{}) {
+	result, err := GetCloudSQLQueryResultE(t, db, query, args...)
+	if assert.NoError(t, err) {
+		assert.Equalf(t, expected, result, "Unexpected result of query %s", query)
+	}
+}
+
+// isCloudSQLMySQLConnection returns true if the given connection uses the MySQL driver, whose placeholders and
+// identifier quotes differ from the Postgres ones.
+func isCloudSQLMySQLConnection(db *sql.DB) bool {
+	_, isMySQL := db.Driver().(*mysql.MySQLDriver)
+	return isMySQL
+}
+
+// quoteCloudSQLIdentifier quotes each part of the given, optionally schema qualified, identifier, with backticks for
+// MySQL and double quotes otherwise.
+func quoteCloudSQLIdentifier(identifier string, mySQL bool) string {
+	quote := `"`
+	if mySQL {
+		quote = "`"
+	}
+
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
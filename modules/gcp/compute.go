@@ -3,43 +3,40 @@ package gcp
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"path"
 	"strings"
-	"time"
-
-	"github.com/gruntwork-io/terratest/modules/retry"
-	"google.golang.org/api/compute/v1"
 
+	compute "cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/testing"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/proto"
 )
 
 // Corresponds to a GCP Compute Instance (https://cloud.google.com/compute/docs/instances/)
 type Instance struct {
 	projectID string
-	*compute.Instance
+	*computepb.Instance
 }
 
 // Corresponds to a GCP Image (https://cloud.google.com/compute/docs/images)
 type Image struct {
 	projectID string
-	*compute.Image
+	*computepb.Image
 }
 
 // Corresponds to a GCP Zonal Instance Group (https://cloud.google.com/compute/docs/instance-groups/)
 type ZonalInstanceGroup struct {
 	projectID string
-	*compute.InstanceGroup
+	*computepb.InstanceGroup
 }
 
 // Corresponds to a GCP Regional Instance Group (https://cloud.google.com/compute/docs/instance-groups/)
 type RegionalInstanceGroup struct {
 	projectID string
-	*compute.InstanceGroup
+	*computepb.InstanceGroup
 }
 
 type InstanceGroup interface {
@@ -62,21 +59,27 @@ func FetchInstanceE(t testing.TestingT, projectID string, name string) (*Instanc
 	logger.Default.Logf(t, "Getting Compute Instance %s", name)
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewInstancesClientE(t)
 	if err != nil {
-		t.Fatal(err)
+		return nil, err
 	}
+	defer client.Close()
 
 	// If we want to fetch an Instance without knowing its Zone, we have to query GCP for all Instances in the project
 	// and match on name.
-	instanceAggregatedList, err := service.Instances.AggregatedList(projectID).Context(ctx).Do()
-	if err != nil {
-		return nil, fmt.Errorf("Instances.AggregatedList(%s) got error: %v", projectID, err)
-	}
+	req := &computepb.AggregatedListInstancesRequest{Project: projectID}
+	it := client.AggregatedList(ctx, req, withReadRetries()...)
+	for {
+		instanceList, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Instances.AggregatedList(%s) got error: %v", projectID, err)
+		}
 
-	for _, instanceList := range instanceAggregatedList.Items {
-		for _, instance := range instanceList.Instances {
-			if name == instance.Name {
+		for _, instance := range instanceList.Value.GetInstances() {
+			if name == instance.GetName() {
 				return &Instance{projectID, instance}, nil
 			}
 		}
@@ -100,13 +103,14 @@ func FetchImageE(t testing.TestingT, projectID string, name string) (*Image, err
 	logger.Default.Logf(t, "Getting Image %s", name)
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewImagesClientE(t)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	req := service.Images.Get(projectID, name)
-	image, err := req.Context(ctx).Do()
+	req := &computepb.GetImageRequest{Project: projectID, Image: name}
+	image, err := client.Get(ctx, req, withReadRetries()...)
 	if err != nil {
 		return nil, err
 	}
@@ -129,13 +133,14 @@ func FetchRegionalInstanceGroupE(t testing.TestingT, projectID string, region st
 	logger.Default.Logf(t, "Getting Regional Instance Group %s", name)
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewRegionInstanceGroupsClientE(t)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	req := service.RegionInstanceGroups.Get(projectID, region, name)
-	instanceGroup, err := req.Context(ctx).Do()
+	req := &computepb.GetRegionInstanceGroupRequest{Project: projectID, Region: region, InstanceGroup: name}
+	instanceGroup, err := client.Get(ctx, req, withReadRetries()...)
 	if err != nil {
 		return nil, err
 	}
@@ -158,13 +163,14 @@ func FetchZonalInstanceGroupE(t testing.TestingT, projectID string, zone string,
 	logger.Default.Logf(t, "Getting Zonal Instance Group %s", name)
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewInstanceGroupsClientE(t)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	req := service.InstanceGroups.Get(projectID, zone, name)
-	instanceGroup, err := req.Context(ctx).Do()
+	req := &computepb.GetInstanceGroupRequest{Project: projectID, Zone: zone, InstanceGroup: name}
+	instanceGroup, err := client.Get(ctx, req, withReadRetries()...)
 	if err != nil {
 		return nil, err
 	}
@@ -185,11 +191,11 @@ func (i *Instance) GetPublicIp(t testing.TestingT) string {
 func (i *Instance) GetPublicIpE(t testing.TestingT) (string, error) {
 	// If there are no accessConfigs specified, then this instance will have no external internet access:
 	// https://cloud.google.com/compute/docs/reference/rest/v1/instances.
-	if len(i.NetworkInterfaces[0].AccessConfigs) == 0 {
-		return "", fmt.Errorf("Attempted to get public IP of Compute Instance %s, but that Compute Instance does not have a public IP address", i.Name)
+	if len(i.NetworkInterfaces[0].GetAccessConfigs()) == 0 {
+		return "", fmt.Errorf("Attempted to get public IP of Compute Instance %s, but that Compute Instance does not have a public IP address", i.GetName())
 	}
 
-	ip := i.NetworkInterfaces[0].AccessConfigs[0].NatIP
+	ip := i.NetworkInterfaces[0].AccessConfigs[0].GetNatIP()
 
 	return ip, nil
 }
@@ -201,7 +207,7 @@ func (i *Instance) GetLabels(t testing.TestingT) map[string]string {
 
 // GetZone returns the Zone in which the Compute Instance is located.
 func (i *Instance) GetZone(t testing.TestingT) string {
-	return ZoneUrlToZone(i.Zone)
+	return ZoneUrlToZone(i.Instance.GetZone())
 }
 
 // SetLabels adds the tags to the given Compute Instance.
@@ -214,25 +220,38 @@ func (i *Instance) SetLabels(t testing.TestingT, labels map[string]string) {
 
 // SetLabelsE adds the tags to the given Compute Instance.
 func (i *Instance) SetLabelsE(t testing.TestingT, labels map[string]string) error {
-	logger.Default.Logf(t, "Adding labels to instance %s in zone %s", i.Name, i.Zone)
+	logger.Default.Logf(t, "Adding labels to instance %s in zone %s", i.GetName(), i.GetZone(t))
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewInstancesClientE(t)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	req := compute.InstancesSetLabelsRequest{Labels: labels, LabelFingerprint: i.LabelFingerprint}
-	if _, err := service.Instances.SetLabels(i.projectID, i.GetZone(t), i.Name, &req).Context(ctx).Do(); err != nil {
-		return fmt.Errorf("Instances.SetLabels(%s) got error: %v", i.Name, err)
+	req := &computepb.SetLabelsInstanceRequest{
+		Project:  i.projectID,
+		Zone:     i.GetZone(t),
+		Instance: i.GetName(),
+		InstancesSetLabelsRequestResource: &computepb.InstancesSetLabelsRequest{
+			Labels:           labels,
+			LabelFingerprint: i.LabelFingerprint,
+		},
+	}
+	op, err := client.SetLabels(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Instances.SetLabels(%s) got error: %v", i.GetName(), err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("Instances.SetLabels(%s) operation got error: %v", i.GetName(), err)
 	}
 
 	return nil
 }
 
 // GetMetadata gets the given Compute Instance's metadata
-func (i *Instance) GetMetadata(t testing.TestingT) []*compute.MetadataItems {
-	return i.Metadata.Items
+func (i *Instance) GetMetadata(t testing.TestingT) []*computepb.Items {
+	return i.Metadata.GetItems()
 }
 
 // SetMetadata sets the given Compute Instance's metadata
@@ -245,18 +264,27 @@ func (i *Instance) SetMetadata(t testing.TestingT, metadata map[string]string) {
 
 // SetLabelsE adds the given metadata map to the existing metadata of the given Compute Instance.
 func (i *Instance) SetMetadataE(t testing.TestingT, metadata map[string]string) error {
-	logger.Default.Logf(t, "Adding metadata to instance %s in zone %s", i.Name, i.Zone)
+	logger.Default.Logf(t, "Adding metadata to instance %s in zone %s", i.GetName(), i.GetZone(t))
 
 	ctx := context.Background()
-	service, err := NewInstancesServiceE(t)
+	client, err := NewInstancesClientE(t)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	metadataItems := newMetadata(t, i.Metadata, metadata)
-	req := service.SetMetadata(i.projectID, i.GetZone(t), i.Name, metadataItems)
-	if _, err := req.Context(ctx).Do(); err != nil {
-		return fmt.Errorf("Instances.SetMetadata(%s) got error: %v", i.Name, err)
+	req := &computepb.SetMetadataInstanceRequest{
+		Project:          i.projectID,
+		Zone:             i.GetZone(t),
+		Instance:         i.GetName(),
+		MetadataResource: newMetadata(t, i.Metadata, metadata),
+	}
+	op, err := client.SetMetadata(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Instances.SetMetadata(%s) got error: %v", i.GetName(), err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("Instances.SetMetadata(%s) operation got error: %v", i.GetName(), err)
 	}
 
 	return nil
@@ -264,19 +292,19 @@ func (i *Instance) SetMetadataE(t testing.TestingT, metadata map[string]string)
 
 // newMetadata takes in a Compute Instance's existing metadata plus a new set of key-value pairs and returns an updated
 // metadata object.
-func newMetadata(t testing.TestingT, oldMetadata *compute.Metadata, kvs map[string]string) *compute.Metadata {
-	items := []*compute.MetadataItems{}
+func newMetadata(t testing.TestingT, oldMetadata *computepb.Metadata, kvs map[string]string) *computepb.Metadata {
+	items := []*computepb.Items{}
 
 	for key, val := range kvs {
-		item := &compute.MetadataItems{
-			Key:   key,
-			Value: &val,
+		item := &computepb.Items{
+			Key:   proto.String(key),
+			Value: proto.String(val),
 		}
 
-		items = append(oldMetadata.Items, item)
+		items = append(oldMetadata.GetItems(), item)
 	}
 
-	newMetadata := &compute.Metadata{
+	newMetadata := &computepb.Metadata{
 		Fingerprint: oldMetadata.Fingerprint,
 		Items:       items,
 	}
@@ -294,7 +322,7 @@ func (i *Instance) AddSshKey(t testing.TestingT, username string, publicKey stri
 
 // Add the given public SSH key to the Compute Instance. Users can SSH in with the given username.
 func (i *Instance) AddSshKeyE(t testing.TestingT, username string, publicKey string) error {
-	logger.Default.Logf(t, "Adding SSH Key to Compute Instance %s for username %s\n", i.Name, username)
+	logger.Default.Logf(t, "Adding SSH Key to Compute Instance %s for username %s\n", i.GetName(), username)
 
 	// We represent the key in the format required per GCP docs (https://cloud.google.com/compute/docs/instances/adding-removing-ssh-keys)
 	publicKeyFormatted := strings.TrimSpace(publicKey)
@@ -322,16 +350,21 @@ func (i *Image) DeleteImage(t testing.TestingT) {
 
 // DeleteImageE deletes the given Compute Image.
 func (i *Image) DeleteImageE(t testing.TestingT) error {
-	logger.Default.Logf(t, "Destroying Image %s", i.Name)
+	logger.Default.Logf(t, "Destroying Image %s", i.GetName())
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewImagesClientE(t)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	if _, err := service.Images.Delete(i.projectID, i.Name).Context(ctx).Do(); err != nil {
-		return fmt.Errorf("Images.Delete(%s) got error: %v", i.Name, err)
+	op, err := client.Delete(ctx, &computepb.DeleteImageRequest{Project: i.projectID, Image: i.GetName()})
+	if err != nil {
+		return fmt.Errorf("Images.Delete(%s) got error: %v", i.GetName(), err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("Images.Delete(%s) operation got error: %v", i.GetName(), err)
 	}
 
 	return nil
@@ -348,35 +381,41 @@ func (ig *ZonalInstanceGroup) GetInstanceIds(t testing.TestingT) []string {
 
 // GetInstanceIdsE gets the IDs of Instances in the given Zonal Instance Group.
 func (ig *ZonalInstanceGroup) GetInstanceIdsE(t testing.TestingT) ([]string, error) {
-	logger.Default.Logf(t, "Get instances for Zonal Instance Group %s", ig.Name)
+	logger.Default.Logf(t, "Get instances for Zonal Instance Group %s", ig.GetName())
 
 	ctx := context.Background()
-	service, err := NewComputeServiceE(t)
+	client, err := NewInstanceGroupsClientE(t)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	requestBody := &compute.InstanceGroupsListInstancesRequest{
-		InstanceState: "ALL",
+	req := &computepb.ListInstancesInstanceGroupsRequest{
+		Project:       ig.projectID,
+		Zone:          ZoneUrlToZone(ig.GetZone()),
+		InstanceGroup: ig.GetName(),
+		InstanceGroupsListInstancesRequestResource: &computepb.InstanceGroupsListInstancesRequest{
+			InstanceState: proto.String("ALL"),
+		},
 	}
 
 	instanceIDs := []string{}
-	zone := ZoneUrlToZone(ig.Zone)
 
-	req := service.InstanceGroups.ListInstances(ig.projectID, zone, ig.Name, requestBody)
-
-	err = req.Pages(ctx, func(page *compute.InstanceGroupsListInstances) error {
-		for _, instance := range page.Items {
-			// For some reason service.InstanceGroups.ListInstances returns us a collection
-			// with Instance URLs and we need only the Instance ID for the next call. Use
-			// the path functions to chop the Instance ID off the end of the URL.
-			instanceID := path.Base(instance.Instance)
-			instanceIDs = append(instanceIDs, instanceID)
+	it := client.ListInstances(ctx, req, withReadRetries()...)
+	for {
+		instance, err := it.Next()
+		if err == iterator.Done {
+			break
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("InstanceGroups.ListInstances(%s) got error: %v", ig.Name, err)
+		if err != nil {
+			return nil, fmt.Errorf("InstanceGroups.ListInstances(%s) got error: %v", ig.GetName(), err)
+		}
+
+		// For some reason InstanceGroups.ListInstances returns us a collection
+		// with Instance URLs and we need only the Instance ID for the next call. Use
+		// the path functions to chop the Instance ID off the end of the URL.
+		instanceID := path.Base(instance.GetInstance())
+		instanceIDs = append(instanceIDs, instanceID)
 	}
 
 	return instanceIDs, nil
@@ -393,36 +432,42 @@ func (ig *RegionalInstanceGroup) GetInstanceIds(t testing.TestingT) []string {
 
 // GetInstanceIdsE gets the IDs of Instances in the given Regional Instance Group.
 func (ig *RegionalInstanceGroup) GetInstanceIdsE(t testing.TestingT) ([]string, error) {
-	logger.Default.Logf(t, "Get instances for Regional Instance Group %s", ig.Name)
+	logger.Default.Logf(t, "Get instances for Regional Instance Group %s", ig.GetName())
 
 	ctx := context.Background()
 
-	service, err := NewComputeServiceE(t)
+	client, err := NewRegionInstanceGroupsClientE(t)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	requestBody := &compute.RegionInstanceGroupsListInstancesRequest{
-		InstanceState: "ALL",
+	req := &computepb.ListInstancesRegionInstanceGroupsRequest{
+		Project:       ig.projectID,
+		Region:        RegionUrlToRegion(ig.GetRegion()),
+		InstanceGroup: ig.GetName(),
+		RegionInstanceGroupsListInstancesRequestResource: &computepb.RegionInstanceGroupsListInstancesRequest{
+			InstanceState: proto.String("ALL"),
+		},
 	}
 
 	instanceIDs := []string{}
-	region := RegionUrlToRegion(ig.Region)
 
-	req := service.RegionInstanceGroups.ListInstances(ig.projectID, region, ig.Name, requestBody)
-
-	err = req.Pages(ctx, func(page *compute.RegionInstanceGroupsListInstances) error {
-		for _, instance := range page.Items {
-			// For some reason service.InstanceGroups.ListInstances returns us a collection
-			// with Instance URLs and we need only the Instance ID for the next call. Use
-			// the path functions to chop the Instance ID off the end of the URL.
-			instanceID := path.Base(instance.Instance)
-			instanceIDs = append(instanceIDs, instanceID)
+	it := client.ListInstances(ctx, req, withReadRetries()...)
+	for {
+		instance, err := it.Next()
+		if err == iterator.Done {
+			break
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("InstanceGroups.ListInstances(%s) got error: %v", ig.Name, err)
+		if err != nil {
+			return nil, fmt.Errorf("InstanceGroups.ListInstances(%s) got error: %v", ig.GetName(), err)
+		}
+
+		// For some reason RegionInstanceGroups.ListInstances returns us a collection
+		// with Instance URLs and we need only the Instance ID for the next call. Use
+		// the path functions to chop the Instance ID off the end of the URL.
+		instanceID := path.Base(instance.GetInstance())
+		instanceIDs = append(instanceIDs, instanceID)
 	}
 
 	return instanceIDs, nil
@@ -528,22 +573,22 @@ func getPublicIpsE(t testing.TestingT, ig InstanceGroup, projectId string) ([]st
 
 // getRandomInstance returns a randomly selected Instance from the Regional Instance Group
 func (ig *ZonalInstanceGroup) GetRandomInstance(t testing.TestingT) *Instance {
-	return getRandomInstance(t, ig, ig.Name, ig.Region, ig.Size, ig.projectID)
+	return getRandomInstance(t, ig, ig.GetName(), ig.GetRegion(), int64(ig.GetSize()), ig.projectID)
 }
 
 // getRandomInstanceE returns a randomly selected Instance from the Regional Instance Group
 func (ig *ZonalInstanceGroup) GetRandomInstanceE(t testing.TestingT) (*Instance, error) {
-	return getRandomInstanceE(t, ig, ig.Name, ig.Region, ig.Size, ig.projectID)
+	return getRandomInstanceE(t, ig, ig.GetName(), ig.GetRegion(), int64(ig.GetSize()), ig.projectID)
 }
 
 // getRandomInstance returns a randomly selected Instance from the Regional Instance Group
 func (ig *RegionalInstanceGroup) GetRandomInstance(t testing.TestingT) *Instance {
-	return getRandomInstance(t, ig, ig.Name, ig.Region, ig.Size, ig.projectID)
+	return getRandomInstance(t, ig, ig.GetName(), ig.GetRegion(), int64(ig.GetSize()), ig.projectID)
 }
 
 // getRandomInstanceE returns a randomly selected Instance from the Regional Instance Group
 func (ig *RegionalInstanceGroup) GetRandomInstanceE(t testing.TestingT) (*Instance, error) {
-	return getRandomInstanceE(t, ig, ig.Name, ig.Region, ig.Size, ig.projectID)
+	return getRandomInstanceE(t, ig, ig.GetName(), ig.GetRegion(), int64(ig.GetSize()), ig.projectID)
 }
 
 func getRandomInstance(t testing.TestingT, ig InstanceGroup, name string, region string, size int64, projectID string) *Instance {
@@ -573,67 +618,124 @@ func getRandomInstanceE(t testing.TestingT, ig InstanceGroup, name string, regio
 	return instance, nil
 }
 
-// NewComputeService creates a new Compute service, which is used to make GCE API calls.
-func NewComputeService(t testing.TestingT) *compute.Service {
-	client, err := NewComputeServiceE(t)
+// NewInstancesClient creates a new Compute Engine Instances client, which is used to make GCE Instance API calls. The
+// caller is responsible for closing the client.
+func NewInstancesClient(t testing.TestingT) *compute.InstancesClient {
+	client, err := NewInstancesClientE(t)
 	if err != nil {
 		t.Fatal(err)
 	}
 	return client
 }
 
-// NewComputeServiceE creates a new Compute service, which is used to make GCE API calls.
-func NewComputeServiceE(t testing.TestingT) (*compute.Service, error) {
-	ctx := context.Background()
-
-	if ts, ok := getStaticTokenSource(); ok {
-		return compute.New(oauth2.NewClient(ctx, ts))
+// NewInstancesClientE creates a new Compute Engine Instances client, which is used to make GCE Instance API calls. The
+// caller is responsible for closing the client.
+func NewInstancesClientE(t testing.TestingT) (*compute.InstancesClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
 	}
+	return compute.NewInstancesRESTClient(context.Background(), opts...)
+}
 
-	// Retrieve the Google OAuth token using a retry loop as it can sometimes return an error.
-	// e.g: oauth2: cannot fetch token: Post https://oauth2.googleapis.com/token: net/http: TLS handshake timeout
-	// This is loosely based on https://github.com/kubernetes/kubernetes/blob/7e8de5422cb5ad76dd0c147cf4336220d282e34b/pkg/cloudprovider/providers/gce/gce.go#L831.
+// NewImagesClient creates a new Compute Engine Images client, which is used to make GCE Image API calls. The caller is
+// responsible for closing the client.
+func NewImagesClient(t testing.TestingT) *compute.ImagesClient {
+	client, err := NewImagesClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
 
-	description := "Attempting to request a Google OAuth2 token"
-	maxRetries := 6
-	timeBetweenRetries := 10 * time.Second
+// NewImagesClientE creates a new Compute Engine Images client, which is used to make GCE Image API calls. The caller is
+// responsible for closing the client.
+func NewImagesClientE(t testing.TestingT) (*compute.ImagesClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewImagesRESTClient(context.Background(), opts...)
+}
 
-	var client *http.Client
+// NewInstanceGroupsClient creates a new Compute Engine Instance Groups client, which is used to make GCE Zonal Instance
+// Group API calls. The caller is responsible for closing the client.
+func NewInstanceGroupsClient(t testing.TestingT) *compute.InstanceGroupsClient {
+	client, err := NewInstanceGroupsClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
 
-	msg, retryErr := retry.DoWithRetryE(t, description, maxRetries, timeBetweenRetries, func() (string, error) {
-		rawClient, err := google.DefaultClient(ctx, compute.CloudPlatformScope)
-		if err != nil {
-			return "Error retrieving default GCP client", err
-		}
-		client = rawClient
-		return "Successfully retrieved default GCP client", nil
-	})
-	logger.Default.Logf(t, msg)
+// NewInstanceGroupsClientE creates a new Compute Engine Instance Groups client, which is used to make GCE Zonal Instance
+// Group API calls. The caller is responsible for closing the client.
+func NewInstanceGroupsClientE(t testing.TestingT) (*compute.InstanceGroupsClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewInstanceGroupsRESTClient(context.Background(), opts...)
+}
 
-	if retryErr != nil {
-		return nil, retryErr
+// NewRegionInstanceGroupsClient creates a new Compute Engine Region Instance Groups client, which is used to make GCE
+// Regional Instance Group API calls. The caller is responsible for closing the client.
+func NewRegionInstanceGroupsClient(t testing.TestingT) *compute.RegionInstanceGroupsClient {
+	client, err := NewRegionInstanceGroupsClientE(t)
+	if err != nil {
+		t.Fatal(err)
 	}
+	return client
+}
 
-	return compute.New(client)
+// NewRegionInstanceGroupsClientE creates a new Compute Engine Region Instance Groups client, which is used to make GCE
+// Regional Instance Group API calls. The caller is responsible for closing the client.
+func NewRegionInstanceGroupsClientE(t testing.TestingT) (*compute.RegionInstanceGroupsClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewRegionInstanceGroupsRESTClient(context.Background(), opts...)
 }
 
-// NewInstancesService creates a new InstancesService service, which is used to make a subset of GCE API calls.
-func NewInstancesService(t testing.TestingT) *compute.InstancesService {
-	client, err := NewInstancesServiceE(t)
+// NewRegionsClient creates a new Compute Engine Regions client, which is used to look up GCP Regions. The caller is
+// responsible for closing the client.
+func NewRegionsClient(t testing.TestingT) *compute.RegionsClient {
+	client, err := NewRegionsClientE(t)
 	if err != nil {
 		t.Fatal(err)
 	}
 	return client
 }
 
-// NewInstancesServiceE creates a new InstancesService service, which is used to make a subset of GCE API calls.
-func NewInstancesServiceE(t testing.TestingT) (*compute.InstancesService, error) {
-	service, err := NewComputeServiceE(t)
+// NewRegionsClientE creates a new Compute Engine Regions client, which is used to look up GCP Regions. The caller is
+// responsible for closing the client.
+func NewRegionsClientE(t testing.TestingT) (*compute.RegionsClient, error) {
+	opts, err := withOptions()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get new Instances Service\n")
+		return nil, err
 	}
+	return compute.NewRegionsRESTClient(context.Background(), opts...)
+}
 
-	return service.Instances, nil
+// NewZonesClient creates a new Compute Engine Zones client, which is used to look up GCP Zones. The caller is
+// responsible for closing the client.
+func NewZonesClient(t testing.TestingT) *compute.ZonesClient {
+	client, err := NewZonesClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewZonesClientE creates a new Compute Engine Zones client, which is used to look up GCP Zones. The caller is
+// responsible for closing the client.
+func NewZonesClientE(t testing.TestingT) (*compute.ZonesClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewZonesRESTClient(context.Background(), opts...)
 }
 
 // Return a random, valid name for GCP resources. Many resources in GCP requires lowercase letters only.
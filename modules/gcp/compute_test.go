@@ -13,9 +13,10 @@ import (
 	"testing"
 	"time"
 
+	"cloud.google.com/go/compute/apiv1/computepb"
 	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/stretchr/testify/assert"
-	"google.golang.org/api/compute/v1"
+	"google.golang.org/protobuf/proto"
 )
 
 const DEFAULT_MACHINE_TYPE = "f1-micro"
@@ -136,7 +137,7 @@ func TestGetAndSetMetadata(t *testing.T) {
 		metadataFromRead := instance.GetMetadata(t)
 		for _, metadataItem := range metadataFromRead {
 			for key, val := range metadataToWrite {
-				if metadataItem.Key == key && *metadataItem.Value == val {
+				if metadataItem.GetKey() == key && metadataItem.GetValue() == val {
 					return "", nil
 				}
 			}
@@ -173,35 +174,33 @@ func createComputeInstance(t *testing.T, projectID string, zone string, name str
 
 	// Based on the properties listed as required at https://cloud.google.com/compute/docs/reference/rest/v1/instances/insert
 	// plus a somewhat painful cycle of add-next-property-try-fix-error-message-repeat.
-	instanceConfig := &compute.Instance{
-		Name:        name,
-		MachineType: machineTypeURL,
-		NetworkInterfaces: []*compute.NetworkInterface{
-			&compute.NetworkInterface{
-				AccessConfigs: []*compute.AccessConfig{
-					&compute.AccessConfig{},
+	instanceConfig := &computepb.Instance{
+		Name:        proto.String(name),
+		MachineType: proto.String(machineTypeURL),
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				AccessConfigs: []*computepb.AccessConfig{
+					{},
 				},
 			},
 		},
-		Disks: []*compute.AttachedDisk{
-			&compute.AttachedDisk{
-				AutoDelete: true,
-				Boot:       true,
-				InitializeParams: &compute.AttachedDiskInitializeParams{
-					SourceImage: sourceImageURL,
+		Disks: []*computepb.AttachedDisk{
+			{
+				AutoDelete: proto.Bool(true),
+				Boot:       proto.Bool(true),
+				InitializeParams: &computepb.AttachedDiskInitializeParams{
+					SourceImage: proto.String(sourceImageURL),
 				},
 			},
 		},
 	}
 
-	service, err := NewComputeServiceE(t)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := NewInstancesClient(t)
+	defer client.Close()
 
 	// Create the Compute Instance
 	ctx := context.Background()
-	_, err = service.Instances.Insert(projectID, zone, instanceConfig).Context(ctx).Do()
+	_, err := client.Insert(ctx, &computepb.InsertInstanceRequest{Project: projectID, Zone: zone, InstanceResource: instanceConfig})
 	if err != nil {
 		t.Fatalf("Error launching new Compute Instance: %s", err)
 	}
@@ -211,14 +210,12 @@ func createComputeInstance(t *testing.T, projectID string, zone string, name str
 func deleteComputeInstance(t *testing.T, projectID string, zone string, name string) {
 	t.Logf("Deleting Compute Instance %s\n", name)
 
-	service, err := NewComputeServiceE(t)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := NewInstancesClient(t)
+	defer client.Close()
 
 	// Delete the Compute Instance
 	ctx := context.Background()
-	_, err = service.Instances.Delete(projectID, zone, name).Context(ctx).Do()
+	_, err := client.Delete(ctx, &computepb.DeleteInstanceRequest{Project: projectID, Zone: zone, Instance: name})
 	if err != nil {
 		t.Fatalf("Error deleting Compute Instance: %s", err)
 	}
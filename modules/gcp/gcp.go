@@ -2,14 +2,48 @@
 package gcp
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/api/option"
 )
 
-func withOptions() (opts []option.ClientOption) {
-	v, ok := getStaticTokenSource()
-	if ok {
-		opts = append(opts, option.WithTokenSource(v))
+// The maximum amount of time a single read against the GCP APIs, including all of its retries, is allowed to take.
+const readTimeout = 2 * time.Minute
+
+// The HTTP status codes of transient GCP API errors which are worth retrying.
+var retryableHTTPCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// withOptions returns the client options that authenticate the Cloud Client Libraries clients of this module with the
+// token source of getConfiguredTokenSourceE, if any.
+func withOptions() ([]option.ClientOption, error) {
+	ts, ok, err := getConfiguredTokenSourceE()
+	if err != nil || !ok {
+		return nil, err
 	}
 
-	return
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+// withReadRetries returns the call options for read-only calls against the REST based Cloud Client Libraries (e.g.
+// Compute Engine). Rate limiting and server errors are retried with an exponential backoff until readTimeout elapses.
+// Mutating calls are not retried, as they are not guaranteed to be idempotent.
+func withReadRetries() []gax.CallOption {
+	return []gax.CallOption{
+		gax.WithTimeout(readTimeout),
+		gax.WithRetry(func() gax.Retryer {
+			return gax.OnHTTPCodes(gax.Backoff{
+				Initial:    time.Second,
+				Max:        30 * time.Second,
+				Multiplier: 2,
+			}, retryableHTTPCodes...)
+		}),
+	}
 }
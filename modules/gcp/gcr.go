@@ -87,7 +87,11 @@ func DeleteGCRImageRefE(t testing.TestingT, ref string) error {
 }
 
 func newGCRAuther() (authn.Authenticator, error) {
-	if ts, ok := getStaticTokenSource(); ok {
+	ts, ok, err := getConfiguredTokenSourceE()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
 		return gcrgoogle.NewTokenSourceAuthenticator(ts), nil
 	}
 
@@ -0,0 +1,205 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	container "cloud.google.com/go/container/apiv1"
+	"cloud.google.com/go/container/apiv1/containerpb"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// GkeClusterAuth contains what a Kubernetes client needs to connect to a GKE cluster.
+type GkeClusterAuth struct {
+	// The URL of the API server of the cluster.
+	Endpoint string
+
+	// The PEM encoded certificate authority of the API server. This is empty for the DNS endpoint, which is served
+	// with a publicly trusted certificate.
+	CertificateAuthorityData []byte
+
+	// An OAuth2 access token for the Google identity of the test. These usually expire after an hour.
+	Token string
+}
+
+// GetGkeCluster returns the GKE cluster with the given name. The location is the region of regional and Autopilot
+// clusters, or the zone of zonal clusters.
+func GetGkeCluster(t testing.TestingT, projectID string, location string, clusterName string) *containerpb.Cluster {
+	cluster, err := GetGkeClusterE(t, projectID, location, clusterName)
+	require.NoError(t, err)
+	return cluster
+}
+
+// GetGkeClusterE returns the GKE cluster with the given name. The location is the region of regional and Autopilot
+// clusters, or the zone of zonal clusters.
+func GetGkeClusterE(t testing.TestingT, projectID string, location string, clusterName string) (*containerpb.Cluster, error) {
+	logger.Default.Logf(t, "Getting GKE cluster %s in location %s", clusterName, location)
+
+	ctx := context.Background()
+	client, err := NewClusterManagerClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	req := &containerpb.GetClusterRequest{
+		Name: fmt.Sprintf("projects/%s/locations/%s/clusters/%s", projectID, location, clusterName),
+	}
+	cluster, err := client.GetCluster(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("ClusterManager.GetCluster(%s) got error: %v", req.Name, err)
+	}
+
+	return cluster, nil
+}
+
+// GetGkeClusterAuth returns the endpoint, certificate authority and a token to connect to the given GKE cluster. This
+// will fail the test if there is an error.
+func GetGkeClusterAuth(t testing.TestingT, projectID string, location string, clusterName string) GkeClusterAuth {
+	auth, err := GetGkeClusterAuthE(t, projectID, location, clusterName)
+	require.NoError(t, err)
+	return auth
+}
+
+// GetGkeClusterAuthE returns the endpoint, certificate authority and a token to connect to the given GKE cluster. The
+// token is fetched from the credentials of NewTokenSourceE, which, like gke-gcloud-auth-plugin, fall back to the
+// application default credentials (including the GKE metadata server when the test runs on a Workload Identity enabled
+// node pool), so the plugin does not need to be installed. See gkeEndpoint for how the endpoint is chosen.
+func GetGkeClusterAuthE(t testing.TestingT, projectID string, location string, clusterName string) (GkeClusterAuth, error) {
+	cluster, err := GetGkeClusterE(t, projectID, location, clusterName)
+	if err != nil {
+		return GkeClusterAuth{}, err
+	}
+
+	endpoint, caData, err := gkeEndpoint(cluster)
+	if err != nil {
+		return GkeClusterAuth{}, err
+	}
+
+	token, err := getGkeTokenE()
+	if err != nil {
+		return GkeClusterAuth{}, err
+	}
+
+	return GkeClusterAuth{
+		Endpoint:                 endpoint,
+		CertificateAuthorityData: caData,
+		Token:                    token,
+	}, nil
+}
+
+// NewKubectlOptionsForGkeCluster returns KubectlOptions to use the given GKE cluster with the Google identity of the
+// test. This will fail the test if there is an error.
+func NewKubectlOptionsForGkeCluster(t testing.TestingT, projectID string, location string, clusterName string) *k8s.KubectlOptions {
+	options, err := NewKubectlOptionsForGkeClusterE(t, projectID, location, clusterName)
+	require.NoError(t, err)
+	return options
+}
+
+// NewKubectlOptionsForGkeClusterE returns KubectlOptions to use the given GKE cluster (e.g., one created by terraform),
+// Standard or Autopilot, with the Google identity of the test, without shelling out to `gcloud container clusters
+// get-credentials`. This writes a kubeconfig to a temp file, with a context named the same way gcloud names it, which
+// works both for the Kubernetes client and for kubectl. Note that the token expires after about an hour: call this
+// function again to refresh it in long running tests. The namespace of the returned options is empty; set it as needed.
+func NewKubectlOptionsForGkeClusterE(t testing.TestingT, projectID string, location string, clusterName string) (*k8s.KubectlOptions, error) {
+	auth, err := GetGkeClusterAuthE(t, projectID, location, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	contextName := gkeContextName(projectID, location, clusterName)
+	configData, err := clientcmd.Write(gkeKubeConfig(contextName, auth))
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := k8s.StoreConfigToTempFileE(t, string(configData))
+	if err != nil {
+		return nil, err
+	}
+
+	return k8s.NewKubectlOptions(contextName, configPath, ""), nil
+}
+
+// NewClusterManagerClient creates a new GKE Cluster Manager client, which is used to make GKE API calls. The caller is
+// responsible for closing the client.
+func NewClusterManagerClient(t testing.TestingT) *container.ClusterManagerClient {
+	client, err := NewClusterManagerClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewClusterManagerClientE creates a new GKE Cluster Manager client, which is used to make GKE API calls. The caller is
+// responsible for closing the client.
+func NewClusterManagerClientE(t testing.TestingT) (*container.ClusterManagerClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return container.NewClusterManagerClient(context.Background(), opts...)
+}
+
+// gkeEndpoint returns the URL and the certificate authority of the API server of the given GKE cluster. The DNS
+// endpoint is preferred when it accepts external traffic, as it is the only endpoint of private clusters which is
+// reachable from outside of their VPC. Otherwise, private clusters without a public endpoint are reached on their
+// private IP, and all other clusters on their public IP, as gcloud does.
+func gkeEndpoint(cluster *containerpb.Cluster) (string, []byte, error) {
+	dnsEndpoint := cluster.GetControlPlaneEndpointsConfig().GetDnsEndpointConfig()
+	if dnsEndpoint.GetEndpoint() != "" && dnsEndpoint.GetAllowExternalTraffic() {
+		return "https://" + dnsEndpoint.GetEndpoint(), nil, nil
+	}
+
+	caData, err := base64.StdEncoding.DecodeString(cluster.GetMasterAuth().GetClusterCaCertificate())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode the certificate authority of GKE cluster %s: %v", cluster.GetName(), err)
+	}
+
+	endpoint := cluster.GetEndpoint()
+	if privateConfig := cluster.GetPrivateClusterConfig(); privateConfig.GetEnablePrivateEndpoint() && privateConfig.GetPrivateEndpoint() != "" {
+		endpoint = privateConfig.GetPrivateEndpoint()
+	}
+	if endpoint == "" {
+		return "", nil, fmt.Errorf("GKE cluster %s has no endpoint, it may still be provisioning", cluster.GetName())
+	}
+
+	return "https://" + endpoint, caData, nil
+}
+
+// gkeContextName returns the name gcloud gives to the kubeconfig context of the given GKE cluster.
+func gkeContextName(projectID string, location string, clusterName string) string {
+	return fmt.Sprintf("gke_%s_%s_%s", projectID, location, clusterName)
+}
+
+// gkeKubeConfig returns a kubeconfig with a single context, with the given name, to connect to a GKE cluster with the
+// given auth.
+func gkeKubeConfig(contextName string, auth GkeClusterAuth) api.Config {
+	config := api.NewConfig()
+	config.Clusters[contextName] = &api.Cluster{
+		Server:                   auth.Endpoint,
+		CertificateAuthorityData: auth.CertificateAuthorityData,
+	}
+	config.AuthInfos[contextName] = &api.AuthInfo{Token: auth.Token}
+	k8s.UpsertConfigContext(config, contextName, contextName, contextName)
+	config.CurrentContext = contextName
+	return *config
+}
+
+// getGkeTokenE returns an access token for the Google identity of the test, which GKE accepts.
+func getGkeTokenE() (string, error) {
+	ts, err := NewTokenSourceWithOptionsE(authOptionsFromEnv())
+	if err != nil {
+		return "", err
+	}
+
+	token, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
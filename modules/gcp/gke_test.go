@@ -0,0 +1,92 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"cloud.google.com/go/container/apiv1/containerpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGkeEndpoint(t *testing.T) {
+	t.Parallel()
+
+	caData := []byte("-----BEGIN CERTIFICATE-----")
+	masterAuth := &containerpb.MasterAuth{ClusterCaCertificate: base64.StdEncoding.EncodeToString(caData)}
+
+	testCases := []struct {
+		name             string
+		cluster          *containerpb.Cluster
+		expectedEndpoint string
+		expectedCAData   []byte
+	}{
+		{
+			"public",
+			&containerpb.Cluster{Endpoint: "34.1.2.3", MasterAuth: masterAuth},
+			"https://34.1.2.3",
+			caData,
+		},
+		{
+			"private",
+			&containerpb.Cluster{
+				Endpoint:             "34.1.2.3",
+				MasterAuth:           masterAuth,
+				PrivateClusterConfig: &containerpb.PrivateClusterConfig{EnablePrivateEndpoint: true, PrivateEndpoint: "10.0.0.2"},
+			},
+			"https://10.0.0.2",
+			caData,
+		},
+		{
+			"dns endpoint",
+			&containerpb.Cluster{
+				Endpoint:             "10.0.0.2",
+				MasterAuth:           masterAuth,
+				PrivateClusterConfig: &containerpb.PrivateClusterConfig{EnablePrivateEndpoint: true, PrivateEndpoint: "10.0.0.2"},
+				ControlPlaneEndpointsConfig: &containerpb.ControlPlaneEndpointsConfig{
+					DnsEndpointConfig: &containerpb.ControlPlaneEndpointsConfig_DNSEndpointConfig{
+						Endpoint:             "gke-0123456789abcdef.us-central1.gke.goog",
+						AllowExternalTraffic: proto.Bool(true),
+					},
+				},
+			},
+			"https://gke-0123456789abcdef.us-central1.gke.goog",
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			endpoint, actualCAData, err := gkeEndpoint(tc.cluster)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedEndpoint, endpoint)
+			assert.Equal(t, tc.expectedCAData, actualCAData)
+		})
+	}
+
+	_, _, err := gkeEndpoint(&containerpb.Cluster{Name: "provisioning"})
+	require.Error(t, err)
+}
+
+func TestGkeKubeConfig(t *testing.T) {
+	t.Parallel()
+
+	contextName := gkeContextName("my-project", "us-central1", "autopilot-cluster")
+	assert.Equal(t, "gke_my-project_us-central1_autopilot-cluster", contextName)
+
+	config := gkeKubeConfig(contextName, GkeClusterAuth{Endpoint: "https://34.1.2.3", CertificateAuthorityData: []byte("ca"), Token: "token"})
+	assert.Equal(t, contextName, config.CurrentContext)
+	assert.Equal(t, "https://34.1.2.3", config.Clusters[contextName].Server)
+	assert.Equal(t, []byte("ca"), config.Clusters[contextName].CertificateAuthorityData)
+	assert.Equal(t, "token", config.AuthInfos[contextName].Token)
+	assert.Equal(t, contextName, config.Contexts[contextName].Cluster)
+}
@@ -0,0 +1,514 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	admin "cloud.google.com/go/iam/admin/apiv1"
+	"cloud.google.com/go/iam/admin/apiv1/adminpb"
+	"cloud.google.com/go/iam/apiv1/iampb"
+	resourcemanager "cloud.google.com/go/resourcemanager/apiv3"
+	"cloud.google.com/go/resourcemanager/apiv3/resourcemanagerpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/expr"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// The IAM policy version that includes conditional role bindings. Policies requested with a lower version omit the
+// conditions of their bindings.
+const iamPolicyVersionWithConditions = 3
+
+// ResourceIAMPolicy is the IAM policy set on a resource of the resource hierarchy.
+type ResourceIAMPolicy struct {
+	// The full name of the resource, e.g. projects/my-project, folders/1234 or organizations/5678.
+	Resource string
+
+	Policy *iampb.Policy
+}
+
+// GetProjectIAMPolicy returns the IAM policy set on the given project, including conditional role bindings.
+func GetProjectIAMPolicy(t testing.TestingT, projectID string) *iampb.Policy {
+	policy, err := GetProjectIAMPolicyE(t, projectID)
+	require.NoError(t, err)
+	return policy
+}
+
+// GetProjectIAMPolicyE returns the IAM policy set on the given project, including conditional role bindings. Role
+// bindings inherited from its folders and organization are not included: see GetEffectiveProjectIAMPoliciesE.
+func GetProjectIAMPolicyE(t testing.TestingT, projectID string) (*iampb.Policy, error) {
+	logger.Default.Logf(t, "Getting IAM policy of project %s", projectID)
+
+	ctx := context.Background()
+	client, err := NewProjectsClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.GetIamPolicy(ctx, newGetIAMPolicyRequest("projects/"+projectID))
+}
+
+// GetFolderIAMPolicy returns the IAM policy set on the folder with the given numeric ID, including conditional role
+// bindings.
+func GetFolderIAMPolicy(t testing.TestingT, folderID string) *iampb.Policy {
+	policy, err := GetFolderIAMPolicyE(t, folderID)
+	require.NoError(t, err)
+	return policy
+}
+
+// GetFolderIAMPolicyE returns the IAM policy set on the folder with the given numeric ID, including conditional role
+// bindings.
+func GetFolderIAMPolicyE(t testing.TestingT, folderID string) (*iampb.Policy, error) {
+	logger.Default.Logf(t, "Getting IAM policy of folder %s", folderID)
+
+	ctx := context.Background()
+	client, err := NewFoldersClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.GetIamPolicy(ctx, newGetIAMPolicyRequest("folders/"+strings.TrimPrefix(folderID, "folders/")))
+}
+
+// GetServiceAccountIAMPolicy returns the IAM policy set on the given service account, which controls who can use,
+// impersonate and manage it.
+func GetServiceAccountIAMPolicy(t testing.TestingT, projectID string, email string) *iampb.Policy {
+	policy, err := GetServiceAccountIAMPolicyE(t, projectID, email)
+	require.NoError(t, err)
+	return policy
+}
+
+// GetServiceAccountIAMPolicyE returns the IAM policy set on the given service account, which controls who can use,
+// impersonate and manage it.
+func GetServiceAccountIAMPolicyE(t testing.TestingT, projectID string, email string) (*iampb.Policy, error) {
+	logger.Default.Logf(t, "Getting IAM policy of service account %s", email)
+
+	ctx := context.Background()
+	client, err := NewIAMAdminClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	policy, err := client.GetIamPolicy(ctx, newGetIAMPolicyRequest(serviceAccountName(projectID, email)))
+	if err != nil {
+		return nil, err
+	}
+	return policy.InternalProto, nil
+}
+
+// GetEffectiveProjectIAMPolicies returns the IAM policies that apply to the given project: its own, then those of its
+// ancestor folders, from the closest one, and of its organization.
+func GetEffectiveProjectIAMPolicies(t testing.TestingT, projectID string) []ResourceIAMPolicy {
+	policies, err := GetEffectiveProjectIAMPoliciesE(t, projectID)
+	require.NoError(t, err)
+	return policies
+}
+
+// GetEffectiveProjectIAMPoliciesE returns the IAM policies that apply to the given project: its own, then those of its
+// ancestor folders, from the closest one, and of its organization. A role binding in any of them applies to the
+// project. This requires permission to get the IAM policies of the ancestors, e.g. the Folder IAM Admin role. IAM deny
+// policies and Principal Access Boundary policies are not taken into account.
+func GetEffectiveProjectIAMPoliciesE(t testing.TestingT, projectID string) ([]ResourceIAMPolicy, error) {
+	logger.Default.Logf(t, "Getting effective IAM policies of project %s", projectID)
+
+	ctx := context.Background()
+	projectsClient, err := NewProjectsClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer projectsClient.Close()
+
+	foldersClient, err := NewFoldersClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer foldersClient.Close()
+
+	organizationsClient, err := NewOrganizationsClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer organizationsClient.Close()
+
+	project, err := projectsClient.GetProject(ctx, &resourcemanagerpb.GetProjectRequest{Name: "projects/" + projectID})
+	if err != nil {
+		return nil, err
+	}
+	policy, err := projectsClient.GetIamPolicy(ctx, newGetIAMPolicyRequest(project.GetName()))
+	if err != nil {
+		return nil, err
+	}
+	policies := []ResourceIAMPolicy{{Resource: project.GetName(), Policy: policy}}
+
+	for parent := project.GetParent(); parent != ""; {
+		switch {
+		case strings.HasPrefix(parent, "folders/"):
+			folder, err := foldersClient.GetFolder(ctx, &resourcemanagerpb.GetFolderRequest{Name: parent})
+			if err != nil {
+				return nil, err
+			}
+			policy, err := foldersClient.GetIamPolicy(ctx, newGetIAMPolicyRequest(parent))
+			if err != nil {
+				return nil, err
+			}
+			policies = append(policies, ResourceIAMPolicy{Resource: parent, Policy: policy})
+			parent = folder.GetParent()
+
+		case strings.HasPrefix(parent, "organizations/"):
+			policy, err := organizationsClient.GetIamPolicy(ctx, newGetIAMPolicyRequest(parent))
+			if err != nil {
+				return nil, err
+			}
+			policies = append(policies, ResourceIAMPolicy{Resource: parent, Policy: policy})
+			parent = ""
+
+		default:
+			return nil, fmt.Errorf("unexpected parent %s in the resource hierarchy of project %s", parent, projectID)
+		}
+	}
+
+	return policies, nil
+}
+
+// AssertIAMPolicyBinding checks that the given IAM policy binds the given role to the given member (e.g.
+// "serviceAccount:ci@my-project.iam.gserviceaccount.com") with the given condition, or without any condition if it is
+// nil, and fails the test if it does not.
+func AssertIAMPolicyBinding(t testing.TestingT, policy *iampb.Policy, role string, member string, condition *expr.Expr) {
+	err := AssertIAMPolicyBindingE(t, policy, role, member, condition)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertIAMPolicyBindingE checks that the given IAM policy binds the given role to the given member with the given
+// condition, or without any condition if it is nil, and returns an error if it does not. Conditions match if they
+// have the same expression, and the same title unless the title of the given condition is empty.
+func AssertIAMPolicyBindingE(t testing.TestingT, policy *iampb.Policy, role string, member string, condition *expr.Expr) error {
+	if !hasIAMBindingWithCondition(policy, role, member, condition) {
+		return fmt.Errorf("IAM policy does not grant role %s to %s %s", role, member, describeIAMCondition(condition))
+	}
+	return nil
+}
+
+// AssertIAMPolicyBindingAbsent checks that the given IAM policy does not bind the given role to the given member (e.g.
+// "allUsers"), with or without a condition, and fails the test if it does.
+func AssertIAMPolicyBindingAbsent(t testing.TestingT, policy *iampb.Policy, role string, member string) {
+	err := AssertIAMPolicyBindingAbsentE(t, policy, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertIAMPolicyBindingAbsentE checks that the given IAM policy does not bind the given role to the given member,
+// with or without a condition, and returns an error if it does.
+func AssertIAMPolicyBindingAbsentE(t testing.TestingT, policy *iampb.Policy, role string, member string) error {
+	if hasIAMBinding(policy, role, member) {
+		return fmt.Errorf("IAM policy grants role %s to %s", role, member)
+	}
+	return nil
+}
+
+// AssertProjectIAMBinding checks that the IAM policy of the given project binds the given role to the given member
+// with the given condition, or without any condition if it is nil, and fails the test if it does not.
+func AssertProjectIAMBinding(t testing.TestingT, projectID string, role string, member string, condition *expr.Expr) {
+	err := AssertProjectIAMBindingE(t, projectID, role, member, condition)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertProjectIAMBindingE checks that the IAM policy of the given project binds the given role to the given member
+// with the given condition, or without any condition if it is nil, and returns an error if it does not.
+func AssertProjectIAMBindingE(t testing.TestingT, projectID string, role string, member string, condition *expr.Expr) error {
+	policy, err := GetProjectIAMPolicyE(t, projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := AssertIAMPolicyBindingE(t, policy, role, member, condition); err != nil {
+		return fmt.Errorf("project %s: %v", projectID, err)
+	}
+	return nil
+}
+
+// AssertProjectIAMBindingAbsent checks that the given role is not granted to the given member on the given project,
+// neither directly nor through its folders and organization, and fails the test if it is.
+func AssertProjectIAMBindingAbsent(t testing.TestingT, projectID string, role string, member string) {
+	err := AssertProjectIAMBindingAbsentE(t, projectID, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertProjectIAMBindingAbsentE checks that the given role is not granted to the given member on the given project,
+// neither directly nor through its folders and organization, and returns an error naming the resource that grants it
+// if it is.
+func AssertProjectIAMBindingAbsentE(t testing.TestingT, projectID string, role string, member string) error {
+	policies, err := GetEffectiveProjectIAMPoliciesE(t, projectID)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if hasIAMBinding(policy.Policy, role, member) {
+			return fmt.Errorf("%s grants role %s to %s, which applies to project %s", policy.Resource, role, member, projectID)
+		}
+	}
+	return nil
+}
+
+// AssertEffectiveProjectIAMBinding checks that the given role is granted to the given member on the given project,
+// directly or through its folders and organization, with the given condition, or without any condition if it is nil,
+// and fails the test if it is not.
+func AssertEffectiveProjectIAMBinding(t testing.TestingT, projectID string, role string, member string, condition *expr.Expr) {
+	err := AssertEffectiveProjectIAMBindingE(t, projectID, role, member, condition)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertEffectiveProjectIAMBindingE checks that the given role is granted to the given member on the given project,
+// directly or through its folders and organization, with the given condition, or without any condition if it is nil,
+// and returns an error if it is not.
+func AssertEffectiveProjectIAMBindingE(t testing.TestingT, projectID string, role string, member string, condition *expr.Expr) error {
+	policies, err := GetEffectiveProjectIAMPoliciesE(t, projectID)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if hasIAMBindingWithCondition(policy.Policy, role, member, condition) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Neither project %s nor its ancestors grant role %s to %s %s", projectID, role, member, describeIAMCondition(condition))
+}
+
+// GetServiceAccountKeys returns the user-managed keys of the given service account.
+func GetServiceAccountKeys(t testing.TestingT, projectID string, email string) []*adminpb.ServiceAccountKey {
+	keys, err := GetServiceAccountKeysE(t, projectID, email)
+	require.NoError(t, err)
+	return keys
+}
+
+// GetServiceAccountKeysE returns the user-managed keys of the given service account, i.e. the keys that were created
+// for it and can be downloaded, as opposed to the keys Google manages for it.
+func GetServiceAccountKeysE(t testing.TestingT, projectID string, email string) ([]*adminpb.ServiceAccountKey, error) {
+	logger.Default.Logf(t, "Getting keys of service account %s", email)
+
+	ctx := context.Background()
+	client, err := NewIAMAdminClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	resp, err := client.ListServiceAccountKeys(ctx, &adminpb.ListServiceAccountKeysRequest{
+		Name:     serviceAccountName(projectID, email),
+		KeyTypes: []adminpb.ListServiceAccountKeysRequest_KeyType{adminpb.ListServiceAccountKeysRequest_USER_MANAGED},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetKeys(), nil
+}
+
+// AssertServiceAccountHasNoKeys checks that the given service account has no enabled user-managed keys, e.g. because
+// workloads authenticate as it through Workload Identity instead, and fails the test if it does.
+func AssertServiceAccountHasNoKeys(t testing.TestingT, projectID string, email string) {
+	err := AssertServiceAccountHasNoKeysE(t, projectID, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertServiceAccountHasNoKeysE checks that the given service account has no enabled user-managed keys, and returns
+// an error listing them if it does.
+func AssertServiceAccountHasNoKeysE(t testing.TestingT, projectID string, email string) error {
+	keys, err := GetServiceAccountKeysE(t, projectID, email)
+	if err != nil {
+		return err
+	}
+
+	keyIDs := []string{}
+	for _, key := range keys {
+		if !key.GetDisabled() {
+			keyIDs = append(keyIDs, key.GetName()[strings.LastIndex(key.GetName(), "/")+1:])
+		}
+	}
+	if len(keyIDs) > 0 {
+		return fmt.Errorf("Service account %s has user-managed keys %s", email, strings.Join(keyIDs, ", "))
+	}
+	return nil
+}
+
+// AssertCanImpersonateServiceAccount checks that the identity of the test can impersonate the given service account,
+// and fails the test if it cannot.
+func AssertCanImpersonateServiceAccount(t testing.TestingT, email string) {
+	err := AssertCanImpersonateServiceAccountE(t, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertCanImpersonateServiceAccountE checks that the identity of the test can impersonate the given service account,
+// by requesting an access token for it, which requires the Service Account Token Creator role on it, and returns an
+// error if it cannot. GOOGLE_IMPERSONATE_SERVICE_ACCOUNT is ignored, so that the check is made with the identity the
+// test authenticates as.
+func AssertCanImpersonateServiceAccountE(t testing.TestingT, email string) error {
+	logger.Default.Logf(t, "Impersonating service account %s", email)
+
+	authOptions := authOptionsFromEnv()
+	authOptions.ImpersonateServiceAccount = email
+
+	ts, err := NewTokenSourceWithOptionsE(authOptions)
+	if err != nil {
+		return err
+	}
+	if _, err := ts.Token(); err != nil {
+		return fmt.Errorf("failed to impersonate service account %s: %v", email, err)
+	}
+	return nil
+}
+
+// NewProjectsClient creates a new Resource Manager Projects client, which is used to make project API calls. The
+// caller is responsible for closing the client.
+func NewProjectsClient(t testing.TestingT) *resourcemanager.ProjectsClient {
+	client, err := NewProjectsClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewProjectsClientE creates a new Resource Manager Projects client, which is used to make project API calls. The
+// caller is responsible for closing the client.
+func NewProjectsClientE(t testing.TestingT) (*resourcemanager.ProjectsClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return resourcemanager.NewProjectsClient(context.Background(), opts...)
+}
+
+// NewFoldersClient creates a new Resource Manager Folders client, which is used to make folder API calls. The caller
+// is responsible for closing the client.
+func NewFoldersClient(t testing.TestingT) *resourcemanager.FoldersClient {
+	client, err := NewFoldersClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewFoldersClientE creates a new Resource Manager Folders client, which is used to make folder API calls. The caller
+// is responsible for closing the client.
+func NewFoldersClientE(t testing.TestingT) (*resourcemanager.FoldersClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return resourcemanager.NewFoldersClient(context.Background(), opts...)
+}
+
+// NewOrganizationsClient creates a new Resource Manager Organizations client, which is used to make organization API
+// calls. The caller is responsible for closing the client.
+func NewOrganizationsClient(t testing.TestingT) *resourcemanager.OrganizationsClient {
+	client, err := NewOrganizationsClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewOrganizationsClientE creates a new Resource Manager Organizations client, which is used to make organization API
+// calls. The caller is responsible for closing the client.
+func NewOrganizationsClientE(t testing.TestingT) (*resourcemanager.OrganizationsClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return resourcemanager.NewOrganizationsClient(context.Background(), opts...)
+}
+
+// NewIAMAdminClient creates a new IAM Admin client, which is used to make service account API calls. The caller is
+// responsible for closing the client.
+func NewIAMAdminClient(t testing.TestingT) *admin.IamClient {
+	client, err := NewIAMAdminClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewIAMAdminClientE creates a new IAM Admin client, which is used to make service account API calls. The caller is
+// responsible for closing the client.
+func NewIAMAdminClientE(t testing.TestingT) (*admin.IamClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return admin.NewIamClient(context.Background(), opts...)
+}
+
+// newGetIAMPolicyRequest returns a request for the IAM policy of the given resource, including conditional bindings.
+func newGetIAMPolicyRequest(resource string) *iampb.GetIamPolicyRequest {
+	return &iampb.GetIamPolicyRequest{
+		Resource: resource,
+		Options:  &iampb.GetPolicyOptions{RequestedPolicyVersion: iamPolicyVersionWithConditions},
+	}
+}
+
+// hasIAMBinding returns true if the given IAM policy binds the given role to the given member, with or without a
+// condition.
+func hasIAMBinding(policy *iampb.Policy, role string, member string) bool {
+	for _, binding := range policy.GetBindings() {
+		if binding.GetRole() == role && hasIAMMember(binding, member) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIAMBindingWithCondition returns true if the given IAM policy binds the given role to the given member with the
+// given condition, or without any condition if it is nil. See AssertIAMPolicyBindingE for how conditions match.
+func hasIAMBindingWithCondition(policy *iampb.Policy, role string, member string, condition *expr.Expr) bool {
+	for _, binding := range policy.GetBindings() {
+		if binding.GetRole() == role && hasIAMMember(binding, member) && iamConditionMatches(binding.GetCondition(), condition) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIAMMember returns true if the given binding includes the given member.
+func hasIAMMember(binding *iampb.Binding, member string) bool {
+	for _, m := range binding.GetMembers() {
+		if m == member {
+			return true
+		}
+	}
+	return false
+}
+
+// iamConditionMatches returns true if the condition of a binding matches the expected condition.
+func iamConditionMatches(actual *expr.Expr, expected *expr.Expr) bool {
+	if expected == nil || actual == nil {
+		return expected == nil && actual == nil
+	}
+	if expected.GetTitle() != "" && expected.GetTitle() != actual.GetTitle() {
+		return false
+	}
+	return strings.TrimSpace(expected.GetExpression()) == strings.TrimSpace(actual.GetExpression())
+}
+
+// describeIAMCondition describes the given condition for error messages.
+func describeIAMCondition(condition *expr.Expr) string {
+	if condition == nil {
+		return "without condition"
+	}
+	return fmt.Sprintf("with condition %q", condition.GetExpression())
+}
+
+// serviceAccountName returns the full name of the given service account.
+func serviceAccountName(projectID string, email string) string {
+	return fmt.Sprintf("projects/%s/serviceAccounts/%s", projectID, email)
+}
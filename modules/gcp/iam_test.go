@@ -0,0 +1,49 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/iam/apiv1/iampb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/type/expr"
+)
+
+func TestAssertIAMPolicyBinding(t *testing.T) {
+	t.Parallel()
+
+	expiry := &expr.Expr{Title: "expires", Expression: `request.time < timestamp("2030-01-01T00:00:00Z")`}
+	policy := &iampb.Policy{
+		Version: 3,
+		Bindings: []*iampb.Binding{
+			{Role: "roles/viewer", Members: []string{"group:devs@example.com", "serviceAccount:ci@my-project.iam.gserviceaccount.com"}},
+			{Role: "roles/editor", Members: []string{"user:jane@example.com"}, Condition: expiry},
+		},
+	}
+
+	assert.NoError(t, AssertIAMPolicyBindingE(t, policy, "roles/viewer", "serviceAccount:ci@my-project.iam.gserviceaccount.com", nil))
+	assert.NoError(t, AssertIAMPolicyBindingE(t, policy, "roles/editor", "user:jane@example.com", expiry))
+	assert.NoError(t, AssertIAMPolicyBindingE(t, policy, "roles/editor", "user:jane@example.com", &expr.Expr{Expression: expiry.Expression}))
+	assert.Error(t, AssertIAMPolicyBindingE(t, policy, "roles/editor", "user:jane@example.com", nil))
+	assert.Error(t, AssertIAMPolicyBindingE(t, policy, "roles/editor", "user:jane@example.com", &expr.Expr{Title: "other", Expression: expiry.Expression}))
+	assert.Error(t, AssertIAMPolicyBindingE(t, policy, "roles/viewer", "user:jane@example.com", nil))
+
+	assert.NoError(t, AssertIAMPolicyBindingAbsentE(t, policy, "roles/owner", "user:jane@example.com"))
+	assert.Error(t, AssertIAMPolicyBindingAbsentE(t, policy, "roles/editor", "user:jane@example.com"))
+}
+
+func TestGetEffectiveProjectIAMPolicies(t *testing.T) {
+	t.Parallel()
+
+	projectID := GetGoogleProjectIDFromEnvVar(t)
+
+	policies := GetEffectiveProjectIAMPolicies(t, projectID)
+	if assert.NotEmpty(t, policies) {
+		assert.True(t, strings.HasPrefix(policies[0].Resource, "projects/"))
+	}
+}
@@ -4,13 +4,12 @@ import (
 	"context"
 	"fmt"
 
+	oslogin "cloud.google.com/go/oslogin/apiv1"
+	"cloud.google.com/go/oslogin/apiv1/osloginpb"
+	"cloud.google.com/go/oslogin/common/commonpb"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	"google.golang.org/api/compute/v1"
-	"google.golang.org/api/oslogin/v1"
 )
 
 // ImportSSHKey will import an SSH key to GCP under the provided user identity.
@@ -49,22 +48,22 @@ func importProjectSSHKeyE(t testing.TestingT, user, key string, projectID *strin
 	logger.Default.Logf(t, "Importing SSH key for user %s", user)
 
 	ctx := context.Background()
-	service, err := NewOSLoginServiceE(t)
+	client, err := NewOSLoginClientE(t)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	parent := fmt.Sprintf("users/%s", user)
-
-	sshPublicKey := &oslogin.SshPublicKey{
-		Key: key,
+	req := &osloginpb.ImportSshPublicKeyRequest{
+		Parent: fmt.Sprintf("users/%s", user),
+		SshPublicKey: &commonpb.SshPublicKey{
+			Key: key,
+		},
 	}
-
-	req := service.Users.ImportSshPublicKey(parent, sshPublicKey)
 	if projectID != nil {
-		req = req.ProjectId(*projectID)
+		req.ProjectId = *projectID
 	}
-	_, err = req.Context(ctx).Do()
+	_, err = client.ImportSshPublicKey(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -87,17 +86,18 @@ func DeleteSSHKeyE(t testing.TestingT, user, key string) error {
 	logger.Default.Logf(t, "Deleting SSH key for user %s", user)
 
 	ctx := context.Background()
-	service, err := NewOSLoginServiceE(t)
+	client, err := NewOSLoginClientE(t)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
 	loginProfile := GetLoginProfile(t, user)
 
 	for _, v := range loginProfile.SshPublicKeys {
 		if key == v.Key {
-			path := fmt.Sprintf("users/%s/sshPublicKeys/%s", user, v.Fingerprint)
-			_, err = service.Users.SshPublicKeys.Delete(path).Context(ctx).Do()
+			name := fmt.Sprintf("users/%s/sshPublicKeys/%s", user, v.Fingerprint)
+			err = client.DeleteSshPublicKey(ctx, &osloginpb.DeleteSshPublicKeyRequest{Name: name})
 			break
 		}
 	}
@@ -113,7 +113,7 @@ func DeleteSSHKeyE(t testing.TestingT, user, key string) error {
 // accounts the user will appear as. Generally, this will only be the OS Login key + account, but `gcloud compute ssh` could create temporary keys and profiles.
 // The `user` parameter should be the email address of the user.
 // This will fail the test if there is an error.
-func GetLoginProfile(t testing.TestingT, user string) *oslogin.LoginProfile {
+func GetLoginProfile(t testing.TestingT, user string) *osloginpb.LoginProfile {
 	profile, err := GetLoginProfileE(t, user)
 	require.NoErrorf(t, err, "Could not get login profile for user %s", user)
 
@@ -123,18 +123,21 @@ func GetLoginProfile(t testing.TestingT, user string) *oslogin.LoginProfile {
 // GetLoginProfileE will retrieve the login profile for a user's Google identity. The login profile is a combination of OS Login + gcloud SSH keys and POSIX
 // accounts the user will appear as. Generally, this will only be the OS Login key + account, but `gcloud compute ssh` could create temporary keys and profiles.
 // The `user` parameter should be the email address of the user.
-func GetLoginProfileE(t testing.TestingT, user string) (*oslogin.LoginProfile, error) {
+func GetLoginProfileE(t testing.TestingT, user string) (*osloginpb.LoginProfile, error) {
 	logger.Default.Logf(t, "Getting login profile for user %s", user)
 
 	ctx := context.Background()
-	service, err := NewOSLoginServiceE(t)
+	client, err := NewOSLoginClientE(t)
 	if err != nil {
 		return nil, err
 	}
+	defer client.Close()
 
-	name := fmt.Sprintf("users/%s", user)
+	req := &osloginpb.GetLoginProfileRequest{
+		Name: fmt.Sprintf("users/%s", user),
+	}
 
-	profile, err := service.Users.GetLoginProfile(name).Context(ctx).Do()
+	profile, err := client.GetLoginProfile(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -142,23 +145,26 @@ func GetLoginProfileE(t testing.TestingT, user string) (*oslogin.LoginProfile, e
 	return profile, nil
 }
 
-// NewOSLoginServiceE creates a new OS Login service, which is used to make OS Login API calls.
-func NewOSLoginServiceE(t testing.TestingT) (*oslogin.Service, error) {
-	ctx := context.Background()
-
-	if ts, ok := getStaticTokenSource(); ok {
-		return oslogin.New(oauth2.NewClient(ctx, ts))
-	}
+// NewOSLoginClient creates a new OS Login client, which is used to make OS Login API calls. The caller is responsible
+// for closing the client.
+func NewOSLoginClient(t testing.TestingT) *oslogin.Client {
+	client, err := NewOSLoginClientE(t)
+	require.NoError(t, err)
+	return client
+}
 
-	client, err := google.DefaultClient(ctx, compute.CloudPlatformScope)
+// NewOSLoginClientE creates a new OS Login client, which is used to make OS Login API calls. The caller is responsible
+// for closing the client. The client retries transient errors using the default retry settings of the OS Login API.
+func NewOSLoginClientE(t testing.TestingT) (*oslogin.Client, error) {
+	opts, err := withOptions()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get default client: %v", err)
+		return nil, err
 	}
 
-	service, err := oslogin.New(client)
+	client, err := oslogin.NewClient(context.Background(), opts...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Failed to create OS Login client: %v", err)
 	}
 
-	return service, nil
+	return client, nil
 }
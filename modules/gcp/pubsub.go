@@ -0,0 +1,420 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	// The number of messages PullPubSubMessagesE pulls if PubSubPullOptions.MaxMessages is not set.
+	defaultPubSubPullMaxMessages = 10
+
+	// How long PullPubSubMessagesE waits for messages if PubSubPullOptions.MaxWait is not set.
+	defaultPubSubPullMaxWait = 30 * time.Second
+
+	// The attribute VerifyPubSubTopicDeliveryE tags its message with, to recognize it among other messages.
+	pubSubDeliveryCheckAttribute = "terratest-delivery-check"
+)
+
+// PubSubMessage is a message published to, or pulled from, Pub/Sub.
+type PubSubMessage struct {
+	// The ID Pub/Sub assigned to the message. Ignored when publishing.
+	ID string
+
+	Data        string
+	Attributes  map[string]string
+	OrderingKey string
+
+	// When Pub/Sub received the message. Ignored when publishing.
+	PublishTime time.Time
+
+	// How many times the message was delivered, which Pub/Sub only tracks for subscriptions with a dead-letter
+	// policy. Ignored when publishing.
+	DeliveryAttempt int
+}
+
+// PubSubPullOptions are the options of PullPubSubMessagesE.
+type PubSubPullOptions struct {
+	// The maximum number of messages to pull. Defaults to 10.
+	MaxMessages int
+
+	// How long to wait for MaxMessages messages before returning the messages pulled so far. Defaults to 30 seconds.
+	MaxWait time.Duration
+
+	// Negatively acknowledge the pulled messages, so that Pub/Sub redelivers them (and eventually forwards them to the
+	// dead-letter topic), instead of acknowledging them.
+	Nack bool
+}
+
+// NewPubSubClient creates a new Pub/Sub client for the given project. The caller is responsible for closing the
+// client.
+func NewPubSubClient(t testing.TestingT, projectID string) *pubsub.Client {
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewPubSubClientE creates a new Pub/Sub client for the given project. The caller is responsible for closing the
+// client.
+func NewPubSubClientE(t testing.TestingT, projectID string) (*pubsub.Client, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return pubsub.NewClient(context.Background(), projectID, opts...)
+}
+
+// CreatePubSubTopic creates a Pub/Sub topic with the given ID.
+func CreatePubSubTopic(t testing.TestingT, projectID string, topicID string) {
+	err := CreatePubSubTopicE(t, projectID, topicID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CreatePubSubTopicE creates a Pub/Sub topic with the given ID.
+func CreatePubSubTopicE(t testing.TestingT, projectID string, topicID string) error {
+	logger.Default.Logf(t, "Creating Pub/Sub topic %s", topicID)
+
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.CreateTopic(context.Background(), topicID)
+	return err
+}
+
+// DeletePubSubTopic deletes the Pub/Sub topic with the given ID.
+func DeletePubSubTopic(t testing.TestingT, projectID string, topicID string) {
+	err := DeletePubSubTopicE(t, projectID, topicID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// DeletePubSubTopicE deletes the Pub/Sub topic with the given ID.
+func DeletePubSubTopicE(t testing.TestingT, projectID string, topicID string) error {
+	logger.Default.Logf(t, "Deleting Pub/Sub topic %s", topicID)
+
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Topic(topicID).Delete(context.Background())
+}
+
+// PublishPubSubMessages publishes the given messages to the given Pub/Sub topic and returns the IDs Pub/Sub assigned
+// to them.
+func PublishPubSubMessages(t testing.TestingT, projectID string, topicID string, messages []PubSubMessage) []string {
+	ids, err := PublishPubSubMessagesE(t, projectID, topicID, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// PublishPubSubMessagesE publishes the given messages to the given Pub/Sub topic and returns the IDs Pub/Sub assigned
+// to them, once all of them are published. Messages with an ordering key are published in order.
+func PublishPubSubMessagesE(t testing.TestingT, projectID string, topicID string, messages []PubSubMessage) ([]string, error) {
+	logger.Default.Logf(t, "Publishing %d messages to Pub/Sub topic %s", len(messages), topicID)
+
+	ctx := context.Background()
+
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	topic := client.Topic(topicID)
+	defer topic.Stop()
+
+	results := make([]*pubsub.PublishResult, 0, len(messages))
+	for _, message := range messages {
+		if message.OrderingKey != "" {
+			topic.EnableMessageOrdering = true
+		}
+		results = append(results, topic.Publish(ctx, &pubsub.Message{
+			Data:        []byte(message.Data),
+			Attributes:  message.Attributes,
+			OrderingKey: message.OrderingKey,
+		}))
+	}
+
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		id, err := result.Get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish message to Pub/Sub topic %s: %v", topicID, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// PullPubSubMessages pulls messages from the given Pub/Sub subscription.
+func PullPubSubMessages(t testing.TestingT, projectID string, subscriptionID string, options PubSubPullOptions) []PubSubMessage {
+	messages, err := PullPubSubMessagesE(t, projectID, subscriptionID, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+// PullPubSubMessagesE pulls up to options.MaxMessages messages from the given Pub/Sub subscription, waiting at most
+// options.MaxWait for them, and acknowledges them, or negatively acknowledges them if options.Nack is set. It returns
+// the pulled messages, which may be fewer than options.MaxMessages, or none, if the wait elapses.
+func PullPubSubMessagesE(t testing.TestingT, projectID string, subscriptionID string, options PubSubPullOptions) ([]PubSubMessage, error) {
+	options = withPubSubPullDefaults(options)
+
+	logger.Default.Logf(t, "Pulling up to %d messages from Pub/Sub subscription %s", options.MaxMessages, subscriptionID)
+
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return receivePubSubMessagesE(client.Subscription(subscriptionID), options, func(*pubsub.Message) bool { return true })
+}
+
+// GetPubSubSubscriptionConfig returns the configuration of the given Pub/Sub subscription.
+func GetPubSubSubscriptionConfig(t testing.TestingT, projectID string, subscriptionID string) pubsub.SubscriptionConfig {
+	config, err := GetPubSubSubscriptionConfigE(t, projectID, subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// GetPubSubSubscriptionConfigE returns the configuration of the given Pub/Sub subscription, e.g. its topic, ack
+// deadline, dead-letter policy and retry policy.
+func GetPubSubSubscriptionConfigE(t testing.TestingT, projectID string, subscriptionID string) (pubsub.SubscriptionConfig, error) {
+	logger.Default.Logf(t, "Getting configuration of Pub/Sub subscription %s", subscriptionID)
+
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		return pubsub.SubscriptionConfig{}, err
+	}
+	defer client.Close()
+
+	return client.Subscription(subscriptionID).Config(context.Background())
+}
+
+// AssertPubSubDeadLetterPolicy checks that the given Pub/Sub subscription forwards messages to the given dead-letter
+// topic after the given number of delivery attempts, and fails the test if it does not.
+func AssertPubSubDeadLetterPolicy(t testing.TestingT, projectID string, subscriptionID string, expectedDeadLetterTopicID string, expectedMaxDeliveryAttempts int) {
+	err := AssertPubSubDeadLetterPolicyE(t, projectID, subscriptionID, expectedDeadLetterTopicID, expectedMaxDeliveryAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertPubSubDeadLetterPolicyE checks that the given Pub/Sub subscription forwards messages to the given dead-letter
+// topic, given by ID or full name, after the given number of delivery attempts, and returns an error if it does not.
+func AssertPubSubDeadLetterPolicyE(t testing.TestingT, projectID string, subscriptionID string, expectedDeadLetterTopicID string, expectedMaxDeliveryAttempts int) error {
+	config, err := GetPubSubSubscriptionConfigE(t, projectID, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	policy := config.DeadLetterPolicy
+	expectedTopic := pubSubTopicName(projectID, expectedDeadLetterTopicID)
+	switch {
+	case policy == nil:
+		return fmt.Errorf("Pub/Sub subscription %s has no dead-letter policy", subscriptionID)
+	case policy.DeadLetterTopic != expectedTopic:
+		return fmt.Errorf("Pub/Sub subscription %s dead-letters to %s, expected %s", subscriptionID, policy.DeadLetterTopic, expectedTopic)
+	case policy.MaxDeliveryAttempts != expectedMaxDeliveryAttempts:
+		return fmt.Errorf("Pub/Sub subscription %s dead-letters after %d delivery attempts, expected %d", subscriptionID, policy.MaxDeliveryAttempts, expectedMaxDeliveryAttempts)
+	}
+	return nil
+}
+
+// AssertPubSubRetryPolicy checks that the given Pub/Sub subscription redelivers messages with an exponential backoff
+// between the given minimum and maximum, and fails the test if it does not.
+func AssertPubSubRetryPolicy(t testing.TestingT, projectID string, subscriptionID string, expectedMinBackoff time.Duration, expectedMaxBackoff time.Duration) {
+	err := AssertPubSubRetryPolicyE(t, projectID, subscriptionID, expectedMinBackoff, expectedMaxBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertPubSubRetryPolicyE checks that the given Pub/Sub subscription redelivers messages with an exponential backoff
+// between the given minimum and maximum, and returns an error if it does not.
+func AssertPubSubRetryPolicyE(t testing.TestingT, projectID string, subscriptionID string, expectedMinBackoff time.Duration, expectedMaxBackoff time.Duration) error {
+	config, err := GetPubSubSubscriptionConfigE(t, projectID, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	if config.RetryPolicy == nil {
+		return fmt.Errorf("Pub/Sub subscription %s has no retry policy, so it redelivers messages immediately", subscriptionID)
+	}
+	minBackoff, maxBackoff := retryPolicyBackoff(config.RetryPolicy)
+	if minBackoff != expectedMinBackoff || maxBackoff != expectedMaxBackoff {
+		return fmt.Errorf("Pub/Sub subscription %s retries with a backoff between %s and %s, expected between %s and %s", subscriptionID, minBackoff, maxBackoff, expectedMinBackoff, expectedMaxBackoff)
+	}
+	return nil
+}
+
+// VerifyPubSubTopicDelivery checks that a message published to the given Pub/Sub topic is delivered to its
+// subscribers, and fails the test if it is not.
+func VerifyPubSubTopicDelivery(t testing.TestingT, projectID string, topicID string, timeout time.Duration) {
+	err := VerifyPubSubTopicDeliveryE(t, projectID, topicID, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// VerifyPubSubTopicDeliveryE checks that a message published to the given Pub/Sub topic is delivered to its
+// subscribers: it creates a temporary subscription on the topic, publishes a uniquely tagged message, and waits up to
+// the given timeout to pull it from the subscription. The subscription is deleted afterwards. Note that the message is
+// also delivered to the other subscriptions of the topic; it has the terratest-delivery-check attribute.
+func VerifyPubSubTopicDeliveryE(t testing.TestingT, projectID string, topicID string, timeout time.Duration) error {
+	ctx := context.Background()
+
+	client, err := NewPubSubClientE(t, projectID)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	subscriptionID := RandomValidGcpName()
+	logger.Default.Logf(t, "Creating temporary Pub/Sub subscription %s on topic %s", subscriptionID, topicID)
+	subscription, err := client.CreateSubscription(ctx, subscriptionID, pubsub.SubscriptionConfig{
+		Topic:            client.Topic(topicID),
+		ExpirationPolicy: 24 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := subscription.Delete(ctx); err != nil {
+			logger.Default.Logf(t, "Failed to delete temporary Pub/Sub subscription %s: %v", subscriptionID, err)
+		}
+	}()
+
+	checkID := random.UniqueId()
+	message := PubSubMessage{
+		Data:       fmt.Sprintf("Terratest delivery check %s", checkID),
+		Attributes: map[string]string{pubSubDeliveryCheckAttribute: checkID},
+	}
+	if _, err := PublishPubSubMessagesE(t, projectID, topicID, []PubSubMessage{message}); err != nil {
+		return err
+	}
+
+	received, err := receivePubSubMessagesE(subscription, PubSubPullOptions{MaxMessages: 1, MaxWait: timeout}, func(m *pubsub.Message) bool {
+		return m.Attributes[pubSubDeliveryCheckAttribute] == checkID
+	})
+	if err != nil {
+		return err
+	}
+	if len(received) == 0 {
+		return fmt.Errorf("message published to Pub/Sub topic %s was not delivered to subscription %s within %s", topicID, subscriptionID, timeout)
+	}
+
+	return nil
+}
+
+// receivePubSubMessagesE receives up to options.MaxMessages messages that match the given filter from the given
+// subscription, waiting at most options.MaxWait. All received messages are acknowledged (or negatively acknowledged
+// if options.Nack is set), including the ones that do not match.
+func receivePubSubMessagesE(subscription *pubsub.Subscription, options PubSubPullOptions, matches func(*pubsub.Message) bool) ([]PubSubMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), options.MaxWait)
+	defer cancel()
+
+	subscription.ReceiveSettings.Synchronous = true
+	subscription.ReceiveSettings.MaxOutstandingMessages = options.MaxMessages
+
+	var mutex sync.Mutex
+	messages := []PubSubMessage{}
+
+	err := subscription.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if len(messages) >= options.MaxMessages || !matches(m) {
+			// Not ours to keep: make it available again right away
+			m.Nack()
+			return
+		}
+		if options.Nack {
+			m.Nack()
+		} else {
+			m.Ack()
+		}
+
+		messages = append(messages, newPubSubMessage(m))
+		if len(messages) >= options.MaxMessages {
+			cancel()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// withPubSubPullDefaults returns the given options with the defaults of the unset ones.
+func withPubSubPullDefaults(options PubSubPullOptions) PubSubPullOptions {
+	if options.MaxMessages <= 0 {
+		options.MaxMessages = defaultPubSubPullMaxMessages
+	}
+	if options.MaxWait <= 0 {
+		options.MaxWait = defaultPubSubPullMaxWait
+	}
+	return options
+}
+
+// newPubSubMessage converts a message received from Pub/Sub.
+func newPubSubMessage(m *pubsub.Message) PubSubMessage {
+	message := PubSubMessage{
+		ID:          m.ID,
+		Data:        string(m.Data),
+		Attributes:  m.Attributes,
+		OrderingKey: m.OrderingKey,
+		PublishTime: m.PublishTime,
+	}
+	if m.DeliveryAttempt != nil {
+		message.DeliveryAttempt = *m.DeliveryAttempt
+	}
+	return message
+}
+
+// pubSubTopicName returns the full name of the given topic, which may be given by ID or full name.
+func pubSubTopicName(projectID string, topic string) string {
+	if strings.HasPrefix(topic, "projects/") {
+		return topic
+	}
+	return fmt.Sprintf("projects/%s/topics/%s", projectID, topic)
+}
+
+// retryPolicyBackoff returns the minimum and maximum backoff of the given retry policy. The Pub/Sub client leaves
+// them unset when they are the Pub/Sub defaults, 10 seconds and 600 seconds.
+func retryPolicyBackoff(policy *pubsub.RetryPolicy) (time.Duration, time.Duration) {
+	minBackoff, maxBackoff := 10*time.Second, 600*time.Second
+	if d, ok := policy.MinimumBackoff.(time.Duration); ok {
+		minBackoff = d
+	}
+	if d, ok := policy.MaximumBackoff.(time.Duration); ok {
+		maxBackoff = d
+	}
+	return minBackoff, maxBackoff
+}
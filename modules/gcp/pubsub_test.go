@@ -0,0 +1,78 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPubSubTopicDelivery(t *testing.T) {
+	t.Parallel()
+
+	projectID := GetGoogleProjectIDFromEnvVar(t)
+	topicID := RandomValidGcpName()
+
+	CreatePubSubTopic(t, projectID, topicID)
+	defer DeletePubSubTopic(t, projectID, topicID)
+
+	VerifyPubSubTopicDelivery(t, projectID, topicID, 2*time.Minute)
+}
+
+func TestNewPubSubMessage(t *testing.T) {
+	t.Parallel()
+
+	deliveryAttempt := 3
+	publishTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	message := newPubSubMessage(&pubsub.Message{
+		ID:              "42",
+		Data:            []byte("hello"),
+		Attributes:      map[string]string{"source": "test"},
+		OrderingKey:     "order-1",
+		PublishTime:     publishTime,
+		DeliveryAttempt: &deliveryAttempt,
+	})
+
+	assert.Equal(t, PubSubMessage{
+		ID:              "42",
+		Data:            "hello",
+		Attributes:      map[string]string{"source": "test"},
+		OrderingKey:     "order-1",
+		PublishTime:     publishTime,
+		DeliveryAttempt: 3,
+	}, message)
+	assert.Equal(t, 0, newPubSubMessage(&pubsub.Message{}).DeliveryAttempt)
+}
+
+func TestWithPubSubPullDefaults(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, PubSubPullOptions{MaxMessages: 10, MaxWait: 30 * time.Second}, withPubSubPullDefaults(PubSubPullOptions{}))
+	assert.Equal(t, PubSubPullOptions{MaxMessages: 1, MaxWait: time.Second, Nack: true}, withPubSubPullDefaults(PubSubPullOptions{MaxMessages: 1, MaxWait: time.Second, Nack: true}))
+}
+
+func TestPubSubTopicName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "projects/my-project/topics/dead-letter", pubSubTopicName("my-project", "dead-letter"))
+	assert.Equal(t, "projects/other/topics/dead-letter", pubSubTopicName("my-project", "projects/other/topics/dead-letter"))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	minBackoff, maxBackoff := retryPolicyBackoff(&pubsub.RetryPolicy{MinimumBackoff: 5 * time.Second, MaximumBackoff: time.Minute})
+	require.Equal(t, 5*time.Second, minBackoff)
+	require.Equal(t, time.Minute, maxBackoff)
+
+	minBackoff, maxBackoff = retryPolicyBackoff(&pubsub.RetryPolicy{})
+	assert.Equal(t, 10*time.Second, minBackoff)
+	assert.Equal(t, 600*time.Second, maxBackoff)
+}
@@ -5,11 +5,12 @@ import (
 	"os"
 	"strings"
 
+	"cloud.google.com/go/compute/apiv1/computepb"
 	"github.com/gruntwork-io/terratest/modules/collections"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/testing"
-	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/iterator"
 )
 
 // You can set this environment variable to force Terratest to use a specific Region rather than a random one. This is
@@ -162,26 +163,27 @@ func GetAllGcpRegions(t testing.TestingT, projectID string) []string {
 func GetAllGcpRegionsE(t testing.TestingT, projectID string) ([]string, error) {
 	logger.Default.Logf(t, "Looking up all GCP regions available in this account")
 
-	// Note that NewComputeServiceE creates a context, but it appears to be empty so we keep the code simpler by
-	// creating a new one here
 	ctx := context.Background()
 
-	service, err := NewComputeServiceE(t)
+	client, err := NewRegionsClientE(t)
 	if err != nil {
 		return nil, err
 	}
-
-	req := service.Regions.List(projectID)
+	defer client.Close()
 
 	regions := []string{}
-	err = req.Pages(ctx, func(page *compute.RegionList) error {
-		for _, region := range page.Items {
-			regions = append(regions, region.Name)
+
+	it := client.List(ctx, &computepb.ListRegionsRequest{Project: projectID}, withReadRetries()...)
+	for {
+		region, err := it.Next()
+		if err == iterator.Done {
+			break
 		}
-		return err
-	})
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+
+		regions = append(regions, region.GetName())
 	}
 
 	return regions, nil
@@ -198,26 +200,27 @@ func GetAllGcpZones(t testing.TestingT, projectID string) []string {
 
 // GetAllGcpZonesE gets the list of GCP Zones available in this account.
 func GetAllGcpZonesE(t testing.TestingT, projectID string) ([]string, error) {
-	// Note that NewComputeServiceE creates a context, but it appears to be empty so we keep the code simpler by
-	// creating a new one here
 	ctx := context.Background()
 
-	service, err := NewComputeServiceE(t)
+	client, err := NewZonesClientE(t)
 	if err != nil {
 		return nil, err
 	}
-
-	req := service.Zones.List(projectID)
+	defer client.Close()
 
 	zones := []string{}
-	err = req.Pages(ctx, func(page *compute.ZoneList) error {
-		for _, zone := range page.Items {
-			zones = append(zones, zone.Name)
+
+	it := client.List(ctx, &computepb.ListZonesRequest{Project: projectID}, withReadRetries()...)
+	for {
+		zone, err := it.Next()
+		if err == iterator.Done {
+			break
 		}
-		return err
-	})
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, zone.GetName())
 	}
 
 	return zones, nil
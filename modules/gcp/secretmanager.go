@@ -0,0 +1,354 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/iam/apiv1/iampb"
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// CreateSecret creates a secret with the given ID in the given project, replicated in the given locations, or
+// automatically if none are given, and returns its full name. This will fail the test if there is an error.
+func CreateSecret(t testing.TestingT, projectID string, secretID string, replicaLocations []string) string {
+	name, err := CreateSecretE(t, projectID, secretID, replicaLocations)
+	require.NoError(t, err)
+	return name
+}
+
+// CreateSecretE creates a secret with the given ID in the given project, replicated in the given locations (e.g.
+// us-east1), or automatically if none are given, and returns its full name, formatted like
+// projects/<project>/secrets/<secret>. The secret has no versions: add one with AddSecretVersionE.
+func CreateSecretE(t testing.TestingT, projectID string, secretID string, replicaLocations []string) (string, error) {
+	logger.Default.Logf(t, "Creating secret %s in project %s", secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	secret, err := client.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+		Parent:   fmt.Sprintf("projects/%s", projectID),
+		SecretId: secretID,
+		Secret:   &secretmanagerpb.Secret{Replication: newSecretReplication(replicaLocations)},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return secret.GetName(), nil
+}
+
+// GetSecret returns the given secret, e.g. to check its replication, labels or rotation settings. This will fail the
+// test if there is an error.
+func GetSecret(t testing.TestingT, projectID string, secretID string) *secretmanagerpb.Secret {
+	secret, err := GetSecretE(t, projectID, secretID)
+	require.NoError(t, err)
+	return secret
+}
+
+// GetSecretE returns the given secret, e.g. to check its replication, labels or rotation settings.
+func GetSecretE(t testing.TestingT, projectID string, secretID string) (*secretmanagerpb.Secret, error) {
+	logger.Default.Logf(t, "Getting secret %s in project %s", secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: secretName(projectID, secretID)})
+}
+
+// DeleteSecret deletes the given secret and all of its versions. This will fail the test if there is an error.
+func DeleteSecret(t testing.TestingT, projectID string, secretID string) {
+	err := DeleteSecretE(t, projectID, secretID)
+	require.NoError(t, err)
+}
+
+// DeleteSecretE deletes the given secret and all of its versions.
+func DeleteSecretE(t testing.TestingT, projectID string, secretID string) error {
+	logger.Default.Logf(t, "Deleting secret %s in project %s", secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{Name: secretName(projectID, secretID)})
+}
+
+// AddSecretVersion adds a version with the given payload to the given secret and returns its version ID, e.g. "1".
+// This will fail the test if there is an error.
+func AddSecretVersion(t testing.TestingT, projectID string, secretID string, payload string) string {
+	version, err := AddSecretVersionE(t, projectID, secretID, payload)
+	require.NoError(t, err)
+	return version
+}
+
+// AddSecretVersionE adds a version with the given payload to the given secret and returns its version ID, e.g. "1".
+// The new version becomes the latest version of the secret.
+func AddSecretVersionE(t testing.TestingT, projectID string, secretID string, payload string) (string, error) {
+	logger.Default.Logf(t, "Adding a version to secret %s in project %s", secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	version, err := client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  secretName(projectID, secretID),
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte(payload)},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return version.GetName()[strings.LastIndex(version.GetName(), "/")+1:], nil
+}
+
+// AccessSecretVersion returns the payload of the given version of the given secret, which can be a version ID or
+// "latest". This will fail the test if there is an error.
+func AccessSecretVersion(t testing.TestingT, projectID string, secretID string, version string) string {
+	payload, err := AccessSecretVersionE(t, projectID, secretID, version)
+	require.NoError(t, err)
+	return payload
+}
+
+// AccessSecretVersionE returns the payload of the given version of the given secret, which can be a version ID or
+// "latest". This requires the Secret Manager Secret Accessor role on the secret. The payload is not logged.
+func AccessSecretVersionE(t testing.TestingT, projectID string, secretID string, version string) (string, error) {
+	logger.Default.Logf(t, "Accessing version %s of secret %s in project %s", version, secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	resp, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: secretVersionName(projectID, secretID, version),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(resp.GetPayload().GetData()), nil
+}
+
+// DestroySecretVersion irrevocably destroys the payload of the given version of the given secret. This will fail the
+// test if there is an error.
+func DestroySecretVersion(t testing.TestingT, projectID string, secretID string, version string) {
+	err := DestroySecretVersionE(t, projectID, secretID, version)
+	require.NoError(t, err)
+}
+
+// DestroySecretVersionE irrevocably destroys the payload of the given version of the given secret. The version itself
+// is kept, in the DESTROYED state, unless the secret has a delayed destruction period, during which it is DISABLED.
+func DestroySecretVersionE(t testing.TestingT, projectID string, secretID string, version string) error {
+	logger.Default.Logf(t, "Destroying version %s of secret %s in project %s", version, secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.DestroySecretVersion(ctx, &secretmanagerpb.DestroySecretVersionRequest{
+		Name: secretVersionName(projectID, secretID, version),
+	})
+	return err
+}
+
+// AssertSecretReplicationAutomatic checks that the given secret is replicated automatically by Google, and fails the
+// test if it is not.
+func AssertSecretReplicationAutomatic(t testing.TestingT, projectID string, secretID string) {
+	err := AssertSecretReplicationAutomaticE(t, projectID, secretID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertSecretReplicationAutomaticE checks that the given secret is replicated automatically by Google, and returns
+// an error if it is not.
+func AssertSecretReplicationAutomaticE(t testing.TestingT, projectID string, secretID string) error {
+	secret, err := GetSecretE(t, projectID, secretID)
+	if err != nil {
+		return err
+	}
+
+	if secret.GetReplication().GetAutomatic() == nil {
+		return fmt.Errorf("Secret %s is not replicated automatically, its replicas are in %v", secretID, secretReplicaLocations(secret.GetReplication()))
+	}
+	return nil
+}
+
+// AssertSecretReplicaLocations checks that the given secret is replicated in exactly the given locations, and fails
+// the test if it is not.
+func AssertSecretReplicaLocations(t testing.TestingT, projectID string, secretID string, expectedLocations []string) {
+	err := AssertSecretReplicaLocationsE(t, projectID, secretID, expectedLocations)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertSecretReplicaLocationsE checks that the given secret has a user-managed replication policy with replicas in
+// exactly the given locations, in any order, and returns an error if it does not.
+func AssertSecretReplicaLocationsE(t testing.TestingT, projectID string, secretID string, expectedLocations []string) error {
+	secret, err := GetSecretE(t, projectID, secretID)
+	if err != nil {
+		return err
+	}
+
+	if secret.GetReplication().GetUserManaged() == nil {
+		return fmt.Errorf("Secret %s is replicated automatically, expected replicas in %v", secretID, expectedLocations)
+	}
+
+	locations := secretReplicaLocations(secret.GetReplication())
+	expected := append([]string{}, expectedLocations...)
+	sort.Strings(expected)
+	if strings.Join(locations, ",") != strings.Join(expected, ",") {
+		return fmt.Errorf("Secret %s is replicated in %v, expected %v", secretID, locations, expected)
+	}
+	return nil
+}
+
+// GetSecretIAMPolicy returns the IAM policy of the given secret. This will fail the test if there is an error.
+func GetSecretIAMPolicy(t testing.TestingT, projectID string, secretID string) *iampb.Policy {
+	policy, err := GetSecretIAMPolicyE(t, projectID, secretID)
+	require.NoError(t, err)
+	return policy
+}
+
+// GetSecretIAMPolicyE returns the IAM policy of the given secret.
+func GetSecretIAMPolicyE(t testing.TestingT, projectID string, secretID string) (*iampb.Policy, error) {
+	logger.Default.Logf(t, "Getting IAM policy of secret %s in project %s", secretID, projectID)
+
+	ctx := context.Background()
+	client, err := NewSecretManagerClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.GetIamPolicy(ctx, newGetIAMPolicyRequest(secretName(projectID, secretID)))
+}
+
+// AssertSecretIAMMember checks that the given member (e.g. "serviceAccount:app@my-project.iam.gserviceaccount.com") is
+// granted the given role (e.g. "roles/secretmanager.secretAccessor") on the given secret, and fails the test if it is
+// not.
+func AssertSecretIAMMember(t testing.TestingT, projectID string, secretID string, role string, member string) {
+	err := AssertSecretIAMMemberE(t, projectID, secretID, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertSecretIAMMemberE checks that the given member is granted the given role on the given secret, and returns an
+// error if it is not. Conditional bindings count as granting the role.
+func AssertSecretIAMMemberE(t testing.TestingT, projectID string, secretID string, role string, member string) error {
+	policy, err := GetSecretIAMPolicyE(t, projectID, secretID)
+	if err != nil {
+		return err
+	}
+
+	if !hasIAMBinding(policy, role, member) {
+		return fmt.Errorf("Secret %s does not grant role %s to %s", secretID, role, member)
+	}
+	return nil
+}
+
+// AssertSecretIAMMemberAbsent checks that the given member (e.g. "allAuthenticatedUsers") is not granted the given
+// role on the given secret, and fails the test if it is.
+func AssertSecretIAMMemberAbsent(t testing.TestingT, projectID string, secretID string, role string, member string) {
+	err := AssertSecretIAMMemberAbsentE(t, projectID, secretID, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertSecretIAMMemberAbsentE checks that the given member is not granted the given role on the given secret, and
+// returns an error if it is.
+func AssertSecretIAMMemberAbsentE(t testing.TestingT, projectID string, secretID string, role string, member string) error {
+	policy, err := GetSecretIAMPolicyE(t, projectID, secretID)
+	if err != nil {
+		return err
+	}
+
+	if hasIAMBinding(policy, role, member) {
+		return fmt.Errorf("Secret %s grants role %s to %s", secretID, role, member)
+	}
+	return nil
+}
+
+// NewSecretManagerClient creates a new Secret Manager client, which is used to make Secret Manager API calls. The
+// caller is responsible for closing the client.
+func NewSecretManagerClient(t testing.TestingT) *secretmanager.Client {
+	client, err := NewSecretManagerClientE(t)
+	require.NoError(t, err)
+	return client
+}
+
+// NewSecretManagerClientE creates a new Secret Manager client, which is used to make Secret Manager API calls. The
+// caller is responsible for closing the client.
+func NewSecretManagerClientE(t testing.TestingT) (*secretmanager.Client, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return secretmanager.NewClient(context.Background(), opts...)
+}
+
+// newSecretReplication returns a user-managed replication policy with replicas in the given locations, or an automatic
+// one if none are given.
+func newSecretReplication(locations []string) *secretmanagerpb.Replication {
+	if len(locations) == 0 {
+		return &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{Automatic: &secretmanagerpb.Replication_Automatic{}},
+		}
+	}
+
+	replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(locations))
+	for _, location := range locations {
+		replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+	}
+	return &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_UserManaged_{UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas}},
+	}
+}
+
+// secretReplicaLocations returns the sorted locations of the replicas of the given user-managed replication policy.
+func secretReplicaLocations(replication *secretmanagerpb.Replication) []string {
+	locations := []string{}
+	for _, replica := range replication.GetUserManaged().GetReplicas() {
+		locations = append(locations, replica.GetLocation())
+	}
+	sort.Strings(locations)
+	return locations
+}
+
+// secretName returns the full name of the given secret.
+func secretName(projectID string, secretID string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", projectID, secretID)
+}
+
+// secretVersionName returns the full name of the given version of the given secret.
+func secretVersionName(projectID string, secretID string, version string) string {
+	return fmt.Sprintf("%s/versions/%s", secretName(projectID, secretID), version)
+}
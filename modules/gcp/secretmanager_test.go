@@ -0,0 +1,45 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretVersionLifecycle(t *testing.T) {
+	t.Parallel()
+
+	projectID := GetGoogleProjectIDFromEnvVar(t)
+	secretID := RandomValidGcpName()
+
+	CreateSecret(t, projectID, secretID, []string{"us-east1", "us-central1"})
+	defer DeleteSecret(t, projectID, secretID)
+
+	AssertSecretReplicaLocations(t, projectID, secretID, []string{"us-central1", "us-east1"})
+	require.Error(t, AssertSecretReplicationAutomaticE(t, projectID, secretID))
+
+	version := AddSecretVersion(t, projectID, secretID, "hunter2")
+	assert.Equal(t, "hunter2", AccessSecretVersion(t, projectID, secretID, "latest"))
+
+	DestroySecretVersion(t, projectID, secretID, version)
+	_, err := AccessSecretVersionE(t, projectID, secretID, version)
+	assert.Error(t, err)
+
+	AssertSecretIAMMemberAbsent(t, projectID, secretID, "roles/secretmanager.secretAccessor", "allAuthenticatedUsers")
+}
+
+func TestNewSecretReplication(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, newSecretReplication(nil).GetAutomatic())
+
+	replication := newSecretReplication([]string{"us-east1", "europe-west1"})
+	assert.Nil(t, replication.GetAutomatic())
+	assert.Equal(t, []string{"europe-west1", "us-east1"}, secretReplicaLocations(replication))
+}
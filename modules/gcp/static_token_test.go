@@ -22,13 +22,13 @@ func TestStaticTokenClient(t *testing.T) {
 	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "non-existent-credentials.json")
 	_, err = NewCloudBuildServiceE(t)
 	require.Error(t, err)
-	_, err = NewComputeServiceE(t)
+	_, err = NewRegionsClientE(t)
 	require.Error(t, err)
 	_, err = newGCRAuther()
 	require.Error(t, err)
-	_, err = NewOSLoginServiceE(t)
+	_, err = NewOSLoginClientE(t)
 	require.Error(t, err)
-	_, err = newStorageClient()
+	_, err = NewStorageClientE(t)
 	require.Error(t, err)
 
 	// now we instantiate client with oauth2 token
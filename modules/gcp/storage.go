@@ -4,6 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"cloud.google.com/go/storage"
 	"github.com/gruntwork-io/terratest/modules/logger"
@@ -26,7 +32,7 @@ func CreateStorageBucketE(t testing.TestingT, projectID string, name string, att
 	ctx := context.Background()
 
 	// Creates a client.
-	client, err := newStorageClient()
+	client, err := NewStorageClientE(t)
 	if err != nil {
 		return err
 	}
@@ -52,7 +58,7 @@ func DeleteStorageBucketE(t testing.TestingT, name string) error {
 
 	ctx := context.Background()
 
-	client, err := newStorageClient()
+	client, err := NewStorageClientE(t)
 	if err != nil {
 		return err
 	}
@@ -75,7 +81,7 @@ func ReadBucketObjectE(t testing.TestingT, bucketName string, filePath string) (
 
 	ctx := context.Background()
 
-	client, err := newStorageClient()
+	client, err := NewStorageClientE(t)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +115,7 @@ func WriteBucketObjectE(t testing.TestingT, bucketName string, filePath string,
 
 	ctx := context.Background()
 
-	client, err := newStorageClient()
+	client, err := NewStorageClientE(t)
 	if err != nil {
 		return "", err
 	}
@@ -147,7 +153,7 @@ func EmptyStorageBucketE(t testing.TestingT, name string) error {
 
 	ctx := context.Background()
 
-	client, err := newStorageClient()
+	client, err := NewStorageClientE(t)
 	if err != nil {
 		return err
 	}
@@ -192,7 +198,7 @@ func AssertStorageBucketExistsE(t testing.TestingT, name string) error {
 	ctx := context.Background()
 
 	// Creates a client.
-	client, err := newStorageClient()
+	client, err := NewStorageClientE(t)
 	if err != nil {
 		return err
 	}
@@ -218,9 +224,171 @@ func AssertStorageBucketExistsE(t testing.TestingT, name string) error {
 	return nil
 }
 
-func newStorageClient() (*storage.Client, error) {
+// UploadDirectoryToBucket uploads all files under the given local directory, recursively, to the given Storage Bucket,
+// under the given object name prefix (e.g. "site/"; may be empty). It returns the names of the uploaded objects.
+func UploadDirectoryToBucket(t testing.TestingT, bucketName string, localDir string, prefix string) []string {
+	out, err := UploadDirectoryToBucketE(t, bucketName, localDir, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// UploadDirectoryToBucketE uploads all files under the given local directory, recursively, to the given Storage Bucket,
+// under the given object name prefix (e.g. "site/"; may be empty). The content type of each object is guessed from the
+// extension of its file. It returns the names of the uploaded objects.
+func UploadDirectoryToBucketE(t testing.TestingT, bucketName string, localDir string, prefix string) ([]string, error) {
+	logger.Default.Logf(t, "Uploading directory %s to bucket %s with prefix %s", localDir, bucketName, prefix)
+
+	objectNames := []string{}
+	err := filepath.WalkDir(localDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		objectName, err := objectNameForFile(localDir, filePath, prefix)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if _, err := WriteBucketObjectE(t, bucketName, objectName, file, mime.TypeByExtension(filepath.Ext(filePath))); err != nil {
+			return fmt.Errorf("failed to upload %s to bucket %s: %v", filePath, bucketName, err)
+		}
+		objectNames = append(objectNames, objectName)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objectNames, nil
+}
+
+// DownloadBucketDirectory downloads all objects of the given Storage Bucket whose names start with the given prefix
+// to the given local directory, recreating the directory structure of their names below the prefix. It returns the
+// paths of the downloaded files.
+func DownloadBucketDirectory(t testing.TestingT, bucketName string, prefix string, localDir string) []string {
+	out, err := DownloadBucketDirectoryE(t, bucketName, prefix, localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DownloadBucketDirectoryE downloads all objects of the given Storage Bucket whose names start with the given prefix
+// to the given local directory, recreating the directory structure of their names below the prefix. Placeholder
+// objects for folders, whose names end with a slash, are skipped. It returns the paths of the downloaded files.
+func DownloadBucketDirectoryE(t testing.TestingT, bucketName string, prefix string, localDir string) ([]string, error) {
+	logger.Default.Logf(t, "Downloading objects with prefix %s from bucket %s to %s", prefix, bucketName, localDir)
+
+	ctx := context.Background()
+
+	client, err := NewStorageClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	bucket := client.Bucket(bucketName)
+	filePaths := []string{}
+
+	it := bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		objectAttrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(objectAttrs.Name, "/") {
+			continue
+		}
+
+		filePath, err := localPathForObject(localDir, prefix, objectAttrs.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := downloadBucketObjectE(ctx, bucket, objectAttrs.Name, filePath); err != nil {
+			return nil, fmt.Errorf("failed to download object %s from bucket %s: %v", objectAttrs.Name, bucketName, err)
+		}
+		filePaths = append(filePaths, filePath)
+	}
+
+	return filePaths, nil
+}
+
+// downloadBucketObjectE writes the contents of the given object to the given file, creating its directory if needed.
+func downloadBucketObjectE(ctx context.Context, bucket *storage.BucketHandle, objectName string, filePath string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	r, err := bucket.Object(objectName).NewReader(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// objectNameForFile returns the name of the object to upload the given file under the given local directory to: the
+// path of the file relative to the directory, with forward slashes, after the given prefix.
+func objectNameForFile(localDir string, filePath string, prefix string) (string, error) {
+	relPath, err := filepath.Rel(localDir, filePath)
+	if err != nil {
+		return "", err
+	}
+	return prefix + filepath.ToSlash(relPath), nil
+}
+
+// localPathForObject returns the path of the file to download the given object to: its name after the given prefix,
+// below the given local directory. Relative path elements (e.g. "../secret") are resolved as if the directory was the
+// root, so objects are never downloaded outside of it.
+func localPathForObject(localDir string, prefix string, objectName string) (string, error) {
+	relPath := path.Clean("/" + strings.TrimPrefix(objectName, prefix))
+	if relPath == "/" {
+		return "", fmt.Errorf("object %s has no name below prefix %s", objectName, prefix)
+	}
+
+	return filepath.Join(localDir, filepath.FromSlash(relPath)), nil
+}
+
+// NewStorageClient creates a new Cloud Storage client, which is used to make Cloud Storage API calls. The caller is
+// responsible for closing the client.
+func NewStorageClient(t testing.TestingT) *storage.Client {
+	client, err := NewStorageClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewStorageClientE creates a new Cloud Storage client, which is used to make Cloud Storage API calls. The caller is
+// responsible for closing the client.
+func NewStorageClientE(t testing.TestingT) (*storage.Client, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
-	client, err := storage.NewClient(ctx, withOptions()...)
+	client, err := storage.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
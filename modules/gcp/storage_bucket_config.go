@@ -0,0 +1,257 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/iam"
+	"cloud.google.com/go/storage"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// GetStorageBucketAttrs returns the attributes of the given Storage Bucket, e.g. its location, storage class,
+// lifecycle rules, retention policy and encryption.
+func GetStorageBucketAttrs(t testing.TestingT, name string) *storage.BucketAttrs {
+	attrs, err := GetStorageBucketAttrsE(t, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attrs
+}
+
+// GetStorageBucketAttrsE returns the attributes of the given Storage Bucket, e.g. its location, storage class,
+// lifecycle rules, retention policy and encryption.
+func GetStorageBucketAttrsE(t testing.TestingT, name string) (*storage.BucketAttrs, error) {
+	logger.Default.Logf(t, "Getting attributes of bucket %s", name)
+
+	client, err := NewStorageClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.Bucket(name).Attrs(context.Background())
+}
+
+// GetStorageBucketIAMPolicy returns the IAM policy of the given Storage Bucket.
+func GetStorageBucketIAMPolicy(t testing.TestingT, name string) *iam.Policy {
+	policy, err := GetStorageBucketIAMPolicyE(t, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy
+}
+
+// GetStorageBucketIAMPolicyE returns the IAM policy of the given Storage Bucket.
+func GetStorageBucketIAMPolicyE(t testing.TestingT, name string) (*iam.Policy, error) {
+	logger.Default.Logf(t, "Getting IAM policy of bucket %s", name)
+
+	client, err := NewStorageClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.Bucket(name).IAM().Policy(context.Background())
+}
+
+// AssertStorageBucketIAMMember checks that the given member (e.g. "serviceAccount:ci@my-project.iam.gserviceaccount.com"
+// or "allUsers") is granted the given role (e.g. "roles/storage.objectViewer") on the given Storage Bucket, and fails
+// the test if it is not.
+func AssertStorageBucketIAMMember(t testing.TestingT, name string, role string, member string) {
+	err := AssertStorageBucketIAMMemberE(t, name, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertStorageBucketIAMMemberE checks that the given member is granted the given role on the given Storage Bucket,
+// and returns an error if it is not.
+func AssertStorageBucketIAMMemberE(t testing.TestingT, name string, role string, member string) error {
+	policy, err := GetStorageBucketIAMPolicyE(t, name)
+	if err != nil {
+		return err
+	}
+
+	if !policy.HasRole(member, iam.RoleName(role)) {
+		return fmt.Errorf("Storage bucket %s does not grant role %s to %s", name, role, member)
+	}
+	return nil
+}
+
+// AssertStorageBucketIAMMemberAbsent checks that the given member (e.g. "allUsers") is not granted the given role on
+// the given Storage Bucket, and fails the test if it is.
+func AssertStorageBucketIAMMemberAbsent(t testing.TestingT, name string, role string, member string) {
+	err := AssertStorageBucketIAMMemberAbsentE(t, name, role, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertStorageBucketIAMMemberAbsentE checks that the given member is not granted the given role on the given Storage
+// Bucket, and returns an error if it is.
+func AssertStorageBucketIAMMemberAbsentE(t testing.TestingT, name string, role string, member string) error {
+	policy, err := GetStorageBucketIAMPolicyE(t, name)
+	if err != nil {
+		return err
+	}
+
+	if policy.HasRole(member, iam.RoleName(role)) {
+		return fmt.Errorf("Storage bucket %s grants role %s to %s", name, role, member)
+	}
+	return nil
+}
+
+// AssertStorageBucketUniformAccessEnabled checks that uniform bucket-level access is enabled on the given Storage
+// Bucket, i.e., that access is only granted through IAM and not through object ACLs, and fails the test if it is not.
+func AssertStorageBucketUniformAccessEnabled(t testing.TestingT, name string) {
+	err := AssertStorageBucketUniformAccessEnabledE(t, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertStorageBucketUniformAccessEnabledE checks that uniform bucket-level access is enabled on the given Storage
+// Bucket, and returns an error if it is not.
+func AssertStorageBucketUniformAccessEnabledE(t testing.TestingT, name string) error {
+	attrs, err := GetStorageBucketAttrsE(t, name)
+	if err != nil {
+		return err
+	}
+
+	if !attrs.UniformBucketLevelAccess.Enabled {
+		return fmt.Errorf("Storage bucket %s does not have uniform bucket-level access enabled", name)
+	}
+	return nil
+}
+
+// AssertStorageBucketLifecycleRule checks that the given Storage Bucket has a lifecycle rule with exactly the given
+// action and condition, e.g. a storage.DeleteAction once AgeInDays is 30, and fails the test if it does not.
+func AssertStorageBucketLifecycleRule(t testing.TestingT, name string, expectedRule storage.LifecycleRule) {
+	err := AssertStorageBucketLifecycleRuleE(t, name, expectedRule)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertStorageBucketLifecycleRuleE checks that the given Storage Bucket has a lifecycle rule with exactly the given
+// action and condition, and returns an error if it does not.
+func AssertStorageBucketLifecycleRuleE(t testing.TestingT, name string, expectedRule storage.LifecycleRule) error {
+	attrs, err := GetStorageBucketAttrsE(t, name)
+	if err != nil {
+		return err
+	}
+
+	if !hasLifecycleRule(attrs.Lifecycle, expectedRule) {
+		return fmt.Errorf("Storage bucket %s does not have lifecycle rule %+v, its rules are %+v", name, expectedRule, attrs.Lifecycle.Rules)
+	}
+	return nil
+}
+
+// AssertStorageBucketRetentionPolicy checks that objects in the given Storage Bucket are retained for the given
+// period, and that the retention policy is locked if expectLocked is true, and fails the test if they are not.
+func AssertStorageBucketRetentionPolicy(t testing.TestingT, name string, expectedPeriod time.Duration, expectLocked bool) {
+	err := AssertStorageBucketRetentionPolicyE(t, name, expectedPeriod, expectLocked)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertStorageBucketRetentionPolicyE checks that objects in the given Storage Bucket are retained for the given
+// period, and that the retention policy is locked if expectLocked is true, and returns an error if they are not.
+func AssertStorageBucketRetentionPolicyE(t testing.TestingT, name string, expectedPeriod time.Duration, expectLocked bool) error {
+	attrs, err := GetStorageBucketAttrsE(t, name)
+	if err != nil {
+		return err
+	}
+
+	policy := attrs.RetentionPolicy
+	switch {
+	case policy == nil:
+		return fmt.Errorf("Storage bucket %s has no retention policy", name)
+	case policy.RetentionPeriod != expectedPeriod:
+		return fmt.Errorf("Storage bucket %s retains objects for %s, expected %s", name, policy.RetentionPeriod, expectedPeriod)
+	case expectLocked && !policy.IsLocked:
+		return fmt.Errorf("Storage bucket %s retention policy is not locked", name)
+	}
+	return nil
+}
+
+// AssertStorageBucketDefaultKMSKey checks that new objects in the given Storage Bucket are encrypted with the given
+// customer-managed encryption key (CMEK), formatted like
+// projects/<project>/locations/<location>/keyRings/<key-ring>/cryptoKeys/<key>, and fails the test if they are not.
+func AssertStorageBucketDefaultKMSKey(t testing.TestingT, name string, expectedKeyName string) {
+	err := AssertStorageBucketDefaultKMSKeyE(t, name, expectedKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertStorageBucketDefaultKMSKeyE checks that new objects in the given Storage Bucket are encrypted with the given
+// customer-managed encryption key (CMEK), and returns an error if they are not.
+func AssertStorageBucketDefaultKMSKeyE(t testing.TestingT, name string, expectedKeyName string) error {
+	attrs, err := GetStorageBucketAttrsE(t, name)
+	if err != nil {
+		return err
+	}
+
+	actualKeyName := ""
+	if attrs.Encryption != nil {
+		actualKeyName = attrs.Encryption.DefaultKMSKeyName
+	}
+	if actualKeyName != expectedKeyName {
+		return fmt.Errorf("Storage bucket %s default KMS key is %q, expected %q", name, actualKeyName, expectedKeyName)
+	}
+	return nil
+}
+
+// AssertBucketObjectKMSKey checks that the given object in the given Storage Bucket is encrypted with the given
+// customer-managed encryption key (CMEK), and fails the test if it is not.
+func AssertBucketObjectKMSKey(t testing.TestingT, bucketName string, filePath string, expectedKeyName string) {
+	err := AssertBucketObjectKMSKeyE(t, bucketName, filePath, expectedKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertBucketObjectKMSKeyE checks that the given object in the given Storage Bucket is encrypted with the given
+// customer-managed encryption key (CMEK), in any of its versions, and returns an error if it is not.
+func AssertBucketObjectKMSKeyE(t testing.TestingT, bucketName string, filePath string, expectedKeyName string) error {
+	logger.Default.Logf(t, "Getting attributes of object %s in bucket %s", filePath, bucketName)
+
+	client, err := NewStorageClientE(t)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	attrs, err := client.Bucket(bucketName).Object(filePath).Attrs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if !isKMSKeyVersionOf(attrs.KMSKeyName, expectedKeyName) {
+		return fmt.Errorf("Object %s in bucket %s is encrypted with KMS key %q, expected %q", filePath, bucketName, attrs.KMSKeyName, expectedKeyName)
+	}
+	return nil
+}
+
+// hasLifecycleRule returns true if the given lifecycle has a rule equal to the given rule.
+func hasLifecycleRule(lifecycle storage.Lifecycle, expectedRule storage.LifecycleRule) bool {
+	for _, rule := range lifecycle.Rules {
+		if reflect.DeepEqual(rule, expectedRule) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKMSKeyVersionOf returns true if the given KMS key name, which Cloud Storage reports for objects with the version
+// of the key they were encrypted with, is the given key or one of its versions.
+func isKMSKeyVersionOf(keyName string, expectedKeyName string) bool {
+	return keyName == expectedKeyName || strings.HasPrefix(keyName, expectedKeyName+"/cryptoKeyVersions/")
+}
@@ -0,0 +1,96 @@
+package gcp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// The layout of the X-Goog-Date query parameter of V4 signed URLs.
+const signedURLDateLayout = "20060102T150405Z"
+
+// GenerateSignedURL returns a V4 signed URL which grants the given HTTP method (e.g. GET or PUT) on the given object
+// of the given Storage Bucket to anyone who has it, until the given expiry elapses (at most 7 days).
+func GenerateSignedURL(t testing.TestingT, bucketName string, filePath string, method string, expiry time.Duration) string {
+	signedURL, err := GenerateSignedURLE(t, bucketName, filePath, method, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedURL
+}
+
+// GenerateSignedURLE returns a V4 signed URL which grants the given HTTP method (e.g. GET or PUT) on the given object
+// of the given Storage Bucket to anyone who has it, until the given expiry elapses (at most 7 days). The URL is signed
+// with the private key of the service account key the test authenticates with, or, when a service account is
+// impersonated (see AuthOptions), or when running on GCP, through the signBlob method of the IAM Credentials API,
+// which requires the Service Account Token Creator role on that service account.
+func GenerateSignedURLE(t testing.TestingT, bucketName string, filePath string, method string, expiry time.Duration) (string, error) {
+	logger.Default.Logf(t, "Generating signed URL for %s on object %s in bucket %s", method, filePath, bucketName)
+
+	client, err := NewStorageClientE(t)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	opts := &storage.SignedURLOptions{
+		Scheme:         storage.SigningSchemeV4,
+		Method:         method,
+		Expires:        time.Now().Add(expiry),
+		GoogleAccessID: authOptionsFromEnv().ImpersonateServiceAccount,
+	}
+
+	return client.Bucket(bucketName).SignedURL(filePath, opts)
+}
+
+// VerifySignedURL downloads the object of the given signed URL, without any credentials, and fails the test if the
+// request is rejected or the object does not have the expected contents.
+func VerifySignedURL(t testing.TestingT, signedURL string, expectedBody string) {
+	err := VerifySignedURLE(t, signedURL, expectedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// VerifySignedURLE downloads the object of the given signed URL, without any credentials, and returns an error if the
+// request is rejected or the object does not have the expected contents.
+func VerifySignedURLE(t testing.TestingT, signedURL string, expectedBody string) error {
+	return http_helper.HttpGetWithValidationE(t, signedURL, nil, http.StatusOK, expectedBody)
+}
+
+// GetSignedURLExpiration returns the time after which the given V4 signed URL is no longer accepted.
+func GetSignedURLExpiration(t testing.TestingT, signedURL string) time.Time {
+	expiration, err := GetSignedURLExpirationE(signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expiration
+}
+
+// GetSignedURLExpirationE returns the time after which the given V4 signed URL is no longer accepted, which is its
+// X-Goog-Date plus its X-Goog-Expires seconds.
+func GetSignedURLExpirationE(signedURL string) (time.Time, error) {
+	parsedURL, err := url.Parse(signedURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	query := parsedURL.Query()
+
+	signedAt, err := time.Parse(signedURLDateLayout, query.Get("X-Goog-Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a V4 signed URL: invalid X-Goog-Date: %v", err)
+	}
+	expiresInSeconds, err := strconv.Atoi(query.Get("X-Goog-Expires"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a V4 signed URL: invalid X-Goog-Expires: %v", err)
+	}
+
+	return signedAt.Add(time.Duration(expiresInSeconds) * time.Second), nil
+}
@@ -8,11 +8,16 @@ package gcp
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -75,3 +80,85 @@ func TestAssertStorageBucketExistsNoFalsePositive(t *testing.T) {
 		t.Fatalf("Function claimed that the Storage Bucket '%s' exists, but in fact it does not.", gsBucketName)
 	}
 }
+
+func TestUploadAndDownloadBucketDirectory(t *testing.T) {
+	t.Parallel()
+
+	projectID := GetGoogleProjectIDFromEnvVar(t)
+	gsBucketName := "gruntwork-terratest-" + strings.ToLower(random.UniqueId())
+
+	CreateStorageBucket(t, projectID, gsBucketName, nil)
+	defer DeleteStorageBucket(t, gsBucketName)
+	defer EmptyStorageBucket(t, gsBucketName)
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "index.html"), []byte("<h1>hello</h1>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "css", "site.css"), []byte("h1 {}"), 0o644))
+
+	objectNames := UploadDirectoryToBucket(t, gsBucketName, srcDir, "site/")
+	assert.ElementsMatch(t, []string{"site/index.html", "site/css/site.css"}, objectNames)
+
+	dstDir := t.TempDir()
+	DownloadBucketDirectory(t, gsBucketName, "site/", dstDir)
+
+	body, err := os.ReadFile(filepath.Join(dstDir, "css", "site.css"))
+	require.NoError(t, err)
+	assert.Equal(t, "h1 {}", string(body))
+}
+
+func TestObjectNameForFile(t *testing.T) {
+	t.Parallel()
+
+	objectName, err := objectNameForFile("/tmp/site", filepath.Join("/tmp/site", "css", "site.css"), "static/")
+	require.NoError(t, err)
+	assert.Equal(t, "static/css/site.css", objectName)
+}
+
+func TestLocalPathForObject(t *testing.T) {
+	t.Parallel()
+
+	filePath, err := localPathForObject("/tmp/out", "site/", "site/css/site.css")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/out", "css", "site.css"), filePath)
+
+	filePath, err = localPathForObject("/tmp/out", "", "../../etc/passwd")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/out", "etc", "passwd"), filePath)
+
+	_, err = localPathForObject("/tmp/out", "site/", "site/")
+	require.Error(t, err)
+}
+
+func TestGetSignedURLExpiration(t *testing.T) {
+	t.Parallel()
+
+	signedURL := "https://storage.googleapis.com/bucket/object?X-Goog-Algorithm=GOOG4-RSA-SHA256&X-Goog-Date=20240501T120000Z&X-Goog-Expires=900&X-Goog-Signature=abc"
+	expiration, err := GetSignedURLExpirationE(signedURL)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 15, 0, 0, time.UTC), expiration)
+
+	_, err = GetSignedURLExpirationE("https://storage.googleapis.com/bucket/object")
+	require.Error(t, err)
+}
+
+func TestHasLifecycleRule(t *testing.T) {
+	t.Parallel()
+
+	lifecycle := storage.Lifecycle{Rules: []storage.LifecycleRule{
+		{Action: storage.LifecycleAction{Type: storage.DeleteAction}, Condition: storage.LifecycleCondition{AgeInDays: 30}},
+	}}
+
+	assert.True(t, hasLifecycleRule(lifecycle, storage.LifecycleRule{Action: storage.LifecycleAction{Type: storage.DeleteAction}, Condition: storage.LifecycleCondition{AgeInDays: 30}}))
+	assert.False(t, hasLifecycleRule(lifecycle, storage.LifecycleRule{Action: storage.LifecycleAction{Type: storage.DeleteAction}, Condition: storage.LifecycleCondition{AgeInDays: 7}}))
+}
+
+func TestIsKMSKeyVersionOf(t *testing.T) {
+	t.Parallel()
+
+	key := "projects/p/locations/us/keyRings/ring/cryptoKeys/key"
+	assert.True(t, isKMSKeyVersionOf(key+"/cryptoKeyVersions/1", key))
+	assert.True(t, isKMSKeyVersionOf(key, key))
+	assert.False(t, isKMSKeyVersionOf(key+"-other/cryptoKeyVersions/1", key))
+	assert.False(t, isKMSKeyVersionOf("", key))
+}
@@ -0,0 +1,541 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"google.golang.org/api/iterator"
+)
+
+// The direction of firewall rules that apply to incoming traffic.
+const firewallIngress = "INGRESS"
+
+// GetNetwork returns the VPC network with the given name.
+func GetNetwork(t testing.TestingT, projectID string, networkName string) *computepb.Network {
+	network, err := GetNetworkE(t, projectID, networkName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+// GetNetworkE returns the VPC network with the given name.
+func GetNetworkE(t testing.TestingT, projectID string, networkName string) (*computepb.Network, error) {
+	logger.Default.Logf(t, "Getting VPC network %s in project %s", networkName, projectID)
+
+	ctx := context.Background()
+	client, err := NewNetworksClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	req := &computepb.GetNetworkRequest{Project: projectID, Network: networkName}
+	network, err := client.Get(ctx, req, withReadRetries()...)
+	if err != nil {
+		return nil, fmt.Errorf("Networks.Get(%s) got error: %v", networkName, err)
+	}
+
+	return network, nil
+}
+
+// GetSubnetwork returns the subnetwork with the given name in the given region.
+func GetSubnetwork(t testing.TestingT, projectID string, region string, subnetworkName string) *computepb.Subnetwork {
+	subnetwork, err := GetSubnetworkE(t, projectID, region, subnetworkName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subnetwork
+}
+
+// GetSubnetworkE returns the subnetwork with the given name in the given region.
+func GetSubnetworkE(t testing.TestingT, projectID string, region string, subnetworkName string) (*computepb.Subnetwork, error) {
+	logger.Default.Logf(t, "Getting subnetwork %s in region %s", subnetworkName, region)
+
+	ctx := context.Background()
+	client, err := NewSubnetworksClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	req := &computepb.GetSubnetworkRequest{Project: projectID, Region: region, Subnetwork: subnetworkName}
+	subnetwork, err := client.Get(ctx, req, withReadRetries()...)
+	if err != nil {
+		return nil, fmt.Errorf("Subnetworks.Get(%s) got error: %v", subnetworkName, err)
+	}
+
+	return subnetwork, nil
+}
+
+// GetSubnetworkSecondaryRanges returns the secondary IP ranges of the given subnetwork, e.g. the Pod and Service ranges
+// of a VPC-native GKE cluster, as a map of range names to CIDR blocks.
+func GetSubnetworkSecondaryRanges(t testing.TestingT, projectID string, region string, subnetworkName string) map[string]string {
+	ranges, err := GetSubnetworkSecondaryRangesE(t, projectID, region, subnetworkName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ranges
+}
+
+// GetSubnetworkSecondaryRangesE returns the secondary IP ranges of the given subnetwork, e.g. the Pod and Service
+// ranges of a VPC-native GKE cluster, as a map of range names to CIDR blocks.
+func GetSubnetworkSecondaryRangesE(t testing.TestingT, projectID string, region string, subnetworkName string) (map[string]string, error) {
+	subnetwork, err := GetSubnetworkE(t, projectID, region, subnetworkName)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := map[string]string{}
+	for _, secondaryRange := range subnetwork.GetSecondaryIpRanges() {
+		ranges[secondaryRange.GetRangeName()] = secondaryRange.GetIpCidrRange()
+	}
+	return ranges, nil
+}
+
+// GetRoutes returns the routes of the given VPC network, including the routes GCP creates for its subnetworks and
+// for the default internet gateway.
+func GetRoutes(t testing.TestingT, projectID string, networkName string) []*computepb.Route {
+	routes, err := GetRoutesE(t, projectID, networkName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+// GetRoutesE returns the routes of the given VPC network, including the routes GCP creates for its subnetworks and
+// for the default internet gateway.
+func GetRoutesE(t testing.TestingT, projectID string, networkName string) ([]*computepb.Route, error) {
+	logger.Default.Logf(t, "Getting routes of VPC network %s in project %s", networkName, projectID)
+
+	ctx := context.Background()
+	client, err := NewRoutesClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	routes := []*computepb.Route{}
+	it := client.List(ctx, &computepb.ListRoutesRequest{Project: projectID}, withReadRetries()...)
+	for {
+		route, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Routes.List(%s) got error: %v", projectID, err)
+		}
+
+		if path.Base(route.GetNetwork()) == networkName {
+			routes = append(routes, route)
+		}
+	}
+
+	return routes, nil
+}
+
+// GetCloudNat returns the Cloud NAT gateway with the given name of the given Cloud Router.
+func GetCloudNat(t testing.TestingT, projectID string, region string, routerName string, natName string) *computepb.RouterNat {
+	nat, err := GetCloudNatE(t, projectID, region, routerName, natName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nat
+}
+
+// GetCloudNatE returns the Cloud NAT gateway with the given name of the given Cloud Router.
+func GetCloudNatE(t testing.TestingT, projectID string, region string, routerName string, natName string) (*computepb.RouterNat, error) {
+	logger.Default.Logf(t, "Getting Cloud NAT %s of Cloud Router %s in region %s", natName, routerName, region)
+
+	ctx := context.Background()
+	client, err := NewRoutersClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	req := &computepb.GetRouterRequest{Project: projectID, Region: region, Router: routerName}
+	router, err := client.Get(ctx, req, withReadRetries()...)
+	if err != nil {
+		return nil, fmt.Errorf("Routers.Get(%s) got error: %v", routerName, err)
+	}
+
+	for _, nat := range router.GetNats() {
+		if nat.GetName() == natName {
+			return nat, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Cloud NAT %s could not be found on Cloud Router %s", natName, routerName)
+}
+
+// GetFirewallRules returns the firewall rules of the given VPC network.
+func GetFirewallRules(t testing.TestingT, projectID string, networkName string) []*computepb.Firewall {
+	rules, err := GetFirewallRulesE(t, projectID, networkName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+// GetFirewallRulesE returns the firewall rules of the given VPC network. Rules of hierarchical and network firewall
+// policies are not included.
+func GetFirewallRulesE(t testing.TestingT, projectID string, networkName string) ([]*computepb.Firewall, error) {
+	logger.Default.Logf(t, "Getting firewall rules of VPC network %s in project %s", networkName, projectID)
+
+	ctx := context.Background()
+	client, err := NewFirewallsClientE(t)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	rules := []*computepb.Firewall{}
+	it := client.List(ctx, &computepb.ListFirewallsRequest{Project: projectID}, withReadRetries()...)
+	for {
+		rule, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Firewalls.List(%s) got error: %v", projectID, err)
+		}
+
+		if path.Base(rule.GetNetwork()) == networkName {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// AssertFirewallAllows checks that the firewall rules of the given VPC network allow TCP traffic from the given source
+// range (an IP or CIDR block, e.g. 0.0.0.0/0) to the given port of instances with the given network tag, and fails the
+// test if they do not.
+func AssertFirewallAllows(t testing.TestingT, projectID string, networkName string, sourceRange string, port int, targetTag string) {
+	err := AssertFirewallAllowsE(t, projectID, networkName, sourceRange, port, targetTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertFirewallAllowsE checks that the firewall rules of the given VPC network allow TCP traffic from the given
+// source range to the given port of instances with the given network tag (or of all instances, if the tag is empty),
+// and returns an error if they do not. See firewallAllowsIngressE for how the rules are evaluated.
+func AssertFirewallAllowsE(t testing.TestingT, projectID string, networkName string, sourceRange string, port int, targetTag string) error {
+	rules, err := GetFirewallRulesE(t, projectID, networkName)
+	if err != nil {
+		return err
+	}
+
+	allowed, ruleName, err := firewallAllowsIngressE(rules, sourceRange, port, targetTag)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("VPC network %s does not allow traffic from %s to port %d of instances tagged %q: %s", networkName, sourceRange, port, targetTag, firewallDecisionReason(ruleName))
+	}
+	return nil
+}
+
+// AssertFirewallDenies checks that the firewall rules of the given VPC network deny TCP traffic from the given source
+// range (an IP or CIDR block, e.g. 0.0.0.0/0) to the given port of instances with the given network tag, and fails the
+// test if they do not.
+func AssertFirewallDenies(t testing.TestingT, projectID string, networkName string, sourceRange string, port int, targetTag string) {
+	err := AssertFirewallDeniesE(t, projectID, networkName, sourceRange, port, targetTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertFirewallDeniesE checks that the firewall rules of the given VPC network deny TCP traffic from the given source
+// range to the given port of instances with the given network tag (or of all instances, if the tag is empty), either
+// explicitly or through the implied deny ingress rule, and returns an error if they do not.
+func AssertFirewallDeniesE(t testing.TestingT, projectID string, networkName string, sourceRange string, port int, targetTag string) error {
+	rules, err := GetFirewallRulesE(t, projectID, networkName)
+	if err != nil {
+		return err
+	}
+
+	allowed, ruleName, err := firewallAllowsIngressE(rules, sourceRange, port, targetTag)
+	if err != nil {
+		return err
+	}
+	if allowed {
+		return fmt.Errorf("VPC network %s allows traffic from %s to port %d of instances tagged %q through firewall rule %s", networkName, sourceRange, port, targetTag, ruleName)
+	}
+	return nil
+}
+
+// AssertSubnetworkSecondaryRange checks that the given subnetwork has a secondary IP range with the given name and CIDR
+// block, and fails the test if it does not.
+func AssertSubnetworkSecondaryRange(t testing.TestingT, projectID string, region string, subnetworkName string, rangeName string, expectedCidr string) {
+	err := AssertSubnetworkSecondaryRangeE(t, projectID, region, subnetworkName, rangeName, expectedCidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertSubnetworkSecondaryRangeE checks that the given subnetwork has a secondary IP range with the given name and
+// CIDR block, and returns an error if it does not.
+func AssertSubnetworkSecondaryRangeE(t testing.TestingT, projectID string, region string, subnetworkName string, rangeName string, expectedCidr string) error {
+	ranges, err := GetSubnetworkSecondaryRangesE(t, projectID, region, subnetworkName)
+	if err != nil {
+		return err
+	}
+
+	cidr, ok := ranges[rangeName]
+	switch {
+	case !ok:
+		return fmt.Errorf("Subnetwork %s has no secondary range %s", subnetworkName, rangeName)
+	case cidr != expectedCidr:
+		return fmt.Errorf("Secondary range %s of subnetwork %s is %s, expected %s", rangeName, subnetworkName, cidr, expectedCidr)
+	}
+	return nil
+}
+
+// NewNetworksClient creates a new Compute Engine Networks client, which is used to make VPC network API calls. The
+// caller is responsible for closing the client.
+func NewNetworksClient(t testing.TestingT) *compute.NetworksClient {
+	client, err := NewNetworksClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewNetworksClientE creates a new Compute Engine Networks client, which is used to make VPC network API calls. The
+// caller is responsible for closing the client.
+func NewNetworksClientE(t testing.TestingT) (*compute.NetworksClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewNetworksRESTClient(context.Background(), opts...)
+}
+
+// NewSubnetworksClient creates a new Compute Engine Subnetworks client, which is used to make subnetwork API calls.
+// The caller is responsible for closing the client.
+func NewSubnetworksClient(t testing.TestingT) *compute.SubnetworksClient {
+	client, err := NewSubnetworksClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewSubnetworksClientE creates a new Compute Engine Subnetworks client, which is used to make subnetwork API calls.
+// The caller is responsible for closing the client.
+func NewSubnetworksClientE(t testing.TestingT) (*compute.SubnetworksClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewSubnetworksRESTClient(context.Background(), opts...)
+}
+
+// NewRoutesClient creates a new Compute Engine Routes client, which is used to make VPC route API calls. The caller is
+// responsible for closing the client.
+func NewRoutesClient(t testing.TestingT) *compute.RoutesClient {
+	client, err := NewRoutesClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewRoutesClientE creates a new Compute Engine Routes client, which is used to make VPC route API calls. The caller
+// is responsible for closing the client.
+func NewRoutesClientE(t testing.TestingT) (*compute.RoutesClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewRoutesRESTClient(context.Background(), opts...)
+}
+
+// NewRoutersClient creates a new Compute Engine Routers client, which is used to make Cloud Router and Cloud NAT API
+// calls. The caller is responsible for closing the client.
+func NewRoutersClient(t testing.TestingT) *compute.RoutersClient {
+	client, err := NewRoutersClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewRoutersClientE creates a new Compute Engine Routers client, which is used to make Cloud Router and Cloud NAT API
+// calls. The caller is responsible for closing the client.
+func NewRoutersClientE(t testing.TestingT) (*compute.RoutersClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewRoutersRESTClient(context.Background(), opts...)
+}
+
+// NewFirewallsClient creates a new Compute Engine Firewalls client, which is used to make VPC firewall rule API calls.
+// The caller is responsible for closing the client.
+func NewFirewallsClient(t testing.TestingT) *compute.FirewallsClient {
+	client, err := NewFirewallsClientE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// NewFirewallsClientE creates a new Compute Engine Firewalls client, which is used to make VPC firewall rule API
+// calls. The caller is responsible for closing the client.
+func NewFirewallsClientE(t testing.TestingT) (*compute.FirewallsClient, error) {
+	opts, err := withOptions()
+	if err != nil {
+		return nil, err
+	}
+	return compute.NewFirewallsRESTClient(context.Background(), opts...)
+}
+
+// firewallAllowsIngressE returns whether the given VPC firewall rules allow TCP traffic from the given source range to
+// the given port of instances with the given network tag, and the name of the rule that decides it, which is empty
+// when no rule matches and the implied deny ingress rule applies. Like GCP, this picks the enabled ingress rule with
+// the lowest priority number that matches, with deny rules winning over allow rules of the same priority. A rule
+// matches the source range only if one of its source ranges contains all of it. Rules that target service accounts,
+// or that only match source tags or service accounts, are ignored, since they cannot be evaluated from a range or tag.
+func firewallAllowsIngressE(rules []*computepb.Firewall, sourceRange string, port int, targetTag string) (bool, string, error) {
+	source, err := parseFirewallRange(sourceRange)
+	if err != nil {
+		return false, "", err
+	}
+
+	matching := []*computepb.Firewall{}
+	for _, rule := range rules {
+		if rule.GetDisabled() || rule.GetDirection() != firewallIngress || len(rule.GetTargetServiceAccounts()) > 0 {
+			continue
+		}
+		if !firewallRuleTargetsTag(rule, targetTag) || !firewallRuleMatchesSource(rule, source) {
+			continue
+		}
+
+		for _, denied := range rule.GetDenied() {
+			if firewallProtocolMatches(denied.GetIPProtocol(), denied.GetPorts(), port) {
+				matching = append(matching, rule)
+			}
+		}
+		for _, allowed := range rule.GetAllowed() {
+			if firewallProtocolMatches(allowed.GetIPProtocol(), allowed.GetPorts(), port) {
+				matching = append(matching, rule)
+			}
+		}
+	}
+	if len(matching) == 0 {
+		return false, "", nil
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		if matching[i].GetPriority() != matching[j].GetPriority() {
+			return matching[i].GetPriority() < matching[j].GetPriority()
+		}
+		return len(matching[i].GetDenied()) > 0 && len(matching[j].GetDenied()) == 0
+	})
+
+	decidingRule := matching[0]
+	return len(decidingRule.GetDenied()) == 0, decidingRule.GetName(), nil
+}
+
+// firewallDecisionReason describes why traffic is denied by the given rule, or by the implied deny ingress rule if the
+// rule name is empty.
+func firewallDecisionReason(ruleName string) string {
+	if ruleName == "" {
+		return "no firewall rule matches, so the implied deny ingress rule applies"
+	}
+	return "denied by firewall rule " + ruleName
+}
+
+// firewallRuleTargetsTag returns true if the given rule applies to instances with the given network tag, i.e. if it
+// has the tag among its target tags, or if it has no target tags and thus applies to all instances.
+func firewallRuleTargetsTag(rule *computepb.Firewall, targetTag string) bool {
+	if len(rule.GetTargetTags()) == 0 {
+		return true
+	}
+	for _, tag := range rule.GetTargetTags() {
+		if tag == targetTag {
+			return true
+		}
+	}
+	return false
+}
+
+// firewallRuleMatchesSource returns true if one of the source ranges of the given rule contains the given source.
+func firewallRuleMatchesSource(rule *computepb.Firewall, source *net.IPNet) bool {
+	sourceOnes, sourceBits := source.Mask.Size()
+	for _, ruleRange := range rule.GetSourceRanges() {
+		ruleNet, err := parseFirewallRange(ruleRange)
+		if err != nil {
+			continue
+		}
+		ruleOnes, ruleBits := ruleNet.Mask.Size()
+		if ruleBits == sourceBits && ruleOnes <= sourceOnes && ruleNet.Contains(source.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// firewallProtocolMatches returns true if the given protocol and ports of a rule cover TCP traffic to the given port.
+// Ports are single ports (e.g. "443") or ranges (e.g. "8000-8100"), and an empty list covers all ports.
+func firewallProtocolMatches(protocol string, ports []string, port int) bool {
+	if protocol != "tcp" && protocol != "all" {
+		return false
+	}
+	if len(ports) == 0 {
+		return true
+	}
+
+	for _, portRange := range ports {
+		from, to, isRange := strings.Cut(portRange, "-")
+		if !isRange {
+			to = from
+		}
+		fromPort, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		toPort, err := strconv.Atoi(to)
+		if err != nil {
+			continue
+		}
+		if fromPort <= port && port <= toPort {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFirewallRange parses the given IP or CIDR block, e.g. 10.0.0.1 or 10.0.0.0/8.
+func parseFirewallRange(ipRange string) (*net.IPNet, error) {
+	if !strings.Contains(ipRange, "/") {
+		ip := net.ParseIP(ipRange)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP range %q", ipRange)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(ipRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP range %q: %v", ipRange, err)
+	}
+	return ipNet, nil
+}
@@ -0,0 +1,84 @@
+//go:build gcp
+// +build gcp
+
+// NOTE: We use build tags to differentiate GCP testing for better isolation and parallelism when executing our tests.
+
+package gcp
+
+import (
+	"testing"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFirewallAllowsIngress(t *testing.T) {
+	t.Parallel()
+
+	rules := []*computepb.Firewall{
+		{
+			Name:         proto.String("allow-https"),
+			Direction:    proto.String("INGRESS"),
+			Priority:     proto.Int32(1000),
+			SourceRanges: []string{"0.0.0.0/0"},
+			TargetTags:   []string{"web"},
+			Allowed:      []*computepb.Allowed{{IPProtocol: proto.String("tcp"), Ports: []string{"443", "8000-8100"}}},
+		},
+		{
+			Name:         proto.String("deny-blocked-range"),
+			Direction:    proto.String("INGRESS"),
+			Priority:     proto.Int32(900),
+			SourceRanges: []string{"203.0.113.0/24"},
+			Denied:       []*computepb.Denied{{IPProtocol: proto.String("all")}},
+		},
+		{
+			Name:         proto.String("allow-internal"),
+			Direction:    proto.String("INGRESS"),
+			Priority:     proto.Int32(65534),
+			SourceRanges: []string{"10.0.0.0/8"},
+			Allowed:      []*computepb.Allowed{{IPProtocol: proto.String("all")}},
+		},
+		{
+			Name:         proto.String("allow-ssh-disabled"),
+			Direction:    proto.String("INGRESS"),
+			Priority:     proto.Int32(1000),
+			Disabled:     proto.Bool(true),
+			SourceRanges: []string{"0.0.0.0/0"},
+			Allowed:      []*computepb.Allowed{{IPProtocol: proto.String("tcp"), Ports: []string{"22"}}},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		sourceRange  string
+		port         int
+		targetTag    string
+		expected     bool
+		expectedRule string
+	}{
+		{"https from anywhere to web", "0.0.0.0/0", 443, "web", true, "allow-https"},
+		{"port range to web", "198.51.100.7", 8080, "web", true, "allow-https"},
+		{"https to untagged instances", "0.0.0.0/0", 443, "", false, ""},
+		{"blocked range to web", "203.0.113.10", 443, "web", false, "deny-blocked-range"},
+		{"internal subnet to any port", "10.1.0.0/16", 5432, "db", true, "allow-internal"},
+		{"range wider than internal", "10.0.0.0/7", 5432, "db", false, ""},
+		{"disabled ssh rule", "0.0.0.0/0", 22, "", false, ""},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			allowed, ruleName, err := firewallAllowsIngressE(rules, testCase.sourceRange, testCase.port, testCase.targetTag)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, allowed)
+			assert.Equal(t, testCase.expectedRule, ruleName)
+		})
+	}
+
+	_, _, err := firewallAllowsIngressE(rules, "not-an-ip", 443, "web")
+	assert.Error(t, err)
+}
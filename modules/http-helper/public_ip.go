@@ -0,0 +1,45 @@
+package http_helper
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// testRunnerIPURL is the URL of a service that returns the public IP of the caller as plain text.
+const testRunnerIPURL = "https://checkip.amazonaws.com"
+
+// GetTestRunnerPublicIP returns the public IP of the machine running the tests, e.g., to allow it through the firewall
+// of a database. This will fail the test if there is an error.
+func GetTestRunnerPublicIP(t testing.TestingT) string {
+	ip, err := GetTestRunnerPublicIPE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ip
+}
+
+// GetTestRunnerPublicIPE returns the public IP of the machine running the tests, e.g., to allow it through the firewall
+// of a database.
+func GetTestRunnerPublicIPE(t testing.TestingT) (string, error) {
+	return getPublicIPE(t, testRunnerIPURL)
+}
+
+// getPublicIPE returns the IP returned by the given URL, which must return the IP of the caller as plain text. Anything
+// other than a 200 response with a valid IP (e.g., a rate limiting page) is an error.
+func getPublicIPE(t testing.TestingT, url string) (string, error) {
+	statusCode, body, err := HttpGetWithOptionsE(t, HttpGetOptions{Url: url, Timeout: 10})
+	if err != nil {
+		return "", err
+	}
+	if statusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned status code %d: %q", url, statusCode, body)
+	}
+	if net.ParseIP(body) == nil {
+		return "", fmt.Errorf("%s returned an invalid IP: %q", url, body)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,49 @@
+package http_helper
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPublicIP(t *testing.T) {
+	t.Parallel()
+
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "203.0.113.7")
+	})
+	defer ts.Close()
+
+	ip, err := getPublicIPE(t, ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", ip)
+}
+
+func TestGetPublicIPInvalidResponse(t *testing.T) {
+	t.Parallel()
+
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html>rate limited</html>")
+	})
+	defer ts.Close()
+
+	_, err := getPublicIPE(t, ts.URL)
+	assert.Error(t, err)
+}
+
+func TestGetPublicIPErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		// A valid IP, but not from a successful response
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprintln(w, "203.0.113.7")
+	})
+	defer ts.Close()
+
+	_, err := getPublicIPE(t, ts.URL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// NewKubectlOptionsForEksCluster returns KubectlOptions to use the given EKS cluster (e.g., one created by terraform)
+// in the given namespace. This will fail the test if there is an error.
+func NewKubectlOptionsForEksCluster(t testing.TestingT, region string, clusterName string, namespace string) *KubectlOptions {
+	options, err := NewKubectlOptionsForEksClusterE(t, region, clusterName, namespace)
+	require.NoError(t, err)
+	return options
+}
+
+// NewKubectlOptionsForEksClusterE returns KubectlOptions to use the given EKS cluster (e.g., one created by terraform)
+// in the given namespace, without shelling out to `aws eks update-kubeconfig`. This writes a kubeconfig to a temp file,
+// with a token for the AWS identity of the test, which works both for the Kubernetes client and for kubectl. Note that
+// the token is only valid for aws.EksTokenLifetime: call this function again to refresh it in long running tests.
+func NewKubectlOptionsForEksClusterE(t testing.TestingT, region string, clusterName string, namespace string) (*KubectlOptions, error) {
+	auth, err := aws.GetEksClusterAuthE(t, region, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	configData, err := clientcmd.Write(eksKubeConfig(clusterName, auth))
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := StoreConfigToTempFileE(t, string(configData))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKubectlOptions(clusterName, configPath, namespace), nil
+}
+
+// eksKubeConfig returns a kubeconfig with a single context, named after the given EKS cluster, to connect to it with
+// the given auth.
+func eksKubeConfig(clusterName string, auth aws.EksClusterAuth) api.Config {
+	config := api.NewConfig()
+	config.Clusters[clusterName] = &api.Cluster{
+		Server:                   auth.Endpoint,
+		CertificateAuthorityData: auth.CertificateAuthorityData,
+	}
+	config.AuthInfos[clusterName] = &api.AuthInfo{Token: auth.Token}
+	UpsertConfigContext(config, clusterName, clusterName, clusterName)
+	config.CurrentContext = clusterName
+	return *config
+}
@@ -0,0 +1,33 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/gruntwork-io/terratest/modules/aws"
+)
+
+func TestEksKubeConfig(t *testing.T) {
+	t.Parallel()
+
+	auth := aws.EksClusterAuth{
+		Endpoint:                 "https://ABC.gr7.us-east-1.eks.amazonaws.com",
+		CertificateAuthorityData: []byte("-----BEGIN CERTIFICATE-----\n"),
+		Token:                    "k8s-aws-v1.token",
+	}
+
+	data, err := clientcmd.Write(eksKubeConfig("my-cluster", auth))
+	require.NoError(t, err)
+	config, err := clientcmd.Load(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-cluster", config.CurrentContext)
+	require.Contains(t, config.Contexts, "my-cluster")
+	assert.Equal(t, "my-cluster", config.Contexts["my-cluster"].Cluster)
+	assert.Equal(t, auth.Endpoint, config.Clusters["my-cluster"].Server)
+	assert.Equal(t, auth.CertificateAuthorityData, config.Clusters["my-cluster"].CertificateAuthorityData)
+	assert.Equal(t, auth.Token, config.AuthInfos["my-cluster"].Token)
+}
@@ -127,7 +127,7 @@ func BuildArtifactE(t testing.TestingT, options *Options) (string, error) {
 		WorkingDir: options.WorkingDir,
 	}
 
-	description := fmt.Sprintf("%s %v", cmd.Command, cmd.Args)
+	description := shell.FormatCommandLine(cmd.Command, cmd.Args)
 	output, err := retry.DoWithRetryableErrorsE(t, description, options.RetryableErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
 		return shell.RunCommandAndGetOutputE(t, cmd)
 	})
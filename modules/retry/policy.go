@@ -0,0 +1,161 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// Policy decides whether and when to retry an action that failed.
+type Policy interface {
+	// NextRetry is called after the given attempt (starting at 1) of the action failed with the given output and
+	// error. It returns how long to sleep before the next attempt, or, if the action should not be retried, the error
+	// to return instead (e.g., a FatalError or a MaxRetriesExceeded error).
+	NextRetry(attempt int, output string, err error) (time.Duration, error)
+}
+
+// RetryableError is a class of errors that a BackoffPolicy retries.
+type RetryableError struct {
+	// A regexp to match against the error and the output of the action.
+	Pattern string
+
+	// What to display to a user if the error is matched.
+	Message string
+
+	// The maximum number of retries for this class of errors. Defaults to the MaxRetries of the policy.
+	MaxRetries int
+}
+
+// RetryableErrorsFromMap converts a map of regexps to messages, as used by DoWithRetryableErrors, to a list of
+// RetryableError with the given maximum number of retries (0 to use the MaxRetries of the policy), sorted by pattern.
+func RetryableErrorsFromMap(retryableErrors map[string]string, maxRetries int) []RetryableError {
+	out := make([]RetryableError, 0, len(retryableErrors))
+	for pattern, message := range retryableErrors {
+		out = append(out, RetryableError{Pattern: pattern, Message: message, MaxRetries: maxRetries})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Pattern < out[j].Pattern })
+	return out
+}
+
+// BackoffPolicy is a Policy that retries with an exponentially increasing sleep between attempts. Set Multiplier to 1
+// for a fixed sleep.
+type BackoffPolicy struct {
+	// The errors to retry. If empty, all errors are retried. If not, errors that don't match any of them are returned
+	// immediately, wrapped in a FatalError.
+	RetryableErrors []RetryableError
+
+	// The maximum number of retries, unless overridden by the matching RetryableError.
+	MaxRetries int
+
+	// The sleep before the first retry.
+	InitialDelay time.Duration
+
+	// The maximum sleep between retries. No maximum if zero.
+	MaxDelay time.Duration
+
+	// The factor the sleep is multiplied by after each retry. Defaults to 2.
+	Multiplier float64
+
+	// The fraction (between 0 and 1) of the sleep that is randomly added or removed, so that parallel tests that
+	// failed at the same time don't all hit a rate-limited API again at the same time.
+	Jitter float64
+}
+
+// NextRetry implements Policy.
+func (policy BackoffPolicy) NextRetry(attempt int, output string, err error) (time.Duration, error) {
+	maxRetries := policy.MaxRetries
+	if len(policy.RetryableErrors) > 0 {
+		retryableError, matchErr := policy.matchingError(output, err)
+		if matchErr != nil {
+			return 0, FatalError{Underlying: matchErr}
+		}
+		if retryableError == nil {
+			return 0, FatalError{Underlying: err}
+		}
+		if retryableError.MaxRetries > 0 {
+			maxRetries = retryableError.MaxRetries
+		}
+	}
+
+	if attempt > maxRetries {
+		return 0, MaxRetriesExceeded{MaxRetries: maxRetries}
+	}
+	return policy.delay(attempt), nil
+}
+
+// matchingError returns the first RetryableError that matches the given output or error, or nil if none do.
+func (policy BackoffPolicy) matchingError(output string, err error) (*RetryableError, error) {
+	for i := range policy.RetryableErrors {
+		errorRegexp, compileErr := regexp.Compile(policy.RetryableErrors[i].Pattern)
+		if compileErr != nil {
+			return nil, compileErr
+		}
+		if errorRegexp.MatchString(output) || errorRegexp.MatchString(err.Error()) {
+			return &policy.RetryableErrors[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// delay returns the sleep before the retry that follows the given attempt.
+func (policy BackoffPolicy) delay(attempt int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(policy.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxDelay > 0 && delay > float64(policy.MaxDelay) {
+		delay = float64(policy.MaxDelay)
+	}
+	if policy.Jitter > 0 {
+		delay += delay * policy.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// DoWithPolicy runs the specified action. If it returns a value, return that value. If it returns an error, ask the
+// given policy whether and when to retry it. If the policy gives up, fail the test.
+func DoWithPolicy(t testing.TestingT, actionDescription string, policy Policy, action func() (string, error)) string {
+	out, err := DoWithPolicyE(t, actionDescription, policy, action)
+	require.NoError(t, err)
+	return out
+}
+
+// DoWithPolicyE runs the specified action. If it returns a value, return that value. If it returns a FatalError, return
+// that error immediately. If it returns any other error, ask the given policy whether and when to retry it. If the
+// policy gives up, return the error returned by the policy.
+func DoWithPolicyE(t testing.TestingT, actionDescription string, policy Policy, action func() (string, error)) (string, error) {
+	for attempt := 1; ; attempt++ {
+		logger.Default.Logf(t, "%s", actionDescription)
+
+		output, err := action()
+		if err == nil {
+			return output, nil
+		}
+
+		if _, isFatalErr := err.(FatalError); isFatalErr {
+			logger.Default.Logf(t, "Returning due to fatal error: %v", err)
+			return output, err
+		}
+
+		sleep, stopErr := policy.NextRetry(attempt, output, err)
+		if stopErr != nil {
+			if exceeded, isExceeded := stopErr.(MaxRetriesExceeded); isExceeded && exceeded.Description == "" {
+				exceeded.Description = actionDescription
+				stopErr = exceeded
+			}
+			logger.Default.Logf(t, "Not retrying '%s': %v", actionDescription, stopErr)
+			return output, stopErr
+		}
+
+		logger.Default.Logf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleep)
+		time.Sleep(sleep)
+	}
+}
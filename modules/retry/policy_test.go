@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := BackoffPolicy{MaxRetries: 10, InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, expectedDelay := range expected {
+		delay, err := policy.NextRetry(i+1, "", errors.New("error"))
+		require.NoError(t, err)
+		assert.Equal(t, expectedDelay, delay)
+	}
+
+	policy.Multiplier = 1
+	delay, err := policy.NextRetry(5, "", errors.New("error"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestBackoffPolicyJitter(t *testing.T) {
+	t.Parallel()
+
+	policy := BackoffPolicy{MaxRetries: 1, InitialDelay: 10 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		delay, err := policy.NextRetry(1, "", errors.New("error"))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, delay, 5*time.Second)
+		assert.LessOrEqual(t, delay, 15*time.Second)
+	}
+}
+
+func TestBackoffPolicyRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	policy := BackoffPolicy{
+		MaxRetries: 1,
+		RetryableErrors: []RetryableError{
+			{Pattern: "Throttling", Message: "Rate limited", MaxRetries: 5},
+			{Pattern: "timeout", Message: "Transient network error"},
+		},
+	}
+
+	_, err := policy.NextRetry(1, "", errors.New("AccessDenied"))
+	assert.Equal(t, FatalError{Underlying: errors.New("AccessDenied")}, err)
+
+	_, err = policy.NextRetry(2, "i/o timeout", errors.New("exit status 1"))
+	assert.Equal(t, MaxRetriesExceeded{MaxRetries: 1}, err)
+
+	_, err = policy.NextRetry(5, "", errors.New("Throttling: Rate exceeded"))
+	assert.NoError(t, err)
+	_, err = policy.NextRetry(6, "", errors.New("Throttling: Rate exceeded"))
+	assert.Equal(t, MaxRetriesExceeded{MaxRetries: 5}, err)
+}
+
+func TestRetryableErrorsFromMap(t *testing.T) {
+	t.Parallel()
+
+	actual := RetryableErrorsFromMap(map[string]string{"b": "B", "a": "A"}, 2)
+	assert.Equal(t, []RetryableError{{Pattern: "a", Message: "A", MaxRetries: 2}, {Pattern: "b", Message: "B", MaxRetries: 2}}, actual)
+}
+
+func TestDoWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := BackoffPolicy{MaxRetries: 2, InitialDelay: time.Millisecond}
+
+	attempts := 0
+	out, err := DoWithPolicyE(t, "succeeds on the second attempt", policy, func() (string, error) {
+		attempts++
+		if attempts < 2 {
+			return "", fmt.Errorf("attempt %d failed", attempts)
+		}
+		return "done", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", out)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	_, err = DoWithPolicyE(t, "always fails", policy, func() (string, error) {
+		attempts++
+		return "", fmt.Errorf("attempt %d failed", attempts)
+	})
+	assert.Equal(t, MaxRetriesExceeded{Description: "always fails", MaxRetries: 2}, err)
+	assert.Equal(t, 3, attempts)
+}
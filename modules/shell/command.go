@@ -24,6 +24,15 @@ type Command struct {
 	Env        map[string]string // Additional environment variables to set
 	// Use the specified logger for the command's output. Use logger.Discard to not print the output while executing the command.
 	Logger *logger.Logger
+	// If set, called with each line of stdout and stderr as soon as the command produces it, e.g., to report progress
+	// of long-running commands. Calls for stdout and stderr lines may happen concurrently.
+	OutputLineCallback func(line string, isStderr bool)
+	// If set, the merged stdout and stderr of the command are appended to this file as the command produces them. The
+	// file is created with mode 0600 if it doesn't exist, as the output may hold secrets.
+	OutputSpoolPath string
+	// If greater than zero, only the last MaxOutputLines lines of stdout and stderr are kept in memory and returned.
+	// Combine with OutputSpoolPath to keep the full output of commands with huge outputs without running out of memory.
+	MaxOutputLines int
 }
 
 // RunCommand runs a shell command and redirects its stdout and stderr to the stdout of the atomic script itself. If
@@ -118,13 +127,24 @@ func (e *ErrWithCmdOutput) Error() string {
 // stdout and stderr of that command will also be printed to the stdout and stderr of this Go program to make debugging
 // easier.
 func runCommand(t testing.TestingT, command Command) (*output, error) {
-	command.Logger.Logf(t, "Running command %s with args %s", command.Command, command.Args)
+	command.Logger.Logf(t, "Running command %s", FormatCommandLine(command.Command, command.Args))
 
 	cmd := exec.Command(command.Command, command.Args...)
 	cmd.Dir = command.WorkingDir
 	cmd.Stdin = os.Stdin
 	cmd.Env = formatEnvVars(command)
 
+	var spool io.Writer
+	if command.OutputSpoolPath != "" {
+		spoolFile, err := os.OpenFile(command.OutputSpoolPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		defer spoolFile.Close()
+		spool = spoolFile
+	}
+	out := newBoundedOutput(command.MaxOutputLines, spool)
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -140,7 +160,7 @@ func runCommand(t testing.TestingT, command Command) (*output, error) {
 		return nil, err
 	}
 
-	output, err := readStdoutAndStderr(t, command.Logger, stdout, stderr)
+	output, err := readStdoutAndStderr(t, command.Logger, command.OutputLineCallback, stdout, stderr, out)
 	if err != nil {
 		return output, err
 	}
@@ -150,8 +170,7 @@ func runCommand(t testing.TestingT, command Command) (*output, error) {
 
 // This function captures stdout and stderr into the given variables while still printing it to the stdout and stderr
 // of this Go program
-func readStdoutAndStderr(t testing.TestingT, log *logger.Logger, stdout, stderr io.ReadCloser) (*output, error) {
-	out := newOutput()
+func readStdoutAndStderr(t testing.TestingT, log *logger.Logger, callback func(string, bool), stdout, stderr io.ReadCloser, out *output) (*output, error) {
 	stdoutReader := bufio.NewReader(stdout)
 	stderrReader := bufio.NewReader(stderr)
 
@@ -161,11 +180,11 @@ func readStdoutAndStderr(t testing.TestingT, log *logger.Logger, stdout, stderr
 	var stdoutErr, stderrErr error
 	go func() {
 		defer wg.Done()
-		stdoutErr = readData(t, log, stdoutReader, out.stdout)
+		stdoutErr = readData(t, log, stdoutReader, out.stdout, lineCallback(callback, false))
 	}()
 	go func() {
 		defer wg.Done()
-		stderrErr = readData(t, log, stderrReader, out.stderr)
+		stderrErr = readData(t, log, stderrReader, out.stderr, lineCallback(callback, true))
 	}()
 	wg.Wait()
 
@@ -179,7 +198,17 @@ func readStdoutAndStderr(t testing.TestingT, log *logger.Logger, stdout, stderr
 	return out, nil
 }
 
-func readData(t testing.TestingT, log *logger.Logger, reader *bufio.Reader, writer io.StringWriter) error {
+// lineCallback adapts the given output line callback to a single stream. Returns nil if callback is nil.
+func lineCallback(callback func(string, bool), isStderr bool) func(string) {
+	if callback == nil {
+		return nil
+	}
+	return func(line string) {
+		callback(line, isStderr)
+	}
+}
+
+func readData(t testing.TestingT, log *logger.Logger, reader *bufio.Reader, writer io.StringWriter, callback func(string)) error {
 	var line string
 	var readErr error
 	for {
@@ -205,6 +234,10 @@ func readData(t testing.TestingT, log *logger.Logger, reader *bufio.Reader, writ
 		// See https://github.com/gruntwork-io/terratest/issues/982.
 		log.Logf(t, "%s", line)
 
+		if callback != nil {
+			callback(line)
+		}
+
 		if _, err := writer.WriteString(line); err != nil {
 			return err
 		}
@@ -3,8 +3,11 @@ package shell
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -210,3 +213,62 @@ func TestCommandWithStdoutAndStdErr(t *testing.T) {
 	})
 
 }
+
+func TestRunCommandWithOutputLineCallback(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	var stdoutLines, stderrLines []string
+	cmd := Command{
+		Command: "bash",
+		Args:    []string{"-c", `echo "Still creating..."; (>&2 echo "oops"); echo "Creation complete"`},
+		OutputLineCallback: func(line string, isStderr bool) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if isStderr {
+				stderrLines = append(stderrLines, line)
+			} else {
+				stdoutLines = append(stdoutLines, line)
+			}
+		},
+	}
+
+	RunCommand(t, cmd)
+	assert.Equal(t, []string{"Still creating...", "Creation complete"}, stdoutLines)
+	assert.Equal(t, []string{"oops"}, stderrLines)
+}
+
+func TestRunCommandWithOutputSpooling(t *testing.T) {
+	t.Parallel()
+
+	spoolPath := filepath.Join(t.TempDir(), "output.log")
+	cmd := Command{
+		Command:         "bash",
+		Args:            []string{"-c", `for i in $(seq 1 100); do echo "line $i"; done`},
+		Logger:          logger.Discard,
+		OutputSpoolPath: spoolPath,
+		MaxOutputLines:  3,
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, "line 98\nline 99\nline 100", out)
+
+	spooled, err := os.ReadFile(spoolPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(spooled), "\n"), "\n")
+	require.Len(t, lines, 100)
+	assert.Equal(t, "line 1", lines[0])
+	assert.Equal(t, "line 100", lines[99])
+}
+
+func TestAppendBounded(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = appendBounded(lines, fmt.Sprint(i), 3)
+	}
+	assert.Equal(t, []string{"8", "9", "10"}, lines)
+
+	assert.Equal(t, []string{"1", "2"}, appendBounded([]string{"1"}, "2", 0))
+}
@@ -1,6 +1,7 @@
 package shell
 
 import (
+	"io"
 	"strings"
 	"sync"
 )
@@ -13,8 +14,10 @@ type output struct {
 	merged *merged
 }
 
-func newOutput() *output {
-	m := new(merged)
+// newBoundedOutput returns an output that only keeps the last maxLines lines of each stream in memory (all of them if
+// maxLines is zero), and also writes every line to spool, if it is not nil.
+func newBoundedOutput(maxLines int, spool io.Writer) *output {
+	m := &merged{maxLines: maxLines, spool: spool}
 	return &output{
 		merged: m,
 		stdout: &outputStream{
@@ -56,7 +59,7 @@ type outputStream struct {
 }
 
 func (st *outputStream) WriteString(s string) (n int, err error) {
-	st.Lines = append(st.Lines, string(s))
+	st.Lines = appendBounded(st.Lines, s, st.merged.maxLines)
 	return st.merged.WriteString(s)
 }
 
@@ -72,6 +75,11 @@ type merged struct {
 	// ensure that there are no parallel writes
 	sync.Mutex
 	Lines []string
+
+	// if greater than zero, only the last maxLines lines are kept
+	maxLines int
+	// if set, every line is also written to it
+	spool io.Writer
 }
 
 func (m *merged) String() string {
@@ -86,7 +94,27 @@ func (m *merged) WriteString(s string) (n int, err error) {
 	m.Lock()
 	defer m.Unlock()
 
-	m.Lines = append(m.Lines, string(s))
+	m.Lines = appendBounded(m.Lines, s, m.maxLines)
+	if m.spool != nil {
+		if _, err := io.WriteString(m.spool, s+"\n"); err != nil {
+			return 0, err
+		}
+	}
 
 	return len(s), nil
 }
+
+// appendBounded appends line to lines, dropping the oldest lines so that at most maxLines are kept if maxLines is
+// greater than zero.
+func appendBounded(lines []string, line string, maxLines int) []string {
+	lines = append(lines, line)
+	if maxLines <= 0 || len(lines) <= maxLines {
+		return lines
+	}
+	// Copy the tail once the slice has grown to twice the limit, so the dropped lines can be garbage collected without
+	// copying on every append
+	if len(lines) >= 2*maxLines {
+		return append(make([]string, 0, 2*maxLines), lines[len(lines)-maxLines:]...)
+	}
+	return lines[1:]
+}
@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// posixSafeArg matches the args that don't need to be quoted for a POSIX shell.
+var posixSafeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// QuoteArg quotes the given arg so that the shell of the current OS (cmd.exe on Windows, a POSIX shell otherwise)
+// passes it to a command as a single, unmodified arg.
+func QuoteArg(arg string) string {
+	return QuoteArgForOS(arg, runtime.GOOS)
+}
+
+// QuoteArgForOS quotes the given arg so that the shell of the given OS (a GOOS value, e.g., "windows" or "linux")
+// passes it to a command as a single, unmodified arg.
+func QuoteArgForOS(arg string, goos string) string {
+	if goos == "windows" {
+		return QuoteWindowsArg(arg)
+	}
+	return QuotePosixArg(arg)
+}
+
+// QuotePosixArg quotes the given arg for a POSIX shell. Args that only contain safe characters are returned as is;
+// everything else is single quoted, so that no expansion (e.g., of $VARS) happens.
+func QuotePosixArg(arg string) string {
+	if posixSafeArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// QuoteWindowsArg quotes the given arg following the rules of CommandLineToArgvW, which is how most Windows programs
+// (including Go ones, such as terraform and packer) split their command line into args. Backslashes are only special
+// when they precede a double quote, so Windows paths are left intact.
+func QuoteWindowsArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(arg, " \t\n\v\"") {
+		return arg
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	backslashes := 0
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			// Escape the preceding backslashes and the quote itself
+			sb.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			sb.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		sb.WriteRune(r)
+		backslashes = 0
+	}
+	// Escape trailing backslashes, so they don't escape the closing quote
+	sb.WriteString(strings.Repeat(`\`, backslashes*2))
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// FormatCommandLine returns the given command and args as a single command line, quoted for the shell of the current
+// OS. This is mainly useful for logs, as it shows unambiguously where each arg starts and ends, and can be copy/pasted
+// to re-run the command.
+func FormatCommandLine(command string, args []string) string {
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, QuoteArg(command))
+	for _, arg := range args {
+		quoted = append(quoted, QuoteArg(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// QuoteHclString returns the given string as a double quoted HCL string literal (e.g., for a string nested in a list or
+// map var passed to terraform or packer with -var). Quotes, backslashes (e.g., in Windows paths), and control
+// characters are escaped, and template sequences (${ and %{) are escaped so they are not interpolated.
+func QuoteHclString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	runes := []rune(s)
+	for i, r := range runes {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '$', '%':
+			if i+1 < len(runes) && runes[i+1] == '{' {
+				sb.WriteRune(r)
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotePosixArg(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arg      string
+		expected string
+	}{
+		{"", `''`},
+		{"apply", "apply"},
+		{"-var-file=/tmp/foo.tfvars", "-var-file=/tmp/foo.tfvars"},
+		{"two words", `'two words'`},
+		{"$HOME", `'$HOME'`},
+		{"it's", `'it'\''s'`},
+		{`foo={"key" = "value"}`, `'foo={"key" = "value"}'`},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, QuotePosixArg(testCase.arg), testCase.arg)
+	}
+}
+
+func TestQuoteWindowsArg(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arg      string
+		expected string
+	}{
+		{"", `""`},
+		{"apply", "apply"},
+		{`C:\Program Files\foo`, `"C:\Program Files\foo"`},
+		{`C:\tmp\plan.out`, `C:\tmp\plan.out`},
+		{`C:\my dir\`, `"C:\my dir\\"`},
+		{`foo={"key" = "value"}`, `"foo={\"key\" = \"value\"}"`},
+		{`a\"b`, `"a\\\"b"`},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, QuoteWindowsArg(testCase.arg), testCase.arg)
+	}
+}
+
+func TestQuoteArgForOS(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `'two words'`, QuoteArgForOS("two words", "linux"))
+	assert.Equal(t, `"two words"`, QuoteArgForOS("two words", "windows"))
+}
+
+func TestQuoteHclString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"", `""`},
+		{"foo", `"foo"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\tmp\foo`, `"C:\\tmp\\foo"`},
+		{"line1\nline2", `"line1\nline2"`},
+		{"${var.foo} and %{if}", `"$${var.foo} and %%{if}"`},
+		{"100% $5", `"100% $5"`},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, QuoteHclString(testCase.value), testCase.value)
+	}
+}
@@ -2,6 +2,7 @@
 package ssh
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
@@ -10,11 +11,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gruntwork-io/terratest/modules/files"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/hashicorp/go-multierror"
 	"golang.org/x/crypto/ssh"
@@ -261,6 +264,134 @@ func CheckSshCommandE(t testing.TestingT, host Host, command string) (string, er
 	return runSSHCommand(t, sshSession)
 }
 
+// RunSshCommandAndGetStdOutErr connects via SSH to the given host and runs the given command, streaming each line of
+// stdout and stderr to the given logger as it is produced. Returns the stdout and stderr separately. This will fail the
+// test if the command can't be run or exits with a non-zero exit code.
+func RunSshCommandAndGetStdOutErr(t testing.TestingT, host Host, command string, log *logger.Logger) (string, string) {
+	stdout, stderr, err := RunSshCommandAndGetStdOutErrE(t, host, command, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stdout, stderr
+}
+
+// RunSshCommandAndGetStdOutErrE connects via SSH to the given host and runs the given command, streaming each line of
+// stdout and stderr to the given logger as it is produced. Returns the stdout and stderr separately. If the command
+// exits with a non-zero exit code, the returned error can be passed to GetExitCodeForSshCommandError to retrieve it.
+func RunSshCommandAndGetStdOutErrE(t testing.TestingT, host Host, command string, log *logger.Logger) (string, string, error) {
+	return RunSshCommandAndStreamOutputE(t, host, command, log, nil)
+}
+
+// RunSshCommandAndStreamOutputE works like RunSshCommandAndGetStdOutErrE, but additionally calls outputLineCallback, if
+// not nil, with each line of stdout and stderr as soon as the remote command produces it.
+func RunSshCommandAndStreamOutputE(t testing.TestingT, host Host, command string, log *logger.Logger, outputLineCallback func(line string, isStderr bool)) (string, string, error) {
+	authMethods, err := createAuthMethodsForHost(host)
+	if err != nil {
+		return "", "", err
+	}
+
+	hostOptions := SshConnectionOptions{
+		Username:    host.SshUserName,
+		Address:     host.Hostname,
+		Port:        host.getPort(),
+		Command:     command,
+		AuthMethods: authMethods,
+	}
+
+	sshSession := &SshSession{
+		Options:  &hostOptions,
+		JumpHost: &JumpHostSession{},
+	}
+
+	defer sshSession.Cleanup(t)
+
+	return runSSHCommandAndStreamOutput(t, sshSession, log, outputLineCallback)
+}
+
+// GetExitCodeForSshCommandError returns the exit code of the remote command from an error returned by
+// RunSshCommandAndGetStdOutErrE. If err is nil, this returns 0.
+func GetExitCodeForSshCommandError(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	return 1, fmt.Errorf("could not determine exit code: %w", err)
+}
+
+// ScpDirTo uploads all the files in localDir, recursively, to remoteDir on the given host using SCP. If filter is not
+// nil, only the files and folders for which it returns true are uploaded. This will fail the test if there is an error.
+func ScpDirTo(t testing.TestingT, host Host, localDir string, remoteDir string, filter func(path string) bool) {
+	err := ScpDirToE(t, host, localDir, remoteDir, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ScpDirToE uploads all the files in localDir, recursively, to remoteDir on the given host using SCP. If filter is not
+// nil, only the files and folders for which it returns true are uploaded. Symlinks are not followed.
+func ScpDirToE(t testing.TestingT, host Host, localDir string, remoteDir string, filter func(path string) bool) error {
+	remoteDirs := []string{remoteDir}
+	localFiles := map[string]os.FileInfo{}
+
+	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == localDir {
+			return nil
+		}
+		if filter != nil && !filter(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		remotePath := remoteDir + "/" + filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			remoteDirs = append(remoteDirs, remotePath)
+		} else if info.Mode().IsRegular() {
+			localFiles[path] = info
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	quotedDirs := make([]string, len(remoteDirs))
+	for i, dir := range remoteDirs {
+		quotedDirs[i] = shell.QuotePosixArg(dir)
+	}
+	if _, err := CheckSshCommandE(t, host, "mkdir -p "+strings.Join(quotedDirs, " ")); err != nil {
+		return err
+	}
+
+	for path, info := range localFiles {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		if err := ScpFileToE(t, host, info.Mode().Perm(), remoteDir+"/"+filepath.ToSlash(relPath), string(contents)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CheckSshCommandWithRetry checks that you can connect via SSH to the given host and run the given command until max retries have been exceeded. Returns the stdout/stderr.
 func CheckSshCommandWithRetry(t testing.TestingT, host Host, command string, retries int, sleepBetweenRetries time.Duration, f ...func(testing.TestingT, Host, string) (string, error)) string {
 	handler := CheckSshCommandE
@@ -496,6 +627,77 @@ func runSSHCommand(t testing.TestingT, sshSession *SshSession) (string, error) {
 	return string(bytes), nil
 }
 
+// runSSHCommandAndStreamOutput runs the command of the given session, logging each line of stdout and stderr as it is
+// produced, and returns stdout and stderr separately once the command has completed.
+func runSSHCommandAndStreamOutput(t testing.TestingT, sshSession *SshSession, log *logger.Logger, callback func(string, bool)) (string, string, error) {
+	log.Logf(t, "Running command %s on %s@%s", sshSession.Options.Command, sshSession.Options.Username, sshSession.Options.Address)
+	if err := setUpSSHClient(sshSession); err != nil {
+		return "", "", err
+	}
+
+	if err := setUpSSHSession(sshSession); err != nil {
+		return "", "", err
+	}
+
+	stdoutPipe, err := sshSession.Session.StdoutPipe()
+	if err != nil {
+		return "", "", err
+	}
+	stderrPipe, err := sshSession.Session.StderrPipe()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := sshSession.Session.Start(sshSession.Options.Command); err != nil {
+		return "", "", err
+	}
+
+	var stdoutLines, stderrLines []string
+	var stdoutErr, stderrErr error
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stdoutLines, stdoutErr = streamLines(t, log, stdoutPipe, callback, false)
+	}()
+	go func() {
+		defer wg.Done()
+		stderrLines, stderrErr = streamLines(t, log, stderrPipe, callback, true)
+	}()
+	wg.Wait()
+
+	err = sshSession.Session.Wait()
+	if err == nil {
+		err = stdoutErr
+	}
+	if err == nil {
+		err = stderrErr
+	}
+	return strings.Join(stdoutLines, "\n"), strings.Join(stderrLines, "\n"), err
+}
+
+// streamLines logs each line read from the given reader, passes it to callback if not nil, and returns all of them once
+// the reader is exhausted. If a line can't be read (e.g., because it is too long), the rest of the reader is discarded,
+// so that the remote command doesn't block on a full pipe, and the error is returned.
+func streamLines(t testing.TestingT, log *logger.Logger, reader io.Reader, callback func(string, bool), isStderr bool) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		log.Logf(t, "%s", line)
+		if callback != nil {
+			callback(line, isStderr)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		io.Copy(io.Discard, reader)
+		return lines, err
+	}
+	return lines, nil
+}
+
 func setUpSSHClient(sshSession *SshSession) error {
 	if sshSession.Options.JumpHost == nil {
 		return fillSSHClientForHost(sshSession)
@@ -1,12 +1,16 @@
 package ssh
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/gruntwork-io/terratest/modules/logger"
 	grunttest "github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHostWithDefaultPort(t *testing.T) {
@@ -110,3 +114,13 @@ func mockSshConnectionE(t grunttest.TestingT, host Host) error {
 func mockSshCommandE(t grunttest.TestingT, host Host, command string) (string, error) {
 	return "", mockSshConnectionE(t, host)
 }
+
+func TestStreamLinesDrainsReaderOnError(t *testing.T) {
+	t.Parallel()
+
+	reader := strings.NewReader("first\n" + strings.Repeat("x", 11*1024*1024) + "\nlast\n")
+	lines, err := streamLines(t, logger.Discard, reader, nil, false)
+	require.ErrorIs(t, err, bufio.ErrTooLong)
+	assert.Equal(t, []string{"first"}, lines)
+	assert.Equal(t, 0, reader.Len())
+}
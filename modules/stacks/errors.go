@@ -0,0 +1,55 @@
+package stacks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DuplicateStackName is returned when two stacks have the same name.
+type DuplicateStackName string
+
+func (err DuplicateStackName) Error() string {
+	return fmt.Sprintf("more than one stack is named %q", string(err))
+}
+
+// UnknownStackDependency is returned when a stack depends on a stack that doesn't exist.
+type UnknownStackDependency struct {
+	Stack      string
+	Dependency string
+}
+
+func (err UnknownStackDependency) Error() string {
+	return fmt.Sprintf("stack %q depends on stack %q, which doesn't exist", err.Stack, err.Dependency)
+}
+
+// DependencyCycle is returned when the dependencies between stacks contain a cycle. It contains the names of the
+// stacks that could not be sorted.
+type DependencyCycle []string
+
+func (err DependencyCycle) Error() string {
+	return fmt.Sprintf("dependency cycle between stacks: %s", strings.Join(err, ", "))
+}
+
+// MissingStackOutput is returned when a stack input references an output that the stack doesn't have.
+type MissingStackOutput struct {
+	Stack  string
+	Output string
+}
+
+func (err MissingStackOutput) Error() string {
+	return fmt.Sprintf("stack %q doesn't have an output named %q", err.Stack, err.Output)
+}
+
+// StackFailed is returned when running terraform on a stack fails.
+type StackFailed struct {
+	Stack      string
+	Underlying error
+}
+
+func (err StackFailed) Error() string {
+	return fmt.Sprintf("stack %q failed: %v", err.Stack, err.Underlying)
+}
+
+func (err StackFailed) Unwrap() error {
+	return err.Underlying
+}
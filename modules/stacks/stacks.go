@@ -0,0 +1,304 @@
+// Package stacks allows to apply and destroy multiple Terraform modules that depend on each other (e.g., network,
+// then data, then app), wiring the outputs of each module into the variables of the modules that depend on it.
+package stacks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+// Stack is a single Terraform module in a set of layered modules.
+type Stack struct {
+	// A unique name for this stack, used to reference it in DependsOn and Inputs
+	Name string
+
+	// The options used to run Terraform for this stack. Vars wired from Inputs are added to Options.Vars.
+	Options *terraform.Options
+
+	// The names of the stacks that must be applied before this one (and destroyed after it). Stacks referenced in
+	// Inputs are implicitly added as dependencies.
+	DependsOn []string
+
+	// The outputs of other stacks to pass as vars to this stack
+	Inputs []Input
+}
+
+// Input wires the output of one stack into a var of another.
+type Input struct {
+	FromStack string // The name of the stack to read the output from
+	Output    string // The name of the output to read
+	Var       string // The name of the var to set. Defaults to the name of the output.
+}
+
+// Options configures how a set of stacks is applied and destroyed.
+type Options struct {
+	Stacks []*Stack
+
+	// The maximum number of stacks to apply or destroy at the same time, among those whose dependencies are all
+	// satisfied. Defaults to 1, i.e., stacks are applied one at a time.
+	Parallelism int
+}
+
+// ApplyAll runs terraform init and apply on all the stacks in dependency order, wiring the outputs of each stack into
+// the stacks that depend on it. This will fail the test if there is an error.
+func ApplyAll(t testing.TestingT, options *Options) {
+	require.NoError(t, ApplyAllE(t, options))
+}
+
+// ApplyAllE runs terraform init and apply on all the stacks in dependency order, wiring the outputs of each stack into
+// the stacks that depend on it. Stacks whose dependencies have all been applied are applied in parallel, up to
+// options.Parallelism at a time. If a stack fails, no further stacks are started, and the errors are returned once the
+// stacks already running have completed.
+func ApplyAllE(t testing.TestingT, options *Options) error {
+	levels, err := SortStacksE(options.Stacks)
+	if err != nil {
+		return err
+	}
+
+	for _, level := range levels {
+		if err := runLevel(level, options.Parallelism, func(stack *Stack) error {
+			if err := wireInputs(t, stack, options.Stacks); err != nil {
+				return err
+			}
+			_, err := terraform.InitAndApplyE(t, stack.Options)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DestroyAll runs terraform destroy on all the stacks in reverse dependency order. This will fail the test if there is
+// an error.
+func DestroyAll(t testing.TestingT, options *Options) {
+	require.NoError(t, DestroyAllE(t, options))
+}
+
+// DestroyAllE runs terraform destroy on all the stacks in reverse dependency order. Inputs are re-read from the
+// dependencies before destroying each stack (they are still around at that point), so this also works in a separate
+// test stage from ApplyAllE. Unlike ApplyAllE, a failure to destroy a stack doesn't stop the stacks that don't depend
+// on it from being destroyed, to leak as little as possible; stacks that depend on a failed stack are skipped.
+func DestroyAllE(t testing.TestingT, options *Options) error {
+	levels, err := SortStacksE(options.Stacks)
+	if err != nil {
+		return err
+	}
+
+	var allErrs *multierror.Error
+	failed := map[string]bool{}
+	for i := len(levels) - 1; i >= 0; i-- {
+		toDestroy := []*Stack{}
+		for _, stack := range levels[i] {
+			if dependent := failedDependent(stack, options.Stacks, failed); dependent != "" {
+				allErrs = multierror.Append(allErrs, fmt.Errorf("not destroying stack %s because stack %s, which depends on it, failed to be destroyed", stack.Name, dependent))
+				failed[stack.Name] = true
+				continue
+			}
+			toDestroy = append(toDestroy, stack)
+		}
+
+		var mutex sync.Mutex
+		err := runLevel(toDestroy, options.Parallelism, func(stack *Stack) error {
+			err := wireInputs(t, stack, options.Stacks)
+			if err == nil {
+				_, err = terraform.DestroyE(t, stack.Options)
+			}
+			if err != nil {
+				mutex.Lock()
+				failed[stack.Name] = true
+				mutex.Unlock()
+			}
+			return err
+		})
+		if err != nil {
+			allErrs = multierror.Append(allErrs, err)
+		}
+	}
+	return allErrs.ErrorOrNil()
+}
+
+// SortStacksE sorts the given stacks in dependency order, returning them grouped in levels: the stacks in each level
+// only depend on stacks in the previous levels. Within a level, stacks are sorted by name. Returns an error if a stack
+// depends on a stack that doesn't exist, if two stacks have the same name, or if there is a dependency cycle.
+func SortStacksE(stacks []*Stack) ([][]*Stack, error) {
+	byName := map[string]*Stack{}
+	for _, stack := range stacks {
+		if _, exists := byName[stack.Name]; exists {
+			return nil, DuplicateStackName(stack.Name)
+		}
+		byName[stack.Name] = stack
+	}
+
+	remaining := map[string][]string{}
+	for _, stack := range stacks {
+		deps := dependenciesOf(stack)
+		for _, dep := range deps {
+			if _, exists := byName[dep]; !exists {
+				return nil, UnknownStackDependency{Stack: stack.Name, Dependency: dep}
+			}
+		}
+		remaining[stack.Name] = deps
+	}
+
+	levels := [][]*Stack{}
+	done := map[string]bool{}
+	for len(remaining) > 0 {
+		level := []*Stack{}
+		for name, deps := range remaining {
+			ready := true
+			for _, dep := range deps {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, byName[name])
+			}
+		}
+
+		if len(level) == 0 {
+			names := []string{}
+			for name := range remaining {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, DependencyCycle(names)
+		}
+
+		sort.Slice(level, func(i, j int) bool { return level[i].Name < level[j].Name })
+		for _, stack := range level {
+			done[stack.Name] = true
+			delete(remaining, stack.Name)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// dependenciesOf returns the names of all the stacks the given stack depends on, explicitly or through its inputs.
+func dependenciesOf(stack *Stack) []string {
+	seen := map[string]bool{}
+	deps := []string{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			deps = append(deps, name)
+		}
+	}
+	for _, dep := range stack.DependsOn {
+		add(dep)
+	}
+	for _, input := range stack.Inputs {
+		add(input.FromStack)
+	}
+	return deps
+}
+
+// failedDependent returns the name of a stack in failed that depends on the given stack, or an empty string if there
+// is none.
+func failedDependent(stack *Stack, stacks []*Stack, failed map[string]bool) string {
+	for _, other := range stacks {
+		if !failed[other.Name] {
+			continue
+		}
+		for _, dep := range dependenciesOf(other) {
+			if dep == stack.Name {
+				return other.Name
+			}
+		}
+	}
+	return ""
+}
+
+// wireInputs reads the outputs referenced by the inputs of the given stack and sets them as vars on its options. The
+// outputs are read with a copy of the options of each dependency, as running a command updates its options (e.g.,
+// EnvVars) and the stacks of a level may read the outputs of the same dependency in parallel.
+func wireInputs(t testing.TestingT, stack *Stack, stacks []*Stack) error {
+	if len(stack.Inputs) == 0 {
+		return nil
+	}
+
+	byName := map[string]*Stack{}
+	for _, other := range stacks {
+		byName[other.Name] = other
+	}
+
+	outputsByStack := map[string]map[string]interface{}{}
+	for _, input := range stack.Inputs {
+		outputs, ok := outputsByStack[input.FromStack]
+		if !ok {
+			dependencyOptions, err := byName[input.FromStack].Options.Clone()
+			if err != nil {
+				return err
+			}
+			outputs, err = terraform.OutputAllE(t, dependencyOptions)
+			if err != nil {
+				return err
+			}
+			outputsByStack[input.FromStack] = outputs
+		}
+
+		value, ok := outputs[input.Output]
+		if !ok {
+			return MissingStackOutput{Stack: input.FromStack, Output: input.Output}
+		}
+
+		varName := input.Var
+		if varName == "" {
+			varName = input.Output
+		}
+		if stack.Options.Vars == nil {
+			stack.Options.Vars = map[string]interface{}{}
+		}
+		stack.Options.Vars[varName] = value
+	}
+	return nil
+}
+
+// runLevel runs the given function on all the given stacks, up to parallelism at a time, and returns all the errors.
+// Once a call has failed, no more calls are started.
+func runLevel(stacks []*Stack, parallelism int, fn func(stack *Stack) error) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var mutex sync.Mutex
+	var allErrs *multierror.Error
+	semaphore := make(chan struct{}, parallelism)
+	wg := &sync.WaitGroup{}
+
+	for _, stack := range stacks {
+		semaphore <- struct{}{}
+
+		mutex.Lock()
+		failed := allErrs != nil
+		mutex.Unlock()
+		if failed {
+			<-semaphore
+			break
+		}
+
+		wg.Add(1)
+		go func(stack *Stack) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if err := fn(stack); err != nil {
+				mutex.Lock()
+				allErrs = multierror.Append(allErrs, StackFailed{Stack: stack.Name, Underlying: err})
+				mutex.Unlock()
+			}
+		}(stack)
+	}
+	wg.Wait()
+
+	return allErrs.ErrorOrNil()
+}
@@ -0,0 +1,112 @@
+package stacks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stackNames(levels [][]*Stack) [][]string {
+	names := [][]string{}
+	for _, level := range levels {
+		levelNames := []string{}
+		for _, stack := range level {
+			levelNames = append(levelNames, stack.Name)
+		}
+		names = append(names, levelNames)
+	}
+	return names
+}
+
+func TestSortStacksE(t *testing.T) {
+	t.Parallel()
+
+	stacks := []*Stack{
+		{Name: "app", Inputs: []Input{{FromStack: "network", Output: "vpc_id"}, {FromStack: "data", Output: "db_url"}}},
+		{Name: "data", DependsOn: []string{"network"}},
+		{Name: "network"},
+		{Name: "dns"},
+	}
+
+	levels, err := SortStacksE(stacks)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"dns", "network"}, {"data"}, {"app"}}, stackNames(levels))
+}
+
+func TestSortStacksEUnknownDependency(t *testing.T) {
+	t.Parallel()
+
+	_, err := SortStacksE([]*Stack{{Name: "app", DependsOn: []string{"network"}}})
+	assert.Equal(t, UnknownStackDependency{Stack: "app", Dependency: "network"}, err)
+}
+
+func TestSortStacksEDuplicateName(t *testing.T) {
+	t.Parallel()
+
+	_, err := SortStacksE([]*Stack{{Name: "app"}, {Name: "app"}})
+	assert.Equal(t, DuplicateStackName("app"), err)
+}
+
+func TestSortStacksECycle(t *testing.T) {
+	t.Parallel()
+
+	stacks := []*Stack{
+		{Name: "network"},
+		{Name: "a", DependsOn: []string{"network", "b"}},
+		{Name: "b", Inputs: []Input{{FromStack: "a", Output: "id"}}},
+	}
+
+	_, err := SortStacksE(stacks)
+	assert.Equal(t, DependencyCycle{"a", "b"}, err)
+}
+
+func TestRunLevelStopsAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	stacks := []*Stack{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	ran := []string{}
+	err := runLevel(stacks, 1, func(stack *Stack) error {
+		ran = append(ran, stack.Name)
+		if stack.Name == "b" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"a", "b"}, ran)
+
+	var stackErr StackFailed
+	require.True(t, errors.As(err, &stackErr))
+	assert.Equal(t, "b", stackErr.Stack)
+}
+
+func TestWireInputsInParallel(t *testing.T) {
+	t.Parallel()
+
+	// A fake terraform that only knows how to print the outputs of the network stack
+	binary := filepath.Join(t.TempDir(), "terraform")
+	script := "#!/bin/sh\necho '{\"vpc_id\": {\"sensitive\": false, \"type\": \"string\", \"value\": \"vpc-1\"}}'\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	// TerraformDir is in the temp folder, so running a command sets an isolated TF_DATA_DIR on the options
+	network := &Stack{Name: "network", Options: &terraform.Options{TerraformBinary: binary, TerraformDir: t.TempDir(), Logger: logger.Discard}}
+	app := &Stack{Name: "app", Options: &terraform.Options{}, Inputs: []Input{{FromStack: "network", Output: "vpc_id"}}}
+	db := &Stack{Name: "db", Options: &terraform.Options{}, Inputs: []Input{{FromStack: "network", Output: "vpc_id", Var: "db_vpc_id"}}}
+	stacks := []*Stack{network, app, db}
+
+	err := runLevel([]*Stack{app, db}, 2, func(stack *Stack) error {
+		return wireInputs(t, stack, stacks)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "vpc-1", app.Options.Vars["vpc_id"])
+	assert.Equal(t, "vpc-1", db.Options.Vars["db_vpc_id"])
+	assert.Empty(t, network.Options.EnvVars)
+}
@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ArtifactStateFileName is the name of the file in the artifact directory of a test that the latest state snapshot is
+// written to.
+const ArtifactStateFileName = "terraform.tfstate"
+
+var invalidArtifactDirChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// GetArtifactDirForTest returns the folder in options.ArtifactDir that the artifacts of the given test are saved to,
+// or an empty string if options.ArtifactDir is not set.
+func GetArtifactDirForTest(t testing.TestingT, options *Options) string {
+	if options.ArtifactDir == "" {
+		return ""
+	}
+	return filepath.Join(options.ArtifactDir, invalidArtifactDirChars.ReplaceAllString(t.Name(), "_"))
+}
+
+// saveArtifacts saves the full output of a command to the artifact directory of the test and, if
+// options.ArtifactPlanAndState is set, the plan file it wrote (for plan) and a snapshot of the state (after apply, or
+// after a failed destroy). It does nothing if options.ArtifactDir is not set. Failures are logged rather than failing
+// the command, as the artifacts are only a diagnostic aid.
+func saveArtifacts(t testing.TestingT, options *Options, args []string, output string, err error) {
+	dir := GetArtifactDirForTest(t, options)
+	if dir == "" {
+		return
+	}
+	if saveErr := saveArtifactsE(t, options, dir, args, output, err); saveErr != nil {
+		options.Logger.Logf(t, "Failed to save terraform artifacts to %s: %v", dir, saveErr)
+	}
+}
+
+func saveArtifactsE(t testing.TestingT, options *Options, dir string, args []string, output string, err error) error {
+	if mkdirErr := os.MkdirAll(dir, os.ModePerm); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	// Number the files so they sort in the order the commands ran in, even across Options clones
+	entries, readErr := os.ReadDir(dir)
+	if readErr != nil {
+		return readErr
+	}
+	index := 0
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".log" {
+			index++
+		}
+	}
+	prefix := fmt.Sprintf("%04d-%s", index, hookedCommandName(args))
+
+	// Never persist secrets to disk
+	secrets := secretValues(options)
+	contents := fmt.Sprintf("# %s %v\n", options.TerraformBinary, maskSecretsInArgs(args, secrets))
+	if err != nil {
+		contents += fmt.Sprintf("# error: %s\n", maskSecrets(err.Error(), secrets))
+	}
+	contents += maskSecrets(output, secrets)
+	if writeErr := os.WriteFile(filepath.Join(dir, prefix+".log"), []byte(contents), 0644); writeErr != nil {
+		return writeErr
+	}
+
+	// The plan file and the state can't be masked, so they are only saved on request. They are on the remote host for
+	// remote commands, and are per module for run-all commands.
+	if !options.ArtifactPlanAndState || options.Remote != nil || len(args) == 0 || args[0] == runAllCmd {
+		return nil
+	}
+
+	if args[0] == "plan" && err == nil && options.PlanFilePath != "" {
+		planFilePath := options.PlanFilePath
+		if !filepath.IsAbs(planFilePath) {
+			planFilePath = filepath.Join(options.TerraformDir, planFilePath)
+		}
+		plan, readErr := os.ReadFile(planFilePath)
+		if readErr != nil {
+			return readErr
+		}
+		if writeErr := os.WriteFile(filepath.Join(dir, prefix+".tfplan"), plan, 0600); writeErr != nil {
+			return writeErr
+		}
+	}
+
+	if args[0] == "apply" || (args[0] == "destroy" && err != nil) {
+		return saveStateArtifact(t, options, dir, secrets)
+	}
+	return nil
+}
+
+// saveStateArtifact pulls the current state and writes it to the artifact directory, replacing the previous snapshot.
+// The state is neither logged nor passed to OutputLineCallback.
+func saveStateArtifact(t testing.TestingT, options *Options, dir string, secrets []string) error {
+	cmd := generateCommand(options, "state", "pull")
+	cmd.Logger = logger.Discard
+	cmd.OutputLineCallback = nil
+	stdout, _, err := runCommandAndGetStdOutErrE(t, options, cmd)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ArtifactStateFileName), []byte(maskSecrets(stdout, secrets)), 0600)
+}
@@ -0,0 +1,94 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetArtifactDirForTest(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", GetArtifactDirForTest(t, &Options{}))
+	assert.Equal(t, filepath.Join("/artifacts", "TestGetArtifactDirForTest"), GetArtifactDirForTest(t, &Options{ArtifactDir: "/artifacts"}))
+
+	t.Run("sub test/with spaces", func(t *testing.T) {
+		assert.Equal(t, filepath.Join("/artifacts", "TestGetArtifactDirForTest_sub_test_with_spaces"), GetArtifactDirForTest(t, &Options{ArtifactDir: "/artifacts"}))
+	})
+}
+
+func TestArtifactsAreSaved(t *testing.T) {
+	t.Parallel()
+
+	terraformDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(terraformDir, "test.tfplan"), []byte("plan contents"), 0644))
+
+	options := &Options{
+		TerraformBinary: "echo",
+		TerraformDir:    terraformDir,
+		ArtifactDir:     t.TempDir(),
+		PlanFilePath:    "test.tfplan",
+		Logger:          logger.Discard,
+		Vars:            map[string]interface{}{"password": "hunter2"},
+		SensitiveVars:   []string{"password"},
+
+		ArtifactPlanAndState: true,
+	}
+	dir := GetArtifactDirForTest(t, options)
+
+	// The state must not be streamed anywhere but to the artifact file
+	lines := []string{}
+	options.OutputLineCallback = func(line string, isStderr bool) { lines = append(lines, line) }
+
+	_, err := RunTerraformCommandE(t, options, FormatArgs(options, "plan")...)
+	require.NoError(t, err)
+	_, err = RunTerraformCommandE(t, options, FormatArgs(options, "apply", "-auto-approve")...)
+	require.NoError(t, err)
+
+	planLog, err := os.ReadFile(filepath.Join(dir, "0000-plan.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(planLog), "password=***")
+	assert.NotContains(t, string(planLog), "hunter2")
+
+	plan, err := os.ReadFile(filepath.Join(dir, "0000-plan.tfplan"))
+	require.NoError(t, err)
+	assert.Equal(t, "plan contents", string(plan))
+
+	_, err = os.Stat(filepath.Join(dir, "0001-apply.log"))
+	require.NoError(t, err)
+
+	state, err := os.ReadFile(filepath.Join(dir, ArtifactStateFileName))
+	require.NoError(t, err)
+	assert.Equal(t, "state pull", string(state))
+	assert.NotContains(t, lines, "state pull")
+}
+
+func TestPlanAndStateArtifactsAreOptIn(t *testing.T) {
+	t.Parallel()
+
+	terraformDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(terraformDir, "test.tfplan"), []byte("plan contents"), 0644))
+
+	options := &Options{
+		TerraformBinary: "echo",
+		TerraformDir:    terraformDir,
+		ArtifactDir:     t.TempDir(),
+		PlanFilePath:    "test.tfplan",
+		Logger:          logger.Discard,
+	}
+	dir := GetArtifactDirForTest(t, options)
+
+	_, err := RunTerraformCommandE(t, options, FormatArgs(options, "plan")...)
+	require.NoError(t, err)
+	_, err = RunTerraformCommandE(t, options, FormatArgs(options, "apply", "-auto-approve")...)
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(dir, "0000-plan.log"))
+	assert.FileExists(t, filepath.Join(dir, "0001-apply.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "0000-plan.tfplan"))
+	assert.NoFileExists(t, filepath.Join(dir, ArtifactStateFileName))
+}
@@ -0,0 +1,209 @@
+// Package backend allows to read the state that Terraform stores in remote backends, without running terraform.
+package backend
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+
+	awsSDK "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/gruntwork-io/terratest/modules/azure"
+	"github.com/gruntwork-io/terratest/modules/gcp"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const defaultWorkspace = "default"
+
+// GetState reads and parses the state stored in the given backend, using the same backend config as would be
+// passed to terraform init (e.g., terraform.Options.BackendConfig), without running terraform or needing the module
+// source. The supported backend types are s3, azurerm, and gcs. An empty workspace means the default workspace. This
+// will fail the test if there is an error.
+func GetState(t testing.TestingT, backendType string, backendConfig map[string]interface{}, workspace string) *terraform.State {
+	state, err := GetStateE(t, backendType, backendConfig, workspace)
+	require.NoError(t, err)
+	return state
+}
+
+// GetStateE reads and parses the state stored in the given backend, using the same backend config as would be
+// passed to terraform init (e.g., terraform.Options.BackendConfig), without running terraform or needing the module
+// source. The supported backend types are s3, azurerm, and gcs. An empty workspace means the default workspace.
+//
+// Credentials are taken from the environment, as for the other helpers of the aws, azure, and gcp modules, rather than
+// from the backend config. For s3, if dynamodb_table is set, the digest of the state is checked against the one in the
+// lock table, to detect stale reads.
+func GetStateE(t testing.TestingT, backendType string, backendConfig map[string]interface{}, workspace string) (*terraform.State, error) {
+	data, err := GetStateContentsE(t, backendType, backendConfig, workspace)
+	if err != nil {
+		return nil, err
+	}
+	return terraform.ParseStateE(data)
+}
+
+// GetOutputs reads the state stored in the given backend and returns its output values. See GetStateE.
+// This will fail the test if there is an error.
+func GetOutputs(t testing.TestingT, backendType string, backendConfig map[string]interface{}, workspace string) map[string]interface{} {
+	outputs, err := GetOutputsE(t, backendType, backendConfig, workspace)
+	require.NoError(t, err)
+	return outputs
+}
+
+// GetOutputsE reads the state stored in the given backend and returns its output values. See GetStateE.
+func GetOutputsE(t testing.TestingT, backendType string, backendConfig map[string]interface{}, workspace string) (map[string]interface{}, error) {
+	state, err := GetStateE(t, backendType, backendConfig, workspace)
+	if err != nil {
+		return nil, err
+	}
+	return state.OutputValues(), nil
+}
+
+// GetStateContentsE reads the raw contents of the state stored in the given backend. See GetStateE.
+func GetStateContentsE(t testing.TestingT, backendType string, backendConfig map[string]interface{}, workspace string) ([]byte, error) {
+	if workspace == "" {
+		workspace = defaultWorkspace
+	}
+
+	switch backendType {
+	case "s3":
+		return getS3BackendStateContentsE(t, backendConfig, workspace)
+	case "azurerm":
+		return getAzurermBackendStateContentsE(t, backendConfig, workspace)
+	case "gcs":
+		return getGcsBackendStateContentsE(t, backendConfig, workspace)
+	}
+	return nil, UnsupportedBackend(backendType)
+}
+
+func getS3BackendStateContentsE(t testing.TestingT, backendConfig map[string]interface{}, workspace string) ([]byte, error) {
+	config, err := requiredBackendConfig("s3", backendConfig, "bucket", "key", "region")
+	if err != nil {
+		return nil, err
+	}
+
+	key := s3StateKey(config["key"], backendConfigString(backendConfig, "workspace_key_prefix"), workspace)
+	contents, err := aws.GetS3ObjectContentsE(t, config["region"], config["bucket"], key)
+	if err != nil {
+		return nil, err
+	}
+
+	if table := backendConfigString(backendConfig, "dynamodb_table"); table != "" {
+		if err := checkS3StateDigestE(t, config["region"], table, config["bucket"], key, []byte(contents)); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(contents), nil
+}
+
+// s3StateKey returns the key of the state of the given workspace in the S3 bucket.
+func s3StateKey(key string, workspaceKeyPrefix string, workspace string) string {
+	if workspace == defaultWorkspace {
+		return key
+	}
+	if workspaceKeyPrefix == "" {
+		workspaceKeyPrefix = "env:"
+	}
+	return path.Join(workspaceKeyPrefix, workspace, key)
+}
+
+// checkS3StateDigestE checks that the MD5 digest of the given state matches the one the s3 backend stores in the
+// DynamoDB lock table.
+func checkS3StateDigestE(t testing.TestingT, region string, table string, bucket string, key string, contents []byte) error {
+	client, err := aws.NewDynamoDBClientE(t, region)
+	if err != nil {
+		return err
+	}
+
+	lockID := fmt.Sprintf("%s/%s-md5", bucket, key)
+	out, err := client.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName:      &table,
+		Key:            map[string]dynamodbtypes.AttributeValue{"LockID": &dynamodbtypes.AttributeValueMemberS{Value: lockID}},
+		ConsistentRead: awsSDK.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	digest, ok := out.Item["Digest"].(*dynamodbtypes.AttributeValueMemberS)
+	if !ok {
+		// No digest has been recorded (e.g., the state was written without the lock table), so there is nothing to check
+		return nil
+	}
+
+	sum := md5.Sum(contents)
+	actual := hex.EncodeToString(sum[:])
+	if digest.Value != actual {
+		return StateDigestMismatch{Expected: digest.Value, Actual: actual}
+	}
+	return nil
+}
+
+func getAzurermBackendStateContentsE(t testing.TestingT, backendConfig map[string]interface{}, workspace string) ([]byte, error) {
+	config, err := requiredBackendConfig("azurerm", backendConfig, "storage_account_name", "container_name", "key")
+	if err != nil {
+		return nil, err
+	}
+
+	blobName := azurermStateBlobName(config["key"], workspace)
+	logger.Default.Logf(t, "Reading state from blob %s in container %s of storage account %s", blobName, config["container_name"], config["storage_account_name"])
+	return azure.GetStorageBlobContentsE(blobName, config["container_name"], config["storage_account_name"], backendConfigString(backendConfig, "sas_token"))
+}
+
+// azurermStateBlobName returns the name of the blob holding the state of the given workspace.
+func azurermStateBlobName(key string, workspace string) string {
+	if workspace == defaultWorkspace {
+		return key
+	}
+	return key + "env:" + workspace
+}
+
+func getGcsBackendStateContentsE(t testing.TestingT, backendConfig map[string]interface{}, workspace string) ([]byte, error) {
+	config, err := requiredBackendConfig("gcs", backendConfig, "bucket")
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := gcp.ReadBucketObjectE(t, config["bucket"], gcsStateObjectName(backendConfigString(backendConfig, "prefix"), workspace))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(reader)
+}
+
+// gcsStateObjectName returns the name of the object holding the state of the given workspace.
+func gcsStateObjectName(prefix string, workspace string) string {
+	return path.Join(prefix, workspace+".tfstate")
+}
+
+// requiredBackendConfig returns the values of the given keys in the backend config, or an error if any is missing.
+func requiredBackendConfig(backend string, backendConfig map[string]interface{}, keys ...string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, key := range keys {
+		value := backendConfigString(backendConfig, key)
+		if value == "" {
+			return nil, BackendConfigMissing{Backend: backend, Key: key}
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// backendConfigString returns the value of the given key in the backend config as a string, or an empty string if
+// it is not set.
+func backendConfigString(backendConfig map[string]interface{}, key string) string {
+	value, ok := backendConfig[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatePaths(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "app/terraform.tfstate", s3StateKey("app/terraform.tfstate", "", defaultWorkspace))
+	assert.Equal(t, "env:/staging/app/terraform.tfstate", s3StateKey("app/terraform.tfstate", "", "staging"))
+	assert.Equal(t, "workspaces/staging/app/terraform.tfstate", s3StateKey("app/terraform.tfstate", "workspaces", "staging"))
+
+	assert.Equal(t, "app.tfstate", azurermStateBlobName("app.tfstate", defaultWorkspace))
+	assert.Equal(t, "app.tfstateenv:staging", azurermStateBlobName("app.tfstate", "staging"))
+
+	assert.Equal(t, "app/default.tfstate", gcsStateObjectName("app", defaultWorkspace))
+	assert.Equal(t, "staging.tfstate", gcsStateObjectName("", "staging"))
+}
+
+func TestGetStateContentsEErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetStateContentsE(t, "consul", map[string]interface{}{}, "")
+	assert.Equal(t, UnsupportedBackend("consul"), err)
+
+	_, err = GetStateContentsE(t, "s3", map[string]interface{}{"bucket": "b", "region": "us-east-1"}, "")
+	assert.Equal(t, BackendConfigMissing{Backend: "s3", Key: "key"}, err)
+}
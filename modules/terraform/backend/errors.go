@@ -0,0 +1,31 @@
+package backend
+
+import "fmt"
+
+// UnsupportedBackend occurs when reading the state of a backend type that is not supported.
+type UnsupportedBackend string
+
+func (err UnsupportedBackend) Error() string {
+	return fmt.Sprintf("reading the state of backend %q is not supported: only s3, azurerm, and gcs are supported", string(err))
+}
+
+// BackendConfigMissing occurs when a backend config key required to read the state is not set.
+type BackendConfigMissing struct {
+	Backend string
+	Key     string
+}
+
+func (err BackendConfigMissing) Error() string {
+	return fmt.Sprintf("backend config %q is required to read the state of backend %q", err.Key, err.Backend)
+}
+
+// StateDigestMismatch occurs when the digest of the state stored in S3 does not match the one in the DynamoDB lock
+// table, e.g., because S3 has not caught up with a recent write yet.
+type StateDigestMismatch struct {
+	Expected string
+	Actual   string
+}
+
+func (err StateDigestMismatch) Error() string {
+	return fmt.Sprintf("the digest of the state in S3 (%s) does not match the one in DynamoDB (%s)", err.Actual, err.Expected)
+}
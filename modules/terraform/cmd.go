@@ -6,10 +6,12 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gruntwork-io/terratest/modules/collections"
 	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/ssh"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +22,12 @@ func generateCommand(options *Options, args ...string) shell.Command {
 		Args:       args,
 		WorkingDir: options.TerraformDir,
 		Env:        options.EnvVars,
-		Logger:     options.Logger,
+		Logger:     loggerForOptions(options),
+
+		OutputLineCallback: outputLineCallbackForOptions(options),
+	}
+	if options.Docker != nil {
+		return generateDockerCommand(options, cmd)
 	}
 	return cmd
 }
@@ -59,12 +66,18 @@ func GetCommonOptions(options *Options, args ...string) (*Options, []string) {
 
 		// for newer Terragrunt version, setting simplified log formatting
 		setTerragruntLogFormatting(options)
+
+		if len(args) > 0 && args[0] == runAllCmd {
+			args = append(args, formatTerragruntDirFilterArgs(options)...)
+		}
 	}
 
 	if options.Parallelism > 0 && len(args) > 0 && collections.ListContains(commandsWithParallelism, args[0]) {
 		args = append(args, fmt.Sprintf("--parallelism=%d", options.Parallelism))
 	}
 
+	setIsolatedDataDir(options)
+
 	// if SshAgent is provided, override the local SSH agent with the socket of our in-process agent
 	if options.SshAgent != nil {
 		// Initialize EnvVars, if it hasn't been set yet
@@ -87,22 +100,45 @@ func RunTerraformCommand(t testing.TestingT, additionalOptions *Options, args ..
 
 // RunTerraformCommandE runs terraform with the given arguments and options and return stdout/stderr.
 func RunTerraformCommandE(t testing.TestingT, additionalOptions *Options, additionalArgs ...string) (string, error) {
+	if err := setRequiredVersionBinary(t, additionalOptions); err != nil {
+		return "", err
+	}
+	if err := writeOptionsVarsFileE(t, additionalOptions); err != nil {
+		return "", err
+	}
 	options, args := GetCommonOptions(additionalOptions, additionalArgs...)
 
 	cmd := generateCommand(options, args...)
-	description := fmt.Sprintf("%s %v", options.TerraformBinary, args)
-
-	return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
-		s, err := shell.RunCommandAndGetOutputE(t, cmd)
-		if err != nil {
-			return s, err
+	spoolCommandOutput(t, options, &cmd, args)
+	description := shell.FormatCommandLine(options.TerraformBinary, maskSecretsInArgs(args, secretValues(options)))
+
+	options.Hooks.runBefore(t, args)
+	span := startCommandSpan(options, args)
+
+	start := time.Now()
+	attempt := 0
+	var lastOut string
+	var lastErr error
+	out, err := retryCommandE(t, options, description, func() (string, error) {
+		attempt++
+		if attempt > 1 {
+			options.Hooks.runRetry(t, args, attempt, lastOut, lastErr)
 		}
-		if err := hasWarning(additionalOptions, s); err != nil {
-			return s, err
+
+		s, err := runCommandAndGetOutputE(t, options, cmd)
+		if err == nil {
+			err = hasWarning(additionalOptions, s)
 		}
+		lastOut, lastErr = s, err
 		return s, err
 	})
 
+	options.Hooks.runAfter(t, args, out, err)
+	options.RunReport.record(t, options, args, start, attempt, out, exitCodeForReport(options, lastErr), err)
+	span.end(exitCodeForReport(options, lastErr), attempt, err)
+	saveArtifacts(t, options, args, out, err)
+	trackIsolatedDataDir(t, options, args, err)
+	return maskCommandOutput(options, args, out), maskCommandError(options, err)
 }
 
 // RunTerraformCommandAndGetStdout runs terraform with the given arguments and options and returns solely its stdout
@@ -129,16 +165,33 @@ func RunTerraformCommandAndGetStdOutErrCode(t testing.TestingT, additionalOption
 
 // RunTerraformCommandAndGetStdOutErrCodeE runs terraform with the given arguments and options and returns its stdout, stderr, and exitcode
 func RunTerraformCommandAndGetStdOutErrCodeE(t testing.TestingT, additionalOptions *Options, additionalArgs ...string) (stdout string, stderr string, exit int, err error) {
+	if err = setRequiredVersionBinary(t, additionalOptions); err != nil {
+		return "", "", DefaultErrorExitCode, err
+	}
+	if err = writeOptionsVarsFileE(t, additionalOptions); err != nil {
+		return "", "", DefaultErrorExitCode, err
+	}
 	options, args := GetCommonOptions(additionalOptions, additionalArgs...)
 
 	cmd := generateCommand(options, args...)
-	description := fmt.Sprintf("%s %v", options.TerraformBinary, args)
+	spoolCommandOutput(t, options, &cmd, args)
+	description := shell.FormatCommandLine(options.TerraformBinary, maskSecretsInArgs(args, secretValues(options)))
+
+	options.Hooks.runBefore(t, args)
+	span := startCommandSpan(options, args)
 
+	start := time.Now()
 	exit = DefaultErrorExitCode
-	_, err = retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
-		stdout, stderr, err = shell.RunCommandAndGetStdOutErrE(t, cmd)
+	attempt := 0
+	_, err = retryCommandE(t, options, description, func() (string, error) {
+		attempt++
+		if attempt > 1 {
+			options.Hooks.runRetry(t, args, attempt, stdout, err)
+		}
+
+		stdout, stderr, err = runCommandAndGetStdOutErrE(t, options, cmd)
 		if err != nil {
-			exitCode, getExitCodeErr := shell.GetExitCodeForRunCommandError(err)
+			exitCode, getExitCodeErr := getExitCodeForCommandError(options, err)
 			if getExitCodeErr == nil {
 				exit = exitCode
 			}
@@ -153,7 +206,12 @@ func RunTerraformCommandAndGetStdOutErrCodeE(t testing.TestingT, additionalOptio
 		return "", nil
 	})
 
-	return
+	options.Hooks.runAfter(t, args, stdout, err)
+	options.RunReport.record(t, options, args, start, attempt, strings.TrimSuffix(stdout+"\n"+stderr, "\n"), exit, err)
+	span.end(exit, attempt, err)
+	saveArtifacts(t, options, args, strings.TrimSuffix(stdout+"\n"+stderr, "\n"), err)
+	trackIsolatedDataDir(t, options, args, err)
+	return maskCommandOutput(options, args, stdout), maskCommandOutput(options, args, stderr), exit, maskCommandError(options, err)
 }
 
 // GetExitCodeForTerraformCommand runs terraform with the given arguments and options and returns exit code
@@ -167,19 +225,118 @@ func GetExitCodeForTerraformCommand(t testing.TestingT, additionalOptions *Optio
 
 // GetExitCodeForTerraformCommandE runs terraform with the given arguments and options and returns exit code
 func GetExitCodeForTerraformCommandE(t testing.TestingT, additionalOptions *Options, additionalArgs ...string) (int, error) {
+	if err := setRequiredVersionBinary(t, additionalOptions); err != nil {
+		return DefaultErrorExitCode, err
+	}
+	if err := writeOptionsVarsFileE(t, additionalOptions); err != nil {
+		return DefaultErrorExitCode, err
+	}
 	options, args := GetCommonOptions(additionalOptions, additionalArgs...)
 
-	additionalOptions.Logger.Logf(t, "Running %s with args %v", options.TerraformBinary, args)
+	loggerForOptions(options).Logf(t, "Running %s with args %v", options.TerraformBinary, args)
 	cmd := generateCommand(options, args...)
-	_, err := shell.RunCommandAndGetOutputE(t, cmd)
+	spoolCommandOutput(t, options, &cmd, args)
+	options.Hooks.runBefore(t, args)
+	span := startCommandSpan(options, args)
+	start := time.Now()
+	out, err := runCommandAndGetOutputE(t, options, cmd)
+	options.Hooks.runAfter(t, args, out, err)
+	options.RunReport.record(t, options, args, start, 1, out, exitCodeForReport(options, err), err)
+	span.end(exitCodeForReport(options, err), 1, err)
+	saveArtifacts(t, options, args, out, err)
+	trackIsolatedDataDir(t, options, args, err)
 	if err == nil {
 		return DefaultSuccessExitCode, nil
 	}
-	exitCode, getExitCodeErr := shell.GetExitCodeForRunCommandError(err)
+	exitCode, getExitCodeErr := getExitCodeForCommandError(options, err)
 	if getExitCodeErr == nil {
 		return exitCode, nil
 	}
-	return DefaultErrorExitCode, getExitCodeErr
+	return DefaultErrorExitCode, maskCommandError(options, getExitCodeErr)
+}
+
+// commandsWithOutputSpooling are the commands whose output is spooled to disk if Options.SpoolOutputLines is set. Other
+// commands (e.g., output -json) are parsed by terratest, so their output must be kept in full.
+var commandsWithOutputSpooling = []string{
+	"plan",
+	"apply",
+	"destroy",
+}
+
+// spoolCommandOutput configures the given command to append its full output to a temp file and only keep the last
+// options.SpoolOutputLines lines in memory, if that is set and the command supports it. The file is only readable by
+// the current user, as the output is not masked, and it is removed when the test finishes, if t supports Cleanup (as
+// *testing.T does).
+func spoolCommandOutput(t testing.TestingT, options *Options, cmd *shell.Command, args []string) {
+	if options.SpoolOutputLines <= 0 || options.Remote != nil || !collections.ListContains(commandsWithOutputSpooling, hookedCommandName(args)) {
+		return
+	}
+
+	spoolFile, err := os.CreateTemp("", fmt.Sprintf("terratest-%s-*.log", hookedCommandName(args)))
+	if err != nil {
+		loggerForOptions(options).Logf(t, "Failed to create a file to spool the output to, keeping it in memory: %v", err)
+		return
+	}
+	defer spoolFile.Close()
+
+	path := spoolFile.Name()
+	if cleaner, ok := t.(interface{ Cleanup(func()) }); ok {
+		cleaner.Cleanup(func() { os.Remove(path) })
+	}
+
+	loggerForOptions(options).Logf(t, "Spooling the full output of %s %s to %s", options.TerraformBinary, hookedCommandName(args), path)
+	cmd.OutputSpoolPath = path
+	cmd.MaxOutputLines = options.SpoolOutputLines
+}
+
+// retryCommandE runs the given action, retrying it according to the RetryPolicy of the options or, if that's not set,
+// according to their RetryableTerraformErrors, MaxRetries, and TimeBetweenRetries.
+func retryCommandE(t testing.TestingT, options *Options, description string, action func() (string, error)) (string, error) {
+	if options.RetryPolicy != nil {
+		return retry.DoWithPolicyE(t, description, options.RetryPolicy, action)
+	}
+	return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, action)
+}
+
+// runCommandAndGetOutputE runs the given command, on the remote host if one is configured in the options, and returns
+// its stdout and stderr combined.
+func runCommandAndGetOutputE(t testing.TestingT, options *Options, cmd shell.Command) (string, error) {
+	if options.Remote != nil {
+		stdout, stderr, err := runRemoteCommandE(t, options, cmd)
+		return strings.TrimSuffix(stdout+"\n"+stderr, "\n"), err
+	}
+	return shell.RunCommandAndGetOutputE(t, cmd)
+}
+
+// runCommandAndGetStdOutErrE runs the given command, on the remote host if one is configured in the options, and
+// returns its stdout and stderr.
+func runCommandAndGetStdOutErrE(t testing.TestingT, options *Options, cmd shell.Command) (string, string, error) {
+	if options.Remote != nil {
+		return runRemoteCommandE(t, options, cmd)
+	}
+	return shell.RunCommandAndGetStdOutErrE(t, cmd)
+}
+
+// exitCodeForReport returns the exit code to record in the run report for a command that returned the given error.
+func exitCodeForReport(options *Options, err error) int {
+	if err == nil {
+		return DefaultSuccessExitCode
+	}
+	exitCode, getExitCodeErr := getExitCodeForCommandError(options, err)
+	if getExitCodeErr != nil || exitCode == DefaultSuccessExitCode {
+		// The command ran fine, but something else failed (e.g., a warning was treated as an error)
+		return DefaultErrorExitCode
+	}
+	return exitCode
+}
+
+// getExitCodeForCommandError returns the exit code from an error returned by runCommandAndGetOutputE or
+// runCommandAndGetStdOutErrE.
+func getExitCodeForCommandError(options *Options, err error) (int, error) {
+	if options.Remote != nil {
+		return ssh.GetExitCodeForSshCommandError(err)
+	}
+	return shell.GetExitCodeForRunCommandError(err)
 }
 
 func defaultTerraformExecutable() string {
@@ -209,7 +366,7 @@ func hasWarning(opts *Options, out string) error {
 		}
 		return fmt.Errorf("warning(s) were found: %s:\n%s", v, strings.Join(m, ""))
 	}
-	return nil
+	return hasMatchingWarning(opts, out)
 }
 
 // setTerragruntLogFormatting sets a default log formatting for terragrunt
@@ -1,10 +1,15 @@
 package terraform
 
 import (
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	ttesting "github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -65,3 +70,43 @@ func TestTerraformCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestRunTerraformCommandWithRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary: "false",
+		TerraformDir:    t.TempDir(),
+		Logger:          logger.Discard,
+		// Ignored, as RetryPolicy is set
+		MaxRetries: 10,
+		RetryPolicy: retry.BackoffPolicy{
+			MaxRetries:      2,
+			InitialDelay:    time.Millisecond,
+			RetryableErrors: []retry.RetryableError{{Pattern: "exit status 1", Message: "Flaky"}},
+		},
+	}
+
+	attempts := 0
+	options.Hooks = &Hooks{OnRetry: func(t ttesting.TestingT, args []string, attempt int, output string, err error) { attempts = attempt }}
+	_, err := RunTerraformCommandE(t, options, "apply")
+	assert.Equal(t, retry.MaxRetriesExceeded{Description: "false apply", MaxRetries: 2}, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestSpooledOutputIsPrivateAndRemoved(t *testing.T) {
+	t.Parallel()
+
+	var spoolPath string
+	t.Run("Apply", func(t *testing.T) {
+		options := &Options{TerraformBinary: "echo", Logger: logger.Discard, SpoolOutputLines: 1}
+		cmd := generateCommand(options, "apply")
+		spoolCommandOutput(t, options, &cmd, []string{"apply"})
+		spoolPath = cmd.OutputSpoolPath
+
+		info, err := os.Stat(spoolPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+	assert.NoFileExists(t, spoolPath)
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gruntwork-io/terratest/modules/testing"
+	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/stretchr/testify/require"
 )
 
@@ -89,3 +91,143 @@ func GetResourceCountE(t testing.TestingT, cmdout string) (*ResourceCount, error
 
 	return nil, errors.New(getResourceCountErrMessage)
 }
+
+// ResourceChangeCounts represents counts of the planned resource changes by action, parsed from the JSON plan.
+type ResourceChangeCounts struct {
+	Create  int
+	Update  int
+	Delete  int
+	Replace int // Resources that are destroyed and re-created (in either order)
+	Read    int // Data sources that are read during apply
+	NoOp    int
+}
+
+// ResourceCount returns the counts in the format of the summary line of terraform plan, where replaced resources
+// count both as added and destroyed.
+func (counts *ResourceChangeCounts) ResourceCount() *ResourceCount {
+	return &ResourceCount{
+		Add:     counts.Create + counts.Replace,
+		Change:  counts.Update,
+		Destroy: counts.Delete + counts.Replace,
+	}
+}
+
+// ResourceChangeFilter selects the resource changes to count. Empty fields match all resource changes.
+type ResourceChangeFilter struct {
+	ModuleAddress       string // Only count the resources in this module (e.g., module.vpc). Use "." for the root module.
+	IncludeChildModules bool   // Also count the resources in the child modules of ModuleAddress
+	ResourceType        string // Only count the resources of this type (e.g., aws_instance)
+}
+
+// GetResourceChangeCounts counts the planned resource changes in the given plan by action, only counting the resource
+// changes matching the given filter (which can be nil).
+func GetResourceChangeCounts(t testing.TestingT, plan *PlanStruct, filter *ResourceChangeFilter) *ResourceChangeCounts {
+	counts, err := GetResourceChangeCountsE(plan, filter)
+	require.NoError(t, err)
+	return counts
+}
+
+// GetResourceChangeCountsE counts the planned resource changes in the given plan by action, only counting the
+// resource changes matching the given filter (which can be nil). Unlike GetResourceCountE, this is based on the JSON
+// plan (e.g., from InitAndPlanAndShowWithStructE), rather than on the human-readable output.
+func GetResourceChangeCountsE(plan *PlanStruct, filter *ResourceChangeFilter) (*ResourceChangeCounts, error) {
+	if plan == nil {
+		return nil, errors.New("a plan is required to count resource changes")
+	}
+
+	counts := &ResourceChangeCounts{}
+	for _, change := range plan.RawPlan.ResourceChanges {
+		if change.Change == nil || !filter.matches(change) {
+			continue
+		}
+		counts.add(change.Change.Actions)
+	}
+	return counts, nil
+}
+
+// GetResourceChangeCountsByModule counts the planned resource changes in the given plan by action, for each module.
+// The keys are the module addresses, with "." for the root module.
+func GetResourceChangeCountsByModule(t testing.TestingT, plan *PlanStruct) map[string]*ResourceChangeCounts {
+	counts, err := GetResourceChangeCountsByModuleE(plan)
+	require.NoError(t, err)
+	return counts
+}
+
+// GetResourceChangeCountsByModuleE counts the planned resource changes in the given plan by action, for each module.
+// The keys are the module addresses, with "." for the root module.
+func GetResourceChangeCountsByModuleE(plan *PlanStruct) (map[string]*ResourceChangeCounts, error) {
+	if plan == nil {
+		return nil, errors.New("a plan is required to count resource changes")
+	}
+
+	counts := map[string]*ResourceChangeCounts{}
+	for _, change := range plan.RawPlan.ResourceChanges {
+		if change.Change == nil {
+			continue
+		}
+		module := change.ModuleAddress
+		if module == "" {
+			module = rootModuleAddress
+		}
+		if counts[module] == nil {
+			counts[module] = &ResourceChangeCounts{}
+		}
+		counts[module].add(change.Change.Actions)
+	}
+	return counts, nil
+}
+
+const rootModuleAddress = "."
+
+// add counts the given actions of a resource change.
+func (counts *ResourceChangeCounts) add(actions tfjson.Actions) {
+	switch {
+	case actions.Replace():
+		counts.Replace++
+	case actions.Create():
+		counts.Create++
+	case actions.Update():
+		counts.Update++
+	case actions.Delete():
+		counts.Delete++
+	case actions.Read():
+		counts.Read++
+	case actions.NoOp():
+		counts.NoOp++
+	}
+}
+
+// matches returns whether the given resource change matches the filter. A nil filter matches all resource changes.
+func (filter *ResourceChangeFilter) matches(change *tfjson.ResourceChange) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.ResourceType != "" && change.Type != filter.ResourceType {
+		return false
+	}
+	if filter.ModuleAddress == "" {
+		return true
+	}
+
+	module := change.ModuleAddress
+	if filter.ModuleAddress == rootModuleAddress {
+		return module == "" || filter.IncludeChildModules
+	}
+
+	// Instances of a module with count or for_each have addresses such as module.vpc[0]
+	rest := strings.TrimPrefix(module, filter.ModuleAddress)
+	if rest == module {
+		return false
+	}
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return false
+		}
+		rest = rest[end+1:]
+	}
+	if rest == "" {
+		return true
+	}
+	return filter.IncludeChildModules && strings.HasPrefix(rest, ".")
+}
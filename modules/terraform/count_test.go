@@ -5,6 +5,7 @@ import (
 
 	"github.com/gruntwork-io/terratest/modules/files"
 	ttesting "github.com/gruntwork-io/terratest/modules/testing"
+	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -89,3 +90,51 @@ func runTestGetResourceCountE(t *testing.T, noColor bool) {
 		})
 
 }
+
+func TestGetResourceChangeCountsE(t *testing.T) {
+	t.Parallel()
+
+	withModule := func(change *tfjson.ResourceChange, module string, resourceType string) *tfjson.ResourceChange {
+		change.ModuleAddress = module
+		change.Type = resourceType
+		return change
+	}
+	replace := &tfjson.ResourceChange{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}}}
+
+	plan := testPlanWithChanges(
+		withModule(testResourceChange("aws_instance.a", tfjson.ActionCreate, nil, nil), "", "aws_instance"),
+		withModule(testResourceChange("aws_instance.b", tfjson.ActionUpdate, nil, nil), "", "aws_instance"),
+		withModule(testResourceChange("module.vpc.aws_vpc.this", tfjson.ActionCreate, nil, nil), "module.vpc", "aws_vpc"),
+		withModule(testResourceChange("module.vpc[\"b\"].aws_vpc.this", tfjson.ActionDelete, nil, nil), "module.vpc[\"b\"]", "aws_vpc"),
+		withModule(testResourceChange("module.vpc.module.subnets.aws_subnet.this", tfjson.ActionNoop, nil, nil), "module.vpc.module.subnets", "aws_subnet"),
+		withModule(replace, "module.vpc2", "aws_instance"),
+	)
+	plan.ResourceChangesMap = parseResourceChanges(plan)
+
+	counts, err := GetResourceChangeCountsE(plan, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &ResourceChangeCounts{Create: 2, Update: 1, Delete: 1, Replace: 1, NoOp: 1}, counts)
+	assert.Equal(t, &ResourceCount{Add: 3, Change: 1, Destroy: 2}, counts.ResourceCount())
+
+	testCases := []struct {
+		name     string
+		filter   *ResourceChangeFilter
+		expected *ResourceChangeCounts
+	}{
+		{"root module", &ResourceChangeFilter{ModuleAddress: "."}, &ResourceChangeCounts{Create: 1, Update: 1}},
+		{"module", &ResourceChangeFilter{ModuleAddress: "module.vpc"}, &ResourceChangeCounts{Create: 1, Delete: 1}},
+		{"module and children", &ResourceChangeFilter{ModuleAddress: "module.vpc", IncludeChildModules: true}, &ResourceChangeCounts{Create: 1, Delete: 1, NoOp: 1}},
+		{"resource type", &ResourceChangeFilter{ResourceType: "aws_instance"}, &ResourceChangeCounts{Create: 1, Update: 1, Replace: 1}},
+	}
+	for _, testCase := range testCases {
+		counts, err := GetResourceChangeCountsE(plan, testCase.filter)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, counts, testCase.name)
+	}
+
+	byModule, err := GetResourceChangeCountsByModuleE(plan)
+	require.NoError(t, err)
+	assert.Equal(t, &ResourceChangeCounts{Create: 1, Update: 1}, byModule["."])
+	assert.Equal(t, &ResourceChangeCounts{Replace: 1}, byModule["module.vpc2"])
+	assert.Len(t, byModule, 5)
+}
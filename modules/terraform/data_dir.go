@@ -0,0 +1,141 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// TfDataDirEnvVar is the environment variable terraform uses to find the folder where it stores the working directory
+// data (modules, providers, backend configuration) that would otherwise go into .terraform.
+const TfDataDirEnvVar = "TF_DATA_DIR"
+
+// isolatedDataDirPrefix is the name prefix of the folders picked by setIsolatedDataDir. Only folders with this prefix
+// are ever removed by terratest, so a TF_DATA_DIR set by the user is left alone.
+const isolatedDataDirPrefix = "terratest-tf-data-"
+
+// isolatedDataDirState tracks the use of an isolated TF_DATA_DIR (or of the RemoteDir picked for RemoteOptions) by the
+// commands run with a given Options, to decide whether it can be removed once the test finishes.
+type isolatedDataDirState struct {
+	created   bool // The folder was picked by setIsolatedDataDir in this process
+	deployed  bool // An apply has succeeded since the last successful destroy
+	destroyed bool // A destroy has succeeded in this process
+	cleanup   bool // The removal of the folder has been registered with the test
+}
+
+// setIsolatedDataDir points TF_DATA_DIR at a unique folder for the given options, so that parallel tests running
+// against the same TerraformDir don't step on each other's .terraform folder. The folder is picked once and stored in
+// EnvVars, so all subsequent commands run with the same options share it. Nothing is done if TF_DATA_DIR has already
+// been set, either in EnvVars or in the environment of this process.
+//
+// Note that this does not cover .terraform.lock.hcl, which terraform always writes to the TerraformDir itself, so
+// parallel init commands against the same source folder can still race on it. Use CopyTerraformFolderToTemp to give
+// each test its own copy of the code if that is a problem.
+func setIsolatedDataDir(options *Options) {
+	if !shouldIsolateDataDir(options) {
+		return
+	}
+
+	if _, inEnv := os.LookupEnv(TfDataDirEnvVar); inEnv {
+		return
+	}
+
+	if options.EnvVars == nil {
+		options.EnvVars = map[string]string{}
+	}
+	if _, inOpts := options.EnvVars[TfDataDirEnvVar]; inOpts {
+		return
+	}
+
+	options.EnvVars[TfDataDirEnvVar] = filepath.Join(os.TempDir(), isolatedDataDirPrefix+random.UniqueId())
+	options.dataDirState = &isolatedDataDirState{created: true}
+}
+
+// trackIsolatedDataDir records the outcome of the given command run against the isolated TF_DATA_DIR of the given
+// options, and registers its removal with the test, if t supports Cleanup (as *testing.T does), so that each test
+// doesn't leave a copy of its providers and modules behind. When the test finishes, the folder is removed unless there
+// is still infrastructure deployed with it, so that a later run of the test (e.g., a teardown stage run with
+// test_structure) can still destroy it; in that case, it is removed by the test that runs the destroy. Folders that
+// weren't picked by setIsolatedDataDir are never removed.
+func trackIsolatedDataDir(t testing.TestingT, options *Options, args []string, err error) {
+	dataDir := options.EnvVars[TfDataDirEnvVar]
+	if !strings.HasPrefix(filepath.Base(dataDir), isolatedDataDirPrefix) {
+		return
+	}
+
+	state := options.dataDirState
+	if state == nil {
+		// The folder was picked by a previous run, e.g., one whose options were saved with test_structure
+		state = &isolatedDataDirState{}
+		options.dataDirState = state
+	}
+
+	if err == nil {
+		switch hookedCommandName(args) {
+		case "apply":
+			state.deployed = true
+		case "destroy":
+			state.deployed = false
+			state.destroyed = true
+		}
+	}
+
+	if state.cleanup {
+		return
+	}
+	cleaner, ok := t.(interface{ Cleanup(func()) })
+	if !ok {
+		return
+	}
+	state.cleanup = true
+	cleaner.Cleanup(func() {
+		if !state.deployed && (state.created || state.destroyed) {
+			os.RemoveAll(dataDir)
+		}
+	})
+}
+
+// shouldIsolateDataDir returns true if a unique TF_DATA_DIR should be used for the given options. Isolation is on when
+// explicitly requested, and by default when TerraformDir lives in the temp folder (e.g., because it was copied there
+// with CopyTerraformFolderToTemp), unless it was explicitly disabled. It is never used with terragrunt, which already
+// keeps the data of each unit in its own .terragrunt-cache folder, and whose run-all commands would otherwise have
+// all the units share (and overwrite) the same TF_DATA_DIR.
+func shouldIsolateDataDir(options *Options) bool {
+	if options.DisableDataDirIsolation || options.TerraformBinary == TerragruntDefaultPath {
+		return false
+	}
+	return options.IsolateDataDir || isInTempDir(options.TerraformDir)
+}
+
+// isInTempDir returns true if the given path is inside the OS temp folder.
+func isInTempDir(path string) bool {
+	if path == "" {
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	tempDir, err := filepath.Abs(os.TempDir())
+	if err != nil {
+		return false
+	}
+
+	// Resolve symlinks where possible, as on some platforms (e.g., macOS) the temp folder is a symlink.
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(tempDir); err == nil {
+		tempDir = resolved
+	}
+
+	rel, err := filepath.Rel(tempDir, absPath)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
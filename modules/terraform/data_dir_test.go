@@ -0,0 +1,123 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsolatedDataDirIsSetForTempFolders(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "terratest-data-dir-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	options := &Options{TerraformDir: dir}
+	setIsolatedDataDir(options)
+	dataDir := options.EnvVars[TfDataDirEnvVar]
+	assert.NotEmpty(t, dataDir)
+
+	// The same folder should be reused for subsequent commands
+	setIsolatedDataDir(options)
+	assert.Equal(t, dataDir, options.EnvVars[TfDataDirEnvVar])
+
+	// Two options pointed at the same folder should not share a data dir
+	other := &Options{TerraformDir: dir}
+	setIsolatedDataDir(other)
+	assert.NotEqual(t, dataDir, other.EnvVars[TfDataDirEnvVar])
+}
+
+func TestIsolatedDataDirIsNotSetForNonTempFolders(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{TerraformDir: "../../examples/terraform-hello-world-example"}
+	setIsolatedDataDir(options)
+	assert.NotContains(t, options.EnvVars, TfDataDirEnvVar)
+
+	options.IsolateDataDir = true
+	setIsolatedDataDir(options)
+	assert.Contains(t, options.EnvVars, TfDataDirEnvVar)
+}
+
+func TestIsolatedDataDirCanBeDisabled(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{TerraformDir: os.TempDir() + "/foo", IsolateDataDir: true, DisableDataDirIsolation: true}
+	setIsolatedDataDir(options)
+	assert.NotContains(t, options.EnvVars, TfDataDirEnvVar)
+}
+
+func TestIsolatedDataDirIsNotSetForTerragrunt(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "terratest-data-dir-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// All the units of a run-all would share the same TF_DATA_DIR
+	options := &Options{TerraformDir: dir, TerraformBinary: TerragruntDefaultPath, IsolateDataDir: true}
+	GetCommonOptions(options, runAllCmd, "apply")
+	assert.NotContains(t, options.EnvVars, TfDataDirEnvVar)
+}
+
+func TestIsolatedDataDirRespectsExplicitValue(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{IsolateDataDir: true, EnvVars: map[string]string{TfDataDirEnvVar: "/my/data/dir"}}
+	setIsolatedDataDir(options)
+	assert.Equal(t, "/my/data/dir", options.EnvVars[TfDataDirEnvVar])
+}
+
+func TestIsolatedDataDirIsRemovedWhenTestFinishes(t *testing.T) {
+	t.Parallel()
+
+	var dataDir string
+	t.Run("init", func(t *testing.T) {
+		options := &Options{IsolateDataDir: true}
+		setIsolatedDataDir(options)
+		dataDir = options.EnvVars[TfDataDirEnvVar]
+		require.NoError(t, os.MkdirAll(dataDir, 0755))
+
+		trackIsolatedDataDir(t, options, []string{"init"}, nil)
+	})
+	assert.NoDirExists(t, dataDir)
+}
+
+func TestIsolatedDataDirIsKeptWhileDeployed(t *testing.T) {
+	t.Parallel()
+
+	var dataDir string
+	var saved map[string]string
+	t.Run("deploy", func(t *testing.T) {
+		options := &Options{IsolateDataDir: true}
+		setIsolatedDataDir(options)
+		dataDir = options.EnvVars[TfDataDirEnvVar]
+		saved = options.EnvVars
+		require.NoError(t, os.MkdirAll(dataDir, 0755))
+
+		trackIsolatedDataDir(t, options, []string{"apply"}, nil)
+	})
+	assert.DirExists(t, dataDir)
+
+	// A later run of the test with the saved options removes it once it has destroyed the infrastructure
+	t.Run("teardown", func(t *testing.T) {
+		options := &Options{EnvVars: saved}
+		trackIsolatedDataDir(t, options, []string{"output"}, nil)
+		trackIsolatedDataDir(t, options, []string{"destroy"}, nil)
+	})
+	assert.NoDirExists(t, dataDir)
+}
+
+func TestIsolatedDataDirIsNotRemovedIfNotPickedByTerratest(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	t.Run("destroy", func(t *testing.T) {
+		options := &Options{EnvVars: map[string]string{TfDataDirEnvVar: dataDir}}
+		trackIsolatedDataDir(t, options, []string{"destroy"}, nil)
+	})
+	assert.DirExists(t, dataDir)
+}
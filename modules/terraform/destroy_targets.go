@@ -0,0 +1,68 @@
+package terraform
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// DestroyTargetsInOrder destroys the given groups of targets one group at a time, in order, and then destroys
+// everything else. This will fail the test if there is an error.
+func DestroyTargetsInOrder(t testing.TestingT, options *Options, targetGroups [][]string) string {
+	out, err := DestroyTargetsInOrderE(t, options, targetGroups)
+	require.NoError(t, err)
+	return out
+}
+
+// DestroyTargetsInOrderE destroys the given groups of targets one group at a time, in order, and then destroys
+// everything else. This is useful for modules where terraform's own destroy ordering gets stuck, e.g., because a
+// resource can only be deleted once something that terraform does not know about (such as the ENIs of a Lambda
+// function, or the finalizers of Kubernetes resources) has been cleaned up. Returns the output of all the destroy
+// commands.
+func DestroyTargetsInOrderE(t testing.TestingT, options *Options, targetGroups [][]string) (string, error) {
+	return DestroyTargetsInOrderWithWaitE(t, options, targetGroups, 0)
+}
+
+// DestroyTargetsInOrderWithWait works like DestroyTargetsInOrder, but sleeps for the given duration after destroying
+// each group of targets, to give asynchronous cleanups time to complete. This will fail the test if there is an error.
+func DestroyTargetsInOrderWithWait(t testing.TestingT, options *Options, targetGroups [][]string, wait time.Duration) string {
+	out, err := DestroyTargetsInOrderWithWaitE(t, options, targetGroups, wait)
+	require.NoError(t, err)
+	return out
+}
+
+// DestroyTargetsInOrderWithWaitE works like DestroyTargetsInOrderE, but sleeps for the given duration after
+// destroying each group of targets, to give asynchronous cleanups time to complete. Each destroy command is retried
+// according to the RetryableTerraformErrors of the options, and the first one that fails stops the destroy.
+func DestroyTargetsInOrderWithWaitE(t testing.TestingT, options *Options, targetGroups [][]string, wait time.Duration) (string, error) {
+	originalTargets := options.Targets
+	defer func() { options.Targets = originalTargets }()
+
+	outputs := []string{}
+	for i, targets := range targetGroups {
+		if len(targets) == 0 {
+			continue
+		}
+
+		loggerForOptions(options).Logf(t, "Destroying phase %d of %d: %v", i+1, len(targetGroups), targets)
+		options.Targets = targets
+		out, err := DestroyE(t, options)
+		outputs = append(outputs, out)
+		if err != nil {
+			return strings.Join(outputs, "\n"), err
+		}
+
+		if wait > 0 {
+			loggerForOptions(options).Logf(t, "Waiting %s before the next destroy phase", wait)
+			time.Sleep(wait)
+		}
+	}
+
+	loggerForOptions(options).Logf(t, "Destroying the remaining resources")
+	options.Targets = originalTargets
+	out, err := DestroyE(t, options)
+	outputs = append(outputs, out)
+	return strings.Join(outputs, "\n"), err
+}
@@ -0,0 +1,39 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestroyTargetsInOrderE(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary: "echo",
+		Logger:          logger.Discard,
+		Targets:         []string{"original"},
+	}
+
+	out, err := DestroyTargetsInOrderE(t, options, [][]string{{"aws_lambda_function.a"}, {}, {"aws_security_group.a", "aws_subnet.a"}})
+	require.NoError(t, err)
+	assert.Equal(t, "destroy -auto-approve -input=false -target aws_lambda_function.a -lock=false\n"+
+		"destroy -auto-approve -input=false -target aws_security_group.a -target aws_subnet.a -lock=false\n"+
+		"destroy -auto-approve -input=false -target original -lock=false", out)
+	assert.Equal(t, []string{"original"}, options.Targets)
+}
+
+func TestDestroyTargetsInOrderEStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary: "false",
+		Logger:          logger.Discard,
+	}
+
+	_, err := DestroyTargetsInOrderE(t, options, [][]string{{"aws_lambda_function.a"}, {"aws_subnet.a"}})
+	require.Error(t, err)
+	assert.Nil(t, options.Targets)
+}
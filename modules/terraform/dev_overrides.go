@@ -0,0 +1,99 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// SetProviderDevOverrides writes a temporary terraform CLI configuration file with a dev_overrides block that points
+// terraform at locally built provider binaries, and sets TF_CLI_CONFIG_FILE in options.EnvVars to use it. The
+// overrides map provider source addresses (e.g., "registry.terraform.io/acme/widget" or "acme/widget") to the folder
+// containing the provider binary (e.g., the output of go build). Returns the path of the configuration file. This will
+// fail the test if there is an error.
+func SetProviderDevOverrides(t testing.TestingT, options *Options, overrides map[string]string) string {
+	path, err := SetProviderDevOverridesE(t, options, overrides)
+	require.NoError(t, err)
+	return path
+}
+
+// SetProviderDevOverridesE writes a temporary terraform CLI configuration file with a dev_overrides block that points
+// terraform at locally built provider binaries, and sets TF_CLI_CONFIG_FILE in options.EnvVars to use it. The
+// overrides map provider source addresses (e.g., "registry.terraform.io/acme/widget" or "acme/widget") to the folder
+// containing the provider binary (e.g., the output of go build). Returns the path of the configuration file.
+//
+// Terraform installs the other providers from their registry as usual. If the options use a plugin cache, the
+// configuration also points at it. Note that terraform does not need (and warns about) running init for the overridden
+// providers, and prints a warning about the overrides on every command, so don't use WarningsAsErrors with a pattern
+// that matches it.
+func SetProviderDevOverridesE(t testing.TestingT, options *Options, overrides map[string]string) (string, error) {
+	cache, err := pluginCacheForOptions(options)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := providerDevOverridesCliConfig(overrides, cache)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp("", "terratest-dev-overrides-*.tfrc")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(config); err != nil {
+		return "", err
+	}
+
+	if options.EnvVars == nil {
+		options.EnvVars = map[string]string{}
+	}
+	options.EnvVars[TfCliConfigFileEnvVar] = file.Name()
+
+	loggerForOptions(options).Logf(t, "Using provider dev_overrides for %v in %s", sortedKeys(overrides), file.Name())
+	return file.Name(), nil
+}
+
+// providerDevOverridesCliConfig returns the contents of a terraform CLI configuration file with the given provider
+// dev_overrides, and the given plugin cache, if not nil.
+func providerDevOverridesCliConfig(overrides map[string]string, cache *PluginCache) (string, error) {
+	if len(overrides) == 0 {
+		return "", fmt.Errorf("at least one provider dev override is required")
+	}
+
+	var sb strings.Builder
+	if cache != nil {
+		fmt.Fprintf(&sb, "plugin_cache_dir = %q\n\n", filepath.ToSlash(cache.Dir))
+	}
+
+	sb.WriteString("provider_installation {\n")
+	sb.WriteString("  dev_overrides {\n")
+	for _, provider := range sortedKeys(overrides) {
+		dir, err := filepath.Abs(overrides[provider])
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("the dev override for provider %s must be the folder containing the provider binary, not %s", provider, dir)
+		}
+		fmt.Fprintf(&sb, "    %q = %q\n", provider, filepath.ToSlash(dir))
+	}
+	sb.WriteString("  }\n")
+
+	if cache != nil && cache.MirrorDir != "" {
+		fmt.Fprintf(&sb, "  filesystem_mirror {\n    path = %q\n  }\n", filepath.ToSlash(cache.MirrorDir))
+	}
+	sb.WriteString("  direct {}\n")
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
@@ -0,0 +1,55 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetProviderDevOverridesE(t *testing.T) {
+	t.Parallel()
+
+	providerDir := t.TempDir()
+	options := &Options{}
+
+	path, err := SetProviderDevOverridesE(t, options, map[string]string{"acme/widget": providerDir})
+	require.NoError(t, err)
+	defer os.Remove(path)
+
+	assert.Equal(t, path, options.EnvVars[TfCliConfigFileEnvVar])
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	expected := "provider_installation {\n" +
+		"  dev_overrides {\n" +
+		"    \"acme/widget\" = \"" + filepath.ToSlash(providerDir) + "\"\n" +
+		"  }\n" +
+		"  direct {}\n" +
+		"}\n"
+	assert.Equal(t, expected, string(contents))
+}
+
+func TestProviderDevOverridesCliConfigWithPluginCache(t *testing.T) {
+	t.Parallel()
+
+	providerDir := t.TempDir()
+	cache := &PluginCache{Dir: "/cache", MirrorDir: "/mirror"}
+
+	config, err := providerDevOverridesCliConfig(map[string]string{"acme/widget": providerDir}, cache)
+	require.NoError(t, err)
+	assert.Contains(t, config, "plugin_cache_dir = \"/cache\"\n")
+	assert.Contains(t, config, "  filesystem_mirror {\n    path = \"/mirror\"\n  }\n  direct {}\n")
+}
+
+func TestProviderDevOverridesCliConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := providerDevOverridesCliConfig(map[string]string{}, nil)
+	require.Error(t, err)
+
+	_, err = providerDevOverridesCliConfig(map[string]string{"acme/widget": filepath.Join(t.TempDir(), "missing")}, nil)
+	require.Error(t, err)
+}
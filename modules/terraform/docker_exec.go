@@ -0,0 +1,225 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
+
+// DefaultDockerCredentialEnvVars is the list of environment variables that are passed through from the host to the
+// container when running Terraform in Docker, if they are set. These cover the most common ways of passing cloud
+// credentials to Terraform providers.
+var DefaultDockerCredentialEnvVars = []string{
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_REGION",
+	"AWS_DEFAULT_REGION",
+	"ARM_CLIENT_ID",
+	"ARM_CLIENT_SECRET",
+	"ARM_SUBSCRIPTION_ID",
+	"ARM_TENANT_ID",
+	"GOOGLE_CREDENTIALS",
+	"GOOGLE_PROJECT",
+	"GOOGLE_REGION",
+}
+
+// DockerOptions configures running Terraform commands inside a Docker container, so tests can pin an exact Terraform
+// and provider toolchain without installing anything on the host. The container is started with `docker run --rm` for
+// each command. TerraformDir is bind mounted at the same path inside the container, so absolute paths to var files and
+// plan files that live under it keep working. VarFiles and PlanFilePath outside of it are mounted at the same path too.
+type DockerOptions struct {
+	// The image to run (e.g., hashicorp/terraform:1.7.5). The TerraformBinary is used as the entrypoint, so the image
+	// must have it on the PATH.
+	Image string
+
+	// The folder to bind mount into the container. Defaults to TerraformDir. Set this to a parent folder if the
+	// Terraform code references modules outside of TerraformDir (e.g., source = "../modules/foo").
+	MountDir string
+
+	// Additional volumes to bind mount, in the docker src:dest[:opts] format
+	Volumes []string
+
+	// Names of environment variables to pass through from the host, if set. Defaults to DefaultDockerCredentialEnvVars.
+	// EnvVars set on the Options are always passed.
+	PassEnvVars []string
+
+	// Username or UID to run the container as. Defaults to the uid:gid of the current user (except on Windows), so that
+	// the files terraform writes to the mounted folders (e.g., .terraform and state files) can be removed by the test.
+	// Set this to "root" to run as the user of the image instead.
+	User string
+
+	// The network to connect the container to
+	Network string
+
+	// Custom CLI options that will be passed as-is to the 'docker run' command
+	OtherOptions []string
+}
+
+// generateDockerCommand wraps the given terraform command in a `docker run` command. Environment variables are passed
+// by name only (e.g., --env FOO), with the values set on the docker client process, so that secrets don't show up in
+// the logged command line.
+func generateDockerCommand(options *Options, cmd shell.Command) shell.Command {
+	dockerOptions := options.Docker
+
+	mountDir := dockerOptions.MountDir
+	if mountDir == "" {
+		mountDir = options.TerraformDir
+	}
+
+	args := []string{"run", "--rm", "-i"}
+
+	mounts := []string{}
+	if mountDir != "" {
+		mounts = append(mounts, mountDir)
+	}
+	mounts = append(mounts, dockerMountsForEnvVars(options.EnvVars)...)
+	mounts = append(mounts, dockerMountsForFiles(options, mountDir)...)
+	for _, mount := range mounts {
+		absMount, err := filepath.Abs(mount)
+		if err != nil {
+			absMount = mount
+		}
+		args = append(args, "--volume", absMount+":"+absMount)
+	}
+	for _, volume := range dockerOptions.Volumes {
+		args = append(args, "--volume", volume)
+	}
+
+	if options.TerraformDir != "" {
+		workingDir, err := filepath.Abs(options.TerraformDir)
+		if err != nil {
+			workingDir = options.TerraformDir
+		}
+		args = append(args, "--workdir", workingDir)
+	}
+
+	env := map[string]string{}
+	passEnvVars := dockerOptions.PassEnvVars
+	if passEnvVars == nil {
+		passEnvVars = DefaultDockerCredentialEnvVars
+	}
+	for _, name := range passEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+	for name, value := range cmd.Env {
+		env[name] = value
+	}
+
+	// Sort the env var names so the generated command is deterministic
+	envNames := make([]string, 0, len(env))
+	for name := range env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		args = append(args, "--env", name)
+	}
+
+	user := dockerOptions.User
+	if user == "" {
+		user = currentDockerUser()
+	}
+	if user != "" {
+		args = append(args, "--user", user)
+	}
+	if dockerOptions.Network != "" {
+		args = append(args, "--network", dockerOptions.Network)
+	}
+	args = append(args, dockerOptions.OtherOptions...)
+
+	args = append(args, "--entrypoint", filepath.Base(cmd.Command), dockerOptions.Image)
+	args = append(args, cmd.Args...)
+
+	return shell.Command{
+		Command: "docker",
+		Args:    args,
+		Env:     env,
+		Logger:  cmd.Logger,
+
+		OutputLineCallback: cmd.OutputLineCallback,
+	}
+}
+
+// dockerMountsForEnvVars returns the host folders referenced by Terraform environment variables that terratest may
+// set (e.g., an isolated TF_DATA_DIR or a shared plugin cache), which must also be mounted for them to work inside the
+// container.
+func dockerMountsForEnvVars(envVars map[string]string) []string {
+	mounts := []string{}
+	if dataDir, ok := envVars[TfDataDirEnvVar]; ok {
+		// Terraform would create this folder itself, but docker would create it as root if we don't do it first.
+		os.MkdirAll(dataDir, os.ModePerm)
+		mounts = append(mounts, dataDir)
+	}
+	if cliConfigFile, ok := envVars[TfCliConfigFileEnvVar]; ok {
+		mounts = append(mounts, filepath.Dir(cliConfigFile))
+	}
+	if pluginCacheDir, ok := envVars[TfPluginCacheDirEnvVar]; ok {
+		mounts = append(mounts, pluginCacheDir)
+	}
+	return mounts
+}
+
+// dockerMountsForFiles returns the files passed to terraform by the given options that live outside of mountDir, and
+// so must also be mounted for terraform to find them inside the container: the VarFiles, the var file rendered for
+// VarsAsFile, and the folder of the PlanFilePath (which may not exist yet when planning). Relative paths are resolved
+// against TerraformDir, which is the working directory of the container.
+func dockerMountsForFiles(options *Options, mountDir string) []string {
+	files := append([]string{}, options.VarFiles...)
+	if options.varsFile != "" {
+		files = append(files, options.varsFile)
+	}
+	if options.PlanFilePath != "" {
+		planDir := filepath.Dir(resolveDockerPath(options.TerraformDir, options.PlanFilePath))
+		// Docker would create this folder as root if we don't do it first
+		os.MkdirAll(planDir, os.ModePerm)
+		files = append(files, planDir)
+	}
+
+	mounts := []string{}
+	for _, file := range files {
+		file = resolveDockerPath(options.TerraformDir, file)
+		if mountDir != "" && isSubPath(file, resolveDockerPath("", mountDir)) {
+			continue
+		}
+		mounts = append(mounts, file)
+	}
+	return mounts
+}
+
+// resolveDockerPath returns the absolute path of the given path, resolving relative paths against workingDir.
+func resolveDockerPath(workingDir string, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return absPath
+}
+
+// isSubPath returns true if path is dir or is inside of it.
+func isSubPath(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// currentDockerUser returns the uid:gid of the current user, in the format expected by docker run --user, or an empty
+// string on platforms that don't have them (i.e., Windows).
+func currentDockerUser() string {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 || gid < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", uid, gid)
+}
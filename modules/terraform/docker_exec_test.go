@@ -0,0 +1,115 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCommandWithDocker(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The container only runs as the current user on platforms that have uids")
+	}
+
+	terraformDir, err := filepath.Abs("../../examples/terraform-hello-world-example")
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformBinary: "terraform",
+		TerraformDir:    terraformDir,
+		EnvVars:         map[string]string{"TF_VAR_secret": "hunter2"},
+		Docker: &DockerOptions{
+			Image:       "hashicorp/terraform:1.7.5",
+			PassEnvVars: []string{},
+			Network:     "host",
+		},
+	}
+
+	cmd := generateCommand(options, "plan", "-input=false")
+	assert.Equal(t, "docker", cmd.Command)
+	assert.Equal(t, []string{
+		"run", "--rm", "-i",
+		"--volume", terraformDir + ":" + terraformDir,
+		"--workdir", terraformDir,
+		"--env", "TF_VAR_secret",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"--network", "host",
+		"--entrypoint", "terraform", "hashicorp/terraform:1.7.5",
+		"plan", "-input=false",
+	}, cmd.Args)
+	assert.Equal(t, "hunter2", cmd.Env["TF_VAR_secret"])
+	assert.NotContains(t, cmd.Args, "hunter2")
+}
+
+func TestGenerateCommandWithDockerMountsDataDir(t *testing.T) {
+	t.Parallel()
+
+	dataDir := filepath.Join(t.TempDir(), "data")
+	options := &Options{
+		TerraformBinary: "tofu",
+		TerraformDir:    t.TempDir(),
+		EnvVars:         map[string]string{TfDataDirEnvVar: dataDir},
+		Docker:          &DockerOptions{Image: "ghcr.io/opentofu/opentofu:1.6.2", MountDir: os.TempDir()},
+	}
+
+	cmd := generateCommand(options, "init")
+	assert.Contains(t, cmd.Args, dataDir+":"+dataDir)
+	assert.Contains(t, cmd.Args, "tofu")
+	assert.DirExists(t, dataDir)
+}
+
+func TestGenerateCommandWithDockerMountsVarsFile(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary: "terraform",
+		TerraformDir:    t.TempDir(),
+		Vars:            map[string]interface{}{"foo": "bar"},
+		VarsAsFile:      true,
+		Docker:          &DockerOptions{Image: "hashicorp/terraform:1.7.5"},
+	}
+
+	args := FormatArgs(options, "plan")
+	cmd := generateCommand(options, args...)
+	assert.Contains(t, cmd.Args, options.varsFile+":"+options.varsFile)
+	assert.Contains(t, cmd.Args, options.varsFile)
+}
+
+func TestGenerateCommandWithDockerMountsFilesOutsideMountDir(t *testing.T) {
+	t.Parallel()
+
+	terraformDir := t.TempDir()
+	outsideDir := t.TempDir()
+	options := &Options{
+		TerraformBinary: "terraform",
+		TerraformDir:    terraformDir,
+		VarFiles:        []string{"inside.tfvars", filepath.Join(outsideDir, "outside.tfvars")},
+		PlanFilePath:    filepath.Join(outsideDir, "plans", "plan.out"),
+		Docker:          &DockerOptions{Image: "hashicorp/terraform:1.7.5", User: "root"},
+	}
+
+	cmd := generateCommand(options, "plan")
+	varFile := filepath.Join(outsideDir, "outside.tfvars")
+	planDir := filepath.Join(outsideDir, "plans")
+	assert.Contains(t, cmd.Args, varFile+":"+varFile)
+	assert.Contains(t, cmd.Args, planDir+":"+planDir)
+	assert.NotContains(t, cmd.Args, filepath.Join(terraformDir, "inside.tfvars")+":"+filepath.Join(terraformDir, "inside.tfvars"))
+	assert.DirExists(t, planDir)
+	assert.Contains(t, strings.Join(cmd.Args, " "), "--user root")
+}
+
+func TestIsSubPath(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isSubPath("/tmp/foo", "/tmp/foo"))
+	assert.True(t, isSubPath("/tmp/foo/bar.tfvars", "/tmp/foo"))
+	assert.False(t, isSubPath("/tmp/foobar/bar.tfvars", "/tmp/foo"))
+	assert.False(t, isSubPath("/tmp/bar.tfvars", "/tmp/foo"))
+}
@@ -0,0 +1,132 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ephemeralVars returns the Vars of the given options that are listed in EphemeralVars.
+func ephemeralVars(options *Options) map[string]interface{} {
+	vars := map[string]interface{}{}
+	for _, name := range options.EphemeralVars {
+		if value, ok := options.Vars[name]; ok {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// GetEphemeralOutputNames returns the names of the outputs declared with `ephemeral = true` in the *.tf files of the
+// given module folder, sorted alphabetically. This will fail the test if the files can't be parsed.
+func GetEphemeralOutputNames(t testing.TestingT, moduleDir string) []string {
+	names, err := GetEphemeralOutputNamesE(moduleDir)
+	require.NoError(t, err)
+	return names
+}
+
+// GetEphemeralOutputNamesE returns the names of the outputs declared with `ephemeral = true` in the *.tf files of the
+// given module folder, sorted alphabetically. As Terraform doesn't persist ephemeral outputs anywhere, the only way to
+// check them is to inspect the configuration.
+func GetEphemeralOutputNamesE(moduleDir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(moduleDir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	parser := hclparse.NewParser()
+	names := []string{}
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, diags := parser.ParseHCL(contents, path)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "output" || len(block.Labels) != 1 {
+				continue
+			}
+			attr, hasEphemeral := block.Body.Attributes["ephemeral"]
+			if !hasEphemeral {
+				continue
+			}
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			if value.Type() == cty.Bool && value.IsKnown() && !value.IsNull() && value.True() {
+				names = append(names, block.Labels[0])
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// AssertOutputIsEphemeral checks that the given module folder declares an output with the given name and
+// `ephemeral = true`, failing the test if it does not.
+func AssertOutputIsEphemeral(t testing.TestingT, moduleDir string, name string) {
+	names, err := GetEphemeralOutputNamesE(moduleDir)
+	if assert.NoError(t, err) {
+		assert.Containsf(t, names, name, "Output %s is not declared as ephemeral in %s", name, moduleDir)
+	}
+}
+
+// EphemeralValuesNotInStateE pulls the state with the given options and returns an EphemeralValuesInState error if it
+// contains the value of any of the EphemeralVars of the options, or any of the given extra values (e.g., the value of
+// an ephemeral resource, or of a write-only attribute, that the test knows about).
+func EphemeralValuesNotInStateE(t testing.TestingT, options *Options, extraValues ...string) error {
+	state, err := RunTerraformCommandAndGetStdoutE(t, options, "state", "pull")
+	if err != nil {
+		return err
+	}
+
+	found := []string{}
+	for _, name := range options.EphemeralVars {
+		value, ok := options.Vars[name]
+		if !ok || value == nil {
+			continue
+		}
+		if str := toHclString(value, false); str != "" && strings.Contains(state, str) {
+			found = append(found, "var."+name)
+		}
+	}
+	for i, value := range extraValues {
+		if value != "" && strings.Contains(state, value) {
+			// Don't include the value itself in the error, as it's a secret
+			found = append(found, "extra value #"+strconv.Itoa(i))
+		}
+	}
+	if len(found) > 0 {
+		return EphemeralValuesInState(found)
+	}
+	return nil
+}
+
+// AssertEphemeralValuesNotInState checks that the state doesn't contain the value of any of the EphemeralVars of the
+// given options, or any of the given extra values, failing the test if it does.
+func AssertEphemeralValuesNotInState(t testing.TestingT, options *Options, extraValues ...string) {
+	assert.NoError(t, EphemeralValuesNotInStateE(t, options, extraValues...))
+}
+
+// RequireEphemeralValuesNotInState checks that the state doesn't contain the value of any of the EphemeralVars of the
+// given options, or any of the given extra values, failing and halting the test if it does.
+func RequireEphemeralValuesNotInState(t testing.TestingT, options *Options, extraValues ...string) {
+	require.NoError(t, EphemeralValuesNotInStateE(t, options, extraValues...))
+}
@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEphemeralOutputNamesE(t *testing.T) {
+	t.Parallel()
+
+	moduleDir := t.TempDir()
+	config := `
+output "token" {
+  value     = ephemeral.random_password.token.result
+  ephemeral = true
+}
+
+output "id" {
+  value = random_id.this.hex
+}
+
+output "not_ephemeral" {
+  value     = "foo"
+  ephemeral = false
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "outputs.tf"), []byte(config), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte(`output "api_key" { ephemeral = true }`), 0644))
+
+	names, err := GetEphemeralOutputNamesE(moduleDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api_key", "token"}, names)
+
+	AssertOutputIsEphemeral(t, moduleDir, "token")
+}
+
+func TestFormatArgsPassesEphemeralVarsToSavedPlanApply(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		Vars:          map[string]interface{}{"token": "secret", "name": "foo"},
+		EphemeralVars: []string{"token"},
+		PlanFilePath:  "plan.out",
+	}
+
+	assert.Equal(t, []string{"apply", "-var", "token=secret", "-lock=false", "plan.out"}, FormatArgs(options, "apply"))
+	assert.Contains(t, secretValues(options), "secret")
+}
+
+func TestEphemeralValuesNotInStateE(t *testing.T) {
+	t.Parallel()
+
+	// echo prints "state pull", which stands in for the state
+	options := &Options{
+		TerraformBinary: "echo",
+		TerraformDir:    t.TempDir(),
+		Logger:          logger.Discard,
+		Vars:            map[string]interface{}{"token": "secret"},
+		EphemeralVars:   []string{"token"},
+	}
+	require.NoError(t, EphemeralValuesNotInStateE(t, options, "other"))
+
+	options.Vars["token"] = "pull"
+	err := EphemeralValuesNotInStateE(t, options, "other", "state")
+	assert.Equal(t, EphemeralValuesInState{"var.token", "extra value #1"}, err)
+}
@@ -3,6 +3,7 @@ package terraform
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // TgInvalidBinary occurs when a terragrunt function is called and the TerraformBinary is
@@ -101,3 +102,68 @@ type WorkspaceDoesNotExist string
 func (err WorkspaceDoesNotExist) Error() string {
 	return fmt.Sprintf("The workspace %q does not exist.", string(err))
 }
+
+// NoMatchingTerraformVersion is returned when no released version of terraform or tofu matches the RequiredVersion
+// constraint.
+type NoMatchingTerraformVersion struct {
+	Binary     string
+	Constraint string
+}
+
+func (err NoMatchingTerraformVersion) Error() string {
+	return fmt.Sprintf("no released version of %s matches the constraint %q", err.Binary, err.Constraint)
+}
+
+// ChecksumMismatch is returned when a downloaded file doesn't match its published checksum.
+type ChecksumMismatch struct {
+	FileName string
+	Expected string
+	Actual   string
+}
+
+func (err ChecksumMismatch) Error() string {
+	if err.Expected == "" {
+		return fmt.Sprintf("no checksum was published for %s", err.FileName)
+	}
+	return fmt.Sprintf("checksum mismatch for %s: expected %s but got %s", err.FileName, err.Expected, err.Actual)
+}
+
+// TgDependencyNotFound occurs when a terragrunt configuration does not contain the given dependency block.
+type TgDependencyNotFound struct {
+	Dependency string
+	ConfigPath string
+}
+
+func (err TgDependencyNotFound) Error() string {
+	return fmt.Sprintf("dependency %q not found in %s", err.Dependency, err.ConfigPath)
+}
+
+// UnsupportedStateVersion occurs when a Terraform state file uses a format version other than 4.
+type UnsupportedStateVersion int
+
+func (err UnsupportedStateVersion) Error() string {
+	return fmt.Sprintf("unsupported Terraform state format version %d: only version 4 (Terraform 0.12 and above) is supported", int(err))
+}
+
+// ResourceNotFoundInState occurs when a Terraform state does not contain a resource with the given address.
+type ResourceNotFoundInState string
+
+func (err ResourceNotFoundInState) Error() string {
+	return fmt.Sprintf("resource %s not found in the state", string(err))
+}
+
+// PlanHasNonMoveChanges occurs when a plan that is expected to only move resources to new addresses also changes some
+// resources. It lists the addresses of those resources along with their planned actions.
+type PlanHasNonMoveChanges []string
+
+func (err PlanHasNonMoveChanges) Error() string {
+	return fmt.Sprintf("expected the plan to only move resources, but it also changes:\n%s", strings.Join(err, "\n"))
+}
+
+// EphemeralValuesInState occurs when the Terraform state contains ephemeral values, which Terraform should never
+// persist. It lists where the values came from, rather than the values themselves.
+type EphemeralValuesInState []string
+
+func (err EphemeralValuesInState) Error() string {
+	return fmt.Sprintf("the state contains the values of ephemeral inputs: %s", strings.Join(err, ", "))
+}
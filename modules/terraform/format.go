@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/gruntwork-io/terratest/modules/collections"
+	"github.com/gruntwork-io/terratest/modules/shell"
 )
 
 const runAllCmd = "run-all"
@@ -50,7 +51,8 @@ func FormatArgs(options *Options, args ...string) []string {
 	lockSupported := collections.ListContains(TerraformCommandsWithLockSupport, commandType)
 	planFileSupported := collections.ListContains(TerraformCommandsWithPlanFileSupport, commandType)
 
-	// Include -var and -var-file flags unless we're running 'apply' with a plan file
+	// Include -var and -var-file flags unless we're running 'apply' with a plan file (in which case only the ephemeral
+	// vars are included)
 	includeVars := !(commandType == "apply" && len(options.PlanFilePath) > 0)
 
 	terraformArgs = append(terraformArgs, args...)
@@ -60,13 +62,21 @@ func FormatArgs(options *Options, args ...string) []string {
 			terraformArgs = append(terraformArgs, v.Args()...)
 		}
 
+		varsArgs := FormatTerraformVarsAsArgs(options.Vars)
+		if options.VarsAsFile {
+			varsArgs = formatTerraformVarsAsVarFileArgs(options)
+		}
+
 		if options.SetVarsAfterVarFiles {
 			terraformArgs = append(terraformArgs, FormatTerraformArgs("-var-file", options.VarFiles)...)
-			terraformArgs = append(terraformArgs, FormatTerraformVarsAsArgs(options.Vars)...)
+			terraformArgs = append(terraformArgs, varsArgs...)
 		} else {
-			terraformArgs = append(terraformArgs, FormatTerraformVarsAsArgs(options.Vars)...)
+			terraformArgs = append(terraformArgs, varsArgs...)
 			terraformArgs = append(terraformArgs, FormatTerraformArgs("-var-file", options.VarFiles)...)
 		}
+	} else if commandType == "apply" {
+		// Saved plans don't store the values of ephemeral variables, so they must be set again when applying them
+		terraformArgs = append(terraformArgs, FormatTerraformVarsAsArgs(ephemeralVars(options))...)
 	}
 
 	terraformArgs = append(terraformArgs, FormatTerraformArgs("-target", options.Targets)...)
@@ -75,6 +85,10 @@ func FormatArgs(options *Options, args ...string) []string {
 		terraformArgs = append(terraformArgs, "-no-color")
 	}
 
+	if options.CompactWarnings && collections.ListContains(TerraformCommandsWithCompactWarningsSupport, commandType) {
+		terraformArgs = append(terraformArgs, "-compact-warnings")
+	}
+
 	if lockSupported {
 		// If command supports locking, handle lock arguments
 		terraformArgs = append(terraformArgs, FormatTerraformLockAsArgs(options.Lock, options.LockTimeout)...)
@@ -107,6 +121,16 @@ func FormatTerraformVarsAsArgs(vars map[string]interface{}) []string {
 	return formatTerraformArgs(vars, "-var", true, false)
 }
 
+// formatTerraformVarsAsVarFileArgs returns the -var-file arg pointing at the var file that the Vars of the given options
+// are rendered into. The file itself is written right before the command runs (see writeOptionsVarsFileE), so that any
+// error writing it can be returned to the caller.
+func formatTerraformVarsAsVarFileArgs(options *Options) []string {
+	if len(options.Vars) == 0 {
+		return nil
+	}
+	return FormatTerraformArgs("-var-file", []string{optionsVarsFile(options)})
+}
+
 // FormatTerraformLockAsArgs formats the lock and lock-timeout variables
 // -lock, -lock-timeout
 func FormatTerraformLockAsArgs(lockCheck bool, lockTimeout string) []string {
@@ -249,7 +273,7 @@ func mapToHclString(m map[string]interface{}) string {
 	keyValuePairs := []string{}
 
 	for key, value := range m {
-		keyValuePair := fmt.Sprintf(`"%s" = %s`, key, toHclString(value, true))
+		keyValuePair := fmt.Sprintf(`%s = %s`, shell.QuoteHclString(key), toHclString(value, true))
 		keyValuePairs = append(keyValuePairs, keyValuePair)
 	}
 
@@ -270,8 +294,9 @@ func primitiveToHclString(value interface{}, isNested bool) string {
 
 	case string:
 		// If string is nested in a larger data structure (e.g. list of string, map of string), ensure value is quoted
+		// and escaped, so that quotes and backslashes (e.g., in Windows paths) produce the same value on every OS
 		if isNested {
-			return fmt.Sprintf("\"%v\"", v)
+			return shell.QuoteHclString(v)
 		}
 
 		return fmt.Sprintf("%v", v)
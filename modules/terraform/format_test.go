@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFormatTerraformPlanFileAsArgs(t *testing.T) {
@@ -111,6 +112,7 @@ func TestMapToHclString(t *testing.T) {
 		{map[string]interface{}{"key1": []int{1, 2, 3}}, "{\"key1\" = [1, 2, 3]}"}, // Any value that isn't a primitive is forced into a string
 		{map[string]interface{}{"key1": "value1", "key2": 0, "key3": false}, "{\"key1\" = \"value1\", \"key2\" = 0, \"key3\" = false}"},
 		{map[string]interface{}{"key1.a.b.c": "value1"}, "{\"key1.a.b.c\" = \"value1\"}"},
+		{map[string]interface{}{`"quoted"`: `C:\tmp`}, `{"\"quoted\"" = "C:\\tmp"}`},
 	}
 
 	for _, testCase := range testCases {
@@ -165,6 +167,7 @@ func TestSliceToHclString(t *testing.T) {
 		{[]interface{}{true}, "[true]"},
 		{[]interface{}{[]int{1, 2, 3}}, "[[1, 2, 3]]"}, // Any value that isn't a primitive is forced into a string
 		{[]interface{}{"foo", 0, false}, "[\"foo\", 0, false]"},
+		{[]interface{}{`C:\tmp\foo`, `say "hi"`, "${var.foo}"}, `["C:\\tmp\\foo", "say \"hi\"", "$${var.foo}"]`},
 		{[]interface{}{map[string]interface{}{"foo": "bar"}}, "[{\"foo\" = \"bar\"}]"},
 		{[]interface{}{map[string]interface{}{"foo": "bar"}, map[string]interface{}{"foo": "bar"}}, "[{\"foo\" = \"bar\"}, {\"foo\" = \"bar\"}]"},
 	}
@@ -348,3 +351,25 @@ func TestMixedVars(t *testing.T) {
 		assert.Equal(t, testCase.expected[len(testCase.expected)-1], result[len(result)-1])
 	}
 }
+
+func TestFormatArgsVarsAsFile(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]interface{}{"foo": "bar", "list": []int{1, 2, 3}}
+	options := &Options{VarsAsFile: true, Vars: vars, VarFiles: []string{"test.tfvars"}}
+	result := FormatArgs(options, "plan")
+
+	require.Len(t, result, 6)
+	assert.Equal(t, []string{"plan", "-var-file"}, result[:2])
+	assert.Equal(t, []string{"-var-file", "test.tfvars", "-lock=false"}, result[3:])
+	assert.NotContains(t, result, "-var")
+
+	// The same file is used for every command run with the same options
+	assert.Equal(t, result[2], FormatArgs(options, "apply")[2])
+
+	require.NoError(t, writeOptionsVarsFileE(t, options))
+	var actual map[string]interface{}
+	GetAllVariablesFromVarFile(t, result[2], &actual)
+	assert.Equal(t, "bar", actual["foo"])
+	assert.Equal(t, []interface{}{1.0, 2.0, 3.0}, actual["list"])
+}
@@ -0,0 +1,98 @@
+package terraform
+
+import (
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Hooks are callbacks that are invoked around the Terraform commands run with an Options struct. They can be used to,
+// e.g., record metrics, snapshot state between stages, or inject faults, without having to wrap every function in this
+// package. Any of the callbacks may be nil. The args passed to the callbacks are the full list of args passed to the
+// Terraform binary, and output is the combined stdout and stderr of the command (or only stdout, for functions that
+// return only stdout).
+type Hooks struct {
+	// Called before every command
+	BeforeCommand func(t testing.TestingT, args []string)
+	// Called after every command, whether it succeeded or not
+	AfterCommand func(t testing.TestingT, args []string, output string, err error)
+
+	// Called before every apply command
+	BeforeApply func(t testing.TestingT, args []string)
+	// Called after every apply command, whether it succeeded or not
+	AfterApply func(t testing.TestingT, args []string, output string, err error)
+
+	// Called before every destroy command
+	BeforeDestroy func(t testing.TestingT, args []string)
+	// Called after every destroy command, whether it succeeded or not
+	AfterDestroy func(t testing.TestingT, args []string, output string, err error)
+
+	// Called before a command is retried, with the number of the attempt that is about to be made (starting at 2) and
+	// the output and error of the previous attempt
+	OnRetry func(t testing.TestingT, args []string, attempt int, output string, err error)
+
+	// Called when a command fails, after all retries have been exhausted
+	OnError func(t testing.TestingT, args []string, output string, err error)
+}
+
+// runBefore invokes the callbacks that should run before the command with the given args. It is safe to call on a nil
+// Hooks.
+func (hooks *Hooks) runBefore(t testing.TestingT, args []string) {
+	if hooks == nil {
+		return
+	}
+	if hooks.BeforeCommand != nil {
+		hooks.BeforeCommand(t, args)
+	}
+	switch hookedCommandName(args) {
+	case "apply":
+		if hooks.BeforeApply != nil {
+			hooks.BeforeApply(t, args)
+		}
+	case "destroy":
+		if hooks.BeforeDestroy != nil {
+			hooks.BeforeDestroy(t, args)
+		}
+	}
+}
+
+// runAfter invokes the callbacks that should run after the command with the given args. It is safe to call on a nil
+// Hooks.
+func (hooks *Hooks) runAfter(t testing.TestingT, args []string, output string, err error) {
+	if hooks == nil {
+		return
+	}
+	if err != nil && hooks.OnError != nil {
+		hooks.OnError(t, args, output, err)
+	}
+	switch hookedCommandName(args) {
+	case "apply":
+		if hooks.AfterApply != nil {
+			hooks.AfterApply(t, args, output, err)
+		}
+	case "destroy":
+		if hooks.AfterDestroy != nil {
+			hooks.AfterDestroy(t, args, output, err)
+		}
+	}
+	if hooks.AfterCommand != nil {
+		hooks.AfterCommand(t, args, output, err)
+	}
+}
+
+// runRetry invokes the OnRetry callback. It is safe to call on a nil Hooks.
+func (hooks *Hooks) runRetry(t testing.TestingT, args []string, attempt int, output string, err error) {
+	if hooks == nil || hooks.OnRetry == nil {
+		return
+	}
+	hooks.OnRetry(t, args, attempt, output, err)
+}
+
+// hookedCommandName returns the name of the Terraform command in the given args, looking through run-all.
+func hookedCommandName(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if args[0] == runAllCmd && len(args) > 1 {
+		return args[1]
+	}
+	return args[0]
+}
@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	ttesting "github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooksAreCalledAroundApply(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	options := &Options{
+		TerraformBinary: "echo",
+		Logger:          logger.Discard,
+		Hooks: &Hooks{
+			BeforeCommand: func(_ ttesting.TestingT, args []string) { calls = append(calls, "before-command") },
+			BeforeApply:   func(_ ttesting.TestingT, args []string) { calls = append(calls, "before-apply") },
+			AfterApply: func(_ ttesting.TestingT, args []string, output string, err error) {
+				assert.Equal(t, "apply -auto-approve", output)
+				assert.NoError(t, err)
+				calls = append(calls, "after-apply")
+			},
+			BeforeDestroy: func(_ ttesting.TestingT, args []string) { calls = append(calls, "before-destroy") },
+			AfterCommand: func(_ ttesting.TestingT, args []string, output string, err error) {
+				calls = append(calls, "after-command")
+			},
+		},
+	}
+
+	_, err := RunTerraformCommandE(t, options, "apply", "-auto-approve")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"before-command", "before-apply", "after-apply", "after-command"}, calls)
+}
+
+func TestHooksAreCalledOnRetryAndError(t *testing.T) {
+	t.Parallel()
+
+	var attempts []int
+	var failedArgs []string
+	options := &Options{
+		TerraformBinary:          "false",
+		Logger:                   logger.Discard,
+		RetryableTerraformErrors: map[string]string{".*": "always retry"},
+		MaxRetries:               2,
+		TimeBetweenRetries:       time.Millisecond,
+		Hooks: &Hooks{
+			OnRetry: func(_ ttesting.TestingT, args []string, attempt int, output string, err error) {
+				assert.Error(t, err)
+				attempts = append(attempts, attempt)
+			},
+			OnError: func(_ ttesting.TestingT, args []string, output string, err error) {
+				failedArgs = args
+			},
+		},
+	}
+
+	_, err := RunTerraformCommandE(t, options, "plan")
+	require.Error(t, err)
+	assert.Equal(t, []int{2, 3}, attempts)
+	assert.Equal(t, []string{"plan"}, failedArgs)
+}
+
+func TestHookedCommandName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "apply", hookedCommandName([]string{"apply", "-auto-approve"}))
+	assert.Equal(t, "destroy", hookedCommandName([]string{"run-all", "destroy"}))
+	assert.Equal(t, "", hookedCommandName(nil))
+}
@@ -34,5 +34,20 @@ func InitE(t testing.TestingT, options *Options) (string, error) {
 
 	args = append(args, FormatTerraformBackendConfigAsArgs(options.BackendConfig)...)
 	args = append(args, FormatTerraformPluginDirAsArgs(options.PluginDir)...)
+
+	// Terraform doesn't support concurrent writes to the plugin cache, so hold the cache lock while running init.
+	cache, err := pluginCacheForOptions(options)
+	if err != nil {
+		return "", err
+	}
+	if cache != nil {
+		cache.configureOptions(options)
+		unlock, err := cache.lock()
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+	}
+
 	return RunTerraformCommandE(t, options, prepend(options.ExtraArgs.Init, args...)...)
 }
@@ -0,0 +1,172 @@
+package terraform
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// OverrideModuleSources rewrites the source of the module calls in the .tf files in the given folder (and its
+// subfolders) that use one of the given sources, so that they use the matching local folder instead. This allows tests
+// to exercise the modules in the working tree rather than the last published version. Returns the paths of the files
+// that were modified. This will fail the test if there is an error.
+func OverrideModuleSources(t testing.TestingT, dir string, overrides map[string]string) []string {
+	modified, err := OverrideModuleSourcesE(t, dir, overrides)
+	require.NoError(t, err)
+	return modified
+}
+
+// OverrideModuleSourcesE rewrites the source of the module calls in the .tf files in the given folder (and its
+// subfolders) that use one of the given sources, so that they use the matching local folder instead. This allows tests
+// to exercise the modules in the working tree rather than the last published version. Returns the paths of the files
+// that were modified.
+//
+// The overrides map module sources, without any version or ref (e.g., "acme/vpc/aws" or
+// "git::https://github.com/acme/modules.git"), to local folders. Sub-folders in the source (e.g.,
+// "git::https://github.com/acme/modules.git//vpc?ref=v1.0.0") are kept, and the version argument of the module calls
+// is removed, as it is not allowed with local paths.
+//
+// NOTE: this modifies the files in place, so you will typically want to call it on a copy of your module made with
+// test_structure.CopyTerraformFolderToTemp.
+func OverrideModuleSourcesE(t testing.TestingT, dir string, overrides map[string]string) ([]string, error) {
+	absOverrides := map[string]string{}
+	for source, localPath := range overrides {
+		absPath, err := filepath.Abs(localPath)
+		if err != nil {
+			return nil, err
+		}
+		absOverrides[source] = absPath
+	}
+
+	modified := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tf" {
+			return nil
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated, changed, err := overrideModuleSourcesInFile(path, contents, absOverrides)
+		if err != nil || !changed {
+			return err
+		}
+		if err := os.WriteFile(path, updated, 0644); err != nil {
+			return err
+		}
+		modified = append(modified, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modified) > 0 {
+		logger.Default.Logf(t, "Overrode module sources with local folders in %v", modified)
+	}
+	return modified, nil
+}
+
+// overrideModuleSourcesInFile rewrites the source of the module calls in the given .tf file that use one of the given
+// sources, and returns the updated contents and whether anything changed.
+func overrideModuleSourcesInFile(filename string, contents []byte, overrides map[string]string) ([]byte, bool, error) {
+	file, diags := hclwrite.ParseConfig(contents, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false, diags
+	}
+
+	changed := false
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "module" {
+			continue
+		}
+		sourceAttr := block.Body().GetAttribute("source")
+		if sourceAttr == nil {
+			continue
+		}
+		source, ok := literalString(sourceAttr.Expr().BuildTokens(nil).Bytes())
+		if !ok {
+			continue
+		}
+
+		localPath, ok := localModulePath(source, overrides)
+		if !ok {
+			continue
+		}
+		relPath, err := filepath.Rel(filepath.Dir(filename), localPath)
+		if err != nil {
+			return nil, false, err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if !strings.HasPrefix(relPath, "../") {
+			relPath = "./" + relPath
+		}
+
+		block.Body().SetAttributeValue("source", cty.StringVal(relPath))
+		block.Body().RemoveAttribute("version")
+		changed = true
+	}
+
+	return hclwrite.Format(file.Bytes()), changed, nil
+}
+
+// localModulePath returns the local folder to use instead of the given module source, if it matches one of the given
+// overrides.
+func localModulePath(source string, overrides map[string]string) (string, bool) {
+	sourceWithoutRef := source
+	if i := strings.Index(sourceWithoutRef, "?"); i >= 0 {
+		sourceWithoutRef = sourceWithoutRef[:i]
+	}
+
+	// Sub-folders are separated by a double slash, which must not be confused with the one after the scheme.
+	subDir := ""
+	schemeEnd := 0
+	if i := strings.Index(sourceWithoutRef, "://"); i >= 0 {
+		schemeEnd = i + len("://")
+	}
+	if i := strings.Index(sourceWithoutRef[schemeEnd:], "//"); i >= 0 {
+		subDir = sourceWithoutRef[schemeEnd+i+len("//"):]
+		sourceWithoutRef = sourceWithoutRef[:schemeEnd+i]
+	}
+
+	localPath, ok := overrides[sourceWithoutRef]
+	if !ok {
+		return "", false
+	}
+	if subDir != "" {
+		localPath = filepath.Join(localPath, filepath.FromSlash(subDir))
+	}
+	return localPath, true
+}
+
+// literalString returns the value of the given HCL expression if it is a literal string without interpolations.
+func literalString(expr []byte) (string, bool) {
+	parsed, diags := hclsyntax.ParseExpression(expr, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", false
+	}
+	value, diags := parsed.Value(nil)
+	if diags.HasErrors() || !value.Type().Equals(cty.String) || !value.IsKnown() || value.IsNull() {
+		return "", false
+	}
+	return value.AsString(), true
+}
@@ -0,0 +1,80 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModuleCalls = `module "vpc" {
+  source  = "acme/vpc/aws"
+  version = "~> 1.0"
+
+  cidr = "10.0.0.0/16"
+}
+
+module "db" {
+  source = "git::https://github.com/acme/modules.git//db?ref=v1.2.0"
+}
+
+module "other" {
+  source = "acme/other/aws"
+}
+`
+
+func TestOverrideModuleSourcesE(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	testDir := filepath.Join(root, "test", "fixture")
+	require.NoError(t, os.MkdirAll(testDir, 0755))
+	mainPath := filepath.Join(testDir, "main.tf")
+	require.NoError(t, os.WriteFile(mainPath, []byte(testModuleCalls), 0644))
+
+	modified, err := OverrideModuleSourcesE(t, testDir, map[string]string{
+		"acme/vpc/aws": filepath.Join(root, "modules", "vpc"),
+		"git::https://github.com/acme/modules.git": filepath.Join(root, "modules"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{mainPath}, modified)
+
+	contents, err := os.ReadFile(mainPath)
+	require.NoError(t, err)
+	main := string(contents)
+	assert.Contains(t, main, `source = "../../modules/vpc"`)
+	assert.NotContains(t, main, "version")
+	assert.Contains(t, main, `cidr = "10.0.0.0/16"`)
+	assert.Contains(t, main, `source = "../../modules/db"`)
+	assert.Contains(t, main, `source = "acme/other/aws"`)
+}
+
+func TestLocalModulePath(t *testing.T) {
+	t.Parallel()
+
+	overrides := map[string]string{
+		"acme/vpc/aws": "/src/vpc",
+		"git::https://github.com/acme/modules.git": "/src/modules",
+	}
+
+	testCases := []struct {
+		source   string
+		expected string
+		ok       bool
+	}{
+		{"acme/vpc/aws", "/src/vpc", true},
+		{"acme/vpc/aws//modules/subnets", "/src/vpc/modules/subnets", true},
+		{"git::https://github.com/acme/modules.git?ref=v1.0.0", "/src/modules", true},
+		{"git::https://github.com/acme/modules.git//db?ref=v1.0.0", "/src/modules/db", true},
+		{"acme/other/aws", "", false},
+		{"./local", "", false},
+	}
+
+	for _, testCase := range testCases {
+		localPath, ok := localModulePath(testCase.source, overrides)
+		assert.Equal(t, testCase.ok, ok, testCase.source)
+		assert.Equal(t, filepath.FromSlash(testCase.expected), localPath, testCase.source)
+	}
+}
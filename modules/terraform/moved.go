@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// GetMovedResources returns a map from the previous address to the new address of every resource that the given plan
+// moves (e.g., because of a `moved` block, or a `terraform state mv`-like refactor).
+func GetMovedResources(plan *PlanStruct) map[string]string {
+	moved := map[string]string{}
+	for _, change := range plan.RawPlan.ResourceChanges {
+		if change.PreviousAddress != "" && change.PreviousAddress != change.Address {
+			moved[change.PreviousAddress] = change.Address
+		}
+	}
+	return moved
+}
+
+// PlanOnlyMovesE returns a PlanHasNonMoveChanges error if the given plan does anything other than move resources to
+// new addresses (and read data sources), e.g., if it creates, updates, or destroys any resource. This is useful to
+// verify that a refactor using `moved` blocks is zero-downtime.
+func PlanOnlyMovesE(plan *PlanStruct) error {
+	var changes []string
+	for _, change := range plan.RawPlan.ResourceChanges {
+		if isMoveOnlyChange(change) {
+			continue
+		}
+		changes = append(changes, change.Address+" ("+actionsString(change.Change.Actions)+")")
+	}
+	if len(changes) > 0 {
+		sort.Strings(changes)
+		return PlanHasNonMoveChanges(changes)
+	}
+	return nil
+}
+
+// AssertPlanOnlyMoves checks that the given plan only moves resources to new addresses, failing the test if it creates,
+// updates, or destroys any resource.
+func AssertPlanOnlyMoves(t testing.TestingT, plan *PlanStruct) {
+	assert.NoError(t, PlanOnlyMovesE(plan))
+}
+
+// RequirePlanOnlyMoves checks that the given plan only moves resources to new addresses, failing and halting the test
+// if it creates, updates, or destroys any resource.
+func RequirePlanOnlyMoves(t testing.TestingT, plan *PlanStruct) {
+	require.NoError(t, PlanOnlyMovesE(plan))
+}
+
+// isMoveOnlyChange returns true if the given resource change doesn't change any real infrastructure.
+func isMoveOnlyChange(change *tfjson.ResourceChange) bool {
+	if change.Change == nil {
+		return true
+	}
+	actions := change.Change.Actions
+	return actions.NoOp() || actions.Read() || len(actions) == 0
+}
+
+// actionsString returns the given actions as a string, e.g., "delete, create".
+func actionsString(actions tfjson.Actions) string {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = string(action)
+	}
+	return strings.Join(names, ", ")
+}
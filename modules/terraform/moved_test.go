@@ -0,0 +1,51 @@
+package terraform
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMovedResourceChange(from string, to string, action tfjson.Action) *tfjson.ResourceChange {
+	change := testResourceChange(to, action, nil, nil)
+	change.PreviousAddress = from
+	return change
+}
+
+func TestGetMovedResources(t *testing.T) {
+	t.Parallel()
+
+	plan := testPlanWithChanges(
+		testMovedResourceChange("aws_s3_bucket.old", "module.bucket.aws_s3_bucket.this", tfjson.ActionNoop),
+		testMovedResourceChange("aws_iam_role.this", "aws_iam_role.this[0]", tfjson.ActionNoop),
+		testResourceChange("aws_sqs_queue.unchanged", tfjson.ActionNoop, nil, nil),
+	)
+
+	assert.Equal(t, map[string]string{
+		"aws_s3_bucket.old": "module.bucket.aws_s3_bucket.this",
+		"aws_iam_role.this": "aws_iam_role.this[0]",
+	}, GetMovedResources(plan))
+}
+
+func TestPlanOnlyMovesE(t *testing.T) {
+	t.Parallel()
+
+	plan := testPlanWithChanges(
+		testMovedResourceChange("aws_s3_bucket.old", "aws_s3_bucket.new", tfjson.ActionNoop),
+		testResourceChange("data.aws_caller_identity.current", tfjson.ActionRead, nil, nil),
+	)
+	require.NoError(t, PlanOnlyMovesE(plan))
+
+	replaced := testMovedResourceChange("aws_iam_role.old", "aws_iam_role.new", tfjson.ActionDelete)
+	replaced.Change.Actions = tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}
+	plan = testPlanWithChanges(
+		testMovedResourceChange("aws_s3_bucket.old", "aws_s3_bucket.new", tfjson.ActionNoop),
+		replaced,
+		testResourceChange("aws_sqs_queue.new", tfjson.ActionCreate, nil, nil),
+	)
+	err := PlanOnlyMovesE(plan)
+	require.Error(t, err)
+	assert.Equal(t, PlanHasNonMoveChanges{"aws_iam_role.new (delete, create)", "aws_sqs_queue.new (create)"}, err)
+}
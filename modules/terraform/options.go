@@ -1,9 +1,11 @@
 package terraform
 
 import (
+	"context"
 	"time"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/ssh"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/jinzhu/copier"
@@ -57,9 +59,9 @@ type Options struct {
 	LockTimeout              string                 // The lock timeout option to pass to the terraform command with -lock-timeout
 	EnvVars                  map[string]string      // Environment variables to set when running Terraform
 	BackendConfig            map[string]interface{} // The vars to pass to the terraform init command for extra configuration for the backend. If a var is nil, it will be formated as `--backend-config=var` instead of `--backend-config=var=null`
-	RetryableTerraformErrors map[string]string      // If Terraform apply fails with one of these (transient) errors, retry. The keys are a regexp to match against the error and the message is what to display to a user if that error is matched.
-	MaxRetries               int                    // Maximum number of times to retry errors matching RetryableTerraformErrors
-	TimeBetweenRetries       time.Duration          // The amount of time to wait between retries
+	RetryableTerraformErrors map[string]string      // If Terraform apply fails with one of these (transient) errors, retry. The keys are a regexp to match against the error and the message is what to display to a user if that error is matched. Ignored if RetryPolicy is set.
+	MaxRetries               int                    // Maximum number of times to retry errors matching RetryableTerraformErrors. Ignored if RetryPolicy is set.
+	TimeBetweenRetries       time.Duration          // The amount of time to wait between retries. Ignored if RetryPolicy is set.
 	Upgrade                  bool                   // Whether the -upgrade flag of the terraform init command should be set to true or not
 	Reconfigure              bool                   // Set the -reconfigure flag to the terraform init command
 	MigrateState             bool                   // Set the -migrate-state and -force-copy (suppress 'yes' answer prompt) flag to the terraform init command
@@ -67,13 +69,55 @@ type Options struct {
 	SshAgent                 *ssh.SshAgent          // Overrides local SSH agent with the given in-process agent
 	NoStderr                 bool                   // Disable stderr redirection
 	OutputMaxLineSize        int                    // The max size of one line in stdout and stderr (in bytes)
+	SpoolOutputLines         int                    // If greater than zero, the full output of plan, apply, and destroy is spooled to a temp file (logged when the command starts, and removed when the test finishes), and only its last SpoolOutputLines lines are kept in memory and returned. Not supported with Remote.
 	Logger                   *logger.Logger         // Set a non-default logger that should be used. See the logger package for more info.
 	Parallelism              int                    // Set the parallelism setting for Terraform
 	PlanFilePath             string                 // The path to output a plan file to (for the plan command) or read one from (for the apply command)
 	PluginDir                string                 // The path of downloaded plugins to pass to the terraform init command (-plugin-dir)
 	SetVarsAfterVarFiles     bool                   // Pass -var options after -var-file options to Terraform commands
 	WarningsAsErrors         map[string]string      // Terraform warning messages that should be treated as errors. The keys are a regexp to match against the warning and the value is what to display to a user if that warning is matched.
+	WarningMatchersAsErrors  []WarningMatcher       // Terraform warnings that should be treated as errors, matched on their parsed fields (summary, address, ...) rather than on the raw output
+	CompactWarnings          bool                   // Pass -compact-warnings to the commands that support it, so that warnings only take one or two lines of the output
 	ExtraArgs                ExtraArgs              // Extra arguments passed to Terraform commands
+	VarsAsFile               bool                   // Render Vars into a temporary JSON var file, shared by all commands run with these options, and pass it with -var-file instead of using -var. The file is removed when the test finishes.
+	IsolateDataDir           bool                   // Set a unique TF_DATA_DIR for this Options instance. This is enabled by default when TerraformDir is a temp folder, and never used with terragrunt. The folder is removed when the test finishes, unless infrastructure is still deployed with it. Note that .terraform.lock.hcl is still written to TerraformDir.
+	DisableDataDirIsolation  bool                   // Never set a unique TF_DATA_DIR, even when TerraformDir is a temp folder
+	PluginCache              *PluginCache           // Share downloaded providers through the given plugin cache. See NewPluginCacheE.
+	UseDefaultPluginCache    bool                   // Share downloaded providers through the process-wide plugin cache. Ignored if PluginCache is set.
+	RequiredVersion          string                 // A version constraint (e.g., ">= 1.6, < 1.8"). If set, the newest matching terraform or tofu binary is downloaded, cached, and used for all commands.
+	Docker                   *DockerOptions         // If set, run the Terraform commands inside a Docker container instead of on the host. RequiredVersion is ignored in this case, as the image pins the version.
+	Remote                   *RemoteOptions         // If set, run the Terraform commands on a remote host over SSH instead of on the host. Can't be combined with Docker; RequiredVersion is ignored.
+	SensitiveVars            []string               // Names of Vars whose values are replaced with *** in logs
+	SensitiveEnvVars         []string               // Names of EnvVars whose values are replaced with *** in logs
+	EphemeralVars            []string               // Names of Vars that are ephemeral (terraform 1.10+). They are also passed when applying a PlanFilePath, as saved plans don't store them, and are replaced with *** in logs
+	TerragruntIncludeDirs    []string               // Only run the terragrunt modules matching these dirs (glob patterns relative to TerraformDir) in run-all commands
+	TerragruntExcludeDirs    []string               // Skip the terragrunt modules matching these dirs (glob patterns relative to TerraformDir) in run-all commands
+	ArtifactDir              string                 // If set, the full output of every command is saved, with secrets masked, to a per-test folder in this dir (e.g., for CI upload). See GetArtifactDirForTest.
+	ArtifactPlanAndState     bool                   // Also save the plan files and the latest state snapshot to ArtifactDir. These hold variable values and sensitive attributes in clear text, so only enable this if the artifacts are kept private.
+	Hooks                    *Hooks                 `json:"-"` // Callbacks invoked around the Terraform commands, e.g., before and after apply. These are not persisted by test_structure.SaveTerraformOptions.
+
+	// If set, called with each line of stdout and stderr as soon as Terraform produces it, rather than only getting the
+	// full output once the command completes. This is useful to, e.g., report progress ("Still creating...") to CI
+	// systems that kill jobs that are silent for too long. This is not persisted by test_structure.SaveTerraformOptions.
+	OutputLineCallback func(line string, isStderr bool) `json:"-"`
+
+	// If set, decides whether and when to retry failed Terraform commands (e.g., with exponential backoff and jitter,
+	// or with a different number of retries per class of errors, see retry.BackoffPolicy), instead of
+	// RetryableTerraformErrors, MaxRetries, and TimeBetweenRetries. This is not persisted by
+	// test_structure.SaveTerraformOptions.
+	RetryPolicy retry.Policy `json:"-"`
+
+	// If set, every Terraform command run with these options is recorded in the given JSON report. This is not
+	// persisted by test_structure.SaveTerraformOptions.
+	RunReport *RunReport `json:"-"`
+
+	// The parent context of the OpenTelemetry spans emitted for each Terraform command (e.g., the context of the span of
+	// the current test stage). Spans are emitted with the global tracer provider, so they are only recorded if the test
+	// has configured one with otel.SetTracerProvider. This is not persisted by test_structure.SaveTerraformOptions.
+	TraceContext context.Context `json:"-"`
+
+	varsFile     string                // The var file Vars are rendered into when VarsAsFile is set. See optionsVarsFile.
+	dataDirState *isolatedDataDirState // The use of the isolated TF_DATA_DIR, if any. See trackIsolatedDataDir.
 }
 
 type ExtraArgs struct {
@@ -0,0 +1,172 @@
+package terraform
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+// PlanDiff is the difference between the resource changes of two plans.
+type PlanDiff struct {
+	// The resource changes that are only in the second plan, sorted by address
+	Added []*tfjson.ResourceChange
+
+	// The resource changes that are only in the first plan, sorted by address
+	Removed []*tfjson.ResourceChange
+
+	// The resources that are in both plans, but with different actions or planned values, sorted by address
+	Changed []*ResourceChangeDiff
+}
+
+// ResourceChangeDiff is the difference between the planned changes to the same resource in two plans.
+type ResourceChangeDiff struct {
+	Address string
+
+	// The change to the resource in the first and second plan
+	Before *tfjson.ResourceChange
+	After  *tfjson.ResourceChange
+
+	// Whether the actions (e.g., create, update) differ between the plans
+	ActionsChanged bool
+
+	// The sorted names of the top-level attributes whose planned values (or whether they are known after apply) differ
+	// between the plans
+	ChangedAttributes []string
+}
+
+// IsEmpty returns true if the plans have the same resource changes.
+func (diff *PlanDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// DiffPlans compares the resource changes of the two given plans. This will fail the test if there is an error.
+func DiffPlans(t testing.TestingT, planA *PlanStruct, planB *PlanStruct) *PlanDiff {
+	diff, err := DiffPlansE(planA, planB)
+	require.NoError(t, err)
+	return diff
+}
+
+// DiffPlansE compares the resource changes of the two given plans, e.g., to check that a refactored module produces
+// the same plan as the previous version.
+func DiffPlansE(planA *PlanStruct, planB *PlanStruct) (*PlanDiff, error) {
+	if planA == nil || planB == nil {
+		return nil, errors.New("both plans are required to compute a diff")
+	}
+
+	diff := &PlanDiff{
+		Added:   []*tfjson.ResourceChange{},
+		Removed: []*tfjson.ResourceChange{},
+		Changed: []*ResourceChangeDiff{},
+	}
+
+	for _, address := range sortedKeys(planA.ResourceChangesMap) {
+		before := planA.ResourceChangesMap[address]
+		after, exists := planB.ResourceChangesMap[address]
+		if !exists {
+			diff.Removed = append(diff.Removed, before)
+			continue
+		}
+		if changeDiff := diffResourceChanges(address, before, after); changeDiff != nil {
+			diff.Changed = append(diff.Changed, changeDiff)
+		}
+	}
+
+	for _, address := range sortedKeys(planB.ResourceChangesMap) {
+		if _, exists := planA.ResourceChangesMap[address]; !exists {
+			diff.Added = append(diff.Added, planB.ResourceChangesMap[address])
+		}
+	}
+
+	return diff, nil
+}
+
+// DiffPlanFiles runs terraform show on the two given saved plan files and compares their resource changes. This will
+// fail the test if there is an error.
+func DiffPlanFiles(t testing.TestingT, options *Options, planFileA string, planFileB string) *PlanDiff {
+	diff, err := DiffPlanFilesE(t, options, planFileA, planFileB)
+	require.NoError(t, err)
+	return diff
+}
+
+// DiffPlanFilesE runs terraform show on the two given saved plan files and compares their resource changes. The
+// options must point at a folder initialized with the providers used in the plans.
+func DiffPlanFilesE(t testing.TestingT, options *Options, planFileA string, planFileB string) (*PlanDiff, error) {
+	plans := []*PlanStruct{}
+	for _, planFile := range []string{planFileA, planFileB} {
+		showOptions, err := options.Clone()
+		if err != nil {
+			return nil, err
+		}
+		showOptions.PlanFilePath = planFile
+
+		plan, err := ShowWithStructE(t, showOptions)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return DiffPlansE(plans[0], plans[1])
+}
+
+// diffResourceChanges returns the difference between the given changes to the same resource, or nil if they are the
+// same.
+func diffResourceChanges(address string, before *tfjson.ResourceChange, after *tfjson.ResourceChange) *ResourceChangeDiff {
+	beforeChange := before.Change
+	afterChange := after.Change
+	if beforeChange == nil {
+		beforeChange = &tfjson.Change{}
+	}
+	if afterChange == nil {
+		afterChange = &tfjson.Change{}
+	}
+
+	diff := &ResourceChangeDiff{
+		Address:           address,
+		Before:            before,
+		After:             after,
+		ActionsChanged:    !reflect.DeepEqual(beforeChange.Actions, afterChange.Actions),
+		ChangedAttributes: changedAttributes(beforeChange, afterChange),
+	}
+	if !diff.ActionsChanged && len(diff.ChangedAttributes) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// changedAttributes returns the sorted names of the top-level attributes whose planned values differ between the
+// given changes.
+func changedAttributes(before *tfjson.Change, after *tfjson.Change) []string {
+	beforeValues, _ := before.After.(map[string]interface{})
+	afterValues, _ := after.After.(map[string]interface{})
+	beforeUnknown, _ := before.AfterUnknown.(map[string]interface{})
+	afterUnknown, _ := after.AfterUnknown.(map[string]interface{})
+
+	names := map[string]bool{}
+	for _, values := range []map[string]interface{}{beforeValues, afterValues, beforeUnknown, afterUnknown} {
+		for name := range values {
+			names[name] = true
+		}
+	}
+
+	changed := []string{}
+	for name := range names {
+		if !reflect.DeepEqual(beforeValues[name], afterValues[name]) || !reflect.DeepEqual(knownAfterApply(beforeUnknown[name]), knownAfterApply(afterUnknown[name])) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// knownAfterApply normalizes the given after_unknown value of an attribute, which terraform either omits or sets to
+// false for attributes that are known.
+func knownAfterApply(unknown interface{}) interface{} {
+	if unknown == false {
+		return nil
+	}
+	return unknown
+}
@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlanWithChanges(changes ...*tfjson.ResourceChange) *PlanStruct {
+	plan := &PlanStruct{RawPlan: tfjson.Plan{ResourceChanges: changes}}
+	plan.ResourceChangesMap = parseResourceChanges(plan)
+	return plan
+}
+
+func testResourceChange(address string, action tfjson.Action, after map[string]interface{}, afterUnknown map[string]interface{}) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: address,
+		Change: &tfjson.Change{
+			Actions:      tfjson.Actions{action},
+			After:        after,
+			AfterUnknown: afterUnknown,
+		},
+	}
+}
+
+func TestDiffPlansE(t *testing.T) {
+	t.Parallel()
+
+	planA := testPlanWithChanges(
+		testResourceChange("aws_s3_bucket.same", tfjson.ActionCreate, map[string]interface{}{"bucket": "a"}, map[string]interface{}{"id": true}),
+		testResourceChange("aws_s3_bucket.removed", tfjson.ActionCreate, map[string]interface{}{"bucket": "b"}, nil),
+		testResourceChange("aws_s3_bucket.changed", tfjson.ActionCreate, map[string]interface{}{"bucket": "c", "acl": "private"}, map[string]interface{}{"arn": false}),
+	)
+	planB := testPlanWithChanges(
+		testResourceChange("aws_s3_bucket.same", tfjson.ActionCreate, map[string]interface{}{"bucket": "a"}, map[string]interface{}{"id": true}),
+		testResourceChange("aws_s3_bucket.changed", tfjson.ActionUpdate, map[string]interface{}{"bucket": "c", "acl": "public-read"}, map[string]interface{}{}),
+		testResourceChange("aws_s3_bucket.added", tfjson.ActionCreate, map[string]interface{}{"bucket": "d"}, nil),
+	)
+
+	diff, err := DiffPlansE(planA, planB)
+	require.NoError(t, err)
+	assert.False(t, diff.IsEmpty())
+
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "aws_s3_bucket.added", diff.Added[0].Address)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "aws_s3_bucket.removed", diff.Removed[0].Address)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "aws_s3_bucket.changed", diff.Changed[0].Address)
+	assert.True(t, diff.Changed[0].ActionsChanged)
+	assert.Equal(t, []string{"acl"}, diff.Changed[0].ChangedAttributes)
+}
+
+func TestDiffPlansEIdentical(t *testing.T) {
+	t.Parallel()
+
+	plan := testPlanWithChanges(testResourceChange("null_resource.test", tfjson.ActionCreate, map[string]interface{}{"triggers": nil}, nil))
+	diff, err := DiffPlansE(plan, plan)
+	require.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+
+	_, err = DiffPlansE(plan, nil)
+	require.Error(t, err)
+}
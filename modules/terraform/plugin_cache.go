@@ -0,0 +1,237 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// TfCliConfigFileEnvVar is the environment variable terraform uses to find its CLI configuration file.
+	TfCliConfigFileEnvVar = "TF_CLI_CONFIG_FILE"
+
+	// TfPluginCacheDirEnvVar is the environment variable terraform uses to override the plugin_cache_dir of its CLI
+	// configuration.
+	TfPluginCacheDirEnvVar = "TF_PLUGIN_CACHE_DIR"
+
+	// PluginCacheDirEnvVar is the environment variable that can be used to override the folder of the process-wide
+	// plugin cache returned by DefaultPluginCache.
+	PluginCacheDirEnvVar = "TERRATEST_PLUGIN_CACHE_DIR"
+
+	// PluginMirrorDirEnvVar is the environment variable that can be used to configure a filesystem mirror for the
+	// process-wide plugin cache returned by DefaultPluginCache.
+	PluginMirrorDirEnvVar = "TERRATEST_PLUGIN_MIRROR_DIR"
+
+	pluginCacheCliConfigFile  = "terratest.tfrc"
+	pluginCacheLockFile       = ".terratest.lock"
+	pluginCacheLockStaleAfter = 10 * time.Minute
+	pluginCacheLockPollPeriod = 250 * time.Millisecond
+
+	// How often the mtime of the lock file is refreshed while the lock is held, so that a lock held during a slow init
+	// is never mistaken for one left over from a crashed process
+	pluginCacheLockRefreshPeriod = pluginCacheLockStaleAfter / 10
+)
+
+var (
+	defaultPluginCache    *PluginCache
+	defaultPluginCacheErr error
+	defaultPluginCacheMu  sync.Mutex
+)
+
+// PluginCache is a provider plugin cache that can be shared by all the terraform commands run by the tests, so that
+// providers are only downloaded once instead of once per test. Terraform does not support concurrent writes to the
+// plugin cache, so init commands that use the cache are serialized, both between goroutines of the same process and
+// between processes (e.g., the different packages run by go test ./...) using a lock file in the cache folder.
+type PluginCache struct {
+	Dir       string // The folder used as plugin_cache_dir
+	MirrorDir string // An optional folder used as a filesystem_mirror. If empty, providers are installed directly from their origin registry.
+
+	mutex sync.Mutex
+}
+
+// NewPluginCache creates the given cache folder (and mirror folder, if not empty), generates the matching terraform CLI
+// configuration, and returns a PluginCache that can be set on Options. This will fail the test if there is an error.
+func NewPluginCache(t testing.TestingT, cacheDir string, mirrorDir string) *PluginCache {
+	cache, err := NewPluginCacheE(cacheDir, mirrorDir)
+	require.NoError(t, err)
+	return cache
+}
+
+// NewPluginCacheE creates the given cache folder (and mirror folder, if not empty), generates the matching terraform
+// CLI configuration, and returns a PluginCache that can be set on Options.
+func NewPluginCacheE(cacheDir string, mirrorDir string) (*PluginCache, error) {
+	if cacheDir == "" {
+		return nil, errors.New("a plugin cache folder is required")
+	}
+
+	cacheDir, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cacheDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	if mirrorDir != "" {
+		mirrorDir, err = filepath.Abs(mirrorDir)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(mirrorDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	cache := &PluginCache{Dir: cacheDir, MirrorDir: mirrorDir}
+	if err := os.WriteFile(cache.CliConfigFile(), []byte(cache.CliConfig()), 0644); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// DefaultPluginCache returns the process-wide plugin cache, creating it on first use. The cache lives in
+// $TMPDIR/terratest-plugin-cache, unless overridden with the TERRATEST_PLUGIN_CACHE_DIR environment variable. This will
+// fail the test if there is an error.
+func DefaultPluginCache(t testing.TestingT) *PluginCache {
+	cache, err := DefaultPluginCacheE()
+	require.NoError(t, err)
+	return cache
+}
+
+// DefaultPluginCacheE returns the process-wide plugin cache, creating it on first use. The cache lives in
+// $TMPDIR/terratest-plugin-cache, unless overridden with the TERRATEST_PLUGIN_CACHE_DIR environment variable.
+func DefaultPluginCacheE() (*PluginCache, error) {
+	defaultPluginCacheMu.Lock()
+	defer defaultPluginCacheMu.Unlock()
+
+	if defaultPluginCache == nil && defaultPluginCacheErr == nil {
+		cacheDir := os.Getenv(PluginCacheDirEnvVar)
+		if cacheDir == "" {
+			cacheDir = filepath.Join(os.TempDir(), "terratest-plugin-cache")
+		}
+		defaultPluginCache, defaultPluginCacheErr = NewPluginCacheE(cacheDir, os.Getenv(PluginMirrorDirEnvVar))
+	}
+	return defaultPluginCache, defaultPluginCacheErr
+}
+
+// CliConfig returns the contents of the terraform CLI configuration file that points terraform at this cache.
+func (cache *PluginCache) CliConfig() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "plugin_cache_dir = %q\n", filepath.ToSlash(cache.Dir))
+	if cache.MirrorDir != "" {
+		sb.WriteString("\nprovider_installation {\n")
+		fmt.Fprintf(&sb, "  filesystem_mirror {\n    path = %q\n  }\n", filepath.ToSlash(cache.MirrorDir))
+		sb.WriteString("  direct {}\n")
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// CliConfigFile returns the path of the terraform CLI configuration file that points terraform at this cache.
+func (cache *PluginCache) CliConfigFile() string {
+	return filepath.Join(cache.Dir, pluginCacheCliConfigFile)
+}
+
+// lock takes an exclusive lock on the cache, both within this process and across processes, and returns a function
+// that releases it. The mtime of the lock file is refreshed every pluginCacheLockRefreshPeriod while the lock is held,
+// so lock files that haven't been touched for pluginCacheLockStaleAfter are considered left over from a crashed process
+// and are removed.
+func (cache *PluginCache) lock() (func(), error) {
+	cache.mutex.Lock()
+
+	lockPath := filepath.Join(cache.Dir, pluginCacheLockFile)
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(lockFile, "%d", os.Getpid())
+			lockFile.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			cache.mutex.Unlock()
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > pluginCacheLockStaleAfter {
+			os.Remove(lockPath)
+			continue
+		}
+		time.Sleep(pluginCacheLockPollPeriod)
+	}
+
+	stopRefresh := make(chan struct{})
+	refreshDone := make(chan struct{})
+	go func() {
+		defer close(refreshDone)
+		refreshLockFile(lockPath, pluginCacheLockRefreshPeriod, stopRefresh)
+	}()
+
+	return func() {
+		close(stopRefresh)
+		<-refreshDone
+		os.Remove(lockPath)
+		cache.mutex.Unlock()
+	}, nil
+}
+
+// refreshLockFile updates the mtime of the given lock file every period, until stop is closed.
+func refreshLockFile(lockPath string, period time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			os.Chtimes(lockPath, now, now)
+		}
+	}
+}
+
+// configureOptions points the given options at this cache. If the user has already set their own CLI config, either
+// in the options or in the environment of this process, it is kept, and the cache folder is passed with
+// TF_PLUGIN_CACHE_DIR instead, which terraform merges into it. The MirrorDir is not used in that case. Nothing is done
+// if TF_PLUGIN_CACHE_DIR is already set.
+func (cache *PluginCache) configureOptions(options *Options) {
+	if options.EnvVars == nil {
+		options.EnvVars = map[string]string{}
+	}
+	if isEnvVarSet(options, TfPluginCacheDirEnvVar) {
+		return
+	}
+	if isEnvVarSet(options, TfCliConfigFileEnvVar) {
+		options.EnvVars[TfPluginCacheDirEnvVar] = cache.Dir
+		return
+	}
+	options.EnvVars[TfCliConfigFileEnvVar] = cache.CliConfigFile()
+}
+
+// isEnvVarSet returns true if the given environment variable is set for the commands run with the given options,
+// either in their EnvVars or in the environment of this process.
+func isEnvVarSet(options *Options, name string) bool {
+	if _, inOpts := options.EnvVars[name]; inOpts {
+		return true
+	}
+	_, inEnv := os.LookupEnv(name)
+	return inEnv
+}
+
+// pluginCacheForOptions returns the plugin cache that should be used with the given options, if any.
+func pluginCacheForOptions(options *Options) (*PluginCache, error) {
+	if options.PluginCache != nil {
+		return options.PluginCache, nil
+	}
+	if options.UseDefaultPluginCache {
+		return DefaultPluginCacheE()
+	}
+	return nil, nil
+}
@@ -0,0 +1,129 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPluginCacheWritesCliConfig(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	cache := NewPluginCache(t, filepath.Join(root, "cache"), "")
+
+	contents, err := os.ReadFile(cache.CliConfigFile())
+	require.NoError(t, err)
+	assert.Equal(t, cache.CliConfig(), string(contents))
+	assert.Contains(t, string(contents), "plugin_cache_dir")
+	assert.NotContains(t, string(contents), "provider_installation")
+}
+
+func TestNewPluginCacheWithMirror(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	cache := NewPluginCache(t, filepath.Join(root, "cache"), filepath.Join(root, "mirror"))
+
+	assert.DirExists(t, cache.MirrorDir)
+	assert.Contains(t, cache.CliConfig(), "filesystem_mirror")
+	assert.Contains(t, cache.CliConfig(), filepath.ToSlash(cache.MirrorDir))
+}
+
+func TestPluginCacheConfigureOptionsRespectsExplicitCliConfig(t *testing.T) {
+	t.Parallel()
+
+	cache := NewPluginCache(t, t.TempDir(), "")
+
+	options := &Options{}
+	cache.configureOptions(options)
+	assert.Equal(t, cache.CliConfigFile(), options.EnvVars[TfCliConfigFileEnvVar])
+
+	options = &Options{EnvVars: map[string]string{TfCliConfigFileEnvVar: "/my/terraformrc"}}
+	cache.configureOptions(options)
+	assert.Equal(t, "/my/terraformrc", options.EnvVars[TfCliConfigFileEnvVar])
+	assert.Equal(t, cache.Dir, options.EnvVars[TfPluginCacheDirEnvVar])
+
+	options = &Options{EnvVars: map[string]string{TfPluginCacheDirEnvVar: "/my/plugin-cache"}}
+	cache.configureOptions(options)
+	assert.NotContains(t, options.EnvVars, TfCliConfigFileEnvVar)
+	assert.Equal(t, "/my/plugin-cache", options.EnvVars[TfPluginCacheDirEnvVar])
+}
+
+func TestPluginCacheConfigureOptionsRespectsCliConfigFromEnvironment(t *testing.T) {
+	t.Setenv(TfCliConfigFileEnvVar, "/my/terraformrc")
+
+	cache := NewPluginCache(t, t.TempDir(), "")
+
+	options := &Options{}
+	cache.configureOptions(options)
+	assert.NotContains(t, options.EnvVars, TfCliConfigFileEnvVar)
+	assert.Equal(t, cache.Dir, options.EnvVars[TfPluginCacheDirEnvVar])
+}
+
+func TestPluginCacheLockIsExclusive(t *testing.T) {
+	t.Parallel()
+
+	cache := NewPluginCache(t, t.TempDir(), "")
+
+	var mutex sync.Mutex
+	holders := 0
+	maxHolders := 0
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := cache.lock()
+			assert.NoError(t, err)
+
+			mutex.Lock()
+			holders++
+			if holders > maxHolders {
+				maxHolders = holders
+			}
+			mutex.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mutex.Lock()
+			holders--
+			mutex.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, maxHolders)
+	assert.NoFileExists(t, filepath.Join(cache.Dir, pluginCacheLockFile))
+}
+
+func TestRefreshLockFileUpdatesMtime(t *testing.T) {
+	t.Parallel()
+
+	lockPath := filepath.Join(t.TempDir(), pluginCacheLockFile)
+	require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(lockPath, old, old))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		refreshLockFile(lockPath, 10*time.Millisecond, stop)
+	}()
+
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(lockPath)
+		return err == nil && time.Since(info.ModTime()) < pluginCacheLockStaleAfter
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(stop)
+	<-done
+}
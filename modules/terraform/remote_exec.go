@@ -0,0 +1,279 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// remoteDirPrefix is the prefix of the folders picked for RemoteDir. Only folders with this prefix are ever removed by
+// terratest, so a RemoteDir set by the user is left alone.
+const remoteDirPrefix = "/tmp/terratest-"
+
+// remoteSecretFilePrefix is the prefix of the files that the environment variables and the var file rendered for
+// VarsAsFile are uploaded to for each command. They are kept out of RemoteDir, and removed once the command completes,
+// as they may hold secrets.
+const remoteSecretFilePrefix = "/tmp/.terratest-"
+
+// A map that tracks which RemoteOptions have already had their folder copied to the remote host.
+var remoteDirSynced sync.Map
+
+// RemoteOptions configures running Terraform commands on a remote host over SSH, e.g., a bastion host that is the only
+// machine with API access to the target platform. The Terraform code is copied to the remote host before the first
+// command and before every init. The Terraform binary must already be installed on the remote host.
+//
+// An isolated TF_DATA_DIR is moved into RemoteDir on the remote host. A TF_CLI_CONFIG_FILE or TF_PLUGIN_CACHE_DIR
+// outside of CopyDir (e.g., the one set by a PluginCache) isn't passed to the remote host, as it points at files that
+// only exist locally.
+type RemoteOptions struct {
+	// The host to run the commands on
+	Host ssh.Host
+
+	// The folder on the remote host to copy the Terraform code to. If empty, a unique folder in /tmp is picked on first
+	// use and stored here, so all subsequent commands run with the same options use it. That folder is removed from the
+	// remote host when the test finishes, unless infrastructure is still deployed with it.
+	RemoteDir string
+
+	// The local folder to copy to RemoteDir. Defaults to TerraformDir. Set this to a parent folder if the Terraform code
+	// references modules outside of TerraformDir (e.g., source = "../modules/foo").
+	CopyDir string
+
+	dirState *isolatedDataDirState // The use of the RemoteDir picked by terratest, if any. See trackRemoteDir.
+}
+
+// runRemoteCommandE runs the given command on the remote host configured in the options, streaming its output back
+// through the logger, and returns its stdout and stderr. Environment variables are uploaded in a file that is only
+// readable by the SSH user, rather than being passed on the command line, so secrets don't end up in the logs. The file
+// is removed once the command completes.
+func runRemoteCommandE(t testing.TestingT, options *Options, cmd shell.Command) (string, string, error) {
+	remote := options.Remote
+
+	localRoot := remote.CopyDir
+	if localRoot == "" {
+		localRoot = options.TerraformDir
+	}
+	localRoot, err := filepath.Abs(localRoot)
+	if err != nil {
+		return "", "", err
+	}
+	if remote.RemoteDir == "" {
+		remote.RemoteDir = remoteDirPrefix + random.UniqueId()
+		remote.dirState = &isolatedDataDirState{created: true}
+	}
+
+	_, synced := remoteDirSynced.Load(remote)
+	if !synced || (len(cmd.Args) > 0 && cmd.Args[0] == "init") {
+		options.Logger.Logf(t, "Copying %s to %s on %s", localRoot, remote.RemoteDir, remote.Host.Hostname)
+		if err := ssh.ScpDirToE(t, remote.Host, localRoot, remote.RemoteDir, isRemoteCopyablePath); err != nil {
+			return "", "", err
+		}
+		remoteDirSynced.Store(remote, true)
+	}
+
+	secretFilePrefix := remoteSecretFilePrefix + random.UniqueId()
+	envFile := secretFilePrefix + ".env"
+	if err := ssh.ScpFileToE(t, remote.Host, 0600, envFile, formatRemoteEnvFile(translateRemoteEnv(cmd.Env, localRoot, remote.RemoteDir))); err != nil {
+		return "", "", err
+	}
+	secretFiles := []string{envFile}
+
+	args := translateRemoteArgs(cmd.Args, localRoot, remote.RemoteDir)
+	if options.varsFile != "" {
+		// The var file rendered for VarsAsFile lives in the local temp folder, outside of the copied folder, so upload
+		// it next to the env file
+		contents, err := os.ReadFile(options.varsFile)
+		if err != nil {
+			return "", "", err
+		}
+		varsFile := secretFilePrefix + ".tfvars.json"
+		if err := ssh.ScpFileToE(t, remote.Host, 0600, varsFile, string(contents)); err != nil {
+			return "", "", err
+		}
+		secretFiles = append(secretFiles, varsFile)
+		args = replaceArg(args, options.varsFile, varsFile)
+	}
+
+	workingDir := toRemotePath(cmd.WorkingDir, localRoot, remote.RemoteDir)
+	command := formatRemoteCommand(workingDir, envFile, secretFiles, cmd.Command, args)
+
+	stdout, stderr, err := ssh.RunSshCommandAndStreamOutputE(t, remote.Host, command, cmd.Logger, cmd.OutputLineCallback)
+	trackRemoteDir(t, options, cmd.Args, err)
+	return stdout, stderr, err
+}
+
+// trackRemoteDir records the outcome of the given command run in the RemoteDir of the given options, and registers its
+// removal from the remote host with the test, if t supports Cleanup (as *testing.T does). As with the isolated
+// TF_DATA_DIR (see trackIsolatedDataDir), the folder is kept while infrastructure is deployed with it, as it holds the
+// local state, and folders that weren't picked by terratest are never removed.
+func trackRemoteDir(t testing.TestingT, options *Options, args []string, err error) {
+	remote := options.Remote
+	if !strings.HasPrefix(remote.RemoteDir, remoteDirPrefix) {
+		return
+	}
+
+	state := remote.dirState
+	if state == nil {
+		// The folder was picked by a previous run, e.g., one whose options were saved with test_structure
+		state = &isolatedDataDirState{}
+		remote.dirState = state
+	}
+
+	if err == nil {
+		switch hookedCommandName(args) {
+		case "apply":
+			state.deployed = true
+		case "destroy":
+			state.deployed = false
+			state.destroyed = true
+		}
+	}
+
+	if state.cleanup {
+		return
+	}
+	cleaner, ok := t.(interface{ Cleanup(func()) })
+	if !ok {
+		return
+	}
+	state.cleanup = true
+	remoteDir := remote.RemoteDir
+	cleaner.Cleanup(func() {
+		if state.deployed || !(state.created || state.destroyed) {
+			return
+		}
+		if _, err := ssh.CheckSshCommandE(t, remote.Host, "rm -rf "+shell.QuotePosixArg(remoteDir)); err != nil {
+			options.Logger.Logf(t, "Failed to remove %s from %s: %v", remoteDir, remote.Host.Hostname, err)
+		}
+		remoteDirSynced.Delete(remote)
+	})
+}
+
+// formatRemoteCommand returns a shell command line that runs the given command with the given args in workingDir,
+// after loading the environment variables from envFile. The given secret files are removed when the shell exits,
+// whether the command succeeded or not.
+func formatRemoteCommand(workingDir string, envFile string, secretFiles []string, command string, args []string) string {
+	quotedArgs := make([]string, len(args))
+	for i, arg := range args {
+		quotedArgs[i] = shell.QuotePosixArg(arg)
+	}
+	quotedSecretFiles := make([]string, len(secretFiles))
+	for i, secretFile := range secretFiles {
+		quotedSecretFiles[i] = shell.QuotePosixArg(secretFile)
+	}
+	removeSecretFiles := "rm -f " + strings.Join(quotedSecretFiles, " ")
+	return fmt.Sprintf("trap %s EXIT && cd %s && set -a && . %s && set +a && %s %s", shell.QuotePosixArg(removeSecretFiles), shell.QuotePosixArg(workingDir), shell.QuotePosixArg(envFile), shell.QuotePosixArg(command), strings.Join(quotedArgs, " "))
+}
+
+// formatRemoteEnvFile renders the given environment variables as a file that can be sourced by a POSIX shell.
+func formatRemoteEnvFile(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s=%s\n", name, shell.QuotePosixArg(env[name]))
+	}
+	return sb.String()
+}
+
+// translateRemoteArgs rewrites the local paths under localRoot in the given args (e.g., a plan file or var file) to the
+// matching paths under remoteRoot. Only args that are a path, or flags whose value is a path (e.g., -out=path), are
+// rewritten, so that other values that happen to contain localRoot (e.g., in a -var) are passed as-is.
+func translateRemoteArgs(args []string, localRoot string, remoteRoot string) []string {
+	translated := make([]string, len(args))
+	for i, arg := range args {
+		translated[i] = arg
+		if remotePath, ok := translateRemotePath(arg, localRoot, remoteRoot); ok {
+			translated[i] = remotePath
+		} else if flag, value, hasValue := strings.Cut(arg, "="); hasValue && strings.HasPrefix(flag, "-") {
+			if remotePath, ok := translateRemotePath(value, localRoot, remoteRoot); ok {
+				translated[i] = flag + "=" + remotePath
+			}
+		}
+	}
+	return translated
+}
+
+// translateRemotePath returns the path under remoteRoot matching the given local path, and true, if the local path is
+// localRoot or a path under it. Otherwise, it returns false.
+func translateRemotePath(localPath string, localRoot string, remoteRoot string) (string, bool) {
+	if localPath == localRoot {
+		return remoteRoot, true
+	}
+	if relPath, ok := strings.CutPrefix(localPath, localRoot+string(filepath.Separator)); ok {
+		return remoteRoot + "/" + filepath.ToSlash(relPath), true
+	}
+	return "", false
+}
+
+// translateRemoteEnv returns a copy of the given environment variables in which the local paths used by Terraform are
+// rewritten for the remote host: an isolated TF_DATA_DIR outside of localRoot is moved into remoteRoot, and a
+// TF_CLI_CONFIG_FILE or TF_PLUGIN_CACHE_DIR outside of localRoot is dropped.
+func translateRemoteEnv(env map[string]string, localRoot string, remoteRoot string) map[string]string {
+	translated := make(map[string]string, len(env))
+	for name, value := range env {
+		translated[name] = value
+	}
+
+	if dataDir, ok := env[TfDataDirEnvVar]; ok {
+		remoteDataDir, ok := translateRemotePath(dataDir, localRoot, remoteRoot)
+		if !ok {
+			remoteDataDir = remoteRoot + "/.terratest-data/" + filepath.Base(dataDir)
+		}
+		translated[TfDataDirEnvVar] = remoteDataDir
+	}
+	for _, name := range []string{TfCliConfigFileEnvVar, TfPluginCacheDirEnvVar} {
+		if path, ok := env[name]; ok {
+			if remotePath, ok := translateRemotePath(path, localRoot, remoteRoot); ok {
+				translated[name] = remotePath
+			} else {
+				delete(translated, name)
+			}
+		}
+	}
+	return translated
+}
+
+// replaceArg returns a copy of the given args with every arg equal to oldArg replaced with newArg.
+func replaceArg(args []string, oldArg string, newArg string) []string {
+	replaced := make([]string, len(args))
+	for i, arg := range args {
+		if arg == oldArg {
+			arg = newArg
+		}
+		replaced[i] = arg
+	}
+	return replaced
+}
+
+// toRemotePath returns the path under remoteRoot matching the given local path under localRoot.
+func toRemotePath(localPath string, localRoot string, remoteRoot string) string {
+	absPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return remoteRoot
+	}
+	relPath, err := filepath.Rel(localRoot, absPath)
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return remoteRoot
+	}
+	return remoteRoot + "/" + filepath.ToSlash(relPath)
+}
+
+// isRemoteCopyablePath returns true if the given path should be copied to the remote host. Local Terraform state and
+// working data are skipped, as they would clobber the state of previous remote runs.
+func isRemoteCopyablePath(path string) bool {
+	base := filepath.Base(path)
+	return base != ".terraform" && base != ".git" && !files.PathContainsTerraformState(path)
+}
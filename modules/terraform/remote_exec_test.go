@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRemoteCommand(t *testing.T) {
+	t.Parallel()
+
+	command := formatRemoteCommand("/tmp/foo/module", "/tmp/.terratest-abc.env", []string{"/tmp/.terratest-abc.env", "/tmp/.terratest-abc.tfvars.json"}, "terraform", []string{"apply", "-var", "name=it's a test"})
+	assert.Equal(t, `trap 'rm -f /tmp/.terratest-abc.env /tmp/.terratest-abc.tfvars.json' EXIT && cd /tmp/foo/module && set -a && . /tmp/.terratest-abc.env && set +a && terraform apply -var 'name=it'\''s a test'`, command)
+}
+
+func TestFormatRemoteEnvFile(t *testing.T) {
+	t.Parallel()
+
+	envFile := formatRemoteEnvFile(map[string]string{"TF_VAR_b": "two words", "TF_VAR_a": "$HOME"})
+	assert.Equal(t, "TF_VAR_a='$HOME'\nTF_VAR_b='two words'\n", envFile)
+}
+
+func TestTranslateRemoteArgs(t *testing.T) {
+	t.Parallel()
+
+	args := translateRemoteArgs([]string{"plan", "-var-file", "/local/root/module/test.tfvars", "-out=/local/root/plan.out"}, "/local/root", "/tmp/terratest-abc")
+	assert.Equal(t, []string{"plan", "-var-file", "/tmp/terratest-abc/module/test.tfvars", "-out=/tmp/terratest-abc/plan.out"}, args)
+
+	// Only whole paths under the local root are rewritten
+	args = translateRemoteArgs([]string{"plan", "-var-file", "/local/rootfs/test.tfvars", "-var", "dir=/local/root/module", "-out=/local/root"}, "/local/root", "/tmp/terratest-abc")
+	assert.Equal(t, []string{"plan", "-var-file", "/local/rootfs/test.tfvars", "-var", "dir=/local/root/module", "-out=/tmp/terratest-abc"}, args)
+}
+
+func TestTranslateRemoteEnv(t *testing.T) {
+	t.Parallel()
+
+	env := translateRemoteEnv(map[string]string{
+		"TF_VAR_dir":           "/local/root/module",
+		TfDataDirEnvVar:        "/tmp/terratest-tf-data-abc",
+		TfCliConfigFileEnvVar:  "/tmp/terratest-plugin-cache/terratest.tfrc",
+		TfPluginCacheDirEnvVar: "/local/root/.plugin-cache",
+	}, "/local/root", "/tmp/terratest-abc")
+	assert.Equal(t, map[string]string{
+		"TF_VAR_dir":           "/local/root/module",
+		TfDataDirEnvVar:        "/tmp/terratest-abc/.terratest-data/terratest-tf-data-abc",
+		TfPluginCacheDirEnvVar: "/tmp/terratest-abc/.plugin-cache",
+	}, env)
+}
+
+func TestReplaceArg(t *testing.T) {
+	t.Parallel()
+
+	args := replaceArg([]string{"plan", "-var-file", "/tmp/terratest-vars-abc.tfvars.json"}, "/tmp/terratest-vars-abc.tfvars.json", "/tmp/terratest-abc/.terratest.tfvars.json")
+	assert.Equal(t, []string{"plan", "-var-file", "/tmp/terratest-abc/.terratest.tfvars.json"}, args)
+}
+
+func TestToRemotePath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/tmp/terratest-abc/examples/foo", toRemotePath("/local/root/examples/foo", "/local/root", "/tmp/terratest-abc"))
+	assert.Equal(t, "/tmp/terratest-abc", toRemotePath("/local/root", "/local/root", "/tmp/terratest-abc"))
+	assert.Equal(t, "/tmp/terratest-abc", toRemotePath("/elsewhere", "/local/root", "/tmp/terratest-abc"))
+}
+
+func TestIsRemoteCopyablePath(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isRemoteCopyablePath("/local/root/main.tf"))
+	assert.False(t, isRemoteCopyablePath("/local/root/.terraform"))
+	assert.False(t, isRemoteCopyablePath("/local/root/terraform.tfstate"))
+}
@@ -0,0 +1,142 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// DefaultRunReportMaxOutputSize is the default number of bytes of output that are kept inline in each entry of a
+// RunReport. The full output is always written to a separate file.
+const DefaultRunReportMaxOutputSize = 4 * 1024
+
+// RunReport records every Terraform command run with the Options it is set on, and writes them as a JSON report to
+// Path after each command. The same RunReport can be shared by the Options of multiple (parallel) tests. This is
+// useful to mine CI data for flaky providers and slow stages.
+type RunReport struct {
+	// The file to write the JSON report to. The full output of each command is written to files in an outputs folder
+	// next to it.
+	Path string
+
+	// The number of bytes at the end of the output of each command to keep inline in the report. Defaults to
+	// DefaultRunReportMaxOutputSize.
+	MaxOutputSize int
+
+	mutex   sync.Mutex
+	entries []RunReportEntry
+}
+
+// RunReportEntry is the record of a single Terraform command in a RunReport.
+type RunReportEntry struct {
+	TestName        string    `json:"test_name"`
+	Binary          string    `json:"binary"`
+	Args            []string  `json:"args"`
+	WorkingDir      string    `json:"working_dir"`
+	StartTime       time.Time `json:"start_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ExitCode        int       `json:"exit_code"`
+	Attempts        int       `json:"attempts"`
+	Retries         int       `json:"retries"`
+	Error           string    `json:"error,omitempty"`
+	Output          string    `json:"output"`
+	OutputTruncated bool      `json:"output_truncated"`
+	OutputPath      string    `json:"output_path,omitempty"`
+}
+
+// NewRunReport returns a RunReport that writes to the given path.
+func NewRunReport(path string) *RunReport {
+	return &RunReport{Path: path}
+}
+
+// Entries returns a copy of the entries recorded so far.
+func (report *RunReport) Entries() []RunReportEntry {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	entries := make([]RunReportEntry, len(report.entries))
+	copy(entries, report.entries)
+	return entries
+}
+
+// record adds an entry for a command to the report and rewrites the report file. It is safe to call on a nil
+// RunReport. Failures to write the report are logged rather than failing the command, as the report is only a
+// diagnostic aid.
+func (report *RunReport) record(t testing.TestingT, options *Options, args []string, start time.Time, attempts int, output string, exitCode int, err error) {
+	if report == nil {
+		return
+	}
+
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	// Never persist secrets to disk
+	secrets := secretValues(options)
+	args = maskSecretsInArgs(args, secrets)
+	output = maskSecrets(output, secrets)
+
+	entry := RunReportEntry{
+		TestName:        t.Name(),
+		Binary:          options.TerraformBinary,
+		Args:            args,
+		WorkingDir:      options.TerraformDir,
+		StartTime:       start,
+		DurationSeconds: time.Since(start).Seconds(),
+		ExitCode:        exitCode,
+		Attempts:        attempts,
+		Retries:         attempts - 1,
+		Output:          output,
+	}
+	if entry.Retries < 0 {
+		entry.Retries = 0
+	}
+	if err != nil {
+		entry.Error = maskSecrets(err.Error(), secrets)
+	}
+
+	maxOutputSize := report.MaxOutputSize
+	if maxOutputSize <= 0 {
+		maxOutputSize = DefaultRunReportMaxOutputSize
+	}
+	if len(output) > maxOutputSize {
+		entry.Output = output[len(output)-maxOutputSize:]
+		entry.OutputTruncated = true
+	}
+
+	outputPath, writeErr := report.writeOutput(len(report.entries), hookedCommandName(args), output)
+	if writeErr != nil {
+		options.Logger.Logf(t, "Failed to write terraform output to run report: %v", writeErr)
+	}
+	entry.OutputPath = outputPath
+
+	report.entries = append(report.entries, entry)
+	if writeErr := report.write(); writeErr != nil {
+		options.Logger.Logf(t, "Failed to write run report to %s: %v", report.Path, writeErr)
+	}
+}
+
+// writeOutput writes the full output of a command to a file in the outputs folder next to the report.
+func (report *RunReport) writeOutput(index int, command string, output string) (string, error) {
+	outputDir := filepath.Join(filepath.Dir(report.Path), "terratest-run-report-outputs")
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	outputPath := filepath.Join(outputDir, fmt.Sprintf("%04d-%s.log", index, command))
+	return outputPath, os.WriteFile(outputPath, []byte(output), 0644)
+}
+
+// write rewrites the whole report file. Must be called with the mutex held.
+func (report *RunReport) write() error {
+	contents, err := json.MarshalIndent(report.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(report.Path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(report.Path, contents, 0644)
+}
@@ -0,0 +1,46 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReportRecordsCommands(t *testing.T) {
+	t.Parallel()
+
+	report := NewRunReport(filepath.Join(t.TempDir(), "report.json"))
+	report.MaxOutputSize = 10
+
+	_, err := RunTerraformCommandE(t, &Options{TerraformBinary: "echo", Logger: logger.Discard, RunReport: report}, "apply", "-auto-approve")
+	require.NoError(t, err)
+	_, err = RunTerraformCommandE(t, &Options{TerraformBinary: "false", Logger: logger.Discard, RunReport: report}, "plan")
+	require.Error(t, err)
+
+	entries := report.Entries()
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, []string{"apply", "-auto-approve"}, entries[0].Args)
+	assert.Equal(t, 0, entries[0].ExitCode)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Equal(t, "to-approve", entries[0].Output)
+	assert.True(t, entries[0].OutputTruncated)
+	fullOutput, err := os.ReadFile(entries[0].OutputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "apply -auto-approve", string(fullOutput))
+
+	assert.Equal(t, 1, entries[1].ExitCode)
+	assert.NotEmpty(t, entries[1].Error)
+
+	contents, err := os.ReadFile(report.Path)
+	require.NoError(t, err)
+	var written []RunReportEntry
+	require.NoError(t, json.Unmarshal(contents, &written))
+	assert.Len(t, written, 2)
+	assert.Equal(t, t.Name(), written[0].TestName)
+}
@@ -0,0 +1,164 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/collections"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// SecretMask is the string that sensitive values are replaced with in logs.
+const SecretMask = "***"
+
+// secretValues returns the values of the SensitiveVars, EphemeralVars, and SensitiveEnvVars in the given options, longest first, so
+// that a secret that contains another one is masked as a whole.
+func secretValues(options *Options) []string {
+	secrets := []string{}
+	// Ephemeral values must never be persisted, so they are masked just like sensitive ones
+	sensitiveVars := append(append([]string{}, options.SensitiveVars...), options.EphemeralVars...)
+	for _, name := range sensitiveVars {
+		value, ok := options.Vars[name]
+		if !ok || value == nil {
+			continue
+		}
+		secrets = append(secrets, toHclString(value, false))
+		if _, isString := value.(string); !isString {
+			// Also mask the individual values of lists and maps, in case they show up on their own in the output
+			secrets = append(secrets, nestedSecretValues(value)...)
+		}
+	}
+	for _, name := range options.SensitiveEnvVars {
+		if value, ok := options.EnvVars[name]; ok {
+			secrets = append(secrets, value)
+		}
+	}
+
+	nonEmpty := []string{}
+	for _, secret := range secrets {
+		if secret != "" {
+			nonEmpty = append(nonEmpty, secret)
+		}
+	}
+	sort.Slice(nonEmpty, func(i, j int) bool { return len(nonEmpty[i]) > len(nonEmpty[j]) })
+	return nonEmpty
+}
+
+// nestedSecretValues returns the strings nested in the given list or map. Numbers and booleans are skipped, as masking
+// every occurrence of, e.g., "1" or "true" would make the logs unreadable.
+func nestedSecretValues(value interface{}) []string {
+	var items []interface{}
+	if slice, isSlice := tryToConvertToGenericSlice(value); isSlice {
+		items = slice
+	} else if m, isMap := tryToConvertToGenericMap(value); isMap {
+		for _, item := range m {
+			items = append(items, item)
+		}
+	}
+
+	values := []string{}
+	for _, item := range items {
+		if str, isString := item.(string); isString {
+			values = append(values, str)
+		} else {
+			values = append(values, nestedSecretValues(item)...)
+		}
+	}
+	return values
+}
+
+// maskSecrets replaces all the given secrets in s with SecretMask.
+func maskSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, SecretMask)
+	}
+	return s
+}
+
+// maskSecretsInArgs replaces all the given secrets in each of the args with SecretMask.
+func maskSecretsInArgs(args []string, secrets []string) []string {
+	if len(secrets) == 0 {
+		return args
+	}
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		masked[i] = maskSecrets(arg, secrets)
+	}
+	return masked
+}
+
+// maskingLogger is a logger.TestLogger that masks secrets before passing messages on to an underlying logger.
+type maskingLogger struct {
+	underlying *logger.Logger
+	secrets    []string
+}
+
+func (l maskingLogger) Logf(t testing.TestingT, format string, args ...interface{}) {
+	l.underlying.Logf(t, "%s", maskSecrets(fmt.Sprintf(format, args...), l.secrets))
+}
+
+// loggerForOptions returns the logger to use for the given options, which masks the values of any sensitive vars and
+// env vars.
+func loggerForOptions(options *Options) *logger.Logger {
+	secrets := secretValues(options)
+	if len(secrets) == 0 {
+		return options.Logger
+	}
+	return logger.New(maskingLogger{underlying: options.Logger, secrets: secrets})
+}
+
+// outputLineCallbackForOptions returns the OutputLineCallback of the given options, wrapped so that the values of any
+// sensitive vars and env vars are masked before the lines reach it.
+func outputLineCallbackForOptions(options *Options) func(line string, isStderr bool) {
+	callback := options.OutputLineCallback
+	secrets := secretValues(options)
+	if callback == nil || len(secrets) == 0 {
+		return callback
+	}
+	return func(line string, isStderr bool) {
+		callback(maskSecrets(line, secrets), isStderr)
+	}
+}
+
+// commandsWithUnmaskedOutput are the commands whose returned output is not masked, as it's parsed by terratest (e.g.,
+// output -json) and tests need the actual values to check them. Their logs are still masked.
+var commandsWithUnmaskedOutput = []string{
+	"output",
+	"show",
+	"state",
+}
+
+// maskCommandOutput masks the values of any sensitive vars and env vars in the given output of a command with the given
+// args, unless that output is parsed by terratest.
+func maskCommandOutput(options *Options, args []string, out string) string {
+	if collections.ListContains(commandsWithUnmaskedOutput, hookedCommandName(args)) {
+		return out
+	}
+	return maskSecrets(out, secretValues(options))
+}
+
+// maskedError is an error whose message has secrets masked. The underlying error is still available via errors.As and
+// errors.Is.
+type maskedError struct {
+	underlying error
+	secrets    []string
+}
+
+func (err maskedError) Error() string {
+	return maskSecrets(err.underlying.Error(), err.secrets)
+}
+
+func (err maskedError) Unwrap() error {
+	return err.underlying
+}
+
+// maskCommandError returns the given error with the values of any sensitive vars and env vars masked in its message.
+func maskCommandError(options *Options, err error) error {
+	secrets := secretValues(options)
+	if err == nil || len(secrets) == 0 || maskSecrets(err.Error(), secrets) == err.Error() {
+		return err
+	}
+	return maskedError{underlying: err, secrets: secrets}
+}
@@ -0,0 +1,128 @@
+package terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	ttesting "github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingLogger struct {
+	messages []string
+}
+
+func (l *capturingLogger) Logf(_ ttesting.TestingT, format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestSecretValues(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		Vars: map[string]interface{}{
+			"db_password": "hunter2",
+			"db_config":   map[string]interface{}{"user": "admin-user", "port": 5432},
+			"region":      "us-east-1",
+		},
+		EnvVars:          map[string]string{"TF_VAR_token": "s3cr3t-token", "AWS_REGION": "us-east-1"},
+		SensitiveVars:    []string{"db_password", "db_config", "missing"},
+		SensitiveEnvVars: []string{"TF_VAR_token"},
+	}
+
+	secrets := secretValues(options)
+	assert.Contains(t, secrets, "hunter2")
+	assert.Contains(t, secrets, "admin-user")
+	assert.Contains(t, secrets, "s3cr3t-token")
+	assert.NotContains(t, secrets, "us-east-1")
+	assert.NotContains(t, secrets, "5432")
+
+	// Longest secrets come first
+	for i := 1; i < len(secrets); i++ {
+		assert.GreaterOrEqual(t, len(secrets[i-1]), len(secrets[i]))
+	}
+}
+
+func TestMaskSecretsInArgs(t *testing.T) {
+	t.Parallel()
+
+	args := maskSecretsInArgs([]string{"apply", "-var", "db_password=hunter2", "-var", "region=us-east-1"}, []string{"hunter2"})
+	assert.Equal(t, []string{"apply", "-var", "db_password=***", "-var", "region=us-east-1"}, args)
+}
+
+func TestCommandLogsAreMasked(t *testing.T) {
+	t.Parallel()
+
+	captured := &capturingLogger{}
+	options := &Options{
+		TerraformBinary: "echo",
+		Logger:          logger.New(captured),
+		Vars:            map[string]interface{}{"db_password": "hunter2"},
+		SensitiveVars:   []string{"db_password"},
+	}
+
+	out := RunTerraformCommand(t, options, FormatArgs(options, "plan")...)
+	assert.Contains(t, out, "db_password="+SecretMask)
+	assert.NotContains(t, out, "hunter2")
+
+	assert.NotEmpty(t, captured.messages)
+	for _, message := range captured.messages {
+		assert.NotContains(t, message, "hunter2")
+	}
+}
+
+func TestOutputLineCallbackIsMasked(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{}
+	options := &Options{
+		TerraformBinary:    "echo",
+		Vars:               map[string]interface{}{"db_password": "hunter2"},
+		SensitiveVars:      []string{"db_password"},
+		OutputLineCallback: func(line string, isStderr bool) { lines = append(lines, line) },
+	}
+
+	RunTerraformCommand(t, options, FormatArgs(options, "plan")...)
+
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.NotContains(t, line, "hunter2")
+	}
+}
+
+func TestCommandErrorsAreMasked(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary: "sh",
+		Vars:            map[string]interface{}{"db_password": "hunter2"},
+		SensitiveVars:   []string{"db_password"},
+	}
+
+	out, err := RunTerraformCommandE(t, options, "-c", "echo hunter2; echo hunter2 >&2; exit 1")
+	require.Error(t, err)
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, err.Error(), "hunter2")
+
+	stdout, stderr, exitCode, err := RunTerraformCommandAndGetStdOutErrCodeE(t, options, "-c", "echo hunter2; echo hunter2 >&2; exit 1")
+	require.Error(t, err)
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, SecretMask, stdout)
+	assert.Equal(t, SecretMask, stderr)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestParsedCommandOutputIsNotMasked(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary: "echo",
+		Vars:            map[string]interface{}{"db_password": "hunter2"},
+		SensitiveVars:   []string{"db_password"},
+	}
+
+	out := RunTerraformCommandAndGetStdout(t, options, "output", "-json", "hunter2")
+	assert.Contains(t, out, "hunter2")
+}
@@ -0,0 +1,254 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// providerConfigRegex matches the provider config address of a resource in a state file, e.g.,
+// module.vpc.provider["registry.terraform.io/hashicorp/aws"].west
+var providerConfigRegex = regexp.MustCompile(`^(?:(.*)\.)?provider\["([^"]+)"\](?:\.(.+))?$`)
+
+// State is a Terraform state file, as stored by the backends (format version 4).
+type State struct {
+	Version          int                    `json:"version"`
+	TerraformVersion string                 `json:"terraform_version"`
+	Serial           int64                  `json:"serial"`
+	Lineage          string                 `json:"lineage"`
+	Outputs          map[string]StateOutput `json:"outputs"`
+	Resources        []StateResource        `json:"resources"`
+}
+
+// StateOutput is an output value in a Terraform state file.
+type StateOutput struct {
+	Value     interface{}     `json:"value"`
+	Type      json.RawMessage `json:"type"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+// StateResource is a resource (or data source) in a Terraform state file, with all its instances (e.g., one per
+// count index or for_each key).
+type StateResource struct {
+	Module    string                  `json:"module,omitempty"`
+	Mode      string                  `json:"mode"`
+	Type      string                  `json:"type"`
+	Name      string                  `json:"name"`
+	Provider  string                  `json:"provider"`
+	Instances []StateResourceInstance `json:"instances"`
+}
+
+// StateResourceInstance is an instance of a resource in a Terraform state file.
+type StateResourceInstance struct {
+	IndexKey      interface{}            `json:"index_key,omitempty"`
+	SchemaVersion int                    `json:"schema_version"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	Dependencies  []string               `json:"dependencies,omitempty"`
+}
+
+// ProviderConfig is a provider configuration used by the resources in a state file.
+type ProviderConfig struct {
+	Module string // The address of the module the provider is configured in, empty for the root module
+	Source string // The source address of the provider, e.g., registry.terraform.io/hashicorp/aws
+	Alias  string // The alias of the provider configuration, empty for the default configuration
+}
+
+// ParseStateFile parses the Terraform state file at the given path, e.g., a local terraform.tfstate file or the output
+// of terraform state pull saved to disk. This will fail the test if there is an error.
+func ParseStateFile(t testing.TestingT, path string) *State {
+	state, err := ParseStateFileE(path)
+	require.NoError(t, err)
+	return state
+}
+
+// ParseStateFileE parses the Terraform state file at the given path, e.g., a local terraform.tfstate file or the
+// output of terraform state pull saved to disk.
+func ParseStateFileE(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateE(data)
+}
+
+// PullState runs terraform state pull with the given options and parses the state, which works with any backend.
+// This will fail the test if there is an error.
+func PullState(t testing.TestingT, options *Options) *State {
+	state, err := PullStateE(t, options)
+	require.NoError(t, err)
+	return state
+}
+
+// PullStateE runs terraform state pull with the given options and parses the state, which works with any backend.
+func PullStateE(t testing.TestingT, options *Options) (*State, error) {
+	out, err := RunTerraformCommandAndGetStdoutE(t, options, "state", "pull")
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateE([]byte(out))
+}
+
+// ParseStateE parses the given Terraform state file contents.
+func ParseStateE(data []byte) (*State, error) {
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Version != 4 {
+		return nil, UnsupportedStateVersion(state.Version)
+	}
+	return state, nil
+}
+
+// OutputValues returns the values of the outputs in the state.
+func (state *State) OutputValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	for name, output := range state.Outputs {
+		values[name] = output.Value
+	}
+	return values
+}
+
+// Address returns the address of the resource, e.g., module.vpc.aws_subnet.private or data.aws_ami.ubuntu.
+func (resource *StateResource) Address() string {
+	parts := []string{}
+	if resource.Module != "" {
+		parts = append(parts, resource.Module)
+	}
+	if resource.Mode == "data" {
+		parts = append(parts, "data")
+	}
+	parts = append(parts, resource.Type, resource.Name)
+	return strings.Join(parts, ".")
+}
+
+// InstanceAddress returns the address of the given instance of the resource, e.g., aws_subnet.private[0] or
+// aws_subnet.private["a"].
+func (resource *StateResource) InstanceAddress(instance *StateResourceInstance) string {
+	switch key := instance.IndexKey.(type) {
+	case nil:
+		return resource.Address()
+	case string:
+		return fmt.Sprintf("%s[%q]", resource.Address(), key)
+	case float64:
+		return fmt.Sprintf("%s[%d]", resource.Address(), int64(key))
+	default:
+		return fmt.Sprintf("%s[%v]", resource.Address(), key)
+	}
+}
+
+// ProviderConfig returns the provider configuration used by the resource.
+func (resource *StateResource) ProviderConfig() (ProviderConfig, error) {
+	matches := providerConfigRegex.FindStringSubmatch(resource.Provider)
+	if matches == nil {
+		return ProviderConfig{}, fmt.Errorf("unexpected provider config address %q for resource %s", resource.Provider, resource.Address())
+	}
+	return ProviderConfig{Module: matches[1], Source: matches[2], Alias: matches[3]}, nil
+}
+
+// Modules returns the sorted addresses of the modules with resources in the state, e.g., module.vpc. The root module
+// is not included.
+func (state *State) Modules() []string {
+	seen := map[string]bool{}
+	modules := []string{}
+	for _, resource := range state.Resources {
+		if resource.Module != "" && !seen[resource.Module] {
+			seen[resource.Module] = true
+			modules = append(modules, resource.Module)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// ProviderConfigs returns the distinct provider configurations used by the resources in the state.
+func (state *State) ProviderConfigs() ([]ProviderConfig, error) {
+	seen := map[ProviderConfig]bool{}
+	configs := []ProviderConfig{}
+	for i := range state.Resources {
+		config, err := state.Resources[i].ProviderConfig()
+		if err != nil {
+			return nil, err
+		}
+		if !seen[config] {
+			seen[config] = true
+			configs = append(configs, config)
+		}
+	}
+	return configs, nil
+}
+
+// ResourcesInModule returns the resources in the given module (e.g., module.vpc, or empty for the root module). Nested
+// modules are not included.
+func (state *State) ResourcesInModule(module string) []*StateResource {
+	resources := []*StateResource{}
+	for i := range state.Resources {
+		if state.Resources[i].Module == module {
+			resources = append(resources, &state.Resources[i])
+		}
+	}
+	return resources
+}
+
+// ResourceAddresses returns the sorted addresses of all the resource instances in the state, e.g.,
+// module.vpc.aws_subnet.private[0].
+func (state *State) ResourceAddresses() []string {
+	addresses := []string{}
+	for i := range state.Resources {
+		resource := &state.Resources[i]
+		for j := range resource.Instances {
+			addresses = append(addresses, resource.InstanceAddress(&resource.Instances[j]))
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// ResourceArns returns the ARNs (the arn attribute) of all the managed resources in the state, sorted alphabetically,
+// e.g., to check that all the AWS resources created by an apply are tagged with aws.AssertResourcesHaveTags. Note that
+// this includes resources that don't support tags (e.g., aws_sns_topic_subscription) and resources in other regions.
+func (state *State) ResourceArns() []string {
+	arns := []string{}
+	for i := range state.Resources {
+		if state.Resources[i].Mode != "managed" {
+			continue
+		}
+		for _, instance := range state.Resources[i].Instances {
+			if arn, isString := instance.Attributes["arn"].(string); isString && arn != "" {
+				arns = append(arns, arn)
+			}
+		}
+	}
+	sort.Strings(arns)
+	return arns
+}
+
+// GetResourceE returns the resource with the given address, e.g., module.vpc.aws_subnet.private.
+func (state *State) GetResourceE(address string) (*StateResource, error) {
+	for i := range state.Resources {
+		if state.Resources[i].Address() == address {
+			return &state.Resources[i], nil
+		}
+	}
+	return nil, ResourceNotFoundInState(address)
+}
+
+// GetResourceInstanceE returns the resource instance with the given address, e.g.,
+// module.vpc.aws_subnet.private[0] or aws_instance.web (for a resource without count or for_each).
+func (state *State) GetResourceInstanceE(address string) (*StateResourceInstance, error) {
+	for i := range state.Resources {
+		resource := &state.Resources[i]
+		for j := range resource.Instances {
+			if resource.InstanceAddress(&resource.Instances[j]) == address {
+				return &resource.Instances[j], nil
+			}
+		}
+	}
+	return nil, ResourceNotFoundInState(address)
+}
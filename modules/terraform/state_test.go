@@ -0,0 +1,86 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStateFile = `{
+  "version": 4,
+  "terraform_version": "1.9.0",
+  "serial": 3,
+  "lineage": "abc",
+  "outputs": {"bucket": {"value": "my-bucket", "type": "string"}},
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_s3_bucket", "name": "this",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"schema_version": 0, "attributes": {"id": "my-bucket", "arn": "arn:aws:s3:::my-bucket"}}]
+    },
+    {
+      "module": "module.vpc", "mode": "managed", "type": "aws_subnet", "name": "private",
+      "provider": "module.vpc.provider[\"registry.terraform.io/hashicorp/aws\"].west",
+      "instances": [
+        {"index_key": 0, "schema_version": 1, "attributes": {"id": "subnet-0"}},
+        {"index_key": 1, "schema_version": 1, "attributes": {"id": "subnet-1"}}
+      ]
+    },
+    {
+      "module": "module.vpc", "mode": "data", "type": "aws_availability_zones", "name": "all",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"index_key": "a", "schema_version": 0, "attributes": {"id": "us-east-1", "arn": "arn:aws:ec2:us-east-1::az"}}]
+    }
+  ]
+}`
+
+func TestParseStateFileE(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(testStateFile), 0644))
+
+	state, err := ParseStateFileE(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{"bucket": "my-bucket"}, state.OutputValues())
+	assert.Equal(t, []string{"module.vpc"}, state.Modules())
+	assert.Len(t, state.ResourcesInModule(""), 1)
+	assert.Len(t, state.ResourcesInModule("module.vpc"), 2)
+
+	assert.Equal(t, []string{
+		"aws_s3_bucket.this",
+		"module.vpc.aws_subnet.private[0]",
+		"module.vpc.aws_subnet.private[1]",
+		"module.vpc.data.aws_availability_zones.all[\"a\"]",
+	}, state.ResourceAddresses())
+	assert.Equal(t, []string{"arn:aws:s3:::my-bucket"}, state.ResourceArns())
+
+	configs, err := state.ProviderConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, []ProviderConfig{
+		{Source: "registry.terraform.io/hashicorp/aws"},
+		{Module: "module.vpc", Source: "registry.terraform.io/hashicorp/aws", Alias: "west"},
+	}, configs)
+
+	resource, err := state.GetResourceE("module.vpc.aws_subnet.private")
+	require.NoError(t, err)
+	assert.Len(t, resource.Instances, 2)
+
+	instance, err := state.GetResourceInstanceE("module.vpc.aws_subnet.private[1]")
+	require.NoError(t, err)
+	assert.Equal(t, "subnet-1", instance.Attributes["id"])
+
+	_, err = state.GetResourceInstanceE("aws_s3_bucket.other")
+	assert.Equal(t, ResourceNotFoundInState("aws_s3_bucket.other"), err)
+}
+
+func TestParseStateEUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseStateE([]byte(`{"version": 3}`))
+	assert.Equal(t, UnsupportedStateVersion(3), err)
+}
@@ -0,0 +1,142 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// TgMockDependencyOutputs sets the mock_outputs of the given dependency block in the terragrunt.hcl file in
+// options.TerraformDir, so that the module can be tested without the dependency existing. This will fail the test if
+// there is an error.
+func TgMockDependencyOutputs(t testing.TestingT, options *Options, dependency string, outputs map[string]interface{}) {
+	require.NoError(t, TgMockDependencyOutputsE(t, options, dependency, outputs))
+}
+
+// TgMockDependencyOutputsE sets the mock_outputs of the given dependency block in the terragrunt.hcl file in
+// options.TerraformDir, so that the module can be tested without the dependency existing. The dependency is also
+// configured with skip_outputs = true, and without any restriction on the commands the mocks are used for, so that
+// terragrunt always uses the mocks instead of reading the outputs of the dependency.
+//
+// NOTE: this modifies the terragrunt.hcl file in place, so you will typically want to call it on a copy of your
+// module made with test_structure.CopyTerragruntFolderToTemp.
+func TgMockDependencyOutputsE(t testing.TestingT, options *Options, dependency string, outputs map[string]interface{}) error {
+	return TgMockDependenciesOutputsE(t, options, map[string]map[string]interface{}{dependency: outputs})
+}
+
+// TgMockDependenciesOutputs sets the mock_outputs of several dependency blocks at once, keyed by the name of the
+// dependency. See TgMockDependencyOutputsE. This will fail the test if there is an error.
+func TgMockDependenciesOutputs(t testing.TestingT, options *Options, outputs map[string]map[string]interface{}) {
+	require.NoError(t, TgMockDependenciesOutputsE(t, options, outputs))
+}
+
+// TgMockDependenciesOutputsE sets the mock_outputs of several dependency blocks at once, keyed by the name of the
+// dependency. See TgMockDependencyOutputsE.
+func TgMockDependenciesOutputsE(t testing.TestingT, options *Options, outputs map[string]map[string]interface{}) error {
+	configPath := filepath.Join(options.TerraformDir, terragruntConfigFile)
+	contents, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	updated, err := mockDependencyOutputs(configPath, contents, outputs)
+	if err != nil {
+		return err
+	}
+
+	loggerForOptions(options).Logf(t, "Mocking the outputs of dependencies %v in %s", sortedKeys(outputs), configPath)
+	return os.WriteFile(configPath, updated, 0644)
+}
+
+// mockDependencyOutputs returns the given terragrunt configuration with the mock_outputs of the given dependencies
+// set.
+func mockDependencyOutputs(filename string, contents []byte, outputs map[string]map[string]interface{}) ([]byte, error) {
+	file, diags := hclwrite.ParseConfig(contents, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	for _, dependency := range sortedKeys(outputs) {
+		block := file.Body().FirstMatchingBlock("dependency", []string{dependency})
+		if block == nil {
+			return nil, TgDependencyNotFound{Dependency: dependency, ConfigPath: filename}
+		}
+
+		jsonBytes, err := json.Marshal(outputs[dependency])
+		if err != nil {
+			return nil, err
+		}
+		ctyType, err := ctyjson.ImpliedType(jsonBytes)
+		if err != nil {
+			return nil, err
+		}
+		value, err := ctyjson.Unmarshal(jsonBytes, ctyType)
+		if err != nil {
+			return nil, err
+		}
+
+		body := block.Body()
+		body.RemoveAttribute("mock_outputs_allowed_terraform_commands")
+		body.RemoveAttribute("mock_outputs_merge_strategy_with_state")
+		body.RemoveAttribute("mock_outputs_merge_with_state")
+		body.SetAttributeValue("mock_outputs", value)
+		body.SetAttributeValue("skip_outputs", cty.True)
+	}
+
+	return hclwrite.Format(file.Bytes()), nil
+}
+
+// sortedKeys returns the keys of the given map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// OutputAllStruct calls terraform (or terragrunt) output and stores all the output values in the value pointed to by
+// v, typically a struct whose json tags are the names of the outputs. This will fail the test if there is an error.
+func OutputAllStruct(t testing.TestingT, options *Options, v interface{}) {
+	require.NoError(t, OutputAllStructE(t, options, v))
+}
+
+// OutputAllStructE calls terraform (or terragrunt) output and stores all the output values in the value pointed to by
+// v, typically a struct whose json tags are the names of the outputs. Returns an error if v is nil or not a pointer,
+// or if an output value is not appropriate for the corresponding field.
+func OutputAllStructE(t testing.TestingT, options *Options, v interface{}) error {
+	out, err := OutputJsonE(t, options, "")
+	if err != nil {
+		return err
+	}
+	return unmarshalOutputValues([]byte(out), v)
+}
+
+// unmarshalOutputValues stores the values of the outputs in the given output -json document in v.
+func unmarshalOutputValues(outputJson []byte, v interface{}) error {
+	outputs := map[string]struct {
+		Value json.RawMessage `json:"value"`
+	}{}
+	if err := json.Unmarshal(outputJson, &outputs); err != nil {
+		return err
+	}
+
+	values := map[string]json.RawMessage{}
+	for name, output := range outputs {
+		values[name] = output.Value
+	}
+	valuesJson, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(valuesJson, v)
+}
@@ -0,0 +1,77 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTerragruntConfigWithDependency = `dependency "vpc" {
+  config_path = "../vpc"
+
+  mock_outputs_allowed_terraform_commands = ["plan"]
+}
+
+inputs = {
+  vpc_id = dependency.vpc.outputs.vpc_id
+}
+`
+
+func TestTgMockDependencyOutputsE(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "terragrunt.hcl")
+	require.NoError(t, os.WriteFile(configPath, []byte(testTerragruntConfigWithDependency), 0644))
+
+	options := &Options{TerraformDir: dir}
+	err := TgMockDependencyOutputsE(t, options, "vpc", map[string]interface{}{
+		"vpc_id":     "vpc-123",
+		"subnet_ids": []string{"subnet-1", "subnet-2"},
+	})
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	config := string(contents)
+	assert.Contains(t, config, `config_path = "../vpc"`)
+	assert.Contains(t, config, `vpc_id     = "vpc-123"`)
+	assert.Contains(t, config, `subnet_ids = ["subnet-1", "subnet-2"]`)
+	assert.Contains(t, config, `skip_outputs = true`)
+	assert.NotContains(t, config, "mock_outputs_allowed_terraform_commands")
+	assert.Contains(t, config, "vpc_id = dependency.vpc.outputs.vpc_id")
+}
+
+func TestTgMockDependencyOutputsEMissingDependency(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "terragrunt.hcl")
+	require.NoError(t, os.WriteFile(configPath, []byte(testTerragruntConfigWithDependency), 0644))
+
+	err := TgMockDependencyOutputsE(t, &Options{TerraformDir: dir}, "db", map[string]interface{}{"url": "x"})
+	assert.Equal(t, TgDependencyNotFound{Dependency: "db", ConfigPath: configPath}, err)
+}
+
+func TestUnmarshalOutputValues(t *testing.T) {
+	t.Parallel()
+
+	outputJson := `{
+  "vpc_id": {"sensitive": false, "type": "string", "value": "vpc-123"},
+  "subnet_ids": {"sensitive": false, "type": ["list", "string"], "value": ["subnet-1", "subnet-2"]},
+  "instance_count": {"sensitive": false, "type": "number", "value": 3}
+}`
+
+	var outputs struct {
+		VpcId         string   `json:"vpc_id"`
+		SubnetIds     []string `json:"subnet_ids"`
+		InstanceCount int      `json:"instance_count"`
+	}
+	require.NoError(t, unmarshalOutputValues([]byte(outputJson), &outputs))
+	assert.Equal(t, "vpc-123", outputs.VpcId)
+	assert.Equal(t, []string{"subnet-1", "subnet-2"}, outputs.SubnetIds)
+	assert.Equal(t, 3, outputs.InstanceCount)
+}
@@ -0,0 +1,285 @@
+package terraform
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	terragruntConfigFile = "terragrunt.hcl"
+
+	// tgRunAllLogCustomFormat prefixes each line of the run-all output with the path of the module it comes from
+	// (e.g., "[modules/vpc] Apply complete!..."), so that the output can be split per module.
+	// https://terragrunt.gruntwork.io/docs/reference/logging/formatting/
+	tgRunAllLogCustomFormat = "%prefix(path=short-relative,prefix='[',suffix='] ')%msg(color=disable)"
+)
+
+// tgModulePrefixRegex matches a line of run-all output prefixed with the path of the module it comes from.
+var tgModulePrefixRegex = regexp.MustCompile(`^\[([^\]\s]+)\] ?(.*)$`)
+
+// TgRunAllResult is the result of a terragrunt run-all command.
+type TgRunAllResult struct {
+	// The full output of the command
+	Output string
+
+	// The results for each module, keyed by the path of the module relative to TerraformDir
+	Modules map[string]*TgModuleResult
+}
+
+// TgModuleResult is the result of a terragrunt run-all command for a single module.
+type TgModuleResult struct {
+	// The path of the module relative to TerraformDir
+	Path string
+
+	// The output of the command for this module
+	Output string
+
+	// The resources affected by the command in this module, or nil if the output doesn't contain a summary (e.g., for
+	// commands other than plan, apply, and destroy, or if the command failed in this module)
+	ResourceCount *ResourceCount
+}
+
+// TgRunAll runs terragrunt run-all with the given command and args (e.g., "plan", "-input=false") and returns the
+// output, split per module. The ExtraArgs for the command are added to the args. This will fail the test if there is
+// an error.
+func TgRunAll(t testing.TestingT, options *Options, args ...string) *TgRunAllResult {
+	result, err := TgRunAllE(t, options, args...)
+	require.NoError(t, err)
+	return result
+}
+
+// TgRunAllE runs terragrunt run-all with the given command and args (e.g., "plan", "-input=false") and returns the
+// output, split per module. The ExtraArgs for the command are added to the args. If the command fails, the result is
+// still returned along with the error, so that the output of the modules that failed can be inspected.
+//
+// To be able to split the output, each line is prefixed with the path of its module by setting
+// TERRAGRUNT_LOG_CUSTOM_FORMAT, unless it is already set in options.EnvVars or in the environment. The given options
+// are not modified.
+func TgRunAllE(t testing.TestingT, options *Options, args ...string) (*TgRunAllResult, error) {
+	if options.TerraformBinary != TerragruntDefaultPath {
+		return nil, TgInvalidBinary(options.TerraformBinary)
+	}
+
+	runAllOptions, err := options.Clone()
+	if err != nil {
+		return nil, err
+	}
+	setTerragruntRunAllLogFormatting(runAllOptions)
+
+	if len(args) > 0 {
+		// Copy the args first, so the caller's slice isn't modified if it has spare capacity
+		args = append(append([]string{}, args...), extraArgsForCommand(options, args[0])...)
+	}
+	out, err := RunTerraformCommandE(t, runAllOptions, FormatArgs(runAllOptions, prepend(args, runAllCmd)...)...)
+	return ParseTgRunAllOutput(out), err
+}
+
+// TgPlanAll runs terragrunt run-all plan with the given options and returns the output, split per module. This will
+// fail the test if there is an error.
+func TgPlanAll(t testing.TestingT, options *Options) *TgRunAllResult {
+	result, err := TgPlanAllE(t, options)
+	require.NoError(t, err)
+	return result
+}
+
+// TgPlanAllE runs terragrunt run-all plan with the given options and returns the output, split per module.
+func TgPlanAllE(t testing.TestingT, options *Options) (*TgRunAllResult, error) {
+	return TgRunAllE(t, options, "plan", "-input=false", "-lock=true")
+}
+
+// ParseTgRunAllOutput splits the output of a terragrunt run-all command per module, using the module prefix at the
+// start of each line (e.g., "[modules/vpc] "). Lines without a prefix (e.g., terragrunt's own logs) are only part of
+// the full output.
+func ParseTgRunAllOutput(output string) *TgRunAllResult {
+	result := &TgRunAllResult{
+		Output:  output,
+		Modules: map[string]*TgModuleResult{},
+	}
+
+	lines := map[string][]string{}
+	for _, line := range strings.Split(output, "\n") {
+		matches := tgModulePrefixRegex.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if matches == nil {
+			continue
+		}
+		path := filepath.ToSlash(filepath.Clean(matches[1]))
+		lines[path] = append(lines[path], matches[2])
+	}
+
+	for path, moduleLines := range lines {
+		module := &TgModuleResult{
+			Path:   path,
+			Output: strings.Join(moduleLines, "\n"),
+		}
+		if count, err := GetResourceCountE(nil, module.Output); err == nil {
+			module.ResourceCount = count
+		}
+		result.Modules[path] = module
+	}
+	return result
+}
+
+// TgOutputAll runs terragrunt output in each of the modules run by run-all (honoring TerragruntIncludeDirs and
+// TerragruntExcludeDirs) and returns the outputs of all of them, keyed by the path of the module relative to
+// TerraformDir. This will fail the test if there is an error.
+func TgOutputAll(t testing.TestingT, options *Options) map[string]map[string]interface{} {
+	outputs, err := TgOutputAllE(t, options)
+	require.NoError(t, err)
+	return outputs
+}
+
+// TgOutputAllE runs terragrunt output in each of the modules run by run-all (honoring TerragruntIncludeDirs and
+// TerragruntExcludeDirs) and returns the outputs of all of them, keyed by the path of the module relative to
+// TerraformDir.
+func TgOutputAllE(t testing.TestingT, options *Options) (map[string]map[string]interface{}, error) {
+	if options.TerraformBinary != TerragruntDefaultPath {
+		return nil, TgInvalidBinary(options.TerraformBinary)
+	}
+
+	modules, err := TgModuleDirsE(options)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]map[string]interface{}{}
+	for _, module := range modules {
+		moduleOptions, err := options.Clone()
+		if err != nil {
+			return nil, err
+		}
+		moduleOptions.TerraformDir = filepath.Join(options.TerraformDir, filepath.FromSlash(module))
+
+		moduleOutputs, err := OutputAllE(t, moduleOptions)
+		if err != nil {
+			return nil, err
+		}
+		outputs[module] = moduleOutputs
+	}
+	return outputs, nil
+}
+
+// TgModuleDirsE returns the paths, relative to TerraformDir and sorted, of the terragrunt modules that run-all
+// commands run: the folders with a terragrunt.hcl file in TerraformDir, filtered by TerragruntIncludeDirs and
+// TerragruntExcludeDirs. The terragrunt and terraform caches are skipped.
+func TgModuleDirsE(options *Options) ([]string, error) {
+	modules := []string{}
+	err := filepath.WalkDir(options.TerraformDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && (entry.Name() == ".terragrunt-cache" || entry.Name() == ".terraform") {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || entry.Name() != terragruntConfigFile {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(options.TerraformDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		included, err := tgModuleIncluded(options, relPath)
+		if err != nil || !included {
+			return err
+		}
+		modules = append(modules, relPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// tgModuleIncluded returns whether the module at the given path, relative to TerraformDir, is run by run-all commands
+// given the TerragruntIncludeDirs and TerragruntExcludeDirs in the options.
+func tgModuleIncluded(options *Options, module string) (bool, error) {
+	if len(options.TerragruntIncludeDirs) > 0 {
+		included, err := tgModuleMatchesAny(options.TerragruntIncludeDirs, module)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+	excluded, err := tgModuleMatchesAny(options.TerragruntExcludeDirs, module)
+	return !excluded, err
+}
+
+// tgModuleMatchesAny returns whether the module at the given path, relative to TerraformDir, matches any of the given
+// glob patterns.
+func tgModuleMatchesAny(patterns []string, module string) (bool, error) {
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(filepath.Clean(pattern))
+		matches, err := filepath.Match(pattern, module)
+		if err != nil {
+			return false, err
+		}
+		if matches {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// formatTerragruntDirFilterArgs returns the args to only run the modules in TerragruntIncludeDirs and to skip the
+// modules in TerragruntExcludeDirs in a run-all command.
+func formatTerragruntDirFilterArgs(options *Options) []string {
+	args := []string{}
+	for _, dir := range options.TerragruntIncludeDirs {
+		args = append(args, "--terragrunt-include-dir", dir)
+	}
+	for _, dir := range options.TerragruntExcludeDirs {
+		args = append(args, "--terragrunt-exclude-dir", dir)
+	}
+	return args
+}
+
+// setTerragruntRunAllLogFormatting sets a log format that prefixes each line with the path of its module, unless a
+// custom format is already set in options.EnvVars or the OS environment vars.
+func setTerragruntRunAllLogFormatting(options *Options) {
+	const tgLogCustomFormatKey = "TERRAGRUNT_LOG_CUSTOM_FORMAT"
+
+	if options.EnvVars == nil {
+		options.EnvVars = map[string]string{}
+	}
+	if _, inOpts := options.EnvVars[tgLogCustomFormatKey]; inOpts {
+		return
+	}
+	if _, inEnv := os.LookupEnv(tgLogCustomFormatKey); inEnv {
+		return
+	}
+	options.EnvVars[tgLogCustomFormatKey] = tgRunAllLogCustomFormat
+}
+
+// extraArgsForCommand returns the ExtraArgs configured for the given terraform command.
+func extraArgsForCommand(options *Options, command string) []string {
+	switch command {
+	case "apply":
+		return options.ExtraArgs.Apply
+	case "destroy":
+		return options.ExtraArgs.Destroy
+	case "get":
+		return options.ExtraArgs.Get
+	case "init":
+		return options.ExtraArgs.Init
+	case "plan":
+		return options.ExtraArgs.Plan
+	case "validate":
+		return options.ExtraArgs.Validate
+	case "output":
+		return options.ExtraArgs.Output
+	case "show":
+		return options.ExtraArgs.Show
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTgRunAllOutput(t *testing.T) {
+	t.Parallel()
+
+	output := `Group 1
+- Module ./vpc
+[vpc] Initializing the backend...
+[vpc] Plan: 2 to add, 0 to change, 0 to destroy.
+[apps/web] No changes. Your infrastructure matches the configuration.
+[apps/web] Terraform has compared your real infrastructure against your configuration.
+level=info msg=done`
+
+	result := ParseTgRunAllOutput(output)
+	assert.Equal(t, output, result.Output)
+	require.Len(t, result.Modules, 2)
+
+	vpc := result.Modules["vpc"]
+	require.NotNil(t, vpc)
+	assert.Equal(t, "Initializing the backend...\nPlan: 2 to add, 0 to change, 0 to destroy.", vpc.Output)
+	assert.Equal(t, &ResourceCount{Add: 2}, vpc.ResourceCount)
+
+	web := result.Modules["apps/web"]
+	require.NotNil(t, web)
+	assert.Equal(t, &ResourceCount{}, web.ResourceCount)
+}
+
+func TestTgModuleDirsE(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"vpc", "apps/web", "apps/worker", "apps/web/.terragrunt-cache/abc"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, dir, "terragrunt.hcl"), []byte{}, 0644))
+	}
+
+	testCases := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"all", nil, nil, []string{"apps/web", "apps/worker", "vpc"}},
+		{"include", []string{"apps/*"}, nil, []string{"apps/web", "apps/worker"}},
+		{"exclude", nil, []string{"./apps/worker"}, []string{"apps/web", "vpc"}},
+		{"include and exclude", []string{"apps/*"}, []string{"apps/web"}, []string{"apps/worker"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &Options{TerraformDir: root, TerragruntIncludeDirs: testCase.include, TerragruntExcludeDirs: testCase.exclude}
+			modules, err := TgModuleDirsE(options)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, modules)
+		})
+	}
+}
+
+func TestGetCommonOptionsTerragruntDirFilters(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary:       "terragrunt",
+		TerraformDir:          "/not/temp",
+		TerragruntIncludeDirs: []string{"apps/*"},
+		TerragruntExcludeDirs: []string{"apps/legacy"},
+	}
+
+	_, args := GetCommonOptions(options, "run-all", "plan")
+	assert.Equal(t, []string{"run-all", "plan", "--terragrunt-non-interactive", "--terragrunt-include-dir", "apps/*", "--terragrunt-exclude-dir", "apps/legacy"}, args)
+
+	_, args = GetCommonOptions(options, "plan")
+	assert.Equal(t, []string{"plan", "--terragrunt-non-interactive"}, args)
+}
+
+func TestTgRunAllEInvalidBinary(t *testing.T) {
+	t.Parallel()
+
+	_, err := TgRunAllE(t, &Options{TerraformBinary: "terraform"}, "plan")
+	assert.Equal(t, TgInvalidBinary("terraform"), err)
+}
@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the OpenTelemetry tracer used for the spans of the Terraform commands.
+const TracerName = "github.com/gruntwork-io/terratest/modules/terraform"
+
+// commandSpan is the OpenTelemetry span of a Terraform command.
+type commandSpan struct {
+	span    trace.Span
+	start   time.Time
+	secrets []string
+}
+
+// startCommandSpan starts a span for the Terraform command with the given args, as a child of options.TraceContext.
+func startCommandSpan(options *Options, args []string) *commandSpan {
+	ctx := options.TraceContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	secrets := secretValues(options)
+	command := hookedCommandName(args)
+	_, span := otel.Tracer(TracerName).Start(ctx, strings.TrimSpace(options.TerraformBinary+" "+command),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("terraform.binary", options.TerraformBinary),
+			attribute.String("terraform.dir", options.TerraformDir),
+			attribute.String("terraform.command", command),
+			attribute.StringSlice("terraform.args", maskSecretsInArgs(args, secrets)),
+		),
+	)
+	return &commandSpan{span: span, start: time.Now(), secrets: secrets}
+}
+
+// end records the result of the command on the span and ends it.
+func (cmdSpan *commandSpan) end(exitCode int, attempts int, err error) {
+	retries := attempts - 1
+	if retries < 0 {
+		retries = 0
+	}
+
+	cmdSpan.span.SetAttributes(
+		attribute.Int("terraform.exit_code", exitCode),
+		attribute.Int("terraform.retries", retries),
+		attribute.Int64("terraform.duration_ms", time.Since(cmdSpan.start).Milliseconds()),
+	)
+	if err != nil {
+		message := maskSecrets(err.Error(), cmdSpan.secrets)
+		cmdSpan.span.AddEvent("exception", trace.WithAttributes(attribute.String("exception.message", message)))
+		cmdSpan.span.SetStatus(codes.Error, message)
+	}
+	cmdSpan.span.End()
+}
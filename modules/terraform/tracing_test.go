@@ -0,0 +1,68 @@
+package terraform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Not parallel, as it sets the global tracer provider
+func TestCommandSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previousProvider)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "test")
+	options := &Options{
+		TerraformBinary: "echo",
+		TerraformDir:    ".",
+		Logger:          logger.Discard,
+		Vars:            map[string]interface{}{"password": "hunter2"},
+		SensitiveVars:   []string{"password"},
+		TraceContext:    ctx,
+	}
+	_, err := RunTerraformCommandE(t, options, FormatArgs(options, "apply", "-auto-approve")...)
+	require.NoError(t, err)
+
+	options.TerraformBinary = "false"
+	_, err = RunTerraformCommandE(t, options, "destroy")
+	require.Error(t, err)
+	exitCode, err := GetExitCodeForTerraformCommandE(t, options, "plan")
+	require.NoError(t, err)
+	require.Equal(t, 1, exitCode)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	apply := spans[0]
+	assert.Equal(t, "echo apply", apply.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), apply.Parent().SpanID())
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, attr := range apply.Attributes() {
+		attributes[attr.Key] = attr.Value
+	}
+	assert.Equal(t, ".", attributes["terraform.dir"].AsString())
+	assert.Equal(t, "apply", attributes["terraform.command"].AsString())
+	assert.Equal(t, int64(0), attributes["terraform.exit_code"].AsInt64())
+	assert.Equal(t, int64(0), attributes["terraform.retries"].AsInt64())
+	assert.Contains(t, attributes["terraform.args"].AsStringSlice(), "password=***")
+	assert.Equal(t, codes.Unset, apply.Status().Code)
+
+	destroy := spans[1]
+	assert.Equal(t, "false destroy", destroy.Name())
+	assert.Equal(t, codes.Error, destroy.Status().Code)
+
+	plan := spans[2]
+	assert.Equal(t, "false plan", plan.Name())
+	assert.Equal(t, codes.Error, plan.Status().Code)
+}
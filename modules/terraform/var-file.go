@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
+	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/testing"
 
 	"github.com/hashicorp/hcl/v2"
@@ -247,3 +249,86 @@ func generateTypeFromValuesMap(valMap map[string]cty.Value) cty.Type {
 	}
 	return cty.Object(outType)
 }
+
+// WriteVarsFile renders the Vars in the given options into a temporary *.auto.tfvars.json file and returns its path.
+// This is useful when the vars are too large or complex to pass on the command line with -var. This will fail the
+// test if there is an error writing the file.
+func WriteVarsFile(t testing.TestingT, options *Options) string {
+	path, err := WriteVarsFileE(t, options)
+	require.NoError(t, err)
+	return path
+}
+
+// WriteVarsFileE renders the Vars in the given options into a temporary *.auto.tfvars.json file and returns its path.
+// This is useful when the vars are too large or complex to pass on the command line with -var. Unlike -var, JSON var
+// files support null values at the top level, so nil values are passed through as-is. As the vars may hold secrets,
+// the file is only readable by the current user, and it is removed when the test finishes, if t supports Cleanup (as
+// *testing.T does).
+func WriteVarsFileE(t testing.TestingT, options *Options) (string, error) {
+	contents, err := encodeVarsFile(options.Vars)
+	if err != nil {
+		return "", err
+	}
+
+	// CreateTemp creates the file with mode 0600
+	tmpFile, err := os.CreateTemp("", "terratest-*.auto.tfvars.json")
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	path := tmpFile.Name()
+	if cleaner, ok := t.(interface{ Cleanup(func()) }); ok {
+		cleaner.Cleanup(func() { os.Remove(path) })
+	}
+
+	if _, err := tmpFile.Write(contents); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// encodeVarsFile encodes the given vars as the contents of a JSON var file.
+func encodeVarsFile(vars map[string]interface{}) ([]byte, error) {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	return json.MarshalIndent(vars, "", "  ")
+}
+
+// optionsVarsFile returns the path of the var file that the Vars of the given options are rendered into when
+// VarsAsFile is set. The path is picked once and stored on the options, so all the commands run with the same options
+// share a single file rather than leaving a new one behind on every command.
+func optionsVarsFile(options *Options) string {
+	if options.varsFile == "" {
+		options.varsFile = filepath.Join(os.TempDir(), "terratest-vars-"+random.UniqueId()+".tfvars.json")
+	}
+	return options.varsFile
+}
+
+// writeOptionsVarsFileE writes the Vars of the given options to the var file returned by optionsVarsFile, if FormatArgs
+// has pointed the command at it. The file is rewritten before each command, so it picks up any changes made to Vars in
+// between. As the file may contain secrets, it is only readable by the current user, and it is removed when the test
+// finishes if t supports Cleanup (as *testing.T does).
+func writeOptionsVarsFileE(t testing.TestingT, options *Options) error {
+	if options.varsFile == "" {
+		return nil
+	}
+
+	contents, err := encodeVarsFile(options.Vars)
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(options.varsFile)
+	if err := os.WriteFile(options.varsFile, contents, 0600); err != nil {
+		return err
+	}
+	if os.IsNotExist(statErr) {
+		path := options.varsFile
+		if cleaner, ok := t.(interface{ Cleanup(func()) }); ok {
+			cleaner.Cleanup(func() { os.Remove(path) })
+		}
+	}
+	return nil
+}
@@ -3,9 +3,12 @@ package terraform
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -473,3 +476,49 @@ func WriteFile(t *testing.T, fileName string, bytes []byte) {
 
 	require.NoError(t, err)
 }
+
+func TestWriteVarsFile(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{Vars: map[string]interface{}{
+		"aws_region": "us-east-2",
+		"tags":       map[string]string{"foo": "bar"},
+	}}
+	var path string
+	t.Run("write", func(t *testing.T) {
+		path = WriteVarsFile(t, options)
+
+		require.True(t, strings.HasSuffix(path, ".auto.tfvars.json"))
+		require.Equal(t, "us-east-2", GetVariableAsStringFromVarFile(t, path, "aws_region"))
+		require.Equal(t, map[string]string{"foo": "bar"}, GetVariableAsMapFromVarFile(t, path, "tags"))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+	assert.NoFileExists(t, path)
+}
+
+func TestWriteOptionsVarsFileRemovedOnCleanup(t *testing.T) {
+	t.Parallel()
+
+	var path string
+	t.Run("write", func(t *testing.T) {
+		options := &Options{VarsAsFile: true, Vars: map[string]interface{}{"password": "hunter2"}}
+		path = FormatArgs(options, "plan")[2]
+		require.NoError(t, writeOptionsVarsFileE(t, options))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+	assert.NoFileExists(t, path)
+}
+
+func TestWriteOptionsVarsFileError(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{VarsAsFile: true, Vars: map[string]interface{}{"foo": "bar"}}
+	options.varsFile = filepath.Join(t.TempDir(), "missing", "vars.tfvars.json")
+	assert.Error(t, writeOptionsVarsFileE(t, options))
+}
@@ -0,0 +1,330 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// TerraformVersionsDirEnvVar is the environment variable that can be used to override the folder where downloaded
+	// terraform and tofu binaries are cached.
+	TerraformVersionsDirEnvVar = "TERRATEST_TERRAFORM_VERSIONS_DIR"
+
+	terraformReleasesURL = "https://releases.hashicorp.com/terraform"
+	tofuReleasesAPIURL   = "https://get.opentofu.org/tofu/api.json"
+	tofuReleasesURL      = "https://github.com/opentofu/opentofu/releases/download"
+
+	terragruntTfPathEnvVar = "TERRAGRUNT_TFPATH"
+)
+
+var (
+	// A map that maps "<binary> <constraint>" to the path of the installed binary matching it.
+	installedBinaryCache sync.Map
+	// Serializes installs, so parallel tests don't download the same version at the same time.
+	installMutex sync.Mutex
+
+	versionHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+)
+
+// InstallTerraform downloads the newest terraform (or tofu, if binary is "tofu") release matching the given version
+// constraint (e.g., ">= 1.6, < 1.8"), caches it, and returns the path to the binary. This will fail the test if there
+// is an error.
+func InstallTerraform(t testing.TestingT, binary string, constraint string) string {
+	path, err := InstallTerraformE(t, binary, constraint)
+	require.NoError(t, err)
+	return path
+}
+
+// InstallTerraformE downloads the newest terraform (or tofu, if binary is "tofu") release matching the given version
+// constraint (e.g., ">= 1.6, < 1.8"), caches it, and returns the path to the binary. Binaries are cached in
+// $TMPDIR/terratest-terraform-versions, unless overridden with the TERRATEST_TERRAFORM_VERSIONS_DIR environment
+// variable, so each version is only downloaded once per machine.
+func InstallTerraformE(t testing.TestingT, binary string, constraint string) (string, error) {
+	if binary != TofuDefaultPath {
+		binary = TerraformDefaultPath
+	}
+
+	cacheKey := binary + " " + constraint
+	if path, ok := installedBinaryCache.Load(cacheKey); ok {
+		return path.(string), nil
+	}
+
+	installMutex.Lock()
+	defer installMutex.Unlock()
+
+	if path, ok := installedBinaryCache.Load(cacheKey); ok {
+		return path.(string), nil
+	}
+
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	versions, err := listReleasedVersions(binary)
+	if err != nil {
+		return "", err
+	}
+
+	matching := newestMatchingVersion(versions, constraints)
+	if matching == nil {
+		return "", NoMatchingTerraformVersion{Binary: binary, Constraint: constraint}
+	}
+
+	path, err := installVersion(t, binary, matching.String())
+	if err != nil {
+		return "", err
+	}
+
+	installedBinaryCache.Store(cacheKey, path)
+	return path, nil
+}
+
+// setRequiredVersionBinary installs the binary matching options.RequiredVersion, if set, and points the options at it.
+// When running terragrunt, the installed binary is passed to terragrunt through TERRAGRUNT_TFPATH instead.
+func setRequiredVersionBinary(t testing.TestingT, options *Options) error {
+	if options.RequiredVersion == "" || options.Docker != nil || options.Remote != nil {
+		return nil
+	}
+
+	if options.TerraformBinary == TerragruntDefaultPath {
+		binary := DefaultExecutable
+		if options.EnvVars != nil && options.EnvVars[terragruntTfPathEnvVar] != "" {
+			binary = filepath.Base(options.EnvVars[terragruntTfPathEnvVar])
+		}
+		path, err := InstallTerraformE(t, strings.TrimSuffix(binary, ".exe"), options.RequiredVersion)
+		if err != nil {
+			return err
+		}
+		if options.EnvVars == nil {
+			options.EnvVars = map[string]string{}
+		}
+		options.EnvVars[terragruntTfPathEnvVar] = path
+		return nil
+	}
+
+	binary := options.TerraformBinary
+	if binary == "" {
+		binary = DefaultExecutable
+	}
+	path, err := InstallTerraformE(t, strings.TrimSuffix(filepath.Base(binary), ".exe"), options.RequiredVersion)
+	if err != nil {
+		return err
+	}
+	options.TerraformBinary = path
+	return nil
+}
+
+// newestMatchingVersion returns the newest final release that matches the given constraints, or nil if there is none.
+// Pre-releases are only considered if the constraints explicitly reference one.
+func newestMatchingVersion(versions []*version.Version, constraints version.Constraints) *version.Version {
+	sorted := make([]*version.Version, len(versions))
+	copy(sorted, versions)
+	sort.Sort(sort.Reverse(version.Collection(sorted)))
+
+	for _, v := range sorted {
+		if constraints.Check(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// listReleasedVersions returns all the released versions of the given binary.
+func listReleasedVersions(binary string) ([]*version.Version, error) {
+	var rawVersions []string
+
+	if binary == TofuDefaultPath {
+		var index struct {
+			Versions []struct {
+				ID string `json:"id"`
+			} `json:"versions"`
+		}
+		if err := getJSON(tofuReleasesAPIURL, &index); err != nil {
+			return nil, err
+		}
+		for _, v := range index.Versions {
+			rawVersions = append(rawVersions, v.ID)
+		}
+	} else {
+		var index struct {
+			Versions map[string]interface{} `json:"versions"`
+		}
+		if err := getJSON(terraformReleasesURL+"/index.json", &index); err != nil {
+			return nil, err
+		}
+		for v := range index.Versions {
+			rawVersions = append(rawVersions, v)
+		}
+	}
+
+	versions := []*version.Version{}
+	for _, raw := range rawVersions {
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// releaseURLs returns the URL of the zip archive and of the SHA256SUMS file for the given binary and version on the
+// given platform.
+func releaseURLs(binary string, v string, goos string, goarch string) (string, string) {
+	archiveName := fmt.Sprintf("%s_%s_%s_%s.zip", binary, v, goos, goarch)
+	sumsName := fmt.Sprintf("%s_%s_SHA256SUMS", binary, v)
+
+	baseURL := fmt.Sprintf("%s/%s", terraformReleasesURL, v)
+	if binary == TofuDefaultPath {
+		baseURL = fmt.Sprintf("%s/v%s", tofuReleasesURL, v)
+	}
+	return baseURL + "/" + archiveName, baseURL + "/" + sumsName
+}
+
+// installVersion downloads, verifies, and extracts the given version of the binary into the versions cache folder,
+// unless it's already there, and returns the path to the binary.
+func installVersion(t testing.TestingT, binary string, v string) (string, error) {
+	binaryName := binary
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	installDir := filepath.Join(terraformVersionsDir(), binary, v)
+	binaryPath := filepath.Join(installDir, binaryName)
+	if _, err := os.Stat(binaryPath); err == nil {
+		return binaryPath, nil
+	}
+
+	archiveURL, sumsURL := releaseURLs(binary, v, runtime.GOOS, runtime.GOARCH)
+	logger.Default.Logf(t, "Downloading %s %s from %s", binary, v, archiveURL)
+
+	archive, err := getBytes(archiveURL)
+	if err != nil {
+		return "", err
+	}
+	sums, err := getBytes(sumsURL)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyChecksum(archive, string(sums), filepath.Base(archiveURL)); err != nil {
+		return "", err
+	}
+
+	// Extract into a temp folder first and rename it into place, so a crashed download never leaves a partial binary
+	// in the cache.
+	if err := os.MkdirAll(filepath.Dir(installDir), os.ModePerm); err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(installDir), v+"-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractFileFromZip(archive, binaryName, filepath.Join(tmpDir, binaryName)); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, installDir); err != nil {
+		// Another process may have installed the same version in the meantime.
+		if _, statErr := os.Stat(binaryPath); statErr == nil {
+			return binaryPath, nil
+		}
+		return "", err
+	}
+	return binaryPath, nil
+}
+
+// verifyChecksum checks the SHA256 of the given archive against the entry for fileName in the given SHA256SUMS
+// contents.
+func verifyChecksum(archive []byte, sums string, fileName string) error {
+	scanner := bufio.NewScanner(strings.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[1] != fileName {
+			continue
+		}
+		actual := sha256.Sum256(archive)
+		if hex.EncodeToString(actual[:]) != fields[0] {
+			return ChecksumMismatch{FileName: fileName, Expected: fields[0], Actual: hex.EncodeToString(actual[:])}
+		}
+		return nil
+	}
+	return ChecksumMismatch{FileName: fileName}
+}
+
+// extractFileFromZip extracts the file with the given name from the zip archive to destPath, making it executable.
+func extractFileFromZip(archive []byte, name string, destPath string) error {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		if file.Name != name {
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		dest, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return err
+		}
+		defer dest.Close()
+
+		_, err = io.Copy(dest, src)
+		return err
+	}
+	return fmt.Errorf("file %s not found in archive", name)
+}
+
+func terraformVersionsDir() string {
+	if dir := os.Getenv(TerraformVersionsDirEnvVar); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "terratest-terraform-versions")
+}
+
+func getBytes(url string) ([]byte, error) {
+	resp, err := versionHTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func getJSON(url string, out interface{}) error {
+	body, err := getBytes(url)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
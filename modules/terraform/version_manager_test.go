@@ -0,0 +1,101 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewestMatchingVersion(t *testing.T) {
+	t.Parallel()
+
+	var versions []*version.Version
+	for _, raw := range []string{"1.5.7", "1.6.0", "1.6.6", "1.7.5", "1.8.0-beta1", "1.8.0", "1.9.2"} {
+		versions = append(versions, version.Must(version.NewVersion(raw)))
+	}
+
+	testCases := []struct {
+		constraint string
+		expected   string
+	}{
+		{">= 1.6, < 1.8", "1.7.5"},
+		{"~> 1.6.0", "1.6.6"},
+		{">= 1.0", "1.9.2"},
+		{"= 1.8.0-beta1", "1.8.0-beta1"},
+		{"< 1.8.0", "1.7.5"},
+		{"> 2.0", ""},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.constraint, func(t *testing.T) {
+			t.Parallel()
+
+			constraints, err := version.NewConstraint(testCase.constraint)
+			require.NoError(t, err)
+
+			actual := newestMatchingVersion(versions, constraints)
+			if testCase.expected == "" {
+				assert.Nil(t, actual)
+			} else {
+				require.NotNil(t, actual)
+				assert.Equal(t, testCase.expected, actual.Original())
+			}
+		})
+	}
+}
+
+func TestReleaseURLs(t *testing.T) {
+	t.Parallel()
+
+	archiveURL, sumsURL := releaseURLs("terraform", "1.7.5", "linux", "amd64")
+	assert.Equal(t, "https://releases.hashicorp.com/terraform/1.7.5/terraform_1.7.5_linux_amd64.zip", archiveURL)
+	assert.Equal(t, "https://releases.hashicorp.com/terraform/1.7.5/terraform_1.7.5_SHA256SUMS", sumsURL)
+
+	archiveURL, sumsURL = releaseURLs("tofu", "1.6.2", "darwin", "arm64")
+	assert.Equal(t, "https://github.com/opentofu/opentofu/releases/download/v1.6.2/tofu_1.6.2_darwin_arm64.zip", archiveURL)
+	assert.Equal(t, "https://github.com/opentofu/opentofu/releases/download/v1.6.2/tofu_1.6.2_SHA256SUMS", sumsURL)
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	t.Parallel()
+
+	archive := []byte("not really a zip")
+	sum := sha256.Sum256(archive)
+	sums := "0000  terraform_1.7.5_darwin_amd64.zip\n" + hex.EncodeToString(sum[:]) + "  terraform_1.7.5_linux_amd64.zip\n"
+
+	assert.NoError(t, verifyChecksum(archive, sums, "terraform_1.7.5_linux_amd64.zip"))
+	assert.Error(t, verifyChecksum(archive, sums, "terraform_1.7.5_darwin_amd64.zip"))
+	assert.Error(t, verifyChecksum(archive, sums, "terraform_1.7.5_windows_amd64.zip"))
+}
+
+func TestExtractFileFromZip(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	for name, contents := range map[string]string{"LICENSE.txt": "license", "terraform": "binary"} {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	destPath := filepath.Join(t.TempDir(), "terraform")
+	require.NoError(t, extractFileFromZip(buf.Bytes(), "terraform", destPath))
+
+	contents, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(contents))
+
+	assert.Error(t, extractFileFromZip(buf.Bytes(), "tofu", destPath))
+}
@@ -0,0 +1,231 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TerraformCommandsWithCompactWarningsSupport is a list of all the Terraform commands that support the
+// -compact-warnings flag.
+var TerraformCommandsWithCompactWarningsSupport = []string{
+	"plan",
+	"apply",
+	"destroy",
+	"refresh",
+}
+
+var (
+	// ansiEscapeRegex matches ANSI escape codes for text formatting (e.g., colors, styles).
+	ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// diagnosticSourceRegex matches the source location of a diagnostic, e.g., "on main.tf line 3, in resource ..."
+	// or, in compact warnings, "on main.tf line 3 (and 2 more)".
+	diagnosticSourceRegex = regexp.MustCompile(`^on (.+?) line (\d+)(?:, in .*| \(and (\d+) more\))?:?$`)
+	// similarWarningsRegex matches the number of similar warnings folded into a warning.
+	similarWarningsRegex = regexp.MustCompile(`^\(and (\d+|one) more similar warnings? elsewhere\)$`)
+	// warningOccurrencesRegex matches the number of occurrences of a compact warning without a source location.
+	warningOccurrencesRegex = regexp.MustCompile(`^\((\d+) occurrences? of this warning\)$`)
+	// sourceSnippetRegex matches a line of source code quoted in a diagnostic, e.g., "3:   acl = "private"".
+	sourceSnippetRegex = regexp.MustCompile(`^\d+:`)
+)
+
+// Warning is a warning reported by Terraform, e.g., "Argument is deprecated".
+type Warning struct {
+	Summary string // The one line summary of the warning
+	Detail  string // The detailed description of the warning, if any. Not available with -compact-warnings.
+	Address string // The address of the resource the warning is about, if any. Not available with -compact-warnings.
+	File    string // The file the warning is about, if any
+	Line    int    // The line the warning is about, if any
+	Count   int    // The number of occurrences of the warning, as Terraform folds similar warnings together
+}
+
+// WarningMatcher matches warnings on their fields, to treat them as errors with Options.WarningMatchersAsErrors. The
+// fields are regular expressions, and empty fields match any value.
+type WarningMatcher struct {
+	Summary string
+	Detail  string
+	Address string
+	File    string
+
+	// The message to show to the user if the warning is matched
+	Message string
+}
+
+// GetWarnings parses the warnings in the given output of a Terraform command, in either the full or the compact
+// (-compact-warnings) format. This will fail the test if there is an error.
+func GetWarnings(t testing.TestingT, output string) []Warning {
+	warnings, err := GetWarningsE(output)
+	require.NoError(t, err)
+	return warnings
+}
+
+// GetWarningsE parses the warnings in the given output of a Terraform command, in either the full or the compact
+// (-compact-warnings) format.
+func GetWarningsE(output string) ([]Warning, error) {
+	warnings := []Warning{}
+	var current *Warning
+	inCompactWarnings := false
+	inSnippet := false
+
+	finish := func() {
+		if current != nil {
+			current.Detail = strings.TrimSpace(current.Detail)
+			warnings = append(warnings, *current)
+			current = nil
+		}
+	}
+
+	for _, rawLine := range strings.Split(ansiEscapeRegex.ReplaceAllString(output, ""), "\n") {
+		line := strings.TrimRight(rawLine, " \r")
+		boxed := false
+		for _, prefix := range []string{"│", "|"} {
+			if strings.HasPrefix(strings.TrimLeft(line, " "), prefix) {
+				line = strings.TrimPrefix(strings.TrimLeft(line, " "), prefix)
+				boxed = true
+				break
+			}
+		}
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "╷") || strings.HasPrefix(trimmed, "╵"):
+			finish()
+			continue
+		case trimmed == "Warnings:":
+			finish()
+			inCompactWarnings = true
+			continue
+		case strings.HasPrefix(trimmed, "To see the full warning notes"):
+			finish()
+			inCompactWarnings = false
+			continue
+		case strings.HasPrefix(trimmed, "Warning: "):
+			finish()
+			current = &Warning{Summary: strings.TrimPrefix(trimmed, "Warning: "), Count: 1}
+			inCompactWarnings = false
+			inSnippet = false
+			continue
+		case strings.HasPrefix(trimmed, "Error: "):
+			finish()
+			inCompactWarnings = false
+			continue
+		case inCompactWarnings && strings.HasPrefix(line, "- "):
+			finish()
+			current = &Warning{Summary: strings.TrimPrefix(line, "- "), Count: 1}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if matches := similarWarningsRegex.FindStringSubmatch(trimmed); matches != nil {
+			more := 1
+			if matches[1] != "one" {
+				more, _ = strconv.Atoi(matches[1])
+			}
+			current.Count = 1 + more
+			continue
+		}
+		if matches := warningOccurrencesRegex.FindStringSubmatch(trimmed); matches != nil {
+			current.Count, _ = strconv.Atoi(matches[1])
+			continue
+		}
+		if strings.HasPrefix(trimmed, "with ") && current.Address == "" && current.Detail == "" {
+			current.Address = strings.TrimSuffix(strings.TrimPrefix(trimmed, "with "), ",")
+			continue
+		}
+		if matches := diagnosticSourceRegex.FindStringSubmatch(trimmed); matches != nil && current.File == "" && current.Detail == "" {
+			current.File = matches[1]
+			current.Line, _ = strconv.Atoi(matches[2])
+			if matches[3] != "" {
+				more, _ := strconv.Atoi(matches[3])
+				current.Count = 1 + more
+			}
+			inSnippet = true
+			continue
+		}
+		if inSnippet && (trimmed == "" || sourceSnippetRegex.MatchString(trimmed) || strings.HasPrefix(trimmed, "├") || strings.HasPrefix(trimmed, "│")) {
+			if trimmed == "" && current.Detail == "" {
+				inSnippet = false
+			}
+			continue
+		}
+		inSnippet = false
+
+		if inCompactWarnings {
+			continue
+		}
+		if trimmed == "" {
+			switch {
+			case current.Detail == "":
+			case !boxed:
+				// Without the box drawing characters (e.g., with -no-color), a blank line after the detail ends the
+				// warning
+				finish()
+			default:
+				current.Detail += "\n"
+			}
+			continue
+		}
+		current.Detail += trimmed + "\n"
+	}
+	finish()
+
+	return warnings, nil
+}
+
+// matches returns whether the given warning matches the matcher.
+func (matcher WarningMatcher) matches(warning Warning) (bool, error) {
+	fields := []struct {
+		pattern string
+		value   string
+	}{
+		{matcher.Summary, warning.Summary},
+		{matcher.Detail, warning.Detail},
+		{matcher.Address, warning.Address},
+		{matcher.File, warning.File},
+	}
+	for _, field := range fields {
+		if field.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(field.pattern)
+		if err != nil {
+			return false, fmt.Errorf("cannot compile regex for warning detection: %w", err)
+		}
+		if !re.MatchString(field.value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// hasMatchingWarning returns an error if the given output contains a warning matching one of the
+// WarningMatchersAsErrors of the options.
+func hasMatchingWarning(opts *Options, out string) error {
+	if len(opts.WarningMatchersAsErrors) == 0 {
+		return nil
+	}
+
+	warnings, err := GetWarningsE(out)
+	if err != nil {
+		return err
+	}
+	for _, matcher := range opts.WarningMatchersAsErrors {
+		for _, warning := range warnings {
+			matches, err := matcher.matches(warning)
+			if err != nil {
+				return err
+			}
+			if matches {
+				return fmt.Errorf("warning(s) were found: %s:\n%s", matcher.Message, warning.Summary)
+			}
+		}
+	}
+	return nil
+}
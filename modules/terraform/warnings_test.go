@@ -0,0 +1,117 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBoxedWarningsOutput = `aws_s3_bucket.this: Creating...
+
+╷
+│ Warning: Argument is deprecated
+│ 
+│   with aws_s3_bucket.this,
+│   on main.tf line 3, in resource "aws_s3_bucket" "this":
+│    3:   acl = "private"
+│ 
+│ Use the aws_s3_bucket_acl resource instead
+│ 
+│ (and 2 more similar warnings elsewhere)
+╵
+╷
+│ Warning: Value for undeclared variable
+│ 
+│ The root module does not declare a variable named "foo".
+╵
+
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.
+`
+
+const testNoColorWarningsOutput = `
+Warning: Argument is deprecated
+
+  with aws_s3_bucket.this,
+  on main.tf line 3, in resource "aws_s3_bucket" "this":
+   3:   acl = "private"
+
+Use the aws_s3_bucket_acl resource instead
+
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.
+`
+
+const testCompactWarningsOutput = `
+Warnings:
+
+- Argument is deprecated
+  on main.tf line 3 (and 2 more)
+- Value for undeclared variable
+  (4 occurrences of this warning)
+
+To see the full warning notes, run Terraform without -compact-warnings.
+
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.
+`
+
+func TestGetWarningsE(t *testing.T) {
+	t.Parallel()
+
+	deprecated := Warning{
+		Summary: "Argument is deprecated",
+		Detail:  "Use the aws_s3_bucket_acl resource instead",
+		Address: "aws_s3_bucket.this",
+		File:    "main.tf",
+		Line:    3,
+		Count:   3,
+	}
+
+	testCases := []struct {
+		name     string
+		output   string
+		expected []Warning
+	}{
+		{"boxed", testBoxedWarningsOutput, []Warning{
+			deprecated,
+			{Summary: "Value for undeclared variable", Detail: `The root module does not declare a variable named "foo".`, Count: 1},
+		}},
+		{"no color", testNoColorWarningsOutput, []Warning{
+			{Summary: deprecated.Summary, Detail: deprecated.Detail, Address: deprecated.Address, File: "main.tf", Line: 3, Count: 1},
+		}},
+		{"compact", testCompactWarningsOutput, []Warning{
+			{Summary: "Argument is deprecated", File: "main.tf", Line: 3, Count: 3},
+			{Summary: "Value for undeclared variable", Count: 4},
+		}},
+		{"none", "Apply complete! Resources: 0 added, 0 changed, 0 destroyed.", []Warning{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			warnings, err := GetWarningsE(testCase.output)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, warnings)
+		})
+	}
+}
+
+func TestHasMatchingWarning(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{WarningMatchersAsErrors: []WarningMatcher{{Summary: "deprecated", Address: `^aws_s3_bucket\.`, Message: "no deprecated arguments"}}}
+	err := hasWarning(options, testBoxedWarningsOutput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no deprecated arguments")
+
+	options = &Options{WarningMatchersAsErrors: []WarningMatcher{{Summary: "deprecated", Address: `^aws_instance\.`}}}
+	assert.NoError(t, hasWarning(options, testBoxedWarningsOutput))
+}
+
+func TestFormatArgsCompactWarnings(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{CompactWarnings: true}
+	assert.Contains(t, FormatArgs(options, "apply"), "-compact-warnings")
+	assert.NotContains(t, FormatArgs(options, "output"), "-compact-warnings")
+}
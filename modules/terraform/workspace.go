@@ -1,8 +1,11 @@
 package terraform
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -94,3 +97,90 @@ func WorkspaceDelete(t testing.TestingT, options *Options, name string) string {
 	require.NoError(t, err)
 	return out
 }
+
+// WorkspaceList runs terraform workspace list with the given options and returns the names of the workspaces.
+func WorkspaceList(t testing.TestingT, options *Options) []string {
+	workspaces, err := WorkspaceListE(t, options)
+	require.NoError(t, err)
+	return workspaces
+}
+
+// WorkspaceListE runs terraform workspace list with the given options and returns the names of the workspaces.
+func WorkspaceListE(t testing.TestingT, options *Options) ([]string, error) {
+	out, err := RunTerraformCommandAndGetStdoutE(t, options, "workspace", "list")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorkspaceList(out), nil
+}
+
+// parseWorkspaceList parses the output of terraform workspace list, where the current workspace is marked with a *.
+func parseWorkspaceList(out string) []string {
+	workspaces := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if name != "" {
+			workspaces = append(workspaces, name)
+		}
+	}
+	return workspaces
+}
+
+// WorkspaceShow runs terraform workspace show with the given options and returns the name of the current workspace.
+func WorkspaceShow(t testing.TestingT, options *Options) string {
+	name, err := WorkspaceShowE(t, options)
+	require.NoError(t, err)
+	return name
+}
+
+// WorkspaceShowE runs terraform workspace show with the given options and returns the name of the current workspace.
+func WorkspaceShowE(t testing.TestingT, options *Options) (string, error) {
+	out, err := RunTerraformCommandAndGetStdoutE(t, options, "workspace", "show")
+	return strings.TrimSpace(out), err
+}
+
+// WithEphemeralWorkspace creates a new workspace named after the given name with a unique suffix (e.g., "test-a1b2c3"),
+// runs fn in it, and then, even if fn fails the test, destroys the resources in the workspace, switches back to the
+// workspace that was current before, and deletes the workspace along with its state. This prevents tests from leaking
+// workspaces in shared backends. fn is called with the name of the workspace. Errors during the cleanup fail the test
+// but don't stop the remaining cleanup steps.
+func WithEphemeralWorkspace(t testing.TestingT, options *Options, name string, fn func(workspace string)) {
+	previousWorkspace, err := WorkspaceShowE(t, options)
+	require.NoError(t, err)
+
+	workspace := fmt.Sprintf("%s-%s", name, strings.ToLower(random.UniqueId()))
+	_, err = RunTerraformCommandE(t, options, prepend(options.ExtraArgs.WorkspaceNew, "workspace", "new", workspace)...)
+	require.NoError(t, err)
+
+	defer func() {
+		if err := deleteEphemeralWorkspaceE(t, options, workspace, previousWorkspace); err != nil {
+			t.Errorf("Failed to clean up workspace %s: %v", workspace, err)
+		}
+	}()
+
+	fn(workspace)
+}
+
+// deleteEphemeralWorkspaceE destroys the resources in the given workspace, switches to the given previous workspace,
+// and force deletes the workspace. All the steps are attempted, and the errors are returned together.
+func deleteEphemeralWorkspaceE(t testing.TestingT, options *Options, workspace string, previousWorkspace string) error {
+	var errs []error
+
+	if _, err := RunTerraformCommandE(t, options, prepend(options.ExtraArgs.WorkspaceSelect, "workspace", "select", workspace)...); err != nil {
+		errs = append(errs, err)
+	} else if _, err := DestroyE(t, options); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := RunTerraformCommandE(t, options, prepend(options.ExtraArgs.WorkspaceSelect, "workspace", "select", previousWorkspace)...); err != nil {
+		errs = append(errs, err)
+	}
+
+	// -force deletes the workspace even if its state still tracks resources, e.g., because destroy failed, as the test
+	// is over and the workspace would otherwise be leaked.
+	if _, err := RunTerraformCommandE(t, options, prepend(options.ExtraArgs.WorkspaceDelete, "workspace", "delete", "-force", workspace)...); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
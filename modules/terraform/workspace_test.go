@@ -207,3 +207,33 @@ func TestWorkspaceDeleteE(t *testing.T) {
 
 	}
 }
+
+func TestParseWorkspaceList(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"default", "foo", "bar"}, parseWorkspaceList("  default\n* foo\n  bar\n\n"))
+	assert.Equal(t, []string{"default"}, parseWorkspaceList("* default\n"))
+}
+
+func TestWithEphemeralWorkspace(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-workspace", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	Init(t, options)
+
+	var ephemeralWorkspace string
+	WithEphemeralWorkspace(t, options, "terratest", func(workspace string) {
+		ephemeralWorkspace = workspace
+		assert.Regexp(t, "^terratest-[a-z0-9]{6}$", workspace)
+		assert.Equal(t, workspace, WorkspaceShow(t, options))
+		Apply(t, options)
+	})
+
+	assert.Equal(t, "default", WorkspaceShow(t, options))
+	assert.NotContains(t, WorkspaceList(t, options), ephemeralWorkspace)
+}
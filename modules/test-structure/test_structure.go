@@ -1,6 +1,7 @@
 package test_structure
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,18 +17,40 @@ import (
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// TracerName is the name of the OpenTelemetry tracer used for the spans of the test stages.
+const TracerName = "github.com/gruntwork-io/terratest/modules/test-structure"
+
 // SKIP_STAGE_ENV_VAR_PREFIX is the prefix used for skipping stage environment variables.
 const SKIP_STAGE_ENV_VAR_PREFIX = "SKIP_"
 
 // RunTestStage executes the given test stage (e.g., setup, teardown, validation) if an environment variable of the name
 // `SKIP_<stageName>` (e.g., SKIP_teardown) is not set.
 func RunTestStage(t testing.TestingT, stageName string, stage func()) {
+	RunTestStageWithContext(t, context.Background(), stageName, func(context.Context) { stage() })
+}
+
+// RunTestStageWithContext executes the given test stage (e.g., setup, teardown, validation) if an environment variable
+// of the name `SKIP_<stageName>` (e.g., SKIP_teardown) is not set. The stage is recorded as an OpenTelemetry span, a
+// child of the given context, if the test has configured a global tracer provider with otel.SetTracerProvider. The
+// stage is called with the context of that span, which can be set as terraform.Options.TraceContext so that the spans
+// of the Terraform commands run in the stage are nested under it.
+func RunTestStageWithContext(t testing.TestingT, ctx context.Context, stageName string, stage func(ctx context.Context)) {
 	envVarName := fmt.Sprintf("%s%s", SKIP_STAGE_ENV_VAR_PREFIX, stageName)
 	if os.Getenv(envVarName) == "" {
 		logger.Default.Logf(t, "The '%s' environment variable is not set, so executing stage '%s'.", envVarName, stageName)
-		stage()
+
+		ctx, span := otel.Tracer(TracerName).Start(ctx, "stage "+stageName, trace.WithAttributes(
+			attribute.String("terratest.test", t.Name()),
+			attribute.String("terratest.stage", stageName),
+		))
+		defer span.End()
+
+		stage(ctx)
 	} else {
 		logger.Default.Logf(t, "The '%s' environment variable is set, so skipping stage '%s'.", envVarName, stageName)
 	}
@@ -134,6 +157,25 @@ func CopyTerraformFolderToDest(t testing.TestingT, rootFolder string, terraformM
 	return tmpTestFolder
 }
 
+// CopyTerraformFolderToTempWithLocalModules works like CopyTerraformFolderToTemp, and then rewrites the module calls
+// in the copied terraform module folder that use one of the given sources (e.g., "acme/vpc/aws") to use the matching
+// local folder instead, so the test exercises the modules in the working tree rather than the last published version.
+// See terraform.OverrideModuleSourcesE for the format of the overrides. Relative local folders are relative to the
+// current working directory, i.e., the test folder.
+//
+// Note that if any of the SKIP_<stage> environment variables is set, the original terraform module folder is returned
+// as with CopyTerraformFolderToTemp, and the module sources are NOT rewritten, as that would modify your source files.
+func CopyTerraformFolderToTempWithLocalModules(t testing.TestingT, rootFolder string, terraformModuleFolder string, overrides map[string]string) string {
+	tmpTestFolder := CopyTerraformFolderToTemp(t, rootFolder, terraformModuleFolder)
+	if SkipStageEnvVarSet() {
+		logger.Default.Logf(t, "A SKIP_XXX environment variable is set. Not overriding module sources in the original examples folder.")
+		return tmpTestFolder
+	}
+
+	terraform.OverrideModuleSources(t, tmpTestFolder, overrides)
+	return tmpTestFolder
+}
+
 func cleanName(originalName string) string {
 	parts := strings.Split(originalName, "/")
 	return parts[len(parts)-1]
@@ -54,7 +54,7 @@ func TestTerraformAzureActionGroupExample(t *testing.T) {
 	actionGroup := azure.GetActionGroupResource(t, expectedAppName, expectedResourceGroupName, "")
 
 	assert.NotNil(actionGroup)
-	assert.Equal(1, len(*actionGroup.EmailReceivers))
-	assert.Equal(0, len(*actionGroup.SmsReceivers))
-	assert.Equal(1, len(*actionGroup.WebhookReceivers))
+	assert.Equal(1, len(actionGroup.Properties.EmailReceivers))
+	assert.Equal(0, len(actionGroup.Properties.SmsReceivers))
+	assert.Equal(1, len(actionGroup.Properties.WebhookReceivers))
 }
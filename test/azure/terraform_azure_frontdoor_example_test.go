@@ -56,6 +56,6 @@ func TestTerraformAzureFrontDoorExample(t *testing.T) {
 	assert.True(t, endpointExists)
 
 	actualFrontDoorEndpoint := azure.GetFrontDoorFrontendEndpoint(t, frontendEndpointName, frontDoorName, resourceGroupName, subscriptionID)
-	endpointProperties := *actualFrontDoorEndpoint.FrontendEndpointProperties
+	endpointProperties := *actualFrontDoorEndpoint.Properties
 	assert.Equal(t, frontDoorUrl, *endpointProperties.HostName)
 }
@@ -48,9 +48,9 @@ func TestTerraformAzureFunctionAppExample(t *testing.T) {
 	site := azure.GetAppService(t, appName, resourceGroupName, "")
 
 	assert.Equal(t, appId, *site.ID)
-	assert.Equal(t, appDefaultHostName, *site.DefaultHostName)
+	assert.Equal(t, appDefaultHostName, *site.Properties.DefaultHostName)
 	assert.Equal(t, appKind, *site.Kind)
 
-	assert.NotEmpty(t, *site.OutboundIPAddresses)
-	assert.Equal(t, "Running", *site.State)
+	assert.NotEmpty(t, *site.Properties.OutboundIPAddresses)
+	assert.Equal(t, "Running", *site.Properties.State)
 }
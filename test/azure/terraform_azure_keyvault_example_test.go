@@ -55,4 +55,8 @@ func TestTerraformAzureKeyVaultExample(t *testing.T) {
 
 	certificateExists := azure.KeyVaultCertificateExists(t, keyVaultName, expectedCertificateName)
 	assert.True(t, certificateExists, "kv-cert does not exist")
+
+	// website::tag::6:: Verify the key type and the operations the key permits
+	assert.Equal(t, "RSA", azure.GetKeyVaultKeyType(t, keyVaultName, expectedKeyName))
+	assert.Subset(t, azure.GetKeyVaultKeyOperations(t, keyVaultName, expectedKeyName), []string{"decrypt", "encrypt", "sign"})
 }
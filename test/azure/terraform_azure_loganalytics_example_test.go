@@ -52,10 +52,10 @@ func TestTerraformAzureLogAnalyticsExample(t *testing.T) {
 
 	actualWorkspace := azure.GetLogAnalyticsWorkspace(t, workspaceName, resourceGroupName, subscriptionID)
 
-	actualSku := string(actualWorkspace.Sku.Name)
+	actualSku := string(*actualWorkspace.Properties.SKU.Name)
 	assert.Equal(t, strings.ToLower(sku), strings.ToLower(actualSku), "log analytics sku mismatch")
 
-	actualRetentionPeriod := *actualWorkspace.RetentionInDays
+	actualRetentionPeriod := *actualWorkspace.Properties.RetentionInDays
 	expectedPeriod, _ := strconv.ParseInt(retentionPeriodString, 10, 32)
 	assert.Equal(t, int32(expectedPeriod), actualRetentionPeriod, "log analytics retention period mismatch")
 }
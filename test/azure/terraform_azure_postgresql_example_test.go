@@ -49,6 +49,6 @@ func TestPostgreSQLDatabase(t *testing.T) {
 	// Verify
 	assert.NotNil(t, actualServer)
 	assert.Equal(t, expectedServername, actualServername)
-	assert.Equal(t, expectedSkuName, *actualServer.Sku.Name)
+	assert.Equal(t, expectedSkuName, *actualServer.SKU.Name)
 
 }
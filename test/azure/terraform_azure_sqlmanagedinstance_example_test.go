@@ -62,8 +62,8 @@ func TestTerraformAzureSQLManagedInstanceExample(t *testing.T) {
 
 	assert.Equal(t, expectedManagedInstanceName, *actualSQLManagedInstance.Name)
 	assert.Equal(t, expectedLocation, *actualSQLManagedInstance.Location)
-	assert.Equal(t, expectedSKUName, *actualSQLManagedInstance.Sku.Name)
-	assert.Equal(t, expectedSQLMIState, *actualSQLManagedInstance.ManagedInstanceProperties.State)
+	assert.Equal(t, expectedSKUName, *actualSQLManagedInstance.SKU.Name)
+	assert.Equal(t, expectedSQLMIState, *actualSQLManagedInstance.Properties.State)
 
 	assert.Equal(t, expectedDatabaseName, *actualSQLManagedInstanceDatabase.Name)
 